@@ -0,0 +1,486 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"road-detector-go/internal/buildinfo"
+	"road-detector-go/internal/cache"
+	"road-detector-go/internal/chaos"
+	appconfig "road-detector-go/internal/config"
+	"road-detector-go/internal/coverageband"
+	"road-detector-go/internal/database"
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/diagnostics"
+	"road-detector-go/internal/errreport"
+	"road-detector-go/internal/geocode"
+	"road-detector-go/internal/hls"
+	"road-detector-go/internal/imageseq"
+	"road-detector-go/internal/logging"
+	"road-detector-go/internal/mapmatch"
+	"road-detector-go/internal/notify"
+	"road-detector-go/internal/onnxanalyzer"
+	"road-detector-go/internal/quality"
+	"road-detector-go/internal/report"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/reqlog"
+	"road-detector-go/internal/service"
+	"road-detector-go/internal/transcode"
+	"road-detector-go/internal/videochunk"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// app общая для сервера и команд CLI часть запуска: конфигурация, логи,
+// отправка ошибок и подключение к базе данных
+type app struct {
+	config      *appconfig.Config
+	logger      *logrus.Logger
+	reporter    errreport.Reporter
+	slowQueries *database.SlowQueryLogger
+	staticDir   string
+
+	chaosEnabled   bool
+	db             *database.Handle
+	postgisEnabled bool
+
+	// closers закрываются при завершении в обратном порядке
+	closers []io.Closer
+}
+
+// newApp загружает конфигурацию и настраивает логи. Команды CLI пишут логи
+// в stderr, чтобы stdout оставался для результата команды.
+func newApp(configPath string, cli bool) *app {
+	logger := logrus.New()
+	config, err := appconfig.Load(configPath)
+	if err != nil {
+		logger.Fatalf("Ошибка конфигурации: %v", err)
+	}
+	logFile, err := logging.Configure(logger, config.Logging)
+	if err != nil {
+		logger.Fatalf("Ошибка настройки логов: %v", err)
+	}
+	if cli && logFile == nil {
+		logger.SetOutput(os.Stderr)
+	}
+	a := &app{config: config, logger: logger}
+	if logFile != nil {
+		a.closers = append(a.closers, logFile)
+	}
+	// Записи, сделанные при обработке запроса, получают его ID
+	logger.AddHook(reqlog.NewHook())
+
+	config.ErrorReporting.Release = buildinfo.Current().Version
+	config.ErrorReporting.RequestID = reqlog.CurrentRequestID
+	a.reporter, err = errreport.New(config.ErrorReporting, logger)
+	if err != nil {
+		logger.Fatalf("Ошибка настройки отправки ошибок: %v", err)
+	}
+	if config.ErrorReporting.DSN != "" {
+		logger.Infof("Ошибки сервера отправляются в Sentry, окружение: %s", config.ErrorReporting.Environment)
+	}
+
+	a.chaosEnabled = config.Chaos.Enabled
+	if a.chaosEnabled && config.Environment == "production" {
+		logger.Error("Режим хаоса запрещен в production и не будет включен")
+		a.chaosEnabled = false
+	}
+
+	a.staticDir = filepath.Join(".", "static")
+	if err := os.MkdirAll(a.staticDir, 0755); err != nil {
+		logger.Fatalf("Ошибка создания папки для статических файлов: %v", err)
+	}
+
+	a.slowQueries = database.NewSlowQueryLogger(logger, config.Database.SlowQueryThreshold)
+	config.Database.Connection.Logger = a.slowQueries
+	diagnostics.PublishCounter("slow_db_queries", a.slowQueries.Count)
+	return a
+}
+
+// connectDatabase подключается к базе данных и выполняет миграции. При
+// allowDegraded и DB_START_DEGRADED сервер запускается без базы данных и
+// подключается к ней в фоне; команды CLI без базы данных не выполняются.
+func (a *app) connectDatabase(allowDegraded bool) {
+	config, logger := a.config, a.logger
+
+	logger.Info("Подключение к базе данных...")
+	db, err := database.Open(config.Database.Connection)
+	if err != nil {
+		logger.Fatalf("Ошибка подключения к базе данных: %v", err)
+	}
+	a.db = db
+	a.closers = append(a.closers, closerFunc(func() error {
+		if err := db.Close(); err != nil {
+			logger.Errorf("Ошибка закрытия соединения с базой данных: %v", err)
+		}
+		return nil
+	}))
+	diagnostics.PublishFunc("db_pool", func() interface{} { return db.PoolStats() })
+	if db.HasReplica() {
+		logger.Infof("Списки, поиск по области и аналитика читаются с реплики %s", config.Database.Connection.ReplicaHost)
+	}
+	dbErr := db.WaitForConnection(config.Database.Retry)
+	switch {
+	case dbErr == nil:
+		a.postgisEnabled, err = prepareDatabase(db, config, a.chaosEnabled, logger)
+		if err != nil {
+			logger.Fatalf("Ошибка подготовки базы данных: %v", err)
+		}
+		db.MarkReady()
+	case allowDegraded && config.Database.StartDegraded:
+		// Без базы данных нельзя узнать, доступен ли PostGIS, поэтому
+		// пространственные запросы используются только при POSTGIS_MODE=on
+		a.postgisEnabled = config.PostGISMode == database.PostGISOn
+		logger.Errorf("База данных недоступна, сервис запущен без нее: запросы к API получают 503, подключение продолжается в фоне: %v", dbErr)
+		go waitForDatabase(db, config, a.chaosEnabled, a.postgisEnabled, logger)
+	default:
+		logger.Fatalf("Ошибка подключения к базе данных: %v", dbErr)
+	}
+}
+
+// Close закрывает базу данных, клиентов анализатора и файл логов
+func (a *app) Close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i].Close()
+	}
+	a.closers = nil
+}
+
+// flushErrors отправляет накопленные ошибки до остановки процесса
+func (a *app) flushErrors() {
+	if !a.reporter.Flush(a.config.ErrorReporting.Timeout) {
+		a.logger.Warn("Не все ошибки отправлены в Sentry до остановки сервиса")
+	}
+}
+
+// closerFunc функция закрытия как io.Closer
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// services сервисы приложения, общие для сервера и команд CLI
+type services struct {
+	userRepo      repository.UserRepository
+	analyticsRepo repository.AnalyticsRepository
+
+	routeService        *service.RouteService
+	routeFeed           *service.RouteFeed
+	roadService         *service.RoadService
+	analyzerService     *service.AnalyzerService
+	analyticsService    *service.AnalyticsService
+	boundaryService     *service.BoundaryService
+	tagService          *service.TagService
+	apiKeyService       *service.APIKeyService
+	orgService          *service.OrganizationService
+	usageService        *service.UsageService
+	auditService        *service.AuditService
+	webhookService      *service.WebhookService
+	outboxService       *service.OutboxService
+	notificationService *service.NotificationService
+	alertService        *service.AlertService
+	reportService       *service.ReportService
+	archiveService      *service.ArchiveService
+	shareService        *service.ShareService
+	shadowService       *service.ShadowService
+	healthService       *service.HealthService
+	maintenanceService  *service.MaintenanceService
+	selfTestService     *service.SelfTestService
+	statsService        *service.StatsService
+	debugStore          *debugcapture.Store
+	unsavedResults      *service.UnsavedResultService
+	bands               *coverageband.Classifier
+	// transcodeService перекодирование видео для браузеров в ролях all и
+	// worker, nil — выключено
+	transcodeService *service.TranscodeService
+	// analysisQueue очередь анализов в ролях api и worker, nil — в роли all
+	analysisQueue *service.AnalysisQueue
+}
+
+// newServices создает репозитории и сервисы и настраивает анализатор по
+// конфигурации. Вызывается после connectDatabase.
+func (a *app) newServices() *services {
+	config, logger, db, staticDir := a.config, a.logger, a.db, a.staticDir
+
+	var routeRepo repository.RouteRepository
+	var boundaryRepo repository.BoundaryRepository
+	if a.postgisEnabled {
+		logger.Info("Пространственные запросы выполняются через PostGIS")
+		routeRepo = repository.NewPostGISRouteRepository(db.Gorm(), db.Reader())
+		boundaryRepo = repository.NewPostGISBoundaryRepository(db.Gorm(), db.Reader())
+	} else {
+		routeRepo = repository.NewRouteRepository(db.Gorm(), db.Reader())
+		boundaryRepo = repository.NewBoundaryRepository(db.Gorm(), db.Reader())
+	}
+	// Аналитика только читает данные, поэтому целиком выполняется на реплике
+	analyticsRepo := repository.NewAnalyticsRepository(db.Reader())
+	roadRepo := repository.NewRoadRepository(db.Gorm())
+	tagRepo := repository.NewTagRepository(db.Gorm())
+	if config.Cache.Enabled() {
+		// Один кэш на маршруты, метки и аналитику: изменение маршрута или меток
+		// сбрасывает и списки, и сводные количества
+		queryCache := cache.New(config.Cache)
+		routeRepo = repository.NewCachedRouteRepository(routeRepo, queryCache)
+		tagRepo = repository.NewCachedTagRepository(tagRepo, queryCache)
+		analyticsRepo = repository.NewCachedAnalyticsRepository(analyticsRepo, queryCache)
+		diagnostics.PublishCounter("cache_hits", queryCache.Hits)
+		diagnostics.PublishCounter("cache_misses", queryCache.Misses)
+		diagnostics.PublishCounter("cache_entries", queryCache.Len)
+	}
+	apiKeyRepo := repository.NewAPIKeyRepository(db.Gorm())
+	userRepo := repository.NewUserRepository(db.Gorm())
+	orgRepo := repository.NewOrganizationRepository(db.Gorm())
+	usageRepo := repository.NewUsageRepository(db.Gorm())
+	auditRepo := repository.NewAuditRepository(db.Gorm())
+	webhookRepo := repository.NewWebhookRepository(db.Gorm())
+	shareRepo := repository.NewShareLinkRepository(db.Gorm())
+	shadowRepo := repository.NewShadowAnalysisRepository(db.Gorm())
+	outboxRepo := repository.NewOutboxRepository(db.Gorm())
+	alertRepo := repository.NewAlertRepository(db.Gorm())
+	reportRepo := repository.NewReportRepository(db.Gorm())
+	notificationRepo := repository.NewNotificationRepository(db.Gorm())
+
+	routeService := service.NewRouteService(routeRepo, logger, staticDir)
+	routeFeed := service.NewRouteFeed()
+	routeService.SetRouteFeed(routeFeed)
+	roadService := service.NewRoadService(roadRepo, routeRepo, logger)
+	routeService.SetRoadService(roadService)
+	analyzerService := service.NewAnalyzerService(config.PythonServices, logger, routeService)
+	diagnostics.PublishFunc("analyzer_instances", func() interface{} { return analyzerService.Instances() })
+	if len(config.PythonServices.URLs) > 1 || config.PythonServices.Discovery != "" {
+		logger.Infof("Анализы распределяются между экземплярами Python сервиса (%s): %s",
+			config.PythonServices.Strategy, strings.Join(config.PythonServices.URLs, ", "))
+	}
+	analyzerService.SetTimeout(config.PythonServiceTimeout)
+	analyzerService.SetTimeoutPolicy(config.AnalyzerTimeout)
+	analyzerService.SetSlowAnalysisThreshold(config.SlowAnalysisThreshold)
+	analyzerService.SetLowConfidenceThreshold(config.LowConfidenceThreshold)
+	diagnostics.PublishCounter("slow_analyses", func() int64 { return analyzerService.Stats().Slow })
+	analyticsService := service.NewAnalyticsService(analyticsRepo, logger)
+	boundaryService := service.NewBoundaryService(boundaryRepo, logger)
+	tagService := service.NewTagService(tagRepo, logger)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, logger)
+	apiKeyService.SetAdminKey(config.APIKeys.AdminKey)
+	apiKeyService.SetOrganizationRepository(orgRepo)
+	orgService := service.NewOrganizationService(orgRepo, userRepo, logger)
+	usageService := service.NewUsageService(usageRepo, routeRepo, logger)
+	auditService := service.NewAuditService(auditRepo, logger)
+	notifier, err := notify.New(config.Alerts)
+	if err != nil {
+		logger.Fatalf("Ошибка загрузки шаблонов уведомлений: %v", err)
+	}
+	webhookService := service.NewWebhookService(webhookRepo, logger)
+	webhookService.SetDeliveryOptions(config.Webhooks)
+	webhookService.SetNotifier(notifier)
+	outboxService := service.NewOutboxService(outboxRepo, webhookService, logger)
+	outboxService.SetOptions(config.Outbox)
+	analyzerService.SetEventOutbox(outboxService)
+	notificationService := service.NewNotificationService(notificationRepo, logger)
+	notificationService.SetNotifier(notifier)
+	analyzerService.SetNotificationService(notificationService)
+	alertService := service.NewAlertService(alertRepo, logger)
+	alertService.SetEventOutbox(outboxService)
+	alertService.SetNotifier(notifier)
+	alertService.SetNotificationService(notificationService)
+	analyzerService.SetAlertService(alertService)
+	reportService := service.NewReportService(reportRepo, config.Reports, logger)
+	reportService.SetEventOutbox(outboxService)
+	reportService.SetNotifier(notifier)
+	reportService.SetNotificationService(notificationService)
+	archiveService := service.NewArchiveService(routeRepo, config.Archive, logger)
+	analyzerService.SetErrorReporter(a.reporter)
+	shareService := service.NewShareService(shareRepo, routeService, logger)
+	shadowService := service.NewShadowService(shadowRepo, routeService, config.Shadow, logger)
+	if shadowService.Enabled() {
+		analyzerService.SetShadowService(shadowService)
+		logger.Infof("Теневой анализ включен: %.1f%% анализов повторяются анализатором %s", config.Shadow.Percent, config.Shadow.URL)
+	}
+	sqlDB, err := db.Gorm().DB()
+	if err != nil {
+		logger.Fatalf("Ошибка получения соединения с базой данных: %v", err)
+	}
+	healthService := service.NewHealthService(sqlDB, analyzerService, staticDir, database.SchemaVersion, logger)
+	healthService.SetOptions(config.Health)
+	healthService.SetDatabaseReady(db.Ready)
+	if db.HasReplica() {
+		replicaDB, err := db.Reader().DB()
+		if err != nil {
+			logger.Fatalf("Ошибка получения соединения с репликой: %v", err)
+		}
+		healthService.SetReplica(replicaDB)
+	}
+	maintenanceService := service.NewMaintenanceService(healthService, analyzerService, logger)
+	analyzerService.SetMaintenance(maintenanceService)
+	healthService.SetMaintenance(maintenanceService)
+	usageService.SetQuotaLimits(config.Quotas)
+	analyzerService.SetUsageService(usageService)
+	if config.Quotas.MonthlyUploads > 0 || config.Quotas.MonthlyAnalysisMinutes > 0 || config.Quotas.StorageBytes > 0 {
+		logger.Infof("Квоты: загрузок %d и минут анализа %g в месяц, хранилище %d байт (0 — без ограничения)",
+			config.Quotas.MonthlyUploads, config.Quotas.MonthlyAnalysisMinutes, config.Quotas.StorageBytes)
+	}
+
+	if config.AnalyzerBackend == "onnx" {
+		local, err := onnxanalyzer.New(config.ONNX)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки локального анализа ONNX: %v", err)
+		}
+		a.closers = append(a.closers, local)
+		analyzerService.SetLocalAnalyzer(local)
+		logger.Warnf("ЭКСПЕРИМЕНТАЛЬНО: анализ выполняется локальной моделью %s без Python сервиса", config.ONNX.ModelPath)
+	}
+
+	var debugStore *debugcapture.Store
+	if config.DebugCapture.Enabled {
+		debugStore, err = debugcapture.NewStore(config.DebugCapture.Dir, config.DebugCapture.MaxBundles)
+		if err != nil {
+			logger.Fatalf("Ошибка инициализации хранилища отладочных пакетов: %v", err)
+		}
+		analyzerService.SetDebugCapture(debugStore)
+		logger.Infof("Отладочные пакеты неудачных анализов сохраняются в %s", config.DebugCapture.Dir)
+	}
+
+	var unsavedResults *service.UnsavedResultService
+	if config.UnsavedResultsDir != "" {
+		unsavedResults, err = service.NewUnsavedResultService(config.UnsavedResultsDir, routeService, logger)
+		if err != nil {
+			logger.Fatalf("Ошибка инициализации хранилища несохраненных результатов: %v", err)
+		}
+		analyzerService.SetUnsavedResults(unsavedResults)
+	}
+
+	if config.MapMatching.Provider != "" {
+		matcher, err := mapmatch.New(config.MapMatching.Provider, config.MapMatching.URL, config.MapMatching.Timeout)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки привязки к дорогам: %v", err)
+		}
+		analyzerService.SetMapMatcher(matcher)
+		logger.Infof("Привязка сегментов к дорогам: %s (%s)", config.MapMatching.Provider, config.MapMatching.URL)
+	}
+
+	if config.Geocoding.Options.Provider != "" {
+		geocoder, err := geocode.New(config.Geocoding.Options)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки геокодирования: %v", err)
+		}
+		analyzerService.SetGeocoder(geocoder, config.Geocoding.Segments)
+		logger.Infof("Названия дорог определяются через %s (%s)", config.Geocoding.Options.Provider, config.Geocoding.Options.URL)
+	}
+
+	scorer, err := quality.New(config.Quality)
+	if err != nil {
+		logger.Fatalf("Ошибка настройки индекса качества: %v", err)
+	}
+	analyzerService.SetQualityScorer(scorer)
+
+	bands, err := coverageband.New(config.CoverageBands)
+	if err != nil {
+		logger.Fatalf("Ошибка настройки полос покрытия: %v", err)
+	}
+	routeService.SetCoverageBands(bands)
+	routeService.SetReportRenderer(report.New(config.Reports.Render))
+	roadService.SetCoverageBands(bands)
+	boundaryService.SetCoverageBands(bands)
+	analyticsService.SetCoverageBands(bands)
+
+	if config.VideoChunking.Options.ChunkDuration > 0 && analyzerService.LocalAnalyzer() == nil {
+		chunker, err := videochunk.New(config.VideoChunking.Options)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки анализа видео по частям: %v", err)
+		}
+		analyzerService.SetVideoChunking(chunker, config.VideoChunking.Parallelism)
+		logger.Infof("Видео длиннее %s анализируются по частям, до %d частей параллельно",
+			config.VideoChunking.Options.ChunkDuration, config.VideoChunking.Parallelism)
+	}
+
+	// Без ffmpeg сервер работает, но не принимает последовательности снимков
+	if converter, err := imageseq.New(config.ImageSequences); err != nil {
+		logger.Warnf("Анализ последовательностей снимков выключен: %v", err)
+	} else {
+		analyzerService.SetImageSequences(converter)
+	}
+
+	if config.PythonServiceTransport == "grpc" && analyzerService.LocalAnalyzer() == nil {
+		conn, err := grpc.NewClient(config.PythonServiceGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			logger.Fatalf("Ошибка настройки gRPC клиента анализатора: %v", err)
+		}
+		a.closers = append(a.closers, conn)
+		analyzerService.SetStreamClient(conn, config.PythonServiceGRPCAddr)
+		logger.Infof("Потоковый анализ через gRPC: %s, при недоступности — через HTTP", config.PythonServiceGRPCAddr)
+	}
+
+	var analysisQueue *service.AnalysisQueue
+	if config.RunMode != "all" {
+		analysisQueue, err = service.NewAnalysisQueue(repository.NewAnalysisJobRepository(db.Gorm()), config.AnalysisQueue, logger)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки очереди анализов: %v", err)
+		}
+	}
+	if config.RunMode == "api" {
+		analyzerService.SetAnalysisQueue(analysisQueue)
+		healthService.SetAnalysisQueue(analysisQueue)
+		logger.Infof("Анализы выполняют воркеры: видео передаются через очередь в %s", config.AnalysisQueue.Dir)
+	}
+
+	if config.HLS.Enabled {
+		packager, err := hls.New(config.HLS.Options)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки воспроизведения видео по HLS: %v", err)
+		}
+		routeService.SetHLSPackager(packager, config.HLS.Timeout)
+		logger.Infof("Видео маршрутов доступны по HLS, сегменты по %s", config.HLS.Options.SegmentDuration)
+	}
+
+	// Видео перекодирует роль, которая сохраняет маршруты после анализа
+	var transcodeService *service.TranscodeService
+	if config.Transcoding.Enabled && config.RunMode != "api" {
+		transcoder, err := transcode.New(config.Transcoding.Options)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки перекодирования видео: %v", err)
+		}
+		transcodeService = service.NewTranscodeService(routeService, transcoder, config.Transcoding.Queue, logger)
+		routeService.SetTranscodeService(transcodeService)
+		logger.Infof("Видео, которые не воспроизводятся в браузерах, перекодируются в H.264 MP4, одновременно до %d", config.Transcoding.Queue.Workers)
+	}
+
+	if a.chaosEnabled && config.Chaos.HTTP.Active() {
+		logger.WithField("faults", config.Chaos.HTTP).Warn("РЕЖИМ ХАОСА: внедрение сбоев в запросы к Python сервису")
+		analyzerService.SetHTTPTransport(chaos.NewTransport(nil, config.Chaos.HTTP))
+	}
+
+	return &services{
+		userRepo:            userRepo,
+		analyticsRepo:       analyticsRepo,
+		routeService:        routeService,
+		routeFeed:           routeFeed,
+		roadService:         roadService,
+		analyzerService:     analyzerService,
+		analyticsService:    analyticsService,
+		boundaryService:     boundaryService,
+		tagService:          tagService,
+		apiKeyService:       apiKeyService,
+		orgService:          orgService,
+		usageService:        usageService,
+		auditService:        auditService,
+		webhookService:      webhookService,
+		outboxService:       outboxService,
+		notificationService: notificationService,
+		alertService:        alertService,
+		reportService:       reportService,
+		archiveService:      archiveService,
+		shareService:        shareService,
+		shadowService:       shadowService,
+		healthService:       healthService,
+		transcodeService:    transcodeService,
+		maintenanceService:  maintenanceService,
+		selfTestService:     service.NewSelfTestService(analyzerService, routeService, logger, config.SelfTestVideoPath),
+		statsService:        service.NewStatsService(analyticsRepo, analyzerService, staticDir, logger),
+		debugStore:          debugStore,
+		unsavedResults:      unsavedResults,
+		bands:               bands,
+		analysisQueue:       analysisQueue,
+	}
+}
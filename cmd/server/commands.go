@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/database"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+)
+
+// command подкоманда исполняемого файла сервера
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands подкоманды. Без подкоманды, в том числе с флагами, запускается
+// сервер, как и до появления подкоманд.
+var commands = []command{
+	{"serve", "запустить сервер API", serve},
+	{"migrate", "выполнить миграции базы данных", runMigrate},
+	{"analyze", "проанализировать видео и сохранить маршрут, результат выводится в JSON", runAnalyze},
+	{"export", "выгрузить маршрут в JSON, GeoJSON или PDF", runExport},
+	{"gc", "удалить давно удаленные маршруты и папки видео без маршрута", runGC},
+	{"create-api-key", "создать ключ API, ключ выводится один раз", runCreateAPIKey},
+	{"set-user-role", "назначить роль зарегистрированному пользователю", runSetUserRole},
+}
+
+// errUsage ошибка в аргументах команды; справка уже выведена
+var errUsage = errors.New("invalid arguments")
+
+// runCommand выполняет подкоманду и возвращает код завершения процесса
+func runCommand(args []string) int {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			if !errors.Is(err, errUsage) {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			}
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Неизвестная команда %q\n\n", name)
+	printUsage(os.Stderr)
+	return 2
+}
+
+// printUsage выводит список подкоманд
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Использование: %s <команда> [аргументы]\n\nКоманды:\n", filepath.Base(os.Args[0]))
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nСправка по команде: %s <команда> -h\n", filepath.Base(os.Args[0]))
+}
+
+// newFlagSet создает набор флагов команды
+func newFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		if usage != "" {
+			fmt.Fprintf(flags.Output(), "Использование: %s %s\n", filepath.Base(os.Args[0]), usage)
+		}
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// configFlag добавляет флаг -config, общий для всех команд
+func configFlag(flags *flag.FlagSet) *string {
+	return flags.String("config", os.Getenv("CONFIG_FILE"), "файл конфигурации YAML или TOML")
+}
+
+// parseFlags разбирает флаги, стоящие как до, так и после позиционных
+// аргументов, и возвращает позиционные аргументы
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// usageError выводит сообщение и справку команды
+func usageError(flags *flag.FlagSet, format string, args ...interface{}) error {
+	fmt.Fprintf(flags.Output(), format+"\n", args...)
+	flags.Usage()
+	return errUsage
+}
+
+// runMigrate выполняет миграции и подготовку PostGIS, как при запуске
+// сервера, и измеряет файлы маршрутов, сохраненных до учета хранилища
+func runMigrate(args []string) error {
+	flags := newFlagSet("migrate", "migrate [-config FILE]")
+	configPath := configFlag(flags)
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	a := newApp(*configPath, true)
+	defer a.Close()
+	a.connectDatabase(false)
+	fmt.Printf("Схема базы данных: версия %d, PostGIS: %t\n", database.SchemaVersion, a.postgisEnabled)
+
+	routes := service.NewRouteService(repository.NewRouteRepository(a.db.Gorm(), nil), a.logger, a.staticDir)
+	measured, err := routes.BackfillStorageBytes()
+	if err != nil {
+		return fmt.Errorf("failed to measure route storage: %w", err)
+	}
+	fmt.Printf("Измерено маршрутов для учета хранилища: %d\n", measured)
+	return nil
+}
+
+// runAnalyze анализирует видео так же, как POST /api/v1/analyze, и выводит
+// результат в stdout
+func runAnalyze(args []string) error {
+	flags := newFlagSet("analyze", "analyze VIDEO -start LAT,LON -end LAT,LON -segment-length M [-route-id ID [-overwrite]] [-name NAME] [-tags A,B]")
+	configPath := configFlag(flags)
+	start := flags.String("start", "", "начало маршрута: широта,долгота")
+	end := flags.String("end", "", "конец маршрута: широта,долгота")
+	segmentLength := flags.Float64("segment-length", 0, "длина сегмента в метрах")
+	routeID := flags.String("route-id", "", "ID маршрута, по умолчанию генерируется")
+	overwrite := flags.Bool("overwrite", false, "заменить анализ существующего маршрута с ID -route-id")
+	name := flags.String("name", "", "название маршрута")
+	tags := flags.String("tags", "", "метки маршрута через запятую")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError(flags, "Нужно указать один файл видео")
+	}
+	startCoord, err := parseCoordinates(*start)
+	if err != nil {
+		return usageError(flags, "Неверный -start: %v", err)
+	}
+	endCoord, err := parseCoordinates(*end)
+	if err != nil {
+		return usageError(flags, "Неверный -end: %v", err)
+	}
+	if *segmentLength <= 0 {
+		return usageError(flags, "-segment-length должен быть положительным числом")
+	}
+	metadata := service.RouteMetadata{Name: *name, Overwrite: *overwrite}
+	if *tags != "" {
+		metadata.Tags = strings.Split(*tags, ",")
+	}
+	if err := metadata.Validate(); err != nil {
+		return err
+	}
+
+	videoPath := positional[0]
+	videoData, err := os.ReadFile(videoPath)
+	if err != nil {
+		return fmt.Errorf("failed to read video: %w", err)
+	}
+
+	a := newApp(*configPath, true)
+	defer a.Close()
+	a.connectDatabase(false)
+	svc := a.newServices()
+	if svc.analyzerService.LocalAnalyzer() == nil {
+		checkPythonCompatibility(svc.analyzerService, a.config, a.logger)
+	}
+
+	result, err := svc.analyzerService.AnalyzeRoadMarking(
+		startCoord.Lat, startCoord.Lon, endCoord.Lat, endCoord.Lon,
+		*segmentLength, bytes.NewReader(videoData), filepath.Base(videoPath), *routeID, metadata,
+	)
+	a.flushErrors()
+	if err != nil {
+		return err
+	}
+	return writeJSON(os.Stdout, result)
+}
+
+// runExport выгружает маршрут в JSON, GeoJSON или PDF
+func runExport(args []string) error {
+	flags := newFlagSet("export", "export ROUTE_ID [-format json|geojson|pdf] [-o FILE]")
+	configPath := configFlag(flags)
+	format := flags.String("format", "json", "формат: json, geojson или pdf")
+	output := flags.String("o", "", "файл результата, по умолчанию stdout")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError(flags, "Нужно указать ID маршрута")
+	}
+	if *format != "json" && *format != "geojson" && *format != "pdf" {
+		return usageError(flags, "Неизвестный формат %q", *format)
+	}
+	routeID := positional[0]
+
+	a := newApp(*configPath, true)
+	defer a.Close()
+	a.connectDatabase(false)
+	svc := a.newServices()
+
+	var data []byte
+	switch *format {
+	case "pdf":
+		data, err = svc.routeService.RouteReportPDF(context.Background(), routeID)
+		if err != nil {
+			return err
+		}
+	default:
+		route, err := svc.routeService.GetRouteByID(routeID, repository.RouteScope{})
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if *format == "geojson" {
+			err = writeJSON(&buf, service.RouteGeoJSON(route))
+		} else {
+			err = writeJSON(&buf, route)
+		}
+		if err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	a.logger.Infof("Маршрут %s выгружен в %s", routeID, *output)
+	return nil
+}
+
+// runGC удаляет безвозвратно маршруты, удаленные раньше заданного срока,
+// и папки видео без маршрута
+func runGC(args []string) error {
+	flags := newFlagSet("gc", "gc [-deleted-days N] [-orphan-min-age DURATION] [-dry-run]")
+	configPath := configFlag(flags)
+	deletedDays := flags.Int("deleted-days", 30, "удалить маршруты, удаленные больше N дней назад")
+	orphanMinAge := flags.Duration("orphan-min-age", 24*time.Hour, "не трогать папки видео моложе этого возраста")
+	dryRun := flags.Bool("dry-run", false, "только показать, что будет удалено")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *deletedDays < 0 {
+		return usageError(flags, "-deleted-days не может быть отрицательным")
+	}
+
+	a := newApp(*configPath, true)
+	defer a.Close()
+	a.connectDatabase(false)
+	svc := a.newServices()
+
+	report, err := svc.routeService.CollectGarbage(service.GCOptions{
+		DeletedBefore: time.Now().AddDate(0, 0, -*deletedDays),
+		OrphanMinAge:  *orphanMinAge,
+		DryRun:        *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+	return writeJSON(os.Stdout, report)
+}
+
+// runCreateAPIKey создает ключ API без запроса к работающему серверу,
+// например первый ключ администратора
+func runCreateAPIKey(args []string) error {
+	flags := newFlagSet("create-api-key", "create-api-key -name NAME [-admin] [-org ID]")
+	configPath := configFlag(flags)
+	name := flags.String("name", "", "название ключа")
+	admin := flags.Bool("admin", false, "ключ администратора")
+	org := flags.Uint("org", 0, "ID организации, данными которой ограничен ключ")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*name) == "" {
+		return usageError(flags, "Нужно указать -name")
+	}
+
+	a := newApp(*configPath, true)
+	defer a.Close()
+	a.connectDatabase(false)
+	svc := a.newServices()
+
+	req := service.CreateAPIKeyRequest{Name: *name, Admin: *admin}
+	if *org != 0 {
+		orgID := *org
+		req.OrganizationID = &orgID
+	}
+	key, err := svc.apiKeyService.CreateKey(req)
+	if err != nil {
+		return err
+	}
+	return writeJSON(os.Stdout, key)
+}
+
+// runSetUserRole назначает роль пользователю, например первому администратору
+func runSetUserRole(args []string) error {
+	flags := newFlagSet("set-user-role", "set-user-role -email EMAIL [-role admin|user]")
+	configPath := configFlag(flags)
+	email := flags.String("email", "", "email зарегистрированного пользователя")
+	role := flags.String("role", model.RoleAdmin, "роль: admin или user")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*email) == "" {
+		return usageError(flags, "Нужно указать -email")
+	}
+
+	a := newApp(*configPath, true)
+	defer a.Close()
+	a.connectDatabase(false)
+	svc := a.newServices()
+
+	users := service.NewUserService(svc.userRepo, nil, a.config.Users.TokenTTL, a.logger)
+	user, err := users.SetRole(*email, *role)
+	if err != nil {
+		return err
+	}
+	return writeJSON(os.Stdout, user)
+}
+
+// parseCoordinates разбирает координаты в виде "широта,долгота"
+func parseCoordinates(value string) (service.Coordinates, error) {
+	latStr, lonStr, ok := strings.Cut(value, ",")
+	if !ok {
+		return service.Coordinates{}, fmt.Errorf("expected lat,lon, got %q", value)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return service.Coordinates{}, fmt.Errorf("invalid latitude %q", latStr)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return service.Coordinates{}, fmt.Errorf("invalid longitude %q", lonStr)
+	}
+	return service.Coordinates{Lat: lat, Lon: lon}, nil
+}
+
+// writeJSON выводит значение в JSON с отступами
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -1,56 +1,145 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/bodylimit"
+	"road-detector-go/internal/buildinfo"
+	"road-detector-go/internal/chaos"
+	"road-detector-go/internal/compression"
+	appconfig "road-detector-go/internal/config"
 	"road-detector-go/internal/database"
+	"road-detector-go/internal/diagnostics"
+	"road-detector-go/internal/dirwatch"
+	"road-detector-go/internal/errreport"
+	"road-detector-go/internal/graphqlapi"
+	"road-detector-go/internal/grpcserver"
 	"road-detector-go/internal/handler"
-	"road-detector-go/internal/repository"
+	"road-detector-go/internal/mqttingest"
+	"road-detector-go/internal/oidc"
+	"road-detector-go/internal/openapi"
+	"road-detector-go/internal/ratelimit"
+	"road-detector-go/internal/reqlog"
 	"road-detector-go/internal/service"
+	"road-detector-go/internal/tlsserver"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
-	logger := logrus.New()
-	logger.SetLevel(logrus.InfoLevel)
-	logger.SetFormatter(&logrus.JSONFormatter{})
+	os.Exit(runCommand(os.Args[1:]))
+}
 
-	logger.Info("Запуск Road Detector API Server")
+// serve запускает HTTP API и фоновые задачи и работает до сигнала остановки
+func serve(args []string) error {
+	flags := newFlagSet("serve", "[serve] [-config FILE]")
+	configPath := configFlag(flags)
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
 
-	config := getConfig()
+	a := newApp(*configPath, false)
+	defer a.Close()
+	config, logger := a.config, a.logger
+	build := buildinfo.Current()
 
-	logger.Info("Подключение к базе данных...")
-	if err := database.Connect(); err != nil {
-		logger.Fatalf("Ошибка подключения к базе данных: %v", err)
-	}
+	logger.WithFields(logrus.Fields{
+		"deployment": config.Deployment.Name,
+		"version":    build.Version,
+		"git_commit": build.GitCommit,
+		"build_date": build.BuildDate,
+	}).Info("Запуск Road Detector API Server")
+	logger.WithFields(logrus.Fields{
+		"config_file": config.File,
+		"config":      config.Summary(),
+	}).Info("Действующая конфигурация")
 
-	logger.Info("Выполнение миграций базы данных...")
-	if err := database.Migrate(); err != nil {
-		logger.Fatalf("Ошибка выполнения миграций: %v", err)
+	a.connectDatabase(true)
+	db := a.db
+	svc := a.newServices()
+	// Экземпляр API в роли api к Python сервису не обращается
+	usesPython := config.RunMode != "api" && svc.analyzerService.LocalAnalyzer() == nil
+	if usesPython {
+		checkPythonCompatibility(svc.analyzerService, config, logger)
 	}
-
-	if err := database.HealthCheck(); err != nil {
-		logger.Fatalf("База данных недоступна: %v", err)
+	if config.RunMode == "worker" {
+		return runWorker(a, svc)
 	}
 
-	logger.Info("База данных успешно подключена и готова к работе")
-
-	staticDir := filepath.Join(".", "static")
-	if err := os.MkdirAll(staticDir, 0755); err != nil {
-		logger.Fatalf("Ошибка создания папки для статических файлов: %v", err)
+	// Ограничитель создается и при RPS 0, чтобы ограничение можно было
+	// включить перезагрузкой конфигурации
+	limiter := ratelimit.New(ratelimit.Options{RPS: config.RateLimit.RPS, Burst: config.RateLimit.Burst})
+	if limiter.Enabled() {
+		logger.Infof("Ограничение частоты запросов: %g в секунду, до %d подряд", config.RateLimit.RPS, limiter.Burst())
 	}
 
-	routeRepo := repository.NewRouteRepository(database.DB)
+	var userService *service.UserService
+	if config.Users.Enabled || config.OIDC.IssuerURL != "" {
+		var secret []byte
+		if config.Users.Enabled {
+			secret = []byte(config.Users.Secret)
+		}
+		userService = service.NewUserService(svc.userRepo, secret, config.Users.TokenTTL, logger)
+		userService.SetRegistrationEnabled(config.Users.RegistrationEnabled)
+	}
+	if config.OIDC.IssuerURL != "" {
+		provider, err := oidc.New(config.OIDC)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки OIDC: %v", err)
+		}
+		userService.SetOIDCProvider(provider)
+		logger.Infof("Принимаются токены OIDC провайдера %s, роль администратора: %s",
+			provider.Issuer(), config.OIDC.AdminRole)
+	}
 
-	routeService := service.NewRouteService(routeRepo, logger, staticDir)
-	analyzerService := service.NewAnalyzerService(config.PythonServiceURL, logger, routeService)
+	routeHandler := handler.NewRouteHandler(svc.analyzerService, svc.routeService, logger)
+	routeHandlerV2 := handler.NewRouteHandlerV2(svc.analyzerService, svc.routeService, logger)
+	analyticsHandler := handler.NewAnalyticsHandler(svc.analyticsService, svc.boundaryService, logger)
+	boundaryHandler := handler.NewBoundaryHandler(svc.boundaryService, logger)
+	roadHandler := handler.NewRoadHandler(svc.roadService, logger)
+	tagHandler := handler.NewTagHandler(svc.tagService, svc.routeService, logger)
+	apiKeyHandler := handler.NewAPIKeyHandler(svc.apiKeyService, logger)
+	authHandler := handler.NewAuthHandler(userService, logger)
+	orgHandler := handler.NewOrganizationHandler(svc.orgService, logger)
+	usageHandler := handler.NewUsageHandler(svc.usageService, limiter, logger)
+	auditHandler := handler.NewAuditHandler(svc.auditService, logger)
+	webhookHandler := handler.NewWebhookHandler(svc.webhookService, logger)
+	alertHandler := handler.NewAlertHandler(svc.alertService, logger)
+	reportHandler := handler.NewReportHandler(svc.reportService, logger)
+	notificationHandler := handler.NewNotificationHandler(svc.notificationService, logger)
+	shareHandler := handler.NewShareHandler(svc.shareService, svc.routeService, logger)
+	graphqlAPI, err := graphqlapi.New(svc.routeService, svc.analyticsService, logger)
+	if err != nil {
+		logger.Fatalf("Ошибка создания схемы GraphQL: %v", err)
+	}
+	graphqlHandler := handler.NewGraphQLHandler(graphqlAPI, logger)
+	liveHandler := handler.NewLiveHandler(svc.routeFeed, logger)
+	shadowHandler := handler.NewShadowHandler(svc.shadowService, svc.routeService, logger)
+	healthHandler := handler.NewHealthHandler(svc.healthService, logger)
+	metaHandler := handler.NewMetaHandler(svc.analyzerService, svc.bands, logger)
+	adminHandler := handler.NewAdminHandler(svc.debugStore, svc.unsavedResults, svc.selfTestService, svc.statsService, svc.maintenanceService, svc.bands, logger)
 
-	routeHandler := handler.NewRouteHandler(analyzerService, routeService, logger)
+	var tlsServer *tlsserver.Server
+	if config.TLS.Enabled() {
+		tlsServer, err = tlsserver.New(config.TLS)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки TLS: %v", err)
+		}
+	}
 
 	// Настраиваем Gin router
 	if config.Environment == "production" {
@@ -58,65 +147,551 @@ func main() {
 	}
 
 	router := gin.New()
+	if err := configureClientIP(router, config); err != nil {
+		logger.Fatalf("Ошибка настройки доверенных прокси: %v", err)
+	}
+	if len(config.ClientIP.TrustedProxies) > 0 {
+		logger.Infof("IP клиента берется из %s от доверенных прокси: %s",
+			strings.Join(config.ClientIP.Headers, ", "), strings.Join(config.ClientIP.TrustedProxies, ", "))
+	}
 
 	// Добавляем middleware
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	// Журнал запросов подключается первым, чтобы видеть итоговый статус ответа
+	router.Use(reqlog.Middleware(logger))
+	// Ответы 5xx и паника отправляются в систему учета ошибок
+	router.Use(errreport.Middleware(a.reporter))
+	if config.Compression.Enabled {
+		// Сжатие подключается до формирования ошибок, чтобы сжимались и тела ошибок
+		router.Use(compression.Middleware(compression.Options{
+			MinBytes: config.Compression.MinBytes,
+			Level:    config.Compression.Level,
+		}))
+	}
+	// Ответы с ошибками формируются в одном месте, в том числе при панике обработчика
+	router.Use(apierror.Middleware(logger, handler.APIv2Prefix))
+	router.Use(gin.CustomRecovery(apierror.Recover))
 	router.Use(corsMiddleware())
+	if !db.Ready() {
+		// Проверка подключается до авторизации, которая тоже обращается к БД
+		router.Use(databaseGate(db))
+	}
+	if tlsServer != nil {
+		if hsts := tlsServer.HSTS(); hsts != nil {
+			router.Use(hsts)
+		}
+	}
+	authOptions := auth.Options{Exempt: config.APIKeys.ExemptPaths, Organizations: svc.orgService}
+	if config.APIKeys.Enabled {
+		authOptions.APIKeys = svc.apiKeyService
+		if config.APIKeys.AdminKey == "" {
+			logger.Warn("API_ADMIN_KEY не задан: новые ключи может создать только существующий ключ администратора")
+		}
+	}
+	if userService != nil {
+		authOptions.Users = userService
+		if config.Users.Enabled {
+			logger.Infof("Вход по паролю включен, токен действует %s, регистрация: %t",
+				config.Users.TokenTTL, config.Users.RegistrationEnabled)
+		}
+	}
+	authEnabled := authOptions.APIKeys != nil || authOptions.Users != nil
+	if authEnabled {
+		router.Use(auth.Middleware(authOptions))
+		logger.Infof("Запросы к /api/v1 требуют авторизацию, кроме: %s", strings.Join(config.APIKeys.ExemptPaths, ", "))
+	} else {
+		logger.Warn("Проверка ключей API и токенов отключена, API доступно без авторизации")
+	}
+	// Ограничение частоты выполняется после проверки доступа, чтобы считать
+	// запросы по ключу или пользователю, а не только по IP
+	router.Use(ratelimit.Middleware(limiter, auth.ClientKey))
+	// Режим обслуживания проверяется до разбора формы с видео
+	router.Use(maintenanceGate(svc.maintenanceService))
+	// Тело запроса проверяется после авторизации, чтобы форму multipart
+	// разбирали только для допущенных клиентов
+	router.Use(bodylimit.Middleware(bodyLimits(config)))
+	// Журнал аудита пишется после проверки доступа, когда известен инициатор операции
+	router.Use(audit.Middleware(svc.auditService))
+	router.NoRoute(func(c *gin.Context) {
+		apierror.Abort(c, apierror.New(apierror.CodeNotFound, "Ресурс не найден"))
+	})
 
-	// Обслуживание статических файлов
-	router.Static("/static", staticDir)
+	// Обслуживание статических файлов, кроме видео маршрутов
+	handler.NewStaticHandler(a.staticDir).RegisterRoutes(router)
 
 	// Регистрируем маршруты
 	routeHandler.RegisterRoutes(router)
+	routeHandlerV2.RegisterRoutes(router)
+	analyticsHandler.RegisterRoutes(router)
+	boundaryHandler.RegisterRoutes(router)
+	roadHandler.RegisterRoutes(router)
+	tagHandler.RegisterRoutes(router)
+	if userService != nil {
+		authHandler.RegisterRoutes(router)
+	}
+	orgHandler.RegisterRoutes(router)
+	usageHandler.RegisterRoutes(router)
+	webhookHandler.RegisterRoutes(router)
+	alertHandler.RegisterRoutes(router)
+	reportHandler.RegisterRoutes(router)
+	notificationHandler.RegisterRoutes(router)
+	shareHandler.RegisterRoutes(router)
+	shadowHandler.RegisterRoutes(router)
+	healthHandler.RegisterRoutes(router)
+	metaHandler.RegisterRoutes(router)
+	graphqlHandler.RegisterRoutes(router)
+	liveHandler.RegisterRoutes(router)
+	// Без проверки доступа /api/v1/admin был бы открыт всем, поэтому
+	// служебные маршруты и диагностика в API не подключаются
+	if authEnabled {
+		apiKeyHandler.RegisterRoutes(router)
+		orgHandler.RegisterAdminRoutes(router)
+		auditHandler.RegisterRoutes(router)
+		adminHandler.RegisterRoutes(router)
+	} else {
+		logger.Warn("Проверка доступа отключена: служебные маршруты /api/v1/admin не подключены")
+	}
+	if config.Diagnostics.AdminAPI {
+		if !authEnabled {
+			logger.Error("DIAGNOSTICS_ADMIN_API требует API_KEY_AUTH_ENABLED или JWT_AUTH_ENABLED, диагностика в API не включена")
+		} else {
+			diagnostics.Mount(router, "/api/v1/admin")
+			logger.Info("Диагностика доступна администраторам: /api/v1/admin/debug/pprof/, /api/v1/admin/debug/vars")
+		}
+	}
+	if config.Diagnostics.Addr != "" {
+		go func() {
+			logger.Infof("Диагностика запущена на %s: /debug/pprof/, /debug/vars", config.Diagnostics.Addr)
+			if err := diagnostics.ListenAndServe(config.Diagnostics.Addr); err != nil {
+				logger.Errorf("Ошибка запуска диагностики: %v", err)
+			}
+		}()
+	}
 
 	// Добавляем базовый маршрут для проверки
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
-			"message": "Road Detector API Server",
-			"version": "1.0.0",
-			"status":  "running",
+			"message":    "Road Detector API Server",
+			"version":    build.Version,
+			"status":     "running",
+			"deployment": config.Deployment,
+			"build":      build,
 		})
 	})
 
+	// Документ OpenAPI собирается по зарегистрированным маршрутам, поэтому
+	// подключается последним
+	if config.APIDocs.Enabled {
+		openAPIHandler := handler.NewOpenAPIHandler(openapi.Options{
+			Title:           "Road Detector API",
+			Description:     "API анализа дорожной разметки по видео проездов",
+			Version:         build.Version,
+			Public:          config.APIKeys.ExemptPaths,
+			APIKeyHeader:    auth.APIKeyHeader,
+			ErrorBody:       apierror.Body{},
+			ProblemBody:     apierror.Problem{},
+			ProblemPrefixes: []string{handler.APIv2Prefix},
+			Skip:            []string{"/static/*", "/api/v1/admin/debug/*"},
+		}, config.APIDocs.SwaggerUIAssetsURL, logger)
+		if err := openAPIHandler.RegisterRoutes(router); err != nil {
+			logger.Fatalf("Ошибка сборки документа OpenAPI: %v", err)
+		}
+	}
+
 	// Запускаем сервер
-	serverAddr := fmt.Sprintf(":%s", config.Port)
-	logger.Infof("Сервер запущен на порту %s", config.Port)
-	logger.Infof("API доступно по адресу: http://localhost:%s/api/v1", config.Port)
+	serverAddr := config.Addr()
+	server := &http.Server{
+		Addr:              serverAddr,
+		Handler:           router,
+		ReadTimeout:       config.HTTPServer.ReadTimeout,
+		ReadHeaderTimeout: config.HTTPServer.ReadHeaderTimeout,
+		WriteTimeout:      config.HTTPServer.WriteTimeout,
+		IdleTimeout:       config.HTTPServer.IdleTimeout,
+		MaxHeaderBytes:    config.HTTPServer.MaxHeaderBytes,
+	}
+	// Ответ на загрузку видео отправляется после анализа, поэтому запись
+	// должна ждать дольше, чем Python сервис
+	if config.HTTPServer.WriteTimeout > 0 && config.HTTPServer.WriteTimeout <= config.HTTPServer.ReadTimeout+svc.analyzerService.MaxTimeout() {
+		logger.Warnf("HTTP_WRITE_TIMEOUT_SEC (%s) не больше суммы HTTP_READ_TIMEOUT_SEC и наибольшего ожидания Python сервиса (%s): ответ на загрузку длинного видео может быть прерван",
+			config.HTTPServer.WriteTimeout, config.HTTPServer.ReadTimeout+svc.analyzerService.MaxTimeout())
+	}
+	var redirectServer *http.Server
+	serverErr := make(chan error, 2)
+	if tlsServer != nil {
+		if config.TLS.RedirectAddr != "" {
+			redirectServer = tlsServer.RedirectServer(serverAddr)
+			go func() {
+				logger.Infof("Перенаправление с HTTP на HTTPS: %s", config.TLS.RedirectAddr)
+				if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					serverErr <- fmt.Errorf("https redirect: %w", err)
+				}
+			}()
+		}
+		logger.Infof("Сервер запущен на порту %s, сертификат: %s", config.Port, tlsServer.Mode())
+		logger.Infof("API доступно по адресу: https://localhost:%s/api/v1", config.Port)
+		go func() {
+			if err := tlsServer.ListenAndServeTLS(server); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	} else {
+		logger.Infof("Сервер запущен на порту %s", config.Port)
+		logger.Infof("API доступно по адресу: http://localhost:%s/api/v1", config.Port)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+
+	var grpcServer *grpcserver.Server
+	if config.GRPC.Addr != "" {
+		jobs := service.NewJobTracker()
+		svc.analyzerService.SetJobTracker(jobs)
+		grpcOptions := config.GRPC
+		grpcOptions.Auth = authOptions
+		grpcServer, err = grpcserver.New(grpcOptions, svc.analyzerService, svc.routeService, jobs, logger)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки gRPC сервера: %v", err)
+		}
+		logger.Infof("gRPC API запущено на %s", config.GRPC.Addr)
+		go func() {
+			if err := grpcServer.ListenAndServe(); err != nil {
+				serverErr <- err
+			}
+		}()
+	}
+
+	var mqttIngester *mqttingest.Ingester
+	if config.MQTT.BrokerURL != "" {
+		mqttIngester, err = mqttingest.New(config.MQTT, svc.analyzerService, svc.routeService, logger)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки приема клипов по MQTT: %v", err)
+		}
+		mqttIngester.Start()
+		logger.Infof("Прием клипов по MQTT: брокер %s, топики %s/+/+/video и track", config.MQTT.BrokerURL, config.MQTT.TopicPrefix)
+	}
+
+	var dirWatcher *dirwatch.Watcher
+	if config.DirWatch.Dir != "" {
+		dirWatcher, err = dirwatch.New(config.DirWatch, svc.analyzerService, svc.routeService, logger)
+		if err != nil {
+			logger.Fatalf("Ошибка настройки приема видео из папки: %v", err)
+		}
+		dirWatcher.Start()
+		logger.Infof("Прием видео из папки %s, проверка каждые %s", config.DirWatch.Dir, config.DirWatch.PollInterval)
+	}
 
-	if err := router.Run(serverAddr); err != nil {
+	// Ждем сигнала остановки или ошибки запуска
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	reloader := &configReloader{
+		logger:   logger,
+		limiter:  limiter,
+		analyzer: svc.analyzerService,
+		usage:    svc.usageService,
+		queries:  a.slowQueries,
+		bands:    svc.bands,
+		current:  config,
+	}
+	go reloader.Watch(ctx)
+	if usesPython {
+		go svc.analyzerService.RunInstanceChecks(ctx)
+	}
+	go runEventRelay(ctx, db, svc.webhookService, svc.outboxService, logger)
+	go func() {
+		if waitDatabaseReady(ctx, db) {
+			svc.archiveService.Run(ctx)
+		}
+	}()
+	go func() {
+		if waitDatabaseReady(ctx, db) {
+			svc.reportService.Run(ctx)
+		}
+	}()
+	if svc.transcodeService != nil {
+		go func() {
+			if waitDatabaseReady(ctx, db) {
+				svc.transcodeService.Run(ctx)
+			}
+		}()
+	}
+	go func() {
+		if !waitDatabaseReady(ctx, db) {
+			return
+		}
+		// Маршруты, сохраненные до учета хранилища, не учитываются в квоте,
+		// пока не измерены
+		if measured, err := svc.routeService.BackfillStorageBytes(); err != nil {
+			logger.Errorf("Не удалось измерить файлы маршрутов для учета хранилища: %v", err)
+		} else if measured > 0 {
+			logger.Infof("Измерены файлы %d маршрутов для учета хранилища", measured)
+		}
+	}()
+	select {
+	case err := <-serverErr:
 		logger.Fatalf("Ошибка запуска сервера: %v", err)
+	case <-ctx.Done():
+		// Повторный сигнал завершает процесс сразу
+		stop()
+	}
+
+	logger.Infof("Получен сигнал остановки, ожидание завершения запросов и анализов (до %s)", config.ShutdownTimeout)
+	deadline := time.Now().Add(config.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	// Сервер перестает принимать соединения и ждет обработчики, в том числе
+	// запросы с анализом видео, которые выполняются синхронно
+	if redirectServer != nil {
+		go redirectServer.Shutdown(shutdownCtx)
+	}
+	grpcStopped := make(chan bool, 1)
+	if grpcServer != nil {
+		go func() { grpcStopped <- grpcServer.Shutdown(shutdownCtx) }()
+	} else {
+		grpcStopped <- true
+	}
+	mqttStopped := make(chan bool, 1)
+	if mqttIngester != nil {
+		go func() { mqttStopped <- mqttIngester.Shutdown(shutdownCtx) }()
+	} else {
+		mqttStopped <- true
+	}
+	dirStopped := make(chan bool, 1)
+	if dirWatcher != nil {
+		go func() { dirStopped <- dirWatcher.Shutdown(shutdownCtx) }()
+	} else {
+		dirStopped <- true
 	}
+	// Соединения WebSocket сервер не отслеживает, они закрываются отдельно
+	svc.routeFeed.Close()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("Не все запросы завершились за %s, соединения закрыты принудительно: %v", config.ShutdownTimeout, err)
+		server.Close()
+	}
+	if !<-grpcStopped {
+		logger.Errorf("Не все gRPC вызовы завершились за %s, соединения закрыты принудительно", config.ShutdownTimeout)
+	}
+	if !<-mqttStopped {
+		logger.Errorf("Не все анализы клипов, полученных по MQTT, завершились за %s", config.ShutdownTimeout)
+	}
+	if !<-dirStopped {
+		logger.Errorf("Не все анализы видео из папки приема завершились за %s, они будут повторены при запуске", config.ShutdownTimeout)
+	}
+
+	// Новые события после остановки сервера не публикуются, ждем текущие доставки
+	if !svc.webhookService.Shutdown(time.Until(deadline)) {
+		logger.Warn("Не все доставки вебхуков завершились до остановки сервиса")
+	}
+	if !svc.alertService.Shutdown(time.Until(deadline)) {
+		logger.Warn("Не все оповещения отправлены по почте и в Telegram до остановки сервиса")
+	}
+	if !svc.reportService.Shutdown(time.Until(deadline)) {
+		logger.Warn("Не все отчеты отправлены по почте до остановки сервиса")
+	}
+	if !svc.notificationService.Shutdown(time.Until(deadline)) {
+		logger.Warn("Не все уведомления о завершенных анализах отправлены до остановки сервиса")
+	}
+
+	a.flushErrors()
+	logger.Info("Сервер остановлен")
+	return nil
 }
 
-// Config содержит конфигурацию приложения
-type Config struct {
-	Port             string
-	PythonServiceURL string
-	Environment      string
+// prepareDatabase выполняет миграции, включает режим хаоса для БД и
+// подготавливает PostGIS. Возвращает, доступны ли пространственные запросы.
+func prepareDatabase(db *database.Handle, config *appconfig.Config, chaosEnabled bool, logger *logrus.Logger) (bool, error) {
+	logger.Info("Выполнение миграций базы данных...")
+	if err := db.Migrate(); err != nil {
+		return false, err
+	}
+
+	if err := db.HealthCheck(); err != nil {
+		return false, fmt.Errorf("database health check failed: %w", err)
+	}
+
+	logger.Info("База данных успешно подключена и готова к работе")
+
+	// Сбои в БД внедряются после миграций, чтобы запуск оставался детерминированным
+	if chaosEnabled && config.Chaos.DB.Active() {
+		logger.WithField("faults", config.Chaos.DB).Warn("РЕЖИМ ХАОСА: внедрение сбоев в запросы к базе данных")
+		if err := db.Use(chaos.NewPlugin(config.Chaos.DB)); err != nil {
+			return false, fmt.Errorf("failed to enable database chaos plugin: %w", err)
+		}
+	}
+
+	postgisEnabled, err := db.SetupPostGIS(config.PostGISMode)
+	if err != nil {
+		return false, fmt.Errorf("failed to setup PostGIS: %w", err)
+	}
+	return postgisEnabled, nil
 }
 
-func getConfig() *Config {
-	return &Config{
-		Port:             getEnv("SERVER_PORT", "8080"),
-		PythonServiceURL: getEnv("PYTHON_API_BASE_URL", "http://localhost:8000"),
-		Environment:      getEnv("ENVIRONMENT", "development"),
+// waitForDatabase подключается к базе данных в фоне после запуска без нее
+// и отмечает ее готовой, когда миграции выполнены. Попытки повторяются
+// без ограничения.
+func waitForDatabase(db *database.Handle, config *appconfig.Config, chaosEnabled, postgisEnabled bool, logger *logrus.Logger) {
+	retry := config.Database.Retry
+	retry.MaxAttempts = 0
+	retry.MaxWait = 0
+	for {
+		err := db.WaitForConnection(retry)
+		var available bool
+		if err == nil {
+			available, err = prepareDatabase(db, config, chaosEnabled, logger)
+		}
+		if err != nil {
+			logger.Errorf("Ошибка подготовки базы данных, повтор через %s: %v", retry.MaxDelay, err)
+			time.Sleep(retry.MaxDelay)
+			continue
+		}
+		if available && !postgisEnabled {
+			logger.Warn("PostGIS доступен, но будет использоваться только после перезапуска сервиса")
+		}
+		db.MarkReady()
+		logger.Info("База данных подключена, сервис работает в обычном режиме")
+		return
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// runEventRelay после подключения к базе данных возобновляет доставки
+// вебхуков, прерванные аварийной остановкой, и публикует события, пока не
+// отменен ctx
+func runEventRelay(ctx context.Context, db *database.Handle, webhooks *service.WebhookService, outbox *service.OutboxService, logger *logrus.Logger) {
+	if !waitDatabaseReady(ctx, db) {
+		return
+	}
+
+	resumed, err := webhooks.ResumePending()
+	if err != nil {
+		logger.Errorf("Не удалось возобновить доставки вебхуков: %v", err)
+	} else if resumed > 0 {
+		logger.Infof("Возобновлены доставки вебхуков, прерванные остановкой сервиса: %d", resumed)
+	}
+	outbox.Run(ctx)
+}
+
+// waitDatabaseReady ждет подготовки базы данных, при запуске без нее — до
+// подключения в фоне. Возвращает false, если ctx отменен раньше.
+func waitDatabaseReady(ctx context.Context, db *database.Handle) bool {
+	for !db.Ready() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(time.Second):
+		}
+	}
+	return true
+}
+
+// checkPythonCompatibility проверяет версию Python сервиса при запуске и
+// выбирает по ней формат API анализа. Неподдерживаемая версия останавливает
+// сервер. Если сервис недоступен, формат выбирается при первой успешной
+// проверке экземпляров, а в строгом режиме сервер останавливается.
+func checkPythonCompatibility(analyzerService *service.AnalyzerService, config *appconfig.Config, logger *logrus.Logger) {
+	status := analyzerService.CheckPythonVersion()
+	fields := logrus.Fields{
+		"python_version":   status.Version,
+		"supported_python": status.Supported.String(),
+		"python_contract":  status.Contract,
+	}
+
+	switch {
+	case !status.Reachable:
+		if config.StrictVersionCheck {
+			logger.WithFields(fields).Fatalf("Не удалось проверить версию Python сервиса: %s", status.Error)
+		}
+		logger.WithFields(fields).Warnf("Не удалось проверить версию Python сервиса: %s", status.Error)
+	case !status.Compatible:
+		logger.WithFields(fields).Fatalf("Версия Python сервиса %q не поддерживается этой сборкой (поддерживаются %s): %s",
+			status.Version, status.Supported, status.Error)
+	default:
+		logger.WithFields(fields).Info("Версия Python сервиса совместима")
+	}
+}
+
+// configureClientIP задает, откуда берется IP клиента для логов, журнала
+// аудита и ограничения частоты запросов. Заголовки учитываются только
+// в запросах от доверенных прокси, без них используется адрес соединения.
+func configureClientIP(router *gin.Engine, config *appconfig.Config) error {
+	router.ForwardedByClientIP = true
+	router.RemoteIPHeaders = config.ClientIP.Headers
+	router.TrustedPlatform = config.ClientIP.TrustedPlatform
+	return router.SetTrustedProxies(config.ClientIP.TrustedProxies)
+}
+
+// analysisRoutes маршруты HTTP API, принимающие видео или снимки на анализ.
+// Ключ — метод и шаблон пути gin.
+var analysisRoutes = []string{
+	"POST /api/v1/analyze",
+	"POST /api/v1/analyze/images",
+	"POST /api/v2/analyze",
+	"POST /api/v2/analyze/images",
+}
+
+// bodyLimits ограничения тел запросов: анализ принимает только форму
+// multipart, остальные запросы к API ограничены по размеру
+func bodyLimits(config *appconfig.Config) bodylimit.Options {
+	upload := bodylimit.Rule{
+		MaxBytes:     config.RequestLimits.MaxUploadBytes,
+		ContentTypes: []string{"multipart/form-data"},
+		MaxMemory:    config.RequestLimits.MultipartMemoryBytes,
+		MaxParts:     config.RequestLimits.MultipartMaxParts,
+	}
+	opts := bodylimit.Options{
+		Routes:  make(map[string]bodylimit.Rule, len(analysisRoutes)),
+		Default: bodylimit.Rule{MaxBytes: config.RequestLimits.MaxBodyBytes},
+	}
+	for _, route := range analysisRoutes {
+		opts.Routes[route] = upload
+	}
+	return opts
+}
+
+// maintenanceGate отвечает 503 с Retry-After на запросы анализа в режиме
+// обслуживания, не дожидаясь загрузки видео. Анализы по gRPC и MQTT
+// отклоняет сам AnalyzerService.
+func maintenanceGate(maintenance *service.MaintenanceService) gin.HandlerFunc {
+	routes := make(map[string]bool, len(analysisRoutes))
+	for _, route := range analysisRoutes {
+		routes[route] = true
+	}
+	return func(c *gin.Context) {
+		if !routes[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+		if maintenanceErr, ok := maintenance.Check().(*service.MaintenanceError); ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(maintenanceErr.RetryAfter.Seconds()))))
+			apierror.Abort(c, apierror.Wrap(maintenanceErr, apierror.CodeInternal, "Сервис на обслуживании"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// databaseGate отвечает 503 на запросы к API, пока база данных не подготовлена.
+// Проверки состояния и версия сервиса доступны без нее.
+func databaseGate(db *database.Handle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if db.Ready() || !(strings.HasPrefix(path, "/api/v1/") || strings.HasPrefix(path, handler.APIv2Prefix)) ||
+			path == "/api/v1/health" || strings.HasPrefix(path, "/api/v1/meta/") {
+			c.Next()
+			return
+		}
+		c.Header("Retry-After", "5")
+		apierror.Abort(c, apierror.New(apierror.CodeDatabaseUnavailable, "База данных недоступна, повторите запрос позже"))
 	}
-	return defaultValue
 }
 
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Requested-With, X-Request-ID, X-API-Key")
 		c.Header("Access-Control-Allow-Credentials", "true")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
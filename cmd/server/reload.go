@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	appconfig "road-detector-go/internal/config"
+	"road-detector-go/internal/coverageband"
+	"road-detector-go/internal/database"
+	"road-detector-go/internal/logging"
+	"road-detector-go/internal/ratelimit"
+	"road-detector-go/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// configReloader перечитывает конфигурацию по сигналу SIGHUP или при
+// изменении файла и применяет параметры, которые не требуют перезапуска.
+// Выполняющиеся запросы и анализы не прерываются.
+type configReloader struct {
+	logger   *logrus.Logger
+	limiter  *ratelimit.Limiter
+	analyzer *service.AnalyzerService
+	usage    *service.UsageService
+	queries  *database.SlowQueryLogger
+	bands    *coverageband.Classifier
+
+	mu      sync.Mutex
+	current *appconfig.Config
+}
+
+// Reload перечитывает конфигурацию. Если она не проходит проверку,
+// остается прежняя.
+func (r *configReloader) Reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := appconfig.Load(r.current.File)
+	if err != nil {
+		r.logger.Errorf("Конфигурация не перезагружена, действуют прежние значения: %v", err)
+		return
+	}
+
+	reloadable, restart := r.current.Changes(next)
+	if len(restart) > 0 {
+		r.logger.Warnf("Изменения %s вступят в силу после перезапуска сервиса", strings.Join(restart, ", "))
+	}
+	if len(reloadable) == 0 {
+		r.logger.Info("Конфигурация перечитана, применяемых без перезапуска изменений нет")
+		r.current = next
+		return
+	}
+
+	for _, key := range reloadable {
+		switch key {
+		case "LOG_LEVEL":
+			// Значение уже проверено при загрузке
+			level, _ := logging.ParseLevel(next.Logging.Level)
+			r.logger.SetLevel(level)
+		case "RATE_LIMIT_RPS", "RATE_LIMIT_BURST":
+			r.limiter.SetLimits(next.RateLimit.RPS, next.RateLimit.Burst)
+		case "PYTHON_API_BASE_URL":
+			r.analyzer.SetServiceURLs(next.PythonServices.URLs)
+		case "PYTHON_API_TIMEOUT_SECONDS":
+			r.analyzer.SetTimeout(next.PythonServiceTimeout)
+		case "ANALYZER_TIMEOUT_MIN_SECONDS", "ANALYZER_TIMEOUT_MAX_SECONDS",
+			"ANALYZER_TIMEOUT_PER_MB_SECONDS", "ANALYZER_TIMEOUT_PER_VIDEO_SECOND":
+			r.analyzer.SetTimeoutPolicy(next.AnalyzerTimeout)
+		case "QUOTA_MONTHLY_UPLOADS", "QUOTA_MONTHLY_ANALYSIS_MINUTES", "QUOTA_STORAGE_MB":
+			r.usage.SetQuotaLimits(next.Quotas)
+		case "DB_SLOW_QUERY_MS":
+			r.queries.SetThreshold(next.Database.SlowQueryThreshold)
+		case "SLOW_ANALYSIS_MINUTES":
+			r.analyzer.SetSlowAnalysisThreshold(next.SlowAnalysisThreshold)
+		case "LOW_CONFIDENCE_THRESHOLD":
+			r.analyzer.SetLowConfidenceThreshold(next.LowConfidenceThreshold)
+		case "COVERAGE_BANDS":
+			// Значение уже проверено при загрузке
+			_ = r.bands.Set(next.CoverageBands)
+		}
+	}
+	summary := next.Summary()
+	fields := logrus.Fields{}
+	for _, key := range reloadable {
+		fields[key] = summary[key]
+	}
+	r.logger.WithFields(fields).Info("Конфигурация перезагружена")
+	r.current = next
+}
+
+// Watch перезагружает конфигурацию по SIGHUP и, если задан ReloadInterval,
+// при изменении файла конфигурации, пока не отменен ctx
+func (r *configReloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	r.mu.Lock()
+	path, interval := r.current.File, r.current.ReloadInterval
+	r.mu.Unlock()
+
+	var tick <-chan time.Time
+	var modified time.Time
+	if path != "" && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+		modified = fileModTime(path)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.logger.Info("Получен SIGHUP, перезагрузка конфигурации")
+			r.Reload()
+		case <-tick:
+			if mtime := fileModTime(path); !mtime.Equal(modified) {
+				modified = mtime
+				r.logger.Infof("Файл конфигурации %s изменен, перезагрузка", path)
+				r.Reload()
+			}
+		}
+	}
+}
+
+// fileModTime время изменения файла, нулевое, если файл недоступен
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/buildinfo"
+	"road-detector-go/internal/errreport"
+	"road-detector-go/internal/handler"
+	"road-detector-go/internal/reqlog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runWorker выполняет анализы из очереди (RUN_MODE=worker). API не
+// запускается: на порту сервера доступны только проверки состояния и
+// версия, чтобы оркестратор мог следить за воркером.
+func runWorker(a *app, svc *services) error {
+	config, logger := a.config, a.logger
+	if config.GRPC.Addr != "" || config.MQTT.BrokerURL != "" || config.DirWatch.Dir != "" {
+		logger.Warn("В роли worker gRPC API, прием по MQTT и из папки не запускаются, они работают в роли api")
+	}
+
+	if config.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	router := gin.New()
+	router.Use(reqlog.Middleware(logger))
+	router.Use(errreport.Middleware(a.reporter))
+	router.Use(apierror.Middleware(logger, handler.APIv2Prefix))
+	router.Use(gin.CustomRecovery(apierror.Recover))
+	router.NoRoute(func(c *gin.Context) {
+		apierror.Abort(c, apierror.New(apierror.CodeNotFound, "Ресурс не найден"))
+	})
+	handler.NewHealthHandler(svc.healthService, logger).RegisterRoutes(router)
+	handler.NewMetaHandler(svc.analyzerService, svc.bands, logger).RegisterRoutes(router)
+	router.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Road Detector analysis worker",
+			"version":    buildinfo.Current().Version,
+			"status":     "running",
+			"deployment": config.Deployment,
+		})
+	})
+
+	server := &http.Server{
+		Addr:              config.Addr(),
+		Handler:           router,
+		ReadHeaderTimeout: config.HTTPServer.ReadHeaderTimeout,
+		IdleTimeout:       config.HTTPServer.IdleTimeout,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Infof("Воркер анализов запущен, проверки состояния на порту %s", config.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if svc.analyzerService.LocalAnalyzer() == nil {
+		go svc.analyzerService.RunInstanceChecks(ctx)
+	}
+	go runEventRelay(ctx, a.db, svc.webhookService, svc.outboxService, logger)
+	if svc.transcodeService != nil {
+		go func() {
+			if waitDatabaseReady(ctx, a.db) {
+				svc.transcodeService.Run(ctx)
+			}
+		}()
+	}
+	queueStopped := make(chan struct{})
+	go func() {
+		defer close(queueStopped)
+		if waitDatabaseReady(ctx, a.db) {
+			svc.analysisQueue.Run(ctx, svc.analyzerService)
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		logger.Fatalf("Ошибка запуска сервера: %v", err)
+	case <-ctx.Done():
+		stop()
+	}
+
+	logger.Infof("Получен сигнал остановки, ожидание завершения анализов (до %s)", config.ShutdownTimeout)
+	deadline := time.Now().Add(config.ShutdownTimeout)
+	select {
+	case <-queueStopped:
+	case <-time.After(time.Until(deadline)):
+		logger.Errorf("Не все анализы из очереди завершились за %s, их возьмут другие воркеры", config.ShutdownTimeout)
+	}
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+	}
+
+	if !svc.webhookService.Shutdown(time.Until(deadline)) {
+		logger.Warn("Не все доставки вебхуков завершились до остановки сервиса")
+	}
+	if !svc.alertService.Shutdown(time.Until(deadline)) {
+		logger.Warn("Не все оповещения отправлены по почте и в Telegram до остановки сервиса")
+	}
+	if !svc.notificationService.Shutdown(time.Until(deadline)) {
+		logger.Warn("Не все уведомления о завершенных анализах отправлены до остановки сервиса")
+	}
+
+	a.flushErrors()
+	logger.Info("Воркер остановлен")
+	return nil
+}
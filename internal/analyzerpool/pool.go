@@ -0,0 +1,316 @@
+// Package analyzerpool распределяет анализы между несколькими экземплярами
+// Python сервиса и пропускает экземпляры, которые не отвечают на /health.
+package analyzerpool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Способы выбора экземпляра
+const (
+	// StrategyLeastBusy экземпляр с наименьшим числом выполняющихся анализов,
+	// при равенстве — по кругу
+	StrategyLeastBusy = "least_busy"
+	// StrategyRoundRobin экземпляры по кругу
+	StrategyRoundRobin = "round_robin"
+)
+
+// DiscoveryDNS адреса экземпляров получаются из A и AAAA записей хостов URL
+const DiscoveryDNS = "dns"
+
+// Options настройки пула экземпляров
+type Options struct {
+	// URLs базовые адреса экземпляров
+	URLs []string
+	// Strategy способ выбора экземпляра, пусто — StrategyLeastBusy
+	Strategy string
+	// Discovery пусто — экземпляры заданы URLs, DiscoveryDNS — каждый
+	// адрес хоста из URLs считается отдельным экземпляром
+	Discovery string
+	// HealthInterval как часто проверять экземпляры и обновлять адреса из DNS
+	HealthInterval time.Duration
+}
+
+// CheckFunc проверяет экземпляр с базовым адресом baseURL
+type CheckFunc func(ctx context.Context, baseURL string) error
+
+// Instance экземпляр Python сервиса
+type Instance struct {
+	// URL базовый адрес экземпляра
+	URL string
+
+	active atomic.Int64
+
+	mu        sync.Mutex
+	healthy   bool
+	lastError string
+	checkedAt time.Time
+}
+
+// Healthy проверяет, что экземпляр прошел последнюю проверку
+func (i *Instance) Healthy() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.healthy
+}
+
+// setHealth сохраняет результат проверки экземпляра
+func (i *Instance) setHealth(err error, at time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.healthy = err == nil
+	i.lastError = ""
+	if err != nil {
+		i.lastError = err.Error()
+	}
+	i.checkedAt = at
+}
+
+// Status состояние экземпляра
+type Status struct {
+	URL       string     `json:"url"`
+	Healthy   bool       `json:"healthy"`
+	Active    int64      `json:"active"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// Pool экземпляры Python сервиса. До первой проверки экземпляры считаются
+// здоровыми.
+type Pool struct {
+	opts     Options
+	check    CheckFunc
+	resolver *net.Resolver
+
+	mu        sync.RWMutex
+	urls      []string
+	instances []*Instance
+
+	next atomic.Uint64
+}
+
+// New создает пул экземпляров из opts.URLs. Проверки и обновление адресов
+// из DNS выполняет Run.
+func New(opts Options, check CheckFunc) *Pool {
+	if opts.Strategy == "" {
+		opts.Strategy = StrategyLeastBusy
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = 10 * time.Second
+	}
+	p := &Pool{
+		opts:     opts,
+		check:    check,
+		resolver: net.DefaultResolver,
+	}
+	p.SetURLs(opts.URLs)
+	return p
+}
+
+// SetURLs заменяет адреса экземпляров. Состояние экземпляров с прежними
+// адресами сохраняется, выполняющиеся анализы не прерываются.
+func (p *Pool) SetURLs(urls []string) {
+	p.mu.Lock()
+	p.urls = slices.Clone(urls)
+	p.mu.Unlock()
+	p.setInstances(urls)
+}
+
+// setInstances заменяет экземпляры пула, сохраняя состояние прежних
+func (p *Pool) setInstances(urls []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	existing := make(map[string]*Instance, len(p.instances))
+	for _, instance := range p.instances {
+		existing[instance.URL] = instance
+	}
+	instances := make([]*Instance, 0, len(urls))
+	for _, u := range urls {
+		instance, ok := existing[u]
+		if !ok {
+			instance = &Instance{URL: u, healthy: true}
+		}
+		instances = append(instances, instance)
+		existing[u] = instance
+	}
+	p.instances = instances
+}
+
+// Acquire выбирает экземпляр для анализа, пропуская skip — экземпляры,
+// которые уже не ответили на этот анализ. Нездоровые экземпляры выбираются,
+// только если здоровых нет: состояние могло измениться с последней
+// проверки. release нужно вызвать после завершения запроса. Возвращает nil,
+// если все экземпляры в skip.
+func (p *Pool) Acquire(skip ...*Instance) (instance *Instance, release func()) {
+	p.mu.RLock()
+	instances := p.instances
+	p.mu.RUnlock()
+
+	var candidates []*Instance
+	for _, healthyOnly := range []bool{true, false} {
+		for _, i := range instances {
+			if !slices.Contains(skip, i) && (!healthyOnly || i.Healthy()) {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, func() {}
+	}
+
+	start := int(p.next.Add(1)-1) % len(candidates)
+	instance = candidates[start]
+	if p.opts.Strategy == StrategyLeastBusy {
+		for n := 1; n < len(candidates); n++ {
+			c := candidates[(start+n)%len(candidates)]
+			if c.active.Load() < instance.active.Load() {
+				instance = c
+			}
+		}
+	}
+
+	instance.active.Add(1)
+	var once sync.Once
+	return instance, func() {
+		once.Do(func() { instance.active.Add(-1) })
+	}
+}
+
+// Pick выбирает экземпляр для служебного запроса, например /health, не
+// учитывая его в числе выполняющихся анализов
+func (p *Pool) Pick() *Instance {
+	instance, release := p.Acquire()
+	release()
+	return instance
+}
+
+// MarkDown отмечает экземпляр нездоровым до следующей успешной проверки,
+// например после отказа в соединении
+func (p *Pool) MarkDown(instance *Instance, err error) {
+	instance.setHealth(err, time.Now())
+}
+
+// Run проверяет экземпляры и обновляет адреса из DNS каждые
+// HealthInterval, пока не отменен ctx
+func (p *Pool) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		if p.opts.Discovery == DiscoveryDNS {
+			p.discover(ctx)
+		}
+		p.checkAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAll проверяет все экземпляры параллельно
+func (p *Pool) checkAll(ctx context.Context) {
+	p.mu.RLock()
+	instances := p.instances
+	p.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, p.opts.HealthInterval)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, instance := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instance.setHealth(p.check(ctx, instance.URL), time.Now())
+		}()
+	}
+	wg.Wait()
+}
+
+// discover заменяет экземпляры адресами хостов из URLs. Если хост не
+// удалось разрешить, остается его URL: запрос разрешит имя сам.
+func (p *Pool) discover(ctx context.Context) {
+	p.mu.RLock()
+	urls := p.urls
+	p.mu.RUnlock()
+
+	var discovered []string
+	for _, raw := range urls {
+		resolved, err := p.resolve(ctx, raw)
+		if err != nil || len(resolved) == 0 {
+			discovered = append(discovered, raw)
+			continue
+		}
+		discovered = append(discovered, resolved...)
+	}
+	slices.Sort(discovered)
+	p.setInstances(slices.Compact(discovered))
+}
+
+// resolve возвращает URL для каждого адреса хоста raw
+func (p *Pool) resolve(ctx context.Context, raw string) ([]string, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	host, port := parsed.Hostname(), parsed.Port()
+	if net.ParseIP(host) != nil {
+		return []string{raw}, nil
+	}
+	addrs, err := p.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+
+	urls := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		u := *parsed
+		u.Host = addr
+		if port != "" {
+			u.Host = net.JoinHostPort(addr, port)
+		} else if net.ParseIP(addr).To4() == nil {
+			u.Host = "[" + addr + "]"
+		}
+		urls = append(urls, u.String())
+	}
+	return urls, nil
+}
+
+// Status возвращает состояние экземпляров
+func (p *Pool) Status() []Status {
+	p.mu.RLock()
+	instances := p.instances
+	p.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(instances))
+	for _, instance := range instances {
+		instance.mu.Lock()
+		status := Status{
+			URL:     instance.URL,
+			Healthy: instance.healthy,
+			Active:  instance.active.Load(),
+			Error:   instance.lastError,
+		}
+		if !instance.checkedAt.IsZero() {
+			checkedAt := instance.checkedAt
+			status.CheckedAt = &checkedAt
+		}
+		instance.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,181 @@
+// Package apierror описывает машиночитаемые ошибки API: коды, HTTP статусы
+// и единый формат тела ответа с ID запроса. API v2 отвечает на ошибки в
+// формате RFC 7807 (application/problem+json).
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Code машиночитаемый код ошибки API
+type Code string
+
+// Коды ошибок API
+const (
+	CodeInvalidRequest         Code = "INVALID_REQUEST"
+	CodeInvalidCoordinates     Code = "INVALID_COORDINATES"
+	CodeInvalidArea            Code = "INVALID_AREA"
+	CodeInvalidTag             Code = "INVALID_TAG"
+	CodeInvalidCursor          Code = "INVALID_CURSOR"
+	CodePayloadTooLarge        Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType   Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeUnauthorized           Code = "UNAUTHORIZED"
+	CodeInvalidCredentials     Code = "INVALID_CREDENTIALS"
+	CodeForbidden              Code = "FORBIDDEN"
+	CodeNotFound               Code = "NOT_FOUND"
+	CodeRouteNotFound          Code = "ROUTE_NOT_FOUND"
+	CodeSegmentNotFound        Code = "SEGMENT_NOT_FOUND"
+	CodeRoadNotFound           Code = "ROAD_NOT_FOUND"
+	CodeRouteExists            Code = "ROUTE_EXISTS"
+	CodeTagNotFound            Code = "TAG_NOT_FOUND"
+	CodeVideoNotFound          Code = "VIDEO_NOT_FOUND"
+	CodeAPIKeyNotFound         Code = "API_KEY_NOT_FOUND"
+	CodeTagExists              Code = "TAG_EXISTS"
+	CodeUserExists             Code = "USER_EXISTS"
+	CodeOrganizationNotFound   Code = "ORGANIZATION_NOT_FOUND"
+	CodeOrganizationExists     Code = "ORGANIZATION_EXISTS"
+	CodeWebhookNotFound        Code = "WEBHOOK_NOT_FOUND"
+	CodeAlertRuleNotFound      Code = "ALERT_RULE_NOT_FOUND"
+	CodeAlertNotFound          Code = "ALERT_NOT_FOUND"
+	CodeReportScheduleNotFound Code = "REPORT_SCHEDULE_NOT_FOUND"
+	CodeReportNotFound         Code = "REPORT_NOT_FOUND"
+	CodeBoundaryNotFound       Code = "BOUNDARY_NOT_FOUND"
+	CodeShareLinkNotFound      Code = "SHARE_LINK_NOT_FOUND"
+	CodeRateLimited            Code = "RATE_LIMITED"
+	CodeQuotaExceeded          Code = "QUOTA_EXCEEDED"
+	CodeStorageQuotaExceeded   Code = "STORAGE_QUOTA_EXCEEDED"
+	CodeAnalyzerRejected       Code = "ANALYZER_REJECTED"
+	CodeAnalyzerBadResponse    Code = "ANALYZER_BAD_RESPONSE"
+	CodeAnalyzerUnavailable    Code = "ANALYZER_UNAVAILABLE"
+	CodeAuthUnavailable        Code = "AUTH_PROVIDER_UNAVAILABLE"
+	CodeDatabaseUnavailable    Code = "DATABASE_UNAVAILABLE"
+	CodeMaintenance            Code = "MAINTENANCE"
+	CodeDependenciesUnhealthy  Code = "DEPENDENCIES_UNHEALTHY"
+	CodeAnalysisPending        Code = "ANALYSIS_PENDING"
+	CodeInternal               Code = "INTERNAL"
+)
+
+// statuses HTTP статусы кодов ошибок
+var statuses = map[Code]int{
+	CodeInvalidRequest:         http.StatusBadRequest,
+	CodeInvalidCoordinates:     http.StatusBadRequest,
+	CodeInvalidArea:            http.StatusBadRequest,
+	CodeInvalidTag:             http.StatusBadRequest,
+	CodeInvalidCursor:          http.StatusBadRequest,
+	CodePayloadTooLarge:        http.StatusRequestEntityTooLarge,
+	CodeUnsupportedMediaType:   http.StatusUnsupportedMediaType,
+	CodeUnauthorized:           http.StatusUnauthorized,
+	CodeInvalidCredentials:     http.StatusUnauthorized,
+	CodeForbidden:              http.StatusForbidden,
+	CodeNotFound:               http.StatusNotFound,
+	CodeRouteNotFound:          http.StatusNotFound,
+	CodeSegmentNotFound:        http.StatusNotFound,
+	CodeRoadNotFound:           http.StatusNotFound,
+	CodeRouteExists:            http.StatusConflict,
+	CodeTagNotFound:            http.StatusNotFound,
+	CodeVideoNotFound:          http.StatusNotFound,
+	CodeAPIKeyNotFound:         http.StatusNotFound,
+	CodeTagExists:              http.StatusConflict,
+	CodeUserExists:             http.StatusConflict,
+	CodeOrganizationNotFound:   http.StatusNotFound,
+	CodeOrganizationExists:     http.StatusConflict,
+	CodeWebhookNotFound:        http.StatusNotFound,
+	CodeAlertRuleNotFound:      http.StatusNotFound,
+	CodeAlertNotFound:          http.StatusNotFound,
+	CodeReportScheduleNotFound: http.StatusNotFound,
+	CodeReportNotFound:         http.StatusNotFound,
+	CodeBoundaryNotFound:       http.StatusNotFound,
+	CodeShareLinkNotFound:      http.StatusNotFound,
+	CodeRateLimited:            http.StatusTooManyRequests,
+	CodeQuotaExceeded:          http.StatusTooManyRequests,
+	CodeStorageQuotaExceeded:   http.StatusRequestEntityTooLarge,
+	CodeAnalyzerRejected:       http.StatusUnprocessableEntity,
+	CodeAnalyzerBadResponse:    http.StatusBadGateway,
+	CodeAnalyzerUnavailable:    http.StatusServiceUnavailable,
+	CodeAuthUnavailable:        http.StatusServiceUnavailable,
+	CodeDatabaseUnavailable:    http.StatusServiceUnavailable,
+	CodeMaintenance:            http.StatusServiceUnavailable,
+	CodeDependenciesUnhealthy:  http.StatusServiceUnavailable,
+	CodeAnalysisPending:        http.StatusGatewayTimeout,
+	CodeInternal:               http.StatusInternalServerError,
+}
+
+// Status возвращает HTTP статус кода, для неизвестного кода — 500
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error ошибка API с кодом и сообщением для клиента
+type Error struct {
+	Code Code
+	// Message сообщение для клиента
+	Message string
+	// Err исходная ошибка, в ответ не попадает
+	Err error
+	// InvalidParams параметры запроса, не прошедшие проверку
+	InvalidParams []InvalidParam
+}
+
+// InvalidParam параметр запроса, не прошедший проверку
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// New создает ошибку API
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap создает ошибку API с исходной ошибкой err. Если err содержит известную
+// ошибку предметной области, а code равен CodeInternal, клиент получит код
+// этой ошибки вместо внутренней.
+func Wrap(err error, code Code, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// Invalid создает ошибку INVALID_REQUEST с перечнем неверных параметров
+func Invalid(message string, params ...InvalidParam) *Error {
+	return &Error{Code: CodeInvalidRequest, Message: message, InvalidParams: params}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Body тело ответа с ошибкой
+type Body struct {
+	// Error сообщение для человека
+	Error     string `json:"error"`
+	Code      Code   `json:"code"`
+	RequestID string `json:"request_id"`
+}
+
+// ProblemContentType тип содержимого ответа с ошибкой в формате RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// Problem тело ответа с ошибкой в формате RFC 7807. Вид ошибки задает
+// Code, поэтому Type всегда about:blank, а Title — текст HTTP статуса.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Detail сообщение для человека
+	Detail string `json:"detail"`
+	// Instance путь запроса
+	Instance      string         `json:"instance"`
+	Code          Code           `json:"code"`
+	RequestID     string         `json:"request_id"`
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
@@ -0,0 +1,117 @@
+package apierror
+
+import (
+	"errors"
+
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/imageseq"
+	"road-detector-go/internal/oidc"
+	"road-detector-go/internal/report"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+)
+
+// domainError сопоставление ошибки предметной области с кодом API
+type domainError struct {
+	target  error
+	code    Code
+	message string
+	// detail добавляет текст исходной ошибки к сообщению
+	detail bool
+}
+
+// domainErrors ошибки предметной области, которые не являются внутренними
+var domainErrors = []domainError{
+	{repository.ErrRouteNotFound, CodeRouteNotFound, "Маршрут не найден", false},
+	{repository.ErrSegmentNotFound, CodeSegmentNotFound, "Сегмент не найден", false},
+	{repository.ErrRoadNotFound, CodeRoadNotFound, "Дорога не найдена", false},
+	{repository.ErrTagNotFound, CodeTagNotFound, "Метка не найдена", false},
+	{repository.ErrTagExists, CodeTagExists, "Метка с таким названием уже существует", false},
+	{repository.ErrAPIKeyNotFound, CodeAPIKeyNotFound, "Ключ API не найден или уже отозван", false},
+	{service.ErrInvalidAPIKeyRequest, CodeInvalidRequest, "Некорректные данные ключа API", true},
+	{repository.ErrUserExists, CodeUserExists, "Пользователь с таким email уже зарегистрирован", false},
+	{service.ErrInvalidUserRequest, CodeInvalidRequest, "Некорректные данные пользователя", true},
+	{service.ErrInvalidCredentials, CodeInvalidCredentials, "Неверный email или пароль", false},
+	{service.ErrRegistrationDisabled, CodeForbidden, "Регистрация пользователей отключена", false},
+	{repository.ErrUserNotFound, CodeNotFound, "Пользователь не найден", false},
+	{repository.ErrOrganizationNotFound, CodeOrganizationNotFound, "Организация не найдена", false},
+	{repository.ErrOrganizationExists, CodeOrganizationExists, "Организация с таким slug уже существует", false},
+	{repository.ErrMemberNotFound, CodeNotFound, "Пользователь не состоит в организации", false},
+	{service.ErrInvalidOrganizationRequest, CodeInvalidRequest, "Некорректные данные организации", true},
+	{service.ErrNotOrganizationMember, CodeForbidden, "Нет доступа к организации", false},
+	{repository.ErrWebhookNotFound, CodeWebhookNotFound, "Вебхук не найден", false},
+	{service.ErrInvalidWebhookRequest, CodeInvalidRequest, "Некорректные данные вебхука", true},
+	{repository.ErrAlertRuleNotFound, CodeAlertRuleNotFound, "Правило оповещений не найдено", false},
+	{repository.ErrAlertNotFound, CodeAlertNotFound, "Оповещение не найдено", false},
+	{service.ErrInvalidAlertRule, CodeInvalidRequest, "Некорректные данные правила оповещений", true},
+	{repository.ErrReportScheduleNotFound, CodeReportScheduleNotFound, "Расписание отчетов не найдено", false},
+	{repository.ErrReportNotFound, CodeReportNotFound, "Отчет не найден", false},
+	{service.ErrInvalidReportSchedule, CodeInvalidRequest, "Некорректные данные расписания отчетов", true},
+	{service.ErrInvalidNotificationPreferences, CodeInvalidRequest, "Некорректные настройки уведомлений", true},
+	{repository.ErrBoundaryNotFound, CodeBoundaryNotFound, "Граница не найдена", false},
+	{service.ErrInvalidBoundary, CodeInvalidRequest, "Некорректные данные границы", true},
+	{report.ErrPDFDisabled, CodeInvalidRequest, "Отчеты в PDF не настроены на сервере", false},
+	{service.ErrImageSequencesDisabled, CodeInvalidRequest, "Анализ снимков не настроен на сервере", false},
+	{service.ErrHLSDisabled, CodeInvalidRequest, "Воспроизведение видео по HLS не настроено на сервере", false},
+	{service.ErrVideoNotFound, CodeVideoNotFound, "Видео маршрута не найдено", false},
+	{imageseq.ErrInvalidArchive, CodeInvalidRequest, "Некорректный архив снимков", true},
+	{repository.ErrShareLinkNotFound, CodeShareLinkNotFound, "Ссылка не найдена, отозвана или просрочена", false},
+	{service.ErrInvalidShareRequest, CodeInvalidRequest, "Некорректные данные ссылки", true},
+	{service.ErrPasswordLoginDisabled, CodeForbidden, "Вход по паролю отключен, используйте вход через OIDC провайдера", false},
+	{oidc.ErrProviderUnavailable, CodeAuthUnavailable, "Сервис входа недоступен", false},
+	{debugcapture.ErrBundleNotFound, CodeNotFound, "Отладочный пакет не найден", false},
+	{service.ErrInvalidRouteMetadata, CodeInvalidRequest, "Некорректные данные маршрута", true},
+	{service.ErrInvalidRouteID, CodeInvalidRequest, "Некорректный ID маршрута", true},
+	{service.ErrRouteExists, CodeRouteExists, "Маршрут с таким ID уже существует, передайте overwrite=true, чтобы заменить его анализ", false},
+	{service.ErrInvalidAnalysisParams, CodeInvalidRequest, "Некорректные параметры анализа", true},
+	{service.ErrInvalidTag, CodeInvalidTag, "Неверная метка", true},
+	{service.ErrInvalidBulkRequest, CodeInvalidRequest, "Некорректный запрос", true},
+	{service.ErrInvalidSplit, CodeInvalidRequest, "Нельзя разделить маршрут", true},
+	{service.ErrInvalidDiff, CodeInvalidRequest, "Нельзя сравнить маршруты", true},
+	{service.ErrNoPreviousAnalysis, CodeNotFound, "Нет более раннего анализа этого участка", false},
+	{service.ErrInvalidTrendQuery, CodeInvalidRequest, "Неверные параметры ряда покрытия", true},
+	{service.ErrInvalidCursor, CodeInvalidCursor, "Неверный курсор", false},
+	{service.ErrInvalidHistogramQuery, CodeInvalidRequest, "Неверные параметры гистограммы покрытия", true},
+	{service.ErrHeatmapTooLarge, CodeInvalidArea, "Слишком много ячеек для указанной области, увеличьте cell", false},
+	{geo.ErrInvalidBoundingBox, CodeInvalidArea, "Неверная область", true},
+	{geo.ErrInvalidPolygon, CodeInvalidArea, "Неверный GeoJSON полигон", true},
+	{service.ErrQuotaExceeded, CodeQuotaExceeded, "Месячная квота исчерпана", true},
+	{service.ErrStorageQuotaExceeded, CodeStorageQuotaExceeded, "Видео не помещается в квоту хранилища, удалите ненужные маршруты", true},
+	{service.ErrMaintenance, CodeMaintenance, "Сервис на обслуживании, новые анализы временно не принимаются", false},
+	{service.ErrDependenciesUnhealthy, CodeDependenciesUnhealthy, "Проверка готовности не проходит", true},
+	{service.ErrAnalyzerRejected, CodeAnalyzerRejected, "Сервис анализа отклонил видео", true},
+	{service.ErrAnalyzerBadResponse, CodeAnalyzerBadResponse, "Некорректный ответ сервиса анализа", false},
+	{service.ErrAnalyzerUnavailable, CodeAnalyzerUnavailable, "Сервис анализа недоступен", false},
+	{service.ErrUnsavedResultNotFound, CodeNotFound, "Несохраненный результат не найден", false},
+	{service.ErrAnalysisPending, CodeAnalysisPending, "Анализ не завершился за время ожидания и продолжается", true},
+}
+
+// Resolve определяет код и сообщение ошибки для клиента. Явно указанный код
+// имеет приоритет, внутренняя ошибка уточняется по ошибкам предметной области.
+func Resolve(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.Code != CodeInternal {
+		return apiErr
+	}
+
+	cause := err
+	if apiErr != nil && apiErr.Err != nil {
+		cause = apiErr.Err
+	}
+	for _, domain := range domainErrors {
+		if !errors.Is(cause, domain.target) {
+			continue
+		}
+		message := domain.message
+		if domain.detail {
+			message += ": " + cause.Error()
+		}
+		return Wrap(cause, domain.code, message)
+	}
+
+	if apiErr != nil {
+		return apiErr
+	}
+	return Wrap(err, CodeInternal, "Внутренняя ошибка сервера")
+}
@@ -0,0 +1,139 @@
+package apierror
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader заголовок с ID запроса
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey ключ ID запроса в контексте gin
+const requestIDKey = "request_id"
+
+// requestIDPattern допустимый ID запроса, переданный клиентом или прокси
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Middleware присваивает запросу ID и отправляет ответ для ошибки,
+// добавленной обработчиком через Abort. ID берется из заголовка X-Request-ID,
+// если он допустим, иначе генерируется, и возвращается в том же заголовке.
+// На запросы к путям с префиксами problemPrefixes ошибка отправляется в
+// формате RFC 7807.
+func Middleware(logger *logrus.Logger, problemPrefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := AssignRequestID(c)
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		apiErr := Resolve(c.Errors.Last().Err)
+		status := apiErr.Code.Status()
+		if status >= 500 {
+			entry := logger.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"method":     c.Request.Method,
+				"path":       c.Request.URL.Path,
+				"client_ip":  c.ClientIP(),
+				"code":       apiErr.Code,
+			})
+			if apiErr.Err != nil {
+				entry.Errorf("%s: %v", apiErr.Message, apiErr.Err)
+			} else {
+				entry.Error(apiErr.Message)
+			}
+		}
+
+		if hasPrefix(c.Request.URL.Path, problemPrefixes) {
+			body, err := json.Marshal(Problem{
+				Type:          "about:blank",
+				Title:         http.StatusText(status),
+				Status:        status,
+				Detail:        apiErr.Message,
+				Instance:      c.Request.URL.Path,
+				Code:          apiErr.Code,
+				RequestID:     requestID,
+				InvalidParams: apiErr.InvalidParams,
+			})
+			if err == nil {
+				c.Data(status, ProblemContentType, body)
+				return
+			}
+		}
+		c.JSON(status, Body{
+			Error:     apiErr.Message,
+			Code:      apiErr.Code,
+			RequestID: requestID,
+		})
+	}
+}
+
+// hasPrefix проверяет, начинается ли путь с одного из префиксов
+func hasPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Abort прерывает обработку запроса с ошибкой err. Ответ отправляет Middleware.
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// PanicError паника обработчика вместе со стеком горутины в момент паники
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// StackTrace возвращает стек горутины в момент паники
+func (e *PanicError) StackTrace() []byte {
+	return e.Stack
+}
+
+// Recover отвечает внутренней ошибкой на панику обработчика, используется
+// с gin.CustomRecovery. Вызывается до раскрутки стека, поэтому стек
+// указывает на место паники.
+func Recover(c *gin.Context, recovered interface{}) {
+	err := &PanicError{Value: recovered, Stack: debug.Stack()}
+	Abort(c, Wrap(err, CodeInternal, "Внутренняя ошибка сервера"))
+}
+
+// AssignRequestID присваивает запросу ID, если он еще не присвоен, и возвращает его.
+// ID берется из заголовка X-Request-ID, если он допустим, иначе генерируется.
+func AssignRequestID(c *gin.Context) string {
+	if requestID := c.GetString(requestIDKey); requestID != "" {
+		return requestID
+	}
+
+	requestID := c.GetHeader(RequestIDHeader)
+	if !requestIDPattern.MatchString(requestID) {
+		requestID = uuid.NewString()
+	}
+	c.Set(requestIDKey, requestID)
+	c.Header(RequestIDHeader, requestID)
+	return requestID
+}
+
+// RequestID возвращает ID текущего запроса
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
@@ -0,0 +1,153 @@
+// Package audit записывает изменяющие операции API в журнал аудита.
+package audit
+
+import (
+	"fmt"
+	"net/http"
+
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// routeIDContextKey ключ ID маршрута операции в контексте gin
+	routeIDContextKey = "audit_route_id"
+	// targetContextKey ключ объекта операции в контексте gin
+	targetContextKey = "audit_target"
+	// summaryContextKey ключ описания операции в контексте gin
+	summaryContextKey = "audit_summary"
+)
+
+// operation операция журнала и вид объекта, который передается параметром :id
+type operation struct {
+	action   string
+	resource string
+}
+
+// operations изменяющие запросы, которые записываются в журнал.
+// Ключ — метод и шаблон пути gin.
+var operations = map[string]operation{
+	"POST /api/v1/analyze":                             {"analysis.submit", ""},
+	"POST /api/v1/analyze/images":                      {"analysis.submit", ""},
+	"PATCH /api/v1/routes/:id":                         {"route.update", "route"},
+	"DELETE /api/v1/routes/:id":                        {"route.delete", "route"},
+	"POST /api/v1/routes/:id/restore":                  {"route.restore", "route"},
+	"POST /api/v1/routes/:id/unarchive":                {"route.unarchive", "route"},
+	"POST /api/v1/routes/bulk":                         {"route.bulk", ""},
+	"POST /api/v1/routes/:id/clone":                    {"route.clone", "route"},
+	"POST /api/v1/routes/:id/split":                    {"route.split", "route"},
+	"PUT /api/v1/routes/:id/tags":                      {"route.tags", "route"},
+	"POST /api/v1/routes/:id/share":                    {"route.share", "route"},
+	"DELETE /api/v1/routes/:id/share/:shareId":         {"route.share_revoke", "route"},
+	"POST /api/v1/tags":                                {"tag.create", ""},
+	"PATCH /api/v1/tags/:id":                           {"tag.rename", "tag"},
+	"DELETE /api/v1/tags/:id":                          {"tag.delete", "tag"},
+	"POST /api/v1/roads/rebuild":                       {"road.rebuild", ""},
+	"POST /api/v1/admin/api-keys":                      {"api_key.create", ""},
+	"DELETE /api/v1/admin/api-keys/:id":                {"api_key.revoke", "api_key"},
+	"POST /api/v1/admin/organizations":                 {"organization.create", ""},
+	"POST /api/v1/organizations/:id/members":           {"organization.member_add", "organization"},
+	"DELETE /api/v1/organizations/:id/members/:userId": {"organization.member_remove", "organization"},
+	"POST /api/v1/webhooks":                            {"webhook.create", ""},
+	"PATCH /api/v1/webhooks/:id":                       {"webhook.update", "webhook"},
+	"DELETE /api/v1/webhooks/:id":                      {"webhook.delete", "webhook"},
+	"POST /api/v1/alert-rules":                         {"alert_rule.create", ""},
+	"PATCH /api/v1/alert-rules/:id":                    {"alert_rule.update", "alert_rule"},
+	"DELETE /api/v1/alert-rules/:id":                   {"alert_rule.delete", "alert_rule"},
+	"POST /api/v1/alerts/:id/acknowledge":              {"alert.acknowledge", "alert"},
+	"DELETE /api/v1/alerts/:id":                        {"alert.delete", "alert"},
+	"POST /api/v1/report-schedules":                    {"report_schedule.create", ""},
+	"PATCH /api/v1/report-schedules/:id":               {"report_schedule.update", "report_schedule"},
+	"DELETE /api/v1/report-schedules/:id":              {"report_schedule.delete", "report_schedule"},
+	"POST /api/v1/report-schedules/:id/run":            {"report_schedule.run", "report_schedule"},
+	"PUT /api/v1/notifications/preferences":            {"notification_preferences.update", ""},
+	"POST /api/v1/boundaries":                          {"boundary.create", ""},
+	"DELETE /api/v1/boundaries/:id":                    {"boundary.delete", "boundary"},
+	"PUT /api/v1/admin/log-level":                      {"admin.log_level", ""},
+	"PUT /api/v1/admin/coverage-bands":                 {"admin.coverage_bands", ""},
+	"POST /api/v1/admin/maintenance":                   {"admin.maintenance", ""},
+	"POST /api/v1/admin/selftest":                      {"selftest.run", ""},
+	"POST /api/v1/admin/unsaved-results/:id/replay":    {"unsaved_result.replay", ""},
+	"POST /api/v1/auth/register":                       {"user.register", ""},
+	"POST /api/v2/analyze":                             {"analysis.submit", ""},
+	"POST /api/v2/analyze/images":                      {"analysis.submit", ""},
+	"PATCH /api/v2/routes/:id":                         {"route.update", "route"},
+	"DELETE /api/v2/routes/:id":                        {"route.delete", "route"},
+}
+
+// Middleware записывает в журнал успешно выполненные изменяющие операции:
+// кто, с какого IP и над каким маршрутом или объектом их выполнил.
+// Подключается после проверки доступа, чтобы знать инициатора операции.
+func Middleware(auditService *service.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, ok := operations[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusBadRequest {
+			return
+		}
+
+		entry := &model.AuditEntry{
+			Action:         op.action,
+			OrganizationID: auth.OrganizationID(c),
+			IP:             c.ClientIP(),
+			Method:         c.Request.Method,
+			Path:           c.Request.URL.Path,
+			Status:         status,
+			RouteID:        c.GetString(routeIDContextKey),
+			Target:         c.GetString(targetContextKey),
+			Summary:        c.GetString(summaryContextKey),
+		}
+		setActor(c, entry)
+		switch {
+		case op.resource == "route" && entry.RouteID == "":
+			entry.RouteID = c.Param("id")
+		case op.resource != "" && op.resource != "route" && entry.Target == "":
+			entry.Target = op.resource + ":" + c.Param("id")
+		}
+
+		auditService.Record(entry)
+	}
+}
+
+// SetRouteID задает маршрут операции, если он не передан в пути запроса
+func SetRouteID(c *gin.Context, routeID string) {
+	c.Set(routeIDContextKey, routeID)
+}
+
+// SetTarget задает объект операции, например созданный ключ API
+func SetTarget(c *gin.Context, resource string, id interface{}) {
+	c.Set(targetContextKey, fmt.Sprintf("%s:%v", resource, id))
+}
+
+// SetSummary задает краткое описание переданных в операции данных
+func SetSummary(c *gin.Context, format string, args ...interface{}) {
+	c.Set(summaryContextKey, fmt.Sprintf(format, args...))
+}
+
+// setActor заполняет инициатора операции: пользователя, ключ API или
+// анонимного клиента, если проверка доступа отключена
+func setActor(c *gin.Context, entry *model.AuditEntry) {
+	if user := auth.CurrentUser(c); user != nil {
+		entry.ActorType = model.AuditActorUser
+		entry.ActorID = auth.UserID(c)
+		entry.ActorName = user.Email
+		return
+	}
+	if key := auth.CurrentAPIKey(c); key != nil {
+		entry.ActorType = model.AuditActorAPIKey
+		entry.ActorID = auth.APIKeyID(c)
+		entry.ActorName = key.Name
+		return
+	}
+	entry.ActorType = model.AuditActorAnonymous
+}
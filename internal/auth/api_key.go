@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"road-detector-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader заголовок с ключом API
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyContextKey ключ данных ключа API в контексте gin
+const apiKeyContextKey = "api_key"
+
+// CurrentAPIKey возвращает ключ API текущего запроса или nil, если проверка
+// ключей отключена или путь не требует ключа
+func CurrentAPIKey(c *gin.Context) *model.APIKey {
+	if value, ok := c.Get(apiKeyContextKey); ok {
+		if key, ok := value.(*model.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// APIKeyID возвращает ID ключа API текущего запроса для сохранения вместе
+// с созданными данными. Для ключа администратора из конфигурации возвращает nil.
+func APIKeyID(c *gin.Context) *uint {
+	key := CurrentAPIKey(c)
+	if key == nil || key.ID == 0 {
+		return nil
+	}
+	id := key.ID
+	return &id
+}
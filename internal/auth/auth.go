@@ -0,0 +1,269 @@
+// Package auth проверяет доступ к API.
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminPrefix пути, доступные только администраторам
+const adminPrefix = "/api/v1/admin"
+
+// apiPrefixes пути, доступ к которым проверяется
+var apiPrefixes = []string{"/api/v1", "/api/v2"}
+
+// publicUserPaths пути регистрации и входа, доступные без токена
+var publicUserPaths = []string{"/api/v1/auth/register", "/api/v1/auth/login"}
+
+// publicSharePaths пути публичных ссылок на маршруты. Доступ к ним дает
+// сам токен ссылки, поэтому они всегда открыты.
+var publicSharePaths = []string{"/api/v1/shared/*"}
+
+// Options настройки проверки доступа
+type Options struct {
+	// APIKeys проверяет ключи из заголовка X-API-Key, nil — ключи не принимаются
+	APIKeys *service.APIKeyService
+	// Users проверяет токены пользователей из заголовка Authorization,
+	// nil — токены не принимаются
+	Users *service.UserService
+	// Organizations определяет организацию запроса по заголовку X-Organization-ID
+	// и членству пользователя, nil — организации учитываются только у ключей API
+	Organizations *service.OrganizationService
+	// Exempt пути, доступные без проверки. Путь, оканчивающийся на *, задает префикс.
+	Exempt []string
+}
+
+// Middleware требует для запросов к /api/v1 и /api/v2 токен пользователя
+// в заголовке Authorization: Bearer или ключ API в заголовке X-API-Key и
+// определяет организацию запроса. Запросы к /api/v1/admin доступны только
+// администраторам и ключам администратора, не ограниченным организацией.
+func Middleware(opts Options) gin.HandlerFunc {
+	exempt := append(append([]string(nil), opts.Exempt...), publicSharePaths...)
+	if opts.Users != nil {
+		exempt = append(exempt, publicUserPaths...)
+	}
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if !underAPI(path) || pathExempt(path, exempt) {
+			c.Next()
+			return
+		}
+
+		token, apiKey, organization := credentials(c)
+		identity, err := identify(opts, token, apiKey)
+		if err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		if identity.User != nil {
+			c.Set(userContextKey, identity.User)
+		}
+		if identity.APIKey != nil {
+			c.Set(apiKeyContextKey, identity.APIKey)
+		}
+		c.Set(adminContextKey, identity.Admin)
+
+		if underPrefix(path, adminPrefix) && !identity.Admin {
+			apierror.Abort(c, apierror.New(apierror.CodeForbidden, "Требуются права администратора"))
+			return
+		}
+
+		tenant, err := resolveTenant(opts.Organizations, organization, identity.User, identity.APIKey, identity.Admin)
+		if err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		if tenant != nil {
+			c.Set(tenantContextKey, tenant)
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAdmin допускает к обработчику только администраторов и ключи
+// администратора без организации. Запросы без пользователя и ключа API
+// отклоняются, даже если проверка доступа отключена.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CurrentUser(c) == nil && CurrentAPIKey(c) == nil {
+			apierror.Abort(c, apierror.New(apierror.CodeUnauthorized, "Требуется авторизация администратора"))
+			return
+		}
+		if !IsAdmin(c) {
+			apierror.Abort(c, apierror.New(apierror.CodeForbidden, "Требуются права администратора"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// Identity инициатор запроса: пользователь или ключ API и организация, от
+// имени которой выполняется запрос
+type Identity struct {
+	User   *service.UserIdentity
+	APIKey *model.APIKey
+	// Admin администратор или ключ администратора без организации
+	Admin bool
+	// Tenant организация запроса, nil — запрос не от имени организации
+	Tenant *service.Tenant
+}
+
+// RouteScope возвращает область маршрутов, доступных инициатору, так же
+// как RouteScope для запросов HTTP
+func (i *Identity) RouteScope() repository.RouteScope {
+	return routeScope(i.OrganizationID(), i.User, i.APIKey)
+}
+
+// OrganizationID возвращает ID организации запроса или nil
+func (i *Identity) OrganizationID() *uint {
+	if i.Tenant == nil {
+		return nil
+	}
+	id := i.Tenant.OrganizationID
+	return &id
+}
+
+// UserID возвращает ID пользователя или nil для ключа API
+func (i *Identity) UserID() *uint {
+	if i.User == nil {
+		return nil
+	}
+	id := i.User.ID
+	return &id
+}
+
+// APIKeyID возвращает ID ключа API или nil для пользователя и ключа
+// администратора из настроек
+func (i *Identity) APIKeyID() *uint {
+	if i.APIKey == nil || i.APIKey.ID == 0 {
+		return nil
+	}
+	id := i.APIKey.ID
+	return &id
+}
+
+// Authenticate проверяет токен пользователя из значения заголовка
+// authorization или ключ API apiKey и определяет организацию запроса по ее
+// ID organization (пусто — не задана) по тем же правилам, что и Middleware.
+// Используется для запросов не через HTTP API. Ошибки возвращаются как
+// *apierror.Error.
+func Authenticate(opts Options, authorization, apiKey, organization string) (*Identity, error) {
+	identity, err := identify(opts, bearerToken(authorization), apiKey)
+	if err != nil {
+		return nil, err
+	}
+	identity.Tenant, err = resolveTenant(opts.Organizations, organization, identity.User, identity.APIKey, identity.Admin)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// identify проверяет токен пользователя или, если его нет, ключ API
+func identify(opts Options, token, apiKey string) (*Identity, error) {
+	switch {
+	case opts.Users != nil && token != "":
+		user, err := opts.Users.Authenticate(token)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidToken) {
+				return nil, apierror.Wrap(err, apierror.CodeUnauthorized, "Токен недействителен или просрочен")
+			}
+			return nil, apierror.Wrap(err, apierror.CodeInternal, "Ошибка проверки токена")
+		}
+		return &Identity{User: user, Admin: user.IsAdmin()}, nil
+	case opts.APIKeys != nil && apiKey != "":
+		key, err := opts.APIKeys.Authenticate(apiKey)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				return nil, apierror.New(apierror.CodeUnauthorized, "Ключ API недействителен")
+			}
+			return nil, apierror.Wrap(err, apierror.CodeInternal, "Ошибка проверки ключа API")
+		}
+		return &Identity{APIKey: key, Admin: key.Admin && key.OrganizationID == nil}, nil
+	default:
+		return nil, apierror.New(apierror.CodeUnauthorized, missingCredentialsMessage(opts))
+	}
+}
+
+// credentials возвращает токен пользователя, ключ API и организацию запроса
+// из заголовков. Браузер не может задать заголовки при подключении WebSocket,
+// поэтому для него они принимаются также из параметров access_token, api_key
+// и organization_id.
+func credentials(c *gin.Context) (token, apiKey, organization string) {
+	token = bearerToken(c.GetHeader("Authorization"))
+	apiKey = c.GetHeader(APIKeyHeader)
+	organization = c.GetHeader(OrganizationHeader)
+	if !strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		return token, apiKey, organization
+	}
+	if token == "" && apiKey == "" {
+		token = c.Query("access_token")
+		apiKey = c.Query("api_key")
+	}
+	if organization == "" {
+		organization = c.Query("organization_id")
+	}
+	return token, apiKey, organization
+}
+
+// bearerToken возвращает токен из значения заголовка Authorization: Bearer
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// missingCredentialsMessage сообщение об отсутствии данных для входа
+// с учетом включенных способов проверки
+func missingCredentialsMessage(opts Options) string {
+	switch {
+	case opts.Users != nil && opts.APIKeys != nil:
+		return "Требуется токен пользователя или ключ API"
+	case opts.Users != nil:
+		return "Требуется токен пользователя"
+	default:
+		return "Ключ API не указан"
+	}
+}
+
+// underPrefix проверяет, что путь равен prefix или вложен в него
+func underPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// underAPI проверяет, относится ли путь к одной из версий API
+func underAPI(path string) bool {
+	for _, prefix := range apiPrefixes {
+		if underPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathExempt проверяет, входит ли путь в список исключений
+func pathExempt(path string, exempt []string) bool {
+	for _, pattern := range exempt {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+			continue
+		}
+		if path == pattern {
+			return true
+		}
+	}
+	return false
+}
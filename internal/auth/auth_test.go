@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeAPIKeyRepository ключи API по значению ключа
+type fakeAPIKeyRepository struct {
+	repository.APIKeyRepository
+	keys map[string]*model.APIKey
+}
+
+func (r fakeAPIKeyRepository) GetByHash(hash string) (*model.APIKey, error) {
+	for raw, key := range r.keys {
+		sum := sha256.Sum256([]byte(raw))
+		if hex.EncodeToString(sum[:]) == hash {
+			return key, nil
+		}
+	}
+	return nil, repository.ErrAPIKeyNotFound
+}
+
+func (fakeAPIKeyRepository) TouchLastUsed(uint, time.Time) error {
+	return nil
+}
+
+func newAuthTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	keys := service.NewAPIKeyService(fakeAPIKeyRepository{keys: map[string]*model.APIKey{
+		"member-key":    {ID: 2},
+		"org-admin-key": {ID: 3, OrganizationID: uintPtr(5), Admin: true},
+		"server-admin":  {ID: 4, Admin: true},
+	}}, logger)
+	keys.SetAdminKey("config-admin")
+
+	router := gin.New()
+	router.Use(apierror.Middleware(logger), Middleware(Options{APIKeys: keys}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/v1/admin/stats", ok)
+	router.GET("/api/v1/administrator", ok)
+	router.GET("/api/v1/routes", ok)
+	return router
+}
+
+func TestMiddlewareAdminPrefix(t *testing.T) {
+	router := newAuthTestRouter()
+
+	tests := []struct {
+		name   string
+		path   string
+		key    string
+		status int
+	}{
+		{"anonymous", "/api/v1/admin/stats", "", http.StatusUnauthorized},
+		{"invalid key", "/api/v1/admin/stats", "unknown", http.StatusUnauthorized},
+		{"organization-less key", "/api/v1/admin/stats", "member-key", http.StatusForbidden},
+		{"organization admin key", "/api/v1/admin/stats", "org-admin-key", http.StatusForbidden},
+		{"server admin key", "/api/v1/admin/stats", "server-admin", http.StatusOK},
+		{"config admin key", "/api/v1/admin/stats", "config-admin", http.StatusOK},
+		{"path sharing the prefix is not admin", "/api/v1/administrator", "member-key", http.StatusOK},
+		{"organization-less key outside admin", "/api/v1/routes", "member-key", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("GET %s with key %q: status %d, want %d", tt.path, tt.key, rec.Code, tt.status)
+			}
+		})
+	}
+}
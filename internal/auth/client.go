@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientKey возвращает идентификатор клиента для ограничения частоты запросов:
+// ключ API или пользователя, а для запросов без них — IP адрес
+func ClientKey(c *gin.Context) string {
+	if key := CurrentAPIKey(c); key != nil {
+		return "key:" + strconv.FormatUint(uint64(key.ID), 10)
+	}
+	if user := CurrentUser(c); user != nil {
+		return "user:" + strconv.FormatUint(uint64(user.ID), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
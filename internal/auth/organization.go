@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHeader заголовок с ID организации, от имени которой
+// выполняется запрос
+const OrganizationHeader = "X-Organization-ID"
+
+const (
+	// tenantContextKey ключ организации запроса в контексте gin
+	tenantContextKey = "tenant"
+	// adminContextKey ключ признака администратора в контексте gin
+	adminContextKey = "admin"
+)
+
+// resolveTenant определяет организацию запроса. Ключ организации работает
+// только с ней, обычный ключ без организации — ни с одной. Администратор
+// выбирает любую организацию заголовком X-Organization-ID или работает со
+// всеми без него. Пользователь работает с организацией из заголовка, если
+// состоит в ней, а без заголовка — со своей единственной организацией.
+func resolveTenant(orgs *service.OrganizationService, header string, user *service.UserIdentity, key *model.APIKey, admin bool) (*service.Tenant, error) {
+	var requested *uint
+	if header != "" {
+		id, err := strconv.ParseUint(header, 10, 32)
+		if err != nil || id == 0 {
+			return nil, apierror.New(apierror.CodeInvalidRequest, "Неверный заголовок "+OrganizationHeader)
+		}
+		orgID := uint(id)
+		requested = &orgID
+	}
+
+	if key != nil && key.OrganizationID != nil {
+		if requested != nil && *requested != *key.OrganizationID {
+			return nil, apierror.New(apierror.CodeForbidden, "Ключ API не дает доступа к этой организации")
+		}
+		role := model.OrgRoleMember
+		if key.Admin {
+			role = model.OrgRoleAdmin
+		}
+		return &service.Tenant{OrganizationID: *key.OrganizationID, Role: role}, nil
+	}
+	if key != nil && !admin {
+		if requested != nil {
+			return nil, apierror.New(apierror.CodeForbidden, "Ключ API не дает доступа к этой организации")
+		}
+		return nil, nil
+	}
+
+	if orgs == nil {
+		if requested != nil {
+			return nil, apierror.New(apierror.CodeInvalidRequest, "Организации не поддерживаются")
+		}
+		return nil, nil
+	}
+
+	if admin {
+		if requested == nil {
+			return nil, nil
+		}
+		if err := orgs.CheckOrganization(*requested); err != nil {
+			return nil, apierror.Wrap(err, apierror.CodeInternal, "Ошибка проверки организации")
+		}
+		return &service.Tenant{OrganizationID: *requested, Role: model.OrgRoleAdmin}, nil
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	tenant, err := orgs.ResolveTenant(user.ID, requested)
+	if err != nil {
+		if errors.Is(err, service.ErrNotOrganizationMember) {
+			return nil, apierror.New(apierror.CodeForbidden, "Нет доступа к организации")
+		}
+		return nil, apierror.Wrap(err, apierror.CodeInternal, "Ошибка проверки организации")
+	}
+	return tenant, nil
+}
+
+// CurrentTenant возвращает организацию текущего запроса или nil, если запрос
+// выполняется не от имени организации
+func CurrentTenant(c *gin.Context) *service.Tenant {
+	if value, ok := c.Get(tenantContextKey); ok {
+		if tenant, ok := value.(*service.Tenant); ok {
+			return tenant
+		}
+	}
+	return nil
+}
+
+// OrganizationID возвращает ID организации текущего запроса для сохранения
+// вместе с созданными данными или nil
+func OrganizationID(c *gin.Context) *uint {
+	tenant := CurrentTenant(c)
+	if tenant == nil {
+		return nil
+	}
+	id := tenant.OrganizationID
+	return &id
+}
+
+// IsAdmin проверяет, выполнен ли запрос администратором или ключом
+// администратора без организации
+func IsAdmin(c *gin.Context) bool {
+	return c.GetBool(adminContextKey)
+}
+
+// RouteScope возвращает область маршрутов, доступных запросу: маршруты
+// организации запроса, а без нее — личные маршруты пользователя или
+// маршруты, загруженные ключом API. Администраторы и ключи администратора
+// без организации, а также запросы без проверки доступа работают со всеми
+// маршрутами.
+func RouteScope(c *gin.Context) repository.RouteScope {
+	return routeScope(OrganizationID(c), CurrentUser(c), CurrentAPIKey(c))
+}
+
+// routeScope область маршрутов организации orgID, пользователя user или
+// ключа API key
+func routeScope(orgID *uint, user *service.UserIdentity, key *model.APIKey) repository.RouteScope {
+	switch {
+	case orgID != nil:
+		return repository.RouteScope{OrganizationID: orgID}
+	case user != nil && !user.IsAdmin():
+		id := user.ID
+		return repository.RouteScope{OwnerID: &id}
+	case user == nil && key != nil && !key.Admin:
+		id := key.ID
+		return repository.RouteScope{APIKeyID: &id}
+	default:
+		return repository.RouteScope{}
+	}
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeOrganizationRepository организации 5 и 9; пользователь 10 состоит в организации 5
+type fakeOrganizationRepository struct {
+	repository.OrganizationRepository
+}
+
+func (fakeOrganizationRepository) GetByID(id uint) (*model.Organization, error) {
+	if id != 5 && id != 9 {
+		return nil, repository.ErrOrganizationNotFound
+	}
+	return &model.Organization{ID: id}, nil
+}
+
+func (fakeOrganizationRepository) ListForUser(userID uint) ([]repository.OrganizationMembership, error) {
+	if userID != 10 {
+		return nil, nil
+	}
+	return []repository.OrganizationMembership{{Organization: model.Organization{ID: 5}, Role: model.OrgRoleMember}}, nil
+}
+
+func (fakeOrganizationRepository) GetMember(orgID, userID uint) (*model.OrganizationMember, error) {
+	if orgID != 5 || userID != 10 {
+		return nil, repository.ErrMemberNotFound
+	}
+	return &model.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: model.OrgRoleMember}, nil
+}
+
+func newTestOrganizationService() *service.OrganizationService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return service.NewOrganizationService(fakeOrganizationRepository{}, nil, logger)
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestResolveTenant(t *testing.T) {
+	orgs := newTestOrganizationService()
+	adminUser := &service.UserIdentity{ID: 1, Role: model.RoleAdmin}
+	member := &service.UserIdentity{ID: 10, Role: model.RoleUser}
+	configKey := &model.APIKey{Name: "admin (config)", Admin: true}
+	orgKey := &model.APIKey{ID: 2, OrganizationID: uintPtr(5)}
+	orgAdminKey := &model.APIKey{ID: 3, OrganizationID: uintPtr(5), Admin: true}
+	orglessKey := &model.APIKey{ID: 4}
+
+	tests := []struct {
+		name     string
+		orgs     *service.OrganizationService
+		header   string
+		user     *service.UserIdentity
+		key      *model.APIKey
+		admin    bool
+		wantOrg  *uint
+		wantRole string
+		wantCode apierror.Code
+	}{
+		{name: "admin without header works with all organizations", orgs: orgs, user: adminUser, admin: true},
+		{name: "admin selects foreign organization", orgs: orgs, header: "9", user: adminUser, admin: true, wantOrg: uintPtr(9), wantRole: model.OrgRoleAdmin},
+		{name: "admin config key selects organization", orgs: orgs, header: "9", key: configKey, admin: true, wantOrg: uintPtr(9), wantRole: model.OrgRoleAdmin},
+		{name: "organization key", orgs: orgs, key: orgKey, wantOrg: uintPtr(5), wantRole: model.OrgRoleMember},
+		{name: "organization admin key", orgs: orgs, key: orgAdminKey, wantOrg: uintPtr(5), wantRole: model.OrgRoleAdmin},
+		{name: "organization key with own header", orgs: orgs, header: "5", key: orgKey, wantOrg: uintPtr(5), wantRole: model.OrgRoleMember},
+		{name: "organization key with foreign header", orgs: orgs, header: "9", key: orgKey, wantCode: apierror.CodeForbidden},
+		{name: "organization-less key", orgs: orgs, key: orglessKey},
+		{name: "organization-less key with header", orgs: orgs, header: "5", key: orglessKey, wantCode: apierror.CodeForbidden},
+		{name: "organization-less key without organizations", header: "5", key: orglessKey, wantCode: apierror.CodeForbidden},
+		{name: "member without header uses single organization", orgs: orgs, user: member, wantOrg: uintPtr(5), wantRole: model.OrgRoleMember},
+		{name: "member with own header", orgs: orgs, header: "5", user: member, wantOrg: uintPtr(5), wantRole: model.OrgRoleMember},
+		{name: "member with foreign header", orgs: orgs, header: "9", user: member, wantCode: apierror.CodeForbidden},
+		{name: "invalid header", orgs: orgs, header: "abc", user: member, wantCode: apierror.CodeInvalidRequest},
+		{name: "header without organizations", header: "5", user: member, wantCode: apierror.CodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant, err := resolveTenant(tt.orgs, tt.header, tt.user, tt.key, tt.admin)
+			if tt.wantCode != "" {
+				var apiErr *apierror.Error
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.wantCode {
+					t.Fatalf("error = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantOrg == nil {
+				if tenant != nil {
+					t.Fatalf("tenant = %+v, want nil", tenant)
+				}
+				return
+			}
+			if tenant == nil || tenant.OrganizationID != *tt.wantOrg || tenant.Role != tt.wantRole {
+				t.Fatalf("tenant = %+v, want organization %d with role %s", tenant, *tt.wantOrg, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestRouteScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		orgID *uint
+		user  *service.UserIdentity
+		key   *model.APIKey
+		want  repository.RouteScope
+	}{
+		{name: "admin user", user: &service.UserIdentity{ID: 1, Role: model.RoleAdmin}},
+		{name: "admin config key", key: &model.APIKey{Admin: true}},
+		{name: "admin in foreign organization", orgID: uintPtr(9), user: &service.UserIdentity{ID: 1, Role: model.RoleAdmin},
+			want: repository.RouteScope{OrganizationID: uintPtr(9)}},
+		{name: "organization key", orgID: uintPtr(5), key: &model.APIKey{ID: 2, OrganizationID: uintPtr(5)},
+			want: repository.RouteScope{OrganizationID: uintPtr(5)}},
+		{name: "organization-less key", key: &model.APIKey{ID: 4},
+			want: repository.RouteScope{APIKeyID: uintPtr(4)}},
+		{name: "user without organization", user: &service.UserIdentity{ID: 10, Role: model.RoleUser},
+			want: repository.RouteScope{OwnerID: uintPtr(10)}},
+		{name: "user in organization", orgID: uintPtr(5), user: &service.UserIdentity{ID: 10, Role: model.RoleUser},
+			want: repository.RouteScope{OrganizationID: uintPtr(5)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := routeScope(tt.orgID, tt.user, tt.key)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("scope = %s, want %s", formatScope(got), formatScope(tt.want))
+			}
+		})
+	}
+}
+
+func formatScope(scope repository.RouteScope) string {
+	format := func(v *uint) string {
+		if v == nil {
+			return "nil"
+		}
+		return strconv.FormatUint(uint64(*v), 10)
+	}
+	return "{organization: " + format(scope.OrganizationID) + ", owner: " + format(scope.OwnerID) + ", api key: " + format(scope.APIKeyID) + "}"
+}
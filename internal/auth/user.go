@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userContextKey ключ пользователя в контексте gin
+const userContextKey = "user"
+
+// CurrentUser возвращает пользователя текущего запроса или nil, если запрос
+// выполнен без токена пользователя
+func CurrentUser(c *gin.Context) *service.UserIdentity {
+	if value, ok := c.Get(userContextKey); ok {
+		if user, ok := value.(*service.UserIdentity); ok {
+			return user
+		}
+	}
+	return nil
+}
+
+// UserID возвращает ID пользователя текущего запроса для сохранения владельца
+// созданных данных или nil для запроса без токена пользователя
+func UserID(c *gin.Context) *uint {
+	user := CurrentUser(c)
+	if user == nil {
+		return nil
+	}
+	id := user.ID
+	return &id
+}
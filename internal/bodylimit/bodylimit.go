@@ -0,0 +1,159 @@
+// Package bodylimit проверяет тело запроса до обработчика: размер, тип
+// содержимого и форму multipart. Ограничения задаются для каждого маршрута,
+// нарушение отклоняется ответом 4xx с кодом ошибки API, а не ошибкой в
+// середине разбора в обработчике.
+package bodylimit
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartFormType тип содержимого формы multipart
+const multipartFormType = "multipart/form-data"
+
+// Rule ограничения тела запроса
+type Rule struct {
+	// MaxBytes наибольший размер тела, 0 — без ограничения
+	MaxBytes int64
+	// ContentTypes допустимые типы содержимого, пусто — любые
+	ContentTypes []string
+	// MaxMemory сколько формы multipart держать в памяти, остальное
+	// записывается во временные файлы
+	MaxMemory int64
+	// MaxParts наибольшее число полей и файлов формы multipart, 0 — без
+	// ограничения
+	MaxParts int
+}
+
+// Options ограничения тел запросов
+type Options struct {
+	// Routes ограничения маршрутов; ключ — метод и шаблон пути gin, как в
+	// журнале аудита: "POST /api/v1/analyze"
+	Routes map[string]Rule
+	// Default ограничения остальных запросов к /api/ с телом
+	Default Rule
+}
+
+// Middleware проверяет тело запроса по правилу маршрута. Форма multipart
+// разбирается здесь же, поэтому вызов ParseMultipartForm в обработчике
+// получает уже разобранную форму.
+func Middleware(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := opts.Routes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			if !strings.HasPrefix(c.Request.URL.Path, "/api/") || !hasBody(c.Request) {
+				c.Next()
+				return
+			}
+			rule = opts.Default
+		}
+
+		if err := rule.check(c); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasBody проверяет, передано ли тело запроса
+func hasBody(r *http.Request) bool {
+	return r.ContentLength != 0 && r.Body != nil && r.Body != http.NoBody
+}
+
+// check проверяет заголовки запроса и разбирает форму multipart
+func (r Rule) check(c *gin.Context) *apierror.Error {
+	req := c.Request
+	if r.MaxBytes > 0 {
+		// Размер из Content-Length проверяется до чтения тела
+		if req.ContentLength > r.MaxBytes {
+			return tooLarge(r.MaxBytes)
+		}
+		req.Body = http.MaxBytesReader(c.Writer, req.Body, r.MaxBytes)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	if len(r.ContentTypes) > 0 && !contains(r.ContentTypes, mediaType) {
+		return apierror.New(apierror.CodeUnsupportedMediaType,
+			fmt.Sprintf("Тип содержимого должен быть %s", strings.Join(r.ContentTypes, " или ")))
+	}
+	if mediaType != multipartFormType || (r.MaxMemory == 0 && r.MaxParts == 0) {
+		return nil
+	}
+
+	if params["boundary"] == "" {
+		return apierror.Invalid("Неверное тело запроса",
+			apierror.InvalidParam{Name: "Content-Type", Reason: "не указан boundary формы multipart"})
+	}
+	if err := req.ParseMultipartForm(r.MaxMemory); err != nil {
+		var maxBytes *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytes):
+			return tooLarge(maxBytes.Limit)
+		case errors.Is(err, multipart.ErrMessageTooLarge):
+			// Поля без файлов больше MaxMemory и 10 МБ, которые net/http
+			// добавляет для них, или частей больше, чем разбирает net/http
+			return apierror.New(apierror.CodePayloadTooLarge, "Поля формы слишком велики или их слишком много")
+		default:
+			return apierror.Invalid("Неверное тело запроса",
+				apierror.InvalidParam{Name: "body", Reason: "форма multipart не читается"})
+		}
+	}
+	if r.MaxParts > 0 {
+		if parts := countParts(req.MultipartForm); parts > r.MaxParts {
+			return apierror.New(apierror.CodePayloadTooLarge,
+				fmt.Sprintf("В форме %d частей, допускается не больше %d", parts, r.MaxParts))
+		}
+	}
+	return nil
+}
+
+// tooLarge ошибка превышения размера тела
+func tooLarge(limit int64) *apierror.Error {
+	return apierror.New(apierror.CodePayloadTooLarge,
+		fmt.Sprintf("Тело запроса больше %s", formatBytes(limit)))
+}
+
+// countParts число полей и файлов формы
+func countParts(form *multipart.Form) int {
+	n := 0
+	for _, values := range form.Value {
+		n += len(values)
+	}
+	for _, files := range form.File {
+		n += len(files)
+	}
+	return n
+}
+
+// formatBytes размер в байтах, КБ или МБ для сообщения клиенту
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d МБ", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%d КБ", n>>10)
+	}
+	return fmt.Sprintf("%d байт", n)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Значения подставляются при сборке через
+// -ldflags "-X road-detector-go/internal/buildinfo.GitCommit=... -X road-detector-go/internal/buildinfo.BuildDate=..."
+var (
+	// Version версия сервиса
+	Version = "1.0.0"
+	// GitCommit SHA коммита, из которого собран бинарник
+	GitCommit = "unknown"
+	// BuildDate дата сборки в формате RFC 3339
+	BuildDate = "unknown"
+)
+
+// Build информация о сборке бинарника
+type Build struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Deployment метаданные конкретной инсталляции сервиса
+type Deployment struct {
+	Name         string `json:"name"`
+	Organization string `json:"organization,omitempty"`
+	Contact      string `json:"contact,omitempty"`
+}
+
+// Current возвращает информацию о текущей сборке
+func Current() Build {
+	return Build{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// ShortCommit возвращает сокращенный SHA коммита
+func (b Build) ShortCommit() string {
+	if len(b.GitCommit) > 7 {
+		return b.GitCommit[:7]
+	}
+	return b.GitCommit
+}
+
+// Footer возвращает строку для подвала отчетов, по которой поддержка
+// может однозначно определить инсталляцию и сборку
+func (d Deployment) Footer(build Build) string {
+	parts := []string{d.Name}
+	if d.Organization != "" {
+		parts = append(parts, d.Organization)
+	}
+	if d.Contact != "" {
+		parts = append(parts, d.Contact)
+	}
+	parts = append(parts, fmt.Sprintf("v%s (%s, %s)", build.Version, build.ShortCommit(), build.BuildDate))
+	return strings.Join(parts, " · ")
+}
+
+// APIVersions версии HTTP API, которые обслуживает сервер
+var APIVersions = []string{"v1", "v2"}
+
+// PythonCompatibility диапазон версий Python сервиса анализа, с которыми
+// совместима эта сборка: Min включительно, Max исключительно
+var PythonCompatibility = VersionRange{Min: "0.1.0", Max: "2.0.0"}
+
+// Форматы API анализа Python сервиса
+const (
+	// PythonContractJSON POST /analyze: поля startLat, startLon, endLat,
+	// endLon, segmentLength, в ответе JSON с результатами кадров
+	PythonContractJSON = "analyze-json"
+	// PythonContractZIP POST /analyze-road-marking: поля lat1, lon1, lat2,
+	// lon2, segment_length_m, в ответе ZIP с результатами и аннотированным видео
+	PythonContractZIP = "analyze-road-marking-zip"
+)
+
+// PythonContract формат API анализа и версии Python сервиса, которые его
+// используют
+type PythonContract struct {
+	Name     string       `json:"name"`
+	Versions VersionRange `json:"versions"`
+}
+
+// PythonContracts форматы API анализа в порядке версий. Диапазоны не
+// пересекаются и вместе составляют PythonCompatibility.
+var PythonContracts = []PythonContract{
+	{Name: PythonContractJSON, Versions: VersionRange{Min: "0.1.0", Max: "1.0.0"}},
+	{Name: PythonContractZIP, Versions: VersionRange{Min: "1.0.0", Max: "2.0.0"}},
+}
+
+// PythonContractFor возвращает формат API анализа для версии Python
+// сервиса. ok = false, если версия не поддерживается.
+func PythonContractFor(version string) (contract PythonContract, ok bool, err error) {
+	for _, c := range PythonContracts {
+		in, err := c.Versions.Contains(version)
+		if err != nil {
+			return PythonContract{}, false, err
+		}
+		if in {
+			return c, true, nil
+		}
+	}
+	return PythonContract{}, false, nil
+}
+
+// VersionRange полуинтервал версий [Min, Max)
+type VersionRange struct {
+	Min string `json:"min"`
+	Max string `json:"max"`
+}
+
+// String возвращает диапазон в виде ">=Min <Max"
+func (r VersionRange) String() string {
+	return fmt.Sprintf(">=%s <%s", r.Min, r.Max)
+}
+
+// Contains проверяет, входит ли версия в диапазон
+func (r VersionRange) Contains(version string) (bool, error) {
+	lower, err := CompareVersions(version, r.Min)
+	if err != nil {
+		return false, err
+	}
+	upper, err := CompareVersions(version, r.Max)
+	if err != nil {
+		return false, err
+	}
+	return lower >= 0 && upper < 0, nil
+}
+
+// CompareVersions сравнивает версии вида major.minor.patch (префикс "v" и
+// суффиксы вроде "-rc1" игнорируются). Возвращает -1, 0 или 1.
+func CompareVersions(a, b string) (int, error) {
+	pa, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range pa {
+		if pa[i] < pb[i] {
+			return -1, nil
+		}
+		if pa[i] > pb[i] {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// parseVersion разбирает версию в тройку чисел, недостающие части считаются нулями
+func parseVersion(version string) ([3]int, error) {
+	var parts [3]int
+
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(v, "-+ "); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return parts, fmt.Errorf("empty version")
+	}
+
+	fields := strings.Split(v, ".")
+	if len(fields) > 3 {
+		return parts, fmt.Errorf("invalid version %q", version)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version %q", version)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
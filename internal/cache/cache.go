@@ -0,0 +1,147 @@
+// Package cache хранит результаты частых запросов к базе данных в памяти
+// процесса с вытеснением давно не использованных записей и временем жизни.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options настройки кэша
+type Options struct {
+	// Size наибольшее количество записей, 0 — кэш отключен
+	Size int
+	// TTL время жизни записи, 0 — кэш отключен
+	TTL time.Duration
+}
+
+// Enabled проверяет, что кэш включен
+func (o Options) Enabled() bool {
+	return o.Size > 0 && o.TTL > 0
+}
+
+// entry запись кэша
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// Cache LRU кэш с временем жизни записей. Purge сбрасывает все записи,
+// в том числе загружаемые в этот момент: результат загрузки, начатой до
+// сброса, не сохраняется.
+type Cache struct {
+	opts Options
+
+	mu         sync.Mutex
+	order      *list.List
+	items      map[string]*list.Element
+	generation uint64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// New создает кэш
+func New(opts Options) *Cache {
+	return &Cache{
+		opts:  opts,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get возвращает значение по ключу, если оно есть и не устарело
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	e := elem.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.remove(elem)
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
+	return e.value, true
+}
+
+// Do возвращает значение по ключу, а если его нет — вызывает load и
+// сохраняет результат. Ошибки load не кэшируются.
+func (c *Cache) Do(key string, load func() (interface{}, error)) (interface{}, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.set(key, value, generation)
+	return value, nil
+}
+
+// set сохраняет значение, если с начала загрузки кэш не сбрасывался
+func (c *Cache) set(key string, value interface{}, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	expires := time.Now().Add(c.opts.TTL)
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.opts.Size {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove удаляет запись, вызывается под c.mu
+func (c *Cache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry).key)
+}
+
+// Purge удаляет все записи
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.generation++
+	c.mu.Unlock()
+}
+
+// Len возвращает количество записей, включая устаревшие, но еще не вытесненные
+func (c *Cache) Len() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int64(c.order.Len())
+}
+
+// Hits возвращает количество найденных в кэше значений с момента запуска
+func (c *Cache) Hits() int64 {
+	return c.hits.Load()
+}
+
+// Misses возвращает количество промахов кэша с момента запуска
+func (c *Cache) Misses() int64 {
+	return c.misses.Load()
+}
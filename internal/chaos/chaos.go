@@ -0,0 +1,76 @@
+// Package chaos внедряет искусственные задержки, ошибки и обрезанные ответы
+// в HTTP клиент Python сервиса и слой базы данных. Используется только для
+// проверки устойчивости на стенде и никогда не включается в production.
+package chaos
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// ErrInjected ошибка, внедренная режимом хаоса
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Faults параметры внедряемых сбоев для одного слоя
+type Faults struct {
+	// MaxLatency верхняя граница случайной задержки перед вызовом
+	MaxLatency time.Duration
+	// ErrorRate вероятность (0..1) вернуть ошибку вместо вызова
+	ErrorRate float64
+	// TruncateRate вероятность (0..1) обрезать тело ответа (только HTTP)
+	TruncateRate float64
+}
+
+// Active сообщает, задан ли хотя бы один вид сбоя
+func (f Faults) Active() bool {
+	return f.MaxLatency > 0 || f.ErrorRate > 0 || f.TruncateRate > 0
+}
+
+// Config конфигурация режима хаоса
+type Config struct {
+	Enabled bool
+	HTTP    Faults
+	DB      Faults
+}
+
+// delay выполняет случайную задержку в пределах MaxLatency
+func (f Faults) delay() {
+	if f.MaxLatency > 0 {
+		time.Sleep(time.Duration(rand.Int63n(int64(f.MaxLatency))))
+	}
+}
+
+// shouldFail решает, нужно ли внедрить ошибку
+func (f Faults) shouldFail() bool {
+	return f.ErrorRate > 0 && rand.Float64() < f.ErrorRate
+}
+
+// shouldTruncate решает, нужно ли обрезать ответ
+func (f Faults) shouldTruncate() bool {
+	return f.TruncateRate > 0 && rand.Float64() < f.TruncateRate
+}
+
+// truncatedBody отдает случайную часть тела ответа и затем io.ErrUnexpectedEOF,
+// как при оборванном соединении
+type truncatedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (t *truncatedBody) Read(p []byte) (int, error) {
+	if t.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > t.remaining {
+		p = p[:t.remaining]
+	}
+	n, err := t.body.Read(p)
+	t.remaining -= int64(n)
+	return n, err
+}
+
+func (t *truncatedBody) Close() error {
+	return t.body.Close()
+}
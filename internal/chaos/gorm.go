@@ -0,0 +1,57 @@
+package chaos
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Plugin GORM плагин, внедряющий задержки и ошибки перед запросами к БД
+type Plugin struct {
+	faults Faults
+}
+
+// NewPlugin создает плагин сбоев для GORM
+func NewPlugin(faults Faults) *Plugin {
+	return &Plugin{faults: faults}
+}
+
+// Name возвращает имя плагина
+func (p *Plugin) Name() string {
+	return "chaos"
+}
+
+// Initialize регистрирует callback перед каждой операцией GORM
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := []struct {
+		name     string
+		register func() error
+	}{
+		{"create", func() error { return db.Callback().Create().Before("gorm:create").Register("chaos:create", p.inject) }},
+		{"query", func() error { return db.Callback().Query().Before("gorm:query").Register("chaos:query", p.inject) }},
+		{"update", func() error { return db.Callback().Update().Before("gorm:update").Register("chaos:update", p.inject) }},
+		{"delete", func() error { return db.Callback().Delete().Before("gorm:delete").Register("chaos:delete", p.inject) }},
+		{"row", func() error { return db.Callback().Row().Before("gorm:row").Register("chaos:row", p.inject) }},
+		{"raw", func() error { return db.Callback().Raw().Before("gorm:raw").Register("chaos:raw", p.inject) }},
+	}
+
+	for _, cb := range callbacks {
+		if err := cb.register(); err != nil {
+			return fmt.Errorf("failed to register chaos %s callback: %w", cb.name, err)
+		}
+	}
+	return nil
+}
+
+// inject выполняет задержку и, с заданной вероятностью, прерывает операцию ошибкой
+func (p *Plugin) inject(db *gorm.DB) {
+	p.faults.delay()
+
+	if p.faults.shouldFail() {
+		table := ""
+		if db.Statement != nil {
+			table = db.Statement.Table
+		}
+		db.AddError(fmt.Errorf("%w: database operation on %q", ErrInjected, table))
+	}
+}
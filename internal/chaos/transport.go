@@ -0,0 +1,46 @@
+package chaos
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+)
+
+// transport http.RoundTripper, внедряющий сбои перед и после запроса
+type transport struct {
+	base   http.RoundTripper
+	faults Faults
+}
+
+// NewTransport оборачивает base транспортом со сбоями. Если base равен nil,
+// используется http.DefaultTransport.
+func NewTransport(base http.RoundTripper, faults Faults) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, faults: faults}
+}
+
+// RoundTrip выполняет запрос с внедренными задержкой, ошибкой или обрезанным ответом
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.faults.delay()
+
+	if t.faults.shouldFail() {
+		return nil, fmt.Errorf("%w: %s %s", ErrInjected, req.Method, req.URL.Path)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.faults.shouldTruncate() {
+		limit := int64(0)
+		if resp.ContentLength > 0 {
+			limit = rand.Int63n(resp.ContentLength)
+		}
+		resp.Body = &truncatedBody{body: resp.Body, remaining: limit}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,284 @@
+// Package compression сжимает ответы HTTP gzip. Сжимаются только ответы
+// текстовых типов не меньше заданного размера; видео, PDF и другие уже
+// сжатые данные, ответы на запросы Range и соединения WebSocket передаются
+// как есть.
+package compression
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultContentTypes типы содержимого, которые сжимаются по умолчанию
+var DefaultContentTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/geo+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/*",
+}
+
+// Options настройки сжатия ответов
+type Options struct {
+	// MinBytes ответы меньше этого размера не сжимаются
+	MinBytes int
+	// Level уровень сжатия gzip от 1 до 9
+	Level int
+	// ContentTypes сжимаемые типы содержимого; * после / — любой подтип
+	ContentTypes []string
+}
+
+// Middleware сжимает ответы, если клиент принимает gzip. Решение принимается
+// по заголовкам ответа при первой записи тела, поэтому ответ до MinBytes
+// накапливается в памяти.
+func Middleware(opts Options) gin.HandlerFunc {
+	if len(opts.ContentTypes) == 0 {
+		opts.ContentTypes = DefaultContentTypes
+	}
+	if opts.Level == 0 {
+		opts.Level = gzip.DefaultCompression
+	}
+	pool := &sync.Pool{New: func() interface{} {
+		gz, _ := gzip.NewWriterLevel(nil, opts.Level)
+		return gz
+	}}
+
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.Request) || c.Request.Method == http.MethodHead ||
+			c.GetHeader("Range") != "" || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		w := &writer{ResponseWriter: c.Writer, opts: &opts, pool: pool}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// acceptsGzip проверяет, принимает ли клиент ответ в gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding != "gzip" && coding != "*" {
+				continue
+			}
+			// gzip;q=0 означает, что клиент сжатие не принимает
+			if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Состояния записи ответа
+const (
+	// stateBuffering тело накапливается до MinBytes
+	stateBuffering = iota
+	// statePassthrough ответ передается без сжатия
+	statePassthrough
+	// stateCompressing ответ сжимается
+	stateCompressing
+)
+
+// writer откладывает отправку заголовков до решения о сжатии
+type writer struct {
+	gin.ResponseWriter
+	opts   *Options
+	pool   *sync.Pool
+	gz     *gzip.Writer
+	state  int
+	status int
+	buf    []byte
+	// wrote тело или статус уже переданы обработчиком
+	wrote bool
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.state == stateBuffering {
+		// Статус запоминается до решения о сжатии; после начала тела он
+		// уже не меняется
+		if !w.wrote {
+			w.status = code
+		}
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) WriteHeaderNow() {
+	w.wrote = true
+	if w.state == stateBuffering {
+		// Ответ без тела: сжимать нечего
+		w.passthrough()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	w.wrote = true
+	switch w.state {
+	case statePassthrough:
+		return w.ResponseWriter.Write(data)
+	case stateCompressing:
+		return w.gz.Write(data)
+	}
+
+	if !w.compressible() {
+		w.passthrough()
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.opts.MinBytes {
+		if err := w.startCompressing(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *writer) Status() int {
+	if w.state == stateBuffering && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *writer) Written() bool {
+	return w.wrote || w.ResponseWriter.Written()
+}
+
+// Flush отправляет накопленное: потоковый ответ сжимается сразу, не
+// дожидаясь MinBytes
+func (w *writer) Flush() {
+	if w.state == stateBuffering {
+		if w.compressible() && len(w.buf) > 0 {
+			_ = w.startCompressing()
+		} else {
+			w.passthrough()
+		}
+	}
+	if w.state == stateCompressing {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.state == stateCompressing {
+		return nil, nil, errors.New("response is already compressed")
+	}
+	w.passthrough()
+	return w.ResponseWriter.Hijack()
+}
+
+// compressible проверяет заголовки ответа: тип содержимого входит в
+// ContentTypes, ответ еще не закодирован и имеет тело
+func (w *writer) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	return matchContentType(header.Get("Content-Type"), w.opts.ContentTypes)
+}
+
+// passthrough отправляет отложенные статус и накопленное тело без сжатия
+func (w *writer) passthrough() {
+	if w.state != stateBuffering {
+		return
+	}
+	w.state = statePassthrough
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(w.buf) > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+// startCompressing отправляет заголовки сжатого ответа и накопленное тело
+func (w *writer) startCompressing() error {
+	w.state = stateCompressing
+	header := w.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	// Сжатый ответ отличается побайтно, поэтому ETag становится слабым
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	w.gz = w.pool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// finish завершает ответ после обработчика: короткое тело отправляется без
+// сжатия, поток gzip закрывается
+func (w *writer) finish() {
+	switch w.state {
+	case stateBuffering:
+		w.passthrough()
+	case stateCompressing:
+		_ = w.gz.Close()
+		w.gz.Reset(nil)
+		w.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// matchContentType проверяет тип содержимого по списку; * после /
+// означает любой подтип
+func matchContentType(contentType string, types []string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return false
+	}
+	for _, pattern := range types {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+			continue
+		}
+		if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -1,57 +1,602 @@
+// Package config загружает конфигурацию сервиса из файла YAML или TOML
+// и переменных окружения и проверяет ее при запуске.
 package config
 
 import (
-	"os"
-	"strconv"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/analyzerpool"
+	"road-detector-go/internal/buildinfo"
+	"road-detector-go/internal/cache"
+	"road-detector-go/internal/chaos"
+	"road-detector-go/internal/coverageband"
+	"road-detector-go/internal/database"
+	"road-detector-go/internal/diagnostics"
+	"road-detector-go/internal/dirwatch"
+	"road-detector-go/internal/errreport"
+	"road-detector-go/internal/geocode"
+	"road-detector-go/internal/grpcserver"
+	"road-detector-go/internal/hls"
+	"road-detector-go/internal/imageseq"
+	"road-detector-go/internal/logging"
+	"road-detector-go/internal/mqttingest"
+	"road-detector-go/internal/notify"
+	"road-detector-go/internal/oidc"
+	"road-detector-go/internal/onnxanalyzer"
+	"road-detector-go/internal/quality"
+	"road-detector-go/internal/report"
+	"road-detector-go/internal/service"
+	"road-detector-go/internal/tlsserver"
+	"road-detector-go/internal/transcode"
+	"road-detector-go/internal/videochunk"
 )
 
-// Config структура конфигурации приложения
+// Config конфигурация приложения
 type Config struct {
-	Server struct {
-		Port int
-		Host string
+	// File путь к файлу конфигурации, пусто — только переменные окружения
+	File string
+	// Host адрес, на котором слушает сервер, пусто — все интерфейсы
+	Host string
+	Port string
+	// PythonServices экземпляры Python сервиса и распределение анализов между ними
+	PythonServices analyzerpool.Options
+	// PythonServiceTimeout ожидание ответа Python сервиса на запрос анализа,
+	// если оно не зависит от видео
+	PythonServiceTimeout time.Duration
+	// AnalyzerTimeout расчет ожидания ответа анализатора по размеру и
+	// длительности видео
+	AnalyzerTimeout service.TimeoutPolicy
+	// LowConfidenceThreshold средняя уверенность модели, ниже которой
+	// сегмент отмечается ненадежным, 0 — не отмечается
+	LowConfidenceThreshold float64
+	// PythonServiceTransport протокол запроса анализа: http или grpc
+	PythonServiceTransport string
+	// PythonServiceGRPCAddr адрес gRPC сервиса потокового анализа
+	PythonServiceGRPCAddr string
+	// Shadow повтор части анализов вторым анализатором для сравнения версий
+	Shadow service.ShadowOptions
+	// AnalyzerBackend чем выполняется анализ: python — Python сервис,
+	// onnx — локальная модель ONNX (экспериментально)
+	AnalyzerBackend string
+	// ONNX настройки локального анализа при AnalyzerBackend = onnx
+	ONNX onnxanalyzer.Options
+	// Quality веса индекса качества дороги
+	Quality quality.Options
+	// CoverageBands полосы покрытия, по которым клиенты раскрашивают карту
+	CoverageBands []coverageband.Band
+	// VideoChunking анализ длинных видео по частям, ChunkDuration 0 — выключен
+	VideoChunking struct {
+		Options     videochunk.Options
+		Parallelism int
+	}
+	// ImageSequences анализ последовательностей снимков, включается, если
+	// найден ffmpeg
+	ImageSequences imageseq.Options
+	// HLS нарезка видео маршрутов на сегменты HLS по первому запросу
+	HLS struct {
+		Enabled bool
+		Options hls.Options
+		// Timeout ограничение нарезки одного видео
+		Timeout time.Duration
+	}
+	// Transcoding копии видео в H.264 MP4 для браузеров
+	Transcoding struct {
+		Enabled bool
+		Options transcode.Options
+		Queue   service.TranscodeOptions
+	}
+	// SlowAnalysisThreshold анализ дольше этого пишется в лог как медленный, 0 — не отмечается
+	SlowAnalysisThreshold time.Duration
+	Environment           string
+	Deployment            buildinfo.Deployment
+	// Logging уровень, формат и вывод логов
+	Logging logging.Options
+	// StrictVersionCheck запрещает запуск с несовместимой версией Python сервиса
+	StrictVersionCheck bool
+	// PostGISMode режим PostGIS: auto, on или off
+	PostGISMode string
+	// Chaos внедрение сбоев для проверки устойчивости (только для стендов)
+	Chaos chaos.Config
+	// DebugCapture сохранение отладочных пакетов неудачных анализов
+	DebugCapture struct {
+		Enabled    bool
+		Dir        string
+		MaxBundles int
+	}
+	// UnsavedResultsDir папка результатов анализов, которые не удалось
+	// сохранить в базе данных, пустая строка отключает их сохранение
+	UnsavedResultsDir string
+	// SelfTestVideoPath видео для самопроверки вместо встроенного
+	SelfTestVideoPath string
+	// MapMatching привязка сегментов к дорожному графу OSM
+	MapMatching struct {
+		Provider string
+		URL      string
+		Timeout  time.Duration
+	}
+	// Geocoding обратное геокодирование названий дорог
+	Geocoding struct {
+		Options  geocode.Options
+		Segments bool
+	}
+	// APIKeys проверка ключей API
+	APIKeys struct {
+		Enabled bool
+		// AdminKey ключ администратора, не хранящийся в БД
+		AdminKey string
+		// ExemptPaths пути /api/v1, доступные без ключа и токена
+		ExemptPaths []string
+	}
+	// Users учетные записи пользователей и токены доступа
+	Users struct {
+		Enabled bool
+		// Secret ключ подписи токенов HS256
+		Secret              string
+		TokenTTL            time.Duration
+		RegistrationEnabled bool
+	}
+	// OIDC проверка токенов внешнего провайдера, включается заданием издателя
+	OIDC oidc.Options
+	// RateLimit ограничение частоты запросов для каждого ключа, пользователя или IP
+	RateLimit struct {
+		// RPS запросов в секунду, 0 — без ограничения
+		RPS   float64
+		Burst int
+	}
+	// Quotas месячные квоты организаций, пользователей и ключей
+	Quotas service.QuotaLimits
+	// TLS завершение TLS самим сервером, включается сертификатом или доменами ACME
+	TLS tlsserver.Options
+	// ClientIP определение IP клиента за обратным прокси
+	ClientIP struct {
+		// TrustedProxies IP адреса и подсети прокси, заголовкам которых можно доверять
+		TrustedProxies []string
+		// Headers заголовки с IP клиента в порядке проверки
+		Headers []string
+		// TrustedPlatform заголовок платформы (например, CF-Connecting-IP),
+		// которому доверяют без проверки адреса прокси
+		TrustedPlatform string
+	}
+	// Webhooks доставка событий анализа подписчикам
+	Webhooks service.WebhookOptions
+	// Alerts отправка оповещений по почте и в Telegram
+	Alerts notify.Options
+	// Reports сводные отчеты по расписаниям и отчеты маршрутов
+	Reports service.ReportOptions
+	// Outbox публикация событий, сохраненных вместе с изменениями
+	Outbox service.OutboxOptions
+	// Archive перенос старых маршрутов в архив
+	Archive service.ArchiveOptions
+	// Health проверка готовности для /readyz и /api/v1/health
+	Health service.HealthOptions
+	// Diagnostics профилировщик pprof и переменные expvar
+	Diagnostics diagnostics.Options
+	// APIDocs документ OpenAPI /openapi.json и Swagger UI /docs
+	APIDocs struct {
+		Enabled bool
+		// SwaggerUIAssetsURL адрес swagger-ui-dist, откуда страница /docs загружает скрипты
+		SwaggerUIAssetsURL string
 	}
-	PythonAPI struct {
-		BaseURL string
-		Timeout int // в секундах
+	// RequestLimits ограничения тел запросов к API
+	RequestLimits struct {
+		// MaxUploadBytes наибольший размер запроса анализа с видео или архивом
+		MaxUploadBytes int64
+		// MaxBodyBytes наибольший размер тела остальных запросов к API
+		MaxBodyBytes int64
+		// MultipartMemoryBytes сколько формы анализа держать в памяти,
+		// остальное записывается во временные файлы
+		MultipartMemoryBytes int64
+		// MultipartMaxParts наибольшее число полей и файлов формы анализа
+		MultipartMaxParts int
 	}
-	Logging struct {
-		Level string
+	// Compression сжатие ответов gzip
+	Compression struct {
+		Enabled bool
+		// MinBytes ответы меньше этого размера не сжимаются
+		MinBytes int
+		// Level уровень сжатия gzip от 1 до 9
+		Level int
 	}
+	// GRPC API маршрутов и анализа по gRPC, пустой адрес — сервер не запускается
+	GRPC grpcserver.Options
+	// MQTT прием клипов от устройств через брокер MQTT, пустой адрес брокера — не включен
+	MQTT mqttingest.Options
+	// DirWatch прием видео с метаданными из папки, пустая папка — не включен
+	DirWatch dirwatch.Options
+	// RunMode роль экземпляра: all — API и анализ, api — API ставит анализы
+	// в очередь, worker — анализ из очереди без API
+	RunMode string
+	// AnalysisQueue очередь анализов между экземплярами api и worker
+	AnalysisQueue service.AnalysisQueueOptions
+	// ErrorReporting отправка ошибок в Sentry
+	ErrorReporting errreport.Options
+	// ShutdownTimeout сколько при остановке ждать завершения запросов и фоновых задач
+	ShutdownTimeout time.Duration
+	// HTTPServer тайм-ауты и ограничения HTTP сервера, 0 — без ограничения
+	HTTPServer struct {
+		ReadTimeout       time.Duration
+		ReadHeaderTimeout time.Duration
+		WriteTimeout      time.Duration
+		IdleTimeout       time.Duration
+		MaxHeaderBytes    int
+	}
+	// Database подключение к базе данных и его повторы при запуске
+	Database struct {
+		Connection database.Config
+		Retry      database.RetryOptions
+		// StartDegraded запускает сервис без базы данных, продолжая подключение в фоне
+		StartDegraded bool
+		// SlowQueryThreshold запрос дольше этого пишется в лог как медленный, 0 — не отмечается
+		SlowQueryThreshold time.Duration
+	}
+	// Cache кэш списков, выборок по области и аналитики в памяти процесса
+	Cache cache.Options
+
+	// ReloadInterval как часто проверять изменение файла конфигурации,
+	// 0 — только по сигналу SIGHUP
+	ReloadInterval time.Duration
+
+	// summary действующие значения параметров для лога
+	summary map[string]string
+	// values действующие значения параметров для сравнения при перезагрузке
+	values map[string]string
 }
 
-// LoadConfig загружает конфигурацию из переменных окружения
-func LoadConfig() *Config {
-	cfg := &Config{}
+// Load читает конфигурацию из файла path (пусто — без файла), применяет
+// поверх него переменные окружения и проверяет результат. Ошибки всех
+// параметров возвращаются вместе.
+func Load(path string) (*Config, error) {
+	src, err := newSource(path)
+	if err != nil {
+		return nil, err
+	}
 
-	// Конфигурация сервера
-	cfg.Server.Port = getEnvInt("SERVER_PORT", 8080)
-	cfg.Server.Host = getEnv("SERVER_HOST", "0.0.0.0")
+	cfg := read(src)
+	cfg.File = path
+	cfg.summary = src.summary()
+	cfg.values = src.values()
 
-	// Конфигурация Python API
-	cfg.PythonAPI.BaseURL = getEnv("PYTHON_API_BASE_URL", "http://localhost:8000")
-	cfg.PythonAPI.Timeout = getEnvInt("PYTHON_API_TIMEOUT_SECONDS", 300) // 5 минут по умолчанию
+	errs := src.errs
+	for _, key := range src.unknownKeys() {
+		errs = append(errs, fmt.Errorf("%s: unknown config file key", key))
+	}
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return cfg, nil
+}
 
-	// Конфигурация логирования
-	cfg.Logging.Level = getEnv("LOG_LEVEL", "info")
+// Summary возвращает действующие значения параметров для записи в лог.
+// Значения секретов скрыты, у заданных в файле или окружении указан источник.
+func (c *Config) Summary() map[string]string {
+	return c.summary
+}
 
-	return cfg
+// Addr адрес HTTP сервера
+func (c *Config) Addr() string {
+	return net.JoinHostPort(c.Host, c.Port)
 }
 
-// getEnv получает значение переменной окружения или возвращает значение по умолчанию
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// read читает все параметры из src
+func read(src *source) *Config {
+	cfg := &Config{
+		Host:                  src.string("SERVER_HOST", ""),
+		Port:                  src.string("SERVER_PORT", "8080"),
+		PythonServiceTimeout:  src.duration("PYTHON_API_TIMEOUT_SECONDS", 300, time.Second),
+		SlowAnalysisThreshold: src.duration("SLOW_ANALYSIS_MINUTES", 3, time.Minute),
+		Environment:           src.string("ENVIRONMENT", "development"),
+		Deployment: buildinfo.Deployment{
+			Name:         src.string("DEPLOYMENT_NAME", "road-detector"),
+			Organization: src.string("OPERATOR_ORGANIZATION", ""),
+			Contact:      src.string("OPERATOR_CONTACT", ""),
+		},
+		Logging: logging.Options{
+			Level:      src.string("LOG_LEVEL", "info"),
+			Format:     src.string("LOG_FORMAT", logging.FormatJSON),
+			File:       src.string("LOG_FILE", ""),
+			MaxSizeMB:  src.int("LOG_MAX_SIZE_MB", 100),
+			MaxBackups: src.int("LOG_MAX_BACKUPS", 5),
+		},
+		StrictVersionCheck: src.bool("STRICT_VERSION_CHECK", false),
+		PostGISMode:        src.string("POSTGIS_MODE", database.PostGISAuto),
+		SelfTestVideoPath:  src.string("SELFTEST_VIDEO_PATH", ""),
+		Chaos: chaos.Config{
+			Enabled: src.bool("CHAOS_ENABLED", false),
+			HTTP: chaos.Faults{
+				MaxLatency:   src.duration("CHAOS_HTTP_LATENCY_MS", 0, time.Millisecond),
+				ErrorRate:    src.float("CHAOS_HTTP_ERROR_RATE", 0),
+				TruncateRate: src.float("CHAOS_HTTP_TRUNCATE_RATE", 0),
+			},
+			DB: chaos.Faults{
+				MaxLatency: src.duration("CHAOS_DB_LATENCY_MS", 0, time.Millisecond),
+				ErrorRate:  src.float("CHAOS_DB_ERROR_RATE", 0),
+			},
+		},
 	}
-	return defaultValue
-}
 
-// getEnvInt получает int значение переменной окружения или возвращает значение по умолчанию
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
+	cfg.PythonServices = analyzerpool.Options{
+		URLs:           src.list("PYTHON_API_BASE_URL", "http://localhost:8000"),
+		Strategy:       src.string("PYTHON_API_BALANCING", analyzerpool.StrategyLeastBusy),
+		Discovery:      src.string("PYTHON_API_DISCOVERY", ""),
+		HealthInterval: src.duration("PYTHON_API_HEALTH_INTERVAL_SEC", 10, time.Second),
+	}
+	cfg.AnalyzerTimeout = service.TimeoutPolicy{
+		Min:            src.duration("ANALYZER_TIMEOUT_MIN_SECONDS", 120, time.Second),
+		Max:            src.duration("ANALYZER_TIMEOUT_MAX_SECONDS", 1800, time.Second),
+		PerMB:          src.duration("ANALYZER_TIMEOUT_PER_MB_SECONDS", 5, time.Second),
+		PerVideoSecond: time.Duration(src.float("ANALYZER_TIMEOUT_PER_VIDEO_SECOND", 2) * float64(time.Second)),
+	}
+	cfg.LowConfidenceThreshold = src.float("LOW_CONFIDENCE_THRESHOLD", 0.5)
+	cfg.PythonServiceTransport = src.string("PYTHON_API_TRANSPORT", "http")
+	cfg.PythonServiceGRPCAddr = src.string("PYTHON_API_GRPC_ADDR", "localhost:50051")
+	cfg.Shadow.URL = src.string("SHADOW_ANALYZER_URL", "")
+	cfg.Shadow.Percent = src.float("SHADOW_ANALYZER_PERCENT", 10)
+	cfg.Shadow.Timeout = cfg.PythonServiceTimeout
+
+	ffmpeg := src.string("FFMPEG_PATH", "ffmpeg")
+	ffprobe := src.string("FFPROBE_PATH", "ffprobe")
+	cfg.AnalyzerBackend = src.string("ANALYZER_BACKEND", "python")
+	cfg.ONNX = onnxanalyzer.Options{
+		ModelPath:         src.string("ONNX_MODEL_PATH", ""),
+		RuntimeLibrary:    src.string("ONNX_RUNTIME_LIBRARY", ""),
+		FFmpegPath:        ffmpeg,
+		SampleFPS:         src.float("ONNX_SAMPLE_FPS", 2),
+		InputWidth:        src.int("ONNX_INPUT_WIDTH", 512),
+		InputHeight:       src.int("ONNX_INPUT_HEIGHT", 256),
+		Threshold:         src.float("ONNX_THRESHOLD", 0.5),
+		MinMarkingPercent: src.float("ONNX_MIN_MARKING_PERCENT", 1),
+	}
+	cfg.VideoChunking.Options = videochunk.Options{
+		FFmpegPath:    ffmpeg,
+		FFprobePath:   ffprobe,
+		ChunkDuration: src.duration("VIDEO_CHUNK_SECONDS", 0, time.Second),
+	}
+	cfg.VideoChunking.Parallelism = src.int("VIDEO_CHUNK_PARALLELISM", 4)
+	cfg.HLS.Enabled = src.bool("HLS_ENABLED", false)
+	cfg.HLS.Options = hls.Options{
+		FFmpegPath:      ffmpeg,
+		FFprobePath:     ffprobe,
+		SegmentDuration: src.duration("HLS_SEGMENT_SECONDS", 6, time.Second),
+	}
+	cfg.HLS.Timeout = src.duration("HLS_TIMEOUT_MINUTES", 60, time.Minute)
+	cfg.Transcoding.Enabled = src.bool("TRANSCODE_ENABLED", false)
+	cfg.Transcoding.Options = transcode.Options{
+		FFmpegPath:  ffmpeg,
+		FFprobePath: ffprobe,
+		CRF:         src.int("TRANSCODE_CRF", 23),
+		MaxHeight:   src.int("TRANSCODE_MAX_HEIGHT", 720),
+	}
+	cfg.Transcoding.Queue = service.TranscodeOptions{
+		Workers:   src.int("TRANSCODE_WORKERS", 1),
+		QueueSize: src.int("TRANSCODE_QUEUE_SIZE", 1000),
+		Timeout:   src.duration("TRANSCODE_TIMEOUT_MINUTES", 60, time.Minute),
+	}
+	cfg.ImageSequences = imageseq.Options{
+		FFmpegPath: ffmpeg,
+		MaxImages:  src.int("IMAGE_SEQUENCE_MAX_IMAGES", 500),
+	}
+	cfg.Quality = quality.Options{
+		CoverageWeight:   src.float("QUALITY_WEIGHT_COVERAGE", 0.6),
+		DefectWeight:     src.float("QUALITY_WEIGHT_DEFECTS", 0.3),
+		ConfidenceWeight: src.float("QUALITY_WEIGHT_CONFIDENCE", 0.1),
+		MaxDefectsPerKm:  src.float("QUALITY_MAX_DEFECTS_PER_KM", 20),
+	}
+	bands := src.list("COVERAGE_BANDS", coverageband.DefaultSpec)
+	if parsed, err := coverageband.Parse(bands); err != nil {
+		src.invalid("COVERAGE_BANDS", strings.Join(bands, ","), err.Error())
+	} else {
+		cfg.CoverageBands = parsed
+	}
+
+	cfg.DebugCapture.Enabled = src.bool("DEBUG_CAPTURE_ENABLED", false)
+	cfg.DebugCapture.Dir = src.string("DEBUG_CAPTURE_DIR", filepath.Join(".", "data", "debug"))
+	cfg.DebugCapture.MaxBundles = src.int("DEBUG_CAPTURE_MAX_BUNDLES", 200)
+	cfg.UnsavedResultsDir = src.string("UNSAVED_RESULTS_DIR", filepath.Join(".", "data", "unsaved"))
+
+	cfg.MapMatching.Provider = src.string("MAP_MATCHING_PROVIDER", "")
+	cfg.MapMatching.URL = src.string("MAP_MATCHING_URL", "http://localhost:5000")
+	cfg.MapMatching.Timeout = src.duration("MAP_MATCHING_TIMEOUT_SEC", 10, time.Second)
+
+	cfg.Geocoding.Options = geocode.Options{
+		Provider:    src.string("GEOCODING_PROVIDER", ""),
+		URL:         src.string("GEOCODING_URL", "https://nominatim.openstreetmap.org"),
+		Language:    src.string("GEOCODING_LANGUAGE", "ru"),
+		Timeout:     src.duration("GEOCODING_TIMEOUT_SEC", 10, time.Second),
+		MinInterval: src.duration("GEOCODING_MIN_INTERVAL_MS", 1000, time.Millisecond),
+	}
+	cfg.Geocoding.Segments = src.bool("GEOCODING_SEGMENTS", false)
+
+	cfg.APIKeys.Enabled = src.bool("API_KEY_AUTH_ENABLED", false)
+	cfg.APIKeys.AdminKey = src.secret("API_ADMIN_KEY", "")
+	cfg.APIKeys.ExemptPaths = src.list("API_KEY_EXEMPT_PATHS", "/api/v1/health,/api/v1/meta/version")
+
+	cfg.Users.Enabled = src.bool("JWT_AUTH_ENABLED", false)
+	cfg.Users.Secret = src.secret("JWT_SECRET", "")
+	cfg.Users.TokenTTL = src.duration("JWT_TTL_HOURS", 24, time.Hour)
+	cfg.Users.RegistrationEnabled = src.bool("JWT_REGISTRATION_ENABLED", true)
+
+	cfg.OIDC = oidc.Options{
+		IssuerURL:  src.string("OIDC_ISSUER_URL", ""),
+		ClientID:   src.string("OIDC_CLIENT_ID", ""),
+		RolesClaim: src.string("OIDC_ROLES_CLAIM", "realm_access.roles"),
+		AdminRole:  src.string("OIDC_ADMIN_ROLE", "admin"),
+		Timeout:    src.duration("OIDC_TIMEOUT_SEC", 10, time.Second),
+	}
+
+	cfg.RateLimit.RPS = src.float("RATE_LIMIT_RPS", 0)
+	cfg.RateLimit.Burst = src.int("RATE_LIMIT_BURST", 20)
+
+	cfg.Quotas = service.QuotaLimits{
+		MonthlyUploads:         int64(src.int("QUOTA_MONTHLY_UPLOADS", 0)),
+		MonthlyAnalysisMinutes: src.float("QUOTA_MONTHLY_ANALYSIS_MINUTES", 0),
+		StorageBytes:           int64(src.int("QUOTA_STORAGE_MB", 0)) << 20,
+	}
+
+	cfg.TLS = tlsserver.Options{
+		CertFile:              src.string("TLS_CERT_FILE", ""),
+		KeyFile:               src.string("TLS_KEY_FILE", ""),
+		AutocertDomains:       src.list("TLS_AUTOCERT_DOMAINS", ""),
+		AutocertCacheDir:      src.string("TLS_AUTOCERT_CACHE_DIR", filepath.Join(".", "data", "autocert")),
+		AutocertEmail:         src.string("TLS_AUTOCERT_EMAIL", ""),
+		RedirectAddr:          src.string("TLS_REDIRECT_ADDR", ""),
+		HSTSMaxAge:            src.duration("TLS_HSTS_MAX_AGE_SEC", 31536000, time.Second),
+		HSTSIncludeSubdomains: src.bool("TLS_HSTS_INCLUDE_SUBDOMAINS", false),
+	}
+
+	cfg.ClientIP.TrustedProxies = src.list("TRUSTED_PROXIES", "")
+	cfg.ClientIP.Headers = src.list("CLIENT_IP_HEADERS", "X-Forwarded-For,X-Real-IP")
+	cfg.ClientIP.TrustedPlatform = src.string("TRUSTED_PLATFORM_HEADER", "")
+
+	cfg.Webhooks = service.WebhookOptions{
+		MaxAttempts: src.int("WEBHOOK_MAX_ATTEMPTS", 6),
+		RetryDelay:  src.duration("WEBHOOK_RETRY_DELAY_SEC", 10, time.Second),
+		Timeout:     src.duration("WEBHOOK_TIMEOUT_SEC", 10, time.Second),
+
+		AllowPrivateNetworks: src.bool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+	}
+	cfg.Alerts = notify.Options{
+		SMTPAddr:      src.string("ALERT_SMTP_ADDR", ""),
+		SMTPUsername:  src.string("ALERT_SMTP_USERNAME", ""),
+		SMTPPassword:  src.secret("ALERT_SMTP_PASSWORD", ""),
+		EmailFrom:     src.string("ALERT_EMAIL_FROM", ""),
+		TelegramToken: src.secret("ALERT_TELEGRAM_BOT_TOKEN", ""),
+		TelegramURL:   src.string("ALERT_TELEGRAM_API_URL", notify.DefaultTelegramURL),
+		Timeout:       src.duration("ALERT_NOTIFY_TIMEOUT_SEC", 10, time.Second),
+		TemplateDir:   src.string("NOTIFY_TEMPLATE_DIR", ""),
+		RouteURL:      src.string("NOTIFY_ROUTE_URL", ""),
+	}
+	cfg.Reports = service.ReportOptions{
+		Interval: src.duration("REPORT_CHECK_INTERVAL_MIN", 10, time.Minute),
+		Render: report.Options{
+			PDFCommand:  src.string("REPORT_PDF_COMMAND", ""),
+			Timeout:     src.duration("REPORT_PDF_TIMEOUT_SEC", 60, time.Second),
+			FFmpegPath:  ffmpeg,
+			FFprobePath: ffprobe,
+		},
+	}
+	cfg.Outbox = service.OutboxOptions{
+		Interval:  src.duration("OUTBOX_RELAY_INTERVAL_SEC", 5, time.Second),
+		Retention: src.duration("OUTBOX_RETENTION_DAYS", 7, 24*time.Hour),
+	}
+	cfg.Archive = service.ArchiveOptions{
+		After:    src.duration("ARCHIVE_AFTER_DAYS", 0, 24*time.Hour),
+		Interval: src.duration("ARCHIVE_INTERVAL_MIN", 60, time.Minute),
+	}
+
+	cfg.Health = service.HealthOptions{
+		Timeout:          src.duration("HEALTH_CHECK_TIMEOUT_SEC", 3, time.Second),
+		MinFreeDiskBytes: uint64(src.duration("HEALTH_MIN_FREE_DISK_MB", 500, 1<<20)),
+	}
+
+	cfg.Diagnostics.Addr = src.string("DIAGNOSTICS_ADDR", "")
+	cfg.Diagnostics.AdminAPI = src.bool("DIAGNOSTICS_ADMIN_API", false)
+
+	cfg.APIDocs.Enabled = src.bool("API_DOCS_ENABLED", true)
+	cfg.APIDocs.SwaggerUIAssetsURL = src.string("SWAGGER_UI_ASSETS_URL", "https://unpkg.com/swagger-ui-dist@5")
+
+	cfg.RequestLimits.MaxUploadBytes = int64(src.int("UPLOAD_MAX_MB", 2048)) << 20
+	cfg.RequestLimits.MaxBodyBytes = int64(src.int("REQUEST_MAX_BODY_MB", 16)) << 20
+	cfg.RequestLimits.MultipartMemoryBytes = int64(src.int("MULTIPART_MEMORY_MB", 32)) << 20
+	cfg.RequestLimits.MultipartMaxParts = src.int("MULTIPART_MAX_PARTS", 64)
+
+	cfg.Compression.Enabled = src.bool("COMPRESSION_ENABLED", true)
+	cfg.Compression.MinBytes = src.int("COMPRESSION_MIN_BYTES", 1024)
+	cfg.Compression.Level = src.int("COMPRESSION_LEVEL", 5)
+
+	cfg.GRPC.Addr = src.string("GRPC_ADDR", "")
+	cfg.GRPC.MaxVideoBytes = int64(src.int("GRPC_MAX_VIDEO_MB", 2048)) << 20
+
+	cfg.MQTT = mqttingest.Options{
+		BrokerURL:       src.string("MQTT_BROKER_URL", ""),
+		ClientID:        src.string("MQTT_CLIENT_ID", "road-detector"),
+		Username:        src.string("MQTT_USERNAME", ""),
+		Password:        src.secret("MQTT_PASSWORD", ""),
+		TopicPrefix:     src.string("MQTT_TOPIC_PREFIX", "road-detector/devices"),
+		QoS:             byte(src.int("MQTT_QOS", 1)),
+		MaxClipBytes:    int64(src.int("MQTT_MAX_CLIP_MB", 256)) << 20,
+		AssemblyTimeout: src.duration("MQTT_ASSEMBLY_TIMEOUT_SEC", 300, time.Second),
+		Workers:         src.int("MQTT_WORKERS", 2),
 	}
-	return defaultValue
-} 
\ No newline at end of file
+	if orgID := src.int("MQTT_ORGANIZATION_ID", 0); orgID > 0 {
+		id := uint(orgID)
+		cfg.MQTT.OrganizationID = &id
+	}
+
+	cfg.DirWatch = dirwatch.Options{
+		Dir:           src.string("INGEST_DIR", ""),
+		ProcessedDir:  src.string("INGEST_PROCESSED_DIR", ""),
+		FailedDir:     src.string("INGEST_FAILED_DIR", ""),
+		PollInterval:  src.duration("INGEST_POLL_INTERVAL_SEC", 10, time.Second),
+		SettleTime:    src.duration("INGEST_SETTLE_SEC", 30, time.Second),
+		MaxVideoBytes: int64(src.int("INGEST_MAX_VIDEO_MB", 2048)) << 20,
+		Workers:       src.int("INGEST_WORKERS", 1),
+	}
+	if orgID := src.int("INGEST_ORGANIZATION_ID", 0); orgID > 0 {
+		id := uint(orgID)
+		cfg.DirWatch.OrganizationID = &id
+	}
+
+	cfg.RunMode = src.string("RUN_MODE", "all")
+	cfg.AnalysisQueue = service.AnalysisQueueOptions{
+		Dir:          src.string("JOB_QUEUE_DIR", "./jobs"),
+		Workers:      src.int("JOB_WORKERS", 2),
+		PollInterval: src.duration("JOB_POLL_INTERVAL_MS", 1000, time.Millisecond),
+		StaleAfter:   src.duration("JOB_STALE_SEC", 120, time.Second),
+		MaxAttempts:  src.int("JOB_MAX_ATTEMPTS", 3),
+		WaitTimeout:  src.duration("JOB_WAIT_TIMEOUT_SEC", 1800, time.Second),
+	}
+
+	cfg.ErrorReporting.DSN = src.secret("SENTRY_DSN", "")
+	cfg.ErrorReporting.Environment = src.string("SENTRY_ENVIRONMENT", cfg.Environment)
+	cfg.ErrorReporting.Timeout = src.duration("SENTRY_TIMEOUT_SEC", 5, time.Second)
+
+	cfg.ShutdownTimeout = src.duration("SHUTDOWN_TIMEOUT_SEC", 60, time.Second)
+
+	cfg.HTTPServer.ReadTimeout = src.duration("HTTP_READ_TIMEOUT_SEC", 600, time.Second)
+	cfg.HTTPServer.ReadHeaderTimeout = src.duration("HTTP_READ_HEADER_TIMEOUT_SEC", 10, time.Second)
+	cfg.HTTPServer.WriteTimeout = src.duration("HTTP_WRITE_TIMEOUT_SEC", 960, time.Second)
+	cfg.HTTPServer.IdleTimeout = src.duration("HTTP_IDLE_TIMEOUT_SEC", 120, time.Second)
+	cfg.HTTPServer.MaxHeaderBytes = src.int("HTTP_MAX_HEADER_BYTES", 1<<20)
+
+	cfg.Database.Connection = database.Config{
+		Host:     src.string("DB_HOST", "localhost"),
+		Port:     src.string("DB_PORT", "5432"),
+		Database: src.string("DB_NAME", "road_detector"),
+		Username: src.string("DB_USER", "postgres"),
+		Password: src.secret("DB_PASSWORD", "postgres123"),
+		SSLMode:  src.string("DB_SSL_MODE", "disable"),
+
+		ReplicaHost: src.string("DB_REPLICA_HOST", ""),
+		ReplicaPort: src.string("DB_REPLICA_PORT", ""),
+
+		Pool: database.PoolOptions{
+			MaxOpen:     src.int("DB_MAX_OPEN_CONNS", 100),
+			MaxIdle:     src.int("DB_MAX_IDLE_CONNS", 10),
+			MaxLifetime: src.duration("DB_CONN_MAX_LIFETIME_MIN", 60, time.Minute),
+			MaxIdleTime: src.duration("DB_CONN_MAX_IDLE_TIME_MIN", 0, time.Minute),
+		},
+	}
+	cfg.Database.Retry = database.RetryOptions{
+		MaxAttempts:  src.int("DB_CONNECT_MAX_ATTEMPTS", 0),
+		InitialDelay: src.duration("DB_CONNECT_RETRY_DELAY_MS", 1000, time.Millisecond),
+		MaxDelay:     src.duration("DB_CONNECT_MAX_DELAY_SEC", 15, time.Second),
+		MaxWait:      src.duration("DB_CONNECT_MAX_WAIT_SEC", 60, time.Second),
+	}
+	cfg.Database.StartDegraded = src.bool("DB_START_DEGRADED", false)
+	cfg.Database.SlowQueryThreshold = src.duration("DB_SLOW_QUERY_MS", 500, time.Millisecond)
+
+	cfg.Cache = cache.Options{
+		Size: src.int("CACHE_SIZE", 1000),
+		TTL:  src.duration("CACHE_TTL_SEC", 30, time.Second),
+	}
+
+	cfg.ReloadInterval = src.duration("CONFIG_RELOAD_INTERVAL_SEC", 0, time.Second)
+
+	return cfg
+}
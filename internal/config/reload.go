@@ -0,0 +1,41 @@
+package config
+
+import "sort"
+
+// reloadableKeys параметры, которые применяются без перезапуска сервиса
+var reloadableKeys = map[string]bool{
+	"LOG_LEVEL":                         true,
+	"RATE_LIMIT_RPS":                    true,
+	"RATE_LIMIT_BURST":                  true,
+	"PYTHON_API_BASE_URL":               true,
+	"PYTHON_API_TIMEOUT_SECONDS":        true,
+	"ANALYZER_TIMEOUT_MIN_SECONDS":      true,
+	"ANALYZER_TIMEOUT_MAX_SECONDS":      true,
+	"ANALYZER_TIMEOUT_PER_MB_SECONDS":   true,
+	"ANALYZER_TIMEOUT_PER_VIDEO_SECOND": true,
+	"QUOTA_MONTHLY_UPLOADS":             true,
+	"QUOTA_MONTHLY_ANALYSIS_MINUTES":    true,
+	"QUOTA_STORAGE_MB":                  true,
+	"DB_SLOW_QUERY_MS":                  true,
+	"SLOW_ANALYSIS_MINUTES":             true,
+	"LOW_CONFIDENCE_THRESHOLD":          true,
+	"COVERAGE_BANDS":                    true,
+}
+
+// Changes возвращает отсортированные имена параметров, значения которых
+// в next отличаются от c: применяемые без перезапуска и требующие его
+func (c *Config) Changes(next *Config) (reloadable, restart []string) {
+	for key, value := range next.values {
+		if old, ok := c.values[key]; ok && old == value {
+			continue
+		}
+		if reloadableKeys[key] {
+			reloadable = append(reloadable, key)
+		} else {
+			restart = append(restart, key)
+		}
+	}
+	sort.Strings(reloadable)
+	sort.Strings(restart)
+	return reloadable, restart
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Источники значений для сводки конфигурации
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+)
+
+// redacted значение секрета в сводке конфигурации
+const redacted = "***"
+
+// setting действующее значение параметра и его источник
+type setting struct {
+	value  string
+	source string
+	secret bool
+}
+
+// source читает параметры из переменных окружения и файла конфигурации.
+// Параметр в файле называется так же, как переменная окружения: вложенные
+// ключи объединяются через _, например server: {port: 8080} — SERVER_PORT.
+// Переменная окружения переопределяет значение из файла.
+type source struct {
+	file     map[string]string
+	settings map[string]setting
+	errs     []error
+}
+
+// newSource читает файл конфигурации path. Пустой path — только переменные окружения.
+func newSource(path string) (*source, error) {
+	s := &source{file: map[string]string{}, settings: map[string]setting{}}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	flatten("", tree, s.file)
+	return s, nil
+}
+
+// flatten записывает вложенные ключи tree в out под именами переменных окружения
+func flatten(prefix string, tree map[string]interface{}, out map[string]string) {
+	for key, value := range tree {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(name, v, out)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			out[name] = strings.Join(items, ",")
+		case nil:
+			out[name] = ""
+		default:
+			out[name] = fmt.Sprint(v)
+		}
+	}
+}
+
+// lookup возвращает значение параметра key из окружения или файла
+func (s *source) lookup(key string) (string, string, bool) {
+	if value := os.Getenv(key); value != "" {
+		return value, sourceEnv, true
+	}
+	if value, ok := s.file[key]; ok {
+		return value, sourceFile, true
+	}
+	return "", sourceDefault, false
+}
+
+// get возвращает значение параметра и запоминает его для сводки
+func (s *source) get(key, defaultValue string, secret bool) (string, bool) {
+	value, from, ok := s.lookup(key)
+	if !ok {
+		value = defaultValue
+	}
+	s.settings[key] = setting{value: value, source: from, secret: secret}
+	return value, ok
+}
+
+// invalid запоминает ошибку значения параметра key
+func (s *source) invalid(key, value, reason string) {
+	s.errs = append(s.errs, fmt.Errorf("%s=%q: %s", key, value, reason))
+}
+
+func (s *source) string(key, defaultValue string) string {
+	value, _ := s.get(key, defaultValue, false)
+	return value
+}
+
+// secret возвращает параметр, значение которого скрывается в сводке
+func (s *source) secret(key, defaultValue string) string {
+	value, _ := s.get(key, defaultValue, true)
+	return value
+}
+
+func (s *source) int(key string, defaultValue int) int {
+	value, ok := s.get(key, strconv.Itoa(defaultValue), false)
+	if !ok {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		s.invalid(key, value, "must be an integer")
+		return defaultValue
+	}
+	return parsed
+}
+
+func (s *source) float(key string, defaultValue float64) float64 {
+	value, ok := s.get(key, strconv.FormatFloat(defaultValue, 'g', -1, 64), false)
+	if !ok {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		s.invalid(key, value, "must be a number")
+		return defaultValue
+	}
+	return parsed
+}
+
+func (s *source) bool(key string, defaultValue bool) bool {
+	value, ok := s.get(key, strconv.FormatBool(defaultValue), false)
+	if !ok {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		s.invalid(key, value, "must be true or false")
+		return defaultValue
+	}
+	return parsed
+}
+
+// duration возвращает целое неотрицательное число единиц unit
+func (s *source) duration(key string, defaultValue int, unit time.Duration) time.Duration {
+	value := s.int(key, defaultValue)
+	if value < 0 {
+		s.invalid(key, strconv.Itoa(value), "must not be negative")
+		return time.Duration(defaultValue) * unit
+	}
+	return time.Duration(value) * unit
+}
+
+// list возвращает значения, перечисленные через запятую
+func (s *source) list(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(s.string(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// unknownKeys возвращает параметры файла, которые не были прочитаны,
+// обычно опечатки в именах
+func (s *source) unknownKeys() []string {
+	var keys []string
+	for key := range s.file {
+		if _, ok := s.settings[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// values возвращает действующие значения параметров без скрытия секретов
+func (s *source) values() map[string]string {
+	out := make(map[string]string, len(s.settings))
+	for key, setting := range s.settings {
+		out[key] = setting.value
+	}
+	return out
+}
+
+// summary возвращает действующие значения параметров с источником,
+// заданным не по умолчанию, например "8081 (env)". Секреты скрываются.
+func (s *source) summary() map[string]string {
+	out := make(map[string]string, len(s.settings))
+	for key, setting := range s.settings {
+		value := setting.value
+		if setting.secret && value != "" {
+			value = redacted
+		}
+		if setting.source != sourceDefault {
+			value += " (" + setting.source + ")"
+		}
+		out[key] = value
+	}
+	return out
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/analyzerpool"
+	"road-detector-go/internal/database"
+	"road-detector-go/internal/logging"
+)
+
+// MinJWTSecretLength минимальная длина ключа подписи токенов
+const MinJWTSecretLength = 32
+
+// validate проверяет значения, которые нельзя проверить при чтении
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, key string, value interface{}, reason string) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s=%q: %s", key, fmt.Sprint(value), reason))
+		}
+	}
+
+	check(validPort(c.Port), "SERVER_PORT", c.Port, "must be a port number between 1 and 65535")
+	check(validPort(c.Database.Connection.Port), "DB_PORT", c.Database.Connection.Port, "must be a port number between 1 and 65535")
+	if c.Database.Connection.ReplicaPort != "" {
+		check(validPort(c.Database.Connection.ReplicaPort), "DB_REPLICA_PORT", c.Database.Connection.ReplicaPort, "must be a port number between 1 and 65535")
+	}
+	check(len(c.PythonServices.URLs) > 0, "PYTHON_API_BASE_URL", "", "must not be empty")
+	for _, u := range c.PythonServices.URLs {
+		check(validURL(u), "PYTHON_API_BASE_URL", u, "must be a comma-separated list of http or https URLs")
+	}
+	switch c.PythonServices.Strategy {
+	case analyzerpool.StrategyLeastBusy, analyzerpool.StrategyRoundRobin:
+	default:
+		check(false, "PYTHON_API_BALANCING", c.PythonServices.Strategy, "must be least_busy or round_robin")
+	}
+	check(c.PythonServices.Discovery == "" || c.PythonServices.Discovery == analyzerpool.DiscoveryDNS,
+		"PYTHON_API_DISCOVERY", c.PythonServices.Discovery, "must be empty or dns")
+	check(c.PythonServices.HealthInterval > 0, "PYTHON_API_HEALTH_INTERVAL_SEC", c.PythonServices.HealthInterval, "must be positive")
+	check(c.AnalyzerTimeout.Min >= 0, "ANALYZER_TIMEOUT_MIN_SECONDS", c.AnalyzerTimeout.Min, "must not be negative")
+	check(c.AnalyzerTimeout.Max > 0 && c.AnalyzerTimeout.Max >= c.AnalyzerTimeout.Min,
+		"ANALYZER_TIMEOUT_MAX_SECONDS", c.AnalyzerTimeout.Max, "must be positive and not less than ANALYZER_TIMEOUT_MIN_SECONDS")
+	check(c.AnalyzerTimeout.PerMB >= 0, "ANALYZER_TIMEOUT_PER_MB_SECONDS", c.AnalyzerTimeout.PerMB, "must not be negative")
+	check(c.AnalyzerTimeout.PerVideoSecond >= 0, "ANALYZER_TIMEOUT_PER_VIDEO_SECOND", c.AnalyzerTimeout.PerVideoSecond, "must not be negative")
+	check(c.LowConfidenceThreshold >= 0 && c.LowConfidenceThreshold <= 1,
+		"LOW_CONFIDENCE_THRESHOLD", c.LowConfidenceThreshold, "must be between 0 and 1")
+	switch c.PythonServiceTransport {
+	case "http":
+	case "grpc":
+		check(validAddr(c.PythonServiceGRPCAddr), "PYTHON_API_GRPC_ADDR", c.PythonServiceGRPCAddr, "must be host:port")
+	default:
+		check(false, "PYTHON_API_TRANSPORT", c.PythonServiceTransport, "must be http or grpc")
+	}
+	switch c.AnalyzerBackend {
+	case "python":
+	case "onnx":
+		check(c.ONNX.ModelPath != "", "ONNX_MODEL_PATH", c.ONNX.ModelPath, "must be set for onnx backend")
+		check(c.ONNX.SampleFPS > 0, "ONNX_SAMPLE_FPS", c.ONNX.SampleFPS, "must be positive")
+		check(c.ONNX.InputWidth > 0, "ONNX_INPUT_WIDTH", c.ONNX.InputWidth, "must be positive")
+		check(c.ONNX.InputHeight > 0, "ONNX_INPUT_HEIGHT", c.ONNX.InputHeight, "must be positive")
+		check(c.ONNX.Threshold > 0 && c.ONNX.Threshold < 1, "ONNX_THRESHOLD", c.ONNX.Threshold, "must be between 0 and 1")
+		check(c.ONNX.MinMarkingPercent >= 0 && c.ONNX.MinMarkingPercent <= 100, "ONNX_MIN_MARKING_PERCENT", c.ONNX.MinMarkingPercent, "must be between 0 and 100")
+	default:
+		check(false, "ANALYZER_BACKEND", c.AnalyzerBackend, "must be python or onnx")
+	}
+	check(c.VideoChunking.Options.ChunkDuration >= 0, "VIDEO_CHUNK_SECONDS", c.VideoChunking.Options.ChunkDuration, "must not be negative")
+	check(c.VideoChunking.Parallelism > 0, "VIDEO_CHUNK_PARALLELISM", c.VideoChunking.Parallelism, "must be positive")
+	check(c.ImageSequences.MaxImages >= 2, "IMAGE_SEQUENCE_MAX_IMAGES", c.ImageSequences.MaxImages, "must be at least 2")
+	check(c.Quality.CoverageWeight >= 0, "QUALITY_WEIGHT_COVERAGE", c.Quality.CoverageWeight, "must not be negative")
+	check(c.Quality.DefectWeight >= 0, "QUALITY_WEIGHT_DEFECTS", c.Quality.DefectWeight, "must not be negative")
+	check(c.Quality.ConfidenceWeight >= 0, "QUALITY_WEIGHT_CONFIDENCE", c.Quality.ConfidenceWeight, "must not be negative")
+	check(c.Quality.CoverageWeight+c.Quality.DefectWeight > 0, "QUALITY_WEIGHT_COVERAGE",
+		c.Quality.CoverageWeight, "coverage or defect weight must be positive")
+	check(c.Quality.MaxDefectsPerKm > 0, "QUALITY_MAX_DEFECTS_PER_KM", c.Quality.MaxDefectsPerKm, "must be positive")
+	if c.Shadow.URL != "" {
+		check(validURL(c.Shadow.URL), "SHADOW_ANALYZER_URL", c.Shadow.URL, "must be an http or https URL")
+	}
+	check(c.Shadow.Percent >= 0 && c.Shadow.Percent <= 100, "SHADOW_ANALYZER_PERCENT", c.Shadow.Percent, "must be between 0 and 100")
+	if c.MapMatching.Provider != "" {
+		check(validURL(c.MapMatching.URL), "MAP_MATCHING_URL", c.MapMatching.URL, "must be an http or https URL")
+	}
+	if c.Geocoding.Options.Provider != "" {
+		check(validURL(c.Geocoding.Options.URL), "GEOCODING_URL", c.Geocoding.Options.URL, "must be an http or https URL")
+	}
+	if c.Alerts.SMTPAddr != "" {
+		check(validAddr(c.Alerts.SMTPAddr), "ALERT_SMTP_ADDR", c.Alerts.SMTPAddr, "must be host:port")
+		check(c.Alerts.EmailFrom != "", "ALERT_EMAIL_FROM", c.Alerts.EmailFrom, "must be set when ALERT_SMTP_ADDR is set")
+	}
+	if c.Alerts.TelegramToken != "" {
+		check(validURL(c.Alerts.TelegramURL), "ALERT_TELEGRAM_API_URL", c.Alerts.TelegramURL, "must be an http or https URL")
+	}
+	check(c.Alerts.Timeout > 0, "ALERT_NOTIFY_TIMEOUT_SEC", c.Alerts.Timeout, "must be positive")
+	if c.Alerts.RouteURL != "" {
+		check(validURL(c.Alerts.RouteURL), "NOTIFY_ROUTE_URL", c.Alerts.RouteURL, "must be an http or https URL")
+	}
+	check(c.Reports.Interval > 0, "REPORT_CHECK_INTERVAL_MIN", c.Reports.Interval, "must be positive")
+	check(c.Reports.Render.Timeout > 0, "REPORT_PDF_TIMEOUT_SEC", c.Reports.Render.Timeout, "must be positive")
+	if c.OIDC.IssuerURL != "" {
+		check(validURL(c.OIDC.IssuerURL), "OIDC_ISSUER_URL", c.OIDC.IssuerURL, "must be an http or https URL")
+	}
+	if c.ErrorReporting.DSN != "" {
+		// Значение DSN содержит ключ и в ошибку не попадает
+		check(validURL(c.ErrorReporting.DSN), "SENTRY_DSN", redacted, "must be an http or https URL")
+	}
+	if c.Diagnostics.Addr != "" {
+		check(validAddr(c.Diagnostics.Addr), "DIAGNOSTICS_ADDR", c.Diagnostics.Addr, "must be host:port")
+	}
+	if c.APIDocs.Enabled {
+		// Путь вида /static/swagger-ui подходит для закрытых сетей без доступа к CDN
+		check(validURL(c.APIDocs.SwaggerUIAssetsURL) || strings.HasPrefix(c.APIDocs.SwaggerUIAssetsURL, "/"),
+			"SWAGGER_UI_ASSETS_URL", c.APIDocs.SwaggerUIAssetsURL, "must be an http or https URL or an absolute path")
+	}
+	check(c.RequestLimits.MaxUploadBytes >= 0, "UPLOAD_MAX_MB", c.RequestLimits.MaxUploadBytes>>20, "must not be negative")
+	check(c.RequestLimits.MaxBodyBytes >= 0, "REQUEST_MAX_BODY_MB", c.RequestLimits.MaxBodyBytes>>20, "must not be negative")
+	check(c.RequestLimits.MultipartMemoryBytes > 0, "MULTIPART_MEMORY_MB", c.RequestLimits.MultipartMemoryBytes>>20, "must be positive")
+	check(c.RequestLimits.MultipartMaxParts >= 0, "MULTIPART_MAX_PARTS", c.RequestLimits.MultipartMaxParts, "must not be negative")
+	if c.Compression.Enabled {
+		check(c.Compression.MinBytes >= 0, "COMPRESSION_MIN_BYTES", c.Compression.MinBytes, "must not be negative")
+		check(c.Compression.Level >= 1 && c.Compression.Level <= 9, "COMPRESSION_LEVEL", c.Compression.Level, "must be between 1 and 9")
+	}
+	if c.GRPC.Addr != "" {
+		check(validAddr(c.GRPC.Addr), "GRPC_ADDR", c.GRPC.Addr, "must be host:port")
+	}
+	check(c.GRPC.MaxVideoBytes >= 0, "GRPC_MAX_VIDEO_MB", c.GRPC.MaxVideoBytes>>20, "must not be negative")
+	if c.MQTT.BrokerURL != "" {
+		check(validBrokerURL(c.MQTT.BrokerURL), "MQTT_BROKER_URL", c.MQTT.BrokerURL, "must be a tcp, ssl, mqtt, mqtts, ws or wss URL")
+		check(strings.Trim(c.MQTT.TopicPrefix, "/") != "" && !strings.ContainsAny(c.MQTT.TopicPrefix, "+#"),
+			"MQTT_TOPIC_PREFIX", c.MQTT.TopicPrefix, "must be a non-empty topic without wildcards")
+		check(c.MQTT.QoS <= 2, "MQTT_QOS", c.MQTT.QoS, "must be 0, 1 or 2")
+		check(c.MQTT.MaxClipBytes >= 0, "MQTT_MAX_CLIP_MB", c.MQTT.MaxClipBytes>>20, "must not be negative")
+		check(c.MQTT.AssemblyTimeout > 0, "MQTT_ASSEMBLY_TIMEOUT_SEC", c.MQTT.AssemblyTimeout, "must be positive")
+		check(c.MQTT.Workers >= 1, "MQTT_WORKERS", c.MQTT.Workers, "must be at least 1")
+	}
+	if c.DirWatch.Dir != "" {
+		check(c.DirWatch.PollInterval > 0, "INGEST_POLL_INTERVAL_SEC", c.DirWatch.PollInterval, "must be positive")
+		check(c.DirWatch.SettleTime >= 0, "INGEST_SETTLE_SEC", c.DirWatch.SettleTime, "must not be negative")
+		check(c.DirWatch.MaxVideoBytes >= 0, "INGEST_MAX_VIDEO_MB", c.DirWatch.MaxVideoBytes>>20, "must not be negative")
+		check(c.DirWatch.Workers >= 1, "INGEST_WORKERS", c.DirWatch.Workers, "must be at least 1")
+	}
+	switch c.RunMode {
+	case "all":
+	case "api", "worker":
+		check(c.AnalysisQueue.Dir != "", "JOB_QUEUE_DIR", c.AnalysisQueue.Dir, "must be set for api and worker modes")
+		check(c.AnalysisQueue.Workers >= 1, "JOB_WORKERS", c.AnalysisQueue.Workers, "must be at least 1")
+		check(c.AnalysisQueue.PollInterval > 0, "JOB_POLL_INTERVAL_MS", c.AnalysisQueue.PollInterval, "must be positive")
+		check(c.AnalysisQueue.StaleAfter >= 3*time.Second, "JOB_STALE_SEC", c.AnalysisQueue.StaleAfter, "must be at least 3 seconds")
+		check(c.AnalysisQueue.MaxAttempts >= 1, "JOB_MAX_ATTEMPTS", c.AnalysisQueue.MaxAttempts, "must be at least 1")
+		check(c.AnalysisQueue.WaitTimeout >= 0, "JOB_WAIT_TIMEOUT_SEC", c.AnalysisQueue.WaitTimeout, "must not be negative")
+	default:
+		check(false, "RUN_MODE", c.RunMode, "must be all, api or worker")
+	}
+	if c.TLS.RedirectAddr != "" {
+		check(validAddr(c.TLS.RedirectAddr), "TLS_REDIRECT_ADDR", c.TLS.RedirectAddr, "must be host:port")
+	}
+
+	switch c.PostGISMode {
+	case database.PostGISAuto, database.PostGISOn, database.PostGISOff:
+	default:
+		check(false, "POSTGIS_MODE", c.PostGISMode, "must be auto, on or off")
+	}
+	_, err := logging.ParseLevel(c.Logging.Level)
+	check(err == nil, "LOG_LEVEL", c.Logging.Level, "must be trace, debug, info, warn, error, fatal or panic")
+	format := strings.ToLower(c.Logging.Format)
+	check(format == logging.FormatJSON || format == logging.FormatText,
+		"LOG_FORMAT", c.Logging.Format, "must be json or text")
+
+	check(c.RateLimit.RPS >= 0, "RATE_LIMIT_RPS", c.RateLimit.RPS, "must not be negative")
+	check(c.Cache.Size >= 0, "CACHE_SIZE", c.Cache.Size, "must not be negative")
+	check(c.Database.Connection.Pool.MaxOpen >= 0, "DB_MAX_OPEN_CONNS", c.Database.Connection.Pool.MaxOpen, "must not be negative")
+	check(c.Database.Connection.Pool.MaxIdle >= 0, "DB_MAX_IDLE_CONNS", c.Database.Connection.Pool.MaxIdle, "must not be negative")
+	if pool := c.Database.Connection.Pool; pool.MaxOpen > 0 && pool.MaxIdle > pool.MaxOpen {
+		check(false, "DB_MAX_IDLE_CONNS", pool.MaxIdle, "must not exceed DB_MAX_OPEN_CONNS")
+	}
+	rates := []struct {
+		key  string
+		rate float64
+	}{
+		{"CHAOS_HTTP_ERROR_RATE", c.Chaos.HTTP.ErrorRate},
+		{"CHAOS_HTTP_TRUNCATE_RATE", c.Chaos.HTTP.TruncateRate},
+		{"CHAOS_DB_ERROR_RATE", c.Chaos.DB.ErrorRate},
+	}
+	for _, r := range rates {
+		check(r.rate >= 0 && r.rate <= 1, r.key, r.rate, "must be between 0 and 1")
+	}
+
+	if c.Users.Enabled {
+		check(len(c.Users.Secret) >= MinJWTSecretLength, "JWT_SECRET", redacted,
+			fmt.Sprintf("must be at least %d characters long", MinJWTSecretLength))
+	}
+	return errs
+}
+
+// validPort проверяет номер порта
+func validPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port >= 1 && port <= 65535
+}
+
+// validURL проверяет, что значение — абсолютный http или https URL
+func validURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// validBrokerURL проверяет адрес брокера MQTT
+func validBrokerURL(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	switch parsed.Scheme {
+	case "tcp", "ssl", "ws", "wss", "mqtt", "mqtts":
+		return true
+	}
+	return false
+}
+
+// validAddr проверяет адрес вида host:port или :port
+func validAddr(value string) bool {
+	_, port, err := net.SplitHostPort(value)
+	return err == nil && validPort(port)
+}
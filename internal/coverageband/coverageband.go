@@ -0,0 +1,151 @@
+// Package coverageband делит покрытие разметкой на полосы, например
+// критическое, предупреждение и норма, чтобы все клиенты раскрашивали
+// карту одинаково.
+package coverageband
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Band полоса покрытия: покрытие от Min включительно до Min следующей полосы
+type Band struct {
+	Name  string  `json:"name"`
+	Min   float64 `json:"min"`
+	Color string  `json:"color"`
+}
+
+// DefaultSpec полосы по умолчанию: меньше 40% — критическое покрытие,
+// от 40 до 70% — предупреждение, от 70% — норма
+const DefaultSpec = "critical:0:#d32f2f,warning:40:#f9a825,ok:70:#2e7d32"
+
+var (
+	// namePattern допустимое название полосы
+	namePattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+	// colorPattern цвет полосы в формате #rrggbb
+	colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// Parse разбирает полосы, заданные как название:нижняя граница:цвет,
+// например warning:40:#f9a825, и проверяет их
+func Parse(items []string) ([]Band, error) {
+	bands := make([]Band, 0, len(items))
+	for _, item := range items {
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("band %q must be name:min:color", item)
+		}
+		lower, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("band %q: min must be a number", item)
+		}
+		bands = append(bands, Band{
+			Name:  strings.TrimSpace(parts[0]),
+			Min:   lower,
+			Color: strings.TrimSpace(parts[2]),
+		})
+	}
+	if err := Validate(bands); err != nil {
+		return nil, err
+	}
+	return bands, nil
+}
+
+// Validate проверяет полосы: хотя бы одна, первая начинается с 0, нижние
+// границы возрастают и не больше 100, названия не повторяются
+func Validate(bands []Band) error {
+	if len(bands) == 0 {
+		return fmt.Errorf("at least one band is required")
+	}
+	names := make(map[string]bool, len(bands))
+	for i, band := range bands {
+		if !namePattern.MatchString(band.Name) {
+			return fmt.Errorf("band name %q must be 1-32 characters a-z, 0-9 or _", band.Name)
+		}
+		if names[band.Name] {
+			return fmt.Errorf("duplicate band %q", band.Name)
+		}
+		names[band.Name] = true
+		if !colorPattern.MatchString(band.Color) {
+			return fmt.Errorf("band %q: color %q must be #rrggbb", band.Name, band.Color)
+		}
+		switch {
+		case i == 0 && band.Min != 0:
+			return fmt.Errorf("band %q: first band must start at 0", band.Name)
+		case i > 0 && band.Min <= bands[i-1].Min:
+			return fmt.Errorf("band %q: min must be greater than min of %q", band.Name, bands[i-1].Name)
+		case band.Min > 100:
+			return fmt.Errorf("band %q: min must not exceed 100", band.Name)
+		}
+	}
+	return nil
+}
+
+// Format записывает полосы в формате Parse
+func Format(bands []Band) string {
+	items := make([]string, len(bands))
+	for i, band := range bands {
+		items[i] = band.Name + ":" + strconv.FormatFloat(band.Min, 'g', -1, 64) + ":" + band.Color
+	}
+	return strings.Join(items, ",")
+}
+
+// Classifier относит покрытие к полосе. Полосы можно заменить во время
+// работы сервиса.
+type Classifier struct {
+	mu    sync.RWMutex
+	bands []Band
+}
+
+// New создает Classifier с проверенными полосами
+func New(bands []Band) (*Classifier, error) {
+	c := &Classifier{}
+	if err := c.Set(bands); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Set заменяет полосы, если они проходят проверку
+func (c *Classifier) Set(bands []Band) error {
+	if err := Validate(bands); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bands = append([]Band(nil), bands...)
+	return nil
+}
+
+// Bands возвращает копию действующих полос
+func (c *Classifier) Bands() []Band {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Band(nil), c.bands...)
+}
+
+// Classify возвращает полосу, к которой относится покрытие в процентах.
+// Покрытие меньше 0 относится к первой полосе.
+func (c *Classifier) Classify(coverage float64) Band {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	band := c.bands[0]
+	for _, candidate := range c.bands[1:] {
+		if coverage < candidate.Min {
+			break
+		}
+		band = candidate
+	}
+	return band
+}
+
+// String возвращает действующие полосы в формате Parse, для nil — пустую строку
+func (c *Classifier) String() string {
+	if c == nil {
+		return ""
+	}
+	return Format(c.Bands())
+}
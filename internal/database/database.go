@@ -1,9 +1,10 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -12,8 +13,18 @@ import (
 	"road-detector-go/internal/model"
 )
 
-// DB глобальная переменная для подключения к базе данных
-var DB *gorm.DB
+// SchemaVersion версия схемы базы данных, соответствует номеру последней
+// миграции в каталоге migrations. Увеличивается вместе с новыми миграциями.
+const SchemaVersion = 39
+
+// Handle подключение к базе данных: пул соединений GORM и признак того,
+// что база данных доступна и миграции выполнены
+type Handle struct {
+	db *gorm.DB
+	// replica реплика для чтения, nil — не настроена
+	replica *gorm.DB
+	ready   atomic.Bool
+}
 
 // Config конфигурация базы данных
 type Config struct {
@@ -23,109 +34,289 @@ type Config struct {
 	Username string
 	Password string
 	SSLMode  string
+	// ReplicaHost адрес реплики для чтения с теми же базой и учетными
+	// данными, пусто — реплика не используется
+	ReplicaHost string
+	// ReplicaPort порт реплики, пусто — как у основной базы
+	ReplicaPort string
+	// Pool размер пула соединений, общий для основной базы и реплики
+	Pool PoolOptions
+	// Logger логгер запросов GORM, nil — запросы не логируются
+	Logger logger.Interface
+}
+
+// PoolOptions ограничения пула соединений
+type PoolOptions struct {
+	// MaxOpen наибольшее число открытых соединений, 0 — без ограничения
+	MaxOpen int
+	// MaxIdle сколько простаивающих соединений держать открытыми
+	MaxIdle int
+	// MaxLifetime через сколько закрывать соединение, 0 — не закрывать
+	MaxLifetime time.Duration
+	// MaxIdleTime через сколько закрывать простаивающее соединение, 0 — не закрывать
+	MaxIdleTime time.Duration
+}
+
+// PoolStats состояние пула соединений
+type PoolStats struct {
+	MaxOpen int `json:"max_open"`
+	Open    int `json:"open"`
+	InUse   int `json:"in_use"`
+	Idle    int `json:"idle"`
+	// WaitCount и WaitDurationMs сколько раз и как долго запросы ждали
+	// свободного соединения с момента запуска
+	WaitCount      int64   `json:"wait_count"`
+	WaitDurationMs float64 `json:"wait_duration_ms"`
+	// Соединения, закрытые из-за MaxIdle, MaxIdleTime и MaxLifetime
+	MaxIdleClosed     int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+// RetryOptions повторы подключения к базе данных при запуске
+type RetryOptions struct {
+	// MaxAttempts сколько попыток выполнить, 0 — без ограничения в пределах MaxWait
+	MaxAttempts int
+	// InitialDelay пауза после первой неудачной попытки, далее она удваивается
+	InitialDelay time.Duration
+	// MaxDelay наибольшая пауза между попытками
+	MaxDelay time.Duration
+	// MaxWait сколько всего ждать подключения, 0 — без ограничения
+	MaxWait time.Duration
 }
 
-// Connect подключается к базе данных PostgreSQL
-func Connect() error {
-	config := Config{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnv("DB_PORT", "5432"),
-		Database: getEnv("DB_NAME", "road_detector"),
-		Username: getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "postgres123"),
-		SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+// Connect подключается к базе данных PostgreSQL и проверяет соединение
+func Connect(config Config) (*Handle, error) {
+	h, err := Open(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.HealthCheck(); err != nil {
+		h.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	log.Println("✅ Successfully connected to PostgreSQL database")
+	return h, nil
+}
+
+// Open создает пул соединений без проверки доступности сервера БД.
+// Соединения устанавливаются при первом запросе.
+func Open(config Config) (*Handle, error) {
+	db, err := openPool(config, config.Host, config.Port)
+	if err != nil {
+		return nil, err
+	}
+	h := &Handle{db: db}
+
+	if config.ReplicaHost != "" {
+		port := config.ReplicaPort
+		if port == "" {
+			port = config.Port
+		}
+		h.replica, err = openPool(config, config.ReplicaHost, port)
+		if err != nil {
+			h.Close()
+			return nil, fmt.Errorf("failed to open read replica: %w", err)
+		}
+	}
+	return h, nil
+}
+
+// openPool создает пул соединений GORM с сервером host:port
+func openPool(config Config, host, port string) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.Username, config.Password, config.Database, config.SSLMode,
+		host, port, config.Username, config.Password, config.Database, config.SSLMode,
 	)
 
-	// Настройка логгера GORM
-	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
-		logger.Config{
-			SlowThreshold:             time.Second,   // Slow SQL threshold
-			LogLevel:                  logger.Silent, // Log level
-			IgnoreRecordNotFoundError: true,          // Ignore ErrRecordNotFound error for logger
-			Colorful:                  false,         // Disable color
-		},
-	)
+	queryLogger := config.Logger
+	if queryLogger == nil {
+		queryLogger = logger.Default.LogMode(logger.Silent)
+	}
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: newLogger,
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: queryLogger,
+		// Доступность проверяется отдельно, чтобы можно было повторять попытки
+		DisableAutomaticPing: true,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	// Настройка пула соединений
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("failed to get database instance: %w", err)
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetMaxIdleConns(config.Pool.MaxIdle)
+	sqlDB.SetMaxOpenConns(config.Pool.MaxOpen)
+	sqlDB.SetConnMaxLifetime(config.Pool.MaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.Pool.MaxIdleTime)
+	return db, nil
+}
 
-	log.Println("✅ Successfully connected to PostgreSQL database")
-	return nil
+// PoolStats возвращает состояние пулов соединений основной базы (primary)
+// и реплики (replica), если она настроена
+func (h *Handle) PoolStats() map[string]PoolStats {
+	stats := make(map[string]PoolStats, 2)
+	for name, db := range map[string]*gorm.DB{"primary": h.db, "replica": h.replica} {
+		if db == nil {
+			continue
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			continue
+		}
+		s := sqlDB.Stats()
+		stats[name] = PoolStats{
+			MaxOpen:           s.MaxOpenConnections,
+			Open:              s.OpenConnections,
+			InUse:             s.InUse,
+			Idle:              s.Idle,
+			WaitCount:         s.WaitCount,
+			WaitDurationMs:    float64(s.WaitDuration.Microseconds()) / 1000,
+			MaxIdleClosed:     s.MaxIdleClosed,
+			MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+			MaxLifetimeClosed: s.MaxLifetimeClosed,
+		}
+	}
+	return stats
 }
 
-// Migrate выполняет автомиграции
-func Migrate() error {
-	if DB == nil {
-		return fmt.Errorf("database connection is not initialized")
+// WaitForConnection проверяет соединение, повторяя попытки с удваивающейся
+// паузой, пока не закончатся попытки или время ожидания
+func (h *Handle) WaitForConnection(opts RetryOptions) error {
+	started := time.Now()
+	delay := opts.InitialDelay
+	if delay <= 0 {
+		delay = time.Second
 	}
+	for attempt := 1; ; attempt++ {
+		err := h.HealthCheck()
+		if err == nil {
+			log.Println("✅ Successfully connected to PostgreSQL database")
+			return nil
+		}
+		if (opts.MaxAttempts > 0 && attempt >= opts.MaxAttempts) ||
+			(opts.MaxWait > 0 && time.Since(started)+delay > opts.MaxWait) {
+			return fmt.Errorf("failed to connect to database after %d attempts: %w", attempt, err)
+		}
+
+		log.Printf("⚠️ Database is not available (attempt %d), retrying in %s: %v", attempt, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+		if opts.MaxDelay > 0 && delay > opts.MaxDelay {
+			delay = opts.MaxDelay
+		}
+	}
+}
 
+// Gorm возвращает пул соединений GORM для репозиториев
+func (h *Handle) Gorm() *gorm.DB {
+	return h.db
+}
+
+// Reader возвращает пул соединений для тяжелых запросов чтения: реплику,
+// если она настроена, иначе основную базу. Данные реплики могут отставать.
+func (h *Handle) Reader() *gorm.DB {
+	if h.replica != nil {
+		return h.replica
+	}
+	return h.db
+}
+
+// HasReplica проверяет, что настроена реплика для чтения
+func (h *Handle) HasReplica() bool {
+	return h.replica != nil
+}
+
+// Use подключает плагин GORM к основной базе и реплике
+func (h *Handle) Use(plugin gorm.Plugin) error {
+	if err := h.db.Use(plugin); err != nil {
+		return err
+	}
+	if h.replica != nil {
+		return h.replica.Use(plugin)
+	}
+	return nil
+}
+
+// Ready проверяет, что база данных доступна и подготовлена к работе
+func (h *Handle) Ready() bool {
+	return h.ready.Load()
+}
+
+// MarkReady отмечает базу данных подготовленной после миграций
+func (h *Handle) MarkReady() {
+	h.ready.Store(true)
+}
+
+// Migrate выполняет автомиграции
+func (h *Handle) Migrate() error {
 	log.Println("🔄 Running database migrations...")
 
-	err := DB.AutoMigrate(
+	err := h.db.AutoMigrate(
 		&model.Route{},
 		&model.Segment{},
+		&model.Road{},
+		&model.RoadSegment{},
+		&model.RoadObservation{},
+		&model.Tag{},
+		&model.APIKey{},
+		&model.User{},
+		&model.Organization{},
+		&model.OrganizationMember{},
+		&model.UsageCounter{},
+		&model.AuditEntry{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
+		&model.ShareLink{},
+		&model.OutboxEvent{},
+		&model.ShadowAnalysis{},
+		&model.AlertRule{},
+		&model.Alert{},
+		&model.ReportSchedule{},
+		&model.Report{},
+		&model.Boundary{},
+		&model.NotificationPreference{},
+		&model.AnalysisJob{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	h.setupFullTextSearch()
+
 	log.Println("✅ Database migrations completed successfully")
 	return nil
 }
 
-// Close закрывает соединение с базой данных
-func Close() error {
-	if DB == nil {
-		return nil
-	}
-
-	sqlDB, err := DB.DB()
-	if err != nil {
-		return err
+// Close закрывает соединения с основной базой и репликой
+func (h *Handle) Close() error {
+	var errs []error
+	for _, db := range []*gorm.DB{h.db, h.replica} {
+		if db == nil {
+			continue
+		}
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
 	}
-
-	return sqlDB.Close()
+	return errors.Join(errs...)
 }
 
 // HealthCheck проверяет состояние подключения к базе данных
-func HealthCheck() error {
-	if DB == nil {
-		return fmt.Errorf("database connection is not initialized")
-	}
-
-	sqlDB, err := DB.DB()
+func (h *Handle) HealthCheck() error {
+	sqlDB, err := h.db.DB()
 	if err != nil {
 		return err
 	}
 
 	return sqlDB.Ping()
 }
-
-// getEnv получает переменную окружения или возвращает значение по умолчанию
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-} 
\ No newline at end of file
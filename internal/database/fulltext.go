@@ -0,0 +1,35 @@
+package database
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// fullTextStatements повторяет migrations/000012_add_routes_search_vector.up.sql.
+// Генерируемые колонки требуют PostgreSQL 12+.
+var fullTextStatements = []string{
+	`ALTER TABLE routes ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian',
+        coalesce(name, '') || ' ' || coalesce(description, '') || ' ' || coalesce(road_name, ''))) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_routes_search_vector ON routes USING GIN (search_vector)`,
+}
+
+// setupFullTextSearch создает колонку полнотекстового поиска по маршрутам.
+// При ошибке поиск работает через ILIKE, запуск не прерывается.
+func (h *Handle) setupFullTextSearch() {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range fullTextStatements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("⚠️ Full-text search index is unavailable, route search falls back to ILIKE: %v", err)
+		return
+	}
+
+	log.Println("✅ Full-text search index for routes is ready")
+}
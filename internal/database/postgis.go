@@ -0,0 +1,98 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// Режимы поддержки PostGIS
+const (
+	// PostGISAuto включает PostGIS, если расширение доступно на сервере БД
+	PostGISAuto = "auto"
+	// PostGISOn требует PostGIS и завершает запуск с ошибкой, если его нет
+	PostGISOn = "on"
+	// PostGISOff всегда использует координатные колонки без PostGIS
+	PostGISOff = "off"
+)
+
+// postgisStatements повторяет migrations/000006_add_postgis_geometry.up.sql
+var postgisStatements = []string{
+	`ALTER TABLE segments ADD COLUMN IF NOT EXISTS geom geometry(LineString, 4326)`,
+	`ALTER TABLE routes ADD COLUMN IF NOT EXISTS geom geometry(LineString, 4326)`,
+	`CREATE OR REPLACE FUNCTION segments_set_geom() RETURNS trigger AS $$
+BEGIN
+    NEW.geom := ST_SetSRID(ST_MakeLine(ST_MakePoint(NEW.start_lon, NEW.start_lat), ST_MakePoint(NEW.end_lon, NEW.end_lat)), 4326);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS trg_segments_geom ON segments`,
+	`CREATE TRIGGER trg_segments_geom BEFORE INSERT OR UPDATE OF start_lat, start_lon, end_lat, end_lon ON segments
+    FOR EACH ROW EXECUTE FUNCTION segments_set_geom()`,
+	`CREATE OR REPLACE FUNCTION routes_set_geom() RETURNS trigger AS $$
+BEGIN
+    NEW.geom := ST_SetSRID(ST_MakeLine(ST_MakePoint(NEW.start_lon, NEW.start_lat), ST_MakePoint(NEW.end_lon, NEW.end_lat)), 4326);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS trg_routes_geom ON routes`,
+	`CREATE TRIGGER trg_routes_geom BEFORE INSERT OR UPDATE OF start_lat, start_lon, end_lat, end_lon ON routes
+    FOR EACH ROW EXECUTE FUNCTION routes_set_geom()`,
+	`UPDATE segments SET geom = ST_SetSRID(ST_MakeLine(ST_MakePoint(start_lon, start_lat), ST_MakePoint(end_lon, end_lat)), 4326) WHERE geom IS NULL`,
+	`UPDATE routes SET geom = ST_SetSRID(ST_MakeLine(ST_MakePoint(start_lon, start_lat), ST_MakePoint(end_lon, end_lat)), 4326) WHERE geom IS NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_segments_geom ON segments USING GIST (geom)`,
+	`CREATE INDEX IF NOT EXISTS idx_segments_geog ON segments USING GIST ((geom::geography))`,
+	`CREATE INDEX IF NOT EXISTS idx_routes_geom ON routes USING GIST (geom)`,
+}
+
+// SetupPostGIS подготавливает геометрические колонки, триггеры и GiST индексы.
+// Возвращает true, если репозитории могут использовать пространственные запросы.
+// В режиме auto отсутствие расширения не считается ошибкой.
+func (h *Handle) SetupPostGIS(mode string) (bool, error) {
+	switch mode {
+	case PostGISOff:
+		log.Println("ℹ️ PostGIS disabled by configuration")
+		return false, nil
+	case PostGISAuto, PostGISOn:
+	default:
+		return false, fmt.Errorf("unknown PostGIS mode %q", mode)
+	}
+
+	var available bool
+	if err := h.db.Raw("SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'postgis')").
+		Scan(&available).Error; err != nil {
+		return false, fmt.Errorf("failed to check PostGIS availability: %w", err)
+	}
+
+	if available {
+		if err := h.db.Exec("CREATE EXTENSION IF NOT EXISTS postgis").Error; err != nil {
+			if mode == PostGISOn {
+				return false, fmt.Errorf("failed to create PostGIS extension: %w", err)
+			}
+			log.Printf("⚠️ PostGIS is available but could not be enabled, falling back: %v", err)
+			return false, nil
+		}
+	} else {
+		if mode == PostGISOn {
+			return false, fmt.Errorf("PostGIS extension is not installed on the database server")
+		}
+		log.Println("ℹ️ PostGIS is not installed, using coordinate columns for spatial queries")
+		return false, nil
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range postgisStatements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare PostGIS geometry: %w", err)
+	}
+
+	log.Println("✅ PostGIS geometry and spatial indexes are ready")
+	return true, nil
+}
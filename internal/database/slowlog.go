@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+)
+
+// SlowQueryLogger логгер GORM, который пишет в лог только запросы дольше
+// порога, с подставленными параметрами, и считает их. Остальные сообщения
+// GORM не выводятся.
+type SlowQueryLogger struct {
+	log       *logrus.Logger
+	threshold atomic.Int64
+	count     atomic.Int64
+}
+
+// NewSlowQueryLogger создает логгер медленных запросов. Порог 0 отключает логирование.
+func NewSlowQueryLogger(log *logrus.Logger, threshold time.Duration) *SlowQueryLogger {
+	l := &SlowQueryLogger{log: log}
+	l.threshold.Store(int64(threshold))
+	return l
+}
+
+// SetThreshold меняет порог медленного запроса без перезапуска
+func (l *SlowQueryLogger) SetThreshold(threshold time.Duration) {
+	l.threshold.Store(int64(threshold))
+}
+
+// Count возвращает количество медленных запросов с момента запуска
+func (l *SlowQueryLogger) Count() int64 {
+	return l.count.Load()
+}
+
+// LogMode уровень сообщений GORM не меняет: выводятся только медленные запросы
+func (l *SlowQueryLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l *SlowQueryLogger) Info(context.Context, string, ...interface{})  {}
+func (l *SlowQueryLogger) Warn(context.Context, string, ...interface{})  {}
+func (l *SlowQueryLogger) Error(context.Context, string, ...interface{}) {}
+
+// Trace пишет в лог запрос, выполнявшийся дольше порога
+func (l *SlowQueryLogger) Trace(_ context.Context, begin time.Time, fc func() (string, int64), err error) {
+	threshold := time.Duration(l.threshold.Load())
+	elapsed := time.Since(begin)
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	l.count.Add(1)
+	sql, rows := fc()
+	entry := l.log.WithFields(logrus.Fields{
+		"duration_ms":  float64(elapsed.Microseconds()) / 1000,
+		"threshold_ms": threshold.Milliseconds(),
+		"rows":         rows,
+		"sql":          sql,
+	})
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	entry.Warn("Медленный запрос к базе данных")
+}
@@ -0,0 +1,254 @@
+// Package debugcapture собирает отладочные пакеты неудачных анализов:
+// параметры запроса, ответ Python сервиса, длительности этапов и логи.
+// Секреты в заголовках и URL маскируются перед сохранением.
+package debugcapture
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// bodySnippetLimit максимальный размер сохраняемого фрагмента ответа
+	bodySnippetLimit = 4096
+	// maxLogEntries ограничивает количество строк лога в пакете
+	maxLogEntries = 500
+	// redacted значение, подставляемое вместо секретов
+	redacted = "[REDACTED]"
+)
+
+// sensitiveHeaders заголовки, значения которых не сохраняются
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// Bundle отладочный пакет одного неудачного анализа
+type Bundle struct {
+	ID        string            `json:"id"`
+	RouteID   string            `json:"route_id"`
+	CreatedAt time.Time         `json:"created_at"`
+	Error     string            `json:"error"`
+	Request   map[string]string `json:"request"`
+	Upstream  *Upstream         `json:"upstream,omitempty"`
+	Timings   []Timing          `json:"timings"`
+	Logs      []LogEntry        `json:"logs"`
+}
+
+// Summary краткое описание пакета для списка
+type Summary struct {
+	ID        string    `json:"id"`
+	RouteID   string    `json:"route_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Error     string    `json:"error"`
+}
+
+// Upstream ответ Python сервиса
+type Upstream struct {
+	URL         string            `json:"url"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	BodySnippet string            `json:"body_snippet,omitempty"`
+}
+
+// Timing длительность этапа анализа
+type Timing struct {
+	Stage      string  `json:"stage"`
+	DurationMs float64 `json:"duration_ms"`
+	Failed     bool    `json:"failed,omitempty"`
+}
+
+// LogEntry строка лога, записанная во время анализа
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+// Recorder накапливает данные для пакета во время одного анализа.
+// Реализует logrus.Hook, чтобы перехватывать логи анализа.
+type Recorder struct {
+	mu       sync.Mutex
+	bundle   Bundle
+	started  map[string]time.Time
+	dropLogs int
+	// onStage вызывается в начале каждого этапа, nil — не вызывается
+	onStage func(stage string)
+}
+
+// NewRecorder создает recorder для анализа маршрута routeID
+func NewRecorder(routeID string) *Recorder {
+	return &Recorder{
+		bundle: Bundle{
+			ID:      uuid.New().String(),
+			RouteID: routeID,
+			Request: make(map[string]string),
+		},
+		started: make(map[string]time.Time),
+	}
+}
+
+// SetRouteID обновляет ID маршрута, если он был сгенерирован позже
+func (r *Recorder) SetRouteID(routeID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bundle.RouteID = routeID
+}
+
+// SetParam сохраняет параметр запроса
+func (r *Recorder) SetParam(key, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bundle.Request[key] = value
+}
+
+// Params возвращает копию сохраненных параметров запроса
+func (r *Recorder) Params() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	params := make(map[string]string, len(r.bundle.Request))
+	for key, value := range r.bundle.Request {
+		params[key] = value
+	}
+	return params
+}
+
+// Timings возвращает длительности завершенных этапов
+func (r *Recorder) Timings() []Timing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Timing(nil), r.bundle.Timings...)
+}
+
+// SetStageHook задает функцию, которая вызывается в начале каждого этапа
+func (r *Recorder) SetStageHook(fn func(stage string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onStage = fn
+}
+
+// StartStage отмечает начало этапа
+func (r *Recorder) StartStage(stage string) {
+	r.mu.Lock()
+	r.started[stage] = time.Now()
+	onStage := r.onStage
+	r.mu.Unlock()
+	if onStage != nil {
+		onStage(stage)
+	}
+}
+
+// EndStage фиксирует длительность этапа
+func (r *Recorder) EndStage(stage string, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	start, ok := r.started[stage]
+	if !ok {
+		return
+	}
+	delete(r.started, stage)
+	r.bundle.Timings = append(r.bundle.Timings, Timing{
+		Stage:      stage,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Failed:     failed,
+	})
+}
+
+// SetUpstream сохраняет ответ Python сервиса с замаскированными секретами
+func (r *Recorder) SetUpstream(rawURL string, resp *http.Response, body []byte) {
+	upstream := &Upstream{URL: RedactURL(rawURL)}
+	if resp != nil {
+		upstream.StatusCode = resp.StatusCode
+		upstream.Headers = RedactHeaders(resp.Header)
+	}
+	if len(body) > bodySnippetLimit {
+		body = body[:bodySnippetLimit]
+	}
+	upstream.BodySnippet = strings.ToValidUTF8(string(body), "?")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bundle.Upstream = upstream
+}
+
+// Levels перехватывает логи всех уровней
+func (r *Recorder) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire сохраняет строку лога в пакет
+func (r *Recorder) Fire(entry *logrus.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.bundle.Logs) >= maxLogEntries {
+		r.dropLogs++
+		return nil
+	}
+	r.bundle.Logs = append(r.bundle.Logs, LogEntry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+	})
+	return nil
+}
+
+// Finish завершает пакет с ошибкой анализа и возвращает его копию
+func (r *Recorder) Finish(err error) Bundle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bundle := r.bundle
+	bundle.CreatedAt = time.Now()
+	if err != nil {
+		bundle.Error = err.Error()
+	}
+	if r.dropLogs > 0 {
+		bundle.Logs = append(bundle.Logs, LogEntry{
+			Time:    bundle.CreatedAt,
+			Level:   "warning",
+			Message: "log entries truncated",
+		})
+	}
+	return bundle
+}
+
+// RedactHeaders копирует заголовки, маскируя чувствительные значения
+func RedactHeaders(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for key, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+			result[key] = redacted
+			continue
+		}
+		result[key] = strings.Join(values, ", ")
+	}
+	return result
+}
+
+// RedactURL убирает пароль и значения параметров запроса из URL
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redacted
+	}
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query.Set(key, redacted)
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,146 @@
+package debugcapture
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrBundleNotFound возвращается, если пакет с указанным ID отсутствует
+var ErrBundleNotFound = errors.New("debug bundle not found")
+
+// Store хранит отладочные пакеты в виде JSON файлов в каталоге
+type Store struct {
+	dir        string
+	maxBundles int
+}
+
+// NewStore создает хранилище пакетов. При превышении maxBundles удаляются
+// самые старые пакеты; 0 отключает ограничение.
+func NewStore(dir string, maxBundles int) (*Store, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create debug bundle directory: %w", err)
+	}
+	return &Store{dir: dir, maxBundles: maxBundles}, nil
+}
+
+// Save сохраняет пакет и удаляет лишние старые пакеты
+func (s *Store) Save(bundle Bundle) error {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode debug bundle: %w", err)
+	}
+
+	if err := os.WriteFile(s.path(bundle.ID), data, 0640); err != nil {
+		return fmt.Errorf("failed to write debug bundle: %w", err)
+	}
+
+	return s.prune()
+}
+
+// Get загружает пакет по ID
+func (s *Store) Get(id string) (*Bundle, error) {
+	// ID всегда UUID, что заодно исключает выход за пределы каталога
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrBundleNotFound
+	}
+
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrBundleNotFound
+		}
+		return nil, fmt.Errorf("failed to read debug bundle: %w", err)
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode debug bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+// List возвращает краткие описания пакетов, новые первыми
+func (s *Store) List() ([]Summary, error) {
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]Summary, 0, len(entries))
+	for _, entry := range entries {
+		bundle, err := s.Get(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		summaries = append(summaries, Summary{
+			ID:        bundle.ID,
+			RouteID:   bundle.RouteID,
+			CreatedAt: bundle.CreatedAt,
+			Error:     bundle.Error,
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].CreatedAt.After(summaries[j].CreatedAt)
+	})
+	return summaries, nil
+}
+
+// prune удаляет самые старые пакеты сверх лимита
+func (s *Store) prune() error {
+	if s.maxBundles <= 0 {
+		return nil
+	}
+
+	entries, err := s.entries()
+	if err != nil {
+		return err
+	}
+	if len(entries) <= s.maxBundles {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		ii, _ := entries[i].Info()
+		ij, _ := entries[j].Info()
+		if ii == nil || ij == nil {
+			return false
+		}
+		return ii.ModTime().Before(ij.ModTime())
+	})
+
+	for _, entry := range entries[:len(entries)-s.maxBundles] {
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove old debug bundle: %w", err)
+		}
+	}
+	return nil
+}
+
+// entries возвращает файлы пакетов в каталоге
+func (s *Store) entries() ([]os.DirEntry, error) {
+	all, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read debug bundle directory: %w", err)
+	}
+
+	entries := make([]os.DirEntry, 0, len(all))
+	for _, entry := range all {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// path возвращает путь к файлу пакета
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
@@ -0,0 +1,102 @@
+// Package diagnostics открывает профилировщик net/http/pprof и переменные
+// expvar для диагностики работающего сервиса: на отдельном служебном порту
+// или в API администратора.
+package diagnostics
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/buildinfo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readHeaderTimeout ожидание заголовков запроса на служебном порту.
+// Общего ограничения времени ответа нет: профиль CPU снимается до минуты и дольше.
+const readHeaderTimeout = 10 * time.Second
+
+// Options настройки диагностики
+type Options struct {
+	// Addr адрес служебного HTTP listener, например 127.0.0.1:6060.
+	// Пусто — listener не запускается.
+	Addr string
+	// AdminAPI открывает диагностику в /api/v1/admin/debug, доступную
+	// только администраторам
+	AdminAPI bool
+}
+
+// Enabled проверяет, что диагностика включена хотя бы одним способом
+func (o Options) Enabled() bool {
+	return o.Addr != "" || o.AdminAPI
+}
+
+var publishOnce sync.Once
+
+// Handler возвращает обработчик /debug/pprof/* и /debug/vars. При первом
+// вызове публикует в expvar версию сборки, число горутин и время работы.
+func Handler() http.Handler {
+	publishOnce.Do(publishVars)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// Mount открывает диагностику в router по путям prefix/debug/pprof/*
+// и prefix/debug/vars. POST нужен pprof symbol.
+func Mount(router *gin.Engine, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	handler := gin.WrapH(http.StripPrefix(prefix, Handler()))
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPost} {
+		router.Handle(method, prefix+"/debug/*path", handler)
+	}
+}
+
+// ListenAndServe запускает служебный HTTP listener с диагностикой на addr
+func ListenAndServe(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	return server.ListenAndServe()
+}
+
+// PublishCounter публикует в expvar счетчик name, значение которого
+// возвращает value. Имя должно быть уникальным.
+func PublishCounter(name string, value func() int64) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return value()
+	}))
+}
+
+// PublishFunc публикует в expvar переменную name, значение которой
+// возвращает value при каждом запросе. Имя должно быть уникальным.
+func PublishFunc(name string, value func() interface{}) {
+	expvar.Publish(name, expvar.Func(value))
+}
+
+// publishVars публикует переменные сервиса в expvar
+func publishVars() {
+	started := time.Now()
+	expvar.Publish("build", expvar.Func(func() interface{} {
+		return buildinfo.Current()
+	}))
+	expvar.Publish("goroutines", expvar.Func(func() interface{} {
+		return runtime.NumGoroutine()
+	}))
+	expvar.Publish("uptime_seconds", expvar.Func(func() interface{} {
+		return time.Since(started).Seconds()
+	}))
+}
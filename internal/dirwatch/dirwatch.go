@@ -0,0 +1,425 @@
+// Package dirwatch анализирует видео, которые машины обследования
+// выгружают в папку: рядом с видео кладется файл метаданных с треком, пара
+// анализируется тем же конвейером, что и POST /api/v1/analyze, и переносится
+// в папку обработанных или неудачных. Папка проверяется периодически, поэтому
+// подходит и сетевой диск, и бакет S3, подключенный как файловая система.
+package dirwatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// sidecarExt расширение файла метаданных видео
+	sidecarExt = ".json"
+	// resultSuffix окончание файла результата, который кладется рядом с
+	// обработанной парой
+	resultSuffix = ".result.json"
+	// processingDir папка, в которую пара переносится на время анализа
+	processingDir = ".processing"
+)
+
+// DefaultVideoExtensions расширения видео, которые берутся в обработку
+var DefaultVideoExtensions = []string{".mp4", ".mov", ".avi", ".mkv", ".webm"}
+
+// Options настройки приема видео из папки
+type Options struct {
+	// Dir папка, в которую выгружаются видео и метаданные
+	Dir string
+	// ProcessedDir и FailedDir папки обработанных и неудачных пар, по
+	// умолчанию processed и failed внутри Dir
+	ProcessedDir string
+	FailedDir    string
+	// PollInterval как часто проверять папку
+	PollInterval time.Duration
+	// SettleTime файлы, измененные позже, считаются еще копирующимися
+	SettleTime time.Duration
+	// MaxVideoBytes наибольший размер видео, 0 — без ограничения
+	MaxVideoBytes int64
+	// Workers сколько пар анализируется одновременно
+	Workers int
+	// VideoExtensions расширения видео, по умолчанию DefaultVideoExtensions
+	VideoExtensions []string
+	// OrganizationID организация, которой принадлежат маршруты, nil —
+	// маршруты без организации
+	OrganizationID *uint
+}
+
+// Sidecar файл метаданных <имя>.json рядом с видео <имя>.mp4
+type Sidecar struct {
+	Points []service.Coordinates `json:"points"`
+	// SegmentLengthM длина сегмента маршрута в метрах
+	SegmentLengthM float64  `json:"segment_length_m"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Tags           []string `json:"tags"`
+}
+
+// Result результат анализа пары, записывается в <имя>.result.json
+type Result struct {
+	Status          string        `json:"status"`
+	Video           string        `json:"video"`
+	RouteID         string        `json:"route_id,omitempty"`
+	AverageCoverage *float64      `json:"average_coverage,omitempty"`
+	Error           string        `json:"error,omitempty"`
+	Code            apierror.Code `json:"code,omitempty"`
+	FinishedAt      time.Time     `json:"finished_at"`
+}
+
+// Статусы результата
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// pair видео и его метаданные
+type pair struct {
+	name    string
+	video   string
+	sidecar string
+}
+
+// Watcher проверяет папку и анализирует найденные пары
+type Watcher struct {
+	opts     Options
+	analyzer *service.AnalyzerService
+	routes   *service.RouteService
+	logger   *logrus.Logger
+
+	queue    chan pair
+	workers  sync.WaitGroup
+	stopping chan struct{}
+	stopped  chan struct{}
+	now      func() time.Time
+}
+
+// New проверяет настройки и создает папки. Проверку папки запускает Start.
+func New(opts Options, analyzer *service.AnalyzerService, routes *service.RouteService, logger *logrus.Logger) (*Watcher, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("ingest directory is required")
+	}
+	if opts.ProcessedDir == "" {
+		opts.ProcessedDir = filepath.Join(opts.Dir, "processed")
+	}
+	if opts.FailedDir == "" {
+		opts.FailedDir = filepath.Join(opts.Dir, "failed")
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 10 * time.Second
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if len(opts.VideoExtensions) == 0 {
+		opts.VideoExtensions = DefaultVideoExtensions
+	}
+	for _, dir := range []string{opts.Dir, opts.ProcessedDir, opts.FailedDir, filepath.Join(opts.Dir, processingDir)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create ingest directory: %w", err)
+		}
+	}
+
+	return &Watcher{
+		opts:     opts,
+		analyzer: analyzer,
+		routes:   routes,
+		logger:   logger,
+		queue:    make(chan pair, opts.Workers),
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+		now:      time.Now,
+	}, nil
+}
+
+// Start возвращает в папку пары, анализ которых прервала остановка
+// сервиса, и запускает проверку папки и анализ
+func (w *Watcher) Start() {
+	w.requeueInterrupted()
+	for n := 0; n < w.opts.Workers; n++ {
+		w.workers.Add(1)
+		go w.work()
+	}
+	go w.poll()
+}
+
+// Shutdown прекращает проверку папки и ждет анализ взятых пар до ctx.
+// Пары, которые не успели проанализировать, остаются в .processing и
+// возвращаются в папку при следующем запуске. Возвращает false, если
+// анализы не завершились до ctx.
+func (w *Watcher) Shutdown(ctx context.Context) bool {
+	close(w.stopping)
+	<-w.stopped
+
+	done := make(chan struct{})
+	go func() {
+		w.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// poll проверяет папку каждые PollInterval и ставит готовые пары в очередь
+func (w *Watcher) poll() {
+	defer close(w.stopped)
+	defer close(w.queue)
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		pairs, err := w.scan()
+		if err != nil {
+			w.logger.Errorf("Не удалось проверить папку %s: %v", w.opts.Dir, err)
+		}
+		for _, p := range pairs {
+			if !w.claim(p) {
+				continue
+			}
+			select {
+			case w.queue <- p:
+			case <-w.stopping:
+				// Пара уже перенесена в .processing и будет взята при
+				// следующем запуске
+				return
+			}
+		}
+
+		select {
+		case <-w.stopping:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan находит пары видео и метаданных, оба файла которых не менялись
+// SettleTime. Файлы без пары ждут второй файл.
+func (w *Watcher) scan() ([]pair, error) {
+	entries, err := os.ReadDir(w.opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]os.FileInfo, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files[entry.Name()] = info
+		}
+	}
+
+	settled := w.now().Add(-w.opts.SettleTime)
+	var pairs []pair
+	for name, info := range files {
+		ext := strings.ToLower(filepath.Ext(name))
+		if !w.isVideo(ext) {
+			continue
+		}
+		base := strings.TrimSuffix(name, filepath.Ext(name))
+		sidecar, ok := files[base+sidecarExt]
+		if !ok || info.ModTime().After(settled) || sidecar.ModTime().After(settled) {
+			continue
+		}
+		pairs = append(pairs, pair{name: base, video: name, sidecar: base + sidecarExt})
+	}
+	return pairs, nil
+}
+
+// isVideo проверяет расширение видео
+func (w *Watcher) isVideo(ext string) bool {
+	for _, videoExt := range w.opts.VideoExtensions {
+		if strings.EqualFold(ext, videoExt) {
+			return true
+		}
+	}
+	return false
+}
+
+// claim переносит пару в .processing, чтобы ее не взяли повторно, в том
+// числе другие экземпляры сервиса, проверяющие ту же папку
+func (w *Watcher) claim(p pair) bool {
+	processing := filepath.Join(w.opts.Dir, processingDir)
+	if err := os.Rename(filepath.Join(w.opts.Dir, p.sidecar), filepath.Join(processing, p.sidecar)); err != nil {
+		// Пару уже взял другой экземпляр
+		return false
+	}
+	if err := os.Rename(filepath.Join(w.opts.Dir, p.video), filepath.Join(processing, p.video)); err != nil {
+		w.logger.Warnf("Не удалось взять видео %s в обработку: %v", p.video, err)
+		_ = os.Rename(filepath.Join(processing, p.sidecar), filepath.Join(w.opts.Dir, p.sidecar))
+		return false
+	}
+	return true
+}
+
+// requeueInterrupted возвращает в папку пары из .processing
+func (w *Watcher) requeueInterrupted() {
+	processing := filepath.Join(w.opts.Dir, processingDir)
+	entries, err := os.ReadDir(processing)
+	if err != nil {
+		w.logger.Warnf("Не удалось проверить папку %s: %v", processing, err)
+		return
+	}
+	for _, entry := range entries {
+		if err := os.Rename(filepath.Join(processing, entry.Name()), filepath.Join(w.opts.Dir, entry.Name())); err != nil {
+			w.logger.Warnf("Не удалось вернуть %s в папку приема: %v", entry.Name(), err)
+			continue
+		}
+		w.logger.Infof("Файл %s, анализ которого был прерван, возвращен в папку приема", entry.Name())
+	}
+}
+
+// work анализирует пары, пока очередь не закрыта
+func (w *Watcher) work() {
+	defer w.workers.Done()
+	for p := range w.queue {
+		w.analyze(p)
+	}
+}
+
+// analyze анализирует пару и переносит ее в папку обработанных или
+// неудачных. В режиме обслуживания пара возвращается в папку приема и
+// берется снова после его выключения.
+func (w *Watcher) analyze(p pair) {
+	log := w.logger.WithField("video", p.video)
+	processing := filepath.Join(w.opts.Dir, processingDir)
+
+	result, err := w.run(p, processing)
+	if errors.Is(err, service.ErrMaintenance) {
+		log.Info("Сервис на обслуживании, видео будет проанализировано позже")
+		w.move(p, processing, w.opts.Dir)
+		return
+	}
+
+	target := w.opts.ProcessedDir
+	if err != nil {
+		apiErr := apierror.Resolve(err)
+		if apiErr.Code == apierror.CodeInternal {
+			log.Errorf("Ошибка анализа видео из папки приема: %v", err)
+		} else {
+			log.Warnf("Видео из папки приема не проанализировано: %s", apiErr.Message)
+		}
+		result = Result{Status: StatusFailed, Video: p.video, Error: apiErr.Message, Code: apiErr.Code}
+		target = w.opts.FailedDir
+	} else {
+		log.Infof("Видео из папки приема проанализировано, маршрут %s, среднее покрытие %.2f%%",
+			result.RouteID, *result.AverageCoverage)
+	}
+	result.FinishedAt = w.now().UTC()
+
+	w.move(p, processing, target)
+	if err := writeResult(filepath.Join(target, p.name+resultSuffix), result); err != nil {
+		log.Warnf("Не удалось записать результат анализа: %v", err)
+	}
+}
+
+// run читает пару из папки dir и анализирует видео. Маршрут строится от
+// первой до последней точки трека, как по start и end формы
+// POST /api/v1/analyze.
+func (w *Watcher) run(p pair, dir string) (Result, error) {
+	sidecar, err := readSidecar(filepath.Join(dir, p.sidecar))
+	if err != nil {
+		return Result{}, err
+	}
+	metadata := service.RouteMetadata{
+		Name:           sidecar.Name,
+		Description:    sidecar.Description,
+		Tags:           sidecar.Tags,
+		OrganizationID: w.opts.OrganizationID,
+	}
+	if metadata.Name == "" {
+		metadata.Name = p.name
+	}
+	if metadata.Description == "" {
+		metadata.Description = fmt.Sprintf("Видео %s из папки приема", p.video)
+	}
+	if err := metadata.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	videoPath := filepath.Join(dir, p.video)
+	if w.opts.MaxVideoBytes > 0 {
+		if info, err := os.Stat(videoPath); err == nil && info.Size() > w.opts.MaxVideoBytes {
+			return Result{}, apierror.New(apierror.CodePayloadTooLarge,
+				fmt.Sprintf("Видео больше %d МБ", w.opts.MaxVideoBytes>>20))
+		}
+	}
+	video, err := os.ReadFile(videoPath)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read video: %w", err)
+	}
+	if len(video) == 0 {
+		return Result{}, apierror.New(apierror.CodeInvalidRequest, "Видео пустое")
+	}
+
+	start, end := sidecar.Points[0], sidecar.Points[len(sidecar.Points)-1]
+	routeID := w.routes.GenerateRouteID()
+	analysis, err := w.analyzer.AnalyzeRoadMarking(
+		start.Lat, start.Lon, end.Lat, end.Lon, sidecar.SegmentLengthM,
+		bytes.NewReader(video), p.video, routeID, metadata,
+	)
+	if err != nil {
+		return Result{}, apierror.Wrap(err, apierror.CodeInternal, "Ошибка анализа дорожной разметки")
+	}
+	coverage := analysis.OverallStats.AverageCoverage
+	return Result{Status: StatusCompleted, Video: p.video, RouteID: routeID, AverageCoverage: &coverage}, nil
+}
+
+// readSidecar читает и проверяет метаданные видео
+func readSidecar(path string) (*Sidecar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sidecar: %w", err)
+	}
+	var sidecar Sidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return nil, apierror.Wrap(err, apierror.CodeInvalidRequest, "Неверный формат файла метаданных")
+	}
+	if len(sidecar.Points) < 2 {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "Трек points должен содержать не меньше двух точек")
+	}
+	for _, p := range sidecar.Points {
+		if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+			return nil, apierror.New(apierror.CodeInvalidCoordinates, "Координаты трека вне допустимого диапазона")
+		}
+	}
+	if !(sidecar.SegmentLengthM > 0) {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "Длина сегмента segment_length_m должна быть положительной")
+	}
+	return &sidecar, nil
+}
+
+// move переносит видео и метаданные пары из папки from в папку to
+func (w *Watcher) move(p pair, from, to string) {
+	for _, name := range []string{p.video, p.sidecar} {
+		if err := os.Rename(filepath.Join(from, name), filepath.Join(to, name)); err != nil {
+			w.logger.Warnf("Не удалось перенести %s в %s: %v", name, to, err)
+		}
+	}
+}
+
+// writeResult записывает результат анализа в JSON
+func writeResult(path string, result Result) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
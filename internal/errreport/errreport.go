@@ -0,0 +1,112 @@
+// Package errreport отправляет ошибки сервера во внешнюю систему учета
+// ошибок, например Sentry: панику и ответы 5xx обработчиков, а также
+// неудачные анализы видео.
+package errreport
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"road-detector-go/internal/debugcapture"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Уровни событий
+const (
+	LevelWarning = "warning"
+	LevelError   = "error"
+	LevelFatal   = "fatal"
+)
+
+// requestIDHeader заголовок ответа с ID запроса
+const requestIDHeader = "X-Request-ID"
+
+// Event ошибка для отправки
+type Event struct {
+	// Level уровень события, по умолчанию LevelError
+	Level string
+	// Message краткое описание, по умолчанию текст Err
+	Message string
+	Err     error
+	// Stack стек горутины в формате runtime/debug.Stack, например для паники
+	Stack []byte
+	// Tags значения для поиска и группировки событий
+	Tags map[string]string
+	// Extra дополнительные данные события
+	Extra   map[string]interface{}
+	Request *Request
+}
+
+// Request HTTP запрос, при обработке которого произошла ошибка
+type Request struct {
+	Method    string
+	URL       string
+	Route     string
+	ClientIP  string
+	UserAgent string
+}
+
+// Reporter отправляет события об ошибках
+type Reporter interface {
+	// Report ставит событие в очередь отправки и не ждет ее
+	Report(event Event)
+	// Flush ожидает отправки событий из очереди не дольше timeout
+	// и возвращает false, если очередь не успела опустеть
+	Flush(timeout time.Duration) bool
+}
+
+// stackTracer ошибка со стеком горутины, например паника обработчика
+type stackTracer interface {
+	StackTrace() []byte
+}
+
+// nopReporter не отправляет события
+type nopReporter struct{}
+
+// Nop возвращает Reporter, который ничего не отправляет
+func Nop() Reporter {
+	return nopReporter{}
+}
+
+func (nopReporter) Report(Event) {}
+
+func (nopReporter) Flush(time.Duration) bool { return true }
+
+// Middleware отправляет ошибки запросов, завершившихся ответом 5xx, вместе
+// с методом, путем, IP клиента и ID запроса. Подключается до middleware,
+// формирующего ответ с ошибкой, чтобы видеть итоговый статус.
+func Middleware(reporter Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < 500 || len(c.Errors) == 0 {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		event := Event{
+			Err: err,
+			Tags: map[string]string{
+				"status":     strconv.Itoa(status),
+				"route":      c.FullPath(),
+				"request_id": c.Writer.Header().Get(requestIDHeader),
+			},
+			Request: &Request{
+				Method:    c.Request.Method,
+				URL:       debugcapture.RedactURL(c.Request.URL.String()),
+				Route:     c.FullPath(),
+				ClientIP:  c.ClientIP(),
+				UserAgent: c.Request.UserAgent(),
+			},
+		}
+		var tracer stackTracer
+		if errors.As(err, &tracer) {
+			event.Level = LevelFatal
+			event.Stack = tracer.StackTrace()
+		}
+		reporter.Report(event)
+	}
+}
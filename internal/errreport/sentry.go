@@ -0,0 +1,297 @@
+package errreport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// sentryQueueSize сколько событий ждут отправки, остальные отбрасываются
+	sentryQueueSize = 100
+	// maxStackLength сколько байт стека отправляется с событием
+	maxStackLength = 16 << 10
+)
+
+// Options настройки отправки в Sentry
+type Options struct {
+	// DSN проекта Sentry, пусто — события не отправляются
+	DSN         string
+	Environment string
+	Release     string
+	// Timeout ожидание ответа Sentry
+	Timeout time.Duration
+	// RequestID возвращает ID обрабатываемого запроса, он добавляется
+	// к событиям сервисов тегом request_id. nil — тег не добавляется.
+	RequestID func() string
+}
+
+// sentryReporter отправляет события в Sentry через envelope API
+// в фоновой горутине
+type sentryReporter struct {
+	endpoint   string
+	auth       string
+	dsn        string
+	opts       Options
+	serverName string
+	client     *http.Client
+	logger     *logrus.Logger
+
+	queue   chan sentryEvent
+	pending sync.WaitGroup
+}
+
+// sentryEvent событие в формате Sentry
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Message     string                 `json:"message,omitempty"`
+	Exception   *sentryExceptions      `json:"exception,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Request     *sentryRequest         `json:"request,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sentryRequest struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// New создает Reporter по настройкам. Без DSN возвращается Nop.
+func New(opts Options, logger *logrus.Logger) (Reporter, error) {
+	if opts.DSN == "" {
+		return Nop(), nil
+	}
+
+	endpoint, key, err := parseDSN(opts.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	serverName, _ := os.Hostname()
+
+	r := &sentryReporter{
+		endpoint:   endpoint,
+		auth:       fmt.Sprintf("Sentry sentry_version=7, sentry_client=road-detector-go/%s, sentry_key=%s", opts.Release, key),
+		dsn:        opts.DSN,
+		opts:       opts,
+		serverName: serverName,
+		client:     &http.Client{Timeout: opts.Timeout},
+		logger:     logger,
+		queue:      make(chan sentryEvent, sentryQueueSize),
+	}
+	go r.run()
+	return r, nil
+}
+
+// parseDSN возвращает адрес envelope API и публичный ключ из DSN вида
+// https://<key>@<host>/<project>
+func parseDSN(dsn string) (string, string, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.User == nil || parsed.User.Username() == "" {
+		return "", "", errors.New("invalid sentry dsn: expected scheme://key@host/project")
+	}
+	path := strings.Trim(parsed.Path, "/")
+	i := strings.LastIndex(path, "/")
+	prefix, project := "", path
+	if i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+	if project == "" {
+		return "", "", errors.New("invalid sentry dsn: project id is missing")
+	}
+	endpoint := fmt.Sprintf("%s://%s%s/api/%s/envelope/", parsed.Scheme, parsed.Host, prefix, project)
+	return endpoint, parsed.User.Username(), nil
+}
+
+// Report преобразует событие и ставит его в очередь. При переполненной
+// очереди событие отбрасывается, чтобы не задерживать запросы.
+func (r *sentryReporter) Report(event Event) {
+	payload := r.convert(event)
+	r.pending.Add(1)
+	select {
+	case r.queue <- payload:
+	default:
+		r.pending.Done()
+		r.logger.Warnf("Очередь отправки ошибок переполнена, событие %s отброшено", payload.EventID)
+	}
+}
+
+// Flush ожидает отправки событий из очереди
+func (r *sentryReporter) Flush(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// run отправляет события из очереди
+func (r *sentryReporter) run() {
+	for event := range r.queue {
+		if err := r.send(event); err != nil {
+			r.logger.Warnf("Не удалось отправить ошибку %s в Sentry: %v", event.EventID, err)
+		}
+		r.pending.Done()
+	}
+}
+
+// send отправляет одно событие в envelope API
+func (r *sentryReporter) send(event sentryEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	header, _ := json.Marshal(map[string]interface{}{
+		"event_id": event.EventID,
+		"dsn":      r.dsn,
+		"sent_at":  time.Now().UTC(),
+	})
+	item, _ := json.Marshal(map[string]interface{}{
+		"type":   "event",
+		"length": len(payload),
+	})
+
+	var body bytes.Buffer
+	body.Write(header)
+	body.WriteByte('\n')
+	body.Write(item)
+	body.WriteByte('\n')
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, r.endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return fmt.Errorf("sentry returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// convert преобразует событие в формат Sentry
+func (r *sentryReporter) convert(event Event) sentryEvent {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	result := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   time.Now().UTC(),
+		Level:       event.Level,
+		Platform:    "go",
+		Logger:      "road-detector",
+		ServerName:  r.serverName,
+		Release:     r.opts.Release,
+		Environment: r.opts.Environment,
+		Message:     event.Message,
+		Tags:        make(map[string]string),
+		Extra:       make(map[string]interface{}),
+	}
+	if result.Level == "" {
+		result.Level = LevelError
+	}
+	for key, value := range event.Tags {
+		if value != "" {
+			result.Tags[key] = value
+		}
+	}
+	for key, value := range event.Extra {
+		result.Extra[key] = value
+	}
+	if _, ok := result.Tags["request_id"]; !ok && r.opts.RequestID != nil {
+		if requestID := r.opts.RequestID(); requestID != "" {
+			result.Tags["request_id"] = requestID
+		}
+	}
+
+	if event.Err != nil {
+		result.Exception = &sentryExceptions{Values: []sentryException{{
+			Type:  errorType(event.Err),
+			Value: event.Err.Error(),
+		}}}
+		if result.Message == "" {
+			result.Message = event.Err.Error()
+		}
+	}
+	if len(event.Stack) > 0 {
+		stack := event.Stack
+		if len(stack) > maxStackLength {
+			stack = stack[:maxStackLength]
+		}
+		result.Extra["stacktrace"] = string(stack)
+	}
+	if event.Request != nil {
+		result.Request = &sentryRequest{
+			URL:     event.Request.URL,
+			Method:  event.Request.Method,
+			Headers: map[string]string{"User-Agent": event.Request.UserAgent},
+			Env:     map[string]string{"REMOTE_ADDR": event.Request.ClientIP},
+		}
+		if event.Request.Route != "" {
+			result.Tags["route"] = event.Request.Route
+		}
+	}
+	return result
+}
+
+// errorType тип самой вложенной ошибки, по нему Sentry группирует события
+func errorType(err error) string {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return reflect.TypeOf(err).String()
+		}
+		err = next
+	}
+}
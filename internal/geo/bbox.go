@@ -0,0 +1,149 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"road-detector-go/pkg/models"
+)
+
+// ErrInvalidBoundingBox возвращается для области, которую нельзя нормализовать
+var ErrInvalidBoundingBox = errors.New("invalid bounding box")
+
+// BoundingBox прямоугольная область на карте.
+// После нормализации SouthWest.Lat <= NorthEast.Lat, долготы лежат в [-180, 180],
+// а SouthWest.Lon > NorthEast.Lon означает пересечение 180-го меридиана.
+type BoundingBox struct {
+	NorthEast models.Coordinates
+	SouthWest models.Coordinates
+}
+
+// NewBoundingBox создает нормализованную область из углов, переданных клиентом.
+// Широты в неверном порядке меняются местами. Для долгот выбирается более узкая
+// из двух возможных трактовок: перепутанные углы или пересечение антимеридиана.
+func NewBoundingBox(neLat, neLon, swLat, swLon float64) (BoundingBox, error) {
+	for _, v := range []float64{neLat, neLon, swLat, swLon} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return BoundingBox{}, fmt.Errorf("%w: coordinates must be finite numbers", ErrInvalidBoundingBox)
+		}
+	}
+	if neLat < -90 || neLat > 90 || swLat < -90 || swLat > 90 {
+		return BoundingBox{}, fmt.Errorf("%w: latitude must be between -90 and 90", ErrInvalidBoundingBox)
+	}
+
+	if swLat > neLat {
+		swLat, neLat = neLat, swLat
+	}
+
+	// Вьюпорт шире 360° покрывает все долготы
+	if math.Abs(neLon-swLon) >= 360 {
+		return BoundingBox{
+			NorthEast: models.Coordinates{Lat: neLat, Lon: 180},
+			SouthWest: models.Coordinates{Lat: swLat, Lon: -180},
+		}, nil
+	}
+
+	swLon = NormalizeLon(swLon)
+	neLon = NormalizeLon(neLon)
+
+	if swLon > neLon {
+		// Ширина области при движении на восток от swLon до neLon через 180°
+		eastward := neLon + 360 - swLon
+		if eastward > 180 {
+			// Узкая область с перепутанными углами, а не почти весь земной шар
+			swLon, neLon = neLon, swLon
+		}
+	}
+
+	return BoundingBox{
+		NorthEast: models.Coordinates{Lat: neLat, Lon: neLon},
+		SouthWest: models.Coordinates{Lat: swLat, Lon: swLon},
+	}, nil
+}
+
+// CrossesAntimeridian сообщает, пересекает ли область 180-й меридиан
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.SouthWest.Lon > b.NorthEast.Lon
+}
+
+// Split разбивает область, пересекающую антимеридиан, на две обычные области
+func (b BoundingBox) Split() []BoundingBox {
+	if !b.CrossesAntimeridian() {
+		return []BoundingBox{b}
+	}
+
+	return []BoundingBox{
+		{
+			NorthEast: models.Coordinates{Lat: b.NorthEast.Lat, Lon: 180},
+			SouthWest: b.SouthWest,
+		},
+		{
+			NorthEast: b.NorthEast,
+			SouthWest: models.Coordinates{Lat: b.SouthWest.Lat, Lon: -180},
+		},
+	}
+}
+
+// WidthDegrees возвращает ширину области по долготе в градусах
+func (b BoundingBox) WidthDegrees() float64 {
+	if b.CrossesAntimeridian() {
+		return b.NorthEast.Lon + 360 - b.SouthWest.Lon
+	}
+	return b.NorthEast.Lon - b.SouthWest.Lon
+}
+
+// NormalizeLon приводит долготу к диапазону [-180, 180]
+func NormalizeLon(lon float64) float64 {
+	if lon >= -180 && lon <= 180 {
+		return lon
+	}
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// BoundingBoxOf возвращает наименьший прямоугольник, содержащий точки.
+// Если точки лежат по обе стороны 180-го меридиана и так прямоугольник уже,
+// возвращается область, пересекающая антимеридиан.
+func BoundingBoxOf(points []models.Coordinates) BoundingBox {
+	box := BoundingBox{
+		NorthEast: models.Coordinates{Lat: -90, Lon: -180},
+		SouthWest: models.Coordinates{Lat: 90, Lon: 180},
+	}
+	// Те же долготы, сдвинутые в диапазон [0, 360)
+	shiftedWest, shiftedEast := 360.0, 0.0
+
+	for _, p := range points {
+		box.NorthEast.Lat = math.Max(box.NorthEast.Lat, p.Lat)
+		box.SouthWest.Lat = math.Min(box.SouthWest.Lat, p.Lat)
+		box.NorthEast.Lon = math.Max(box.NorthEast.Lon, p.Lon)
+		box.SouthWest.Lon = math.Min(box.SouthWest.Lon, p.Lon)
+
+		shifted := p.Lon
+		if shifted < 0 {
+			shifted += 360
+		}
+		shiftedWest = math.Min(shiftedWest, shifted)
+		shiftedEast = math.Max(shiftedEast, shifted)
+	}
+
+	if shiftedEast-shiftedWest < box.NorthEast.Lon-box.SouthWest.Lon {
+		box.SouthWest.Lon = NormalizeLon(shiftedWest)
+		box.NorthEast.Lon = NormalizeLon(shiftedEast)
+	}
+	return box
+}
+
+// Contains проверяет, лежит ли точка в области, включая границы
+func (b BoundingBox) Contains(p models.Coordinates) bool {
+	if p.Lat < b.SouthWest.Lat || p.Lat > b.NorthEast.Lat {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return p.Lon >= b.SouthWest.Lon || p.Lon <= b.NorthEast.Lon
+	}
+	return p.Lon >= b.SouthWest.Lon && p.Lon <= b.NorthEast.Lon
+}
@@ -0,0 +1,40 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"road-detector-go/pkg/models"
+)
+
+// geoJSONLineString GeoJSON LineString с координатами [lon, lat]
+type geoJSONLineString struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+// LineStringGeoJSON кодирует ломаную в GeoJSON LineString
+func LineStringGeoJSON(points []models.Coordinates) ([]byte, error) {
+	line := geoJSONLineString{Type: "LineString", Coordinates: make([][2]float64, len(points))}
+	for i, p := range points {
+		line.Coordinates[i] = [2]float64{p.Lon, p.Lat}
+	}
+	return json.Marshal(line)
+}
+
+// ParseGeoJSONLineString разбирает GeoJSON LineString
+func ParseGeoJSONLineString(data []byte) ([]models.Coordinates, error) {
+	var line geoJSONLineString
+	if err := json.Unmarshal(data, &line); err != nil {
+		return nil, fmt.Errorf("failed to decode line string: %w", err)
+	}
+	if line.Type != "LineString" {
+		return nil, fmt.Errorf("geometry type must be LineString, got %q", line.Type)
+	}
+
+	points := make([]models.Coordinates, len(line.Coordinates))
+	for i, c := range line.Coordinates {
+		points[i] = models.Coordinates{Lat: c[1], Lon: c[0]}
+	}
+	return points, nil
+}
@@ -0,0 +1,240 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"road-detector-go/pkg/models"
+)
+
+// ErrInvalidPolygon возвращается для некорректной геометрии полигона
+var ErrInvalidPolygon = errors.New("invalid polygon")
+
+// maxPolygonVertices ограничивает размер полигона в запросе
+const maxPolygonVertices = 10000
+
+// Polygon полигон с внешним контуром и, возможно, дырами.
+// Первый контур внешний, остальные - внутренние. Контуры замкнуты.
+type Polygon struct {
+	Rings [][]models.Coordinates
+}
+
+// geoJSONObject минимальное подмножество GeoJSON для разбора полигона
+type geoJSONObject struct {
+	Type        string                 `json:"type"`
+	Coordinates [][][]float64          `json:"coordinates"`
+	Geometry    *geoJSONObject         `json:"geometry"`
+	Features    []geoJSONObject        `json:"features"`
+	Properties  map[string]interface{} `json:"properties"`
+}
+
+// Feature полигон из GeoJSON вместе со свойствами объекта
+type Feature struct {
+	Properties map[string]interface{}
+	Polygon    Polygon
+}
+
+// ParseGeoJSONPolygon разбирает GeoJSON Polygon, а также Feature или
+// FeatureCollection с единственным полигоном
+func ParseGeoJSONPolygon(data []byte) (Polygon, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return Polygon{}, fmt.Errorf("%w: %v", ErrInvalidPolygon, err)
+	}
+
+	switch obj.Type {
+	case "FeatureCollection":
+		if len(obj.Features) != 1 || obj.Features[0].Geometry == nil {
+			return Polygon{}, fmt.Errorf("%w: feature collection must contain exactly one polygon feature", ErrInvalidPolygon)
+		}
+		obj = *obj.Features[0].Geometry
+	case "Feature":
+		if obj.Geometry == nil {
+			return Polygon{}, fmt.Errorf("%w: feature has no geometry", ErrInvalidPolygon)
+		}
+		obj = *obj.Geometry
+	}
+
+	if obj.Type != "Polygon" {
+		return Polygon{}, fmt.Errorf("%w: geometry type must be Polygon, got %q", ErrInvalidPolygon, obj.Type)
+	}
+
+	return NewPolygon(obj.Coordinates)
+}
+
+// ParseGeoJSONFeatures разбирает GeoJSON FeatureCollection, Feature или
+// Polygon в список полигонов. Геометрией каждого объекта должен быть Polygon.
+func ParseGeoJSONFeatures(data []byte) ([]Feature, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolygon, err)
+	}
+
+	objects := []geoJSONObject{obj}
+	if obj.Type == "FeatureCollection" {
+		if len(obj.Features) == 0 {
+			return nil, fmt.Errorf("%w: feature collection is empty", ErrInvalidPolygon)
+		}
+		objects = obj.Features
+	}
+
+	features := make([]Feature, 0, len(objects))
+	for i, object := range objects {
+		geometry := object
+		if object.Type == "Feature" {
+			if object.Geometry == nil {
+				return nil, fmt.Errorf("%w: feature %d has no geometry", ErrInvalidPolygon, i)
+			}
+			geometry = *object.Geometry
+		}
+		if geometry.Type != "Polygon" {
+			return nil, fmt.Errorf("%w: feature %d geometry type must be Polygon, got %q", ErrInvalidPolygon, i, geometry.Type)
+		}
+		polygon, err := NewPolygon(geometry.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		features = append(features, Feature{Properties: object.Properties, Polygon: polygon})
+	}
+
+	return features, nil
+}
+
+// NewPolygon создает полигон из координат в порядке GeoJSON ([lon, lat]).
+// Незамкнутые контуры замыкаются автоматически.
+func NewPolygon(coordinates [][][]float64) (Polygon, error) {
+	if len(coordinates) == 0 {
+		return Polygon{}, fmt.Errorf("%w: polygon has no rings", ErrInvalidPolygon)
+	}
+
+	total := 0
+	polygon := Polygon{Rings: make([][]models.Coordinates, 0, len(coordinates))}
+	for i, rawRing := range coordinates {
+		ring := make([]models.Coordinates, 0, len(rawRing)+1)
+		for _, position := range rawRing {
+			if len(position) < 2 {
+				return Polygon{}, fmt.Errorf("%w: position must have at least 2 numbers", ErrInvalidPolygon)
+			}
+			lon, lat := position[0], position[1]
+			if math.IsNaN(lat) || math.IsNaN(lon) || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+				return Polygon{}, fmt.Errorf("%w: position [%g, %g] is out of range", ErrInvalidPolygon, lon, lat)
+			}
+			ring = append(ring, models.Coordinates{Lat: lat, Lon: lon})
+		}
+
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			ring = append(ring, ring[0])
+		}
+		if len(ring) < 4 {
+			return Polygon{}, fmt.Errorf("%w: ring %d must have at least 3 distinct positions", ErrInvalidPolygon, i)
+		}
+
+		total += len(ring)
+		if total > maxPolygonVertices {
+			return Polygon{}, fmt.Errorf("%w: polygon has more than %d vertices", ErrInvalidPolygon, maxPolygonVertices)
+		}
+		polygon.Rings = append(polygon.Rings, ring)
+	}
+
+	return polygon, nil
+}
+
+// BoundingBox возвращает описывающий прямоугольник внешнего контура
+func (p Polygon) BoundingBox() BoundingBox {
+	box := BoundingBox{
+		NorthEast: models.Coordinates{Lat: -90, Lon: -180},
+		SouthWest: models.Coordinates{Lat: 90, Lon: 180},
+	}
+	for _, c := range p.Rings[0] {
+		box.NorthEast.Lat = math.Max(box.NorthEast.Lat, c.Lat)
+		box.NorthEast.Lon = math.Max(box.NorthEast.Lon, c.Lon)
+		box.SouthWest.Lat = math.Min(box.SouthWest.Lat, c.Lat)
+		box.SouthWest.Lon = math.Min(box.SouthWest.Lon, c.Lon)
+	}
+	return box
+}
+
+// ContainsPoint проверяет попадание точки в полигон с учетом дыр
+func (p Polygon) ContainsPoint(point models.Coordinates) bool {
+	if !ringContains(p.Rings[0], point) {
+		return false
+	}
+	for _, hole := range p.Rings[1:] {
+		if ringContains(hole, point) {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsSegment проверяет, пересекает ли отрезок полигон: один из концов
+// лежит внутри или отрезок пересекает одну из границ
+func (p Polygon) IntersectsSegment(start, end models.Coordinates) bool {
+	if p.ContainsPoint(start) || p.ContainsPoint(end) {
+		return true
+	}
+	for _, ring := range p.Rings {
+		for i := 0; i+1 < len(ring); i++ {
+			if segmentsIntersect(start, end, ring[i], ring[i+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GeoJSON возвращает полигон в виде GeoJSON геометрии
+func (p Polygon) GeoJSON() ([]byte, error) {
+	coordinates := make([][][]float64, len(p.Rings))
+	for i, ring := range p.Rings {
+		coordinates[i] = make([][]float64, len(ring))
+		for j, c := range ring {
+			coordinates[i][j] = []float64{c.Lon, c.Lat}
+		}
+	}
+	return json.Marshal(map[string]interface{}{
+		"type":        "Polygon",
+		"coordinates": coordinates,
+	})
+}
+
+// ringContains проверяет попадание точки в замкнутый контур методом трассировки луча
+func ringContains(ring []models.Coordinates, point models.Coordinates) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Lon < (b.Lon-a.Lon)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// segmentsIntersect проверяет пересечение двух отрезков на плоскости lon/lat
+func segmentsIntersect(p1, p2, q1, q2 models.Coordinates) bool {
+	d1 := orientation(q1, q2, p1)
+	d2 := orientation(q1, q2, p2)
+	d3 := orientation(p1, p2, q1)
+	d4 := orientation(p1, p2, q2)
+
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+
+	return (d1 == 0 && onSegment(q1, q2, p1)) || (d2 == 0 && onSegment(q1, q2, p2)) ||
+		(d3 == 0 && onSegment(p1, p2, q1)) || (d4 == 0 && onSegment(p1, p2, q2))
+}
+
+// orientation знак векторного произведения (b - a) x (c - a)
+func orientation(a, b, c models.Coordinates) float64 {
+	return (b.Lon-a.Lon)*(c.Lat-a.Lat) - (b.Lat-a.Lat)*(c.Lon-a.Lon)
+}
+
+// onSegment проверяет, лежит ли коллинеарная точка c на отрезке ab
+func onSegment(a, b, c models.Coordinates) bool {
+	return math.Min(a.Lon, b.Lon) <= c.Lon && c.Lon <= math.Max(a.Lon, b.Lon) &&
+		math.Min(a.Lat, b.Lat) <= c.Lat && c.Lat <= math.Max(a.Lat, b.Lat)
+}
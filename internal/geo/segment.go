@@ -0,0 +1,58 @@
+package geo
+
+import (
+	"math"
+
+	"road-detector-go/pkg/models"
+)
+
+// earthRadiusMeters средний радиус Земли в метрах
+const earthRadiusMeters = 6371000.0
+
+// PointToSegmentMeters возвращает расстояние в метрах от точки до отрезка ab.
+// Использует локальную равнопромежуточную проекцию вокруг точки, что достаточно
+// точно для отрезков длиной в сотни метров.
+func PointToSegmentMeters(p, a, b models.Coordinates) float64 {
+	ax, ay := project(p, a)
+	bx, by := project(p, b)
+
+	dx, dy := bx-ax, by-ay
+	lengthSq := dx*dx + dy*dy
+	t := 0.0
+	if lengthSq > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSq))
+	}
+
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+// Midpoint возвращает середину отрезка ab
+func Midpoint(a, b models.Coordinates) models.Coordinates {
+	return models.Coordinates{
+		Lat: (a.Lat + b.Lat) / 2,
+		Lon: NormalizeLon(a.Lon + NormalizeLon(b.Lon-a.Lon)/2),
+	}
+}
+
+// AxisAngleDegrees возвращает угол в градусах [0, 90] между направлениями
+// отрезков без учета направления движения: встречные проезды дают 0
+func AxisAngleDegrees(a1, a2, b1, b2 models.Coordinates) float64 {
+	ax, ay := project(a1, a2)
+	bx, by := project(a1, b2)
+	cx, cy := project(a1, b1)
+	bx, by = bx-cx, by-cy
+
+	if (ax == 0 && ay == 0) || (bx == 0 && by == 0) {
+		return 0
+	}
+	cos := math.Abs(ax*bx+ay*by) / (math.Hypot(ax, ay) * math.Hypot(bx, by))
+	return math.Acos(math.Min(cos, 1)) * 180 / math.Pi
+}
+
+// project переводит точку в метры относительно начала координат origin
+func project(origin, point models.Coordinates) (x, y float64) {
+	rad := math.Pi / 180
+	x = NormalizeLon(point.Lon-origin.Lon) * rad * earthRadiusMeters * math.Cos(origin.Lat*rad)
+	y = (point.Lat - origin.Lat) * rad * earthRadiusMeters
+	return x, y
+}
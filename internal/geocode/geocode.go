@@ -0,0 +1,91 @@
+// Package geocode определяет названия дорог по координатам через сервис
+// обратного геокодирования (Nominatim или Photon).
+package geocode
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"road-detector-go/pkg/models"
+)
+
+// Поддерживаемые провайдеры
+const (
+	ProviderNominatim = "nominatim"
+	ProviderPhoton    = "photon"
+)
+
+// userAgent обязателен по правилам использования публичного Nominatim
+const userAgent = "road-detector-go"
+
+// ErrNotFound возвращается, если рядом с точкой нет дороги с названием
+var ErrNotFound = errors.New("road name not found")
+
+// Geocoder определяет название дороги в точке
+type Geocoder interface {
+	RoadName(point models.Coordinates) (string, error)
+}
+
+// Options настройки клиента геокодирования
+type Options struct {
+	Provider string
+	URL      string
+	Language string
+	Timeout  time.Duration
+	// MinInterval минимальный интервал между запросами (публичный Nominatim
+	// допускает не более одного запроса в секунду)
+	MinInterval time.Duration
+}
+
+// New создает Geocoder для указанного провайдера
+func New(opts Options) (Geocoder, error) {
+	base := &client{
+		baseURL:     opts.URL,
+		language:    opts.Language,
+		http:        &http.Client{Timeout: opts.Timeout},
+		minInterval: opts.MinInterval,
+	}
+
+	switch opts.Provider {
+	case ProviderNominatim:
+		return &nominatimGeocoder{client: base}, nil
+	case ProviderPhoton:
+		return &photonGeocoder{client: base}, nil
+	default:
+		return nil, fmt.Errorf("unknown geocoding provider %q", opts.Provider)
+	}
+}
+
+// client общая часть HTTP клиентов провайдеров с ограничением частоты запросов
+type client struct {
+	baseURL     string
+	language    string
+	http        *http.Client
+	minInterval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// get выполняет GET запрос, выдерживая интервал между запросами
+func (c *client) get(url string) (*http.Response, error) {
+	c.mu.Lock()
+	if wait := c.minInterval - time.Since(c.last); wait > 0 {
+		time.Sleep(wait)
+	}
+	c.last = time.Now()
+	c.mu.Unlock()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if c.language != "" {
+		req.Header.Set("Accept-Language", c.language)
+	}
+	return c.http.Do(req)
+}
@@ -0,0 +1,54 @@
+package geocode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"road-detector-go/pkg/models"
+)
+
+// nominatimGeocoder клиент Nominatim /reverse
+type nominatimGeocoder struct {
+	*client
+}
+
+// nominatimResponse нужная часть ответа /reverse
+type nominatimResponse struct {
+	Error   string `json:"error"`
+	Address struct {
+		Road string `json:"road"`
+	} `json:"address"`
+}
+
+// RoadName возвращает название ближайшей дороги
+func (g *nominatimGeocoder) RoadName(point models.Coordinates) (string, error) {
+	query := url.Values{}
+	query.Set("format", "jsonv2")
+	query.Set("lat", fmt.Sprintf("%.6f", point.Lat))
+	query.Set("lon", fmt.Sprintf("%.6f", point.Lon))
+	query.Set("zoom", "17") // уровень улиц
+	query.Set("layer", "address")
+
+	resp, err := g.get(strings.TrimRight(g.baseURL, "/") + "/reverse?" + query.Encode())
+	if err != nil {
+		return "", fmt.Errorf("failed to send Nominatim request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Nominatim returned status %d", resp.StatusCode)
+	}
+
+	var parsed nominatimResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", fmt.Errorf("failed to decode Nominatim response: %w", err)
+	}
+	if parsed.Error != "" || parsed.Address.Road == "" {
+		return "", ErrNotFound
+	}
+
+	return parsed.Address.Road, nil
+}
@@ -0,0 +1,67 @@
+package geocode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"road-detector-go/pkg/models"
+)
+
+// photonGeocoder клиент Photon /reverse
+type photonGeocoder struct {
+	*client
+}
+
+// photonResponse нужная часть ответа /reverse (GeoJSON FeatureCollection)
+type photonResponse struct {
+	Features []struct {
+		Properties struct {
+			Name   string `json:"name"`
+			Street string `json:"street"`
+			OSMKey string `json:"osm_key"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+// RoadName возвращает название ближайшей дороги
+func (g *photonGeocoder) RoadName(point models.Coordinates) (string, error) {
+	query := url.Values{}
+	query.Set("lat", fmt.Sprintf("%.6f", point.Lat))
+	query.Set("lon", fmt.Sprintf("%.6f", point.Lon))
+	query.Set("limit", "1")
+	if g.language != "" {
+		query.Set("lang", g.language)
+	}
+
+	resp, err := g.get(strings.TrimRight(g.baseURL, "/") + "/reverse?" + query.Encode())
+	if err != nil {
+		return "", fmt.Errorf("failed to send Photon request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Photon returned status %d", resp.StatusCode)
+	}
+
+	var parsed photonResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", fmt.Errorf("failed to decode Photon response: %w", err)
+	}
+	if len(parsed.Features) == 0 {
+		return "", ErrNotFound
+	}
+
+	// Для самой дороги название в name, для адреса рядом с ней - в street
+	props := parsed.Features[0].Properties
+	switch {
+	case props.OSMKey == "highway" && props.Name != "":
+		return props.Name, nil
+	case props.Street != "":
+		return props.Street, nil
+	default:
+		return "", ErrNotFound
+	}
+}
@@ -0,0 +1,101 @@
+// Package graphqlapi предоставляет маршруты, сегменты и аналитику покрытия
+// через GraphQL, чтобы клиенты карты получали за один запрос ровно те поля
+// и вложенные страницы, которые им нужны. Схема описана в schema.graphqls,
+// код выполнения и заготовки резолверов генерирует gqlgen (make graphql).
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// API схема GraphQL поверх сервисов маршрутов и аналитики
+type API struct {
+	executor *executor.Executor
+}
+
+// Request запрос GraphQL
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// New создает схему GraphQL
+func New(routes *service.RouteService, analytics *service.AnalyticsService, logger *logrus.Logger) (*API, error) {
+	resolver := &Resolver{routes: routes, analytics: analytics, logger: logger}
+	exec := executor.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	exec.Use(extension.Introspection{})
+	exec.SetErrorPresenter(presentError)
+	exec.SetRecoverFunc(func(ctx context.Context, recovered interface{}) error {
+		return resolver.fail(&apierror.PanicError{Value: recovered, Stack: debug.Stack()}, "Внутренняя ошибка сервера")
+	})
+	return &API{executor: exec}, nil
+}
+
+// Execute выполняет запрос с областью маршрутов scope. Ошибки полей
+// возвращаются в результате вместе с данными остальных полей.
+func (a *API) Execute(ctx context.Context, req Request, scope repository.RouteScope) *graphql.Response {
+	ctx = context.WithValue(ctx, scopeKey{}, scope)
+	ctx = graphql.StartOperationTrace(ctx)
+	params := &graphql.RawParams{
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+		ReadTime:      graphql.TraceTiming{Start: time.Now(), End: time.Now()},
+	}
+
+	opCtx, errs := a.executor.CreateOperationContext(ctx, params)
+	if errs != nil {
+		return a.executor.DispatchError(graphql.WithOperationContext(ctx, opCtx), errs)
+	}
+	responses, ctx := a.executor.DispatchOperation(ctx, opCtx)
+	return responses(ctx)
+}
+
+// scopeKey ключ области маршрутов запроса в контексте
+type scopeKey struct{}
+
+// scopeFrom возвращает область маршрутов запроса
+func scopeFrom(ctx context.Context) repository.RouteScope {
+	scope, _ := ctx.Value(scopeKey{}).(repository.RouteScope)
+	return scope
+}
+
+// fieldError ошибка поля с кодом ошибки API
+type fieldError struct {
+	err *apierror.Error
+}
+
+func (e *fieldError) Error() string {
+	return e.err.Message
+}
+
+// presentError дополняет ошибку поля в ответе кодом в extensions.code,
+// как в REST API
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+	var fieldErr *fieldError
+	if errors.As(err, &fieldErr) {
+		presented.Message = fieldErr.err.Message
+		presented.Extensions = map[string]interface{}{"code": fieldErr.err.Code}
+	}
+	return presented
+}
+
+// invalid ошибка неверного аргумента
+func invalid(message string) error {
+	return &fieldError{apierror.New(apierror.CodeInvalidRequest, message)}
+}
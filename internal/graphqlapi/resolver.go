@@ -0,0 +1,85 @@
+package graphqlapi
+
+//go:generate go run github.com/99designs/gqlgen@v0.17.78 generate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Ограничения страниц, как в REST API
+const (
+	maxRoutesPageSize   = 100
+	maxSegmentsPageSize = 500
+)
+
+// Resolver корневой резолвер схемы, общий для всех запросов
+type Resolver struct {
+	routes    *service.RouteService
+	analytics *service.AnalyticsService
+	logger    *logrus.Logger
+}
+
+// fail ошибка сервиса: известные ошибки предметной области получают свой
+// код, остальные записываются в лог и возвращаются как внутренние
+func (r *Resolver) fail(err error, message string) error {
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) && apiErr.Code != apierror.CodeInternal {
+		return &fieldError{apiErr}
+	}
+	resolved := apierror.Resolve(apierror.Wrap(err, apierror.CodeInternal, message))
+	if resolved.Code == apierror.CodeInternal {
+		r.logger.Errorf("%s: %v", message, err)
+	}
+	return &fieldError{resolved}
+}
+
+// optionalArea проверяет необязательный аргумент area, nil — не задан
+func (r *Resolver) optionalArea(area *Area) (*geo.BoundingBox, error) {
+	if area == nil {
+		return nil, nil
+	}
+	ne, sw := areaCorners(*area)
+	box, err := geo.NewBoundingBox(ne.Lat, ne.Lon, sw.Lat, sw.Lon)
+	if err != nil {
+		return nil, r.fail(err, "Неверная область")
+	}
+	return &box, nil
+}
+
+// checkPage проверяет номер и размер страницы
+func checkPage(page, size, maxSize int) error {
+	if page < 1 {
+		return invalid("Номер страницы page должен быть не меньше 1")
+	}
+	if size < 1 || size > maxSize {
+		return invalid(fmt.Sprintf("Размер страницы size должен быть от 1 до %d", maxSize))
+	}
+	return nil
+}
+
+// areaCorners углы области
+func areaCorners(area Area) (ne, sw service.Coordinates) {
+	if area.NorthEast != nil {
+		ne = *area.NorthEast
+	}
+	if area.SouthWest != nil {
+		sw = *area.SouthWest
+	}
+	return ne, sw
+}
+
+// trimmed значение необязательного строкового аргумента без пробелов по краям
+func trimmed(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(*value)
+}
@@ -0,0 +1,157 @@
+package graphqlapi
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.78
+
+import (
+	"context"
+	"errors"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+	"time"
+)
+
+// Route is the resolver for the route field.
+func (r *queryResolver) Route(ctx context.Context, id string) (*service.RouteResponse, error) {
+	route, err := r.routes.GetRouteByID(id, scopeFrom(ctx))
+	if err != nil {
+		if errors.Is(err, repository.ErrRouteNotFound) {
+			return nil, nil
+		}
+		return nil, r.fail(err, "Ошибка получения маршрута")
+	}
+	return route, nil
+}
+
+// Routes is the resolver for the routes field.
+func (r *queryResolver) Routes(ctx context.Context, page int, size int, name *string, road *string, tags []string, minCoverage *float64, maxCoverage *float64, minQuality *float64, maxQuality *float64, sort string, order string, cursor *string) (*service.ListRoutesResponse, error) {
+	if err := checkPage(page, size, maxRoutesPageSize); err != nil {
+		return nil, err
+	}
+	query := repository.RouteListQuery{
+		Name:        trimmed(name),
+		RoadName:    trimmed(road),
+		MinCoverage: minCoverage,
+		MaxCoverage: maxCoverage,
+		MinQuality:  minQuality,
+		MaxQuality:  maxQuality,
+		SortBy:      sort,
+		Desc:        order == "desc",
+		Scope:       scopeFrom(ctx),
+	}
+	if len(tags) > 0 {
+		normalized, err := service.NormalizeTags(tags)
+		if err != nil {
+			return nil, r.fail(err, "Неверная метка")
+		}
+		query.Tags = normalized
+	}
+	if raw := trimmed(cursor); raw != "" {
+		if query.SortBy != repository.RouteSortCreatedAt {
+			return nil, invalid("Курсор поддерживается только при сортировке по created_at")
+		}
+		decoded, err := service.DecodeRouteCursor(raw)
+		if err != nil {
+			return nil, r.fail(err, "Неверный курсор")
+		}
+		query.After = &decoded
+	}
+
+	routes, total, nextCursor, err := r.routes.ListRoutes(page, size, query)
+	if err != nil {
+		return nil, r.fail(err, "Ошибка получения списка маршрутов")
+	}
+	return &service.ListRoutesResponse{Routes: routes, Total: total, Page: page, Size: size, NextCursor: nextCursor}, nil
+}
+
+// RoutesInArea is the resolver for the routes_in_area field.
+func (r *queryResolver) RoutesInArea(ctx context.Context, area Area, page int, size int) (*service.ListRoutesResponse, error) {
+	if err := checkPage(page, size, maxRoutesPageSize); err != nil {
+		return nil, err
+	}
+	ne, sw := areaCorners(area)
+	routes, total, err := r.routes.GetRoutesByArea(ne.Lat, ne.Lon, sw.Lat, sw.Lon, false, repository.DefectFilter{}, "", scopeFrom(ctx), page, size)
+	if err != nil {
+		return nil, r.fail(err, "Ошибка получения маршрутов по области")
+	}
+	return &service.ListRoutesResponse{Routes: routes, Total: total, Page: page, Size: size}, nil
+}
+
+// Heatmap is the resolver for the heatmap field.
+func (r *queryResolver) Heatmap(ctx context.Context, area Area, cell float64) (*service.HeatmapResponse, error) {
+	if cell < 10 || cell > 100000 {
+		return nil, invalid("Неверный размер ячейки cell (от 10 до 100000 метров)")
+	}
+	ne, sw := areaCorners(area)
+	heatmap, err := r.analytics.GetCoverageHeatmap(ne.Lat, ne.Lon, sw.Lat, sw.Lon, cell, scopeFrom(ctx))
+	if err != nil {
+		return nil, r.fail(err, "Ошибка построения тепловой карты")
+	}
+	return heatmap, nil
+}
+
+// WorstSegments is the resolver for the worst_segments field.
+func (r *queryResolver) WorstSegments(ctx context.Context, area *Area, limit int, distanceM float64) (*service.WorstSegmentsResponse, error) {
+	if limit < 1 || limit > 500 {
+		return nil, invalid("Неверное значение limit (от 1 до 500)")
+	}
+	if distanceM <= 0 || distanceM > 500 {
+		return nil, invalid("Неверная ширина коридора distance_m (от 0 до 500 метров)")
+	}
+	box, err := r.optionalArea(area)
+	if err != nil {
+		return nil, err
+	}
+	response, err := r.analytics.GetWorstSegments(box, distanceM, limit, scopeFrom(ctx))
+	if err != nil {
+		return nil, r.fail(err, "Ошибка получения худших сегментов")
+	}
+	return response, nil
+}
+
+// CoverageHistogram is the resolver for the coverage_histogram field.
+func (r *queryResolver) CoverageHistogram(ctx context.Context, area *Area, from *time.Time, to *time.Time, bucket int) (*service.CoverageHistogramResponse, error) {
+	box, err := r.optionalArea(area)
+	if err != nil {
+		return nil, err
+	}
+	query := service.CoverageHistogramQuery{Area: box, From: from, To: to, BucketSize: bucket}
+	histogram, err := r.analytics.GetCoverageHistogram(query, scopeFrom(ctx))
+	if err != nil {
+		return nil, r.fail(err, "Ошибка построения гистограммы покрытия")
+	}
+	return histogram, nil
+}
+
+// Segments is the resolver for the segments field. Сегменты загружаются
+// из области доступа запроса, как и сам маршрут.
+func (r *routeResolver) Segments(ctx context.Context, obj *service.RouteResponse, page int, size int, hasData *bool, coverageLt *float64, coverageGt *float64, sort string, order string) (*service.ListSegmentsResponse, error) {
+	if err := checkPage(page, size, maxSegmentsPageSize); err != nil {
+		return nil, err
+	}
+	query := repository.SegmentQuery{
+		Page:       page,
+		PageSize:   size,
+		HasData:    hasData,
+		CoverageLT: coverageLt,
+		CoverageGT: coverageGt,
+		SortBy:     sort,
+		Desc:       order == "desc",
+		Scope:      scopeFrom(ctx),
+	}
+	segments, err := r.routes.ListSegments(obj.ID, query)
+	if err != nil {
+		return nil, r.fail(err, "Ошибка получения сегментов маршрута")
+	}
+	return segments, nil
+}
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Route returns RouteResolver implementation.
+func (r *Resolver) Route() RouteResolver { return &routeResolver{r} }
+
+type queryResolver struct{ *Resolver }
+type routeResolver struct{ *Resolver }
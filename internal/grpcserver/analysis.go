@@ -0,0 +1,212 @@
+package grpcserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/model"
+	pb "road-detector-go/internal/proto/roaddetector"
+	"road-detector-go/internal/service"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// analysisServer реализует AnalysisService
+type analysisServer struct {
+	pb.UnimplementedAnalysisServiceServer
+
+	analyzer      *service.AnalyzerService
+	routes        *service.RouteService
+	jobs          *service.JobTracker
+	maxVideoBytes int64
+	stopping      <-chan struct{}
+	logger        *logrus.Logger
+}
+
+// AnalyzeVideo принимает параметры и видео частями и отвечает результатом
+// анализа после сохранения маршрута, как POST /api/v1/analyze
+func (s *analysisServer) AnalyzeVideo(stream grpc.ClientStreamingServer[pb.AnalyzeVideoRequest, pb.AnalysisResult]) error {
+	first, err := stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return apierror.New(apierror.CodeInvalidRequest, "Первое сообщение должно содержать параметры анализа")
+		}
+		return err
+	}
+	params := first.GetParams()
+	if params == nil {
+		return apierror.New(apierror.CodeInvalidRequest, "Первое сообщение должно содержать параметры анализа")
+	}
+	metadata, err := s.analysisMetadata(stream, params)
+	if err != nil {
+		return err
+	}
+
+	var video bytes.Buffer
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if msg.GetParams() != nil {
+			return apierror.New(apierror.CodeInvalidRequest, "Параметры анализа передаются только в первом сообщении")
+		}
+		if s.maxVideoBytes > 0 && int64(video.Len()+len(msg.GetChunk())) > s.maxVideoBytes {
+			return apierror.New(apierror.CodeInvalidRequest, fmt.Sprintf("Видео больше %d байт", s.maxVideoBytes))
+		}
+		video.Write(msg.GetChunk())
+	}
+	if video.Len() == 0 {
+		return apierror.New(apierror.CodeInvalidRequest, "Видео файл обязателен")
+	}
+	s.logger.Infof("Получено по gRPC %d байт видео данных из файла %s", video.Len(), params.GetVideoFilename())
+
+	routeID := strings.TrimSpace(params.GetRouteId())
+	if routeID == "" {
+		routeID = s.routes.GenerateRouteID()
+	}
+	result, err := s.analyzer.AnalyzeRoadMarking(
+		params.GetStartPoint().GetLat(), params.GetStartPoint().GetLon(),
+		params.GetEndPoint().GetLat(), params.GetEndPoint().GetLon(),
+		params.GetSegmentLengthM(), bytes.NewReader(video.Bytes()), params.GetVideoFilename(), routeID, metadata,
+	)
+	if err != nil {
+		return apierror.Wrap(err, apierror.CodeInternal, "Ошибка анализа дорожной разметки")
+	}
+	return stream.SendAndClose(analysisResultMessage(routeID, result))
+}
+
+// analysisMetadata проверяет параметры анализа так же, как форма HTTP API,
+// и возвращает данные будущего маршрута
+func (s *analysisServer) analysisMetadata(stream grpc.ServerStream, params *pb.AnalyzeVideoParams) (service.RouteMetadata, error) {
+	if params.GetStartPoint() == nil || params.GetEndPoint() == nil || params.GetSegmentLengthM() == 0 {
+		return service.RouteMetadata{}, apierror.New(apierror.CodeInvalidRequest,
+			"Отсутствуют обязательные параметры: start_point, end_point, segment_length_m")
+	}
+	if strings.TrimSpace(params.GetVideoFilename()) == "" {
+		return service.RouteMetadata{}, apierror.New(apierror.CodeInvalidRequest, "Не указано имя видеофайла video_filename")
+	}
+
+	identity := identityFrom(stream.Context())
+	metadata := service.RouteMetadata{
+		Name:           params.GetName(),
+		Description:    params.GetDescription(),
+		APIKeyID:       identity.APIKeyID(),
+		OwnerID:        identity.UserID(),
+		OrganizationID: identity.OrganizationID(),
+		AnalysisParams: model.AnalysisParams{
+			FrameSampleRate:     params.GetFrameSampleRate(),
+			ConfidenceThreshold: params.GetConfidenceThreshold(),
+			ModelVariant:        strings.TrimSpace(params.GetModelVariant()),
+		},
+	}
+	for _, tag := range params.GetTags() {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			metadata.Tags = append(metadata.Tags, tag)
+		}
+	}
+	if value := params.GetRoi(); value != "" {
+		roi, err := service.ParseROI(value)
+		if err != nil {
+			return metadata, err
+		}
+		metadata.AnalysisParams.ROI = roi
+	}
+	if seconds := params.GetTimeoutSeconds(); seconds != 0 {
+		if !(seconds >= 1) {
+			return metadata, apierror.New(apierror.CodeInvalidRequest, "timeout_seconds должен быть не меньше 1")
+		}
+		metadata.Timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if err := metadata.Validate(); err != nil {
+		return metadata, err
+	}
+	return metadata, nil
+}
+
+// WatchJobs отправляет выполняющиеся анализы, доступные вызывающему, а затем
+// изменения их статусов до отмены вызова или остановки сервера
+func (s *analysisServer) WatchJobs(req *pb.WatchJobsRequest, stream grpc.ServerStreamingServer[pb.Job]) error {
+	scope := identityFrom(stream.Context()).RouteScope()
+	routeID := strings.TrimSpace(req.GetRouteId())
+	visible := func(job *service.Job) bool {
+		return (routeID == "" || job.RouteID == routeID) && job.Visible(scope)
+	}
+
+	running, watcher := s.jobs.Watch()
+	defer s.jobs.Unwatch(watcher)
+
+	sort.Slice(running, func(i, j int) bool { return running[i].StartedAt.Before(running[j].StartedAt) })
+	for i := range running {
+		if !visible(&running[i]) {
+			continue
+		}
+		if err := stream.Send(jobMessage(&running[i])); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case job, ok := <-watcher.Updates:
+			if !ok {
+				// Канал закрывается здесь только при переполнении, Unwatch
+				// вызывается после выхода
+				return status.Error(codes.Aborted, "Изменения статусов не успевали отправляться, подписка отключена")
+			}
+			if !visible(&job) {
+				continue
+			}
+			if err := stream.Send(jobMessage(&job)); err != nil {
+				return err
+			}
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "Сервер останавливается")
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// jobStatuses статусы анализа в сообщениях gRPC
+var jobStatuses = map[string]pb.JobStatus{
+	service.JobRunning:   pb.JobStatus_JOB_STATUS_RUNNING,
+	service.JobCompleted: pb.JobStatus_JOB_STATUS_COMPLETED,
+	service.JobFailed:    pb.JobStatus_JOB_STATUS_FAILED,
+}
+
+// jobMessage переводит состояние анализа в сообщение gRPC
+func jobMessage(job *service.Job) *pb.Job {
+	msg := &pb.Job{
+		RouteId:       job.RouteID,
+		VideoFilename: job.VideoFilename,
+		Status:        jobStatuses[job.Status],
+		Stage:         job.Stage,
+		StartedAt:     timestamppb.New(job.StartedAt),
+	}
+	if job.Err != nil {
+		// Клиент получает то же сообщение, что и в ответе на анализ
+		msg.Error = apierror.Resolve(job.Err).Message
+	}
+	if job.OrganizationID != nil {
+		id := uint32(*job.OrganizationID)
+		msg.OrganizationId = &id
+	}
+	if job.FinishedAt != nil {
+		msg.FinishedAt = timestamppb.New(*job.FinishedAt)
+	}
+	return msg
+}
@@ -0,0 +1,82 @@
+package grpcserver
+
+import (
+	"context"
+
+	"road-detector-go/internal/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// identityKey ключ инициатора вызова в контексте
+type identityKey struct{}
+
+// identityFrom возвращает инициатора вызова. Без проверки доступа это
+// пустой Identity, которому доступны все маршруты.
+func identityFrom(ctx context.Context) *auth.Identity {
+	if identity, ok := ctx.Value(identityKey{}).(*auth.Identity); ok {
+		return identity
+	}
+	return &auth.Identity{}
+}
+
+// authenticate проверяет метаданные вызова так же, как заголовки HTTP
+// запроса: authorization (Bearer токен), x-api-key и x-organization-id
+func (s *Server) authenticate(ctx context.Context) (context.Context, error) {
+	if s.opts.Auth.APIKeys == nil && s.opts.Auth.Users == nil {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	identity, err := auth.Authenticate(s.opts.Auth,
+		firstValue(md, "authorization"), firstValue(md, auth.APIKeyHeader), firstValue(md, auth.OrganizationHeader))
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, identityKey{}, identity), nil
+}
+
+// firstValue первое значение ключа метаданных. Ключи метаданных не
+// различают регистр, поэтому имена заголовков HTTP подходят как есть.
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// unaryInterceptor проверяет доступ и переводит ошибки в статусы gRPC
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, s.status(info.FullMethod, err)
+	}
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, s.status(info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+// streamInterceptor проверяет доступ и переводит ошибки в статусы gRPC
+// для потоковых вызовов
+func (s *Server) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(stream.Context())
+	if err != nil {
+		return s.status(info.FullMethod, err)
+	}
+	if err := handler(srv, &identityStream{ServerStream: stream, ctx: ctx}); err != nil {
+		return s.status(info.FullMethod, err)
+	}
+	return nil
+}
+
+// identityStream поток с контекстом, содержащим инициатора вызова
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
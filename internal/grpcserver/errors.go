@@ -0,0 +1,69 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/service"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain домен кодов ошибок в ErrorInfo
+const errorDomain = "road-detector"
+
+// grpcCodes коды gRPC для HTTP статусов ошибок API
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.AlreadyExists,
+	http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusServiceUnavailable:    codes.Unavailable,
+	http.StatusGatewayTimeout:        codes.DeadlineExceeded,
+}
+
+// status переводит ошибку в статус gRPC с тем же сообщением, что и в HTTP
+// API. Код ошибки API передается в ErrorInfo.Reason, ожидание до сброса
+// квоты или конца обслуживания — в RetryInfo.
+func (s *Server) status(method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	apiErr := apierror.Resolve(err)
+	code, ok := grpcCodes[apiErr.Code.Status()]
+	if !ok {
+		// Отмена вызова клиентом не является ошибкой сервера
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return status.FromContextError(err).Err()
+		}
+		code = codes.Internal
+	}
+	if code == codes.Internal || code == codes.Unavailable {
+		s.logger.Errorf("Ошибка gRPC вызова %s: %v", method, err)
+	}
+
+	st := status.New(code, apiErr.Message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(apiErr.Code), Domain: errorDomain}}
+	var quotaErr *service.QuotaError
+	var maintenanceErr *service.MaintenanceError
+	switch {
+	case errors.As(err, &quotaErr):
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(quotaErr.RetryAfter)})
+	case errors.As(err, &maintenanceErr):
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(maintenanceErr.RetryAfter)})
+	}
+	if withDetails, detailsErr := st.WithDetails(details...); detailsErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
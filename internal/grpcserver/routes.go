@@ -0,0 +1,146 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+	pb "road-detector-go/internal/proto/roaddetector"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// routeServer реализует RouteService
+type routeServer struct {
+	pb.UnimplementedRouteServiceServer
+
+	routes *service.RouteService
+	logger *logrus.Logger
+}
+
+// GetRoute возвращает маршрут с сегментами. Маршрут вне области доступа
+// считается несуществующим, как в HTTP API.
+func (s *routeServer) GetRoute(ctx context.Context, req *pb.GetRouteRequest) (*pb.Route, error) {
+	routeID := strings.TrimSpace(req.GetId())
+	if routeID == "" {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "Не указан ID маршрута")
+	}
+	route, err := s.routes.GetRouteByID(routeID, identityFrom(ctx).RouteScope())
+	if err != nil {
+		return nil, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрута")
+	}
+	return routeMessage(route), nil
+}
+
+// ListRoutes возвращает страницу доступных маршрутов без сегментов,
+// начиная с последних
+func (s *routeServer) ListRoutes(ctx context.Context, req *pb.ListRoutesRequest) (*pb.ListRoutesResponse, error) {
+	page := int(req.GetPage())
+	if page < 1 {
+		page = 1
+	}
+	size := int(req.GetPageSize())
+	if size == 0 {
+		size = 10
+	}
+	if size < 1 || size > 100 {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "page_size должен быть от 1 до 100")
+	}
+
+	query := repository.RouteListQuery{Desc: true, Scope: identityFrom(ctx).RouteScope()}
+	routes, total, _, err := s.routes.ListRoutes(page, size, query)
+	if err != nil {
+		return nil, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка маршрутов")
+	}
+
+	resp := &pb.ListRoutesResponse{
+		Routes:   make([]*pb.Route, len(routes)),
+		Total:    total,
+		Page:     int32(page),
+		PageSize: int32(size),
+	}
+	for i := range routes {
+		resp.Routes[i] = routeMessage(&routes[i])
+	}
+	return resp, nil
+}
+
+// routeMessage переводит маршрут в сообщение gRPC
+func routeMessage(route *service.RouteResponse) *pb.Route {
+	msg := &pb.Route{
+		Id:            route.ID,
+		Name:          route.Name,
+		Description:   route.Description,
+		RoadName:      route.RoadName,
+		StartPoint:    coordinatesMessage(route.StartPoint),
+		EndPoint:      coordinatesMessage(route.EndPoint),
+		SegmentLength: route.SegmentLength,
+		Segments:      segmentMessages(route.Segments),
+		OverallStats:  statsMessage(route.OverallStats),
+		Tags:          route.Tags,
+		CreatedAt:     timestamppb.New(route.CreatedAt),
+		UpdatedAt:     timestamppb.New(route.UpdatedAt),
+	}
+	if route.OrganizationID != nil {
+		id := uint32(*route.OrganizationID)
+		msg.OrganizationId = &id
+	}
+	return msg
+}
+
+// analysisResultMessage переводит результат анализа в сообщение gRPC
+func analysisResultMessage(routeID string, result *service.AnalysisResult) *pb.AnalysisResult {
+	return &pb.AnalysisResult{
+		RouteId:       routeID,
+		StartPoint:    coordinatesMessage(result.StartPoint),
+		EndPoint:      coordinatesMessage(result.EndPoint),
+		SegmentLength: result.SegmentLength,
+		Segments:      segmentMessages(result.Segments),
+		OverallStats:  statsMessage(result.OverallStats),
+		RoadName:      result.RoadName,
+	}
+}
+
+func coordinatesMessage(c service.Coordinates) *pb.Coordinates {
+	return &pb.Coordinates{Lat: c.Lat, Lon: c.Lon}
+}
+
+func segmentMessages(segments []service.SegmentInfo) []*pb.Segment {
+	if len(segments) == 0 {
+		return nil
+	}
+	result := make([]*pb.Segment, len(segments))
+	for i, segment := range segments {
+		result[i] = &pb.Segment{
+			SegmentId:          int32(segment.SegmentID),
+			Start:              coordinatesMessage(segment.StartCoordinate),
+			End:                coordinatesMessage(segment.EndCoordinate),
+			CoveragePercentage: segment.CoveragePercentage,
+			HasData:            segment.HasData,
+			FramesCount:        int32(segment.FramesCount),
+			RoadName:           segment.RoadName,
+			DefectsCount:       int32(segment.DefectsCount),
+			QualityScore:       segment.QualityScore,
+			CoverageBand:       segment.CoverageBand,
+			CoverageColor:      segment.CoverageColor,
+		}
+	}
+	return result
+}
+
+func statsMessage(stats service.OverallStats) *pb.OverallStats {
+	return &pb.OverallStats{
+		TotalFrames:         int32(stats.TotalFrames),
+		TotalDistanceMeters: stats.TotalDistanceMeters,
+		TotalSegments:       int32(stats.TotalSegments),
+		SegmentsWithData:    int32(stats.SegmentsWithData),
+		AverageCoverage:     stats.AverageCoverage,
+		TotalDefects:        int32(stats.TotalDefects),
+		QualityScore:        stats.QualityScore,
+		CoverageBand:        stats.CoverageBand,
+		CoverageColor:       stats.CoverageColor,
+	}
+}
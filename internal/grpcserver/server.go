@@ -0,0 +1,101 @@
+// Package grpcserver предоставляет API маршрутов и анализа видео по gRPC
+// для внутренних сервисов, которым удобнее типизированные клиенты и
+// потоковая передача. Сервер работает рядом с HTTP API и использует те же
+// сервисы, проверку доступа и коды ошибок.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"road-detector-go/internal/auth"
+	pb "road-detector-go/internal/proto/roaddetector"
+	"road-detector-go/internal/service"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Options настройки gRPC сервера
+type Options struct {
+	// Addr адрес, на котором слушает сервер, например :9090
+	Addr string
+	// MaxVideoBytes наибольший размер видео, принимаемого AnalyzeVideo,
+	// 0 — без ограничения
+	MaxVideoBytes int64
+	// Auth проверка доступа, как у HTTP API. Без ключей API и токенов
+	// пользователей запросы выполняются без проверки.
+	Auth auth.Options
+}
+
+// Server gRPC сервер API маршрутов и анализа
+type Server struct {
+	opts   Options
+	server *grpc.Server
+	// stopping закрывается при остановке, чтобы завершить подписки WatchJobs
+	stopping chan struct{}
+	logger   *logrus.Logger
+}
+
+// New создает сервер и регистрирует сервисы маршрутов, анализа и
+// отражения (reflection) для grpcurl и подобных клиентов
+func New(opts Options, analyzer *service.AnalyzerService, routes *service.RouteService, jobs *service.JobTracker, logger *logrus.Logger) (*Server, error) {
+	if opts.Addr == "" {
+		return nil, errors.New("grpc server address is required")
+	}
+	if jobs == nil {
+		return nil, errors.New("job tracker is required")
+	}
+
+	s := &Server{opts: opts, stopping: make(chan struct{}), logger: logger}
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	)
+	pb.RegisterRouteServiceServer(s.server, &routeServer{routes: routes, logger: logger})
+	pb.RegisterAnalysisServiceServer(s.server, &analysisServer{
+		analyzer:      analyzer,
+		routes:        routes,
+		jobs:          jobs,
+		maxVideoBytes: opts.MaxVideoBytes,
+		stopping:      s.stopping,
+		logger:        logger,
+	})
+	reflection.Register(s.server)
+	return s, nil
+}
+
+// ListenAndServe принимает соединения до вызова Shutdown
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen grpc: %w", err)
+	}
+	if err := s.server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("failed to serve grpc: %w", err)
+	}
+	return nil
+}
+
+// Shutdown завершает подписки WatchJobs, перестает принимать вызовы и ждет
+// выполняющиеся, в том числе анализы. Если они не завершились до ctx,
+// соединения закрываются принудительно и возвращается false.
+func (s *Server) Shutdown(ctx context.Context) bool {
+	close(s.stopping)
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+		return false
+	}
+}
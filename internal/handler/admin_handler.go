@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/coverageband"
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/logging"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminHandler обрабатывает служебные запросы администраторов
+type AdminHandler struct {
+	debugStore         *debugcapture.Store
+	unsavedResults     *service.UnsavedResultService
+	selfTestService    *service.SelfTestService
+	statsService       *service.StatsService
+	maintenanceService *service.MaintenanceService
+	bands              *coverageband.Classifier
+	logger             *logrus.Logger
+}
+
+// NewAdminHandler создает новый экземпляр AdminHandler.
+// debugStore может быть nil, если сохранение отладочных пакетов отключено,
+// unsavedResults — если отключено сохранение несохраненных результатов.
+func NewAdminHandler(debugStore *debugcapture.Store, unsavedResults *service.UnsavedResultService, selfTestService *service.SelfTestService, statsService *service.StatsService, maintenanceService *service.MaintenanceService, bands *coverageband.Classifier, logger *logrus.Logger) *AdminHandler {
+	return &AdminHandler{
+		debugStore:         debugStore,
+		unsavedResults:     unsavedResults,
+		selfTestService:    selfTestService,
+		statsService:       statsService,
+		maintenanceService: maintenanceService,
+		bands:              bands,
+		logger:             logger,
+	}
+}
+
+// RegisterRoutes регистрирует служебные маршруты
+func (h *AdminHandler) RegisterRoutes(router *gin.Engine) {
+	admin := router.Group("/api/v1/admin")
+	{
+		admin.GET("/stats", h.GetStats)
+		admin.GET("/debug-bundles", h.ListDebugBundles)
+		admin.GET("/debug-bundles/:id", h.GetDebugBundle)
+		admin.GET("/unsaved-results", h.ListUnsavedResults)
+		admin.POST("/unsaved-results/:id/replay", auth.RequireAdmin(), h.ReplayUnsavedResult)
+		admin.POST("/selftest", h.RunSelfTest)
+		admin.GET("/log-level", h.GetLogLevel)
+		admin.PUT("/log-level", auth.RequireAdmin(), h.SetLogLevel)
+		admin.GET("/coverage-bands", h.GetCoverageBands)
+		admin.PUT("/coverage-bands", h.SetCoverageBands)
+		admin.GET("/maintenance", h.GetMaintenance)
+		admin.POST("/maintenance", auth.RequireAdmin(), h.SetMaintenance)
+	}
+}
+
+// GetStats возвращает сводку для панели администратора
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	stats, err := h.statsService.GetAdminStats()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения сводки"))
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// logLevelRequest запрос изменения уровня логов
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// GetLogLevel возвращает текущий уровень логов
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": h.logger.GetLevel().String()})
+}
+
+// SetLogLevel меняет уровень логов до перезапуска сервиса
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req logLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Level == "" {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Укажите уровень логов в поле level"))
+		return
+	}
+	level, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неизвестный уровень логов, допустимы: trace, debug, info, warn, error, fatal, panic"))
+		return
+	}
+
+	previous := h.logger.GetLevel()
+	h.logger.SetLevel(level)
+	h.logger.Warnf("Уровень логов изменен: %s -> %s", previous, level)
+	audit.SetSummary(c, "%s -> %s", previous, level)
+
+	c.JSON(http.StatusOK, gin.H{"level": level.String()})
+}
+
+// coverageBandsRequest запрос изменения полос покрытия
+type coverageBandsRequest struct {
+	Bands []coverageband.Band `json:"bands"`
+}
+
+// GetCoverageBands возвращает действующие полосы покрытия
+func (h *AdminHandler) GetCoverageBands(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"bands": h.bands.Bands()})
+}
+
+// SetCoverageBands заменяет полосы покрытия до перезапуска сервиса или
+// перезагрузки конфигурации с измененным COVERAGE_BANDS
+func (h *AdminHandler) SetCoverageBands(c *gin.Context) {
+	var req coverageBandsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Укажите полосы покрытия в поле bands"))
+		return
+	}
+	previous := h.bands.String()
+	if err := h.bands.Set(req.Bands); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверные полосы покрытия: "+err.Error()))
+		return
+	}
+	h.logger.Warnf("Полосы покрытия изменены: %s -> %s", previous, coverageband.Format(req.Bands))
+	audit.SetSummary(c, "%s -> %s", previous, coverageband.Format(req.Bands))
+
+	c.JSON(http.StatusOK, gin.H{"bands": h.bands.Bands()})
+}
+
+// maintenanceRequest запрос включения или выключения режима обслуживания
+type maintenanceRequest struct {
+	Enabled *bool  `json:"enabled"`
+	Reason  string `json:"reason"`
+	// RetryAfterSeconds через сколько клиентам повторить анализ, 0 — 300
+	RetryAfterSeconds int `json:"retry_after_seconds"`
+	// Force выключить режим, даже если проверка готовности не проходит
+	Force bool `json:"force"`
+}
+
+// GetMaintenance возвращает состояние режима обслуживания
+func (h *AdminHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, h.maintenanceService.Status())
+}
+
+// SetMaintenance включает или выключает режим обслуживания. В режиме новые
+// анализы отклоняются с 503 и Retry-After, а чтение и начатые анализы
+// продолжают работать. Режим выключается, только если проходит проверка
+// готовности, либо с force.
+func (h *AdminHandler) SetMaintenance(c *gin.Context) {
+	var req maintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Enabled == nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Укажите enabled: true или false"))
+		return
+	}
+	if req.RetryAfterSeconds < 0 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "retry_after_seconds не может быть отрицательным"))
+		return
+	}
+
+	if *req.Enabled {
+		status := h.maintenanceService.Enable(req.Reason, time.Duration(req.RetryAfterSeconds)*time.Second)
+		audit.SetSummary(c, "включен: %s", req.Reason)
+		c.JSON(http.StatusOK, status)
+		return
+	}
+
+	status, err := h.maintenanceService.Disable(req.Force)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка выключения режима обслуживания"))
+		return
+	}
+	if req.Force {
+		audit.SetSummary(c, "выключен без проверки готовности")
+	} else {
+		audit.SetSummary(c, "выключен")
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// RunSelfTest прогоняет тестовое видео через весь конвейер и возвращает
+// результат каждого этапа. При неудаче любого этапа возвращается 503.
+func (h *AdminHandler) RunSelfTest(c *gin.Context) {
+	h.logger.Info("Запрошена самопроверка конвейера анализа")
+
+	report := h.selfTestService.Run()
+
+	status := http.StatusOK
+	if !report.Passed {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// ListDebugBundles возвращает список отладочных пакетов неудачных анализов
+func (h *AdminHandler) ListDebugBundles(c *gin.Context) {
+	if h.debugStore == nil {
+		apierror.Abort(c, apierror.New(apierror.CodeNotFound, "Сохранение отладочных пакетов отключено"))
+		return
+	}
+
+	bundles, err := h.debugStore.List()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка отладочных пакетов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bundles": bundles,
+		"total":   len(bundles),
+	})
+}
+
+// GetDebugBundle возвращает отладочный пакет по ID
+func (h *AdminHandler) GetDebugBundle(c *gin.Context) {
+	if h.debugStore == nil {
+		apierror.Abort(c, apierror.New(apierror.CodeNotFound, "Сохранение отладочных пакетов отключено"))
+		return
+	}
+
+	bundle, err := h.debugStore.Get(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка чтения отладочного пакета"))
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ListUnsavedResults возвращает результаты анализов, которые не удалось
+// сохранить в базе данных
+func (h *AdminHandler) ListUnsavedResults(c *gin.Context) {
+	if h.unsavedResults == nil {
+		apierror.Abort(c, apierror.New(apierror.CodeNotFound, "Сохранение несохраненных результатов отключено"))
+		return
+	}
+
+	results, err := h.unsavedResults.List()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка несохраненных результатов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"total":   len(results),
+	})
+}
+
+// ReplayUnsavedResult повторяет сохранение результата анализа в базе
+// данных без повторного анализа
+func (h *AdminHandler) ReplayUnsavedResult(c *gin.Context) {
+	if h.unsavedResults == nil {
+		apierror.Abort(c, apierror.New(apierror.CodeNotFound, "Сохранение несохраненных результатов отключено"))
+		return
+	}
+
+	route, err := h.unsavedResults.Replay(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка повторного сохранения результата анализа"))
+		return
+	}
+	audit.SetRouteID(c, route.ID)
+
+	c.JSON(http.StatusOK, route)
+}
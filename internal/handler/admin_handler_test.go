@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"road-detector-go/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func newAdminTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	router := gin.New()
+	router.Use(apierror.Middleware(logger))
+	NewAdminHandler(nil, nil, nil, nil, nil, nil, logger).RegisterRoutes(router)
+	return router
+}
+
+func TestSetMaintenanceRejectsAnonymous(t *testing.T) {
+	router := newAdminTestRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/maintenance", strings.NewReader(`{"enabled": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized && rec.Code != http.StatusForbidden {
+		t.Fatalf("anonymous POST /api/v1/admin/maintenance: status %d, want 401 or 403", rec.Code)
+	}
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AlertHandler обрабатывает запросы к правилам оповещений и оповещениям
+type AlertHandler struct {
+	alertService *service.AlertService
+	logger       *logrus.Logger
+}
+
+// NewAlertHandler создает новый экземпляр AlertHandler
+func NewAlertHandler(alertService *service.AlertService, logger *logrus.Logger) *AlertHandler {
+	return &AlertHandler{
+		alertService: alertService,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты правил и оповещений. Как и
+// подписками, ими управляют администраторы организации или сервера.
+func (h *AlertHandler) RegisterRoutes(router *gin.Engine) {
+	rules := router.Group("/api/v1/alert-rules")
+	{
+		rules.GET("", h.ListRules)
+		rules.POST("", h.CreateRule)
+		rules.GET("/:id", h.GetRule)
+		rules.PATCH("/:id", h.UpdateRule)
+		rules.DELETE("/:id", h.DeleteRule)
+	}
+
+	alerts := router.Group("/api/v1/alerts")
+	{
+		alerts.GET("", h.ListAlerts)
+		alerts.GET("/:id", h.GetAlert)
+		alerts.POST("/:id/acknowledge", h.AcknowledgeAlert)
+		alerts.DELETE("/:id", h.DeleteAlert)
+	}
+}
+
+// ListRules возвращает правила организации запроса
+func (h *AlertHandler) ListRules(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.alertService.ListRules(orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка правил оповещений"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListAlertRulesResponse{Rules: rules, Total: len(rules)})
+}
+
+// CreateRule создает правило оповещений
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	rule, err := h.alertService.CreateRule(orgID, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка создания правила оповещений"))
+		return
+	}
+	audit.SetTarget(c, "alert_rule", rule.ID)
+	audit.SetSummary(c, "%s, активно: %t", rule.Name, rule.Active)
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetRule возвращает правило оповещений
+func (h *AlertHandler) GetRule(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID правила")
+	if !ok {
+		return
+	}
+
+	rule, err := h.alertService.GetRule(id, orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения правила оповещений"))
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule меняет условия, область, получателей или признак активности правила
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID правила")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	rule, err := h.alertService.UpdateRule(id, orgID, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка изменения правила оповещений"))
+		return
+	}
+	audit.SetSummary(c, "%s, активно: %t", rule.Name, rule.Active)
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule удаляет правило. Созданные по нему оповещения сохраняются.
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID правила")
+	if !ok {
+		return
+	}
+
+	if err := h.alertService.DeleteRule(id, orgID); err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка удаления правила оповещений"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAlerts возвращает страницу оповещений организации с фильтрами по
+// статусу, правилу и маршруту
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "50"))
+	if err != nil || size < 1 || size > 500 {
+		size = 50
+	}
+	query := repository.AlertQuery{
+		Status:   c.Query("status"),
+		RouteID:  c.Query("route_id"),
+		Page:     page,
+		PageSize: size,
+	}
+	if query.Status != "" && query.Status != model.AlertStatusOpen && query.Status != model.AlertStatusAcknowledged {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение status, допустимо open или acknowledged"))
+		return
+	}
+	if raw := c.Query("rule_id"); raw != "" {
+		ruleID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || ruleID == 0 {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение rule_id"))
+			return
+		}
+		query.RuleID = uint(ruleID)
+	}
+
+	response, err := h.alertService.ListAlerts(orgID, query)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка оповещений"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAlert возвращает оповещение
+func (h *AlertHandler) GetAlert(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID оповещения")
+	if !ok {
+		return
+	}
+
+	alert, err := h.alertService.GetAlert(id, orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения оповещения"))
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// AcknowledgeAlert подтверждает оповещение от имени пользователя или ключа API запроса
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID оповещения")
+	if !ok {
+		return
+	}
+
+	by := ""
+	if user := auth.CurrentUser(c); user != nil {
+		by = user.Email
+	} else if key := auth.CurrentAPIKey(c); key != nil {
+		by = key.Name
+	}
+
+	alert, err := h.alertService.AcknowledgeAlert(id, orgID, by)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка подтверждения оповещения"))
+		return
+	}
+	audit.SetRouteID(c, alert.RouteID)
+	audit.SetSummary(c, "правило %q, сегментов: %d", alert.RuleName, alert.SegmentsCount)
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// DeleteAlert удаляет оповещение
+func (h *AlertHandler) DeleteAlert(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID оповещения")
+	if !ok {
+		return
+	}
+
+	if err := h.alertService.DeleteAlert(id, orgID); err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка удаления оповещения"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseAlertID разбирает ID правила или оповещения из пути, при ошибке
+// отвечает 400 с сообщением message
+func parseAlertID(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, message))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AnalyticsHandler обрабатывает HTTP запросы аналитики
+type AnalyticsHandler struct {
+	analyticsService *service.AnalyticsService
+	boundaryService  *service.BoundaryService
+	logger           *logrus.Logger
+}
+
+// NewAnalyticsHandler создает новый экземпляр AnalyticsHandler
+func NewAnalyticsHandler(analyticsService *service.AnalyticsService, boundaryService *service.BoundaryService, logger *logrus.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+		boundaryService:  boundaryService,
+		logger:           logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты аналитики
+func (h *AnalyticsHandler) RegisterRoutes(router *gin.Engine) {
+	analytics := router.Group("/api/v1/analytics")
+	{
+		analytics.GET("/heatmap", h.GetCoverageHeatmap)
+		analytics.GET("/by-area", h.GetAreaStatistics)
+		analytics.GET("/worst-segments", h.GetWorstSegments)
+		analytics.GET("/coverage-histogram", h.GetCoverageHistogram)
+	}
+}
+
+// GetCoverageHeatmap возвращает тепловую карту среднего покрытия по области.
+// bbox передается в формате sw_lon,sw_lat,ne_lon,ne_lat, cell - размер ячейки в метрах.
+func (h *AnalyticsHandler) GetCoverageHeatmap(c *gin.Context) {
+	h.logger.Info("Получен запрос на построение тепловой карты покрытия")
+
+	bbox := c.Query("bbox")
+	if bbox == "" {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidArea, "Отсутствует обязательный параметр bbox (sw_lon,sw_lat,ne_lon,ne_lat)"))
+		return
+	}
+
+	swLon, swLat, neLon, neLat, err := parseBBox(bbox)
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidArea, "Неверный формат bbox, ожидается sw_lon,sw_lat,ne_lon,ne_lat"))
+		return
+	}
+
+	cellSize, err := strconv.ParseFloat(c.DefaultQuery("cell", "250"), 64)
+	if err != nil || cellSize < 10 || cellSize > 100000 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный размер ячейки cell (от 10 до 100000 метров)"))
+		return
+	}
+
+	heatmap, err := h.analyticsService.GetCoverageHeatmap(neLat, neLon, swLat, swLon, cellSize, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка построения тепловой карты"))
+		return
+	}
+
+	c.JSON(http.StatusOK, heatmap)
+}
+
+// GetWorstSegments возвращает сегменты с наименьшим покрытием по последним
+// анализам каждого участка дороги. bbox (sw_lon,sw_lat,ne_lon,ne_lat)
+// необязателен, distance_m — коридор, в котором более поздний анализ
+// заменяет сегмент.
+func (h *AnalyticsHandler) GetWorstSegments(c *gin.Context) {
+	area, ok := parseOptionalBBox(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение limit (от 1 до 500)"))
+		return
+	}
+	corridor, err := strconv.ParseFloat(c.DefaultQuery("distance_m", "20"), 64)
+	if err != nil || corridor <= 0 || corridor > 500 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная ширина коридора distance_m (от 0 до 500 метров)"))
+		return
+	}
+
+	response, err := h.analyticsService.GetWorstSegments(area, corridor, limit, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения худших сегментов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCoverageHistogram возвращает распределение сегментов с данными по
+// интервалам покрытия шириной bucket процентов. bbox и период from/to
+// необязательны.
+func (h *AnalyticsHandler) GetCoverageHistogram(c *gin.Context) {
+	area, ok := parseOptionalBBox(c)
+	if !ok {
+		return
+	}
+
+	query := service.CoverageHistogramQuery{Area: area}
+	bucket, err := strconv.Atoi(c.DefaultQuery("bucket", "10"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная ширина интервала bucket"))
+		return
+	}
+	query.BucketSize = bucket
+
+	for param, target := range map[string]**time.Time{
+		"from": &query.From,
+		"to":   &query.To,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := parseTimeParam(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная дата "+param+" (RFC 3339 или ГГГГ-ММ-ДД)"))
+			return
+		}
+		*target = &value
+	}
+
+	histogram, err := h.analyticsService.GetCoverageHistogram(query, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка построения гистограммы покрытия"))
+		return
+	}
+
+	c.JSON(http.StatusOK, histogram)
+}
+
+// GetAreaStatistics возвращает по каждой доступной границе протяженность
+// проанализированных сегментов, среднее покрытие и худшие сегменты.
+// kind оставляет границы одного вида, worst — число худших сегментов.
+func (h *AnalyticsHandler) GetAreaStatistics(c *gin.Context) {
+	worst, err := strconv.Atoi(c.DefaultQuery("worst", "5"))
+	if err != nil || worst < 0 || worst > service.MaxAreaWorstSegments {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, fmt.Sprintf("Неверное значение worst (от 0 до %d)", service.MaxAreaWorstSegments)))
+		return
+	}
+
+	response, err := h.boundaryService.AreaStatistics(auth.OrganizationID(c), strings.TrimSpace(c.Query("kind")), worst, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка расчета статистики по границам"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseOptionalBBox разбирает необязательный параметр bbox, при ошибке
+// отвечает 400. nil — область не задана.
+func parseOptionalBBox(c *gin.Context) (*geo.BoundingBox, bool) {
+	bbox := c.Query("bbox")
+	if bbox == "" {
+		return nil, true
+	}
+	swLon, swLat, neLon, neLat, err := parseBBox(bbox)
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidArea, "Неверный формат bbox, ожидается sw_lon,sw_lat,ne_lon,ne_lat"))
+		return nil, false
+	}
+	area, err := geo.NewBoundingBox(neLat, neLon, swLat, swLon)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Неверная область"))
+		return nil, false
+	}
+	return &area, true
+}
+
+// parseBBox разбирает строку вида sw_lon,sw_lat,ne_lon,ne_lat
+func parseBBox(bbox string) (swLon, swLat, neLon, neLat float64, err error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return 0, 0, 0, 0, errors.New("bbox must contain 4 comma-separated numbers")
+	}
+
+	values := make([]float64, 4)
+	for i, part := range parts {
+		values[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return 0, 0, 0, 0, err
+		}
+	}
+
+	return values[0], values[1], values[2], values[3], nil
+}
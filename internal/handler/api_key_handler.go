@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// APIKeyHandler обрабатывает запросы управления ключами API
+type APIKeyHandler struct {
+	keyService *service.APIKeyService
+	logger     *logrus.Logger
+}
+
+// NewAPIKeyHandler создает новый экземпляр APIKeyHandler
+func NewAPIKeyHandler(keyService *service.APIKeyService, logger *logrus.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		keyService: keyService,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты управления ключами
+func (h *APIKeyHandler) RegisterRoutes(router *gin.Engine) {
+	admin := router.Group("/api/v1/admin")
+	{
+		admin.GET("/api-keys", h.ListKeys)
+		admin.POST("/api-keys", h.CreateKey)
+		admin.DELETE("/api-keys/:id", h.RevokeKey)
+	}
+}
+
+// ListKeys возвращает все ключи API без их значений
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.keyService.ListKeys()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка ключей API"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListAPIKeysResponse{Keys: keys, Total: len(keys)})
+}
+
+// CreateKey создает ключ API. Значение ключа возвращается только в этом ответе.
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req service.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	key, err := h.keyService.CreateKey(req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка создания ключа API"))
+		return
+	}
+	audit.SetTarget(c, "api_key", key.ID)
+	summary := fmt.Sprintf("ключ %q, администратор: %t", key.Name, key.Admin)
+	if key.OrganizationID != nil {
+		summary += fmt.Sprintf(", организация %d", *key.OrganizationID)
+	}
+	audit.SetSummary(c, "%s", summary)
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// RevokeKey отзывает ключ API
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный ID ключа API"))
+		return
+	}
+
+	key, err := h.keyService.RevokeKey(uint(id))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка отзыва ключа API"))
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
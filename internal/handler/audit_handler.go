@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditHandler обрабатывает запросы к журналу аудита
+type AuditHandler struct {
+	auditService *service.AuditService
+	logger       *logrus.Logger
+}
+
+// NewAuditHandler создает новый экземпляр AuditHandler
+func NewAuditHandler(auditService *service.AuditService, logger *logrus.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты журнала аудита. Журнал доступен
+// только администраторам.
+func (h *AuditHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/api/v1/admin/audit", h.ListEntries)
+}
+
+// ListEntries возвращает записи журнала аудита, начиная с последних
+func (h *AuditHandler) ListEntries(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "50"))
+	if err != nil || size < 1 || size > 500 {
+		size = 50
+	}
+
+	query := repository.AuditQuery{
+		Action:    c.Query("action"),
+		ActorType: c.Query("actor_type"),
+		RouteID:   c.Query("route_id"),
+		Page:      page,
+		PageSize:  size,
+	}
+	switch query.ActorType {
+	case "", model.AuditActorUser, model.AuditActorAPIKey, model.AuditActorAnonymous:
+	default:
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение actor_type (user, api_key или anonymous)"))
+		return
+	}
+
+	for param, target := range map[string]**uint{
+		"actor_id":        &query.ActorID,
+		"organization_id": &query.OrganizationID,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || value == 0 {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение "+param))
+			return
+		}
+		id := uint(value)
+		*target = &id
+	}
+
+	for param, target := range map[string]**time.Time{
+		"from": &query.From,
+		"to":   &query.To,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := parseTimeParam(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная дата "+param+" (RFC 3339 или ГГГГ-ММ-ДД)"))
+			return
+		}
+		*target = &value
+	}
+
+	response, err := h.auditService.ListEntries(query)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения журнала аудита"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AuthHandler обрабатывает регистрацию и вход пользователей
+type AuthHandler struct {
+	userService *service.UserService
+	logger      *logrus.Logger
+}
+
+// NewAuthHandler создает новый экземпляр AuthHandler
+func NewAuthHandler(userService *service.UserService, logger *logrus.Logger) *AuthHandler {
+	return &AuthHandler{
+		userService: userService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты пользователей
+func (h *AuthHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1/auth")
+	{
+		api.POST("/register", h.Register)
+		api.POST("/login", h.Login)
+		api.GET("/me", h.Me)
+	}
+}
+
+// Register регистрирует пользователя
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req service.RegisterUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	user, err := h.userService.Register(req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка регистрации пользователя"))
+		return
+	}
+	audit.SetTarget(c, "user", user.ID)
+	audit.SetSummary(c, "email %s", user.Email)
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// Login проверяет email и пароль и возвращает токен доступа
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req service.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	token, err := h.userService.Login(req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка входа"))
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// Me возвращает пользователя текущего токена
+func (h *AuthHandler) Me(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	if user == nil {
+		apierror.Abort(c, apierror.New(apierror.CodeUnauthorized, "Требуется токен пользователя"))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// BoundaryHandler обрабатывает запросы к административным границам
+type BoundaryHandler struct {
+	boundaryService *service.BoundaryService
+	logger          *logrus.Logger
+}
+
+// NewBoundaryHandler создает новый экземпляр BoundaryHandler
+func NewBoundaryHandler(boundaryService *service.BoundaryService, logger *logrus.Logger) *BoundaryHandler {
+	return &BoundaryHandler{
+		boundaryService: boundaryService,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты границ. Просматривать границы могут
+// все участники организации, загружать и удалять — ее администраторы.
+func (h *BoundaryHandler) RegisterRoutes(router *gin.Engine) {
+	boundaries := router.Group("/api/v1/boundaries")
+	{
+		boundaries.GET("", h.ListBoundaries)
+		boundaries.POST("", h.CreateBoundaries)
+		boundaries.GET("/:id", h.GetBoundary)
+		boundaries.DELETE("/:id", h.DeleteBoundary)
+	}
+}
+
+// ListBoundaries возвращает границы организации запроса и общие границы
+func (h *BoundaryHandler) ListBoundaries(c *gin.Context) {
+	boundaries, err := h.boundaryService.ListBoundaries(auth.OrganizationID(c), strings.TrimSpace(c.Query("kind")))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка границ"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListBoundariesResponse{Boundaries: boundaries, Total: len(boundaries)})
+}
+
+// CreateBoundaries загружает границы из GeoJSON
+func (h *BoundaryHandler) CreateBoundaries(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateBoundariesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	boundaries, err := h.boundaryService.CreateBoundaries(orgID, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка загрузки границ"))
+		return
+	}
+	if len(boundaries) == 1 {
+		audit.SetTarget(c, "boundary", boundaries[0].ID)
+	}
+	audit.SetSummary(c, "границ: %d, вид %q", len(boundaries), strings.TrimSpace(req.Kind))
+
+	c.JSON(http.StatusCreated, service.ListBoundariesResponse{Boundaries: boundaries, Total: len(boundaries)})
+}
+
+// GetBoundary возвращает границу с ее полигоном
+func (h *BoundaryHandler) GetBoundary(c *gin.Context) {
+	id, ok := parseAlertID(c, "Неверный ID границы")
+	if !ok {
+		return
+	}
+
+	boundary, err := h.boundaryService.GetBoundary(id, auth.OrganizationID(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения границы"))
+		return
+	}
+
+	c.JSON(http.StatusOK, boundary)
+}
+
+// DeleteBoundary удаляет границу организации
+func (h *BoundaryHandler) DeleteBoundary(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID границы")
+	if !ok {
+		return
+	}
+
+	if err := h.boundaryService.DeleteBoundary(id, orgID); err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка удаления границы"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fieldTree дерево выбранных полей JSON; nil означает поле целиком
+type fieldTree map[string]fieldTree
+
+// fieldSelection поля маршрута, запрошенные через ?fields= и ?exclude=
+type fieldSelection struct {
+	include fieldTree
+	exclude fieldTree
+}
+
+// routeFieldNames допустимые поля верхнего уровня маршрута в ответах API
+var routeFieldNames = jsonFieldNames(reflect.TypeOf(service.NearbyRoute{}))
+
+// parseFieldSelection разбирает параметры fields и exclude, например
+// fields=id,name,overall_stats.average_coverage или exclude=segments.
+// При неизвестном поле отвечает 400.
+func parseFieldSelection(c *gin.Context) (fieldSelection, bool) {
+	var selection fieldSelection
+	params := []struct {
+		name   string
+		target *fieldTree
+	}{
+		{"fields", &selection.include},
+		{"exclude", &selection.exclude},
+	}
+	for _, param := range params {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		tree := fieldTree{}
+		for _, path := range strings.Split(raw, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			if !routeFieldNames[strings.SplitN(path, ".", 2)[0]] {
+				apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неизвестное поле в "+param.name+": "+path))
+				return selection, false
+			}
+			tree.add(strings.Split(path, "."))
+		}
+		*param.target = tree
+	}
+	return selection, true
+}
+
+// empty возвращает true, если клиент не ограничивал поля
+func (s fieldSelection) empty() bool {
+	return len(s.include) == 0 && len(s.exclude) == 0
+}
+
+// wants проверяет, что поле верхнего уровня name попадет в ответ,
+// чтобы не загружать данные исключенных полей
+func (s fieldSelection) wants(name string) bool {
+	if len(s.include) > 0 {
+		if _, ok := s.include[name]; !ok {
+			return false
+		}
+	}
+	if child, ok := s.exclude[name]; ok && child == nil {
+		return false
+	}
+	return true
+}
+
+// shape применяет выбор полей к ответу. Если listKey не пуст, поля
+// выбираются у каждого элемента списка response[listKey], иначе у самого ответа.
+// Без выбора полей ответ возвращается без изменений.
+func (s fieldSelection) shape(response interface{}, listKey string) (interface{}, error) {
+	if s.empty() {
+		return response, nil
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	if listKey == "" {
+		return s.shapeObject(value), nil
+	}
+	if items, ok := value[listKey].([]interface{}); ok {
+		for i, item := range items {
+			if object, ok := item.(map[string]interface{}); ok {
+				items[i] = s.shapeObject(object)
+			}
+		}
+	}
+	return value, nil
+}
+
+// shapeObject оставляет в объекте выбранные поля и удаляет исключенные
+func (s fieldSelection) shapeObject(object map[string]interface{}) map[string]interface{} {
+	if len(s.include) > 0 {
+		object = s.include.pick(object)
+	}
+	if len(s.exclude) > 0 {
+		s.exclude.drop(object)
+	}
+	return object
+}
+
+// add добавляет путь к дереву. Поле целиком поглощает вложенные пути.
+func (t fieldTree) add(path []string) {
+	key := path[0]
+	child, exists := t[key]
+	if len(path) == 1 {
+		t[key] = nil
+		return
+	}
+	if exists && child == nil {
+		return
+	}
+	if child == nil {
+		child = fieldTree{}
+		t[key] = child
+	}
+	child.add(path[1:])
+}
+
+// pick возвращает копию объекта только с полями дерева
+func (t fieldTree) pick(object map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(t))
+	for key, child := range t {
+		value, ok := object[key]
+		if !ok {
+			continue
+		}
+		if child == nil {
+			result[key] = value
+			continue
+		}
+		result[key] = child.apply(value, child.pick)
+	}
+	return result
+}
+
+// drop удаляет из объекта поля дерева
+func (t fieldTree) drop(object map[string]interface{}) {
+	for key, child := range t {
+		if child == nil {
+			delete(object, key)
+			continue
+		}
+		if value, ok := object[key]; ok {
+			child.apply(value, func(nested map[string]interface{}) map[string]interface{} {
+				child.drop(nested)
+				return nested
+			})
+		}
+	}
+}
+
+// apply применяет fn к вложенному объекту или к каждому объекту массива
+func (t fieldTree) apply(value interface{}, fn func(map[string]interface{}) map[string]interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return fn(typed)
+	case []interface{}:
+		for i, item := range typed {
+			if object, ok := item.(map[string]interface{}); ok {
+				typed[i] = fn(object)
+			}
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+// jsonFieldNames возвращает имена JSON полей структуры, включая встроенные
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for name := range jsonFieldNames(field.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// respondShaped отправляет ответ 200 с учетом выбора полей
+func (h *RouteHandler) respondShaped(c *gin.Context, selection fieldSelection, response interface{}, listKey string) {
+	shaped, err := selection.shape(response, listKey)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка формирования ответа"))
+		return
+	}
+	c.JSON(http.StatusOK, shaped)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/graphqlapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GraphQLHandler обрабатывает запросы GraphQL
+type GraphQLHandler struct {
+	api    *graphqlapi.API
+	logger *logrus.Logger
+}
+
+// NewGraphQLHandler создает новый экземпляр GraphQLHandler
+func NewGraphQLHandler(api *graphqlapi.API, logger *logrus.Logger) *GraphQLHandler {
+	return &GraphQLHandler{
+		api:    api,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes регистрирует конечную точку GraphQL. Она находится под
+// /api/v1, чтобы на нее действовали проверка доступа и ограничение частоты.
+func (h *GraphQLHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1")
+	{
+		api.POST("/graphql", h.Query)
+		api.GET("/graphql", h.Query)
+	}
+}
+
+// Query выполняет запрос GraphQL из JSON тела (POST) или параметров query,
+// operationName и variables (GET). Ошибки полей возвращаются в errors
+// ответа со статусом 200, как принято в GraphQL.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req graphqlapi.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if raw := c.Query("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат параметра variables"))
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Не указан запрос query"))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.api.Execute(c.Request.Context(), req, auth.RouteScope(c)))
+}
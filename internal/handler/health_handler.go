@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// HealthHandler обрабатывает проверки состояния сервиса
+type HealthHandler struct {
+	healthService *service.HealthService
+	logger        *logrus.Logger
+}
+
+// NewHealthHandler создает новый экземпляр HealthHandler
+func NewHealthHandler(healthService *service.HealthService, logger *logrus.Logger) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes регистрирует проверки состояния. /healthz и /readyz
+// предназначены для оркестратора и находятся вне /api/v1, поэтому
+// не требуют авторизации и не ограничиваются по частоте.
+func (h *HealthHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/healthz", h.Liveness)
+	router.GET("/readyz", h.Readiness)
+	router.GET("/api/v1/health", h.CheckHealth)
+}
+
+// Liveness отвечает, что процесс работает и обрабатывает запросы.
+// Зависимости не проверяются, чтобы их сбой не приводил к перезапуску.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness проверяет, готов ли сервис принимать запросы
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	h.respond(c)
+}
+
+// CheckHealth возвращает состояние каждой зависимости, время ее проверки
+// и версии сервиса. Ответ тот же, что у Readiness.
+func (h *HealthHandler) CheckHealth(c *gin.Context) {
+	h.respond(c)
+}
+
+// respond отвечает состоянием сервиса
+func (h *HealthHandler) respond(c *gin.Context) {
+	health := h.healthService.Check()
+	c.JSON(healthStatusCode(health.Status), health)
+}
+
+// healthStatusCode HTTP статус ответа проверки состояния
+func healthStatusCode(status string) int {
+	if status != service.HealthStatusHealthy {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// liveWriteTimeout сколько ждать отправки одного сообщения клиенту
+	liveWriteTimeout = 10 * time.Second
+	// livePingInterval как часто проверять, что клиент на связи
+	livePingInterval = 30 * time.Second
+	// livePongTimeout через сколько без ответа на ping соединение закрывается
+	livePongTimeout = 2 * livePingInterval
+	// liveMaxMessageBytes наибольший размер сообщения от клиента
+	liveMaxMessageBytes = 4 << 10
+)
+
+// Сообщения клиента
+const (
+	liveSubscribe   = "subscribe"
+	liveUnsubscribe = "unsubscribe"
+)
+
+// liveArea область подписки, как у area в GraphQL
+type liveArea struct {
+	NorthEast service.Coordinates `json:"north_east"`
+	SouthWest service.Coordinates `json:"south_west"`
+}
+
+// liveRequest сообщение клиента
+type liveRequest struct {
+	Type string    `json:"type"`
+	Area *liveArea `json:"area,omitempty"`
+}
+
+// liveMessage сообщение клиенту: подтверждение, ошибка или изменение маршрута
+type liveMessage struct {
+	Type    string                 `json:"type"`
+	Area    *liveArea              `json:"area,omitempty"`
+	RouteID string                 `json:"route_id,omitempty"`
+	Route   *service.RouteResponse `json:"route,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+	Code    apierror.Code          `json:"code,omitempty"`
+}
+
+// LiveHandler передает клиентам карты изменения маршрутов по WebSocket
+type LiveHandler struct {
+	feed     *service.RouteFeed
+	upgrader websocket.Upgrader
+	logger   *logrus.Logger
+}
+
+// NewLiveHandler создает новый экземпляр LiveHandler
+func NewLiveHandler(feed *service.RouteFeed, logger *logrus.Logger) *LiveHandler {
+	return &LiveHandler{
+		feed: feed,
+		upgrader: websocket.Upgrader{
+			// Доступ проверяется по ключу или токену, а не по cookie,
+			// поэтому, как и для остального API, подключение разрешено с любых сайтов
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		logger: logger,
+	}
+}
+
+// RegisterRoutes регистрирует подключение WebSocket
+func (h *LiveHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1")
+	{
+		api.GET("/ws", h.Connect)
+	}
+}
+
+// Connect открывает соединение WebSocket. Клиент подписывается на область
+// сообщением subscribe и получает изменения маршрутов, проходящих через нее.
+func (h *LiveHandler) Connect(c *gin.Context) {
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Требуется подключение WebSocket"))
+		return
+	}
+	scope := auth.RouteScope(c)
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Ответ с ошибкой уже отправлен клиенту
+		h.logger.Warnf("Не удалось открыть соединение WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	watcher := h.feed.Watch()
+	defer h.feed.Unwatch(watcher)
+
+	requests := make(chan liveRequest)
+	done := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go h.readRequests(conn, requests, done, stop)
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	var area *geo.BoundingBox
+	for {
+		var message *liveMessage
+		select {
+		case <-done:
+			return
+		case req := <-requests:
+			message, area = h.handleRequest(req, area)
+		case change, ok := <-watcher.Updates:
+			if !ok {
+				h.close(conn, watcher.Dropped())
+				return
+			}
+			if area == nil || !change.Visible(scope) || !change.InArea(*area) {
+				continue
+			}
+			route := change.Route
+			message = &liveMessage{Type: change.Type, RouteID: change.RouteID, Route: &route}
+		case <-ping.C:
+			deadline := time.Now().Add(liveWriteTimeout)
+			if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+			continue
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		if err := conn.WriteJSON(message); err != nil {
+			return
+		}
+	}
+}
+
+// handleRequest выполняет сообщение клиента и возвращает ответ и новую
+// область подписки
+func (h *LiveHandler) handleRequest(req liveRequest, area *geo.BoundingBox) (*liveMessage, *geo.BoundingBox) {
+	switch req.Type {
+	case liveSubscribe:
+		if req.Area == nil {
+			return liveError(apierror.New(apierror.CodeInvalidArea, "Не указана область area")), area
+		}
+		box, err := geo.NewBoundingBox(req.Area.NorthEast.Lat, req.Area.NorthEast.Lon, req.Area.SouthWest.Lat, req.Area.SouthWest.Lon)
+		if err != nil {
+			return liveError(apierror.Wrap(err, apierror.CodeInvalidArea, "Неверная область area")), area
+		}
+		return &liveMessage{Type: "subscribed", Area: &liveArea{
+			NorthEast: service.Coordinates{Lat: box.NorthEast.Lat, Lon: box.NorthEast.Lon},
+			SouthWest: service.Coordinates{Lat: box.SouthWest.Lat, Lon: box.SouthWest.Lon},
+		}}, &box
+	case liveUnsubscribe:
+		return &liveMessage{Type: "unsubscribed"}, nil
+	default:
+		return liveError(apierror.New(apierror.CodeInvalidRequest, "Неизвестный тип сообщения, ожидается subscribe или unsubscribe")), area
+	}
+}
+
+// readRequests читает сообщения клиента, пока соединение не закроется или
+// не закрыт stop, и закрывает done
+func (h *LiveHandler) readRequests(conn *websocket.Conn, requests chan<- liveRequest, done, stop chan struct{}) {
+	defer close(done)
+
+	conn.SetReadLimit(liveMaxMessageBytes)
+	conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.logger.Debugf("Соединение WebSocket прервано: %v", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+
+		var req liveRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			req = liveRequest{Type: "invalid"}
+		}
+		select {
+		case requests <- req:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// close закрывает соединение с причиной: клиент не успевал получать
+// изменения или сервис останавливается
+func (h *LiveHandler) close(conn *websocket.Conn, dropped bool) {
+	code, reason := websocket.CloseGoingAway, "Сервис останавливается"
+	if dropped {
+		code, reason = websocket.CloseTryAgainLater, "Клиент не успевает получать изменения"
+	}
+	deadline := time.Now().Add(liveWriteTimeout)
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+}
+
+// liveError сообщение об ошибке в формате ошибок API
+func liveError(err *apierror.Error) *liveMessage {
+	return &liveMessage{Type: "error", Error: err.Message, Code: err.Code}
+}
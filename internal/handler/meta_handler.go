@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"road-detector-go/internal/buildinfo"
+	"road-detector-go/internal/coverageband"
+	"road-detector-go/internal/database"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// MetaHandler обрабатывает запросы метаинформации о сервисе
+type MetaHandler struct {
+	analyzerService *service.AnalyzerService
+	bands           *coverageband.Classifier
+	logger          *logrus.Logger
+}
+
+// NewMetaHandler создает новый экземпляр MetaHandler
+func NewMetaHandler(analyzerService *service.AnalyzerService, bands *coverageband.Classifier, logger *logrus.Logger) *MetaHandler {
+	return &MetaHandler{
+		analyzerService: analyzerService,
+		bands:           bands,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты метаинформации
+func (h *MetaHandler) RegisterRoutes(router *gin.Engine) {
+	meta := router.Group("/api/v1/meta")
+	{
+		meta.GET("/version", h.GetVersion)
+		meta.GET("/coverage-bands", h.GetCoverageBands)
+	}
+}
+
+// GetVersion возвращает версии сервиса, схемы БД, API и совместимость с Python сервисом
+func (h *MetaHandler) GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, service.VersionResponse{
+		Service:         buildinfo.Current(),
+		PythonService:   h.analyzerService.CheckPythonVersion(),
+		DBSchemaVersion: database.SchemaVersion,
+		APIVersions:     buildinfo.APIVersions,
+	})
+}
+
+// GetCoverageBands возвращает полосы покрытия и их цвета для легенды карты
+func (h *MetaHandler) GetCoverageBands(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"bands": h.bands.Bands()})
+}
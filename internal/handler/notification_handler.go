@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// NotificationHandler обрабатывает запросы к настройкам уведомлений
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	logger              *logrus.Logger
+}
+
+// NewNotificationHandler создает новый экземпляр NotificationHandler
+func NewNotificationHandler(notificationService *service.NotificationService, logger *logrus.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты настроек уведомлений. Настройки
+// принадлежат пользователю и доступны только с его токеном.
+func (h *NotificationHandler) RegisterRoutes(router *gin.Engine) {
+	notifications := router.Group("/api/v1/notifications")
+	{
+		notifications.GET("/preferences", h.GetPreferences)
+		notifications.PUT("/preferences", h.UpdatePreferences)
+	}
+}
+
+// GetPreferences возвращает настройки уведомлений текущего пользователя
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	if user == nil {
+		apierror.Abort(c, apierror.New(apierror.CodeUnauthorized, "Требуется токен пользователя"))
+		return
+	}
+
+	preferences, err := h.notificationService.GetPreferences(user)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения настроек уведомлений"))
+		return
+	}
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences меняет настройки уведомлений текущего пользователя
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	if user == nil {
+		apierror.Abort(c, apierror.New(apierror.CodeUnauthorized, "Требуется токен пользователя"))
+		return
+	}
+
+	var req service.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(user, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка изменения настроек уведомлений"))
+		return
+	}
+	c.JSON(http.StatusOK, preferences)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"road-detector-go/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Пути документа OpenAPI и Swagger UI
+const (
+	OpenAPIPath = "/openapi.json"
+	DocsPath    = "/docs"
+)
+
+// swaggerUIPage страница Swagger UI; скрипты и стили загружаются из
+// swagger-ui-dist по адресу AssetsURL
+var swaggerUIPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.AssetsURL}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.AssetsURL}}/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui", deepLinking: true});
+</script>
+</body>
+</html>
+`))
+
+// OpenAPIHandler отдает документ OpenAPI и Swagger UI
+type OpenAPIHandler struct {
+	options   openapi.Options
+	assetsURL string
+	logger    *logrus.Logger
+	spec      []byte
+	page      []byte
+}
+
+// NewOpenAPIHandler создает новый экземпляр OpenAPIHandler. assetsURL —
+// адрес swagger-ui-dist для страницы /docs.
+func NewOpenAPIHandler(options openapi.Options, assetsURL string, logger *logrus.Logger) *OpenAPIHandler {
+	options.Skip = append(options.Skip, OpenAPIPath, DocsPath)
+	return &OpenAPIHandler{
+		options:   options,
+		assetsURL: strings.TrimRight(assetsURL, "/"),
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes собирает документ по уже зарегистрированным маршрутам и
+// регистрирует /openapi.json и /docs. Вызывается после регистрации всех
+// остальных маршрутов.
+func (h *OpenAPIHandler) RegisterRoutes(router *gin.Engine) error {
+	result := openapi.Build(router.Routes(), apiOperations, h.options)
+	for _, route := range result.Undocumented {
+		h.logger.Warnf("Маршрут %s не описан в документе OpenAPI", route)
+	}
+	for _, route := range result.Stale {
+		h.logger.Warnf("Описание OpenAPI %s не соответствует ни одному маршруту", route)
+	}
+
+	spec, err := json.Marshal(result.Document)
+	if err != nil {
+		return err
+	}
+	var page bytes.Buffer
+	if err := swaggerUIPage.Execute(&page, map[string]string{
+		"Title":     h.options.Title,
+		"AssetsURL": h.assetsURL,
+		"SpecURL":   OpenAPIPath,
+	}); err != nil {
+		return err
+	}
+	h.spec, h.page = spec, page.Bytes()
+
+	router.GET(OpenAPIPath, h.GetSpec)
+	router.GET(DocsPath, h.GetDocs)
+	h.logger.Infof("Документ OpenAPI: %d путей, Swagger UI: %s", len(result.Document.Paths), DocsPath)
+	return nil
+}
+
+// GetSpec возвращает документ OpenAPI
+func (h *OpenAPIHandler) GetSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// GetDocs возвращает страницу Swagger UI
+func (h *OpenAPIHandler) GetDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", h.page)
+}
@@ -0,0 +1,533 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/graphqlapi"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/openapi"
+	"road-detector-go/internal/service"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// messageResponse ответ операции без данных
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+// statusResponse ответ проверки живости
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// debugBundlesResponse список отладочных пакетов
+type debugBundlesResponse struct {
+	Bundles []debugcapture.Summary `json:"bundles"`
+	Total   int                    `json:"total"`
+}
+
+// unsavedResultsResponse список несохраненных результатов анализов
+type unsavedResultsResponse struct {
+	Results []service.UnsavedResultSummary `json:"results"`
+	Total   int                            `json:"total"`
+}
+
+// analysisForm поля формы анализа, общие для видео и снимков
+var analysisForm = []openapi.Param{
+	{Name: "segment_length", Type: "number", Required: true, Description: "Длина сегмента в метрах"},
+	{Name: "route_id", Description: "ID маршрута до 36 символов A-Z a-z 0-9 _ -; по умолчанию генерируется, занятый ID — 409 ROUTE_EXISTS"},
+	{Name: "overwrite", Type: "boolean", Description: "Заменить анализ существующего маршрута с тем же route_id"},
+	{Name: "name", Description: "Название маршрута"},
+	{Name: "description", Description: "Описание маршрута"},
+	{Name: "tags", Array: true, Description: "Метки маршрута; поле повторяется или значения перечисляются через запятую"},
+	{Name: "frame_sample_rate", Type: "number", Description: "Кадров в секунду для анализа"},
+	{Name: "confidence_threshold", Type: "number", Description: "Порог уверенности детекций от 0 до 1"},
+	{Name: "model_variant", Description: "Вариант модели анализатора"},
+	{Name: "roi", Description: "Область кадра x,y,width,height"},
+	{Name: "timeout_seconds", Type: "number", Description: "Ожидание ответа анализатора в секундах, не меньше 1"},
+}
+
+// videoAnalysisForm поля формы анализа видео
+var videoAnalysisForm = append([]openapi.Param{
+	{Name: "video", File: true, Required: true, Description: "Видео проезда"},
+	{Name: "start_lat", Type: "number", Required: true, Description: "Широта начала маршрута"},
+	{Name: "start_lon", Type: "number", Required: true, Description: "Долгота начала маршрута"},
+	{Name: "end_lat", Type: "number", Required: true, Description: "Широта конца маршрута"},
+	{Name: "end_lon", Type: "number", Required: true, Description: "Долгота конца маршрута"},
+}, analysisForm...)
+
+// imagesAnalysisForm поля формы анализа последовательности снимков
+var imagesAnalysisForm = append([]openapi.Param{
+	{Name: "images", File: true, Required: true, Description: "ZIP архив JPEG снимков с координатами в EXIF GPS или в таблице CSV"},
+}, analysisForm...)
+
+// Параметры запроса, общие для нескольких операций
+var (
+	fieldsParams = []openapi.Param{
+		{Name: "fields", Description: "Поля ответа через запятую, например id,name,overall_stats.average_coverage"},
+		{Name: "exclude", Description: "Поля, исключаемые из ответа, например segments"},
+	}
+	defectParams = []openapi.Param{
+		{Name: "has_defects", Type: "boolean", Description: "Только маршруты с дефектами или без них"},
+		{Name: "defect_type", Description: "Тип дефекта"},
+		{Name: "marking_type", Description: "Тип разметки"},
+	}
+	corridorParams = []openapi.Param{
+		{Name: "distance_m", Type: "number", Default: 20, Description: "Ширина коридора в метрах"},
+		{Name: "min_overlap", Type: "number", Default: 0.3, Description: "Минимальная доля перекрытия"},
+	}
+	periodParams = []openapi.Param{
+		{Name: "from", Description: "Начало периода, RFC 3339"},
+		{Name: "to", Description: "Конец периода, RFC 3339"},
+	}
+	bboxParam      = openapi.Param{Name: "bbox", Description: "Область sw_lon,sw_lat,ne_lon,ne_lat"}
+	thresholdParam = openapi.Param{Name: "threshold", Type: "number", Default: 5, Description: "Порог расхождения покрытия в процентных пунктах"}
+)
+
+// pagedParams параметры страницы с размером по умолчанию size и
+// дополнительные параметры
+func pagedParams(size int, params ...openapi.Param) []openapi.Param {
+	return append([]openapi.Param{
+		{Name: "page", Type: "integer", Default: 1, Description: "Номер страницы"},
+		{Name: "size", Type: "integer", Default: size, Description: "Размер страницы"},
+	}, params...)
+}
+
+// joinParams объединяет группы параметров
+func joinParams(groups ...[]openapi.Param) []openapi.Param {
+	var params []openapi.Param
+	for _, group := range groups {
+		params = append(params, group...)
+	}
+	return params
+}
+
+// apiOperations описания операций API для документа OpenAPI. Ключ — метод и
+// шаблон пути gin. Маршрут без описания и описание без маршрута попадают в
+// журнал при запуске.
+var apiOperations = map[string]openapi.Spec{
+	// Служебные маршруты
+	"GET /": {
+		OperationID: "GetServiceInfo",
+		Summary:     "Версия и состояние сервиса",
+		Tag:         "service",
+	},
+	"GET /healthz": {Summary: "Проверка живости процесса", Response: statusResponse{}},
+	"GET /readyz": {
+		Summary:     "Готовность к приему запросов",
+		Description: "При недоступности зависимостей отвечает 503 с тем же телом.",
+		Response:    service.HealthResponse{},
+	},
+	"GET /api/v1/health": {
+		Summary:     "Подробное состояние сервиса и зависимостей",
+		Description: "Тот же ответ, что у /readyz. При недоступности зависимостей отвечает 503 с тем же телом.",
+		Response:    service.HealthResponse{},
+	},
+	"GET /api/v1/meta/version":        {Summary: "Версии сервиса, схемы БД, API и Python сервиса", Response: service.VersionResponse{}},
+	"GET /api/v1/meta/coverage-bands": {Summary: "Полосы покрытия для легенды карты", Response: coverageBandsRequest{}},
+
+	// Анализ
+	"POST /api/v1/analyze": {
+		Summary:     "Анализ видео проезда",
+		Description: "Видео делится на сегменты между началом и концом маршрута, результат сохраняется как маршрут. Ключи формы в camelCase (startLat, segmentLength, routeId) также принимаются.",
+		Form:        videoAnalysisForm,
+		Response:    service.AnalysisResult{},
+	},
+	"POST /api/v1/analyze/images": {
+		Summary:     "Анализ последовательности снимков",
+		Description: "Из снимков архива собирается видео; маршрут проходит по местам съемки от первого снимка к последнему.",
+		Form:        imagesAnalysisForm,
+		Response:    service.AnalysisResult{},
+	},
+
+	// Маршруты
+	"GET /api/v1/routes": {
+		Summary: "Список маршрутов",
+		Query: joinParams(pagedParams(10,
+			openapi.Param{Name: "name", Description: "Подстрока названия"},
+			openapi.Param{Name: "road", Description: "Подстрока названия дороги"},
+			openapi.Param{Name: "tag", Array: true, Description: "Метка маршрута; несколько меток — маршруты со всеми метками"},
+			openapi.Param{Name: "created_after", Description: "Созданы не раньше, RFC 3339"},
+			openapi.Param{Name: "created_before", Description: "Созданы не позже, RFC 3339"},
+			openapi.Param{Name: "deleted", Type: "boolean", Description: "Удаленные маршруты"},
+			openapi.Param{Name: "archived", Type: "boolean", Description: "Архивные маршруты"},
+			openapi.Param{Name: "include", Enum: []string{"segments"}, Description: "Включить сегменты"},
+			openapi.Param{Name: "sort", Default: "created_at", Enum: []string{"created_at", "coverage", "distance", "quality"}},
+			openapi.Param{Name: "order", Default: "desc", Enum: []string{"asc", "desc"}},
+			openapi.Param{Name: "cursor", Description: "Курсор следующей страницы вместо page"},
+		), defectParams, fieldsParams),
+		Response: service.ListRoutesResponse{},
+	},
+	"GET /api/v1/routes/search": {
+		Summary:  "Полнотекстовый поиск маршрутов",
+		Query:    joinParams([]openapi.Param{{Name: "q", Required: true, Description: "Текст запроса"}}, pagedParams(10), fieldsParams),
+		Response: service.SearchRoutesResponse{},
+	},
+	"GET /api/v1/routes/:id": {
+		Summary:     "Маршрут с сегментами",
+		Description: "Поддерживает ETag и If-None-Match.",
+		Query:       fieldsParams,
+		Response:    service.RouteResponse{},
+	},
+	"PATCH /api/v1/routes/:id": {Summary: "Изменение метаданных маршрута", Body: service.UpdateRouteRequest{}, Response: service.RouteResponse{}},
+	"DELETE /api/v1/routes/:id": {
+		Summary:  "Удаление маршрута",
+		Query:    []openapi.Param{{Name: "purge", Type: "boolean", Default: false, Description: "Удалить окончательно вместе с видео"}},
+		Response: messageResponse{},
+	},
+	"POST /api/v1/routes/:id/restore":   {Summary: "Восстановление удаленного маршрута", Response: service.RouteResponse{}},
+	"POST /api/v1/routes/:id/unarchive": {Summary: "Возврат маршрута из архива", Response: service.RouteResponse{}},
+	"POST /api/v1/routes/bulk":          {Summary: "Массовая операция над маршрутами", Body: service.BulkRouteRequest{}, Response: service.BulkRouteResponse{}},
+	"POST /api/v1/routes/:id/clone":     {Summary: "Копия маршрута", Status: http.StatusCreated, Response: service.RouteResponse{}},
+	"POST /api/v1/routes/:id/split": {
+		Summary:  "Разделение маршрута на два",
+		Query:    []openapi.Param{{Name: "at_segment", Type: "integer", Required: true, Description: "Первый сегмент второго маршрута"}},
+		Status:   http.StatusCreated,
+		Response: service.SplitRouteResponse{},
+	},
+	"GET /api/v1/routes/area": {
+		Summary:     "Маршруты в прямоугольной области",
+		Description: "Поддерживает ETag и If-None-Match.",
+		Query: joinParams([]openapi.Param{
+			{Name: "ne_lat", Type: "number", Required: true},
+			{Name: "ne_lon", Type: "number", Required: true},
+			{Name: "sw_lat", Type: "number", Required: true},
+			{Name: "sw_lon", Type: "number", Required: true},
+		}, pagedParams(100), defectParams, fieldsParams),
+		Response: service.GetSegmentsByAreaResponse{},
+	},
+	"GET /api/v1/routes/near": {
+		Summary: "Маршруты рядом с точкой",
+		Query: joinParams([]openapi.Param{
+			{Name: "lat", Type: "number", Required: true},
+			{Name: "lon", Type: "number", Required: true},
+			{Name: "radius_m", Type: "number", Default: 500, Description: "Радиус поиска в метрах"},
+			{Name: "limit", Type: "integer", Default: 20},
+		}, fieldsParams),
+		Response: service.GetRoutesNearResponse{},
+	},
+	"GET /api/v1/routes/nearest": {
+		Summary:  "Ближайший к точке маршрут",
+		Query:    joinParams([]openapi.Param{{Name: "lat", Type: "number", Required: true}, {Name: "lon", Type: "number", Required: true}}, fieldsParams),
+		Response: service.NearbyRoute{},
+	},
+	"POST /api/v1/routes/search/polygon": {
+		Summary:         "Маршруты, пересекающие полигон",
+		Body:            service.GeoJSONGeometry{},
+		BodyDescription: "GeoJSON Polygon, Feature или FeatureCollection с полигоном",
+		Response:        service.PolygonSearchResponse{},
+	},
+	"GET /api/v1/routes/:id/overlaps": {Summary: "Маршруты, перекрывающие маршрут", Query: corridorParams, Response: service.RouteOverlapResponse{}},
+	"GET /api/v1/routes/:id/diff": {
+		Summary: "Изменение покрытия относительно другого проезда",
+		Query: joinParams([]openapi.Param{{Name: "against", Required: true, Description: "ID маршрута или previous — предыдущий анализ"}},
+			corridorParams, []openapi.Param{thresholdParam}),
+		Response: service.RouteDiffResponse{},
+	},
+	"GET /api/v1/routes/:id/segments": {
+		Summary: "Сегменты маршрута",
+		Query: pagedParams(50,
+			openapi.Param{Name: "has_data", Type: "boolean"},
+			openapi.Param{Name: "low_confidence", Type: "boolean"},
+			openapi.Param{Name: "sort", Default: "segment_id", Enum: []string{"segment_id", "coverage", "frames_count", "quality"}},
+			openapi.Param{Name: "order", Default: "asc", Enum: []string{"asc", "desc"}},
+		),
+		Response: service.ListSegmentsResponse{},
+	},
+	"GET /api/v1/routes/:id/segments/:segmentId": {Summary: "Сегмент маршрута", Response: service.SegmentInfo{}},
+	"GET /api/v1/routes/:id/video": {
+		Summary:     "Размеченное видео маршрута",
+		Description: "Копия видео в H.264 MP4, если исходное видео не воспроизводится в браузерах и уже перекодировано",
+		Query:       []openapi.Param{{Name: "original", Type: "boolean", Default: false, Description: "Исходное видео вместо копии для браузеров"}},
+		Produces:    "video/mp4",
+	},
+	"GET /api/v1/routes/:id/video/hls/:file": {
+		Summary:     "Видео маршрута по HLS",
+		Description: "Плейлист playlist.m3u8 или сегмент segmentNNNNN.ts. При первом запросе видео нарезается на сегменты",
+		Produces:    "application/vnd.apple.mpegurl",
+	},
+	"GET /api/v1/routes/:id/report.pdf": {Summary: "PDF отчет по маршруту", Produces: "application/pdf"},
+	"GET /api/v1/routes/:id/compare-analyses": {
+		Summary:  "Сравнение основного анализа с теневыми",
+		Query:    []openapi.Param{thresholdParam},
+		Response: service.AnalysisComparison{},
+	},
+	"GET /api/v1/routes/:id/geojson": {Summary: "Маршрут в GeoJSON", Response: service.GeoJSONFeatureCollection{}},
+	"PUT /api/v1/routes/:id/tags":    {Summary: "Замена меток маршрута", Body: routeTagsRequest{}, Response: service.RouteTagsResponse{}},
+
+	// Публичные ссылки
+	"POST /api/v1/routes/:id/share": {
+		Summary:         "Создание публичной ссылки на маршрут",
+		Body:            service.CreateShareLinkRequest{},
+		BodyDescription: "Тело можно не передавать",
+		Status:          http.StatusCreated,
+		Response:        service.CreatedShareLinkResponse{},
+	},
+	"GET /api/v1/routes/:id/share":             {Summary: "Публичные ссылки маршрута", Response: service.ListShareLinksResponse{}},
+	"DELETE /api/v1/routes/:id/share/:shareId": {Summary: "Отзыв публичной ссылки", Response: service.ShareLinkInfo{}},
+	"GET /api/v1/shared/:token":                {Summary: "Маршрут по публичной ссылке", Public: true, Response: service.RouteResponse{}},
+	"GET /api/v1/shared/:token/video":          {Summary: "Видео маршрута по публичной ссылке", Public: true, Produces: "video/mp4"},
+	"GET /api/v1/shared/:token/geojson":        {Summary: "GeoJSON маршрута по публичной ссылке", Public: true, Response: service.GeoJSONFeatureCollection{}},
+
+	// Метки
+	"GET /api/v1/tags":        {Summary: "Список меток", Response: service.ListTagsResponse{}},
+	"POST /api/v1/tags":       {Summary: "Создание метки", Body: tagRequest{}, Status: http.StatusCreated, Response: service.TagInfo{}},
+	"PATCH /api/v1/tags/:id":  {Summary: "Переименование метки", Body: tagRequest{}, Response: service.TagInfo{}},
+	"DELETE /api/v1/tags/:id": {Summary: "Удаление метки", Response: messageResponse{}},
+
+	// Аналитика
+	"GET /api/v1/analytics/heatmap": {
+		Summary: "Тепловая карта покрытия",
+		Query: []openapi.Param{
+			{Name: "bbox", Required: true, Description: bboxParam.Description},
+			{Name: "cell", Type: "number", Default: 250, Description: "Размер ячейки в метрах"},
+		},
+		Response: service.HeatmapResponse{},
+	},
+	"GET /api/v1/analytics/by-area": {
+		Summary: "Статистика по административным границам",
+		Query: []openapi.Param{
+			{Name: "kind", Description: "Вид границ"},
+			{Name: "worst", Type: "integer", Default: 5, Description: "Число худших участков каждой границы"},
+		},
+		Response: service.AreaStatisticsResponse{},
+	},
+	"GET /api/v1/analytics/worst-segments": {
+		Summary: "Худшие участки дорог",
+		Query: []openapi.Param{
+			bboxParam,
+			{Name: "limit", Type: "integer", Default: 50},
+			{Name: "distance_m", Type: "number", Default: 20, Description: "Ширина коридора в метрах"},
+		},
+		Response: service.WorstSegmentsResponse{},
+	},
+	"GET /api/v1/analytics/coverage-histogram": {
+		Summary:  "Распределение сегментов по покрытию",
+		Query:    joinParams([]openapi.Param{bboxParam, {Name: "bucket", Type: "integer", Default: 10, Description: "Ширина интервала в процентах"}}, periodParams),
+		Response: service.CoverageHistogramResponse{},
+	},
+
+	// Административные границы
+	"GET /api/v1/boundaries": {
+		Summary:  "Список административных границ",
+		Query:    []openapi.Param{{Name: "kind", Description: "Вид границ"}},
+		Response: service.ListBoundariesResponse{},
+	},
+	"POST /api/v1/boundaries":       {Summary: "Загрузка административных границ", Body: service.CreateBoundariesRequest{}, Status: http.StatusCreated, Response: service.ListBoundariesResponse{}},
+	"GET /api/v1/boundaries/:id":    {Summary: "Административная граница", Response: service.BoundaryInfo{}},
+	"DELETE /api/v1/boundaries/:id": {Summary: "Удаление административной границы", Status: http.StatusNoContent},
+
+	// Дороги
+	"GET /api/v1/roads": {
+		Summary:  "Список дорог",
+		Query:    pagedParams(10, openapi.Param{Name: "name", Description: "Подстрока названия"}),
+		Response: service.ListRoadsResponse{},
+	},
+	"GET /api/v1/roads/:id": {Summary: "Дорога с участками", Response: service.RoadResponse{}},
+	"GET /api/v1/roads/:id/trend": {
+		Summary: "Динамика покрытия дороги",
+		Query: []openapi.Param{
+			{Name: "smoothing", Default: service.TrendSmoothingNone, Enum: []string{service.TrendSmoothingNone, service.TrendSmoothingMovingAverage, service.TrendSmoothingEWMA}},
+			{Name: "window", Type: "integer", Default: 3, Description: "Окно скользящего среднего"},
+			{Name: "alpha", Type: "number", Default: 0.3, Description: "Коэффициент экспоненциального сглаживания"},
+		},
+		Response: service.RoadTrendResponse{},
+	},
+	"POST /api/v1/roads/rebuild": {Summary: "Пересборка дорог по маршрутам", Response: service.RoadRebuildResponse{}},
+
+	// Пользователи и организации
+	"POST /api/v1/auth/register": {Summary: "Регистрация пользователя", Public: true, Body: service.RegisterUserRequest{}, Status: http.StatusCreated, Response: service.UserInfo{}},
+	"POST /api/v1/auth/login":    {Summary: "Вход и получение токена", Public: true, Body: service.LoginRequest{}, Response: service.TokenResponse{}},
+	"GET /api/v1/auth/me":        {Summary: "Текущий пользователь", Response: service.UserIdentity{}},
+	"GET /api/v1/admin/organizations": {
+		Summary:  "Список организаций",
+		Response: service.ListOrganizationsResponse{},
+	},
+	"POST /api/v1/admin/organizations":                 {Summary: "Создание организации", Body: service.CreateOrganizationRequest{}, Status: http.StatusCreated, Response: service.OrganizationInfo{}},
+	"GET /api/v1/organizations":                        {Summary: "Организации текущего пользователя", Response: service.ListOrganizationsResponse{}},
+	"GET /api/v1/organizations/:id/members":            {Summary: "Участники организации", Response: service.ListMembersResponse{}},
+	"POST /api/v1/organizations/:id/members":           {Summary: "Добавление участника организации", Body: service.AddMemberRequest{}, Response: service.OrganizationMemberInfo{}},
+	"DELETE /api/v1/organizations/:id/members/:userId": {Summary: "Удаление участника организации", Status: http.StatusNoContent},
+
+	// Ключи API
+	"GET /api/v1/admin/api-keys":        {Summary: "Список ключей API", Response: service.ListAPIKeysResponse{}},
+	"POST /api/v1/admin/api-keys":       {Summary: "Выпуск ключа API", Body: service.CreateAPIKeyRequest{}, Status: http.StatusCreated, Response: service.CreatedAPIKeyResponse{}},
+	"DELETE /api/v1/admin/api-keys/:id": {Summary: "Отзыв ключа API", Response: service.APIKeyInfo{}},
+
+	// Квоты и аудит
+	"GET /api/v1/usage": {Summary: "Использование квот", Response: service.UsageResponse{}},
+	"GET /api/v1/usage/storage": {
+		Summary:     "Использование хранилища",
+		Description: "Место, занятое видео и аннотированными видео маршрутов организации, пользователя или ключа API, и квота хранилища",
+		Response:    service.StorageUsageResponse{},
+	},
+	"GET /api/v1/admin/audit": {
+		Summary: "Журнал аудита",
+		Query: joinParams(pagedParams(50,
+			openapi.Param{Name: "action", Description: "Операция, например POST /api/v1/analyze"},
+			openapi.Param{Name: "actor_type", Enum: []string{model.AuditActorUser, model.AuditActorAPIKey, model.AuditActorAnonymous}},
+			openapi.Param{Name: "actor_id", Type: "integer"},
+			openapi.Param{Name: "organization_id", Type: "integer"},
+			openapi.Param{Name: "route_id"},
+		), periodParams),
+		Response: service.ListAuditResponse{},
+	},
+
+	// Вебхуки
+	"GET /api/v1/webhooks":        {Summary: "Список вебхуков", Response: service.ListWebhooksResponse{}},
+	"POST /api/v1/webhooks":       {Summary: "Создание вебхука", Body: service.CreateWebhookRequest{}, Status: http.StatusCreated, Response: service.CreatedWebhookResponse{}},
+	"GET /api/v1/webhooks/:id":    {Summary: "Вебхук", Response: service.WebhookInfo{}},
+	"PATCH /api/v1/webhooks/:id":  {Summary: "Изменение вебхука", Body: service.UpdateWebhookRequest{}, Response: service.WebhookInfo{}},
+	"DELETE /api/v1/webhooks/:id": {Summary: "Удаление вебхука", Status: http.StatusNoContent},
+	"GET /api/v1/webhooks/:id/deliveries": {
+		Summary:  "Последние доставки вебхука",
+		Query:    []openapi.Param{{Name: "limit", Type: "integer", Default: 50}},
+		Response: service.ListWebhookDeliveriesResponse{},
+	},
+
+	// Оповещения
+	"GET /api/v1/alert-rules":        {Summary: "Список правил оповещений", Response: service.ListAlertRulesResponse{}},
+	"POST /api/v1/alert-rules":       {Summary: "Создание правила оповещений", Body: service.CreateAlertRuleRequest{}, Status: http.StatusCreated, Response: service.AlertRuleInfo{}},
+	"GET /api/v1/alert-rules/:id":    {Summary: "Правило оповещений", Response: service.AlertRuleInfo{}},
+	"PATCH /api/v1/alert-rules/:id":  {Summary: "Изменение правила оповещений", Body: service.UpdateAlertRuleRequest{}, Response: service.AlertRuleInfo{}},
+	"DELETE /api/v1/alert-rules/:id": {Summary: "Удаление правила оповещений", Status: http.StatusNoContent},
+	"GET /api/v1/alerts": {
+		Summary: "Список оповещений",
+		Query: pagedParams(50,
+			openapi.Param{Name: "status", Description: "Состояние оповещения"},
+			openapi.Param{Name: "route_id"},
+			openapi.Param{Name: "rule_id", Type: "integer"},
+		),
+		Response: service.ListAlertsResponse{},
+	},
+	"GET /api/v1/alerts/:id":              {Summary: "Оповещение", Response: model.Alert{}},
+	"POST /api/v1/alerts/:id/acknowledge": {Summary: "Подтверждение оповещения", Response: model.Alert{}},
+	"DELETE /api/v1/alerts/:id":           {Summary: "Удаление оповещения", Status: http.StatusNoContent},
+
+	// Отчеты
+	"GET /api/v1/report-schedules":        {Summary: "Список расписаний отчетов", Response: service.ListReportSchedulesResponse{}},
+	"POST /api/v1/report-schedules":       {Summary: "Создание расписания отчетов", Body: service.CreateReportScheduleRequest{}, Status: http.StatusCreated, Response: service.ReportScheduleInfo{}},
+	"GET /api/v1/report-schedules/:id":    {Summary: "Расписание отчетов", Response: service.ReportScheduleInfo{}},
+	"PATCH /api/v1/report-schedules/:id":  {Summary: "Изменение расписания отчетов", Body: service.UpdateReportScheduleRequest{}, Response: service.ReportScheduleInfo{}},
+	"DELETE /api/v1/report-schedules/:id": {Summary: "Удаление расписания отчетов", Status: http.StatusNoContent},
+	"POST /api/v1/report-schedules/:id/run": {
+		Summary:  "Внеочередной отчет по расписанию",
+		Status:   http.StatusCreated,
+		Response: service.ReportInfo{},
+	},
+	"GET /api/v1/reports": {
+		Summary:  "Список отчетов",
+		Query:    pagedParams(50, openapi.Param{Name: "schedule_id", Type: "integer"}),
+		Response: service.ListReportsResponse{},
+	},
+	"GET /api/v1/reports/:id": {Summary: "Отчет", Response: service.ReportInfo{}},
+	"GET /api/v1/reports/:id/download": {
+		Summary:     "Файл отчета",
+		Description: "HTML или PDF в зависимости от format.",
+		Query:       []openapi.Param{{Name: "format", Default: model.ReportFormatHTML, Enum: []string{model.ReportFormatHTML, model.ReportFormatPDF}}},
+		Produces:    "text/html",
+	},
+
+	// Уведомления
+	"GET /api/v1/notifications/preferences": {Summary: "Настройки уведомлений пользователя", Response: service.NotificationPreferencesInfo{}},
+	"PUT /api/v1/notifications/preferences": {
+		Summary:  "Изменение настроек уведомлений",
+		Body:     service.UpdateNotificationPreferencesRequest{},
+		Response: service.NotificationPreferencesInfo{},
+	},
+
+	// GraphQL и WebSocket
+	"POST /api/v1/graphql": {Summary: "Запрос GraphQL", Body: graphqlapi.Request{}, Response: graphql.Response{}},
+	"GET /api/v1/graphql": {
+		Summary: "Запрос GraphQL в параметрах",
+		Query: []openapi.Param{
+			{Name: "query", Required: true},
+			{Name: "operationName"},
+			{Name: "variables", Description: "Переменные в JSON"},
+		},
+		Response: graphql.Response{},
+	},
+	"GET /api/v1/ws": {
+		Summary:     "Подписка на изменения маршрутов в области",
+		Description: "Соединение WebSocket; сообщения описаны в API_DOCUMENTATION.md.",
+		Status:      http.StatusSwitchingProtocols,
+	},
+
+	// Администрирование
+	"GET /api/v1/admin/stats":             {Summary: "Статистика сервиса", Response: service.AdminStatsResponse{}},
+	"GET /api/v1/admin/debug-bundles":     {Summary: "Список отладочных пакетов", Response: debugBundlesResponse{}},
+	"GET /api/v1/admin/debug-bundles/:id": {Summary: "Отладочный пакет", Response: debugcapture.Bundle{}},
+	"GET /api/v1/admin/unsaved-results":   {Summary: "Результаты анализов, не сохраненные в базе данных", Response: unsavedResultsResponse{}},
+	"POST /api/v1/admin/unsaved-results/:id/replay": {
+		Summary:     "Повторное сохранение результата анализа",
+		Description: "Сохраняет маршрут из несохраненного результата без повторного анализа и удаляет результат из хранилища.",
+		Response:    service.RouteResponse{},
+	},
+	"POST /api/v1/admin/selftest":      {Summary: "Самопроверка конвейера анализа", Description: "При неудаче этапа отвечает 503 с тем же телом.", Response: service.SelfTestReport{}},
+	"GET /api/v1/admin/log-level":      {Summary: "Уровень логов", Response: logLevelRequest{}},
+	"PUT /api/v1/admin/log-level":      {Summary: "Изменение уровня логов", Body: logLevelRequest{}, Response: logLevelRequest{}},
+	"GET /api/v1/admin/coverage-bands": {Summary: "Полосы покрытия", Response: coverageBandsRequest{}},
+	"PUT /api/v1/admin/coverage-bands": {Summary: "Изменение полос покрытия", Body: coverageBandsRequest{}, Response: coverageBandsRequest{}},
+	"GET /api/v1/admin/maintenance":    {Summary: "Режим обслуживания", Response: service.MaintenanceStatus{}},
+	"POST /api/v1/admin/maintenance": {
+		Summary:     "Включение и выключение режима обслуживания",
+		Description: "В режиме обслуживания анализы отклоняются с 503 MAINTENANCE и Retry-After. Режим выключается, только если проходит проверка готовности, иначе 503 DEPENDENCIES_UNHEALTHY; force выключает без проверки.",
+		Body:        maintenanceRequest{},
+		Response:    service.MaintenanceStatus{},
+	},
+}
+
+// apiV2Reads операции чтения API v2, которые выполняют обработчики v1: путь
+// после версии и operationId
+var apiV2Reads = map[string]string{
+	"GET /routes":                         "V2ListRoutes",
+	"GET /routes/area":                    "V2GetRoutesByArea",
+	"GET /routes/near":                    "V2GetRoutesNear",
+	"GET /routes/:id":                     "V2GetRoute",
+	"GET /routes/:id/segments":            "V2ListRouteSegments",
+	"GET /routes/:id/segments/:segmentId": "V2GetRouteSegment",
+	"GET /routes/:id/video":               "V2GetRouteVideo",
+}
+
+func init() {
+	// Описания чтения v2 совпадают с v1, отличаются только ошибки
+	for route, operationID := range apiV2Reads {
+		method, path, _ := strings.Cut(route, " ")
+		spec := apiOperations[method+" /api/v1"+path]
+		spec.OperationID = operationID
+		apiOperations[method+" /api/v2"+path] = spec
+	}
+
+	apiOperations["POST /api/v2/analyze"] = openapi.Spec{
+		OperationID: "V2AnalyzeRoadMarking",
+		Summary:     "Анализ видео проезда",
+		Description: "Поля формы только в snake_case, неизвестные и повторенные поля отклоняются. Отвечает сохраненным маршрутом и заголовком Location.",
+		Form:        videoAnalysisForm,
+		Status:      http.StatusCreated,
+		Response:    service.RouteResponse{},
+	}
+	apiOperations["POST /api/v2/analyze/images"] = openapi.Spec{
+		OperationID: "V2AnalyzeImages",
+		Summary:     "Анализ последовательности снимков",
+		Description: "Поля формы только в snake_case, неизвестные и повторенные поля отклоняются. Отвечает сохраненным маршрутом и заголовком Location.",
+		Form:        imagesAnalysisForm,
+		Status:      http.StatusCreated,
+		Response:    service.RouteResponse{},
+	}
+	apiOperations["PATCH /api/v2/routes/:id"] = openapi.Spec{
+		OperationID:     "V2UpdateRoute",
+		Summary:         "Изменение метаданных маршрута",
+		Body:            service.UpdateRouteRequest{},
+		BodyDescription: "Неизвестные поля отклоняются",
+		Response:        service.RouteResponse{},
+	}
+	apiOperations["DELETE /api/v2/routes/:id"] = openapi.Spec{
+		OperationID: "V2DeleteRoute",
+		Summary:     "Удаление маршрута",
+		Query:       apiOperations["DELETE /api/v1/routes/:id"].Query,
+		Status:      http.StatusNoContent,
+	}
+}
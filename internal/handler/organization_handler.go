@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// OrganizationHandler обрабатывает запросы к организациям и их участникам
+type OrganizationHandler struct {
+	orgService *service.OrganizationService
+	logger     *logrus.Logger
+}
+
+// NewOrganizationHandler создает новый экземпляр OrganizationHandler
+func NewOrganizationHandler(orgService *service.OrganizationService, logger *logrus.Logger) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService: orgService,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты организаций. Участниками управляют
+// администраторы организации.
+func (h *OrganizationHandler) RegisterRoutes(router *gin.Engine) {
+	orgs := router.Group("/api/v1/organizations")
+	{
+		orgs.GET("", h.ListMyOrganizations)
+		orgs.GET("/:id/members", h.ListMembers)
+		orgs.POST("/:id/members", h.AddMember)
+		orgs.DELETE("/:id/members/:userId", h.RemoveMember)
+	}
+}
+
+// RegisterAdminRoutes регистрирует создание организаций и полный список,
+// доступные администраторам
+func (h *OrganizationHandler) RegisterAdminRoutes(router *gin.Engine) {
+	admin := router.Group("/api/v1/admin")
+	{
+		admin.GET("/organizations", h.ListOrganizations)
+		admin.POST("/organizations", h.CreateOrganization)
+	}
+}
+
+// ListOrganizations возвращает все организации
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	orgs, err := h.orgService.ListOrganizations()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка организаций"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListOrganizationsResponse{Organizations: orgs, Total: len(orgs)})
+}
+
+// CreateOrganization создает организацию
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req service.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	org, err := h.orgService.CreateOrganization(req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка создания организации"))
+		return
+	}
+	audit.SetTarget(c, "organization", org.ID)
+	audit.SetSummary(c, "организация %q (%s)", org.Name, org.Slug)
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// ListMyOrganizations возвращает организации текущего пользователя с его ролью в них
+func (h *OrganizationHandler) ListMyOrganizations(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	if user == nil {
+		apierror.Abort(c, apierror.New(apierror.CodeUnauthorized, "Требуется токен пользователя"))
+		return
+	}
+
+	orgs, err := h.orgService.ListUserOrganizations(user.ID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка организаций"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListOrganizationsResponse{Organizations: orgs, Total: len(orgs)})
+}
+
+// ListMembers возвращает участников организации. Доступно ее участникам.
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	orgID, ok := h.authorize(c, false)
+	if !ok {
+		return
+	}
+
+	members, err := h.orgService.ListMembers(orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка участников"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListMembersResponse{Members: members, Total: len(members)})
+}
+
+// AddMember добавляет пользователя в организацию или меняет его роль
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	orgID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	var req service.AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	member, err := h.orgService.AddMember(orgID, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка добавления участника"))
+		return
+	}
+	audit.SetSummary(c, "пользователь %d (%s), роль: %s", member.UserID, member.Email, member.Role)
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember исключает пользователя из организации
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	orgID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil || userID == 0 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный ID пользователя"))
+		return
+	}
+
+	if err := h.orgService.RemoveMember(orgID, uint(userID)); err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка исключения участника"))
+		return
+	}
+	audit.SetSummary(c, "пользователь %d", userID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// authorize проверяет доступ к организации :id и возвращает ее ID.
+// Администраторы и запросы без проверки доступа работают с любой организацией,
+// остальные — только со своей, а при manage — только с ролью admin в ней.
+func (h *OrganizationHandler) authorize(c *gin.Context, manage bool) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный ID организации"))
+		return 0, false
+	}
+	orgID := uint(id)
+
+	user := auth.CurrentUser(c)
+	if auth.IsAdmin(c) || (user == nil && auth.CurrentAPIKey(c) == nil) {
+		return orgID, true
+	}
+
+	tenant := auth.CurrentTenant(c)
+	if (tenant == nil || tenant.OrganizationID != orgID) && user != nil {
+		tenant, err = h.orgService.ResolveTenant(user.ID, &orgID)
+		if err != nil && !errors.Is(err, service.ErrNotOrganizationMember) {
+			apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка проверки организации"))
+			return 0, false
+		}
+	}
+	if tenant == nil || tenant.OrganizationID != orgID {
+		apierror.Abort(c, apierror.New(apierror.CodeForbidden, "Нет доступа к организации"))
+		return 0, false
+	}
+	if manage && tenant.Role != model.OrgRoleAdmin {
+		apierror.Abort(c, apierror.New(apierror.CodeForbidden, "Требуются права администратора организации"))
+		return 0, false
+	}
+	return orgID, true
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ReportHandler обрабатывает запросы к расписаниям отчетов и отчетам
+type ReportHandler struct {
+	reportService *service.ReportService
+	logger        *logrus.Logger
+}
+
+// NewReportHandler создает новый экземпляр ReportHandler
+func NewReportHandler(reportService *service.ReportService, logger *logrus.Logger) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты расписаний и отчетов. Как и
+// подписками, ими управляют администраторы организации или сервера.
+func (h *ReportHandler) RegisterRoutes(router *gin.Engine) {
+	schedules := router.Group("/api/v1/report-schedules")
+	{
+		schedules.GET("", h.ListSchedules)
+		schedules.POST("", h.CreateSchedule)
+		schedules.GET("/:id", h.GetSchedule)
+		schedules.PATCH("/:id", h.UpdateSchedule)
+		schedules.DELETE("/:id", h.DeleteSchedule)
+		schedules.POST("/:id/run", h.RunSchedule)
+	}
+
+	reports := router.Group("/api/v1/reports")
+	{
+		reports.GET("", h.ListReports)
+		reports.GET("/:id", h.GetReport)
+		reports.GET("/:id/download", h.DownloadReport)
+	}
+}
+
+// ListSchedules возвращает расписания организации запроса
+func (h *ReportHandler) ListSchedules(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.reportService.ListSchedules(orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка расписаний отчетов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListReportSchedulesResponse{Schedules: schedules, Total: len(schedules)})
+}
+
+// CreateSchedule создает расписание отчетов
+func (h *ReportHandler) CreateSchedule(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateReportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	schedule, err := h.reportService.CreateSchedule(orgID, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка создания расписания отчетов"))
+		return
+	}
+	audit.SetTarget(c, "report_schedule", schedule.ID)
+	audit.SetSummary(c, "%s, %s, активно: %t", schedule.Name, schedule.Period, schedule.Active)
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// GetSchedule возвращает расписание отчетов
+func (h *ReportHandler) GetSchedule(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID расписания")
+	if !ok {
+		return
+	}
+
+	schedule, err := h.reportService.GetSchedule(id, orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения расписания отчетов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateSchedule меняет название, период, формат, получателей или признак
+// активности расписания
+func (h *ReportHandler) UpdateSchedule(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID расписания")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateReportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	schedule, err := h.reportService.UpdateSchedule(id, orgID, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка изменения расписания отчетов"))
+		return
+	}
+	audit.SetSummary(c, "%s, %s, активно: %t", schedule.Name, schedule.Period, schedule.Active)
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule удаляет расписание. Созданные по нему отчеты сохраняются.
+func (h *ReportHandler) DeleteSchedule(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID расписания")
+	if !ok {
+		return
+	}
+
+	if err := h.reportService.DeleteSchedule(id, orgID); err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка удаления расписания отчетов"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RunSchedule создает и доставляет отчет за последний завершенный период
+// вне расписания
+func (h *ReportHandler) RunSchedule(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID расписания")
+	if !ok {
+		return
+	}
+
+	report, err := h.reportService.RunNow(id, orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка создания отчета"))
+		return
+	}
+	audit.SetSummary(c, "отчет %d за %s — %s", report.ID,
+		report.PeriodStart.Format("2006-01-02"), report.PeriodEnd.Format("2006-01-02"))
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// ListReports возвращает страницу отчетов организации с фильтром по расписанию
+func (h *ReportHandler) ListReports(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "50"))
+	if err != nil || size < 1 || size > 500 {
+		size = 50
+	}
+	var scheduleID uint
+	if raw := c.Query("schedule_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || id == 0 {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение schedule_id"))
+			return
+		}
+		scheduleID = uint(id)
+	}
+
+	response, err := h.reportService.ListReports(orgID, scheduleID, page, size)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка отчетов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetReport возвращает отчет с его данными
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID отчета")
+	if !ok {
+		return
+	}
+
+	report, err := h.reportService.GetReport(id, orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения отчета"))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// DownloadReport отдает отчет файлом в формате format: html (по умолчанию) или pdf
+func (h *ReportHandler) DownloadReport(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAlertID(c, "Неверный ID отчета")
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", model.ReportFormatHTML)
+	switch format {
+	case model.ReportFormatHTML:
+	case model.ReportFormatPDF:
+		if !h.reportService.PDFEnabled() {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Отчеты в PDF не настроены на сервере"))
+			return
+		}
+	default:
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение format, допустимо html или pdf"))
+		return
+	}
+
+	content, contentType, filename, err := h.reportService.RenderReport(c.Request.Context(), id, orgID, format)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка отрисовки отчета"))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, content)
+}
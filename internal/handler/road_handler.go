@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RoadHandler обрабатывает запросы к дорогам, собранным из нескольких проездов
+type RoadHandler struct {
+	roadService *service.RoadService
+	logger      *logrus.Logger
+}
+
+// NewRoadHandler создает новый экземпляр RoadHandler
+func NewRoadHandler(roadService *service.RoadService, logger *logrus.Logger) *RoadHandler {
+	return &RoadHandler{
+		roadService: roadService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты дорог. Дороги собираются из проездов
+// всех организаций, поэтому доступны только без ограничения области маршрутов.
+func (h *RoadHandler) RegisterRoutes(router *gin.Engine) {
+	roads := router.Group("/api/v1/roads", requireGlobalScope())
+	{
+		roads.GET("", h.ListRoads)
+		roads.GET("/:id", h.GetRoad)
+		roads.GET("/:id/trend", h.GetRoadTrend)
+		roads.POST("/rebuild", h.RebuildRoads)
+	}
+}
+
+// ListRoads возвращает список дорог с пагинацией
+func (h *RoadHandler) ListRoads(c *gin.Context) {
+	h.logger.Info("Получен запрос на получение списка дорог")
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	size, err := strconv.Atoi(c.DefaultQuery("size", "10"))
+	if err != nil || size < 1 || size > 100 {
+		size = 10
+	}
+
+	roads, total, err := h.roadService.ListRoads(page, size, strings.TrimSpace(c.Query("name")))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка дорог"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListRoadsResponse{
+		Roads: roads,
+		Total: total,
+		Page:  page,
+		Size:  size,
+	})
+}
+
+// GetRoad возвращает дорогу с агрегированным покрытием по участкам
+func (h *RoadHandler) GetRoad(c *gin.Context) {
+	roadID := c.Param("id")
+	h.logger.Infof("Получен запрос на получение дороги с ID: %s", roadID)
+
+	road, err := h.roadService.GetRoad(roadID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения дороги"))
+		return
+	}
+
+	c.JSON(http.StatusOK, road)
+}
+
+// GetRoadTrend возвращает покрытие дороги во времени, по точке на проезд,
+// с необязательным сглаживанием
+func (h *RoadHandler) GetRoadTrend(c *gin.Context) {
+	roadID := c.Param("id")
+	h.logger.Infof("Получен запрос на получение ряда покрытия дороги %s", roadID)
+
+	query := service.RoadTrendQuery{Smoothing: c.DefaultQuery("smoothing", service.TrendSmoothingNone)}
+
+	window, err := strconv.Atoi(c.DefaultQuery("window", "3"))
+	if err != nil || window < 2 || window > 50 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное окно сглаживания window (от 2 до 50 проездов)"))
+		return
+	}
+	query.Window = window
+
+	alpha, err := strconv.ParseFloat(c.DefaultQuery("alpha", "0.3"), 64)
+	if err != nil || alpha <= 0 || alpha > 1 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный вес сглаживания alpha (больше 0 и не больше 1)"))
+		return
+	}
+	query.Alpha = alpha
+
+	for param, target := range map[string]**time.Time{
+		"from": &query.From,
+		"to":   &query.To,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := parseTimeParam(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная дата "+param+" (RFC 3339 или ГГГГ-ММ-ДД)"))
+			return
+		}
+		*target = &value
+	}
+
+	trend, err := h.roadService.GetTrend(roadID, query)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения ряда покрытия дороги"))
+		return
+	}
+
+	c.JSON(http.StatusOK, trend)
+}
+
+// RebuildRoads заново строит дороги по всем сохраненным маршрутам
+func (h *RoadHandler) RebuildRoads(c *gin.Context) {
+	h.logger.Info("Получен запрос на пересчет дорог")
+
+	result, err := h.roadService.Rebuild()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка пересчета дорог"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireRouteAccess пропускает запрос к маршруту :id, только если маршрут
+// входит в область доступа запроса: принадлежит организации запроса или,
+// без организации, текущему пользователю. Администраторы, ключи API без
+// организации и запросы без проверки доступа работают со всеми маршрутами.
+// Недоступный маршрут считается несуществующим, чтобы не раскрывать его наличие.
+func requireRouteAccess(routeService *service.RouteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := routeService.CheckRouteAccess(c.Param("id"), auth.RouteScope(c)); err != nil {
+			apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка проверки доступа к маршруту"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requireGlobalScope пропускает только запросы без ограничения области
+// маршрутов. Им закрыты данные, общие для всех организаций: дороги,
+// собранные из проездов разных организаций, и управление метками.
+func requireGlobalScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.RouteScope(c).Empty() {
+			apierror.Abort(c, apierror.New(apierror.CodeForbidden, "Доступно только администраторам"))
+			return
+		}
+
+		c.Next()
+	}
+}
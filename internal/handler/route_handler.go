@@ -2,10 +2,24 @@ package handler
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/hls"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
 	"road-detector-go/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -31,14 +45,32 @@ func NewRouteHandler(analyzerService *service.AnalyzerService, routeService *ser
 // RegisterRoutes регистрирует маршруты API
 func (h *RouteHandler) RegisterRoutes(router *gin.Engine) {
 	api := router.Group("/api/v1")
+	access := requireRouteAccess(h.routeService)
 	{
 		api.POST("/analyze", h.AnalyzeRoadMarking)
+		api.POST("/analyze/images", h.AnalyzeImages)
 		api.GET("/routes", h.ListRoutes)
-		api.GET("/routes/:id", h.GetRoute)
-		api.DELETE("/routes/:id", h.DeleteRoute)
+		api.GET("/routes/search", h.SearchRoutes)
+		api.GET("/routes/:id", access, h.GetRoute)
+		api.PATCH("/routes/:id", access, h.UpdateRoute)
+		api.DELETE("/routes/:id", access, h.DeleteRoute)
+		api.POST("/routes/:id/restore", access, h.RestoreRoute)
+		api.POST("/routes/:id/unarchive", access, h.UnarchiveRoute)
+		api.POST("/routes/bulk", h.BulkRoutes)
+		api.POST("/routes/:id/clone", access, h.CloneRoute)
+		api.POST("/routes/:id/split", access, h.SplitRoute)
 		api.GET("/routes/area", h.GetRoutesByArea)
-		api.GET("/health", h.CheckHealth)
-		api.GET("/routes/:id/video", h.GetRouteVideo)
+		api.GET("/routes/near", h.GetRoutesNear)
+		api.GET("/routes/nearest", h.GetNearestRoute)
+		api.POST("/routes/search/polygon", h.SearchRoutesByPolygon)
+		api.GET("/routes/:id/overlaps", access, h.GetRouteOverlaps)
+		api.GET("/routes/:id/diff", access, h.GetRouteDiff)
+		api.GET("/routes/:id/segments", access, h.ListRouteSegments)
+		api.GET("/routes/:id/segments/:segmentId", access, h.GetRouteSegment)
+		api.GET("/routes/:id/video", access, h.GetRouteVideo)
+		api.GET("/routes/:id/video/hls/:file", access, h.GetRouteVideoHLS)
+		api.GET("/routes/:id/video/annotated", access, h.GetRouteAnnotatedVideo)
+		api.GET("/routes/:id/report.pdf", access, h.GetRouteReport)
 	}
 }
 
@@ -49,7 +81,7 @@ func (h *RouteHandler) AnalyzeRoadMarking(c *gin.Context) {
 	// Парсим multipart form
 	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
 		h.logger.Errorf("Ошибка парсинга multipart form: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка парсинга формы"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Ошибка парсинга формы"))
 		return
 	}
 
@@ -64,9 +96,8 @@ func (h *RouteHandler) AnalyzeRoadMarking(c *gin.Context) {
 	// Проверяем обязательные параметры
 	if startLatStr == "" || startLonStr == "" || endLatStr == "" || endLonStr == "" || segmentLengthStr == "" {
 		h.logger.Error("Отсутствуют обязательные параметры")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Отсутствуют обязательные параметры: start_lat (или startLat), start_lon (или startLon), end_lat (или endLat), end_lon (или endLon), segment_length (или segment_length_m, segmentLength)",
-		})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest,
+			"Отсутствуют обязательные параметры: start_lat (или startLat), start_lon (или startLon), end_lat (или endLat), end_lon (или endLon), segment_length (или segment_length_m, segmentLength)"))
 		return
 	}
 
@@ -74,35 +105,41 @@ func (h *RouteHandler) AnalyzeRoadMarking(c *gin.Context) {
 	startLat, err := strconv.ParseFloat(startLatStr, 64)
 	if err != nil {
 		h.logger.Errorf("Ошибка парсинга start_lat: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат start_lat"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат start_lat"))
 		return
 	}
 
 	startLon, err := strconv.ParseFloat(startLonStr, 64)
 	if err != nil {
 		h.logger.Errorf("Ошибка парсинга start_lon: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат start_lon"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат start_lon"))
 		return
 	}
 
 	endLat, err := strconv.ParseFloat(endLatStr, 64)
 	if err != nil {
 		h.logger.Errorf("Ошибка парсинга end_lat: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат end_lat"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат end_lat"))
 		return
 	}
 
 	endLon, err := strconv.ParseFloat(endLonStr, 64)
 	if err != nil {
 		h.logger.Errorf("Ошибка парсинга end_lon: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат end_lon"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат end_lon"))
 		return
 	}
 
 	segmentLength, err := strconv.ParseFloat(segmentLengthStr, 64)
 	if err != nil {
 		h.logger.Errorf("Ошибка парсинга segment_length: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат segment_length"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат segment_length"))
+		return
+	}
+
+	metadata, err := parseAnalysisMetadata(c)
+	if err != nil {
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -110,7 +147,7 @@ func (h *RouteHandler) AnalyzeRoadMarking(c *gin.Context) {
 	file, header, err := c.Request.FormFile("video")
 	if err != nil {
 		h.logger.Errorf("Ошибка получения видео файла: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Видео файл обязателен"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Видео файл обязателен"))
 		return
 	}
 	defer file.Close()
@@ -119,7 +156,7 @@ func (h *RouteHandler) AnalyzeRoadMarking(c *gin.Context) {
 	videoData, err := io.ReadAll(file)
 	if err != nil {
 		h.logger.Errorf("Ошибка чтения видео файла: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения видео файла"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Ошибка чтения видео файла"))
 		return
 	}
 	h.logger.Infof("Прочитано %d байт видео данных из файла %s", len(videoData), header.Filename)
@@ -127,14 +164,20 @@ func (h *RouteHandler) AnalyzeRoadMarking(c *gin.Context) {
 	// Создаем reader из буфера для передачи в сервис анализа
 	videoReader := bytes.NewReader(videoData)
 
+	// ID генерируется до анализа, чтобы записать его в журнал аудита
+	if routeID == "" {
+		routeID = h.routeService.GenerateRouteID()
+	}
+	audit.SetRouteID(c, routeID)
+	audit.SetSummary(c, "видео %s, %d байт", header.Filename, len(videoData))
+
 	// Вызываем сервис анализа
 	result, err := h.analyzerService.AnalyzeRoadMarking(
 		startLat, startLon, endLat, endLon,
-		segmentLength, videoReader, header.Filename, routeID,
+		segmentLength, videoReader, header.Filename, routeID, metadata,
 	)
 	if err != nil {
-		h.logger.Errorf("Ошибка анализа: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка анализа дорожной разметки"})
+		abortAnalysis(c, err)
 		return
 	}
 
@@ -142,6 +185,169 @@ func (h *RouteHandler) AnalyzeRoadMarking(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// AnalyzeImages анализирует последовательность снимков из ZIP архива.
+// Координаты снимков берутся из EXIF GPS или из таблицы CSV в архиве,
+// начало и конец маршрута — места первого и последнего снимков.
+func (h *RouteHandler) AnalyzeImages(c *gin.Context) {
+	h.logger.Info("Получен запрос на анализ последовательности снимков")
+
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+		h.logger.Errorf("Ошибка парсинга multipart form: %v", err)
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Ошибка парсинга формы"))
+		return
+	}
+
+	segmentLengthStr := getFormValue(c, []string{"segment_length", "segment_length_m", "segmentLength"})
+	if segmentLengthStr == "" {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest,
+			"Отсутствует обязательный параметр segment_length (или segment_length_m, segmentLength)"))
+		return
+	}
+	segmentLength, err := strconv.ParseFloat(segmentLengthStr, 64)
+	if err != nil || !(segmentLength > 0) {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "segment_length должен быть положительным числом"))
+		return
+	}
+	routeID := getFormValue(c, []string{"route_id", "routeId"})
+
+	metadata, err := parseAnalysisMetadata(c)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	file, header, err := c.Request.FormFile("images")
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "ZIP архив снимков images обязателен"))
+		return
+	}
+	defer file.Close()
+	archive, err := io.ReadAll(file)
+	if err != nil {
+		h.logger.Errorf("Ошибка чтения архива снимков: %v", err)
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Ошибка чтения архива снимков"))
+		return
+	}
+	h.logger.Infof("Прочитано %d байт архива снимков %s", len(archive), header.Filename)
+
+	if routeID == "" {
+		routeID = h.routeService.GenerateRouteID()
+	}
+	audit.SetRouteID(c, routeID)
+	audit.SetSummary(c, "снимки %s, %d байт", header.Filename, len(archive))
+
+	result, err := h.analyzerService.AnalyzeImageSequence(archive, header.Filename, segmentLength, routeID, metadata)
+	if err != nil {
+		abortAnalysis(c, err)
+		return
+	}
+
+	h.logger.Info("Анализ последовательности снимков завершен успешно")
+	c.JSON(http.StatusOK, result)
+}
+
+// parseAnalysisMetadata получает из формы анализа метаданные маршрута,
+// параметры анализа и ожидание ответа анализатора и проверяет их
+func parseAnalysisMetadata(c *gin.Context) (service.RouteMetadata, error) {
+	metadata := service.RouteMetadata{
+		Name:           c.PostForm("name"),
+		Description:    c.PostForm("description"),
+		Tags:           splitFormList(c.PostFormArray("tags")),
+		APIKeyID:       auth.APIKeyID(c),
+		OwnerID:        auth.UserID(c),
+		OrganizationID: auth.OrganizationID(c),
+	}
+
+	var err error
+	metadata.AnalysisParams, err = parseAnalysisParams(c)
+	if err != nil {
+		return metadata, err
+	}
+	if value := c.PostForm("timeout_seconds"); value != "" {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil || !(seconds >= 1) {
+			return metadata, apierror.New(apierror.CodeInvalidRequest, "timeout_seconds должен быть числом не меньше 1")
+		}
+		metadata.Timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if value := c.PostForm("overwrite"); value != "" {
+		overwrite, err := strconv.ParseBool(value)
+		if err != nil {
+			return metadata, apierror.New(apierror.CodeInvalidRequest, "overwrite должен быть true или false")
+		}
+		metadata.Overwrite = overwrite
+		metadata.Scope = auth.RouteScope(c)
+	}
+
+	if err := metadata.Validate(); err != nil {
+		return metadata, err
+	}
+	return metadata, nil
+}
+
+// abortAnalysis отвечает ошибкой анализа. При исчерпанной квоте и в режиме
+// обслуживания в ответ добавляется Retry-After.
+func abortAnalysis(c *gin.Context, err error) {
+	var quotaErr *service.QuotaError
+	var maintenanceErr *service.MaintenanceError
+	switch {
+	case errors.As(err, &quotaErr):
+		setRetryAfter(c, quotaErr.RetryAfter)
+	case errors.As(err, &maintenanceErr):
+		setRetryAfter(c, maintenanceErr.RetryAfter)
+	}
+	apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка анализа дорожной разметки"))
+}
+
+// setRetryAfter добавляет заголовок Retry-After в секундах
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
+
+// splitFormList объединяет значения поля формы, переданные несколько раз
+// или через запятую, пропуская пустые
+func splitFormList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+// parseAnalysisParams получает из формы параметры анализа, передаваемые
+// Python сервису. Проверку значений выполняет RouteMetadata.Validate.
+func parseAnalysisParams(c *gin.Context) (model.AnalysisParams, error) {
+	params := model.AnalysisParams{
+		ModelVariant: strings.TrimSpace(c.PostForm("model_variant")),
+	}
+	if value := c.PostForm("frame_sample_rate"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return params, apierror.New(apierror.CodeInvalidRequest, "Неверный формат frame_sample_rate")
+		}
+		params.FrameSampleRate = rate
+	}
+	if value := c.PostForm("confidence_threshold"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return params, apierror.New(apierror.CodeInvalidRequest, "Неверный формат confidence_threshold")
+		}
+		params.ConfidenceThreshold = threshold
+	}
+	if value := c.PostForm("roi"); value != "" {
+		roi, err := service.ParseROI(value)
+		if err != nil {
+			return params, err
+		}
+		params.ROI = roi
+	}
+	return params, nil
+}
+
 // getFormValue получает значение из формы, пробуя разные варианты ключей
 func getFormValue(c *gin.Context, keys []string) string {
 	for _, key := range keys {
@@ -170,23 +376,233 @@ func (h *RouteHandler) ListRoutes(c *gin.Context) {
 		size = 10
 	}
 
+	query, ok := parseRouteListQuery(c)
+	if !ok {
+		return
+	}
+	query.Scope = auth.RouteScope(c)
+	selection, ok := parseFieldSelection(c)
+	if !ok {
+		return
+	}
+
 	// Получаем маршруты
-	routes, total, err := h.routeService.ListRoutes(page, size)
+	routes, total, nextCursor, err := h.routeService.ListRoutes(page, size, query)
 	if err != nil {
-		h.logger.Errorf("Ошибка получения списка маршрутов: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения списка маршрутов"})
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка маршрутов"))
 		return
 	}
 
 	response := service.ListRoutesResponse{
+		Routes:     routes,
+		Total:      total,
+		Page:       page,
+		Size:       size,
+		NextCursor: nextCursor,
+	}
+
+	h.logger.Infof("Возвращено %d маршрутов из %d", len(routes), total)
+	h.respondShaped(c, selection, response, "routes")
+}
+
+// SearchRoutes ищет маршруты по названию, описанию и названию дороги
+func (h *RouteHandler) SearchRoutes(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	h.logger.Infof("Получен запрос на поиск маршрутов: %q", text)
+
+	if text == "" {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Не указан поисковый запрос q"))
+		return
+	}
+	if len([]rune(text)) > 200 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Поисковый запрос длиннее 200 символов"))
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	size, err := strconv.Atoi(c.DefaultQuery("size", "10"))
+	if err != nil || size < 1 || size > 100 {
+		size = 10
+	}
+
+	selection, ok := parseFieldSelection(c)
+	if !ok {
+		return
+	}
+
+	routes, total, err := h.routeService.SearchRoutes(text, auth.RouteScope(c), page, size)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка поиска маршрутов"))
+		return
+	}
+
+	h.respondShaped(c, selection, service.SearchRoutesResponse{
+		Query:  text,
 		Routes: routes,
 		Total:  total,
 		Page:   page,
 		Size:   size,
+	}, "routes")
+}
+
+// parseDefectFilter разбирает фильтры дефектов покрытия has_defects и
+// defect_type. При ошибке отправляет 400 и возвращает false.
+func parseDefectFilter(c *gin.Context) (repository.DefectFilter, bool) {
+	var filter repository.DefectFilter
+	if raw := c.Query("has_defects"); raw != "" {
+		hasDefects, err := strconv.ParseBool(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение has_defects"))
+			return filter, false
+		}
+		filter.HasDefects = &hasDefects
 	}
+	filter.Type = strings.ToLower(strings.TrimSpace(c.Query("defect_type")))
+	return filter, true
+}
 
-	h.logger.Infof("Возвращено %d маршрутов из %d", len(routes), total)
-	c.JSON(http.StatusOK, response)
+// parseMarkingType разбирает фильтр marking_type по типу разметки.
+// При ошибке отправляет 400 и возвращает false.
+func parseMarkingType(c *gin.Context) (string, bool) {
+	markingType := strings.ToLower(strings.TrimSpace(c.Query("marking_type")))
+	if markingType != "" && !model.ValidMarkingType(markingType) {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный тип разметки marking_type"))
+		return "", false
+	}
+	return markingType, true
+}
+
+// parseRouteListQuery разбирает фильтры и сортировку списка маршрутов.
+// При ошибке отправляет 400 и возвращает false.
+func parseRouteListQuery(c *gin.Context) (repository.RouteListQuery, bool) {
+	query := repository.RouteListQuery{
+		Name:     strings.TrimSpace(c.Query("name")),
+		RoadName: strings.TrimSpace(c.Query("road")),
+		SortBy:   repository.RouteSortCreatedAt,
+		Desc:     true,
+	}
+
+	if raw := c.Query("deleted"); raw != "" {
+		deleted, err := strconv.ParseBool(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение deleted"))
+			return query, false
+		}
+		query.Deleted = deleted
+	}
+
+	if raw := c.Query("archived"); raw != "" {
+		archived, err := strconv.ParseBool(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение archived"))
+			return query, false
+		}
+		query.Archived = archived
+	}
+
+	if tags := c.QueryArray("tag"); len(tags) > 0 {
+		normalized, err := service.NormalizeTags(tags)
+		if err != nil {
+			apierror.Abort(c, err)
+			return query, false
+		}
+		query.Tags = normalized
+	}
+
+	defects, ok := parseDefectFilter(c)
+	if !ok {
+		return query, false
+	}
+	query.Defects = defects
+
+	if query.MarkingType, ok = parseMarkingType(c); !ok {
+		return query, false
+	}
+
+	// По умолчанию список возвращается без сегментов, ?include=segments возвращает их
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		if strings.TrimSpace(include) == "segments" {
+			query.WithSegments = true
+		}
+	}
+
+	for param, target := range map[string]**time.Time{
+		"created_after":  &query.CreatedAfter,
+		"created_before": &query.CreatedBefore,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := parseTimeParam(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная дата "+param+" (RFC 3339 или ГГГГ-ММ-ДД)"))
+			return query, false
+		}
+		*target = &value
+	}
+
+	for param, target := range map[string]**float64{
+		"min_coverage": &query.MinCoverage,
+		"max_coverage": &query.MaxCoverage,
+		"min_quality":  &query.MinQuality,
+		"max_quality":  &query.MaxQuality,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение "+param))
+			return query, false
+		}
+		*target = &value
+	}
+
+	switch sortBy := c.DefaultQuery("sort", repository.RouteSortCreatedAt); sortBy {
+	case repository.RouteSortCreatedAt, repository.RouteSortCoverage, repository.RouteSortDistance, repository.RouteSortQuality:
+		query.SortBy = sortBy
+	default:
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное поле сортировки (created_at, coverage, distance или quality)"))
+		return query, false
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		if query.SortBy != repository.RouteSortCreatedAt {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Курсор поддерживается только при сортировке по created_at"))
+			return query, false
+		}
+		cursor, err := service.DecodeRouteCursor(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidCursor, "Неверный курсор"))
+			return query, false
+		}
+		query.After = &cursor
+	}
+
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		query.Desc = false
+	case "desc":
+	default:
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный порядок сортировки (asc или desc)"))
+		return query, false
+	}
+
+	return query, true
+}
+
+// parseTimeParam разбирает дату в формате RFC 3339 или ГГГГ-ММ-ДД (UTC)
+func parseTimeParam(raw string) (time.Time, error) {
+	if value, err := time.Parse(time.RFC3339, raw); err == nil {
+		return value, nil
+	}
+	return time.Parse("2006-01-02", raw)
 }
 
 // GetRoute возвращает маршрут по ID
@@ -194,26 +610,97 @@ func (h *RouteHandler) GetRoute(c *gin.Context) {
 	routeID := c.Param("id")
 	h.logger.Infof("Получен запрос на получение маршрута с ID: %s", routeID)
 
-	route, err := h.routeService.GetRouteByID(routeID)
+	selection, ok := parseFieldSelection(c)
+	if !ok {
+		return
+	}
+
+	// Версия проверяется до загрузки сегментов, чтобы 304 не нагружал БД
+	version, err := h.routeService.GetRouteVersion(routeID)
 	if err != nil {
-		h.logger.Errorf("Ошибка получения маршрута: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Маршрут не найден"})
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрута"))
+		return
+	}
+	// Полосы покрытия входят в ETag: после их изменения меняется ответ, но не маршрут
+	etag := etagOf([]byte(routeID), []byte(strconv.FormatInt(version.UnixNano(), 10)), []byte(c.Request.URL.RawQuery),
+		[]byte(h.routeService.CoverageBands().String()))
+	if checkNotModified(c, etag, version) {
+		return
+	}
+
+	route, err := h.routeService.GetRouteByID(routeID, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрута"))
 		return
 	}
 
 	h.logger.Info("Маршрут найден и возвращен")
+	h.respondShaped(c, selection, route, "")
+}
+
+// UpdateRoute частично обновляет название, описание и пользовательские поля маршрута
+func (h *RouteHandler) UpdateRoute(c *gin.Context) {
+	routeID := c.Param("id")
+	h.logger.Infof("Получен запрос на обновление маршрута с ID: %s", routeID)
+
+	var req service.UpdateRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	route, err := h.routeService.UpdateRouteMetadata(routeID, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка обновления маршрута"))
+		return
+	}
+	audit.SetSummary(c, "изменены поля: %s", strings.Join(updatedFields(req), ", "))
+
 	c.JSON(http.StatusOK, route)
 }
 
-// DeleteRoute удаляет маршрут по ID
+// updatedFields перечисляет поля маршрута, переданные в запросе обновления
+func updatedFields(req service.UpdateRouteRequest) []string {
+	var fields []string
+	if req.Name != nil {
+		fields = append(fields, "name")
+	}
+	if req.Description != nil {
+		fields = append(fields, "description")
+	}
+	for key := range req.CustomFields {
+		fields = append(fields, "custom_fields."+key)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// DeleteRoute удаляет маршрут по ID. По умолчанию удаление мягкое,
+// с ?purge=true маршрут и его файлы удаляются безвозвратно.
 func (h *RouteHandler) DeleteRoute(c *gin.Context) {
 	routeID := c.Param("id")
 	h.logger.Infof("Получен запрос на удаление маршрута с ID: %s", routeID)
 
-	err := h.routeService.DeleteRoute(routeID)
+	purge, err := strconv.ParseBool(c.DefaultQuery("purge", "false"))
 	if err != nil {
-		h.logger.Errorf("Ошибка удаления маршрута: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления маршрута"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение purge"))
+		return
+	}
+
+	if purge {
+		err = h.routeService.PurgeRoute(routeID)
+	} else {
+		err = h.routeService.DeleteRoute(routeID)
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка удаления маршрута"))
+		return
+	}
+
+	if purge {
+		audit.SetSummary(c, "окончательное удаление")
+		h.logger.Info("Маршрут окончательно удален")
+		c.JSON(http.StatusOK, gin.H{"message": "Маршрут окончательно удален"})
 		return
 	}
 
@@ -221,6 +708,95 @@ func (h *RouteHandler) DeleteRoute(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Маршрут успешно удален"})
 }
 
+// BulkRoutes выполняет массовую операцию над маршрутами
+func (h *RouteHandler) BulkRoutes(c *gin.Context) {
+	h.logger.Info("Получен запрос на массовую операцию над маршрутами")
+
+	var req service.BulkRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+	req.Scope = auth.RouteScope(c)
+
+	result, err := h.routeService.BulkRoutes(req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка массовой операции, изменения отменены"))
+		return
+	}
+	audit.SetSummary(c, "операция %s, выполнено %d из %d", result.Operation, result.Succeeded, result.Total)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CloneRoute создает копию маршрута
+func (h *RouteHandler) CloneRoute(c *gin.Context) {
+	routeID := c.Param("id")
+	h.logger.Infof("Получен запрос на копирование маршрута с ID: %s", routeID)
+
+	route, err := h.routeService.CloneRoute(routeID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка копирования маршрута"))
+		return
+	}
+	audit.SetSummary(c, "создана копия %s", route.ID)
+
+	c.JSON(http.StatusCreated, route)
+}
+
+// SplitRoute делит маршрут на два по номеру сегмента
+func (h *RouteHandler) SplitRoute(c *gin.Context) {
+	routeID := c.Param("id")
+	h.logger.Infof("Получен запрос на разделение маршрута с ID: %s", routeID)
+
+	atSegment, err := strconv.Atoi(c.Query("at_segment"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Параметр at_segment обязателен и должен быть целым числом"))
+		return
+	}
+
+	result, err := h.routeService.SplitRoute(routeID, atSegment)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSplit) {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Нельзя разделить маршрут по сегменту "+strconv.Itoa(atSegment)+": одна из частей будет пустой"))
+			return
+		}
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка разделения маршрута"))
+		return
+	}
+	audit.SetSummary(c, "по сегменту %d, создан маршрут %s", atSegment, result.Created.ID)
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// RestoreRoute восстанавливает мягко удаленный маршрут
+func (h *RouteHandler) RestoreRoute(c *gin.Context) {
+	routeID := c.Param("id")
+	h.logger.Infof("Получен запрос на восстановление маршрута с ID: %s", routeID)
+
+	route, err := h.routeService.RestoreRoute(routeID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка восстановления маршрута"))
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
+// UnarchiveRoute возвращает маршрут из архива
+func (h *RouteHandler) UnarchiveRoute(c *gin.Context) {
+	routeID := c.Param("id")
+	h.logger.Infof("Получен запрос на возврат маршрута из архива с ID: %s", routeID)
+
+	route, err := h.routeService.UnarchiveRoute(routeID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка возврата маршрута из архива"))
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
 // GetRoutesByArea возвращает маршруты в указанной области
 func (h *RouteHandler) GetRoutesByArea(c *gin.Context) {
 	h.logger.Info("Получен запрос на получение маршрутов по области")
@@ -233,95 +809,467 @@ func (h *RouteHandler) GetRoutesByArea(c *gin.Context) {
 
 	if neLat == "" || neLon == "" || swLat == "" || swLon == "" {
 		h.logger.Error("Отсутствуют параметры области")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Отсутствуют обязательные параметры: ne_lat, ne_lon, sw_lat, sw_lon",
-		})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidArea, "Отсутствуют обязательные параметры: ne_lat, ne_lon, sw_lat, sw_lon"))
 		return
 	}
 
 	// Парсим координаты
 	neLatFloat, err := strconv.ParseFloat(neLat, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ne_lat"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат ne_lat"))
 		return
 	}
 
 	neLonFloat, err := strconv.ParseFloat(neLon, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ne_lon"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат ne_lon"))
 		return
 	}
 
 	swLatFloat, err := strconv.ParseFloat(swLat, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат sw_lat"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат sw_lat"))
 		return
 	}
 
 	swLonFloat, err := strconv.ParseFloat(swLon, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат sw_lon"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат sw_lon"))
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	size, err := strconv.Atoi(c.DefaultQuery("size", "100"))
+	if err != nil || size < 1 || size > 500 {
+		size = 100
+	}
+
+	defects, ok := parseDefectFilter(c)
+	if !ok {
+		return
+	}
+	markingType, ok := parseMarkingType(c)
+	if !ok {
+		return
+	}
+
+	selection, ok := parseFieldSelection(c)
+	if !ok {
 		return
 	}
 
-	// Получаем маршруты в области
-	routes, err := h.routeService.GetRoutesByArea(neLatFloat, neLonFloat, swLatFloat, swLonFloat)
+	// Сегменты не загружаются, если клиент исключил их из ответа
+	routes, total, err := h.routeService.GetRoutesByArea(neLatFloat, neLonFloat, swLatFloat, swLonFloat,
+		selection.wants("segments"), defects, markingType, auth.RouteScope(c), page, size)
 	if err != nil {
-		h.logger.Errorf("Ошибка получения маршрутов по области: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения маршрутов"})
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрутов"))
 		return
 	}
 
 	response := service.GetSegmentsByAreaResponse{
 		Routes: routes,
-		Total:  len(routes),
+		Total:  total,
+		Page:   page,
+		Size:   size,
+	}
+
+	h.logger.Infof("Возвращено %d маршрутов из %d в указанной области", len(routes), total)
+
+	shaped, err := selection.shape(response, "routes")
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрутов"))
+		return
+	}
+
+	// Набор маршрутов области меняется при добавлении и удалении,
+	// поэтому ETag считается по содержимому ответа
+	body, err := json.Marshal(shaped)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрутов"))
+		return
+	}
+	var lastModified time.Time
+	for _, route := range routes {
+		if route.UpdatedAt.After(lastModified) {
+			lastModified = route.UpdatedAt
+		}
+	}
+	if checkNotModified(c, etagOf(body), lastModified) {
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// GetRoutesNear возвращает маршруты в радиусе от точки
+func (h *RouteHandler) GetRoutesNear(c *gin.Context) {
+	h.logger.Info("Получен запрос на получение маршрутов рядом с точкой")
+
+	lat, lon, ok := parsePoint(c)
+	if !ok {
+		return
+	}
+
+	radius, err := strconv.ParseFloat(c.DefaultQuery("radius_m", "500"), 64)
+	if err != nil || radius <= 0 || radius > 50000 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный радиус radius_m (от 0 до 50000 метров)"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	selection, ok := parseFieldSelection(c)
+	if !ok {
+		return
+	}
+
+	routes, err := h.routeService.GetRoutesNear(lat, lon, radius, limit, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрутов"))
+		return
+	}
+
+	h.respondShaped(c, selection, service.GetRoutesNearResponse{
+		Center:  service.Coordinates{Lat: lat, Lon: lon},
+		RadiusM: radius,
+		Routes:  routes,
+		Total:   len(routes),
+	}, "routes")
+}
+
+// ListRouteSegments возвращает страницу сегментов маршрута с фильтрами по покрытию и сортировкой
+func (h *RouteHandler) ListRouteSegments(c *gin.Context) {
+	routeID := c.Param("id")
+	h.logger.Infof("Получен запрос на получение сегментов маршрута %s", routeID)
+
+	query, ok := parseSegmentQuery(c)
+	if !ok {
+		return
+	}
+	query.Scope = auth.RouteScope(c)
+
+	segments, err := h.routeService.ListSegments(routeID, query)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения сегментов маршрута"))
+		return
+	}
+
+	c.JSON(http.StatusOK, segments)
+}
+
+// GetRouteSegment возвращает один сегмент маршрута по номеру
+func (h *RouteHandler) GetRouteSegment(c *gin.Context) {
+	routeID := c.Param("id")
+
+	segmentID, err := strconv.Atoi(c.Param("segmentId"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный номер сегмента"))
+		return
+	}
+
+	segment, err := h.routeService.GetSegment(routeID, segmentID, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения сегмента"))
+		return
+	}
+
+	c.JSON(http.StatusOK, segment)
+}
+
+// parseSegmentQuery разбирает параметры выборки сегментов. При ошибке
+// отправляет 400 и возвращает false.
+func parseSegmentQuery(c *gin.Context) (repository.SegmentQuery, bool) {
+	query := repository.SegmentQuery{Page: 1, PageSize: 50, SortBy: repository.SegmentSortID}
+
+	if page, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && page >= 1 {
+		query.Page = page
+	}
+	if size, err := strconv.Atoi(c.DefaultQuery("size", "50")); err == nil && size >= 1 && size <= 500 {
+		query.PageSize = size
+	}
+
+	for param, target := range map[string]**float64{
+		"coverage_lt": &query.CoverageLT,
+		"coverage_gt": &query.CoverageGT,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение покрытия "+param))
+			return query, false
+		}
+		*target = &value
+	}
+
+	if raw := c.Query("has_data"); raw != "" {
+		hasData, err := strconv.ParseBool(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение has_data (true или false)"))
+			return query, false
+		}
+		query.HasData = &hasData
+	}
+
+	markingType, ok := parseMarkingType(c)
+	if !ok {
+		return query, false
+	}
+	query.MarkingType = markingType
+
+	if raw := c.Query("low_confidence"); raw != "" {
+		lowConfidence, err := strconv.ParseBool(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение low_confidence (true или false)"))
+			return query, false
+		}
+		query.LowConfidence = &lowConfidence
+	}
+
+	switch sortBy := c.DefaultQuery("sort", repository.SegmentSortID); sortBy {
+	case repository.SegmentSortID, repository.SegmentSortCoverage, repository.SegmentSortFrames, repository.SegmentSortQuality:
+		query.SortBy = sortBy
+	default:
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное поле сортировки (segment_id, coverage, frames_count или quality)"))
+		return query, false
+	}
+
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный порядок сортировки (asc или desc)"))
+		return query, false
+	}
+
+	return query, true
+}
+
+// GetRouteOverlaps возвращает маршруты, проходящие по тому же коридору,
+// с парами совпадающих сегментов
+func (h *RouteHandler) GetRouteOverlaps(c *gin.Context) {
+	routeID := c.Param("id")
+	h.logger.Infof("Получен запрос на поиск пересечений маршрута %s", routeID)
+
+	distance, err := strconv.ParseFloat(c.DefaultQuery("distance_m", "20"), 64)
+	if err != nil || distance <= 0 || distance > 500 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная ширина коридора distance_m (от 0 до 500 метров)"))
+		return
+	}
+
+	minOverlap, err := strconv.ParseFloat(c.DefaultQuery("min_overlap", "0.3"), 64)
+	if err != nil || minOverlap < 0 || minOverlap > 1 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная минимальная доля пересечения min_overlap (от 0 до 1)"))
+		return
+	}
+
+	overlaps, err := h.routeService.FindOverlaps(routeID, distance, minOverlap, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка поиска пересечений маршрута"))
+		return
 	}
 
-	h.logger.Infof("Найдено %d маршрутов в указанной области", len(routes))
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, overlaps)
 }
 
-// CheckHealth проверяет состояние сервиса
-func (h *RouteHandler) CheckHealth(c *gin.Context) {
-	h.logger.Info("Получен запрос проверки здоровья сервиса")
+// GetRouteDiff сравнивает покрытие маршрута с другим анализом того же
+// коридора: маршрутом against или предыдущим анализом (against=previous)
+func (h *RouteHandler) GetRouteDiff(c *gin.Context) {
+	routeID := c.Param("id")
+	h.logger.Infof("Получен запрос на сравнение маршрута %s", routeID)
+
+	against := c.Query("against")
+	if against == "" {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Укажите against: ID маршрута или previous"))
+		return
+	}
+
+	distance, err := strconv.ParseFloat(c.DefaultQuery("distance_m", "20"), 64)
+	if err != nil || distance <= 0 || distance > 500 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная ширина коридора distance_m (от 0 до 500 метров)"))
+		return
+	}
+
+	minOverlap, err := strconv.ParseFloat(c.DefaultQuery("min_overlap", "0.3"), 64)
+	if err != nil || minOverlap < 0 || minOverlap > 1 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверная минимальная доля пересечения min_overlap (от 0 до 1)"))
+		return
+	}
+
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "5"), 64)
+	if err != nil || threshold < 0 || threshold > 100 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный порог изменения threshold (от 0 до 100 процентных пунктов)"))
+		return
+	}
 
-	// Проверяем состояние анализатора
-	err := h.analyzerService.CheckHealth()
+	diff, err := h.routeService.DiffRoutes(routeID, against, distance, minOverlap, threshold, auth.RouteScope(c))
 	if err != nil {
-		h.logger.Errorf("Сервис анализа недоступен: %v", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status": "unhealthy",
-			"error":  "Сервис анализа недоступен",
-		})
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка сравнения маршрутов"))
 		return
 	}
 
-	h.logger.Info("Сервис работает нормально")
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"message": "Сервис работает нормально",
-	})
+	c.JSON(http.StatusOK, diff)
+}
+
+// GetNearestRoute возвращает маршрут, ближайший к точке
+func (h *RouteHandler) GetNearestRoute(c *gin.Context) {
+	h.logger.Info("Получен запрос на получение ближайшего маршрута")
+
+	lat, lon, ok := parsePoint(c)
+	if !ok {
+		return
+	}
+
+	selection, ok := parseFieldSelection(c)
+	if !ok {
+		return
+	}
+
+	route, err := h.routeService.GetNearestRoute(lat, lon, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения ближайшего маршрута"))
+		return
+	}
+
+	h.respondShaped(c, selection, route, "")
+}
+
+// SearchRoutesByPolygon возвращает маршруты и сегменты, пересекающие GeoJSON полигон
+func (h *RouteHandler) SearchRoutesByPolygon(c *gin.Context) {
+	h.logger.Info("Получен запрос на поиск маршрутов по полигону")
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 4<<20))
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Ошибка чтения тела запроса"))
+		return
+	}
+
+	polygon, err := geo.ParseGeoJSONPolygon(body)
+	if err != nil {
+		h.logger.Warnf("Неверный полигон: %v", err)
+		apierror.Abort(c, err)
+		return
+	}
+
+	result, err := h.routeService.SearchByPolygon(polygon, auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка поиска маршрутов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parsePoint разбирает параметры lat и lon запроса, при ошибке отвечает 400
+func parsePoint(c *gin.Context) (lat, lon float64, ok bool) {
+	latStr := c.Query("lat")
+	lonStr := c.Query("lon")
+	if latStr == "" || lonStr == "" {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Отсутствуют обязательные параметры: lat, lon"))
+		return 0, 0, false
+	}
+
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат lat (от -90 до 90)"))
+		return 0, 0, false
+	}
+
+	lon, err = strconv.ParseFloat(lonStr, 64)
+	if err != nil || lon < -180 || lon > 180 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidCoordinates, "Неверный формат lon (от -180 до 180)"))
+		return 0, 0, false
+	}
+
+	return lat, lon, true
 }
 
 // GetRouteVideo возвращает видео для конкретного маршрута
 func (h *RouteHandler) GetRouteVideo(c *gin.Context) {
 	routeID := c.Param("id")
 	if routeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "route ID is required"})
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Не указан ID маршрута"))
 		return
 	}
 
-	route, err := h.routeService.GetRouteByID(routeID)
+	route, err := h.routeService.GetRouteByID(routeID, auth.RouteScope(c))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрута"))
 		return
 	}
 
 	if route.VideoPath == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "video not found for this route"})
+		apierror.Abort(c, apierror.New(apierror.CodeVideoNotFound, "Видео маршрута не найдено"))
+		return
+	}
+
+	// Отправляем видео для браузеров, если оно есть и не запрошено исходное
+	if c.Query("original") == "true" {
+		c.File(route.VideoPath)
+		return
+	}
+	c.File(playableVideoPath(route))
+}
+
+// GetRouteVideoHLS отдает плейлист или сегмент HLS видео маршрута. Первый
+// запрос ждет, пока видео нарезается на сегменты.
+func (h *RouteHandler) GetRouteVideoHLS(c *gin.Context) {
+	file := c.Param("file")
+	path, err := h.routeService.RouteVideoHLS(c.Request.Context(), c.Param("id"), file)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения видео маршрута"))
+		return
+	}
+
+	if file == hls.PlaylistName {
+		c.Header("Content-Type", "application/vnd.apple.mpegurl")
+	} else {
+		c.Header("Content-Type", "video/mp2t")
+	}
+	c.File(path)
+}
+
+// GetRouteAnnotatedVideo отдает видео маршрута с разметкой, нанесенной
+// Python сервисом
+func (h *RouteHandler) GetRouteAnnotatedVideo(c *gin.Context) {
+	path, err := h.routeService.RouteAnnotatedVideo(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения видео маршрута"))
+		return
+	}
+
+	c.File(path)
+}
+
+// playableVideoPath возвращает видео маршрута, которое воспроизводится в
+// браузерах, а пока оно не готово — исходное видео
+func playableVideoPath(route *service.RouteResponse) string {
+	if route.PlayableVideoPath != "" {
+		return route.PlayableVideoPath
+	}
+	return route.VideoPath
+}
+
+// GetRouteReport отдает отчет маршрута в PDF для приложения к заявке на
+// ремонт разметки
+func (h *RouteHandler) GetRouteReport(c *gin.Context) {
+	routeID := c.Param("id")
+
+	pdf, err := h.routeService.RouteReportPDF(c.Request.Context(), routeID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка создания отчета маршрута"))
 		return
 	}
 
-	// Отправляем видео файл
-	c.File(route.VideoPath)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "route-"+routeID+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", pdf)
 }
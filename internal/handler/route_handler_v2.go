@@ -0,0 +1,412 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// APIv2Prefix начало путей API v2. Ошибки этих путей отправляются в формате
+// RFC 7807.
+const APIv2Prefix = "/api/v2/"
+
+// Поля формы анализа API v2
+var (
+	analysisFormFields = []string{
+		"segment_length", "route_id", "name", "description", "tags",
+		"frame_sample_rate", "confidence_threshold", "model_variant", "roi", "timeout_seconds", "overwrite",
+	}
+	videoFormFields = append([]string{"start_lat", "start_lon", "end_lat", "end_lon"}, analysisFormFields...)
+)
+
+// Параметры запроса чтения маршрутов API v2
+var (
+	fieldsQuery     = []string{"fields", "exclude"}
+	defectQuery     = []string{"has_defects", "defect_type", "marking_type"}
+	listRoutesQuery = joinNames([]string{
+		"page", "size", "cursor", "sort", "order", "include", "name", "road", "tag",
+		"created_after", "created_before", "deleted", "archived",
+	}, defectQuery, fieldsQuery)
+	areaQuery     = joinNames([]string{"ne_lat", "ne_lon", "sw_lat", "sw_lon", "page", "size"}, defectQuery, fieldsQuery)
+	nearQuery     = joinNames([]string{"lat", "lon", "radius_m", "limit"}, fieldsQuery)
+	segmentsQuery = []string{"page", "size", "has_data", "low_confidence", "sort", "order"}
+)
+
+// RouteHandlerV2 обрабатывает запросы анализа и маршрутов API v2. Поля
+// форм, параметры запроса и поля JSON называются только в snake_case,
+// неизвестные и повторенные поля отклоняются с перечнем в invalid_params.
+// Анализ отвечает сохраненным маршрутом в том же виде, что и
+// GET /api/v2/routes/:id. Чтение маршрутов выполняют обработчики v1, ответы
+// которых уже следуют этому соглашению.
+type RouteHandlerV2 struct {
+	v1              *RouteHandler
+	analyzerService *service.AnalyzerService
+	routeService    *service.RouteService
+	logger          *logrus.Logger
+}
+
+// NewRouteHandlerV2 создает новый экземпляр RouteHandlerV2
+func NewRouteHandlerV2(analyzerService *service.AnalyzerService, routeService *service.RouteService, logger *logrus.Logger) *RouteHandlerV2 {
+	return &RouteHandlerV2{
+		v1:              NewRouteHandler(analyzerService, routeService, logger),
+		analyzerService: analyzerService,
+		routeService:    routeService,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты API v2
+func (h *RouteHandlerV2) RegisterRoutes(router *gin.Engine) {
+	access := requireRouteAccess(h.routeService)
+	api := router.Group(strings.TrimSuffix(APIv2Prefix, "/"))
+	{
+		api.POST("/analyze", strictQuery(), h.AnalyzeRoadMarking)
+		api.POST("/analyze/images", strictQuery(), h.AnalyzeImages)
+		api.GET("/routes", strictQuery(listRoutesQuery...), h.v1.ListRoutes)
+		api.GET("/routes/area", strictQuery(areaQuery...), h.v1.GetRoutesByArea)
+		api.GET("/routes/near", strictQuery(nearQuery...), h.v1.GetRoutesNear)
+		api.GET("/routes/:id", strictQuery(fieldsQuery...), access, h.v1.GetRoute)
+		api.PATCH("/routes/:id", strictQuery(), access, h.UpdateRoute)
+		api.DELETE("/routes/:id", strictQuery("purge"), access, h.DeleteRoute)
+		api.GET("/routes/:id/segments", strictQuery(segmentsQuery...), access, h.v1.ListRouteSegments)
+		api.GET("/routes/:id/segments/:segmentId", strictQuery(), access, h.v1.GetRouteSegment)
+		api.GET("/routes/:id/video", strictQuery("original"), access, h.v1.GetRouteVideo)
+	}
+}
+
+// AnalyzeRoadMarking анализирует видео проезда и отвечает сохраненным маршрутом
+func (h *RouteHandlerV2) AnalyzeRoadMarking(c *gin.Context) {
+	h.logger.Info("Получен запрос v2 на анализ дорожной разметки")
+
+	form, ok := parseStrictForm(c, videoFormFields, "video")
+	if !ok {
+		return
+	}
+	startLat := form.float("start_lat", validLat)
+	startLon := form.float("start_lon", validLon)
+	endLat := form.float("end_lat", validLat)
+	endLon := form.float("end_lon", validLon)
+	segmentLength := form.float("segment_length", positive)
+	if !form.valid(c) {
+		return
+	}
+
+	metadata, err := parseAnalysisMetadata(c)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	data, filename, ok := readFormFile(c, "video")
+	if !ok {
+		return
+	}
+
+	routeID := h.routeID(c)
+	audit.SetSummary(c, "видео %s, %d байт", filename, len(data))
+
+	_, err = h.analyzerService.AnalyzeRoadMarking(startLat, startLon, endLat, endLon,
+		segmentLength, bytes.NewReader(data), filename, routeID, metadata)
+	if err != nil {
+		abortAnalysis(c, err)
+		return
+	}
+	h.respondCreated(c, routeID)
+}
+
+// AnalyzeImages анализирует последовательность снимков из ZIP архива и
+// отвечает сохраненным маршрутом
+func (h *RouteHandlerV2) AnalyzeImages(c *gin.Context) {
+	h.logger.Info("Получен запрос v2 на анализ последовательности снимков")
+
+	form, ok := parseStrictForm(c, analysisFormFields, "images")
+	if !ok {
+		return
+	}
+	segmentLength := form.float("segment_length", positive)
+	if !form.valid(c) {
+		return
+	}
+
+	metadata, err := parseAnalysisMetadata(c)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	archive, filename, ok := readFormFile(c, "images")
+	if !ok {
+		return
+	}
+
+	routeID := h.routeID(c)
+	audit.SetSummary(c, "снимки %s, %d байт", filename, len(archive))
+
+	if _, err := h.analyzerService.AnalyzeImageSequence(archive, filename, segmentLength, routeID, metadata); err != nil {
+		abortAnalysis(c, err)
+		return
+	}
+	h.respondCreated(c, routeID)
+}
+
+// routeID возвращает ID маршрута из формы или новый и записывает его в журнал аудита
+func (h *RouteHandlerV2) routeID(c *gin.Context) string {
+	routeID := strings.TrimSpace(c.PostForm("route_id"))
+	if routeID == "" {
+		routeID = h.routeService.GenerateRouteID()
+	}
+	audit.SetRouteID(c, routeID)
+	return routeID
+}
+
+// respondCreated отвечает сохраненным маршрутом. Анализ не возвращает
+// ошибку, если маршрут не удалось сохранить, поэтому маршрут загружается
+// заново.
+func (h *RouteHandlerV2) respondCreated(c *gin.Context, routeID string) {
+	route, err := h.routeService.GetRouteByID(routeID, auth.RouteScope(c))
+	if err != nil {
+		// Ошибка не должна превратиться в ROUTE_NOT_FOUND: анализ выполнен
+		apierror.Abort(c, apierror.Wrap(fmt.Errorf("route %s is not saved after analysis: %v", routeID, err),
+			apierror.CodeInternal, "Анализ выполнен, но маршрут не сохранен"))
+		return
+	}
+	h.logger.Infof("Анализ завершен, маршрут %s сохранен", routeID)
+	c.Header("Location", APIv2Prefix+"routes/"+routeID)
+	c.JSON(http.StatusCreated, route)
+}
+
+// UpdateRoute частично обновляет название, описание и пользовательские
+// поля маршрута. Неизвестные поля тела отклоняются.
+func (h *RouteHandlerV2) UpdateRoute(c *gin.Context) {
+	var req service.UpdateRouteRequest
+	if err := bindStrictJSON(c, &req); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	route, err := h.routeService.UpdateRouteMetadata(c.Param("id"), req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка обновления маршрута"))
+		return
+	}
+	audit.SetSummary(c, "изменены поля: %s", strings.Join(updatedFields(req), ", "))
+
+	c.JSON(http.StatusOK, route)
+}
+
+// DeleteRoute удаляет маршрут и отвечает 204. С ?purge=true маршрут и его
+// файлы удаляются безвозвратно.
+func (h *RouteHandlerV2) DeleteRoute(c *gin.Context) {
+	routeID := c.Param("id")
+	purge, err := strconv.ParseBool(c.DefaultQuery("purge", "false"))
+	if err != nil {
+		apierror.Abort(c, apierror.Invalid("Неверные параметры запроса", apierror.InvalidParam{Name: "purge", Reason: "ожидается true или false"}))
+		return
+	}
+
+	if purge {
+		err = h.routeService.PurgeRoute(routeID)
+		audit.SetSummary(c, "окончательное удаление")
+	} else {
+		err = h.routeService.DeleteRoute(routeID)
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка удаления маршрута"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// strictQuery отклоняет запрос с параметрами, которых нет в allowed
+func strictQuery(allowed ...string) gin.HandlerFunc {
+	known := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		known[name] = true
+	}
+	return func(c *gin.Context) {
+		var invalid []apierror.InvalidParam
+		for name := range c.Request.URL.Query() {
+			if !known[name] {
+				invalid = append(invalid, apierror.InvalidParam{Name: name, Reason: "неизвестный параметр"})
+			}
+		}
+		if len(invalid) > 0 {
+			sortInvalid(invalid)
+			apierror.Abort(c, apierror.Invalid("Неизвестные параметры запроса", invalid...))
+			return
+		}
+		c.Next()
+	}
+}
+
+// strictForm форма multipart API v2 с накоплением ошибок проверки полей
+type strictForm struct {
+	c       *gin.Context
+	invalid []apierror.InvalidParam
+}
+
+// parseStrictForm разбирает форму multipart и проверяет, что в ней нет
+// неизвестных полей и файлов, а поля, кроме tags, переданы не больше раза
+func parseStrictForm(c *gin.Context, fields []string, file string) (*strictForm, bool) {
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+		reason := "ожидается multipart/form-data"
+		if !errors.Is(err, http.ErrNotMultipart) {
+			reason = "форма не читается"
+		}
+		apierror.Abort(c, apierror.Invalid("Неверное тело запроса", apierror.InvalidParam{Name: "body", Reason: reason}))
+		return nil, false
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, name := range fields {
+		known[name] = true
+	}
+	form := &strictForm{c: c}
+	for name, values := range c.Request.MultipartForm.Value {
+		switch {
+		case !known[name]:
+			form.fail(name, "неизвестное поле")
+		case len(values) > 1 && name != "tags":
+			form.fail(name, "поле передано несколько раз")
+		}
+	}
+	for name, files := range c.Request.MultipartForm.File {
+		switch {
+		case name != file:
+			form.fail(name, "неизвестный файл")
+		case len(files) > 1:
+			form.fail(name, "файл передан несколько раз")
+		}
+	}
+	if len(c.Request.MultipartForm.File[file]) == 0 {
+		form.fail(file, "обязательный файл")
+	}
+	return form, true
+}
+
+// fail добавляет ошибку поля
+func (f *strictForm) fail(name, reason string) {
+	f.invalid = append(f.invalid, apierror.InvalidParam{Name: name, Reason: reason})
+}
+
+// float читает обязательное числовое поле и проверяет его
+func (f *strictForm) float(name string, check func(float64) string) float64 {
+	raw := strings.TrimSpace(f.c.PostForm(name))
+	if raw == "" {
+		f.fail(name, "обязательное поле")
+		return 0
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		f.fail(name, "ожидается число")
+		return 0
+	}
+	if reason := check(value); reason != "" {
+		f.fail(name, reason)
+	}
+	return value
+}
+
+// valid отвечает 400 с перечнем ошибок, если они есть
+func (f *strictForm) valid(c *gin.Context) bool {
+	if len(f.invalid) == 0 {
+		return true
+	}
+	sortInvalid(f.invalid)
+	apierror.Abort(c, apierror.Invalid("Неверные поля формы", f.invalid...))
+	return false
+}
+
+// validLat проверяет широту; проверки числовых полей возвращают причину
+// ошибки или пустую строку
+func validLat(value float64) string {
+	if value < -90 || value > 90 {
+		return "ожидается широта от -90 до 90"
+	}
+	return ""
+}
+
+// validLon проверяет долготу
+func validLon(value float64) string {
+	if value < -180 || value > 180 {
+		return "ожидается долгота от -180 до 180"
+	}
+	return ""
+}
+
+// positive проверяет, что значение положительно
+func positive(value float64) string {
+	if value <= 0 {
+		return "ожидается положительное число"
+	}
+	return ""
+}
+
+// readFormFile читает файл формы
+func readFormFile(c *gin.Context, name string) ([]byte, string, bool) {
+	file, header, err := c.Request.FormFile(name)
+	if err != nil {
+		apierror.Abort(c, apierror.Invalid("Неверные поля формы", apierror.InvalidParam{Name: name, Reason: "обязательный файл"}))
+		return nil, "", false
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInvalidRequest, "Ошибка чтения файла "+name))
+		return nil, "", false
+	}
+	return data, header.Filename, true
+}
+
+// bindStrictJSON разбирает тело JSON, отклоняя неизвестные поля, поля
+// неверного типа и данные после значения
+func bindStrictJSON(c *gin.Context, target interface{}) error {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			name, _ := strconv.Unquote(field)
+			return apierror.Invalid("Неверные поля тела запроса", apierror.InvalidParam{Name: name, Reason: "неизвестное поле"})
+		}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return apierror.Invalid("Неверные поля тела запроса", apierror.InvalidParam{Name: typeErr.Field, Reason: "ожидается " + typeErr.Type.String()})
+		}
+		return apierror.Invalid("Неверное тело запроса", apierror.InvalidParam{Name: "body", Reason: "ожидается объект JSON"})
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return apierror.Invalid("Неверное тело запроса", apierror.InvalidParam{Name: "body", Reason: "после объекта JSON есть данные"})
+	}
+	return nil
+}
+
+// sortInvalid упорядочивает ошибки по имени, чтобы ответ не зависел от
+// порядка обхода map
+func sortInvalid(invalid []apierror.InvalidParam) {
+	sort.Slice(invalid, func(i, j int) bool { return invalid[i].Name < invalid[j].Name })
+}
+
+// joinNames объединяет списки имен
+func joinNames(groups ...[]string) []string {
+	var names []string
+	for _, group := range groups {
+		names = append(names, group...)
+	}
+	return names
+}
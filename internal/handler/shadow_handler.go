@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ShadowHandler обрабатывает запросы сравнения основного и теневых анализов
+type ShadowHandler struct {
+	shadowService *service.ShadowService
+	routeService  *service.RouteService
+	logger        *logrus.Logger
+}
+
+// NewShadowHandler создает новый экземпляр ShadowHandler
+func NewShadowHandler(shadowService *service.ShadowService, routeService *service.RouteService, logger *logrus.Logger) *ShadowHandler {
+	return &ShadowHandler{
+		shadowService: shadowService,
+		routeService:  routeService,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршрут сравнения анализов
+func (h *ShadowHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/api/v1/routes/:id/compare-analyses", requireRouteAccess(h.routeService), h.CompareAnalyses)
+}
+
+// CompareAnalyses сравнивает основной анализ маршрута с теневыми. Порог
+// расхождения покрытия сегмента задается параметром threshold, по
+// умолчанию 5 процентных пунктов.
+func (h *ShadowHandler) CompareAnalyses(c *gin.Context) {
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "5"), 64)
+	if err != nil || threshold < 0 || math.IsNaN(threshold) || math.IsInf(threshold, 0) {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Параметр threshold должен быть неотрицательным числом"))
+		return
+	}
+
+	comparison, err := h.shadowService.Compare(c.Param("id"), threshold)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка сравнения анализов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// geoJSONContentType тип содержимого выгрузки маршрута для карт
+const geoJSONContentType = "application/geo+json"
+
+// ShareHandler обрабатывает запросы к публичным ссылкам на маршруты
+type ShareHandler struct {
+	shareService *service.ShareService
+	routeService *service.RouteService
+	logger       *logrus.Logger
+}
+
+// NewShareHandler создает новый экземпляр ShareHandler
+func NewShareHandler(shareService *service.ShareService, routeService *service.RouteService, logger *logrus.Logger) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+		routeService: routeService,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты ссылок. Ссылками управляют те, у кого
+// есть доступ к маршруту, а пути /api/v1/shared открыты всем, кто знает токен.
+func (h *ShareHandler) RegisterRoutes(router *gin.Engine) {
+	access := requireRouteAccess(h.routeService)
+	routes := router.Group("/api/v1/routes")
+	{
+		routes.GET("/:id/geojson", access, h.GetRouteGeoJSON)
+		routes.POST("/:id/share", access, h.CreateShareLink)
+		routes.GET("/:id/share", access, h.ListShareLinks)
+		routes.DELETE("/:id/share/:shareId", access, h.RevokeShareLink)
+	}
+
+	shared := router.Group("/api/v1/shared")
+	{
+		shared.GET("/:token", h.GetSharedRoute)
+		shared.GET("/:token/video", h.GetSharedVideo)
+		shared.GET("/:token/geojson", h.GetSharedGeoJSON)
+	}
+}
+
+// GetRouteGeoJSON выгружает маршрут для карт в формате GeoJSON
+func (h *ShareHandler) GetRouteGeoJSON(c *gin.Context) {
+	route, err := h.routeService.GetRouteByID(c.Param("id"), auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрута"))
+		return
+	}
+
+	c.Header("Content-Type", geoJSONContentType)
+	c.JSON(http.StatusOK, service.RouteGeoJSON(route))
+}
+
+// CreateShareLink создает ссылку на маршрут. Токен возвращается только в этом ответе.
+func (h *ShareHandler) CreateShareLink(c *gin.Context) {
+	var req service.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	creator := service.ShareLinkCreator{UserID: auth.UserID(c), APIKeyID: auth.APIKeyID(c)}
+	link, err := h.shareService.CreateShareLink(c.Param("id"), req, creator)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка создания ссылки"))
+		return
+	}
+	audit.SetTarget(c, "share_link", link.ID)
+	if link.ExpiresAt != nil {
+		audit.SetSummary(c, "ссылка %s до %s", link.Prefix, link.ExpiresAt.UTC().Format("2006-01-02 15:04:05"))
+	} else {
+		audit.SetSummary(c, "ссылка %s без срока действия", link.Prefix)
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// ListShareLinks возвращает ссылки маршрута
+func (h *ShareHandler) ListShareLinks(c *gin.Context) {
+	links, err := h.shareService.ListShareLinks(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка ссылок"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListShareLinksResponse{ShareLinks: links, Total: len(links)})
+}
+
+// RevokeShareLink отзывает ссылку маршрута
+func (h *ShareHandler) RevokeShareLink(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("shareId"), 10, 32)
+	if err != nil || id == 0 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный ID ссылки"))
+		return
+	}
+
+	link, err := h.shareService.RevokeShareLink(c.Param("id"), uint(id))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка отзыва ссылки"))
+		return
+	}
+	audit.SetTarget(c, "share_link", link.ID)
+	audit.SetSummary(c, "ссылка %s", link.Prefix)
+
+	c.JSON(http.StatusOK, link)
+}
+
+// GetSharedRoute возвращает маршрут по токену ссылки без данных о владельцах
+func (h *ShareHandler) GetSharedRoute(c *gin.Context) {
+	route, ok := h.resolve(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, service.SharedRouteView(route))
+}
+
+// GetSharedVideo возвращает видео маршрута по токену ссылки
+func (h *ShareHandler) GetSharedVideo(c *gin.Context) {
+	route, ok := h.resolve(c)
+	if !ok {
+		return
+	}
+
+	if route.VideoPath == "" {
+		apierror.Abort(c, apierror.New(apierror.CodeVideoNotFound, "Видео маршрута не найдено"))
+		return
+	}
+
+	c.File(playableVideoPath(route))
+}
+
+// GetSharedGeoJSON выгружает маршрут для карт по токену ссылки
+func (h *ShareHandler) GetSharedGeoJSON(c *gin.Context) {
+	route, ok := h.resolve(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", geoJSONContentType)
+	c.JSON(http.StatusOK, service.RouteGeoJSON(route))
+}
+
+// resolve находит маршрут по токену ссылки из пути запроса
+func (h *ShareHandler) resolve(c *gin.Context) (*service.RouteResponse, bool) {
+	route, err := h.shareService.ResolveShareLink(c.Param("token"))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения маршрута по ссылке"))
+		return nil, false
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	return route, true
+}
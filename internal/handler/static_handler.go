@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"path"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaticHandler раздает файлы каталога static, например копию Swagger UI.
+// Видео маршрутов хранятся в том же каталоге, но отдаются только через
+// /api/v1/routes/:id/video с проверкой доступа к маршруту.
+type StaticHandler struct {
+	dir string
+}
+
+// NewStaticHandler создает новый экземпляр StaticHandler
+func NewStaticHandler(dir string) *StaticHandler {
+	return &StaticHandler{dir: dir}
+}
+
+// RegisterRoutes регистрирует раздачу файлов /static
+func (h *StaticHandler) RegisterRoutes(router *gin.Engine) {
+	static := router.Group("/static", rejectRouteMedia)
+	static.Static("/", h.dir)
+}
+
+// rejectRouteMedia отклоняет запросы к видео маршрутов, их копиям,
+// сегментам HLS и аннотированным видео, как к несуществующим файлам
+func rejectRouteMedia(c *gin.Context) {
+	name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+	if name == "videos" || strings.HasPrefix(name, "videos/") || strings.HasPrefix(name, "annotated_") {
+		apierror.Abort(c, apierror.New(apierror.CodeNotFound, "Ресурс не найден"))
+		return
+	}
+
+	c.Next()
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// TagHandler обрабатывает запросы к меткам маршрутов
+type TagHandler struct {
+	tagService   *service.TagService
+	routeService *service.RouteService
+	logger       *logrus.Logger
+}
+
+// NewTagHandler создает новый экземпляр TagHandler
+func NewTagHandler(tagService *service.TagService, routeService *service.RouteService, logger *logrus.Logger) *TagHandler {
+	return &TagHandler{
+		tagService:   tagService,
+		routeService: routeService,
+		logger:       logger,
+	}
+}
+
+// tagRequest тело запроса создания и переименования метки
+type tagRequest struct {
+	Name string `json:"name"`
+}
+
+// routeTagsRequest тело запроса замены меток маршрута
+type routeTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// RegisterRoutes регистрирует маршруты меток
+func (h *TagHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1")
+	{
+		api.GET("/tags", h.ListTags)
+		api.POST("/tags", requireGlobalScope(), h.CreateTag)
+		api.PATCH("/tags/:id", requireGlobalScope(), h.RenameTag)
+		api.DELETE("/tags/:id", requireGlobalScope(), h.DeleteTag)
+		api.PUT("/routes/:id/tags", requireRouteAccess(h.routeService), h.SetRouteTags)
+	}
+}
+
+// ListTags возвращает все метки с количеством доступных маршрутов
+func (h *TagHandler) ListTags(c *gin.Context) {
+	tags, err := h.tagService.ListTags(auth.RouteScope(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка меток"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListTagsResponse{Tags: tags, Total: len(tags)})
+}
+
+// CreateTag создает метку
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var req tagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	tag, err := h.tagService.CreateTag(req.Name)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка создания метки"))
+		return
+	}
+	audit.SetTarget(c, "tag", tag.ID)
+	audit.SetSummary(c, "метка %q", tag.Name)
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// RenameTag переименовывает метку
+func (h *TagHandler) RenameTag(c *gin.Context) {
+	id, ok := parseTagID(c)
+	if !ok {
+		return
+	}
+
+	var req tagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	tag, err := h.tagService.RenameTag(id, req.Name)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка переименования метки"))
+		return
+	}
+	audit.SetSummary(c, "новое название %q", tag.Name)
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag удаляет метку у всех маршрутов
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	id, ok := parseTagID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.tagService.DeleteTag(id); err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка удаления метки"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Метка удалена"})
+}
+
+// SetRouteTags заменяет метки маршрута
+func (h *TagHandler) SetRouteTags(c *gin.Context) {
+	routeID := c.Param("id")
+
+	var req routeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	result, err := h.tagService.SetRouteTags(routeID, req.Tags)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка изменения меток маршрута"))
+		return
+	}
+	audit.SetSummary(c, "метки: %s", strings.Join(req.Tags, ", "))
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseTagID разбирает ID метки из пути, при ошибке отвечает 400
+func parseTagID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный ID метки"))
+		return 0, false
+	}
+	return uint(id), true
+}
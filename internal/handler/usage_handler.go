@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/ratelimit"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// UsageHandler обрабатывает запросы использования квот
+type UsageHandler struct {
+	usageService *service.UsageService
+	limiter      *ratelimit.Limiter
+	logger       *logrus.Logger
+}
+
+// NewUsageHandler создает новый экземпляр UsageHandler. limiter может быть nil,
+// если ограничение частоты запросов отключено.
+func NewUsageHandler(usageService *service.UsageService, limiter *ratelimit.Limiter, logger *logrus.Logger) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+		limiter:      limiter,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты использования
+func (h *UsageHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/api/v1/usage", h.GetUsage)
+	router.GET("/api/v1/usage/storage", h.GetStorageUsage)
+}
+
+// GetUsage возвращает использование квот организацией, пользователем или
+// ключом API текущего запроса в текущем месяце
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	subject := service.QuotaSubject(auth.OrganizationID(c), auth.UserID(c), auth.APIKeyID(c))
+
+	usage, err := h.usageService.GetUsage(subject)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения использования квот"))
+		return
+	}
+	if h.limiter != nil && h.limiter.Enabled() {
+		usage.RateLimit = &service.RateLimitInfo{RPS: h.limiter.RPS(), Burst: h.limiter.Burst()}
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetStorageUsage возвращает место в хранилище, занятое маршрутами
+// организации, пользователя или ключа API текущего запроса
+func (h *UsageHandler) GetStorageUsage(c *gin.Context) {
+	usage, err := h.usageService.GetStorageUsage(auth.OrganizationID(c), auth.UserID(c), auth.APIKeyID(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения использования хранилища"))
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/audit"
+	"road-detector-go/internal/auth"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookHandler обрабатывает запросы к подпискам на события анализа
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+	logger         *logrus.Logger
+}
+
+// NewWebhookHandler создает новый экземпляр WebhookHandler
+func NewWebhookHandler(webhookService *service.WebhookService, logger *logrus.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты подписок. Подписками организации
+// управляют ее администраторы, подписками на события всех маршрутов —
+// администраторы сервера.
+func (h *WebhookHandler) RegisterRoutes(router *gin.Engine) {
+	webhooks := router.Group("/api/v1/webhooks")
+	{
+		webhooks.GET("", h.ListWebhooks)
+		webhooks.POST("", h.CreateWebhook)
+		webhooks.GET("/:id", h.GetWebhook)
+		webhooks.PATCH("/:id", h.UpdateWebhook)
+		webhooks.DELETE("/:id", h.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", h.ListDeliveries)
+	}
+}
+
+// ListWebhooks возвращает подписки организации запроса
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения списка вебхуков"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListWebhooksResponse{Webhooks: webhooks, Total: len(webhooks)})
+}
+
+// CreateWebhook создает подписку. Ключ подписи возвращается только в этом ответе.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(orgID, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка создания вебхука"))
+		return
+	}
+	audit.SetTarget(c, "webhook", webhook.ID)
+	audit.SetSummary(c, "url %s, события: %v", webhook.URL, webhook.Events)
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// GetWebhook возвращает подписку
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(id, orgID)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения вебхука"))
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook меняет адрес, события или признак активности подписки
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный формат тела запроса"))
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(id, orgID, req)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка изменения вебхука"))
+		return
+	}
+	audit.SetSummary(c, "url %s, события: %v, активен: %t", webhook.URL, webhook.Events, webhook.Active)
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook удаляет подписку вместе с журналом доставок
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(id, orgID); err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка удаления вебхука"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries возвращает последние доставки событий подписке
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	orgID, ok := organizationAdminScope(c)
+	if !ok {
+		return
+	}
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверное значение limit"))
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(id, orgID, limit)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, apierror.CodeInternal, "Ошибка получения журнала доставок"))
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListWebhookDeliveriesResponse{Deliveries: deliveries, Total: len(deliveries)})
+}
+
+// organizationAdminScope возвращает организацию, подписками, правилами
+// оповещений и оповещениями которой управляет запрос.
+// Запрос от имени организации требует роли admin в ней, без организации
+// (nil — записи для всех маршрутов) — прав администратора сервера.
+func organizationAdminScope(c *gin.Context) (*uint, bool) {
+	if tenant := auth.CurrentTenant(c); tenant != nil {
+		if tenant.Role != model.OrgRoleAdmin {
+			apierror.Abort(c, apierror.New(apierror.CodeForbidden, "Требуются права администратора организации"))
+			return nil, false
+		}
+		orgID := tenant.OrganizationID
+		return &orgID, true
+	}
+
+	if auth.IsAdmin(c) || (auth.CurrentUser(c) == nil && auth.CurrentAPIKey(c) == nil) {
+		return nil, true
+	}
+	apierror.Abort(c, apierror.New(apierror.CodeForbidden, "Требуются права администратора"))
+	return nil, false
+}
+
+// parseWebhookID разбирает ID подписки из пути, при ошибке отвечает 400
+func parseWebhookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		apierror.Abort(c, apierror.New(apierror.CodeInvalidRequest, "Неверный ID вебхука"))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,109 @@
+// Package hls нарезает видео на сегменты HLS с плейлистом с помощью ffmpeg,
+// чтобы длинные видео можно было смотреть без загрузки всего файла.
+package hls
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/mediatool"
+)
+
+// PlaylistName имя плейлиста в каталоге сегментов
+const PlaylistName = "playlist.m3u8"
+
+// segmentPattern имена сегментов, которые создает Package
+var segmentPattern = regexp.MustCompile(`^segment[0-9]{5}\.ts$`)
+
+// Packager нарезает видео на сегменты HLS
+type Packager interface {
+	// Package записывает в каталог dir плейлист PlaylistName и сегменты
+	// видео input
+	Package(ctx context.Context, input, dir string) error
+}
+
+// Options настройки нарезки
+type Options struct {
+	// FFmpegPath путь к ffmpeg, пусто — ffmpeg из PATH
+	FFmpegPath string
+	// FFprobePath путь к ffprobe, пусто — ffprobe из PATH
+	FFprobePath string
+	// SegmentDuration длительность сегмента
+	SegmentDuration time.Duration
+}
+
+// ffmpegPackager нарезает видео внешними ffmpeg и ffprobe
+type ffmpegPackager struct {
+	opts    Options
+	ffmpeg  string
+	ffprobe string
+}
+
+// New проверяет, что ffmpeg и ffprobe доступны
+func New(opts Options) (Packager, error) {
+	if opts.FFmpegPath == "" {
+		opts.FFmpegPath = "ffmpeg"
+	}
+	if opts.FFprobePath == "" {
+		opts.FFprobePath = "ffprobe"
+	}
+	if opts.SegmentDuration <= 0 {
+		return nil, fmt.Errorf("segment duration must be positive")
+	}
+	ffmpeg, err := exec.LookPath(opts.FFmpegPath)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	ffprobe, err := exec.LookPath(opts.FFprobePath)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe not found: %w", err)
+	}
+	return &ffmpegPackager{opts: opts, ffmpeg: ffmpeg, ffprobe: ffprobe}, nil
+}
+
+// IsSegmentName проверяет, что name — имя сегмента, которое создает Package
+func IsSegmentName(name string) bool {
+	return segmentPattern.MatchString(name)
+}
+
+// Package нарезает видео на сегменты. Видео H.264 не перекодируется,
+// остальное перекодируется в H.264, звук — в AAC.
+func (p *ffmpegPackager) Package(ctx context.Context, input, dir string) error {
+	codec, err := mediatool.Run(ctx, p.ffprobe,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=codec_name",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		input,
+	)
+	if err != nil {
+		return err
+	}
+
+	video := []string{"-c:v", "copy"}
+	if strings.TrimSpace(string(codec)) != "h264" {
+		video = []string{"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-pix_fmt", "yuv420p"}
+	}
+	args := []string{
+		"-hide_banner", "-loglevel", "error", "-nostdin", "-y",
+		"-i", input,
+		"-map", "0:v:0", "-map", "0:a:0?",
+	}
+	args = append(args, video...)
+	args = append(args,
+		"-c:a", "aac", "-b:a", "128k",
+		"-f", "hls",
+		"-hls_time", strconv.FormatFloat(p.opts.SegmentDuration.Seconds(), 'f', -1, 64),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(dir, "segment%05d.ts"),
+		filepath.Join(dir, PlaylistName),
+	)
+	_, err = mediatool.Run(ctx, p.ffmpeg, args...)
+	return err
+}
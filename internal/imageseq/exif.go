@@ -0,0 +1,277 @@
+package imageseq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"road-detector-go/pkg/models"
+)
+
+// Теги EXIF, которые нужны для привязки снимка
+const (
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+	tagGPSTimeStamp     = 0x0007
+	tagGPSDateStamp     = 0x001d
+)
+
+// Типы значений TIFF
+const (
+	typeASCII    = 2
+	typeShort    = 3
+	typeLong     = 4
+	typeRational = 5
+)
+
+// exifMeta место и время съемки из EXIF
+type exifMeta struct {
+	// position nil — в EXIF нет координат
+	position *models.Coordinates
+	// taken время GPS в UTC, а если его нет — время съемки по часам камеры
+	taken time.Time
+}
+
+// readEXIF читает координаты и время съемки из сегмента APP1 EXIF JPEG
+// снимка. Снимок без EXIF не является ошибкой.
+func readEXIF(data []byte) (exifMeta, error) {
+	payload := findEXIF(data)
+	if payload == nil {
+		return exifMeta{}, nil
+	}
+	t, err := newTIFF(payload)
+	if err != nil {
+		return exifMeta{}, err
+	}
+	root, err := t.ifd(t.order.Uint32(payload[4:8]))
+	if err != nil {
+		return exifMeta{}, err
+	}
+
+	var meta exifMeta
+	if entry, ok := root[tagExifIFD]; ok {
+		offset, err := t.offset(entry)
+		if err != nil {
+			return exifMeta{}, err
+		}
+		exif, err := t.ifd(offset)
+		if err != nil {
+			return exifMeta{}, err
+		}
+		if entry, ok := exif[tagDateTimeOriginal]; ok {
+			meta.taken, _ = time.Parse("2006:01:02 15:04:05", t.ascii(entry))
+		}
+	}
+
+	entry, ok := root[tagGPSIFD]
+	if !ok {
+		return meta, nil
+	}
+	offset, err := t.offset(entry)
+	if err != nil {
+		return exifMeta{}, err
+	}
+	gps, err := t.ifd(offset)
+	if err != nil {
+		return exifMeta{}, err
+	}
+	lat, latOK := t.degrees(gps[tagGPSLatitude])
+	lon, lonOK := t.degrees(gps[tagGPSLongitude])
+	if latOK && lonOK {
+		if strings.EqualFold(t.ascii(gps[tagGPSLatitudeRef]), "S") {
+			lat = -lat
+		}
+		if strings.EqualFold(t.ascii(gps[tagGPSLongitudeRef]), "W") {
+			lon = -lon
+		}
+		meta.position = &models.Coordinates{Lat: lat, Lon: lon}
+	}
+	if taken, ok := t.gpsTime(gps); ok {
+		meta.taken = taken
+	}
+	return meta, nil
+}
+
+// findEXIF возвращает содержимое сегмента APP1 EXIF без заголовка,
+// nil — сегмента нет
+func findEXIF(data []byte) []byte {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Байты заполнения перед маркером
+			i++
+			continue
+		case marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// Дальше сжатые данные изображения
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i = end
+	}
+	return nil
+}
+
+// tiff структура TIFF внутри сегмента EXIF
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// ifdEntry запись каталога TIFF
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+func newTIFF(data []byte) (*tiff, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("TIFF header is too short")
+	}
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("unknown TIFF byte order")
+	}
+	if t.order.Uint16(data[2:4]) != 42 {
+		return nil, fmt.Errorf("invalid TIFF header")
+	}
+	return t, nil
+}
+
+// typeSize размер одного значения типа, 0 — тип не нужен для привязки
+func typeSize(typ uint16) int {
+	switch typ {
+	case typeASCII:
+		return 1
+	case typeShort:
+		return 2
+	case typeLong:
+		return 4
+	case typeRational:
+		return 8
+	}
+	return 0
+}
+
+// ifd читает каталог по смещению. Записи неизвестных типов пропускаются.
+func (t *tiff) ifd(offset uint32) (map[uint16]ifdEntry, error) {
+	start := int(offset)
+	if start < 8 || start+2 > len(t.data) {
+		return nil, fmt.Errorf("IFD offset %d is out of range", offset)
+	}
+	count := int(t.order.Uint16(t.data[start:]))
+	if start+2+count*12 > len(t.data) {
+		return nil, fmt.Errorf("IFD at %d is truncated", offset)
+	}
+
+	entries := make(map[uint16]ifdEntry, count)
+	for i := 0; i < count; i++ {
+		raw := t.data[start+2+i*12 : start+2+(i+1)*12]
+		entry := ifdEntry{typ: t.order.Uint16(raw[2:4]), count: t.order.Uint32(raw[4:8])}
+		size := typeSize(entry.typ)
+		if size == 0 || entry.count > uint32(len(t.data)) {
+			continue
+		}
+		total := size * int(entry.count)
+		if total <= 4 {
+			entry.value = raw[8 : 8+total]
+		} else {
+			valueOffset := int(t.order.Uint32(raw[8:12]))
+			if valueOffset < 0 || valueOffset+total > len(t.data) {
+				continue
+			}
+			entry.value = t.data[valueOffset : valueOffset+total]
+		}
+		entries[t.order.Uint16(raw[0:2])] = entry
+	}
+	return entries, nil
+}
+
+// offset читает смещение вложенного каталога
+func (t *tiff) offset(entry ifdEntry) (uint32, error) {
+	switch {
+	case entry.typ == typeLong && len(entry.value) >= 4:
+		return t.order.Uint32(entry.value), nil
+	case entry.typ == typeShort && len(entry.value) >= 2:
+		return uint32(t.order.Uint16(entry.value)), nil
+	}
+	return 0, fmt.Errorf("invalid IFD pointer")
+}
+
+// ascii читает строку без завершающих нулей
+func (t *tiff) ascii(entry ifdEntry) string {
+	if entry.typ != typeASCII {
+		return ""
+	}
+	return strings.TrimRight(string(entry.value), "\x00 ")
+}
+
+// rationals читает дроби
+func (t *tiff) rationals(entry ifdEntry) ([]float64, bool) {
+	if entry.typ != typeRational || len(entry.value) == 0 {
+		return nil, false
+	}
+	values := make([]float64, 0, len(entry.value)/8)
+	for i := 0; i+8 <= len(entry.value); i += 8 {
+		num := t.order.Uint32(entry.value[i:])
+		den := t.order.Uint32(entry.value[i+4:])
+		if den == 0 {
+			return nil, false
+		}
+		values = append(values, float64(num)/float64(den))
+	}
+	return values, true
+}
+
+// degrees переводит градусы, минуты и секунды GPS в градусы
+func (t *tiff) degrees(entry ifdEntry) (float64, bool) {
+	values, ok := t.rationals(entry)
+	if !ok || len(values) < 3 {
+		return 0, false
+	}
+	return values[0] + values[1]/60 + values[2]/3600, true
+}
+
+// gpsTime читает дату и время GPS в UTC
+func (t *tiff) gpsTime(gps map[uint16]ifdEntry) (time.Time, bool) {
+	date, err := time.Parse("2006:01:02", t.ascii(gps[tagGPSDateStamp]))
+	if err != nil {
+		return time.Time{}, false
+	}
+	clock, ok := t.rationals(gps[tagGPSTimeStamp])
+	if !ok || len(clock) < 3 {
+		return time.Time{}, false
+	}
+	offset := time.Duration(clock[0]*float64(time.Hour) + clock[1]*float64(time.Minute) + clock[2]*float64(time.Second))
+	return date.Add(offset), true
+}
@@ -0,0 +1,277 @@
+// Package imageseq готовит к анализу последовательность снимков дороги:
+// читает ZIP архив с JPEG снимками, определяет координаты каждого снимка по
+// EXIF GPS или по таблице CSV в архиве и собирает из снимков видео, в
+// котором каждому снимку соответствует одна секунда, с помощью ffmpeg.
+package imageseq
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/mediatool"
+	"road-detector-go/pkg/models"
+)
+
+// ErrInvalidArchive архив не содержит подходящей последовательности снимков
+var ErrInvalidArchive = errors.New("invalid image archive")
+
+// FrameRate кадров в секунду собранного видео: один кадр на снимок
+const FrameRate = 1
+
+const (
+	// maxImageBytes наибольший размер одного снимка
+	maxImageBytes = 32 << 20
+	// maxArchiveBytes наибольший размер всех снимков архива после распаковки
+	maxArchiveBytes = 1 << 30
+	// frameWidth и frameHeight размер кадра видео. Снимки вписываются в
+	// кадр с сохранением пропорций.
+	frameWidth  = 1280
+	frameHeight = 720
+)
+
+// Options настройки анализа последовательностей снимков
+type Options struct {
+	// FFmpegPath путь к ffmpeg, пусто — ffmpeg из PATH
+	FFmpegPath string
+	// MaxImages наибольшее число снимков в архиве
+	MaxImages int
+}
+
+// Image снимок последовательности
+type Image struct {
+	// Name путь снимка в архиве
+	Name string
+	// Position место съемки
+	Position models.Coordinates
+	// Taken время съемки, нулевое — неизвестно
+	Taken time.Time
+	Data  []byte
+}
+
+// Converter читает архивы снимков и собирает из них видео
+type Converter struct {
+	opts   Options
+	ffmpeg string
+}
+
+// New проверяет, что ffmpeg доступен
+func New(opts Options) (*Converter, error) {
+	if opts.FFmpegPath == "" {
+		opts.FFmpegPath = "ffmpeg"
+	}
+	if opts.MaxImages < 2 {
+		return nil, fmt.Errorf("max images must be at least 2")
+	}
+	ffmpeg, err := exec.LookPath(opts.FFmpegPath)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	return &Converter{opts: opts, ffmpeg: ffmpeg}, nil
+}
+
+// MaxImages возвращает наибольшее число снимков в архиве
+func (c *Converter) MaxImages() int {
+	return c.opts.MaxImages
+}
+
+// Parse читает снимки из ZIP архива в порядке съемки. Координаты снимка
+// берутся из таблицы CSV в архиве, а если снимка в ней нет — из EXIF GPS.
+// Снимки упорядочиваются по времени съемки, если оно известно для всех,
+// иначе по именам файлов. Ошибки данных архива оборачивают ErrInvalidArchive.
+func (c *Converter) Parse(archive []byte) ([]Image, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a ZIP archive: %v", ErrInvalidArchive, err)
+	}
+
+	var (
+		images  []Image
+		sidecar map[string]sidecarRow
+		total   uint64
+	)
+	names := make(map[string]string)
+	for _, file := range reader.File {
+		base := path.Base(file.Name)
+		if file.FileInfo().IsDir() || strings.HasPrefix(base, ".") || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+		switch strings.ToLower(path.Ext(base)) {
+		case ".jpg", ".jpeg":
+			if len(images) == c.opts.MaxImages {
+				return nil, fmt.Errorf("%w: more than %d images", ErrInvalidArchive, c.opts.MaxImages)
+			}
+			if other, ok := names[base]; ok {
+				return nil, fmt.Errorf("%w: images %s and %s have the same file name", ErrInvalidArchive, other, file.Name)
+			}
+			names[base] = file.Name
+			total += file.UncompressedSize64
+			if total > maxArchiveBytes {
+				return nil, fmt.Errorf("%w: images exceed %d bytes", ErrInvalidArchive, maxArchiveBytes)
+			}
+			data, err := readFile(file, maxImageBytes)
+			if err != nil {
+				return nil, err
+			}
+			images = append(images, Image{Name: file.Name, Data: data})
+		case ".csv":
+			if sidecar != nil {
+				return nil, fmt.Errorf("%w: more than one CSV file", ErrInvalidArchive)
+			}
+			data, err := readFile(file, maxImageBytes)
+			if err != nil {
+				return nil, err
+			}
+			if sidecar, err = parseSidecar(data); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, file.Name, err)
+			}
+		}
+	}
+	if len(images) < 2 {
+		return nil, fmt.Errorf("%w: at least 2 JPEG images are required, found %d", ErrInvalidArchive, len(images))
+	}
+
+	for name := range sidecar {
+		if _, ok := names[name]; !ok {
+			return nil, fmt.Errorf("%w: CSV references unknown image %s", ErrInvalidArchive, name)
+		}
+	}
+	for i := range images {
+		if err := locate(&images[i], sidecar); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, images[i].Name, err)
+		}
+	}
+
+	sortImages(images)
+	return images, nil
+}
+
+// readFile читает файл архива не больше limit байт
+func readFile(file *zip.File, limit int64) ([]byte, error) {
+	if file.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrInvalidArchive, file.Name, limit)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open %s: %v", ErrInvalidArchive, file.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s: %v", ErrInvalidArchive, file.Name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrInvalidArchive, file.Name, limit)
+	}
+	return data, nil
+}
+
+// locate проверяет снимок и определяет место и время съемки
+func locate(img *Image, sidecar map[string]sidecarRow) error {
+	if _, err := jpeg.DecodeConfig(bytes.NewReader(img.Data)); err != nil {
+		return fmt.Errorf("not a valid JPEG: %v", err)
+	}
+
+	meta, err := readEXIF(img.Data)
+	if err != nil {
+		return fmt.Errorf("failed to read EXIF: %v", err)
+	}
+	img.Taken = meta.taken
+
+	row, ok := sidecar[path.Base(img.Name)]
+	switch {
+	case ok:
+		img.Position = row.position
+		if !row.taken.IsZero() {
+			img.Taken = row.taken
+		}
+	case meta.position != nil:
+		img.Position = *meta.position
+	default:
+		return fmt.Errorf("no EXIF GPS position and no CSV row")
+	}
+	if !validPosition(img.Position) {
+		return fmt.Errorf("invalid position %.6f,%.6f", img.Position.Lat, img.Position.Lon)
+	}
+	return nil
+}
+
+// validPosition проверяет диапазоны широты и долготы
+func validPosition(p models.Coordinates) bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+// sortImages упорядочивает снимки по времени съемки, если оно известно для
+// всех снимков, иначе по именам файлов
+func sortImages(images []Image) {
+	byTime := true
+	for _, img := range images {
+		if img.Taken.IsZero() {
+			byTime = false
+			break
+		}
+	}
+	sort.SliceStable(images, func(i, j int) bool {
+		if byTime && !images[i].Taken.Equal(images[j].Taken) {
+			return images[i].Taken.Before(images[j].Taken)
+		}
+		return images[i].Name < images[j].Name
+	})
+}
+
+// Encode собирает из снимков видео MP4, в котором каждый снимок занимает
+// один кадр при FrameRate кадров в секунду
+func (c *Converter) Encode(ctx context.Context, images []Image) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "imageseq-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, img := range images {
+		name := filepath.Join(dir, fmt.Sprintf("frame%05d.jpg", i))
+		if err := os.WriteFile(name, img.Data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write temp file: %w", err)
+		}
+	}
+
+	// Снимки могут быть разного размера, поэтому вписываются в один кадр
+	output := filepath.Join(dir, "output.mp4")
+	filter := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,format=yuv420p",
+		frameWidth, frameHeight, frameWidth, frameHeight)
+	if _, err := mediatool.Run(ctx, c.ffmpeg,
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-framerate", fmt.Sprint(FrameRate), "-i", filepath.Join(dir, "frame%05d.jpg"),
+		"-vf", filter,
+		"-c:v", "libx264", "-preset", "veryfast", "-r", fmt.Sprint(FrameRate),
+		"-movflags", "+faststart",
+		output,
+	); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encoded video: %w", err)
+	}
+	return data, nil
+}
+
+// VideoFilename возвращает имя файла видео, собранного из архива archive
+func VideoFilename(archive string) string {
+	name := strings.TrimSuffix(archive, filepath.Ext(archive))
+	if name == "" {
+		name = "images"
+	}
+	return name + ".mp4"
+}
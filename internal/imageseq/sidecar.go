@@ -0,0 +1,93 @@
+package imageseq
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"road-detector-go/pkg/models"
+)
+
+// sidecarRow строка таблицы координат снимков
+type sidecarRow struct {
+	position models.Coordinates
+	// taken нулевое — время в таблице не указано
+	taken time.Time
+}
+
+// Названия столбцов таблицы координат
+var (
+	sidecarNameColumns = []string{"filename", "file", "image", "name"}
+	sidecarLatColumns  = []string{"lat", "latitude"}
+	sidecarLonColumns  = []string{"lon", "lng", "longitude"}
+	sidecarTimeColumns = []string{"time", "timestamp", "taken_at"}
+)
+
+// parseSidecar читает таблицу CSV с заголовком: имя файла снимка, широта,
+// долгота и необязательное время съемки в RFC 3339. Строки ищутся по имени
+// файла без каталогов.
+func parseSidecar(data []byte) (map[string]sidecarRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV is empty")
+	}
+
+	header := records[0]
+	nameCol := sidecarColumn(header, sidecarNameColumns)
+	latCol := sidecarColumn(header, sidecarLatColumns)
+	lonCol := sidecarColumn(header, sidecarLonColumns)
+	timeCol := sidecarColumn(header, sidecarTimeColumns)
+	if nameCol < 0 || latCol < 0 || lonCol < 0 {
+		return nil, fmt.Errorf("CSV header must name the filename, lat and lon columns")
+	}
+
+	rows := make(map[string]sidecarRow, len(records)-1)
+	for i, record := range records[1:] {
+		line := i + 2
+		name := path.Base(strings.TrimSpace(record[nameCol]))
+		if name == "" || name == "." {
+			return nil, fmt.Errorf("line %d: empty filename", line)
+		}
+		if _, ok := rows[name]; ok {
+			return nil, fmt.Errorf("line %d: duplicate filename %s", line, name)
+		}
+		var row sidecarRow
+		if row.position.Lat, err = strconv.ParseFloat(strings.TrimSpace(record[latCol]), 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid lat %q", line, record[latCol])
+		}
+		if row.position.Lon, err = strconv.ParseFloat(strings.TrimSpace(record[lonCol]), 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid lon %q", line, record[lonCol])
+		}
+		if timeCol >= 0 {
+			if value := strings.TrimSpace(record[timeCol]); value != "" {
+				if row.taken, err = time.Parse(time.RFC3339, value); err != nil {
+					return nil, fmt.Errorf("line %d: invalid time %q, expected RFC 3339", line, value)
+				}
+			}
+		}
+		rows[name] = row
+	}
+	return rows, nil
+}
+
+// sidecarColumn находит столбец по одному из названий, -1 — столбца нет
+func sidecarColumn(header []string, names []string) int {
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		for _, name := range names {
+			if column == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
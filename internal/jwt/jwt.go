@@ -0,0 +1,211 @@
+// Package jwt выпускает и проверяет JSON Web Token. Выпускаются токены
+// с подписью HS256, проверяются HS256 и RS256/RS384/RS512.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512" // SHA-384 и SHA-512 для RS384 и RS512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken возвращается для токена с неверным форматом, алгоритмом
+// или подписью
+var ErrInvalidToken = errors.New("invalid token")
+
+// ErrTokenExpired возвращается для просроченного или еще не действующего токена
+var ErrTokenExpired = errors.New("token expired")
+
+// clockSkew допустимое расхождение часов при проверке exp и nbf
+const clockSkew = 30 * time.Second
+
+// rsaHashes хэш-функции алгоритмов подписи RSA
+var rsaHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+}
+
+// Claims данные токена
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Email     string   `json:"email,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// Audience получатели токена. В JSON может быть строкой или массивом строк.
+type Audience []string
+
+// UnmarshalJSON принимает aud в виде строки или массива
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Contains проверяет, входит ли value в список получателей
+func (a Audience) Contains(value string) bool {
+	for _, item := range a {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Header заголовок токена
+type Header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	// Kid ID ключа подписи в наборе ключей издателя
+	Kid string `json:"kid,omitempty"`
+}
+
+// Token разобранный, но еще не проверенный токен
+type Token struct {
+	Header Header
+	Claims Claims
+	// Raw все данные токена, включая нестандартные
+	Raw map[string]interface{}
+
+	signingInput string
+	signature    []byte
+}
+
+// Sign подписывает claims ключом secret алгоритмом HS256
+func Sign(claims Claims, secret []byte) (string, error) {
+	headerJSON, err := json.Marshal(Header{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token claims: %w", err)
+	}
+
+	signingInput := encode(headerJSON) + "." + encode(claimsJSON)
+	return signingInput + "." + encode(hmacSHA256(signingInput, secret)), nil
+}
+
+// Parse проверяет подпись HS256 и срок действия токена на момент now
+// и возвращает его данные
+func Parse(token string, secret []byte, now time.Time) (*Claims, error) {
+	parsed, err := Decode(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.VerifyHMAC(secret); err != nil {
+		return nil, err
+	}
+	if err := parsed.ValidateTime(now); err != nil {
+		return nil, err
+	}
+	return &parsed.Claims, nil
+}
+
+// Decode разбирает токен без проверки подписи
+func Decode(token string) (*Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	headerJSON, err := decode(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad header encoding", ErrInvalidToken)
+	}
+	parsed := &Token{signingInput: parts[0] + "." + parts[1]}
+	if err := json.Unmarshal(headerJSON, &parsed.Header); err != nil {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidToken)
+	}
+
+	claimsJSON, err := decode(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad claims encoding", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(claimsJSON, &parsed.Claims); err != nil {
+		return nil, fmt.Errorf("%w: bad claims", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(claimsJSON, &parsed.Raw); err != nil {
+		return nil, fmt.Errorf("%w: bad claims", ErrInvalidToken)
+	}
+
+	if parsed.signature, err = decode(parts[2]); err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrInvalidToken)
+	}
+	return parsed, nil
+}
+
+// VerifyHMAC проверяет подпись HS256
+func (t *Token) VerifyHMAC(secret []byte) error {
+	if t.Header.Alg != "HS256" {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, t.Header.Alg)
+	}
+	if !hmac.Equal(t.signature, hmacSHA256(t.signingInput, secret)) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+	}
+	return nil
+}
+
+// VerifyRSA проверяет подпись RS256, RS384 или RS512 открытым ключом key
+func (t *Token) VerifyRSA(key *rsa.PublicKey) error {
+	hash, ok := rsaHashes[t.Header.Alg]
+	if !ok {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, t.Header.Alg)
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(t.signingInput))
+	if err := rsa.VerifyPKCS1v15(key, hash, hasher.Sum(nil), t.signature); err != nil {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+	}
+	return nil
+}
+
+// ValidateTime проверяет срок действия токена на момент now. Токен без exp
+// не принимается.
+func (t *Token) ValidateTime(now time.Time) error {
+	if t.Claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	if now.After(time.Unix(t.Claims.ExpiresAt, 0).Add(clockSkew)) {
+		return ErrTokenExpired
+	}
+	if t.Claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(t.Claims.NotBefore, 0)) {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+func hmacSHA256(signingInput string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(part string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(part)
+}
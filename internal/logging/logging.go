@@ -0,0 +1,80 @@
+// Package logging настраивает уровень, формат и вывод логов сервиса.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Форматы логов
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options настройки логов
+type Options struct {
+	// Level минимальный уровень: trace, debug, info, warn, error, fatal, panic
+	Level string
+	// Format формат записей: json или text
+	Format string
+	// File путь к файлу логов. Пусто — логи пишутся в stdout.
+	File string
+	// MaxSizeMB размер файла, после которого он переименовывается в <File>.1,
+	// 0 — без ротации
+	MaxSizeMB int
+	// MaxBackups сколько переименованных файлов хранится
+	MaxBackups int
+}
+
+// Configure применяет настройки к logger и возвращает файл вывода, который
+// нужно закрыть при остановке, или nil при выводе в stdout
+func Configure(logger *logrus.Logger, opts Options) (io.Closer, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(opts.Format) {
+	case "", FormatJSON:
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	case FormatText:
+		logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+			DisableColors: opts.File != "",
+		})
+	default:
+		return nil, fmt.Errorf("unknown log format %q, supported: %s, %s", opts.Format, FormatJSON, FormatText)
+	}
+
+	var closer io.Closer
+	if opts.File != "" {
+		file, err := OpenRotatingFile(opts.File, int64(opts.MaxSizeMB)<<20, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		logger.SetOutput(file)
+		closer = file
+	} else {
+		logger.SetOutput(os.Stdout)
+	}
+
+	logger.SetLevel(level)
+	return closer, nil
+}
+
+// ParseLevel разбирает уровень логов, пустая строка означает info
+func ParseLevel(raw string) (logrus.Level, error) {
+	if strings.TrimSpace(raw) == "" {
+		return logrus.InfoLevel, nil
+	}
+	level, err := logrus.ParseLevel(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("unknown log level %q", raw)
+	}
+	return level, nil
+}
@@ -0,0 +1,53 @@
+package mapmatch
+
+import (
+	"errors"
+
+	"road-detector-go/pkg/models"
+)
+
+// chunkedMatcher разбивает длинный трек на части, которые принимает сервис
+// (у OSRM по умолчанию не более 100 точек). Соседние части перекрываются
+// на одну точку, чтобы геометрия оставалась непрерывной.
+type chunkedMatcher struct {
+	inner     Matcher
+	maxPoints int
+}
+
+// Match привязывает трек по частям. Части, которые не удалось привязать,
+// дают nil точки, остальные сохраняются.
+func (m *chunkedMatcher) Match(points []models.Coordinates) (*Result, error) {
+	if len(points) <= m.maxPoints {
+		return m.inner.Match(points)
+	}
+
+	result := &Result{Points: make([]*models.Coordinates, len(points))}
+	matched := false
+	for start := 0; start < len(points)-1; start += m.maxPoints - 1 {
+		end := start + m.maxPoints
+		if end > len(points) {
+			end = len(points)
+		}
+
+		part, err := m.inner.Match(points[start:end])
+		if errors.Is(err, ErrNoMatch) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		matched = true
+		for i, p := range part.Points {
+			if result.Points[start+i] == nil {
+				result.Points[start+i] = p
+			}
+		}
+		result.Geometry = append(result.Geometry, part.Geometry...)
+	}
+
+	if !matched {
+		return nil, ErrNoMatch
+	}
+	return result, nil
+}
@@ -0,0 +1,54 @@
+// Package mapmatch привязывает координаты сегментов к дорожному графу
+// OpenStreetMap через внешний сервис map-matching (OSRM или Valhalla).
+package mapmatch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"road-detector-go/pkg/models"
+)
+
+// Поддерживаемые провайдеры
+const (
+	ProviderOSRM     = "osrm"
+	ProviderValhalla = "valhalla"
+)
+
+// maxPointsPerRequest ограничение числа точек в одном запросе к сервису
+const maxPointsPerRequest = 100
+
+// ErrNoMatch возвращается, если сервис не смог привязать трек к дорогам
+var ErrNoMatch = errors.New("no map match found")
+
+// Result результат привязки трека
+type Result struct {
+	// Points привязанные точки в порядке входных; nil, если точку привязать не удалось
+	Points []*models.Coordinates
+	// Geometry геометрия всего трека по дорожному графу
+	Geometry []models.Coordinates
+}
+
+// Matcher привязывает последовательность точек к дорожной сети
+type Matcher interface {
+	Match(points []models.Coordinates) (*Result, error)
+}
+
+// New создает Matcher для указанного провайдера
+func New(provider, baseURL string, timeout time.Duration) (Matcher, error) {
+	client := &http.Client{Timeout: timeout}
+
+	var inner Matcher
+	switch provider {
+	case ProviderOSRM:
+		inner = &osrmMatcher{baseURL: baseURL, client: client}
+	case ProviderValhalla:
+		inner = &valhallaMatcher{baseURL: baseURL, client: client}
+	default:
+		return nil, fmt.Errorf("unknown map matching provider %q", provider)
+	}
+
+	return &chunkedMatcher{inner: inner, maxPoints: maxPointsPerRequest}, nil
+}
@@ -0,0 +1,84 @@
+package mapmatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"road-detector-go/pkg/models"
+)
+
+// osrmMatcher клиент OSRM Match API
+type osrmMatcher struct {
+	baseURL string
+	client  *http.Client
+}
+
+// osrmResponse нужная часть ответа /match
+type osrmResponse struct {
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	Tracepoints []*struct {
+		Location [2]float64 `json:"location"`
+	} `json:"tracepoints"`
+	Matchings []struct {
+		Geometry struct {
+			Coordinates [][2]float64 `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"matchings"`
+}
+
+// Match привязывает точки через /match/v1/driving
+func (m *osrmMatcher) Match(points []models.Coordinates) (*Result, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("at least 2 points are required for map matching")
+	}
+
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%.6f,%.6f", p.Lon, p.Lat)
+	}
+	url := fmt.Sprintf("%s/match/v1/driving/%s?geometries=geojson&overview=full&tidy=true",
+		strings.TrimRight(m.baseURL, "/"), strings.Join(coords, ";"))
+
+	resp, err := m.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send OSRM request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OSRM response: %w", err)
+	}
+
+	var parsed osrmResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode OSRM response (status %d): %w", resp.StatusCode, err)
+	}
+	if parsed.Code == "NoMatch" {
+		return nil, ErrNoMatch
+	}
+	if resp.StatusCode != http.StatusOK || parsed.Code != "Ok" {
+		return nil, fmt.Errorf("OSRM returned %s: %s", parsed.Code, parsed.Message)
+	}
+	if len(parsed.Tracepoints) != len(points) {
+		return nil, fmt.Errorf("OSRM returned %d tracepoints for %d points", len(parsed.Tracepoints), len(points))
+	}
+
+	result := &Result{Points: make([]*models.Coordinates, len(points))}
+	for i, tp := range parsed.Tracepoints {
+		if tp != nil {
+			result.Points[i] = &models.Coordinates{Lat: tp.Location[1], Lon: tp.Location[0]}
+		}
+	}
+	for _, matching := range parsed.Matchings {
+		for _, c := range matching.Geometry.Coordinates {
+			result.Geometry = append(result.Geometry, models.Coordinates{Lat: c[1], Lon: c[0]})
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package mapmatch
+
+import (
+	"fmt"
+
+	"road-detector-go/pkg/models"
+)
+
+// decodePolyline декодирует Google encoded polyline с заданной точностью
+// (Valhalla использует 1e6)
+func decodePolyline(encoded string, precision float64) ([]models.Coordinates, error) {
+	var (
+		coordinates []models.Coordinates
+		lat, lon    int
+	)
+
+	for i := 0; i < len(encoded); {
+		var deltas [2]int
+		for d := range deltas {
+			result, shift := 0, uint(0)
+			for {
+				if i >= len(encoded) {
+					return nil, fmt.Errorf("truncated polyline")
+				}
+				b := int(encoded[i]) - 63
+				i++
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[d] = ^(result >> 1)
+			} else {
+				deltas[d] = result >> 1
+			}
+		}
+		lat += deltas[0]
+		lon += deltas[1]
+		coordinates = append(coordinates, models.Coordinates{
+			Lat: float64(lat) / precision,
+			Lon: float64(lon) / precision,
+		})
+	}
+
+	return coordinates, nil
+}
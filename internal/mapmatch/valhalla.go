@@ -0,0 +1,115 @@
+package mapmatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"road-detector-go/pkg/models"
+)
+
+// valhallaMatcher клиент Valhalla trace_attributes API
+type valhallaMatcher struct {
+	baseURL string
+	client  *http.Client
+}
+
+// valhallaRequest запрос /trace_attributes
+type valhallaRequest struct {
+	Shape      []valhallaPoint `json:"shape"`
+	Costing    string          `json:"costing"`
+	ShapeMatch string          `json:"shape_match"`
+	Filters    valhallaFilters `json:"filters"`
+}
+
+type valhallaPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type valhallaFilters struct {
+	Attributes []string `json:"attributes"`
+	Action     string   `json:"action"`
+}
+
+// valhallaResponse нужная часть ответа /trace_attributes
+type valhallaResponse struct {
+	Shape         string `json:"shape"`
+	MatchedPoints []struct {
+		Lat  float64 `json:"lat"`
+		Lon  float64 `json:"lon"`
+		Type string  `json:"type"`
+	} `json:"matched_points"`
+	ErrorCode int    `json:"error_code"`
+	Error     string `json:"error"`
+}
+
+// valhallaNoMatchCode код ошибки Valhalla "не удалось привязать трек"
+const valhallaNoMatchCode = 444
+
+// Match привязывает точки через /trace_attributes
+func (m *valhallaMatcher) Match(points []models.Coordinates) (*Result, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("at least 2 points are required for map matching")
+	}
+
+	request := valhallaRequest{
+		Costing:    "auto",
+		ShapeMatch: "map_snap",
+		Filters: valhallaFilters{
+			Attributes: []string{"shape", "matched.point", "matched.type"},
+			Action:     "include",
+		},
+	}
+	for _, p := range points {
+		request.Shape = append(request.Shape, valhallaPoint{Lat: p.Lat, Lon: p.Lon})
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Valhalla request: %w", err)
+	}
+
+	url := strings.TrimRight(m.baseURL, "/") + "/trace_attributes"
+	resp, err := m.client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send Valhalla request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Valhalla response: %w", err)
+	}
+
+	var parsed valhallaResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode Valhalla response (status %d): %w", resp.StatusCode, err)
+	}
+	if parsed.ErrorCode == valhallaNoMatchCode {
+		return nil, ErrNoMatch
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Valhalla returned error %d: %s", parsed.ErrorCode, parsed.Error)
+	}
+	if len(parsed.MatchedPoints) != len(points) {
+		return nil, fmt.Errorf("Valhalla returned %d matched points for %d points", len(parsed.MatchedPoints), len(points))
+	}
+
+	geometry, err := decodePolyline(parsed.Shape, 1e6)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Valhalla shape: %w", err)
+	}
+
+	result := &Result{Points: make([]*models.Coordinates, len(points)), Geometry: geometry}
+	for i, mp := range parsed.MatchedPoints {
+		if mp.Type != "unmatched" {
+			result.Points[i] = &models.Coordinates{Lat: mp.Lat, Lon: mp.Lon}
+		}
+	}
+
+	return result, nil
+}
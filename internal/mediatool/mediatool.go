@@ -0,0 +1,41 @@
+// Package mediatool запускает внешние команды обработки видео и документов,
+// например ffmpeg, ffprobe и wkhtmltopdf.
+package mediatool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// maxErrorOutput максимальная длина вывода команды в тексте ошибки
+const maxErrorOutput = 500
+
+// Run выполняет команду и возвращает ее вывод. В ошибку попадает начало
+// вывода ошибок команды.
+func Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return RunInput(ctx, nil, name, args...)
+}
+
+// RunInput выполняет команду с данными stdin на входе и возвращает ее вывод.
+// Если stdin равен nil, команда не получает входных данных.
+func RunInput(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(stderr.String())
+		if len(output) > maxErrorOutput {
+			output = output[:maxErrorOutput] + "..."
+		}
+		return nil, fmt.Errorf("%s failed: %v: %s", filepath.Base(name), err, output)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+)
+
+// Статусы оповещения
+const (
+	AlertStatusOpen         = "open"
+	AlertStatusAcknowledged = "acknowledged"
+)
+
+// AlertRule правило оповещения: после анализа маршрута проверяются сегменты
+// в области правила. Правило организации проверяется только для ее
+// маршрутов, правило без организации — для всех маршрутов.
+type AlertRule struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrganizationID *uint  `gorm:"index" json:"organization_id,omitempty"`
+	Name           string `gorm:"type:varchar(255);not null" json:"name"`
+	// Area GeoJSON полигон области правила, пусто — без ограничения области
+	Area string `gorm:"type:text" json:"-"`
+	// MaxCoverage сегмент с данными и покрытием ниже этого нарушает правило
+	MaxCoverage *float64 `json:"max_coverage,omitempty"`
+	// MinDefectSeverity сегмент с дефектом этой степени или выше нарушает правило
+	MinDefectSeverity string `gorm:"type:varchar(16)" json:"min_defect_severity,omitempty"`
+	// Emails и TelegramChats получатели оповещений через запятую
+	Emails        string    `gorm:"type:text" json:"-"`
+	TelegramChats string    `gorm:"type:text" json:"-"`
+	Active        bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName указывает имя таблицы для AlertRule
+func (AlertRule) TableName() string {
+	return "alert_rules"
+}
+
+// AlertSegment сегмент маршрута, нарушивший правило
+type AlertSegment struct {
+	SegmentID          int     `json:"segment_id"`
+	CoveragePercentage float64 `json:"coverage_percentage"`
+	// DefectSeverity наибольшая степень дефектов сегмента
+	DefectSeverity string `json:"defect_severity,omitempty"`
+}
+
+// Alert оповещение о сегментах маршрута, нарушивших правило. Принадлежит
+// организации правила.
+type Alert struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	RuleID         uint   `gorm:"not null;index" json:"rule_id"`
+	OrganizationID *uint  `gorm:"index" json:"organization_id,omitempty"`
+	RouteID        string `gorm:"type:varchar(36);not null;index" json:"route_id"`
+	// RuleName название правила на момент оповещения
+	RuleName      string         `gorm:"type:varchar(255);not null" json:"rule_name"`
+	Segments      []AlertSegment `gorm:"type:jsonb;serializer:json" json:"segments"`
+	SegmentsCount int            `gorm:"not null;default:0" json:"segments_count"`
+	Status        string         `gorm:"type:varchar(16);not null;default:'open';index" json:"status"`
+	// AcknowledgedBy email пользователя или название ключа API, подтвердившего оповещение
+	AcknowledgedBy string     `gorm:"type:varchar(255)" json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName указывает имя таблицы для Alert
+func (Alert) TableName() string {
+	return "alerts"
+}
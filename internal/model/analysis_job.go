@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+)
+
+// Статусы анализа в очереди
+const (
+	AnalysisJobQueued    = "queued"
+	AnalysisJobRunning   = "running"
+	AnalysisJobCompleted = "completed"
+	AnalysisJobFailed    = "failed"
+)
+
+// Виды анализа в очереди
+const (
+	AnalysisJobVideo  = "video"
+	AnalysisJobImages = "images"
+)
+
+// AnalysisJob анализ, который экземпляр API поставил в очередь для
+// воркеров. Видео или архив снимков лежат в общей папке очереди, результат
+// или ошибка записываются воркером сюда же.
+type AnalysisJob struct {
+	ID      string `gorm:"type:varchar(36);primaryKey" json:"id"`
+	RouteID string `gorm:"type:varchar(36);not null;index" json:"route_id"`
+	Kind    string `gorm:"type:varchar(16);not null" json:"kind"`
+	Status  string `gorm:"type:varchar(16);not null;index" json:"status"`
+	// Params координаты, длина сегмента и метаданные маршрута в JSON
+	Params string `gorm:"type:text;not null" json:"-"`
+	// PayloadPath видео или архив снимков в папке очереди
+	PayloadPath string `gorm:"type:text;not null" json:"-"`
+	Filename    string `gorm:"type:varchar(255);not null" json:"filename"`
+	// WorkerID воркер, выполняющий анализ
+	WorkerID string `gorm:"type:varchar(255)" json:"worker_id,omitempty"`
+	// Attempts сколько раз анализ брался воркерами
+	Attempts int `gorm:"not null;default:0" json:"attempts"`
+	// HeartbeatAt последний сигнал воркера; анализ без сигнала дольше
+	// заданного срока берет другой воркер
+	HeartbeatAt *time.Time `json:"heartbeat_at,omitempty"`
+	// Result результат анализа в JSON
+	Result string `gorm:"type:text" json:"-"`
+	Error  string `gorm:"type:text" json:"error,omitempty"`
+	// ErrorReason вид ошибки: analyzer_rejected, analyzer_unavailable и другие
+	ErrorReason string `gorm:"type:varchar(32)" json:"error_reason,omitempty"`
+
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// TableName указывает имя таблицы для AnalysisJob
+func (AnalysisJob) TableName() string {
+	return "analysis_jobs"
+}
@@ -0,0 +1,29 @@
+package model
+
+// AnalysisParams параметры анализа, переданные Python сервису вместе с
+// видео. Сохраняются с маршрутом, чтобы анализ можно было повторить с теми
+// же параметрами. Нулевое значение поля — значение по умолчанию сервиса.
+type AnalysisParams struct {
+	// FrameSampleRate сколько кадров в секунду видео анализировать
+	FrameSampleRate float64 `json:"frame_sample_rate,omitempty"`
+	// ConfidenceThreshold минимальная уверенность модели в разметке, (0, 1]
+	ConfidenceThreshold float64 `json:"confidence_threshold,omitempty"`
+	// ModelVariant вариант модели, который выбирает Python сервис
+	ModelVariant string `json:"model_variant,omitempty"`
+	// ROI область кадра, которая анализируется, nil — весь кадр
+	ROI *ROI `json:"roi,omitempty"`
+}
+
+// ROI прямоугольная область кадра в долях его ширины и высоты от левого
+// верхнего угла
+type ROI struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Empty проверяет, что ни один параметр не задан
+func (p *AnalysisParams) Empty() bool {
+	return p == nil || *p == AnalysisParams{}
+}
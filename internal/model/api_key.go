@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// APIKey ключ доступа к API. Хранится только хэш ключа, сам ключ
+// показывается один раз при создании.
+type APIKey struct {
+	ID   uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name string `gorm:"type:varchar(100);not null" json:"name"`
+	// Prefix начало ключа, по которому его можно узнать в списке
+	Prefix string `gorm:"type:varchar(16);not null" json:"prefix"`
+	// KeyHash SHA-256 ключа в hex
+	KeyHash string `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	// Admin разрешает управление ключами и служебные запросы
+	Admin      bool       `gorm:"not null;default:false" json:"admin"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// OrganizationID организация ключа. Ключ организации видит только ее данные,
+	// ключ без организации — данные всех организаций.
+	OrganizationID *uint `gorm:"index" json:"organization_id,omitempty"`
+}
+
+// TableName указывает имя таблицы для APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+)
+
+// Типы инициаторов действий в журнале аудита
+const (
+	AuditActorUser      = "user"
+	AuditActorAPIKey    = "api_key"
+	AuditActorAnonymous = "anonymous"
+)
+
+// AuditEntry запись журнала аудита об изменяющей операции
+type AuditEntry struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	// Action операция, например route.delete или api_key.create
+	Action string `gorm:"type:varchar(64);not null;index" json:"action"`
+	// ActorType кто выполнил операцию: user, api_key или anonymous
+	ActorType string `gorm:"type:varchar(16);not null" json:"actor_type"`
+	// ActorID ID пользователя или ключа API, пусто для ключа из конфигурации
+	ActorID *uint `gorm:"index" json:"actor_id,omitempty"`
+	// ActorName email пользователя или название ключа на момент операции
+	ActorName      string `gorm:"type:varchar(255)" json:"actor_name,omitempty"`
+	OrganizationID *uint  `gorm:"index" json:"organization_id,omitempty"`
+	IP             string `gorm:"type:varchar(64)" json:"ip"`
+	Method         string `gorm:"type:varchar(8)" json:"method"`
+	Path           string `gorm:"type:varchar(255)" json:"path"`
+	Status         int    `json:"status"`
+	RouteID        string `gorm:"type:varchar(36);index" json:"route_id,omitempty"`
+	// Target другой объект операции, например tag:5 или api_key:3
+	Target string `gorm:"type:varchar(64)" json:"target,omitempty"`
+	// Summary краткое описание переданных данных
+	Summary string `gorm:"type:text" json:"summary,omitempty"`
+}
+
+// TableName указывает имя таблицы для AuditEntry
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// Boundary административная граница (район, муниципальное образование),
+// по которой агрегируется статистика покрытия. Граница без организации
+// доступна всем, граница организации — только ее участникам.
+type Boundary struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrganizationID *uint  `gorm:"index" json:"organization_id,omitempty"`
+	Name           string `gorm:"type:varchar(255);not null" json:"name"`
+	// Kind вид границы, например district или municipality
+	Kind string `gorm:"type:varchar(64);not null;default:'';index" json:"kind"`
+	// Geometry полигон границы в GeoJSON
+	Geometry string `gorm:"type:text;not null" json:"-"`
+
+	// Описывающий прямоугольник полигона для отбора сегментов без PostGIS
+	MinLat float64 `gorm:"not null" json:"-"`
+	MinLon float64 `gorm:"not null" json:"-"`
+	MaxLat float64 `gorm:"not null" json:"-"`
+	MaxLon float64 `gorm:"not null" json:"-"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName указывает имя таблицы для Boundary
+func (Boundary) TableName() string {
+	return "boundaries"
+}
@@ -0,0 +1,79 @@
+package model
+
+import "strings"
+
+// Типы дефектов покрытия, которые распознает Python сервис. Сервис может
+// присылать и другие типы, они сохраняются как есть.
+const (
+	DefectPothole = "pothole"
+	DefectCrack   = "crack"
+)
+
+// Степени дефектов покрытия по возрастанию
+const (
+	DefectSeverityLow    = "low"
+	DefectSeverityMedium = "medium"
+	DefectSeverityHigh   = "high"
+)
+
+// maxDefectTypeLength максимальная длина типа дефекта
+const maxDefectTypeLength = 64
+
+// SegmentDefect дефекты покрытия одного типа и степени на сегменте
+type SegmentDefect struct {
+	Type     string `json:"type"`
+	Severity string `json:"severity,omitempty"`
+	Count    int    `json:"count"`
+}
+
+// NormalizeDefects приводит типы и степени дефектов к нижнему регистру,
+// отбрасывает записи без типа или с неположительным количеством и
+// объединяет записи одного типа и степени. Неизвестная степень
+// заменяется пустой.
+func NormalizeDefects(defects []SegmentDefect) []SegmentDefect {
+	var result []SegmentDefect
+	index := make(map[SegmentDefect]int)
+	for _, d := range defects {
+		d.Type = strings.ToLower(strings.TrimSpace(d.Type))
+		if d.Type == "" || len(d.Type) > maxDefectTypeLength || d.Count <= 0 {
+			continue
+		}
+		d.Severity = strings.ToLower(strings.TrimSpace(d.Severity))
+		switch d.Severity {
+		case DefectSeverityLow, DefectSeverityMedium, DefectSeverityHigh:
+		default:
+			d.Severity = ""
+		}
+		key := SegmentDefect{Type: d.Type, Severity: d.Severity}
+		if i, ok := index[key]; ok {
+			result[i].Count += d.Count
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, d)
+	}
+	return result
+}
+
+// CountDefects возвращает общее число дефектов
+func CountDefects(defects []SegmentDefect) int {
+	total := 0
+	for _, d := range defects {
+		total += d.Count
+	}
+	return total
+}
+
+// DefectSeverityRank возвращает порядок степени дефекта: 1 — low, 2 —
+// medium, 3 — high, 0 — степень неизвестна
+func DefectSeverityRank(severity string) int {
+	switch severity {
+	case DefectSeverityLow:
+		return 1
+	case DefectSeverityMedium:
+		return 2
+	case DefectSeverityHigh:
+		return 3
+	}
+	return 0
+}
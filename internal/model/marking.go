@@ -0,0 +1,62 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Типы разметки, которые различает Python сервис. Сервис может присылать и
+// другие типы, они сохраняются как есть.
+const (
+	MarkingLaneLine  = "lane_line"
+	MarkingCrosswalk = "crosswalk"
+	MarkingStopLine  = "stop_line"
+	MarkingArrow     = "arrow"
+)
+
+// markingTypePattern допустимое название типа разметки
+var markingTypePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// ValidMarkingType проверяет название типа разметки: до 64 символов a-z,
+// 0-9 и _
+func ValidMarkingType(markingType string) bool {
+	return markingTypePattern.MatchString(markingType)
+}
+
+// NormalizeClassCoverage приводит типы разметки к нижнему регистру,
+// отбрасывает недопустимые типы и ограничивает покрытие диапазоном
+// [0, 100]. Возвращает nil, если покрытие по типам не задано.
+func NormalizeClassCoverage(coverage map[string]float64) map[string]float64 {
+	var result map[string]float64
+	for markingType, value := range coverage {
+		markingType = strings.ToLower(strings.TrimSpace(markingType))
+		if !ValidMarkingType(markingType) {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]float64, len(coverage))
+		}
+		result[markingType] = min(max(value, 0), 100)
+	}
+	return result
+}
+
+// AverageClassCoverage возвращает среднее покрытие по каждому типу
+// разметки среди сегментов, для которых покрытие этого типа задано
+func AverageClassCoverage(coverages []map[string]float64) map[string]float64 {
+	var sums map[string]float64
+	counts := make(map[string]int)
+	for _, coverage := range coverages {
+		for markingType, value := range coverage {
+			if sums == nil {
+				sums = make(map[string]float64)
+			}
+			sums[markingType] += value
+			counts[markingType]++
+		}
+	}
+	for markingType := range sums {
+		sums[markingType] /= float64(counts[markingType])
+	}
+	return sums
+}
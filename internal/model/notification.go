@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// Виды уведомлений, на которые подписывается пользователь
+const (
+	// NotificationAnalysisCompleted завершение анализов пользователя
+	NotificationAnalysisCompleted = "analysis_completed"
+	// NotificationAlerts оповещения организации пользователя
+	NotificationAlerts = "alerts"
+	// NotificationReports сводные отчеты организации пользователя
+	NotificationReports = "reports"
+)
+
+// NotificationPreference настройки уведомлений пользователя по почте.
+// Без записи пользователь не получает уведомлений.
+type NotificationPreference struct {
+	UserID            uint      `gorm:"primaryKey" json:"user_id"`
+	AnalysisCompleted bool      `gorm:"not null;default:false" json:"analysis_completed"`
+	Alerts            bool      `gorm:"not null;default:false" json:"alerts"`
+	Reports           bool      `gorm:"not null;default:false" json:"reports"`
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName указывает имя таблицы для NotificationPreference
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
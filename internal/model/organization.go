@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+)
+
+// Роли участников организации
+const (
+	OrgRoleMember = "member"
+	OrgRoleAdmin  = "admin"
+)
+
+// Organization организация, которой принадлежат маршруты. Данные разных
+// организаций изолированы друг от друга.
+type Organization struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string    `gorm:"type:varchar(200);not null" json:"name"`
+	Slug      string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"slug"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName указывает имя таблицы для Organization
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// OrganizationMember участие пользователя в организации
+type OrganizationMember struct {
+	OrganizationID uint `gorm:"primaryKey" json:"organization_id"`
+	UserID         uint `gorm:"primaryKey;index" json:"user_id"`
+	// Role роль в организации: member или admin
+	Role      string    `gorm:"type:varchar(16);not null;default:'member'" json:"role"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName указывает имя таблицы для OrganizationMember
+func (OrganizationMember) TableName() string {
+	return "organization_members"
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// OutboxEvent событие, ожидающее публикации. Записывается в одной транзакции
+// с изменением, о котором сообщает, и публикуется фоновым ретранслятором.
+type OutboxEvent struct {
+	ID uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	// EventID ID события, по которому подписчики отбрасывают повторы
+	EventID        string `gorm:"type:varchar(36);not null;uniqueIndex" json:"event_id"`
+	Event          string `gorm:"type:varchar(64);not null" json:"event"`
+	OrganizationID *uint  `json:"organization_id,omitempty"`
+	// Data данные события в JSON
+	Data string `gorm:"type:text;not null" json:"data"`
+	// Attempts неудачные попытки публикации, Error — ошибка последней из них
+	Attempts int    `gorm:"not null;default:0" json:"attempts"`
+	Error    string `gorm:"type:text" json:"error,omitempty"`
+	// PublishedAt время публикации, nil — событие еще не опубликовано
+	PublishedAt *time.Time `gorm:"index" json:"published_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName указывает имя таблицы для OutboxEvent
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+)
+
+// Периоды отчетов
+const (
+	ReportPeriodWeekly  = "weekly"
+	ReportPeriodMonthly = "monthly"
+)
+
+// Форматы отчетов
+const (
+	ReportFormatHTML = "html"
+	ReportFormatPDF  = "pdf"
+)
+
+// ReportSchedule расписание сводных отчетов. Отчет организации учитывает
+// только ее маршруты, отчет без организации — все маршруты.
+type ReportSchedule struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrganizationID *uint  `gorm:"index" json:"organization_id,omitempty"`
+	Name           string `gorm:"type:varchar(255);not null" json:"name"`
+	Period         string `gorm:"type:varchar(16);not null" json:"period"`
+	// Format формат отчета во вложении письма
+	Format string `gorm:"type:varchar(8);not null;default:'html'" json:"format"`
+	// Emails получатели отчета через запятую
+	Emails string `gorm:"type:text" json:"-"`
+	// Webhook публиковать событие report.generated подписчикам вебхуков
+	Webhook bool `gorm:"not null;default:false" json:"webhook"`
+	Active  bool `gorm:"not null;default:true" json:"active"`
+	// NextRunAt начало следующего периода, после которого создается отчет
+	NextRunAt time.Time  `gorm:"not null;index" json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName указывает имя таблицы для ReportSchedule
+func (ReportSchedule) TableName() string {
+	return "report_schedules"
+}
+
+// Report созданный отчет. Хранятся данные отчета, HTML и PDF отрисовываются
+// по ним при скачивании.
+type Report struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ScheduleID     uint      `gorm:"not null;index" json:"schedule_id"`
+	OrganizationID *uint     `gorm:"index" json:"organization_id,omitempty"`
+	Period         string    `gorm:"type:varchar(16);not null" json:"period"`
+	PeriodStart    time.Time `gorm:"not null" json:"period_start"`
+	PeriodEnd      time.Time `gorm:"not null" json:"period_end"`
+	// Data данные отчета в формате report.Data
+	Data      string    `gorm:"type:text;not null" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName указывает имя таблицы для Report
+func (Report) TableName() string {
+	return "reports"
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+)
+
+// Road логическая дорога, объединяющая несколько проездов по одному коридору
+type Road struct {
+	ID   string `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name string `gorm:"type:varchar(255);not null" json:"name"`
+
+	// Агрегаты по сегментам, пересчитываются при добавлении и удалении проездов
+	SegmentCount    int     `gorm:"not null;default:0" json:"segment_count"`
+	RouteCount      int     `gorm:"not null;default:0" json:"route_count"`
+	AverageCoverage float64 `gorm:"not null;default:0" json:"average_coverage"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Segments []RoadSegment `gorm:"foreignKey:RoadID;constraint:OnDelete:CASCADE" json:"segments"`
+}
+
+// RoadSegment участок дороги с покрытием, агрегированным по всем проездам
+type RoadSegment struct {
+	ID       uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	RoadID   string  `gorm:"type:varchar(36);not null;index" json:"road_id"`
+	Position int     `gorm:"not null" json:"position"`
+	StartLat float64 `gorm:"not null" json:"start_lat"`
+	StartLon float64 `gorm:"not null" json:"start_lon"`
+	EndLat   float64 `gorm:"not null" json:"end_lat"`
+	EndLon   float64 `gorm:"not null" json:"end_lon"`
+
+	ObservationCount int        `gorm:"not null;default:0" json:"observation_count"`
+	LatestCoverage   float64    `gorm:"not null;default:0" json:"latest_coverage"`
+	WorstCoverage    float64    `gorm:"not null;default:0" json:"worst_coverage"`
+	AverageCoverage  float64    `gorm:"not null;default:0" json:"average_coverage"`
+	LastObservedAt   *time.Time `json:"last_observed_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// RoadObservation покрытие участка дороги, измеренное одним сегментом маршрута
+type RoadObservation struct {
+	ID                 uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RoadID             string    `gorm:"type:varchar(36);not null;index" json:"road_id"`
+	RoadSegmentID      uint      `gorm:"not null;index" json:"road_segment_id"`
+	RouteID            string    `gorm:"type:varchar(36);not null;index" json:"route_id"`
+	SegmentID          int32     `gorm:"not null" json:"segment_id"`
+	CoveragePercentage float64   `gorm:"not null" json:"coverage_percentage"`
+	ObservedAt         time.Time `gorm:"not null" json:"observed_at"`
+
+	RoadSegment RoadSegment `gorm:"foreignKey:RoadSegmentID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName указывает имя таблицы для Road
+func (Road) TableName() string {
+	return "roads"
+}
+
+// TableName указывает имя таблицы для RoadSegment
+func (RoadSegment) TableName() string {
+	return "road_segments"
+}
+
+// TableName указывает имя таблицы для RoadObservation
+func (RoadObservation) TableName() string {
+	return "road_observations"
+}
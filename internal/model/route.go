@@ -18,6 +18,8 @@ type Route struct {
 	SegmentLengthM int     `gorm:"not null" json:"segment_length_m"`
 	VideoFilename  string  `gorm:"type:varchar(255)" json:"video_filename"`
 	VideoPath      string  `gorm:"type:varchar(500)" json:"video_path"`
+	// RoadName название дороги по данным обратного геокодирования
+	RoadName string `gorm:"type:varchar(255)" json:"road_name,omitempty"`
 
 	// Общая статистика
 	TotalFrames         int     `gorm:"not null;default:0" json:"total_frames"`
@@ -26,12 +28,59 @@ type Route struct {
 	SegmentsWithData    int     `gorm:"not null;default:0" json:"segments_with_data"`
 	AverageCoverage     float64 `gorm:"not null;default:0" json:"average_coverage"`
 
+	// DefectsCount число дефектов покрытия на всех сегментах маршрута
+	DefectsCount int `gorm:"not null;default:0;index" json:"defects_count"`
+	// SegmentsWithDefects число сегментов, на которых найдены дефекты
+	SegmentsWithDefects int `gorm:"not null;default:0" json:"segments_with_defects"`
+
+	// ClassCoverage среднее покрытие по типам разметки, nil — анализатор
+	// не различал типы разметки
+	ClassCoverage map[string]float64 `gorm:"type:jsonb;serializer:json" json:"class_coverage,omitempty"`
+
+	// AverageConfidence средняя уверенность модели по сегментам, nil —
+	// анализатор не сообщал уверенность
+	AverageConfidence *float64 `json:"average_confidence,omitempty"`
+	// LowConfidenceSegments число сегментов с низкой уверенностью модели
+	LowConfidenceSegments int `gorm:"not null;default:0" json:"low_confidence_segments"`
+	// QualityScore индекс качества дороги от 0 до 100, nil — не рассчитан
+	QualityScore *float64 `gorm:"index" json:"quality_score,omitempty"`
+
+	// PlayableVideoPath видео, которое воспроизводится в браузерах: VideoPath
+	// или его копия в H.264 MP4. Пусто — видео еще не проверено.
+	PlayableVideoPath string `gorm:"type:varchar(500)" json:"playable_video_path,omitempty"`
+
+	// StorageBytes размер видео, его копии для браузеров и аннотированного
+	// видео маршрута, nil — еще не измерен
+	StorageBytes *int64 `json:"storage_bytes,omitempty"`
+
+	// MatchedGeometry трек, привязанный к дорожному графу OSM (GeoJSON LineString)
+	MatchedGeometry string `gorm:"type:text" json:"matched_geometry,omitempty"`
+
+	// CustomFields произвольные пользовательские поля маршрута
+	CustomFields map[string]string `gorm:"type:jsonb;serializer:json" json:"custom_fields,omitempty"`
+
+	// AnalysisParams параметры анализа, переданные Python сервису
+	AnalysisParams *AnalysisParams `gorm:"type:jsonb;serializer:json" json:"analysis_params,omitempty"`
+
+	// APIKeyID ключ API, с которым был создан маршрут
+	APIKeyID *uint `gorm:"index" json:"api_key_id,omitempty"`
+
+	// OwnerID пользователь, которому принадлежит маршрут
+	OwnerID *uint `gorm:"index" json:"owner_id,omitempty"`
+	// OrganizationID организация, которой принадлежит маршрут
+	OrganizationID *uint `gorm:"index" json:"organization_id,omitempty"`
+
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	// ArchivedAt время переноса в архив, nil — маршрут не в архиве
+	ArchivedAt *time.Time `gorm:"index" json:"archived_at,omitempty"`
 
 	// Связь с сегментами
 	Segments []Segment `gorm:"foreignKey:RouteID;constraint:OnDelete:CASCADE" json:"segments"`
+
+	// Метки маршрута, связь через таблицу route_tags
+	Tags []Tag `gorm:"many2many:route_tags;constraint:OnDelete:CASCADE" json:"tags,omitempty"`
 }
 
 // Segment представляет сегмент маршрута в базе данных
@@ -42,10 +91,36 @@ type Segment struct {
 	FramesCount        int32   `gorm:"not null" json:"frames_count"`
 	CoveragePercentage float64 `gorm:"not null" json:"coverage_percentage"`
 	HasData            bool    `gorm:"not null" json:"has_data"`
-	StartLat           float64 `gorm:"not null" json:"start_lat"`
-	StartLon           float64 `gorm:"not null" json:"start_lon"`
-	EndLat             float64 `gorm:"not null" json:"end_lat"`
-	EndLon             float64 `gorm:"not null" json:"end_lon"`
+	StartLat           float64 `gorm:"not null;index:idx_segments_start,priority:1" json:"start_lat"`
+	StartLon           float64 `gorm:"not null;index:idx_segments_start,priority:2" json:"start_lon"`
+	EndLat             float64 `gorm:"not null;index:idx_segments_end,priority:1" json:"end_lat"`
+	EndLon             float64 `gorm:"not null;index:idx_segments_end,priority:2" json:"end_lon"`
+	RoadName           string  `gorm:"type:varchar(255)" json:"road_name,omitempty"`
+
+	// Defects дефекты покрытия сегмента по типам и степеням
+	Defects []SegmentDefect `gorm:"type:jsonb;serializer:json" json:"defects,omitempty"`
+	// DefectsCount общее число дефектов покрытия сегмента
+	DefectsCount int `gorm:"not null;default:0" json:"defects_count"`
+	// ClassCoverage покрытие сегмента по типам разметки, nil — анализатор
+	// не различал типы разметки
+	ClassCoverage map[string]float64 `gorm:"type:jsonb;serializer:json" json:"class_coverage,omitempty"`
+	// AverageConfidence и MinConfidence средняя и минимальная уверенность
+	// модели на кадрах сегмента от 0 до 1, nil — анализатор не сообщал
+	// уверенность
+	AverageConfidence *float64 `json:"average_confidence,omitempty"`
+	MinConfidence     *float64 `json:"min_confidence,omitempty"`
+	// LowConfidence результат сегмента ненадежен: средняя уверенность ниже
+	// порога, например при съемке ночью или в дождь
+	LowConfidence bool `gorm:"not null;default:false" json:"low_confidence"`
+	// QualityScore индекс качества сегмента от 0 до 100, nil — у сегмента
+	// нет данных
+	QualityScore *float64 `json:"quality_score,omitempty"`
+
+	// Координаты, привязанные к дорожному графу OSM; nil, если привязка не выполнялась
+	MatchedStartLat *float64 `json:"matched_start_lat,omitempty"`
+	MatchedStartLon *float64 `json:"matched_start_lon,omitempty"`
+	MatchedEndLat   *float64 `json:"matched_end_lat,omitempty"`
+	MatchedEndLon   *float64 `json:"matched_end_lon,omitempty"`
 
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// Статусы теневого анализа
+const (
+	ShadowAnalysisCompleted = "completed"
+	ShadowAnalysisFailed    = "failed"
+)
+
+// ShadowAnalysis результат анализа того же видео вторым (теневым)
+// анализатором. Не влияет на маршрут и нужен, чтобы сравнить версии
+// анализатора до переключения.
+type ShadowAnalysis struct {
+	ID      uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	RouteID string `gorm:"type:varchar(36);not null;index" json:"route_id"`
+	// AnalyzerURL и AnalyzerVersion теневой анализатор и его версия из /health
+	AnalyzerURL     string `gorm:"type:varchar(500);not null" json:"analyzer_url"`
+	AnalyzerVersion string `gorm:"type:varchar(64)" json:"analyzer_version,omitempty"`
+	Status          string `gorm:"type:varchar(16);not null" json:"status"`
+	Error           string `gorm:"type:text" json:"error,omitempty"`
+	// DurationMs длительность запроса к теневому анализатору
+	DurationMs float64 `gorm:"not null;default:0" json:"duration_ms"`
+
+	TotalFrames      int     `gorm:"not null;default:0" json:"total_frames"`
+	TotalSegments    int     `gorm:"not null;default:0" json:"total_segments"`
+	SegmentsWithData int     `gorm:"not null;default:0" json:"segments_with_data"`
+	AverageCoverage  float64 `gorm:"not null;default:0" json:"average_coverage"`
+	// Segments результаты сегментов в порядке маршрута
+	Segments []ShadowSegment `gorm:"type:jsonb;serializer:json" json:"segments"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ShadowSegment результат сегмента в теневом анализе
+type ShadowSegment struct {
+	SegmentID          int     `json:"segment_id"`
+	FramesCount        int     `json:"frames_count"`
+	CoveragePercentage float64 `json:"coverage_percentage"`
+	HasData            bool    `json:"has_data"`
+}
+
+// TableName указывает имя таблицы для ShadowAnalysis
+func (ShadowAnalysis) TableName() string {
+	return "shadow_analyses"
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+// ShareLink публичная ссылка на маршрут только для чтения. Хранится только
+// хэш токена, сам токен показывается один раз при создании.
+type ShareLink struct {
+	ID      uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	RouteID string `gorm:"type:varchar(36);not null;index" json:"route_id"`
+	// Prefix начало токена, по которому ссылку можно узнать в списке
+	Prefix string `gorm:"type:varchar(16);not null" json:"prefix"`
+	// TokenHash SHA-256 токена в hex
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	// CreatedByUserID и CreatedByAPIKeyID кто создал ссылку
+	CreatedByUserID   *uint `json:"created_by_user_id,omitempty"`
+	CreatedByAPIKeyID *uint `json:"created_by_api_key_id,omitempty"`
+}
+
+// TableName указывает имя таблицы для ShareLink
+func (ShareLink) TableName() string {
+	return "share_links"
+}
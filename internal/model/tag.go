@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+)
+
+// Tag метка для группировки маршрутов: кампания съемки, район, подрядчик и т.п.
+type Tag struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName указывает имя таблицы для Tag
+func (Tag) TableName() string {
+	return "tags"
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// UsageCounter использование сервиса за месяц: организацией, пользователем
+// или ключом API
+type UsageCounter struct {
+	// Subject чей это счетчик, например org:1, user:5 или key:3
+	Subject string `gorm:"primaryKey;type:varchar(64)" json:"subject"`
+	// Period месяц в формате 2006-01 (UTC)
+	Period  string `gorm:"primaryKey;type:varchar(7)" json:"period"`
+	Uploads int64  `gorm:"not null;default:0" json:"uploads"`
+	// AnalysisSeconds суммарное время анализа видео
+	AnalysisSeconds float64   `gorm:"not null;default:0" json:"analysis_seconds"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName указывает имя таблицы для UsageCounter
+func (UsageCounter) TableName() string {
+	return "usage_counters"
+}
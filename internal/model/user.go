@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User учетная запись пользователя. Пароль хранится только в виде bcrypt хэша.
+// У пользователей внешнего OIDC провайдера пароля нет, они определяются
+// по издателю и subject токена.
+type User struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Email        string `gorm:"type:varchar(255);not null;uniqueIndex" json:"email"`
+	PasswordHash string `gorm:"type:varchar(100);not null" json:"-"`
+	Role         string `gorm:"type:varchar(16);not null;default:'user'" json:"role"`
+	// ExternalIssuer и ExternalSubject издатель и sub токена OIDC провайдера
+	ExternalIssuer  *string   `gorm:"type:varchar(255);uniqueIndex:idx_users_external" json:"-"`
+	ExternalSubject *string   `gorm:"type:varchar(255);uniqueIndex:idx_users_external" json:"-"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName указывает имя таблицы для User
+func (User) TableName() string {
+	return "users"
+}
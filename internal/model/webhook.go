@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+)
+
+// События, о которых сообщают вебхуки
+const (
+	WebhookEventAnalysisCompleted = "analysis.completed"
+	WebhookEventAnalysisFailed    = "analysis.failed"
+	WebhookEventAlertCreated      = "alert.created"
+	WebhookEventReportGenerated   = "report.generated"
+)
+
+// Форматы тела запроса подписки
+const (
+	// WebhookFormatJSON событие в JSON с подписью
+	WebhookFormatJSON = "json"
+	// WebhookFormatSlack сообщение для входящих вебхуков Slack и Mattermost
+	WebhookFormatSlack = "slack"
+)
+
+// Статусы доставки вебхука
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook подписка на события анализа. Подписка организации получает события
+// только ее маршрутов, подписка без организации — события всех маршрутов.
+type Webhook struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrganizationID *uint  `gorm:"index" json:"organization_id,omitempty"`
+	URL            string `gorm:"type:varchar(2048);not null" json:"url"`
+	// Secret ключ подписи HMAC-SHA256, показывается только при создании
+	Secret string `gorm:"type:varchar(128);not null" json:"-"`
+	// Events события подписки через запятую
+	Events string `gorm:"type:varchar(255);not null" json:"events"`
+	// Format формат тела запроса: json или slack
+	Format    string    `gorm:"type:varchar(16);not null;default:'json'" json:"format"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName указывает имя таблицы для Webhook
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery доставка события подписчику и результат последней попытки
+type WebhookDelivery struct {
+	ID        uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	WebhookID uint `gorm:"not null;index" json:"webhook_id"`
+	// EventID общий ID события для всех подписчиков
+	EventID  string `gorm:"type:varchar(36);not null" json:"event_id"`
+	Event    string `gorm:"type:varchar(64);not null" json:"event"`
+	Payload  string `gorm:"type:text;not null" json:"payload"`
+	Status   string `gorm:"type:varchar(16);not null;default:'pending'" json:"status"`
+	Attempts int    `gorm:"not null;default:0" json:"attempts"`
+	// ResponseStatus HTTP статус ответа подписчика на последнюю попытку
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `gorm:"type:text" json:"error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName указывает имя таблицы для WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,417 @@
+// Package mqttingest принимает клипы и GPS треки от устройств на машинах
+// обследования через брокер MQTT и анализирует их тем же конвейером, что и
+// POST /api/v1/analyze. Устройство публикует видео клипа и его трек
+// отдельными сообщениями, клип анализируется, когда получены оба.
+package mqttingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/apierror"
+	"road-detector-go/internal/service"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+)
+
+// Части топиков клипа: <prefix>/<устройство>/<клип>/<часть>
+const (
+	partVideo  = "video"
+	partTrack  = "track"
+	partResult = "result"
+)
+
+const (
+	// queueSize сколько собранных клипов ждут анализа
+	queueSize = 32
+	// maxPendingClips сколько клипов одновременно ждут второй части
+	maxPendingClips = 64
+	// publishTimeout сколько ждать подтверждения публикации результата
+	publishTimeout = 10 * time.Second
+	// disconnectQuiesce сколько миллисекунд ждать отправки сообщений при отключении
+	disconnectQuiesce = 250
+)
+
+// Options настройки приема клипов по MQTT
+type Options struct {
+	// BrokerURL адрес брокера, например tcp://mqtt:1883 или ssl://mqtt:8883
+	BrokerURL string
+	ClientID  string
+	Username  string
+	Password  string
+	// TopicPrefix начало топиков клипов
+	TopicPrefix string
+	// QoS уровень доставки подписки и результатов: 0, 1 или 2
+	QoS byte
+	// MaxClipBytes наибольший размер видео клипа, 0 — без ограничения
+	MaxClipBytes int64
+	// AssemblyTimeout сколько ждать вторую часть клипа
+	AssemblyTimeout time.Duration
+	// Workers сколько клипов анализируется одновременно
+	Workers int
+	// OrganizationID организация, которой принадлежат маршруты клипов,
+	// nil — маршруты без организации
+	OrganizationID *uint
+}
+
+// Track трек клипа и параметры маршрута
+type Track struct {
+	VideoFilename string                `json:"video_filename"`
+	Points        []service.Coordinates `json:"points"`
+	// SegmentLengthM длина сегмента маршрута в метрах
+	SegmentLengthM float64  `json:"segment_length_m"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Tags           []string `json:"tags"`
+}
+
+// Result результат анализа клипа, публикуемый устройству
+type Result struct {
+	Status          string        `json:"status"`
+	RouteID         string        `json:"route_id,omitempty"`
+	AverageCoverage *float64      `json:"average_coverage,omitempty"`
+	Error           string        `json:"error,omitempty"`
+	Code            apierror.Code `json:"code,omitempty"`
+}
+
+// Статусы результата клипа
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// clip части клипа, полученные от устройства
+type clip struct {
+	device     string
+	id         string
+	video      []byte
+	track      *Track
+	receivedAt time.Time
+}
+
+// Ingester подписчик MQTT, собирающий клипы в анализы
+type Ingester struct {
+	opts     Options
+	client   mqtt.Client
+	analyzer *service.AnalyzerService
+	routes   *service.RouteService
+	logger   *logrus.Logger
+
+	mu      sync.Mutex
+	pending map[string]*clip
+	closed  bool
+
+	queue    chan *clip
+	workers  sync.WaitGroup
+	stopping chan struct{}
+	now      func() time.Time
+}
+
+// New создает подписчика. Подключение к брокеру выполняет Start.
+func New(opts Options, analyzer *service.AnalyzerService, routes *service.RouteService, logger *logrus.Logger) (*Ingester, error) {
+	if opts.BrokerURL == "" {
+		return nil, errors.New("mqtt broker url is required")
+	}
+	opts.TopicPrefix = strings.Trim(opts.TopicPrefix, "/")
+	if opts.TopicPrefix == "" || strings.ContainsAny(opts.TopicPrefix, "+#") {
+		return nil, fmt.Errorf("invalid mqtt topic prefix %q", opts.TopicPrefix)
+	}
+	if opts.QoS > 2 {
+		return nil, fmt.Errorf("invalid mqtt qos %d", opts.QoS)
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.AssemblyTimeout <= 0 {
+		opts.AssemblyTimeout = 5 * time.Minute
+	}
+
+	i := &Ingester{
+		opts:     opts,
+		analyzer: analyzer,
+		routes:   routes,
+		logger:   logger,
+		pending:  make(map[string]*clip),
+		queue:    make(chan *clip, queueSize),
+		stopping: make(chan struct{}),
+		now:      time.Now,
+	}
+	clientOpts := mqtt.NewClientOptions().
+		AddBroker(opts.BrokerURL).
+		SetClientID(opts.ClientID).
+		SetUsername(opts.Username).
+		SetPassword(opts.Password).
+		// Сессия сохраняется, чтобы брокер придержал сообщения QoS 1 и 2 на
+		// время переподключения
+		SetCleanSession(false).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(i.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logger.Warnf("Соединение с брокером MQTT потеряно, переподключение: %v", err)
+		})
+	i.client = mqtt.NewClient(clientOpts)
+	return i, nil
+}
+
+// Start подключается к брокеру в фоне и запускает анализ клипов. Если
+// брокер недоступен, подключение повторяется.
+func (i *Ingester) Start() {
+	for n := 0; n < i.opts.Workers; n++ {
+		i.workers.Add(1)
+		go i.work()
+	}
+	go i.expire()
+	i.client.Connect()
+}
+
+// Shutdown отменяет подписку, ждет анализ принятых клипов до ctx и
+// отключается от брокера. Клипы, ожидающие второй части, теряются.
+// Возвращает false, если анализы не завершились до ctx.
+func (i *Ingester) Shutdown(ctx context.Context) bool {
+	if i.client.IsConnectionOpen() {
+		i.client.Unsubscribe(i.subscription()).WaitTimeout(publishTimeout)
+	}
+
+	i.mu.Lock()
+	i.closed = true
+	i.pending = make(map[string]*clip)
+	close(i.queue)
+	i.mu.Unlock()
+	close(i.stopping)
+
+	done := make(chan struct{})
+	go func() {
+		i.workers.Wait()
+		close(done)
+	}()
+	finished := true
+	select {
+	case <-done:
+	case <-ctx.Done():
+		finished = false
+	}
+	i.client.Disconnect(disconnectQuiesce)
+	return finished
+}
+
+// subscription фильтр топиков частей клипов
+func (i *Ingester) subscription() string {
+	return i.opts.TopicPrefix + "/+/+/+"
+}
+
+// onConnect подписывается на топики клипов, в том числе после переподключения
+func (i *Ingester) onConnect(client mqtt.Client) {
+	topic := i.subscription()
+	token := client.Subscribe(topic, i.opts.QoS, i.handleMessage)
+	if !token.WaitTimeout(publishTimeout) || token.Error() != nil {
+		i.logger.Errorf("Не удалось подписаться на %s: %v", topic, token.Error())
+		return
+	}
+	i.logger.Infof("Подключено к брокеру MQTT %s, подписка на %s", i.opts.BrokerURL, topic)
+}
+
+// handleMessage сохраняет часть клипа и, если клип собран, ставит его в
+// очередь анализа
+func (i *Ingester) handleMessage(_ mqtt.Client, msg mqtt.Message) {
+	parts := strings.Split(strings.TrimPrefix(msg.Topic(), i.opts.TopicPrefix+"/"), "/")
+	if len(parts) != 3 || parts[2] == partResult {
+		return
+	}
+	device, clipID, part := parts[0], parts[1], parts[2]
+	log := i.logger.WithFields(logrus.Fields{"device": device, "clip": clipID})
+
+	var track *Track
+	var video []byte
+	switch part {
+	case partVideo:
+		payload := msg.Payload()
+		if len(payload) == 0 {
+			i.fail(device, clipID, apierror.New(apierror.CodeInvalidRequest, "Видео клипа пустое"))
+			return
+		}
+		if i.opts.MaxClipBytes > 0 && int64(len(payload)) > i.opts.MaxClipBytes {
+			i.fail(device, clipID, apierror.New(apierror.CodeInvalidRequest, fmt.Sprintf("Видео клипа больше %d байт", i.opts.MaxClipBytes)))
+			return
+		}
+		video = append([]byte(nil), payload...)
+	case partTrack:
+		var err error
+		if track, err = parseTrack(msg.Payload()); err != nil {
+			i.fail(device, clipID, err)
+			return
+		}
+	default:
+		log.Warnf("Неизвестная часть клипа %q в топике %s", part, msg.Topic())
+		return
+	}
+
+	key := device + "/" + clipID
+	i.mu.Lock()
+	if i.closed {
+		i.mu.Unlock()
+		return
+	}
+	c, ok := i.pending[key]
+	if !ok {
+		if len(i.pending) >= maxPendingClips {
+			i.mu.Unlock()
+			i.fail(device, clipID, apierror.New(apierror.CodeRateLimited, "Слишком много клипов ожидают второй части"))
+			return
+		}
+		c = &clip{device: device, id: clipID, receivedAt: i.now()}
+		i.pending[key] = c
+	}
+	if video != nil {
+		c.video = video
+	}
+	if track != nil {
+		c.track = track
+	}
+	if c.video == nil || c.track == nil {
+		i.mu.Unlock()
+		log.Debugf("Получена часть клипа %s, ожидается вторая", part)
+		return
+	}
+	delete(i.pending, key)
+	select {
+	case i.queue <- c:
+		i.mu.Unlock()
+		log.Infof("Клип собран и поставлен в очередь анализа: %d байт видео, %d точек трека", len(c.video), len(c.track.Points))
+	default:
+		i.mu.Unlock()
+		i.fail(device, clipID, apierror.New(apierror.CodeRateLimited, "Очередь анализа клипов заполнена, клип нужно отправить позже"))
+	}
+}
+
+// parseTrack разбирает и проверяет трек клипа
+func parseTrack(payload []byte) (*Track, error) {
+	var track Track
+	if err := json.Unmarshal(payload, &track); err != nil {
+		return nil, apierror.Wrap(err, apierror.CodeInvalidRequest, "Неверный формат трека клипа")
+	}
+	track.VideoFilename = strings.TrimSpace(track.VideoFilename)
+	if track.VideoFilename == "" {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "Не указано имя видеофайла video_filename")
+	}
+	if len(track.Points) < 2 {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "Трек points должен содержать не меньше двух точек")
+	}
+	for _, p := range track.Points {
+		if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+			return nil, apierror.New(apierror.CodeInvalidCoordinates, "Координаты трека вне допустимого диапазона")
+		}
+	}
+	if !(track.SegmentLengthM > 0) {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "Длина сегмента segment_length_m должна быть положительной")
+	}
+	return &track, nil
+}
+
+// work анализирует собранные клипы, пока очередь не закрыта
+func (i *Ingester) work() {
+	defer i.workers.Done()
+	for c := range i.queue {
+		i.analyze(c)
+	}
+}
+
+// analyze анализирует клип и публикует результат устройству. Маршрут
+// строится от первой до последней точки трека, как по start и end формы
+// POST /api/v1/analyze.
+func (i *Ingester) analyze(c *clip) {
+	metadata := service.RouteMetadata{
+		Name:           c.track.Name,
+		Description:    c.track.Description,
+		Tags:           c.track.Tags,
+		OrganizationID: i.opts.OrganizationID,
+	}
+	if metadata.Description == "" {
+		metadata.Description = fmt.Sprintf("Клип %s устройства %s, получен по MQTT", c.id, c.device)
+	}
+	if err := metadata.Validate(); err != nil {
+		i.fail(c.device, c.id, err)
+		return
+	}
+
+	start, end := c.track.Points[0], c.track.Points[len(c.track.Points)-1]
+	routeID := i.routes.GenerateRouteID()
+	result, err := i.analyzer.AnalyzeRoadMarking(
+		start.Lat, start.Lon, end.Lat, end.Lon, c.track.SegmentLengthM,
+		bytes.NewReader(c.video), c.track.VideoFilename, routeID, metadata,
+	)
+	if err != nil {
+		i.fail(c.device, c.id, apierror.Wrap(err, apierror.CodeInternal, "Ошибка анализа дорожной разметки"))
+		return
+	}
+	coverage := result.OverallStats.AverageCoverage
+	i.logger.WithFields(logrus.Fields{"device": c.device, "clip": c.id}).
+		Infof("Клип проанализирован, маршрут %s, среднее покрытие %.2f%%", routeID, coverage)
+	i.publish(c.device, c.id, Result{Status: StatusCompleted, RouteID: routeID, AverageCoverage: &coverage})
+}
+
+// expire удаляет клипы, вторая часть которых не пришла за AssemblyTimeout
+func (i *Ingester) expire() {
+	ticker := time.NewTicker(i.opts.AssemblyTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-i.stopping:
+			return
+		case <-ticker.C:
+		}
+
+		var expired []*clip
+		deadline := i.now().Add(-i.opts.AssemblyTimeout)
+		i.mu.Lock()
+		for key, c := range i.pending {
+			if c.receivedAt.Before(deadline) {
+				delete(i.pending, key)
+				expired = append(expired, c)
+			}
+		}
+		i.mu.Unlock()
+
+		for _, c := range expired {
+			missing := partTrack
+			if c.video == nil {
+				missing = partVideo
+			}
+			i.fail(c.device, c.id, apierror.New(apierror.CodeInvalidRequest,
+				fmt.Sprintf("Часть клипа %s не получена за %s", missing, i.opts.AssemblyTimeout)))
+		}
+	}
+}
+
+// fail записывает ошибку клипа в лог и публикует ее устройству
+func (i *Ingester) fail(device, clipID string, err error) {
+	apiErr := apierror.Resolve(err)
+	log := i.logger.WithFields(logrus.Fields{"device": device, "clip": clipID})
+	if apiErr.Code == apierror.CodeInternal {
+		log.Errorf("Ошибка анализа клипа: %v", err)
+	} else {
+		log.Warnf("Клип не проанализирован: %s", apiErr.Message)
+	}
+	i.publish(device, clipID, Result{Status: StatusFailed, Error: apiErr.Message, Code: apiErr.Code})
+}
+
+// publish отправляет результат клипа в топик <prefix>/<устройство>/<клип>/result
+func (i *Ingester) publish(device, clipID string, result Result) {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		i.logger.Errorf("Не удалось сформировать результат клипа: %v", err)
+		return
+	}
+	topic := strings.Join([]string{i.opts.TopicPrefix, device, clipID, partResult}, "/")
+	token := i.client.Publish(topic, i.opts.QoS, false, payload)
+	if !token.WaitTimeout(publishTimeout) || token.Error() != nil {
+		i.logger.Warnf("Не удалось опубликовать результат клипа в %s: %v", topic, token.Error())
+	}
+}
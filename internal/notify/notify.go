@@ -0,0 +1,287 @@
+// Package notify отправляет оповещения по электронной почте через SMTP и
+// в чаты Telegram через Bot API.
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTelegramURL адрес Telegram Bot API
+const DefaultTelegramURL = "https://api.telegram.org"
+
+// maxResponseError сколько байт ответа Telegram включается в ошибку
+const maxResponseError = 500
+
+// Options настройки каналов оповещений. Канал выключен, если не задан
+// SMTPAddr или TelegramToken соответственно.
+type Options struct {
+	// SMTPAddr адрес SMTP сервера host:port
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	// EmailFrom адрес отправителя писем
+	EmailFrom     string
+	TelegramToken string
+	TelegramURL   string
+	// Timeout ожидание SMTP сервера или Telegram на одну отправку
+	Timeout time.Duration
+	// TemplateDir каталог шаблонов сообщений, заменяющих встроенные
+	TemplateDir string
+	// RouteURL ссылка на маршрут в сообщениях, {id} заменяется ID маршрута.
+	// Пусто — сообщения без ссылки.
+	RouteURL string
+}
+
+// Attachment вложение письма
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Email письмо. HTML, если задан, показывается вместо Text почтовыми
+// клиентами, которые его поддерживают.
+type Email struct {
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Notifier отправляет оповещения по включенным каналам
+type Notifier struct {
+	opts      Options
+	http      *http.Client
+	templates *Templates
+}
+
+// New создает Notifier и загружает шаблоны сообщений
+func New(opts Options) (*Notifier, error) {
+	if opts.TelegramURL == "" {
+		opts.TelegramURL = DefaultTelegramURL
+	}
+	opts.TelegramURL = strings.TrimRight(opts.TelegramURL, "/")
+	templates, err := LoadTemplates(opts.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Notifier{opts: opts, http: &http.Client{Timeout: opts.Timeout}, templates: templates}, nil
+}
+
+// Render заполняет шаблон сообщения name и возвращает тему и текст
+func (n *Notifier) Render(name string, data interface{}) (string, string, error) {
+	return n.templates.Render(name, data)
+}
+
+// RouteURL возвращает ссылку на маршрут, пусто — ссылка не настроена
+func (n *Notifier) RouteURL(routeID string) string {
+	if n == nil || n.opts.RouteURL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(n.opts.RouteURL, "{id}", url.PathEscape(routeID))
+}
+
+// EmailEnabled проверяет, включена ли отправка писем
+func (n *Notifier) EmailEnabled() bool {
+	return n != nil && n.opts.SMTPAddr != ""
+}
+
+// TelegramEnabled проверяет, включена ли отправка в Telegram
+func (n *Notifier) TelegramEnabled() bool {
+	return n != nil && n.opts.TelegramToken != ""
+}
+
+// SendEmail отправляет письмо с текстом body получателям to
+func (n *Notifier) SendEmail(to []string, subject, body string) error {
+	return n.Send(Email{To: to, Subject: subject, Text: body})
+}
+
+// Send отправляет письмо. Если сервер поддерживает STARTTLS, соединение
+// шифруется, а при заданном имени пользователя выполняется вход.
+func (n *Notifier) Send(email Email) error {
+	if !n.EmailEnabled() {
+		return errors.New("email notifications are not configured")
+	}
+	host, _, err := net.SplitHostPort(n.opts.SMTPAddr)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", n.opts.SMTPAddr, n.opts.Timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if n.opts.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(n.opts.Timeout))
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("smtp starttls failed: %w", err)
+		}
+	}
+	if n.opts.SMTPUsername != "" {
+		auth := smtp.PlainAuth("", n.opts.SMTPUsername, n.opts.SMTPPassword, host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(n.opts.EmailFrom); err != nil {
+		return fmt.Errorf("smtp sender rejected: %w", err)
+	}
+	for _, recipient := range email.To {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("smtp recipient %s rejected: %w", recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data failed: %w", err)
+	}
+	data, err := message(n.opts.EmailFrom, email)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp server rejected email: %w", err)
+	}
+	return client.Quit()
+}
+
+// message формирует письмо с заголовками. Письмо только с текстом
+// отправляется одной частью, с HTML или вложениями — частями multipart.
+func message(from string, email Email) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	if email.HTML == "" && len(email.Attachments) == 0 {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		writeQuotedPrintable(&buf, email.Text)
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mixed.Boundary())
+
+	var body bytes.Buffer
+	alternative := multipart.NewWriter(&body)
+	parts := []struct{ contentType, content string }{{"text/plain", email.Text}}
+	if email.HTML != "" {
+		parts = append(parts, struct{ contentType, content string }{"text/html", email.HTML})
+	}
+	for _, part := range parts {
+		w, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		writeQuotedPrintable(w, part.content)
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+	w, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+	_, _ = w.Write(body.Bytes())
+
+	for _, attachment := range email.Attachments {
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		writeBase64(w, attachment.Data)
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable пишет текст в quoted-printable с переводами строк CRLF
+func writeQuotedPrintable(w io.Writer, text string) {
+	qp := quotedprintable.NewWriter(w)
+	_, _ = qp.Write([]byte(strings.ReplaceAll(text, "\n", "\r\n")))
+	_ = qp.Close()
+}
+
+// writeBase64 пишет данные в base64 строками по 76 символов
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		_, _ = io.WriteString(w, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	_, _ = io.WriteString(w, encoded+"\r\n")
+}
+
+// SendTelegram отправляет сообщение text в чат chatID
+func (n *Notifier) SendTelegram(chatID, text string) error {
+	if !n.TelegramEnabled() {
+		return errors.New("telegram notifications are not configured")
+	}
+	body, err := json.Marshal(map[string]string{"chat_id": chatID, "text": text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal telegram message: %w", err)
+	}
+
+	endpoint := n.opts.TelegramURL + "/bot" + n.opts.TelegramToken + "/sendMessage"
+	resp, err := n.http.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// Адрес запроса содержит токен бота и в ошибку не попадает
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send telegram message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseError))
+		return fmt.Errorf("telegram returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseError))
+	return nil
+}
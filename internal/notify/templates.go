@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Имена шаблонов сообщений. Шаблон задает блоки subject (тема, одна строка)
+// и text (текст письма или сообщения в чат).
+const (
+	TemplateAnalysisCompleted = "analysis_completed"
+	TemplateAlert             = "alert"
+	TemplateReport            = "report"
+)
+
+// AnalysisMessage данные шаблона analysis_completed
+type AnalysisMessage struct {
+	RouteID  string
+	Name     string
+	RoadName string
+	// URL ссылка на маршрут, пусто — ссылка не настроена
+	URL              string
+	DistanceKm       float64
+	TotalSegments    int
+	SegmentsWithData int
+	AverageCoverage  float64
+	// Band полоса среднего покрытия, пусто — полосы не заданы
+	Band         string
+	TotalDefects int
+	// QualityScore индекс качества, nil — не рассчитан
+	QualityScore *float64
+}
+
+// AlertSegment сегмент в данных шаблона alert
+type AlertSegment struct {
+	SegmentID          int
+	CoveragePercentage float64
+	DefectSeverity     string
+}
+
+// AlertMessage данные шаблона alert
+type AlertMessage struct {
+	RuleName      string
+	RouteID       string
+	URL           string
+	SegmentsCount int
+	// Segments первые сегменты оповещения, More — сколько не вошло
+	Segments []AlertSegment
+	More     int
+}
+
+// ReportMessage данные шаблона report
+type ReportMessage struct {
+	Title string
+	// From и To первый и последний день периода
+	From time.Time
+	To   time.Time
+	// Summary текстовая сводка отчета
+	Summary string
+}
+
+// templateFuncs функции, доступные шаблонам
+var templateFuncs = template.FuncMap{
+	"date":  func(t time.Time) string { return t.Format("02.01.2006") },
+	"value": func(v *float64) float64 { return *v },
+}
+
+// defaultTemplates встроенные шаблоны сообщений
+var defaultTemplates = map[string]string{
+	TemplateAnalysisCompleted: `{{define "subject"}}Анализ завершен: {{.Name}}{{end}}
+{{define "text"}}Маршрут: {{.Name}}{{with .RoadName}} ({{.}}){{end}}
+Протяженность: {{printf "%.2f" .DistanceKm}} км
+Сегментов: {{.TotalSegments}}, с данными: {{.SegmentsWithData}}
+Среднее покрытие: {{printf "%.1f%%" .AverageCoverage}}{{with .Band}} ({{.}}){{end}}
+{{with .QualityScore}}Индекс качества: {{printf "%.0f" (value .)}} из 100
+{{end}}Дефектов разметки: {{.TotalDefects}}
+{{with .URL}}
+Открыть маршрут: {{.}}
+{{end}}{{end}}`,
+
+	TemplateAlert: `{{define "subject"}}Оповещение «{{.RuleName}}»: {{.SegmentsCount}} сегм. маршрута {{.RouteID}}{{end}}
+{{define "text"}}Правило: {{.RuleName}}
+Маршрут: {{.RouteID}}
+Сегменты:
+{{range .Segments}}- №{{.SegmentID}}: покрытие {{printf "%.1f%%" .CoveragePercentage}}{{with .DefectSeverity}}, дефекты: {{.}}{{end}}
+{{end}}{{if .More}}и еще {{.More}}
+{{end}}{{with .URL}}
+Открыть маршрут: {{.}}
+{{end}}{{end}}`,
+
+	TemplateReport: `{{define "subject"}}{{.Title}}: {{date .From}} — {{date .To}}{{end}}
+{{define "text"}}{{.Summary}}{{end}}`,
+}
+
+// Templates шаблоны сообщений text/template
+type Templates struct {
+	set map[string]*template.Template
+}
+
+// LoadTemplates загружает встроенные шаблоны и заменяет их файлами
+// <имя>.tmpl из каталога dir, если он задан
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{set: make(map[string]*template.Template, len(defaultTemplates))}
+	for name, text := range defaultTemplates {
+		if dir != "" {
+			data, err := os.ReadFile(filepath.Join(dir, name+".tmpl"))
+			switch {
+			case err == nil:
+				text = string(data)
+			case !errors.Is(err, os.ErrNotExist):
+				return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+			}
+		}
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+		for _, block := range []string{"subject", "text"} {
+			if tmpl.Lookup(block) == nil {
+				return nil, fmt.Errorf("template %s must define %q", name, block)
+			}
+		}
+		t.set[name] = tmpl
+	}
+	return t, nil
+}
+
+// Render заполняет шаблон name данными data и возвращает тему и текст.
+// Переводы строк в теме заменяются пробелами.
+func (t *Templates) Render(name string, data interface{}) (string, string, error) {
+	tmpl, ok := t.set[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown template %s", name)
+	}
+	var subject, text strings.Builder
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.ExecuteTemplate(&text, "text", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	return strings.Join(strings.Fields(subject.String()), " "), strings.TrimSpace(text.String()) + "\n", nil
+}
@@ -0,0 +1,313 @@
+// Package oidc проверяет токены доступа, выпущенные внешним OpenID Connect
+// провайдером (например, Keycloak), по ключам из его JWKS.
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/jwt"
+)
+
+// ErrInvalidToken возвращается, если токен не выпущен настроенным провайдером
+// или не прошел проверку
+var ErrInvalidToken = errors.New("invalid oidc token")
+
+// ErrProviderUnavailable возвращается, если не удалось загрузить ключи провайдера
+var ErrProviderUnavailable = errors.New("oidc provider unavailable")
+
+const (
+	// keysTTL как долго используются загруженные ключи без обновления
+	keysTTL = time.Hour
+	// minRefreshInterval не чаще этого интервала ключи перезагружаются
+	// из-за неизвестного kid
+	minRefreshInterval = 30 * time.Second
+	// maxDocumentSize ограничение размера ответов провайдера
+	maxDocumentSize = 1 << 20
+)
+
+// Options настройки проверки токенов
+type Options struct {
+	// IssuerURL издатель токенов, например https://sso.example.com/realms/roads
+	IssuerURL string
+	// ClientID ожидаемый получатель токена (aud или azp). Пусто — не проверяется.
+	ClientID string
+	// RolesClaim путь к списку ролей в токене через точку, например realm_access.roles
+	RolesClaim string
+	// AdminRole роль, дающая права администратора
+	AdminRole string
+	Timeout   time.Duration
+}
+
+// Identity пользователь, подтвержденный токеном провайдера
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Roles         []string
+	Admin         bool
+}
+
+// Provider проверяет токены провайдера. Адрес JWKS определяется через
+// /.well-known/openid-configuration, ключи кэшируются.
+type Provider struct {
+	opts Options
+	http *http.Client
+
+	mu        sync.Mutex
+	jwksURI   string
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	// attemptedAt время последней попытки загрузки, в том числе неудачной
+	attemptedAt time.Time
+}
+
+// New создает Provider. Провайдер не опрашивается до первой проверки токена,
+// чтобы его недоступность не мешала запуску сервера.
+func New(opts Options) (*Provider, error) {
+	opts.IssuerURL = strings.TrimRight(opts.IssuerURL, "/")
+	if opts.IssuerURL == "" {
+		return nil, errors.New("oidc issuer url is required")
+	}
+	if opts.RolesClaim == "" {
+		opts.RolesClaim = "realm_access.roles"
+	}
+	if opts.AdminRole == "" {
+		opts.AdminRole = "admin"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	return &Provider{
+		opts: opts,
+		http: &http.Client{Timeout: opts.Timeout},
+	}, nil
+}
+
+// Issuer возвращает издателя токенов
+func (p *Provider) Issuer() string {
+	return p.opts.IssuerURL
+}
+
+// Verify проверяет подпись, издателя, получателя и срок действия токена
+// и возвращает пользователя из него
+func (p *Provider) Verify(token string) (*Identity, error) {
+	parsed, err := jwt.Decode(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if strings.TrimRight(parsed.Claims.Issuer, "/") != p.opts.IssuerURL {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, parsed.Claims.Issuer)
+	}
+
+	key, err := p.key(parsed.Header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.VerifyRSA(key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := parsed.ValidateTime(time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if p.opts.ClientID != "" && !parsed.Claims.Audience.Contains(p.opts.ClientID) &&
+		stringClaim(parsed.Raw, "azp") != p.opts.ClientID {
+		return nil, fmt.Errorf("%w: token is not issued for client %q", ErrInvalidToken, p.opts.ClientID)
+	}
+	if parsed.Claims.Subject == "" {
+		return nil, fmt.Errorf("%w: sub is required", ErrInvalidToken)
+	}
+
+	identity := &Identity{
+		Issuer:  p.opts.IssuerURL,
+		Subject: parsed.Claims.Subject,
+		Email:   strings.ToLower(parsed.Claims.Email),
+		Roles:   stringsClaim(parsed.Raw, p.opts.RolesClaim),
+	}
+	identity.EmailVerified, _ = parsed.Raw["email_verified"].(bool)
+	for _, role := range identity.Roles {
+		if role == p.opts.AdminRole {
+			identity.Admin = true
+			break
+		}
+	}
+	return identity, nil
+}
+
+// key возвращает ключ подписи kid, при необходимости загружая ключи провайдера
+func (p *Provider) key(kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	canRefresh := time.Since(p.attemptedAt) > minRefreshInterval
+
+	// Устаревшие ключи продолжают использоваться, если провайдер недоступен
+	if time.Since(p.fetchedAt) > keysTTL && canRefresh {
+		if err := p.refresh(); err != nil && p.keys == nil {
+			return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+		}
+		canRefresh = false
+	}
+	if p.keys == nil {
+		return nil, fmt.Errorf("%w: signing keys are not loaded yet", ErrProviderUnavailable)
+	}
+	if key := p.lookup(kid); key != nil {
+		return key, nil
+	}
+
+	// Провайдер мог сменить ключи, перезагружаем их, но не чаще minRefreshInterval
+	if canRefresh {
+		if err := p.refresh(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+		}
+		if key := p.lookup(kid); key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+}
+
+// lookup ищет ключ среди загруженных. Пустой kid допустим, если ключ один.
+func (p *Provider) lookup(kid string) *rsa.PublicKey {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+// refresh загружает адрес JWKS и ключи провайдера
+func (p *Provider) refresh() error {
+	p.attemptedAt = time.Now()
+	if p.jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := p.getJSON(p.opts.IssuerURL+"/.well-known/openid-configuration", &discovery); err != nil {
+			return fmt.Errorf("failed to load oidc discovery document: %w", err)
+		}
+		if strings.TrimRight(discovery.Issuer, "/") != p.opts.IssuerURL {
+			return fmt.Errorf("oidc discovery issuer %q does not match %q", discovery.Issuer, p.opts.IssuerURL)
+		}
+		if discovery.JWKSURI == "" {
+			return errors.New("oidc discovery document has no jwks_uri")
+		}
+		p.jwksURI = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(p.jwksURI, &jwks); err != nil {
+		return fmt.Errorf("failed to load oidc signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("oidc provider has no rsa signing keys")
+	}
+
+	p.keys = keys
+	p.fetchedAt = time.Now()
+	return nil
+}
+
+// getJSON выполняет GET запрос и разбирает JSON ответ
+func (p *Provider) getJSON(url string, target interface{}) error {
+	resp, err := p.http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, target)
+}
+
+// jsonWebKey ключ из JWKS
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 2 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("bad rsa exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+// stringClaim возвращает строковое значение claim верхнего уровня
+func stringClaim(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// stringsClaim возвращает список строк по пути через точку, например
+// realm_access.roles или resource_access.road-detector.roles
+func stringsClaim(claims map[string]interface{}, path string) []string {
+	var value interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+
+	switch typed := value.(type) {
+	case []interface{}:
+		result := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	case string:
+		return strings.Fields(typed)
+	default:
+		return nil
+	}
+}
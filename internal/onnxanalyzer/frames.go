@@ -0,0 +1,109 @@
+package onnxanalyzer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"road-detector-go/internal/model"
+)
+
+// maxFFmpegErrorOutput максимальная длина вывода ffmpeg в тексте ошибки
+const maxFFmpegErrorOutput = 500
+
+// frameOptions как извлекать кадры
+type frameOptions struct {
+	fps           float64
+	width, height int
+	// roi область кадра в долях, nil — весь кадр
+	roi *model.ROI
+}
+
+// extractFrames декодирует видео ffmpeg и передает fn кадры RGB размера
+// width x height по мере декодирования. Видео записывается во временный
+// файл: MP4 с индексом в конце файла ffmpeg не может прочитать из потока.
+func extractFrames(ctx context.Context, ffmpeg string, video []byte, opts frameOptions, fn func(frame []byte) error) error {
+	tmp, err := os.CreateTemp("", "onnx-video-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(video)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-i", tmp.Name(),
+		"-vf", frameFilter(opts),
+		"-f", "rawvideo", "-pix_fmt", "rgb24", "pipe:1",
+	)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	frame := make([]byte, opts.width*opts.height*3)
+	var readErr error
+	for {
+		if _, readErr = io.ReadFull(stdout, frame); readErr != nil {
+			break
+		}
+		if readErr = fn(frame); readErr != nil {
+			// Остальные кадры не нужны, ffmpeg завершается отменой ctx
+			cancel()
+			break
+		}
+	}
+	waitErr := cmd.Wait()
+
+	if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+		return readErr
+	}
+	if waitErr != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("ffmpeg interrupted: %w", ctx.Err())
+		}
+		output := strings.TrimSpace(stderr.String())
+		if len(output) > maxFFmpegErrorOutput {
+			output = output[:maxFFmpegErrorOutput] + "..."
+		}
+		return fmt.Errorf("%w: ffmpeg failed to decode video: %v: %s", ErrInvalidInput, waitErr, output)
+	}
+	return nil
+}
+
+// frameFilter цепочка фильтров ffmpeg: частота кадров, область кадра и
+// масштабирование под вход модели
+func frameFilter(opts frameOptions) string {
+	filters := []string{"fps=" + strconv.FormatFloat(opts.fps, 'f', -1, 64)}
+	if roi := opts.roi; roi != nil {
+		filters = append(filters, fmt.Sprintf("crop=iw*%s:ih*%s:iw*%s:ih*%s",
+			formatFraction(roi.Width), formatFraction(roi.Height), formatFraction(roi.X), formatFraction(roi.Y)))
+	}
+	filters = append(filters, fmt.Sprintf("scale=%d:%d", opts.width, opts.height))
+	return strings.Join(filters, ",")
+}
+
+// formatFraction форматирует долю кадра для выражения ffmpeg
+func formatFraction(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
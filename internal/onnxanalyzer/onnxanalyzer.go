@@ -0,0 +1,192 @@
+// Package onnxanalyzer экспериментальный анализ дорожной разметки без
+// Python сервиса: кадры видео извлекаются ffmpeg, модель сегментации
+// выполняется через ONNX Runtime в процессе сервера.
+//
+// Привязки ONNX Runtime подключаются тегом сборки onnx:
+//
+//	go get github.com/yalue/onnxruntime_go
+//	go build -tags onnx ./cmd/server
+//
+// Без тега New возвращает ErrNotBuilt.
+package onnxanalyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+
+	"road-detector-go/internal/model"
+)
+
+// Ошибки локального анализа
+var (
+	// ErrNotBuilt сервер собран без поддержки ONNX Runtime
+	ErrNotBuilt = errors.New("onnx runtime support is not built in, rebuild with -tags onnx")
+	// ErrInvalidInput видео или параметры анализа не подходят для локального
+	// анализа
+	ErrInvalidInput = errors.New("invalid input for local analysis")
+)
+
+// Options настройки локального анализа
+type Options struct {
+	// ModelPath путь к модели сегментации в формате ONNX. Модель принимает
+	// тензор [1, 3, InputHeight, InputWidth] RGB в диапазоне 0..1 и
+	// возвращает вероятности [1, C, InputHeight, InputWidth]: при C = 1 —
+	// вероятность разметки, иначе канал 1 — класс разметки.
+	ModelPath string
+	// RuntimeLibrary путь к разделяемой библиотеке onnxruntime, пусто —
+	// поиск библиотеки по умолчанию
+	RuntimeLibrary string
+	// FFmpegPath путь к ffmpeg, пусто — ffmpeg из PATH
+	FFmpegPath string
+	// SampleFPS сколько кадров в секунду видео анализируется
+	SampleFPS float64
+	// InputWidth и InputHeight размер входа модели, кадры масштабируются под него
+	InputWidth  int
+	InputHeight int
+	// Threshold минимальная вероятность, с которой пиксель считается разметкой
+	Threshold float64
+	// MinMarkingPercent доля пикселей разметки в процентах, начиная с
+	// которой на кадре есть разметка
+	MinMarkingPercent float64
+}
+
+// Analyzer локальный анализ видео моделью ONNX. Сессия модели одна,
+// кадры разных анализов обрабатываются по очереди.
+type Analyzer struct {
+	opts   Options
+	ffmpeg string
+
+	mu      sync.Mutex
+	session session
+}
+
+// session сессия модели сегментации. Run возвращает вероятности разметки
+// для каждого пикселя кадра.
+type session interface {
+	Run(input []float32) ([]float32, error)
+	Close() error
+}
+
+// New загружает модель и проверяет, что ffmpeg доступен
+func New(opts Options) (*Analyzer, error) {
+	if opts.FFmpegPath == "" {
+		opts.FFmpegPath = "ffmpeg"
+	}
+	ffmpeg, err := exec.LookPath(opts.FFmpegPath)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	if _, err := os.Stat(opts.ModelPath); err != nil {
+		return nil, fmt.Errorf("failed to open model: %w", err)
+	}
+
+	sess, err := newSession(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Analyzer{opts: opts, ffmpeg: ffmpeg, session: sess}, nil
+}
+
+// Analyze извлекает кадры видео и возвращает результат каждого кадра:
+// 1 — разметка есть, 0 — нет. Параметры анализа заменяют частоту кадров,
+// порог вероятности и область кадра; выбор варианта модели не
+// поддерживается.
+func (a *Analyzer) Analyze(ctx context.Context, video []byte, params model.AnalysisParams) ([]int, error) {
+	if params.ModelVariant != "" {
+		return nil, fmt.Errorf("%w: model_variant is not supported by local analysis", ErrInvalidInput)
+	}
+	fps := a.opts.SampleFPS
+	if params.FrameSampleRate > 0 {
+		fps = params.FrameSampleRate
+	}
+	threshold := a.opts.Threshold
+	if params.ConfidenceThreshold > 0 {
+		threshold = params.ConfidenceThreshold
+	}
+
+	var results []int
+	err := extractFrames(ctx, a.ffmpeg, video, frameOptions{
+		fps:    fps,
+		width:  a.opts.InputWidth,
+		height: a.opts.InputHeight,
+		roi:    params.ROI,
+	}, func(frame []byte) error {
+		marked, err := a.classify(frame, threshold)
+		if err != nil {
+			return err
+		}
+		result := 0
+		if marked {
+			result = 1
+		}
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w: no frames extracted from video", ErrInvalidInput)
+	}
+	return results, nil
+}
+
+// classify проверяет, что на кадре RGB есть разметка
+func (a *Analyzer) classify(frame []byte, threshold float64) (bool, error) {
+	pixels := a.opts.InputWidth * a.opts.InputHeight
+	input := make([]float32, 3*pixels)
+	for i := 0; i < pixels; i++ {
+		for c := 0; c < 3; c++ {
+			input[c*pixels+i] = float32(frame[i*3+c]) / 255
+		}
+	}
+
+	a.mu.Lock()
+	output, err := a.session.Run(input)
+	a.mu.Unlock()
+	if err != nil {
+		return false, fmt.Errorf("failed to run model: %w", err)
+	}
+
+	// При нескольких каналах разметка — канал 1
+	probs := output
+	if len(output) >= 2*pixels {
+		probs = output[pixels : 2*pixels]
+	}
+	if len(probs) < pixels {
+		return false, fmt.Errorf("unexpected model output size %d for %d pixels", len(output), pixels)
+	}
+	marked := 0
+	for _, p := range probs[:pixels] {
+		if float64(p) >= threshold {
+			marked++
+		}
+	}
+	return float64(marked)*100/float64(pixels) >= a.opts.MinMarkingPercent, nil
+}
+
+// Check проверяет, что ffmpeg по-прежнему доступен, и возвращает
+// настройки анализа для проверки готовности
+func (a *Analyzer) Check() (map[string]interface{}, error) {
+	details := map[string]interface{}{
+		"model":      a.opts.ModelPath,
+		"ffmpeg":     a.ffmpeg,
+		"input_size": fmt.Sprintf("%dx%d", a.opts.InputWidth, a.opts.InputHeight),
+		"sample_fps": a.opts.SampleFPS,
+	}
+	if _, err := os.Stat(a.ffmpeg); err != nil {
+		return details, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	return details, nil
+}
+
+// Close освобождает сессию модели
+func (a *Analyzer) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.session.Close()
+}
@@ -0,0 +1,79 @@
+//go:build onnx
+
+package onnxanalyzer
+
+import (
+	"fmt"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// onnxSession сессия ONNX Runtime с тензорами входа и выхода,
+// выделенными один раз
+type onnxSession struct {
+	session *ort.AdvancedSession
+	input   *ort.Tensor[float32]
+	output  *ort.Tensor[float32]
+}
+
+// newSession инициализирует ONNX Runtime и загружает модель. Имена и
+// число каналов выхода берутся из модели.
+func newSession(opts Options) (session, error) {
+	if opts.RuntimeLibrary != "" {
+		ort.SetSharedLibraryPath(opts.RuntimeLibrary)
+	}
+	if !ort.IsInitialized() {
+		if err := ort.InitializeEnvironment(); err != nil {
+			return nil, fmt.Errorf("failed to initialize onnx runtime: %w", err)
+		}
+	}
+
+	inputs, outputs, err := ort.GetInputOutputInfo(opts.ModelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model inputs: %w", err)
+	}
+	if len(inputs) != 1 || len(outputs) == 0 {
+		return nil, fmt.Errorf("model must have one input and at least one output, got %d and %d", len(inputs), len(outputs))
+	}
+	channels := int64(1)
+	if dims := outputs[0].Dimensions; len(dims) == 4 && dims[1] > 0 {
+		channels = dims[1]
+	}
+
+	h, w := int64(opts.InputHeight), int64(opts.InputWidth)
+	input, err := ort.NewEmptyTensor[float32](ort.NewShape(1, 3, h, w))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input tensor: %w", err)
+	}
+	output, err := ort.NewEmptyTensor[float32](ort.NewShape(1, channels, h, w))
+	if err != nil {
+		input.Destroy()
+		return nil, fmt.Errorf("failed to create output tensor: %w", err)
+	}
+	sess, err := ort.NewAdvancedSession(opts.ModelPath,
+		[]string{inputs[0].Name}, []string{outputs[0].Name},
+		[]ort.Value{input}, []ort.Value{output}, nil)
+	if err != nil {
+		input.Destroy()
+		output.Destroy()
+		return nil, fmt.Errorf("failed to load model: %w", err)
+	}
+	return &onnxSession{session: sess, input: input, output: output}, nil
+}
+
+// Run выполняет модель на одном кадре
+func (s *onnxSession) Run(input []float32) ([]float32, error) {
+	copy(s.input.GetData(), input)
+	if err := s.session.Run(); err != nil {
+		return nil, err
+	}
+	return s.output.GetData(), nil
+}
+
+// Close освобождает сессию и тензоры
+func (s *onnxSession) Close() error {
+	err := s.session.Destroy()
+	s.input.Destroy()
+	s.output.Destroy()
+	return err
+}
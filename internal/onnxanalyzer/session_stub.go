@@ -0,0 +1,8 @@
+//go:build !onnx
+
+package onnxanalyzer
+
+// newSession без тега onnx привязки ONNX Runtime недоступны
+func newSession(Options) (session, error) {
+	return nil, ErrNotBuilt
+}
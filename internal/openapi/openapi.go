@@ -0,0 +1,392 @@
+// Package openapi собирает документ OpenAPI 3.0 по маршрутам, которые
+// зарегистрированы в gin. Пути, методы и параметры пути берутся из
+// маршрутов, поэтому документ не расходится с сервером; описания операций,
+// параметры запроса и тела задаются таблицей Spec, а схемы тел строятся по
+// типам Go.
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version версия спецификации OpenAPI документа
+const Version = "3.0.3"
+
+// Document документ OpenAPI
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info описание API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag группа операций
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Components схемы тел и способы авторизации
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme способ авторизации
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Operation операция документа
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter параметр пути или запроса
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody тело запроса
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType содержимое тела определенного типа
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Response ответ операции
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Param параметр запроса или поле формы в описании операции
+type Param struct {
+	Name string
+	// Type string, integer, number или boolean, пусто — string
+	Type        string
+	Description string
+	Required    bool
+	Default     interface{}
+	Enum        []string
+	// Array параметр можно передать несколько раз
+	Array bool
+	// File поле формы с файлом
+	File bool
+}
+
+// Spec описание операции. Ключ таблицы описаний — метод и шаблон пути gin,
+// как в журнале аудита: "POST /api/v1/analyze".
+type Spec struct {
+	// OperationID имя операции, пусто — имя обработчика
+	OperationID string
+	Summary     string
+	Description string
+	// Tag группа операции, пусто — первая часть пути после версии API
+	Tag   string
+	Query []Param
+	// Body значение типа JSON тела запроса
+	Body interface{}
+	// BodyDescription описание тела, если схемы типа недостаточно
+	BodyDescription string
+	// Form поля multipart/form-data тела запроса
+	Form []Param
+	// Status код успешного ответа, 0 — 200
+	Status int
+	// Response значение типа JSON тела успешного ответа
+	Response interface{}
+	// Produces тип содержимого ответа, если это не JSON
+	Produces string
+	// Public операция доступна без ключа и токена
+	Public bool
+}
+
+// Options параметры документа
+type Options struct {
+	Title       string
+	Description string
+	Version     string
+	// Public пути, доступные без ключа и токена; * в конце — префикс
+	Public []string
+	// APIKeyHeader заголовок с ключом API
+	APIKeyHeader string
+	// ErrorBody значение типа тела ответа с ошибкой
+	ErrorBody interface{}
+	// ProblemBody значение типа тела ответа с ошибкой в формате RFC 7807
+	// для путей с префиксами ProblemPrefixes
+	ProblemBody     interface{}
+	ProblemPrefixes []string
+	// Skip пути, которые не попадают в документ; * в конце — префикс
+	Skip []string
+}
+
+// Result документ и расхождения таблицы описаний с маршрутами
+type Result struct {
+	Document *Document
+	// Undocumented маршруты без описания; в документ они попадают без
+	// параметров запроса и тел
+	Undocumented []string
+	// Stale описания, для которых нет маршрута
+	Stale []string
+}
+
+// Build собирает документ по маршрутам routes и описаниям specs
+func Build(routes gin.RoutesInfo, specs map[string]Spec, opts Options) Result {
+	s := &schemas{components: make(map[string]*Schema)}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: opts.Title, Description: opts.Description, Version: opts.Version},
+		Paths:   make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas: s.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+				"apiKeyAuth": {Type: "apiKey", In: "header", Name: opts.APIKeyHeader},
+			},
+		},
+	}
+	errorSchema := s.ref(opts.ErrorBody)
+	problemSchema := s.ref(opts.ProblemBody)
+
+	var result Result
+	used := make(map[string]bool)
+	operationIDs := make(map[string]bool)
+	tags := make(map[string]bool)
+	for _, route := range routes {
+		if route.Method == http.MethodHead || matchPath(route.Path, opts.Skip) {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		spec, ok := specs[key]
+		if ok {
+			used[key] = true
+		} else {
+			result.Undocumented = append(result.Undocumented, key)
+		}
+
+		path, params := convertPath(route.Path)
+		op := &Operation{
+			OperationID: operationID(route, spec.OperationID, operationIDs),
+			Summary:     spec.Summary,
+			Description: spec.Description,
+			Tags:        []string{tagFor(route.Path, spec.Tag)},
+			Parameters:  params,
+			Responses:   make(map[string]*Response),
+		}
+		tags[op.Tags[0]] = true
+		for _, param := range spec.Query {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:        param.Name,
+				In:          "query",
+				Description: param.Description,
+				Required:    param.Required,
+				Schema:      paramSchema(param),
+			})
+		}
+
+		switch {
+		case len(spec.Form) > 0:
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+				"multipart/form-data": {Schema: formSchema(spec.Form)},
+			}}
+		case spec.Body != nil:
+			op.RequestBody = &RequestBody{Description: spec.BodyDescription, Required: true, Content: map[string]*MediaType{
+				"application/json": {Schema: s.ref(spec.Body)},
+			}}
+		}
+
+		status := spec.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &Response{Description: http.StatusText(status)}
+		switch {
+		case spec.Produces != "":
+			success.Content = map[string]*MediaType{spec.Produces: {Schema: &Schema{Type: "string", Format: "binary"}}}
+		case spec.Response != nil:
+			success.Content = map[string]*MediaType{"application/json": {Schema: s.ref(spec.Response)}}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+		switch {
+		case problemSchema != nil && matchPrefix(route.Path, opts.ProblemPrefixes):
+			op.Responses["default"] = &Response{
+				Description: "Ошибка",
+				Content:     map[string]*MediaType{"application/problem+json": {Schema: problemSchema}},
+			}
+		case errorSchema != nil:
+			op.Responses["default"] = &Response{
+				Description: "Ошибка",
+				Content:     map[string]*MediaType{"application/json": {Schema: errorSchema}},
+			}
+		}
+
+		if strings.HasPrefix(route.Path, "/api/") && !spec.Public && !matchPath(route.Path, opts.Public) {
+			op.Security = []map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	for key := range specs {
+		if !used[key] {
+			result.Stale = append(result.Stale, key)
+		}
+	}
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	sort.Strings(result.Undocumented)
+	sort.Strings(result.Stale)
+	result.Document = doc
+	return result
+}
+
+// convertPath переводит шаблон пути gin в шаблон OpenAPI и возвращает
+// параметры пути
+func convertPath(path string) (string, []Parameter) {
+	var params []Parameter
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if len(part) < 2 || (part[0] != ':' && part[0] != '*') {
+			continue
+		}
+		name := part[1:]
+		parts[i] = "{" + name + "}"
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// operationID заданное имя операции или имя обработчика без пакета,
+// например RouteHandler.GetRoute. Для второго метода того же обработчика
+// добавляется метод.
+func operationID(route gin.RouteInfo, name string, seen map[string]bool) string {
+	if name == "" {
+		name = operationName(route.Handler)
+	}
+	if seen[name] {
+		name += "_" + strings.ToLower(route.Method)
+	}
+	seen[name] = true
+	return name
+}
+
+// operationName имя обработчика без пакета и суффиксов метода
+func operationName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if _, rest, ok := strings.Cut(name, "."); ok {
+		name = rest
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
+
+// tagFor возвращает группу операции: заданную или первую часть пути после
+// версии API. Группы версий, кроме v1, начинаются с версии: v2/routes.
+func tagFor(path, tag string) string {
+	if tag != "" {
+		return tag
+	}
+	rest, ok := strings.CutPrefix(path, "/api/")
+	if !ok {
+		return "service"
+	}
+	version, rest, _ := strings.Cut(rest, "/")
+	first, _, _ := strings.Cut(rest, "/")
+	if version == "v1" {
+		return first
+	}
+	return version + "/" + first
+}
+
+// matchPrefix проверяет, начинается ли путь с одного из префиксов
+func matchPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath проверяет путь по списку шаблонов; * в конце — префикс
+func matchPath(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+			continue
+		}
+		if path == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// paramSchema схема параметра запроса или поля формы
+func paramSchema(param Param) *Schema {
+	schema := &Schema{Type: param.Type, Enum: param.Enum, Default: param.Default}
+	if param.Type == "" {
+		schema.Type = "string"
+	}
+	if param.File {
+		schema = &Schema{Type: "string", Format: "binary"}
+	}
+	if param.Array {
+		return &Schema{Type: "array", Items: schema}
+	}
+	return schema
+}
+
+// formSchema схема тела multipart/form-data
+func formSchema(fields []Param) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, field := range fields {
+		prop := paramSchema(field)
+		prop.Description = field.Description
+		schema.Properties[field.Name] = prop
+		if field.Required {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+	return schema
+}
@@ -0,0 +1,1346 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.1
+// source: roaddetector/road_detector.proto
+
+package roaddetector
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Статус анализа
+type JobStatus int32
+
+const (
+	JobStatus_JOB_STATUS_UNSPECIFIED JobStatus = 0
+	JobStatus_JOB_STATUS_RUNNING     JobStatus = 1
+	JobStatus_JOB_STATUS_COMPLETED   JobStatus = 2
+	JobStatus_JOB_STATUS_FAILED      JobStatus = 3
+)
+
+// Enum value maps for JobStatus.
+var (
+	JobStatus_name = map[int32]string{
+		0: "JOB_STATUS_UNSPECIFIED",
+		1: "JOB_STATUS_RUNNING",
+		2: "JOB_STATUS_COMPLETED",
+		3: "JOB_STATUS_FAILED",
+	}
+	JobStatus_value = map[string]int32{
+		"JOB_STATUS_UNSPECIFIED": 0,
+		"JOB_STATUS_RUNNING":     1,
+		"JOB_STATUS_COMPLETED":   2,
+		"JOB_STATUS_FAILED":      3,
+	}
+)
+
+func (x JobStatus) Enum() *JobStatus {
+	p := new(JobStatus)
+	*p = x
+	return p
+}
+
+func (x JobStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JobStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_roaddetector_road_detector_proto_enumTypes[0].Descriptor()
+}
+
+func (JobStatus) Type() protoreflect.EnumType {
+	return &file_roaddetector_road_detector_proto_enumTypes[0]
+}
+
+func (x JobStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JobStatus.Descriptor instead.
+func (JobStatus) EnumDescriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{0}
+}
+
+// Координаты точки
+type Coordinates struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lat           float64                `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon           float64                `protobuf:"fixed64,2,opt,name=lon,proto3" json:"lon,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Coordinates) Reset() {
+	*x = Coordinates{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Coordinates) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Coordinates) ProtoMessage() {}
+
+func (x *Coordinates) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Coordinates.ProtoReflect.Descriptor instead.
+func (*Coordinates) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Coordinates) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *Coordinates) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+// Сегмент маршрута
+type Segment struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	SegmentId          int32                  `protobuf:"varint,1,opt,name=segment_id,json=segmentId,proto3" json:"segment_id,omitempty"`
+	Start              *Coordinates           `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+	End                *Coordinates           `protobuf:"bytes,3,opt,name=end,proto3" json:"end,omitempty"`
+	CoveragePercentage float64                `protobuf:"fixed64,4,opt,name=coverage_percentage,json=coveragePercentage,proto3" json:"coverage_percentage,omitempty"`
+	HasData            bool                   `protobuf:"varint,5,opt,name=has_data,json=hasData,proto3" json:"has_data,omitempty"`
+	FramesCount        int32                  `protobuf:"varint,6,opt,name=frames_count,json=framesCount,proto3" json:"frames_count,omitempty"`
+	RoadName           string                 `protobuf:"bytes,7,opt,name=road_name,json=roadName,proto3" json:"road_name,omitempty"`
+	DefectsCount       int32                  `protobuf:"varint,8,opt,name=defects_count,json=defectsCount,proto3" json:"defects_count,omitempty"`
+	QualityScore       *float64               `protobuf:"fixed64,9,opt,name=quality_score,json=qualityScore,proto3,oneof" json:"quality_score,omitempty"` // Индекс качества, не задан — не рассчитан
+	CoverageBand       string                 `protobuf:"bytes,10,opt,name=coverage_band,json=coverageBand,proto3" json:"coverage_band,omitempty"`        // Полоса покрытия, пусто — полосы не заданы
+	CoverageColor      string                 `protobuf:"bytes,11,opt,name=coverage_color,json=coverageColor,proto3" json:"coverage_color,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Segment) Reset() {
+	*x = Segment{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Segment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Segment) ProtoMessage() {}
+
+func (x *Segment) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Segment.ProtoReflect.Descriptor instead.
+func (*Segment) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Segment) GetSegmentId() int32 {
+	if x != nil {
+		return x.SegmentId
+	}
+	return 0
+}
+
+func (x *Segment) GetStart() *Coordinates {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *Segment) GetEnd() *Coordinates {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *Segment) GetCoveragePercentage() float64 {
+	if x != nil {
+		return x.CoveragePercentage
+	}
+	return 0
+}
+
+func (x *Segment) GetHasData() bool {
+	if x != nil {
+		return x.HasData
+	}
+	return false
+}
+
+func (x *Segment) GetFramesCount() int32 {
+	if x != nil {
+		return x.FramesCount
+	}
+	return 0
+}
+
+func (x *Segment) GetRoadName() string {
+	if x != nil {
+		return x.RoadName
+	}
+	return ""
+}
+
+func (x *Segment) GetDefectsCount() int32 {
+	if x != nil {
+		return x.DefectsCount
+	}
+	return 0
+}
+
+func (x *Segment) GetQualityScore() float64 {
+	if x != nil && x.QualityScore != nil {
+		return *x.QualityScore
+	}
+	return 0
+}
+
+func (x *Segment) GetCoverageBand() string {
+	if x != nil {
+		return x.CoverageBand
+	}
+	return ""
+}
+
+func (x *Segment) GetCoverageColor() string {
+	if x != nil {
+		return x.CoverageColor
+	}
+	return ""
+}
+
+// Общая статистика маршрута
+type OverallStats struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	TotalFrames         int32                  `protobuf:"varint,1,opt,name=total_frames,json=totalFrames,proto3" json:"total_frames,omitempty"`
+	TotalDistanceMeters float64                `protobuf:"fixed64,2,opt,name=total_distance_meters,json=totalDistanceMeters,proto3" json:"total_distance_meters,omitempty"`
+	TotalSegments       int32                  `protobuf:"varint,3,opt,name=total_segments,json=totalSegments,proto3" json:"total_segments,omitempty"`
+	SegmentsWithData    int32                  `protobuf:"varint,4,opt,name=segments_with_data,json=segmentsWithData,proto3" json:"segments_with_data,omitempty"`
+	AverageCoverage     float64                `protobuf:"fixed64,5,opt,name=average_coverage,json=averageCoverage,proto3" json:"average_coverage,omitempty"`
+	TotalDefects        int32                  `protobuf:"varint,6,opt,name=total_defects,json=totalDefects,proto3" json:"total_defects,omitempty"`
+	QualityScore        *float64               `protobuf:"fixed64,7,opt,name=quality_score,json=qualityScore,proto3,oneof" json:"quality_score,omitempty"`
+	CoverageBand        string                 `protobuf:"bytes,8,opt,name=coverage_band,json=coverageBand,proto3" json:"coverage_band,omitempty"`
+	CoverageColor       string                 `protobuf:"bytes,9,opt,name=coverage_color,json=coverageColor,proto3" json:"coverage_color,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *OverallStats) Reset() {
+	*x = OverallStats{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OverallStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OverallStats) ProtoMessage() {}
+
+func (x *OverallStats) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OverallStats.ProtoReflect.Descriptor instead.
+func (*OverallStats) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OverallStats) GetTotalFrames() int32 {
+	if x != nil {
+		return x.TotalFrames
+	}
+	return 0
+}
+
+func (x *OverallStats) GetTotalDistanceMeters() float64 {
+	if x != nil {
+		return x.TotalDistanceMeters
+	}
+	return 0
+}
+
+func (x *OverallStats) GetTotalSegments() int32 {
+	if x != nil {
+		return x.TotalSegments
+	}
+	return 0
+}
+
+func (x *OverallStats) GetSegmentsWithData() int32 {
+	if x != nil {
+		return x.SegmentsWithData
+	}
+	return 0
+}
+
+func (x *OverallStats) GetAverageCoverage() float64 {
+	if x != nil {
+		return x.AverageCoverage
+	}
+	return 0
+}
+
+func (x *OverallStats) GetTotalDefects() int32 {
+	if x != nil {
+		return x.TotalDefects
+	}
+	return 0
+}
+
+func (x *OverallStats) GetQualityScore() float64 {
+	if x != nil && x.QualityScore != nil {
+		return *x.QualityScore
+	}
+	return 0
+}
+
+func (x *OverallStats) GetCoverageBand() string {
+	if x != nil {
+		return x.CoverageBand
+	}
+	return ""
+}
+
+func (x *OverallStats) GetCoverageColor() string {
+	if x != nil {
+		return x.CoverageColor
+	}
+	return ""
+}
+
+// Маршрут
+type Route struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description    string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	RoadName       string                 `protobuf:"bytes,4,opt,name=road_name,json=roadName,proto3" json:"road_name,omitempty"`
+	StartPoint     *Coordinates           `protobuf:"bytes,5,opt,name=start_point,json=startPoint,proto3" json:"start_point,omitempty"`
+	EndPoint       *Coordinates           `protobuf:"bytes,6,opt,name=end_point,json=endPoint,proto3" json:"end_point,omitempty"`
+	SegmentLength  float64                `protobuf:"fixed64,7,opt,name=segment_length,json=segmentLength,proto3" json:"segment_length,omitempty"`
+	Segments       []*Segment             `protobuf:"bytes,8,rep,name=segments,proto3" json:"segments,omitempty"` // Пусто в списке маршрутов
+	OverallStats   *OverallStats          `protobuf:"bytes,9,opt,name=overall_stats,json=overallStats,proto3" json:"overall_stats,omitempty"`
+	Tags           []string               `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	OrganizationId *uint32                `protobuf:"varint,11,opt,name=organization_id,json=organizationId,proto3,oneof" json:"organization_id,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Route) Reset() {
+	*x = Route{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Route) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Route) ProtoMessage() {}
+
+func (x *Route) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Route.ProtoReflect.Descriptor instead.
+func (*Route) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Route) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Route) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Route) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Route) GetRoadName() string {
+	if x != nil {
+		return x.RoadName
+	}
+	return ""
+}
+
+func (x *Route) GetStartPoint() *Coordinates {
+	if x != nil {
+		return x.StartPoint
+	}
+	return nil
+}
+
+func (x *Route) GetEndPoint() *Coordinates {
+	if x != nil {
+		return x.EndPoint
+	}
+	return nil
+}
+
+func (x *Route) GetSegmentLength() float64 {
+	if x != nil {
+		return x.SegmentLength
+	}
+	return 0
+}
+
+func (x *Route) GetSegments() []*Segment {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+func (x *Route) GetOverallStats() *OverallStats {
+	if x != nil {
+		return x.OverallStats
+	}
+	return nil
+}
+
+func (x *Route) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Route) GetOrganizationId() uint32 {
+	if x != nil && x.OrganizationId != nil {
+		return *x.OrganizationId
+	}
+	return 0
+}
+
+func (x *Route) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Route) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetRouteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRouteRequest) Reset() {
+	*x = GetRouteRequest{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRouteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRouteRequest) ProtoMessage() {}
+
+func (x *GetRouteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRouteRequest.ProtoReflect.Descriptor instead.
+func (*GetRouteRequest) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetRouteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListRoutesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`                         // Номер страницы с 1, 0 — первая
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // Размер страницы от 1 до 100, 0 — 10
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoutesRequest) Reset() {
+	*x = ListRoutesRequest{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoutesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoutesRequest) ProtoMessage() {}
+
+func (x *ListRoutesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoutesRequest.ProtoReflect.Descriptor instead.
+func (*ListRoutesRequest) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListRoutesRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListRoutesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type ListRoutesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Routes        []*Route               `protobuf:"bytes,1,rep,name=routes,proto3" json:"routes,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoutesResponse) Reset() {
+	*x = ListRoutesResponse{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoutesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoutesResponse) ProtoMessage() {}
+
+func (x *ListRoutesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoutesResponse.ProtoReflect.Descriptor instead.
+func (*ListRoutesResponse) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListRoutesResponse) GetRoutes() []*Route {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
+}
+
+func (x *ListRoutesResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListRoutesResponse) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListRoutesResponse) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// Параметры анализа видео, как в форме POST /api/v1/analyze
+type AnalyzeVideoParams struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	VideoFilename       string                 `protobuf:"bytes,1,opt,name=video_filename,json=videoFilename,proto3" json:"video_filename,omitempty"`
+	StartPoint          *Coordinates           `protobuf:"bytes,2,opt,name=start_point,json=startPoint,proto3" json:"start_point,omitempty"`
+	EndPoint            *Coordinates           `protobuf:"bytes,3,opt,name=end_point,json=endPoint,proto3" json:"end_point,omitempty"`
+	SegmentLengthM      float64                `protobuf:"fixed64,4,opt,name=segment_length_m,json=segmentLengthM,proto3" json:"segment_length_m,omitempty"`
+	RouteId             string                 `protobuf:"bytes,5,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"` // ID маршрута, пусто — генерируется
+	Name                string                 `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`
+	Description         string                 `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
+	Tags                []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	FrameSampleRate     float64                `protobuf:"fixed64,9,opt,name=frame_sample_rate,json=frameSampleRate,proto3" json:"frame_sample_rate,omitempty"`            // 0 — по умолчанию сервиса анализа
+	ConfidenceThreshold float64                `protobuf:"fixed64,10,opt,name=confidence_threshold,json=confidenceThreshold,proto3" json:"confidence_threshold,omitempty"` // 0 — по умолчанию сервиса анализа
+	ModelVariant        string                 `protobuf:"bytes,11,opt,name=model_variant,json=modelVariant,proto3" json:"model_variant,omitempty"`
+	Roi                 string                 `protobuf:"bytes,12,opt,name=roi,proto3" json:"roi,omitempty"`                                               // Область кадра "x,y,width,height", как в HTTP API, пусто — весь кадр
+	TimeoutSeconds      float64                `protobuf:"fixed64,13,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"` // Ожидание анализатора, 0 — по размеру видео
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *AnalyzeVideoParams) Reset() {
+	*x = AnalyzeVideoParams{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeVideoParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeVideoParams) ProtoMessage() {}
+
+func (x *AnalyzeVideoParams) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeVideoParams.ProtoReflect.Descriptor instead.
+func (*AnalyzeVideoParams) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AnalyzeVideoParams) GetVideoFilename() string {
+	if x != nil {
+		return x.VideoFilename
+	}
+	return ""
+}
+
+func (x *AnalyzeVideoParams) GetStartPoint() *Coordinates {
+	if x != nil {
+		return x.StartPoint
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoParams) GetEndPoint() *Coordinates {
+	if x != nil {
+		return x.EndPoint
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoParams) GetSegmentLengthM() float64 {
+	if x != nil {
+		return x.SegmentLengthM
+	}
+	return 0
+}
+
+func (x *AnalyzeVideoParams) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *AnalyzeVideoParams) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AnalyzeVideoParams) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *AnalyzeVideoParams) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoParams) GetFrameSampleRate() float64 {
+	if x != nil {
+		return x.FrameSampleRate
+	}
+	return 0
+}
+
+func (x *AnalyzeVideoParams) GetConfidenceThreshold() float64 {
+	if x != nil {
+		return x.ConfidenceThreshold
+	}
+	return 0
+}
+
+func (x *AnalyzeVideoParams) GetModelVariant() string {
+	if x != nil {
+		return x.ModelVariant
+	}
+	return ""
+}
+
+func (x *AnalyzeVideoParams) GetRoi() string {
+	if x != nil {
+		return x.Roi
+	}
+	return ""
+}
+
+func (x *AnalyzeVideoParams) GetTimeoutSeconds() float64 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+type AnalyzeVideoRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*AnalyzeVideoRequest_Params
+	//	*AnalyzeVideoRequest_Chunk
+	Payload       isAnalyzeVideoRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzeVideoRequest) Reset() {
+	*x = AnalyzeVideoRequest{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeVideoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeVideoRequest) ProtoMessage() {}
+
+func (x *AnalyzeVideoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeVideoRequest.ProtoReflect.Descriptor instead.
+func (*AnalyzeVideoRequest) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AnalyzeVideoRequest) GetPayload() isAnalyzeVideoRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoRequest) GetParams() *AnalyzeVideoParams {
+	if x != nil {
+		if x, ok := x.Payload.(*AnalyzeVideoRequest_Params); ok {
+			return x.Params
+		}
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*AnalyzeVideoRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isAnalyzeVideoRequest_Payload interface {
+	isAnalyzeVideoRequest_Payload()
+}
+
+type AnalyzeVideoRequest_Params struct {
+	Params *AnalyzeVideoParams `protobuf:"bytes,1,opt,name=params,proto3,oneof"`
+}
+
+type AnalyzeVideoRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"` // Очередная часть видеофайла
+}
+
+func (*AnalyzeVideoRequest_Params) isAnalyzeVideoRequest_Payload() {}
+
+func (*AnalyzeVideoRequest_Chunk) isAnalyzeVideoRequest_Payload() {}
+
+// Результат анализа
+type AnalysisResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RouteId       string                 `protobuf:"bytes,1,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"`
+	StartPoint    *Coordinates           `protobuf:"bytes,2,opt,name=start_point,json=startPoint,proto3" json:"start_point,omitempty"`
+	EndPoint      *Coordinates           `protobuf:"bytes,3,opt,name=end_point,json=endPoint,proto3" json:"end_point,omitempty"`
+	SegmentLength float64                `protobuf:"fixed64,4,opt,name=segment_length,json=segmentLength,proto3" json:"segment_length,omitempty"`
+	Segments      []*Segment             `protobuf:"bytes,5,rep,name=segments,proto3" json:"segments,omitempty"`
+	OverallStats  *OverallStats          `protobuf:"bytes,6,opt,name=overall_stats,json=overallStats,proto3" json:"overall_stats,omitempty"`
+	RoadName      string                 `protobuf:"bytes,7,opt,name=road_name,json=roadName,proto3" json:"road_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalysisResult) Reset() {
+	*x = AnalysisResult{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalysisResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalysisResult) ProtoMessage() {}
+
+func (x *AnalysisResult) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalysisResult.ProtoReflect.Descriptor instead.
+func (*AnalysisResult) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AnalysisResult) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *AnalysisResult) GetStartPoint() *Coordinates {
+	if x != nil {
+		return x.StartPoint
+	}
+	return nil
+}
+
+func (x *AnalysisResult) GetEndPoint() *Coordinates {
+	if x != nil {
+		return x.EndPoint
+	}
+	return nil
+}
+
+func (x *AnalysisResult) GetSegmentLength() float64 {
+	if x != nil {
+		return x.SegmentLength
+	}
+	return 0
+}
+
+func (x *AnalysisResult) GetSegments() []*Segment {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+func (x *AnalysisResult) GetOverallStats() *OverallStats {
+	if x != nil {
+		return x.OverallStats
+	}
+	return nil
+}
+
+func (x *AnalysisResult) GetRoadName() string {
+	if x != nil {
+		return x.RoadName
+	}
+	return ""
+}
+
+type WatchJobsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RouteId       string                 `protobuf:"bytes,1,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"` // Только анализ этого маршрута, пусто — все доступные
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchJobsRequest) Reset() {
+	*x = WatchJobsRequest{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchJobsRequest) ProtoMessage() {}
+
+func (x *WatchJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchJobsRequest.ProtoReflect.Descriptor instead.
+func (*WatchJobsRequest) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WatchJobsRequest) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+// Анализ видео и его текущее состояние
+type Job struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RouteId        string                 `protobuf:"bytes,1,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"`
+	VideoFilename  string                 `protobuf:"bytes,2,opt,name=video_filename,json=videoFilename,proto3" json:"video_filename,omitempty"`
+	Status         JobStatus              `protobuf:"varint,3,opt,name=status,proto3,enum=roaddetector.v1.JobStatus" json:"status,omitempty"`
+	Stage          string                 `protobuf:"bytes,4,opt,name=stage,proto3" json:"stage,omitempty"` // Текущий этап выполняющегося анализа
+	Error          string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"` // Ошибка неудачного анализа
+	OrganizationId *uint32                `protobuf:"varint,6,opt,name=organization_id,json=organizationId,proto3,oneof" json:"organization_id,omitempty"`
+	StartedAt      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_roaddetector_road_detector_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_roaddetector_road_detector_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_roaddetector_road_detector_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Job) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *Job) GetVideoFilename() string {
+	if x != nil {
+		return x.VideoFilename
+	}
+	return ""
+}
+
+func (x *Job) GetStatus() JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return JobStatus_JOB_STATUS_UNSPECIFIED
+}
+
+func (x *Job) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *Job) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Job) GetOrganizationId() uint32 {
+	if x != nil && x.OrganizationId != nil {
+		return *x.OrganizationId
+	}
+	return 0
+}
+
+func (x *Job) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *Job) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+var File_roaddetector_road_detector_proto protoreflect.FileDescriptor
+
+const file_roaddetector_road_detector_proto_rawDesc = "" +
+	"\n" +
+	" roaddetector/road_detector.proto\x12\x0froaddetector.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"1\n" +
+	"\vCoordinates\x12\x10\n" +
+	"\x03lat\x18\x01 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x02 \x01(\x01R\x03lon\"\xc5\x03\n" +
+	"\aSegment\x12\x1d\n" +
+	"\n" +
+	"segment_id\x18\x01 \x01(\x05R\tsegmentId\x122\n" +
+	"\x05start\x18\x02 \x01(\v2\x1c.roaddetector.v1.CoordinatesR\x05start\x12.\n" +
+	"\x03end\x18\x03 \x01(\v2\x1c.roaddetector.v1.CoordinatesR\x03end\x12/\n" +
+	"\x13coverage_percentage\x18\x04 \x01(\x01R\x12coveragePercentage\x12\x19\n" +
+	"\bhas_data\x18\x05 \x01(\bR\ahasData\x12!\n" +
+	"\fframes_count\x18\x06 \x01(\x05R\vframesCount\x12\x1b\n" +
+	"\troad_name\x18\a \x01(\tR\broadName\x12#\n" +
+	"\rdefects_count\x18\b \x01(\x05R\fdefectsCount\x12(\n" +
+	"\rquality_score\x18\t \x01(\x01H\x00R\fqualityScore\x88\x01\x01\x12#\n" +
+	"\rcoverage_band\x18\n" +
+	" \x01(\tR\fcoverageBand\x12%\n" +
+	"\x0ecoverage_color\x18\v \x01(\tR\rcoverageColorB\x10\n" +
+	"\x0e_quality_score\"\x92\x03\n" +
+	"\fOverallStats\x12!\n" +
+	"\ftotal_frames\x18\x01 \x01(\x05R\vtotalFrames\x122\n" +
+	"\x15total_distance_meters\x18\x02 \x01(\x01R\x13totalDistanceMeters\x12%\n" +
+	"\x0etotal_segments\x18\x03 \x01(\x05R\rtotalSegments\x12,\n" +
+	"\x12segments_with_data\x18\x04 \x01(\x05R\x10segmentsWithData\x12)\n" +
+	"\x10average_coverage\x18\x05 \x01(\x01R\x0faverageCoverage\x12#\n" +
+	"\rtotal_defects\x18\x06 \x01(\x05R\ftotalDefects\x12(\n" +
+	"\rquality_score\x18\a \x01(\x01H\x00R\fqualityScore\x88\x01\x01\x12#\n" +
+	"\rcoverage_band\x18\b \x01(\tR\fcoverageBand\x12%\n" +
+	"\x0ecoverage_color\x18\t \x01(\tR\rcoverageColorB\x10\n" +
+	"\x0e_quality_score\"\xd1\x04\n" +
+	"\x05Route\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1b\n" +
+	"\troad_name\x18\x04 \x01(\tR\broadName\x12=\n" +
+	"\vstart_point\x18\x05 \x01(\v2\x1c.roaddetector.v1.CoordinatesR\n" +
+	"startPoint\x129\n" +
+	"\tend_point\x18\x06 \x01(\v2\x1c.roaddetector.v1.CoordinatesR\bendPoint\x12%\n" +
+	"\x0esegment_length\x18\a \x01(\x01R\rsegmentLength\x124\n" +
+	"\bsegments\x18\b \x03(\v2\x18.roaddetector.v1.SegmentR\bsegments\x12B\n" +
+	"\roverall_stats\x18\t \x01(\v2\x1d.roaddetector.v1.OverallStatsR\foverallStats\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x12,\n" +
+	"\x0forganization_id\x18\v \x01(\rH\x00R\x0eorganizationId\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\x12\n" +
+	"\x10_organization_id\"!\n" +
+	"\x0fGetRouteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"D\n" +
+	"\x11ListRoutesRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\"\x8b\x01\n" +
+	"\x12ListRoutesResponse\x12.\n" +
+	"\x06routes\x18\x01 \x03(\v2\x16.roaddetector.v1.RouteR\x06routes\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\"\x83\x04\n" +
+	"\x12AnalyzeVideoParams\x12%\n" +
+	"\x0evideo_filename\x18\x01 \x01(\tR\rvideoFilename\x12=\n" +
+	"\vstart_point\x18\x02 \x01(\v2\x1c.roaddetector.v1.CoordinatesR\n" +
+	"startPoint\x129\n" +
+	"\tend_point\x18\x03 \x01(\v2\x1c.roaddetector.v1.CoordinatesR\bendPoint\x12(\n" +
+	"\x10segment_length_m\x18\x04 \x01(\x01R\x0esegmentLengthM\x12\x19\n" +
+	"\broute_id\x18\x05 \x01(\tR\arouteId\x12\x12\n" +
+	"\x04name\x18\x06 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\a \x01(\tR\vdescription\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12*\n" +
+	"\x11frame_sample_rate\x18\t \x01(\x01R\x0fframeSampleRate\x121\n" +
+	"\x14confidence_threshold\x18\n" +
+	" \x01(\x01R\x13confidenceThreshold\x12#\n" +
+	"\rmodel_variant\x18\v \x01(\tR\fmodelVariant\x12\x10\n" +
+	"\x03roi\x18\f \x01(\tR\x03roi\x12'\n" +
+	"\x0ftimeout_seconds\x18\r \x01(\x01R\x0etimeoutSeconds\"w\n" +
+	"\x13AnalyzeVideoRequest\x12=\n" +
+	"\x06params\x18\x01 \x01(\v2#.roaddetector.v1.AnalyzeVideoParamsH\x00R\x06params\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload\"\xe3\x02\n" +
+	"\x0eAnalysisResult\x12\x19\n" +
+	"\broute_id\x18\x01 \x01(\tR\arouteId\x12=\n" +
+	"\vstart_point\x18\x02 \x01(\v2\x1c.roaddetector.v1.CoordinatesR\n" +
+	"startPoint\x129\n" +
+	"\tend_point\x18\x03 \x01(\v2\x1c.roaddetector.v1.CoordinatesR\bendPoint\x12%\n" +
+	"\x0esegment_length\x18\x04 \x01(\x01R\rsegmentLength\x124\n" +
+	"\bsegments\x18\x05 \x03(\v2\x18.roaddetector.v1.SegmentR\bsegments\x12B\n" +
+	"\roverall_stats\x18\x06 \x01(\v2\x1d.roaddetector.v1.OverallStatsR\foverallStats\x12\x1b\n" +
+	"\troad_name\x18\a \x01(\tR\broadName\"-\n" +
+	"\x10WatchJobsRequest\x12\x19\n" +
+	"\broute_id\x18\x01 \x01(\tR\arouteId\"\xe1\x02\n" +
+	"\x03Job\x12\x19\n" +
+	"\broute_id\x18\x01 \x01(\tR\arouteId\x12%\n" +
+	"\x0evideo_filename\x18\x02 \x01(\tR\rvideoFilename\x122\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x1a.roaddetector.v1.JobStatusR\x06status\x12\x14\n" +
+	"\x05stage\x18\x04 \x01(\tR\x05stage\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12,\n" +
+	"\x0forganization_id\x18\x06 \x01(\rH\x00R\x0eorganizationId\x88\x01\x01\x129\n" +
+	"\n" +
+	"started_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAtB\x12\n" +
+	"\x10_organization_id*p\n" +
+	"\tJobStatus\x12\x1a\n" +
+	"\x16JOB_STATUS_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12JOB_STATUS_RUNNING\x10\x01\x12\x18\n" +
+	"\x14JOB_STATUS_COMPLETED\x10\x02\x12\x15\n" +
+	"\x11JOB_STATUS_FAILED\x10\x032\xab\x01\n" +
+	"\fRouteService\x12D\n" +
+	"\bGetRoute\x12 .roaddetector.v1.GetRouteRequest\x1a\x16.roaddetector.v1.Route\x12U\n" +
+	"\n" +
+	"ListRoutes\x12\".roaddetector.v1.ListRoutesRequest\x1a#.roaddetector.v1.ListRoutesResponse2\xb2\x01\n" +
+	"\x0fAnalysisService\x12W\n" +
+	"\fAnalyzeVideo\x12$.roaddetector.v1.AnalyzeVideoRequest\x1a\x1f.roaddetector.v1.AnalysisResult(\x01\x12F\n" +
+	"\tWatchJobs\x12!.roaddetector.v1.WatchJobsRequest\x1a\x14.roaddetector.v1.Job0\x01B.Z,road-detector-go/internal/proto/roaddetectorb\x06proto3"
+
+var (
+	file_roaddetector_road_detector_proto_rawDescOnce sync.Once
+	file_roaddetector_road_detector_proto_rawDescData []byte
+)
+
+func file_roaddetector_road_detector_proto_rawDescGZIP() []byte {
+	file_roaddetector_road_detector_proto_rawDescOnce.Do(func() {
+		file_roaddetector_road_detector_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_roaddetector_road_detector_proto_rawDesc), len(file_roaddetector_road_detector_proto_rawDesc)))
+	})
+	return file_roaddetector_road_detector_proto_rawDescData
+}
+
+var file_roaddetector_road_detector_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_roaddetector_road_detector_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_roaddetector_road_detector_proto_goTypes = []any{
+	(JobStatus)(0),                // 0: roaddetector.v1.JobStatus
+	(*Coordinates)(nil),           // 1: roaddetector.v1.Coordinates
+	(*Segment)(nil),               // 2: roaddetector.v1.Segment
+	(*OverallStats)(nil),          // 3: roaddetector.v1.OverallStats
+	(*Route)(nil),                 // 4: roaddetector.v1.Route
+	(*GetRouteRequest)(nil),       // 5: roaddetector.v1.GetRouteRequest
+	(*ListRoutesRequest)(nil),     // 6: roaddetector.v1.ListRoutesRequest
+	(*ListRoutesResponse)(nil),    // 7: roaddetector.v1.ListRoutesResponse
+	(*AnalyzeVideoParams)(nil),    // 8: roaddetector.v1.AnalyzeVideoParams
+	(*AnalyzeVideoRequest)(nil),   // 9: roaddetector.v1.AnalyzeVideoRequest
+	(*AnalysisResult)(nil),        // 10: roaddetector.v1.AnalysisResult
+	(*WatchJobsRequest)(nil),      // 11: roaddetector.v1.WatchJobsRequest
+	(*Job)(nil),                   // 12: roaddetector.v1.Job
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+}
+var file_roaddetector_road_detector_proto_depIdxs = []int32{
+	1,  // 0: roaddetector.v1.Segment.start:type_name -> roaddetector.v1.Coordinates
+	1,  // 1: roaddetector.v1.Segment.end:type_name -> roaddetector.v1.Coordinates
+	1,  // 2: roaddetector.v1.Route.start_point:type_name -> roaddetector.v1.Coordinates
+	1,  // 3: roaddetector.v1.Route.end_point:type_name -> roaddetector.v1.Coordinates
+	2,  // 4: roaddetector.v1.Route.segments:type_name -> roaddetector.v1.Segment
+	3,  // 5: roaddetector.v1.Route.overall_stats:type_name -> roaddetector.v1.OverallStats
+	13, // 6: roaddetector.v1.Route.created_at:type_name -> google.protobuf.Timestamp
+	13, // 7: roaddetector.v1.Route.updated_at:type_name -> google.protobuf.Timestamp
+	4,  // 8: roaddetector.v1.ListRoutesResponse.routes:type_name -> roaddetector.v1.Route
+	1,  // 9: roaddetector.v1.AnalyzeVideoParams.start_point:type_name -> roaddetector.v1.Coordinates
+	1,  // 10: roaddetector.v1.AnalyzeVideoParams.end_point:type_name -> roaddetector.v1.Coordinates
+	8,  // 11: roaddetector.v1.AnalyzeVideoRequest.params:type_name -> roaddetector.v1.AnalyzeVideoParams
+	1,  // 12: roaddetector.v1.AnalysisResult.start_point:type_name -> roaddetector.v1.Coordinates
+	1,  // 13: roaddetector.v1.AnalysisResult.end_point:type_name -> roaddetector.v1.Coordinates
+	2,  // 14: roaddetector.v1.AnalysisResult.segments:type_name -> roaddetector.v1.Segment
+	3,  // 15: roaddetector.v1.AnalysisResult.overall_stats:type_name -> roaddetector.v1.OverallStats
+	0,  // 16: roaddetector.v1.Job.status:type_name -> roaddetector.v1.JobStatus
+	13, // 17: roaddetector.v1.Job.started_at:type_name -> google.protobuf.Timestamp
+	13, // 18: roaddetector.v1.Job.finished_at:type_name -> google.protobuf.Timestamp
+	5,  // 19: roaddetector.v1.RouteService.GetRoute:input_type -> roaddetector.v1.GetRouteRequest
+	6,  // 20: roaddetector.v1.RouteService.ListRoutes:input_type -> roaddetector.v1.ListRoutesRequest
+	9,  // 21: roaddetector.v1.AnalysisService.AnalyzeVideo:input_type -> roaddetector.v1.AnalyzeVideoRequest
+	11, // 22: roaddetector.v1.AnalysisService.WatchJobs:input_type -> roaddetector.v1.WatchJobsRequest
+	4,  // 23: roaddetector.v1.RouteService.GetRoute:output_type -> roaddetector.v1.Route
+	7,  // 24: roaddetector.v1.RouteService.ListRoutes:output_type -> roaddetector.v1.ListRoutesResponse
+	10, // 25: roaddetector.v1.AnalysisService.AnalyzeVideo:output_type -> roaddetector.v1.AnalysisResult
+	12, // 26: roaddetector.v1.AnalysisService.WatchJobs:output_type -> roaddetector.v1.Job
+	23, // [23:27] is the sub-list for method output_type
+	19, // [19:23] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
+}
+
+func init() { file_roaddetector_road_detector_proto_init() }
+func file_roaddetector_road_detector_proto_init() {
+	if File_roaddetector_road_detector_proto != nil {
+		return
+	}
+	file_roaddetector_road_detector_proto_msgTypes[1].OneofWrappers = []any{}
+	file_roaddetector_road_detector_proto_msgTypes[2].OneofWrappers = []any{}
+	file_roaddetector_road_detector_proto_msgTypes[3].OneofWrappers = []any{}
+	file_roaddetector_road_detector_proto_msgTypes[8].OneofWrappers = []any{
+		(*AnalyzeVideoRequest_Params)(nil),
+		(*AnalyzeVideoRequest_Chunk)(nil),
+	}
+	file_roaddetector_road_detector_proto_msgTypes[11].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_roaddetector_road_detector_proto_rawDesc), len(file_roaddetector_road_detector_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_roaddetector_road_detector_proto_goTypes,
+		DependencyIndexes: file_roaddetector_road_detector_proto_depIdxs,
+		EnumInfos:         file_roaddetector_road_detector_proto_enumTypes,
+		MessageInfos:      file_roaddetector_road_detector_proto_msgTypes,
+	}.Build()
+	File_roaddetector_road_detector_proto = out.File
+	file_roaddetector_road_detector_proto_goTypes = nil
+	file_roaddetector_road_detector_proto_depIdxs = nil
+}
@@ -0,0 +1,953 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.1
+// source: video_analysis.proto
+
+package road_marking
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Параметры потокового анализа
+type AnalyzeVideoParams struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	VideoFilename       string                 `protobuf:"bytes,1,opt,name=video_filename,json=videoFilename,proto3" json:"video_filename,omitempty"`                     // Имя видеофайла
+	StartPoint          *Coordinates           `protobuf:"bytes,2,opt,name=start_point,json=startPoint,proto3" json:"start_point,omitempty"`                              // Начальная точка маршрута
+	EndPoint            *Coordinates           `protobuf:"bytes,3,opt,name=end_point,json=endPoint,proto3" json:"end_point,omitempty"`                                    // Конечная точка маршрута
+	SegmentLengthM      int32                  `protobuf:"varint,4,opt,name=segment_length_m,json=segmentLengthM,proto3" json:"segment_length_m,omitempty"`               // Длина сегмента в метрах
+	RouteId             string                 `protobuf:"bytes,5,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"`                                       // ID маршрута
+	FrameSampleRate     float64                `protobuf:"fixed64,6,opt,name=frame_sample_rate,json=frameSampleRate,proto3" json:"frame_sample_rate,omitempty"`           // Кадров в секунду для анализа, 0 — по умолчанию сервиса
+	ConfidenceThreshold float64                `protobuf:"fixed64,7,opt,name=confidence_threshold,json=confidenceThreshold,proto3" json:"confidence_threshold,omitempty"` // Порог уверенности модели, 0 — по умолчанию сервиса
+	ModelVariant        string                 `protobuf:"bytes,8,opt,name=model_variant,json=modelVariant,proto3" json:"model_variant,omitempty"`                        // Вариант модели, пусто — по умолчанию сервиса
+	Roi                 *RegionOfInterest      `protobuf:"bytes,9,opt,name=roi,proto3" json:"roi,omitempty"`                                                              // Анализируемая область кадра, не задана — весь кадр
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *AnalyzeVideoParams) Reset() {
+	*x = AnalyzeVideoParams{}
+	mi := &file_video_analysis_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeVideoParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeVideoParams) ProtoMessage() {}
+
+func (x *AnalyzeVideoParams) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeVideoParams.ProtoReflect.Descriptor instead.
+func (*AnalyzeVideoParams) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AnalyzeVideoParams) GetVideoFilename() string {
+	if x != nil {
+		return x.VideoFilename
+	}
+	return ""
+}
+
+func (x *AnalyzeVideoParams) GetStartPoint() *Coordinates {
+	if x != nil {
+		return x.StartPoint
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoParams) GetEndPoint() *Coordinates {
+	if x != nil {
+		return x.EndPoint
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoParams) GetSegmentLengthM() int32 {
+	if x != nil {
+		return x.SegmentLengthM
+	}
+	return 0
+}
+
+func (x *AnalyzeVideoParams) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *AnalyzeVideoParams) GetFrameSampleRate() float64 {
+	if x != nil {
+		return x.FrameSampleRate
+	}
+	return 0
+}
+
+func (x *AnalyzeVideoParams) GetConfidenceThreshold() float64 {
+	if x != nil {
+		return x.ConfidenceThreshold
+	}
+	return 0
+}
+
+func (x *AnalyzeVideoParams) GetModelVariant() string {
+	if x != nil {
+		return x.ModelVariant
+	}
+	return ""
+}
+
+func (x *AnalyzeVideoParams) GetRoi() *RegionOfInterest {
+	if x != nil {
+		return x.Roi
+	}
+	return nil
+}
+
+// Сообщение клиента в потоке анализа
+type AnalyzeVideoRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*AnalyzeVideoRequest_Params
+	//	*AnalyzeVideoRequest_VideoChunk
+	Payload       isAnalyzeVideoRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzeVideoRequest) Reset() {
+	*x = AnalyzeVideoRequest{}
+	mi := &file_video_analysis_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeVideoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeVideoRequest) ProtoMessage() {}
+
+func (x *AnalyzeVideoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeVideoRequest.ProtoReflect.Descriptor instead.
+func (*AnalyzeVideoRequest) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AnalyzeVideoRequest) GetPayload() isAnalyzeVideoRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoRequest) GetParams() *AnalyzeVideoParams {
+	if x != nil {
+		if x, ok := x.Payload.(*AnalyzeVideoRequest_Params); ok {
+			return x.Params
+		}
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoRequest) GetVideoChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*AnalyzeVideoRequest_VideoChunk); ok {
+			return x.VideoChunk
+		}
+	}
+	return nil
+}
+
+type isAnalyzeVideoRequest_Payload interface {
+	isAnalyzeVideoRequest_Payload()
+}
+
+type AnalyzeVideoRequest_Params struct {
+	Params *AnalyzeVideoParams `protobuf:"bytes,1,opt,name=params,proto3,oneof"` // Параметры анализа, первое сообщение
+}
+
+type AnalyzeVideoRequest_VideoChunk struct {
+	VideoChunk []byte `protobuf:"bytes,2,opt,name=video_chunk,json=videoChunk,proto3,oneof"` // Часть видеофайла
+}
+
+func (*AnalyzeVideoRequest_Params) isAnalyzeVideoRequest_Payload() {}
+
+func (*AnalyzeVideoRequest_VideoChunk) isAnalyzeVideoRequest_Payload() {}
+
+// Результат анализа кадра
+type FrameResult struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	FrameIndex         int32                  `protobuf:"varint,1,opt,name=frame_index,json=frameIndex,proto3" json:"frame_index,omitempty"`                          // Номер кадра
+	SegmentId          int32                  `protobuf:"varint,2,opt,name=segment_id,json=segmentId,proto3" json:"segment_id,omitempty"`                             // ID сегмента, к которому относится кадр
+	CoveragePercentage float64                `protobuf:"fixed64,3,opt,name=coverage_percentage,json=coveragePercentage,proto3" json:"coverage_percentage,omitempty"` // Процент покрытия разметкой на кадре
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *FrameResult) Reset() {
+	*x = FrameResult{}
+	mi := &file_video_analysis_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FrameResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FrameResult) ProtoMessage() {}
+
+func (x *FrameResult) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FrameResult.ProtoReflect.Descriptor instead.
+func (*FrameResult) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FrameResult) GetFrameIndex() int32 {
+	if x != nil {
+		return x.FrameIndex
+	}
+	return 0
+}
+
+func (x *FrameResult) GetSegmentId() int32 {
+	if x != nil {
+		return x.SegmentId
+	}
+	return 0
+}
+
+func (x *FrameResult) GetCoveragePercentage() float64 {
+	if x != nil {
+		return x.CoveragePercentage
+	}
+	return 0
+}
+
+// Итог анализа
+type AnalysisSummary struct {
+	state                protoimpl.MessageState  `protogen:"open.v1"`
+	OverallStats         *OverallStats           `protobuf:"bytes,1,opt,name=overall_stats,json=overallStats,proto3" json:"overall_stats,omitempty"`                           // Общая статистика
+	Segments             []*SegmentInfo          `protobuf:"bytes,2,rep,name=segments,proto3" json:"segments,omitempty"`                                                       // Информация о сегментах
+	SegmentDefects       []*SegmentDefects       `protobuf:"bytes,3,rep,name=segment_defects,json=segmentDefects,proto3" json:"segment_defects,omitempty"`                     // Дефекты покрытия по сегментам
+	SegmentClassCoverage []*SegmentClassCoverage `protobuf:"bytes,4,rep,name=segment_class_coverage,json=segmentClassCoverage,proto3" json:"segment_class_coverage,omitempty"` // Покрытие по типам разметки по сегментам
+	SegmentConfidence    []*SegmentConfidence    `protobuf:"bytes,5,rep,name=segment_confidence,json=segmentConfidence,proto3" json:"segment_confidence,omitempty"`            // Уверенность модели по сегментам
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *AnalysisSummary) Reset() {
+	*x = AnalysisSummary{}
+	mi := &file_video_analysis_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalysisSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalysisSummary) ProtoMessage() {}
+
+func (x *AnalysisSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalysisSummary.ProtoReflect.Descriptor instead.
+func (*AnalysisSummary) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AnalysisSummary) GetOverallStats() *OverallStats {
+	if x != nil {
+		return x.OverallStats
+	}
+	return nil
+}
+
+func (x *AnalysisSummary) GetSegments() []*SegmentInfo {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+func (x *AnalysisSummary) GetSegmentDefects() []*SegmentDefects {
+	if x != nil {
+		return x.SegmentDefects
+	}
+	return nil
+}
+
+func (x *AnalysisSummary) GetSegmentClassCoverage() []*SegmentClassCoverage {
+	if x != nil {
+		return x.SegmentClassCoverage
+	}
+	return nil
+}
+
+func (x *AnalysisSummary) GetSegmentConfidence() []*SegmentConfidence {
+	if x != nil {
+		return x.SegmentConfidence
+	}
+	return nil
+}
+
+// Сообщение сервиса в потоке анализа
+type AnalyzeVideoResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*AnalyzeVideoResponse_Frame
+	//	*AnalyzeVideoResponse_Summary
+	//	*AnalyzeVideoResponse_AnnotatedVideoChunk
+	Payload       isAnalyzeVideoResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzeVideoResponse) Reset() {
+	*x = AnalyzeVideoResponse{}
+	mi := &file_video_analysis_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeVideoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeVideoResponse) ProtoMessage() {}
+
+func (x *AnalyzeVideoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeVideoResponse.ProtoReflect.Descriptor instead.
+func (*AnalyzeVideoResponse) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AnalyzeVideoResponse) GetPayload() isAnalyzeVideoResponse_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoResponse) GetFrame() *FrameResult {
+	if x != nil {
+		if x, ok := x.Payload.(*AnalyzeVideoResponse_Frame); ok {
+			return x.Frame
+		}
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoResponse) GetSummary() *AnalysisSummary {
+	if x != nil {
+		if x, ok := x.Payload.(*AnalyzeVideoResponse_Summary); ok {
+			return x.Summary
+		}
+	}
+	return nil
+}
+
+func (x *AnalyzeVideoResponse) GetAnnotatedVideoChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*AnalyzeVideoResponse_AnnotatedVideoChunk); ok {
+			return x.AnnotatedVideoChunk
+		}
+	}
+	return nil
+}
+
+type isAnalyzeVideoResponse_Payload interface {
+	isAnalyzeVideoResponse_Payload()
+}
+
+type AnalyzeVideoResponse_Frame struct {
+	Frame *FrameResult `protobuf:"bytes,1,opt,name=frame,proto3,oneof"` // Результат очередного кадра
+}
+
+type AnalyzeVideoResponse_Summary struct {
+	Summary *AnalysisSummary `protobuf:"bytes,2,opt,name=summary,proto3,oneof"` // Итог анализа, после всех кадров
+}
+
+type AnalyzeVideoResponse_AnnotatedVideoChunk struct {
+	AnnotatedVideoChunk []byte `protobuf:"bytes,3,opt,name=annotated_video_chunk,json=annotatedVideoChunk,proto3,oneof"` // Часть аннотированного видео, после итога
+}
+
+func (*AnalyzeVideoResponse_Frame) isAnalyzeVideoResponse_Payload() {}
+
+func (*AnalyzeVideoResponse_Summary) isAnalyzeVideoResponse_Payload() {}
+
+func (*AnalyzeVideoResponse_AnnotatedVideoChunk) isAnalyzeVideoResponse_Payload() {}
+
+// Область кадра в долях ширины и высоты от левого верхнего угла
+type RegionOfInterest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width         float64                `protobuf:"fixed64,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        float64                `protobuf:"fixed64,4,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegionOfInterest) Reset() {
+	*x = RegionOfInterest{}
+	mi := &file_video_analysis_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegionOfInterest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegionOfInterest) ProtoMessage() {}
+
+func (x *RegionOfInterest) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegionOfInterest.ProtoReflect.Descriptor instead.
+func (*RegionOfInterest) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RegionOfInterest) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *RegionOfInterest) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *RegionOfInterest) GetWidth() float64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *RegionOfInterest) GetHeight() float64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+// Дефекты покрытия сегмента
+type SegmentDefects struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SegmentId     int32                  `protobuf:"varint,1,opt,name=segment_id,json=segmentId,proto3" json:"segment_id,omitempty"` // ID сегмента из segments итога
+	Defects       []*SurfaceDefect       `protobuf:"bytes,2,rep,name=defects,proto3" json:"defects,omitempty"`                       // Дефекты по типам и степеням
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentDefects) Reset() {
+	*x = SegmentDefects{}
+	mi := &file_video_analysis_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentDefects) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentDefects) ProtoMessage() {}
+
+func (x *SegmentDefects) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentDefects.ProtoReflect.Descriptor instead.
+func (*SegmentDefects) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SegmentDefects) GetSegmentId() int32 {
+	if x != nil {
+		return x.SegmentId
+	}
+	return 0
+}
+
+func (x *SegmentDefects) GetDefects() []*SurfaceDefect {
+	if x != nil {
+		return x.Defects
+	}
+	return nil
+}
+
+// Дефекты покрытия одного типа и степени
+type SurfaceDefect struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`         // Тип дефекта: pothole, crack
+	Severity      string                 `protobuf:"bytes,2,opt,name=severity,proto3" json:"severity,omitempty"` // Степень: low, medium, high
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`      // Количество дефектов
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SurfaceDefect) Reset() {
+	*x = SurfaceDefect{}
+	mi := &file_video_analysis_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SurfaceDefect) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SurfaceDefect) ProtoMessage() {}
+
+func (x *SurfaceDefect) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SurfaceDefect.ProtoReflect.Descriptor instead.
+func (*SurfaceDefect) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SurfaceDefect) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SurfaceDefect) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *SurfaceDefect) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// Покрытие сегмента по типам разметки
+type SegmentClassCoverage struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	SegmentId     int32                   `protobuf:"varint,1,opt,name=segment_id,json=segmentId,proto3" json:"segment_id,omitempty"` // ID сегмента из segments итога
+	Classes       []*MarkingClassCoverage `protobuf:"bytes,2,rep,name=classes,proto3" json:"classes,omitempty"`                       // Покрытие по типам
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentClassCoverage) Reset() {
+	*x = SegmentClassCoverage{}
+	mi := &file_video_analysis_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentClassCoverage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentClassCoverage) ProtoMessage() {}
+
+func (x *SegmentClassCoverage) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentClassCoverage.ProtoReflect.Descriptor instead.
+func (*SegmentClassCoverage) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SegmentClassCoverage) GetSegmentId() int32 {
+	if x != nil {
+		return x.SegmentId
+	}
+	return 0
+}
+
+func (x *SegmentClassCoverage) GetClasses() []*MarkingClassCoverage {
+	if x != nil {
+		return x.Classes
+	}
+	return nil
+}
+
+// Покрытие разметкой одного типа
+type MarkingClassCoverage struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	MarkingType        string                 `protobuf:"bytes,1,opt,name=marking_type,json=markingType,proto3" json:"marking_type,omitempty"`                        // Тип разметки: lane_line, crosswalk, stop_line, arrow
+	CoveragePercentage float64                `protobuf:"fixed64,2,opt,name=coverage_percentage,json=coveragePercentage,proto3" json:"coverage_percentage,omitempty"` // Процент покрытия разметкой этого типа
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *MarkingClassCoverage) Reset() {
+	*x = MarkingClassCoverage{}
+	mi := &file_video_analysis_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkingClassCoverage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkingClassCoverage) ProtoMessage() {}
+
+func (x *MarkingClassCoverage) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkingClassCoverage.ProtoReflect.Descriptor instead.
+func (*MarkingClassCoverage) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MarkingClassCoverage) GetMarkingType() string {
+	if x != nil {
+		return x.MarkingType
+	}
+	return ""
+}
+
+func (x *MarkingClassCoverage) GetCoveragePercentage() float64 {
+	if x != nil {
+		return x.CoveragePercentage
+	}
+	return 0
+}
+
+// Уверенность модели на кадрах сегмента
+type SegmentConfidence struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	SegmentId         int32                  `protobuf:"varint,1,opt,name=segment_id,json=segmentId,proto3" json:"segment_id,omitempty"`                          // ID сегмента из segments итога
+	AverageConfidence float64                `protobuf:"fixed64,2,opt,name=average_confidence,json=averageConfidence,proto3" json:"average_confidence,omitempty"` // Средняя уверенность от 0 до 1
+	MinConfidence     float64                `protobuf:"fixed64,3,opt,name=min_confidence,json=minConfidence,proto3" json:"min_confidence,omitempty"`             // Минимальная уверенность от 0 до 1
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *SegmentConfidence) Reset() {
+	*x = SegmentConfidence{}
+	mi := &file_video_analysis_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentConfidence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentConfidence) ProtoMessage() {}
+
+func (x *SegmentConfidence) ProtoReflect() protoreflect.Message {
+	mi := &file_video_analysis_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentConfidence.ProtoReflect.Descriptor instead.
+func (*SegmentConfidence) Descriptor() ([]byte, []int) {
+	return file_video_analysis_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SegmentConfidence) GetSegmentId() int32 {
+	if x != nil {
+		return x.SegmentId
+	}
+	return 0
+}
+
+func (x *SegmentConfidence) GetAverageConfidence() float64 {
+	if x != nil {
+		return x.AverageConfidence
+	}
+	return 0
+}
+
+func (x *SegmentConfidence) GetMinConfidence() float64 {
+	if x != nil {
+		return x.MinConfidence
+	}
+	return 0
+}
+
+var File_video_analysis_proto protoreflect.FileDescriptor
+
+const file_video_analysis_proto_rawDesc = "" +
+	"\n" +
+	"\x14video_analysis.proto\x12\froad_marking\x1a\x12road_marking.proto\"\xaa\x03\n" +
+	"\x12AnalyzeVideoParams\x12%\n" +
+	"\x0evideo_filename\x18\x01 \x01(\tR\rvideoFilename\x12:\n" +
+	"\vstart_point\x18\x02 \x01(\v2\x19.road_marking.CoordinatesR\n" +
+	"startPoint\x126\n" +
+	"\tend_point\x18\x03 \x01(\v2\x19.road_marking.CoordinatesR\bendPoint\x12(\n" +
+	"\x10segment_length_m\x18\x04 \x01(\x05R\x0esegmentLengthM\x12\x19\n" +
+	"\broute_id\x18\x05 \x01(\tR\arouteId\x12*\n" +
+	"\x11frame_sample_rate\x18\x06 \x01(\x01R\x0fframeSampleRate\x121\n" +
+	"\x14confidence_threshold\x18\a \x01(\x01R\x13confidenceThreshold\x12#\n" +
+	"\rmodel_variant\x18\b \x01(\tR\fmodelVariant\x120\n" +
+	"\x03roi\x18\t \x01(\v2\x1e.road_marking.RegionOfInterestR\x03roi\"\x7f\n" +
+	"\x13AnalyzeVideoRequest\x12:\n" +
+	"\x06params\x18\x01 \x01(\v2 .road_marking.AnalyzeVideoParamsH\x00R\x06params\x12!\n" +
+	"\vvideo_chunk\x18\x02 \x01(\fH\x00R\n" +
+	"videoChunkB\t\n" +
+	"\apayload\"~\n" +
+	"\vFrameResult\x12\x1f\n" +
+	"\vframe_index\x18\x01 \x01(\x05R\n" +
+	"frameIndex\x12\x1d\n" +
+	"\n" +
+	"segment_id\x18\x02 \x01(\x05R\tsegmentId\x12/\n" +
+	"\x13coverage_percentage\x18\x03 \x01(\x01R\x12coveragePercentage\"\xfa\x02\n" +
+	"\x0fAnalysisSummary\x12?\n" +
+	"\roverall_stats\x18\x01 \x01(\v2\x1a.road_marking.OverallStatsR\foverallStats\x125\n" +
+	"\bsegments\x18\x02 \x03(\v2\x19.road_marking.SegmentInfoR\bsegments\x12E\n" +
+	"\x0fsegment_defects\x18\x03 \x03(\v2\x1c.road_marking.SegmentDefectsR\x0esegmentDefects\x12X\n" +
+	"\x16segment_class_coverage\x18\x04 \x03(\v2\".road_marking.SegmentClassCoverageR\x14segmentClassCoverage\x12N\n" +
+	"\x12segment_confidence\x18\x05 \x03(\v2\x1f.road_marking.SegmentConfidenceR\x11segmentConfidence\"\xc5\x01\n" +
+	"\x14AnalyzeVideoResponse\x121\n" +
+	"\x05frame\x18\x01 \x01(\v2\x19.road_marking.FrameResultH\x00R\x05frame\x129\n" +
+	"\asummary\x18\x02 \x01(\v2\x1d.road_marking.AnalysisSummaryH\x00R\asummary\x124\n" +
+	"\x15annotated_video_chunk\x18\x03 \x01(\fH\x00R\x13annotatedVideoChunkB\t\n" +
+	"\apayload\"\\\n" +
+	"\x10RegionOfInterest\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x01R\x01y\x12\x14\n" +
+	"\x05width\x18\x03 \x01(\x01R\x05width\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\x01R\x06height\"f\n" +
+	"\x0eSegmentDefects\x12\x1d\n" +
+	"\n" +
+	"segment_id\x18\x01 \x01(\x05R\tsegmentId\x125\n" +
+	"\adefects\x18\x02 \x03(\v2\x1b.road_marking.SurfaceDefectR\adefects\"U\n" +
+	"\rSurfaceDefect\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1a\n" +
+	"\bseverity\x18\x02 \x01(\tR\bseverity\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\"s\n" +
+	"\x14SegmentClassCoverage\x12\x1d\n" +
+	"\n" +
+	"segment_id\x18\x01 \x01(\x05R\tsegmentId\x12<\n" +
+	"\aclasses\x18\x02 \x03(\v2\".road_marking.MarkingClassCoverageR\aclasses\"j\n" +
+	"\x14MarkingClassCoverage\x12!\n" +
+	"\fmarking_type\x18\x01 \x01(\tR\vmarkingType\x12/\n" +
+	"\x13coverage_percentage\x18\x02 \x01(\x01R\x12coveragePercentage\"\x88\x01\n" +
+	"\x11SegmentConfidence\x12\x1d\n" +
+	"\n" +
+	"segment_id\x18\x01 \x01(\x05R\tsegmentId\x12-\n" +
+	"\x12average_confidence\x18\x02 \x01(\x01R\x11averageConfidence\x12%\n" +
+	"\x0emin_confidence\x18\x03 \x01(\x01R\rminConfidence2q\n" +
+	"\x14VideoAnalysisService\x12Y\n" +
+	"\fAnalyzeVideo\x12!.road_marking.AnalyzeVideoRequest\x1a\".road_marking.AnalyzeVideoResponse(\x010\x01B-Z+github.com/road-detector/proto/road_markingb\x06proto3"
+
+var (
+	file_video_analysis_proto_rawDescOnce sync.Once
+	file_video_analysis_proto_rawDescData []byte
+)
+
+func file_video_analysis_proto_rawDescGZIP() []byte {
+	file_video_analysis_proto_rawDescOnce.Do(func() {
+		file_video_analysis_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_video_analysis_proto_rawDesc), len(file_video_analysis_proto_rawDesc)))
+	})
+	return file_video_analysis_proto_rawDescData
+}
+
+var file_video_analysis_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_video_analysis_proto_goTypes = []any{
+	(*AnalyzeVideoParams)(nil),   // 0: road_marking.AnalyzeVideoParams
+	(*AnalyzeVideoRequest)(nil),  // 1: road_marking.AnalyzeVideoRequest
+	(*FrameResult)(nil),          // 2: road_marking.FrameResult
+	(*AnalysisSummary)(nil),      // 3: road_marking.AnalysisSummary
+	(*AnalyzeVideoResponse)(nil), // 4: road_marking.AnalyzeVideoResponse
+	(*RegionOfInterest)(nil),     // 5: road_marking.RegionOfInterest
+	(*SegmentDefects)(nil),       // 6: road_marking.SegmentDefects
+	(*SurfaceDefect)(nil),        // 7: road_marking.SurfaceDefect
+	(*SegmentClassCoverage)(nil), // 8: road_marking.SegmentClassCoverage
+	(*MarkingClassCoverage)(nil), // 9: road_marking.MarkingClassCoverage
+	(*SegmentConfidence)(nil),    // 10: road_marking.SegmentConfidence
+	(*Coordinates)(nil),          // 11: road_marking.Coordinates
+	(*OverallStats)(nil),         // 12: road_marking.OverallStats
+	(*SegmentInfo)(nil),          // 13: road_marking.SegmentInfo
+}
+var file_video_analysis_proto_depIdxs = []int32{
+	11, // 0: road_marking.AnalyzeVideoParams.start_point:type_name -> road_marking.Coordinates
+	11, // 1: road_marking.AnalyzeVideoParams.end_point:type_name -> road_marking.Coordinates
+	5,  // 2: road_marking.AnalyzeVideoParams.roi:type_name -> road_marking.RegionOfInterest
+	0,  // 3: road_marking.AnalyzeVideoRequest.params:type_name -> road_marking.AnalyzeVideoParams
+	12, // 4: road_marking.AnalysisSummary.overall_stats:type_name -> road_marking.OverallStats
+	13, // 5: road_marking.AnalysisSummary.segments:type_name -> road_marking.SegmentInfo
+	6,  // 6: road_marking.AnalysisSummary.segment_defects:type_name -> road_marking.SegmentDefects
+	8,  // 7: road_marking.AnalysisSummary.segment_class_coverage:type_name -> road_marking.SegmentClassCoverage
+	10, // 8: road_marking.AnalysisSummary.segment_confidence:type_name -> road_marking.SegmentConfidence
+	2,  // 9: road_marking.AnalyzeVideoResponse.frame:type_name -> road_marking.FrameResult
+	3,  // 10: road_marking.AnalyzeVideoResponse.summary:type_name -> road_marking.AnalysisSummary
+	7,  // 11: road_marking.SegmentDefects.defects:type_name -> road_marking.SurfaceDefect
+	9,  // 12: road_marking.SegmentClassCoverage.classes:type_name -> road_marking.MarkingClassCoverage
+	1,  // 13: road_marking.VideoAnalysisService.AnalyzeVideo:input_type -> road_marking.AnalyzeVideoRequest
+	4,  // 14: road_marking.VideoAnalysisService.AnalyzeVideo:output_type -> road_marking.AnalyzeVideoResponse
+	14, // [14:15] is the sub-list for method output_type
+	13, // [13:14] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_video_analysis_proto_init() }
+func file_video_analysis_proto_init() {
+	if File_video_analysis_proto != nil {
+		return
+	}
+	file_road_marking_proto_init()
+	file_video_analysis_proto_msgTypes[1].OneofWrappers = []any{
+		(*AnalyzeVideoRequest_Params)(nil),
+		(*AnalyzeVideoRequest_VideoChunk)(nil),
+	}
+	file_video_analysis_proto_msgTypes[4].OneofWrappers = []any{
+		(*AnalyzeVideoResponse_Frame)(nil),
+		(*AnalyzeVideoResponse_Summary)(nil),
+		(*AnalyzeVideoResponse_AnnotatedVideoChunk)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_video_analysis_proto_rawDesc), len(file_video_analysis_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_video_analysis_proto_goTypes,
+		DependencyIndexes: file_video_analysis_proto_depIdxs,
+		MessageInfos:      file_video_analysis_proto_msgTypes,
+	}.Build()
+	File_video_analysis_proto = out.File
+	file_video_analysis_proto_goTypes = nil
+	file_video_analysis_proto_depIdxs = nil
+}
@@ -0,0 +1,108 @@
+// Package quality считает индекс качества дороги от 0 до 100 по покрытию
+// разметкой, плотности дефектов покрытия и уверенности модели.
+package quality
+
+import (
+	"fmt"
+	"math"
+)
+
+// Options веса составляющих индекса и плотность дефектов, при которой
+// составляющая дефектов равна 0. Веса нормируются, их сумма не важна.
+type Options struct {
+	CoverageWeight   float64
+	DefectWeight     float64
+	ConfidenceWeight float64
+	// MaxDefectsPerKm плотность дефектов на километр, при которой и выше
+	// которой составляющая дефектов равна 0
+	MaxDefectsPerKm float64
+}
+
+// Segment данные сегмента для расчета индекса
+type Segment struct {
+	HasData bool
+	// Coverage покрытие разметкой в процентах
+	Coverage float64
+	// Defects число дефектов покрытия
+	Defects int
+	// LengthMeters длина сегмента
+	LengthMeters float64
+	// Confidence средняя уверенность модели от 0 до 1, nil — неизвестна
+	Confidence *float64
+}
+
+// Scorer считает индекс качества
+type Scorer struct {
+	opts Options
+}
+
+// New проверяет веса: они не могут быть отрицательными, и хотя бы вес
+// покрытия или дефектов должен быть положительным
+func New(opts Options) (*Scorer, error) {
+	if opts.CoverageWeight < 0 || opts.DefectWeight < 0 || opts.ConfidenceWeight < 0 {
+		return nil, fmt.Errorf("quality weights must not be negative")
+	}
+	if opts.CoverageWeight+opts.DefectWeight == 0 {
+		return nil, fmt.Errorf("coverage or defect weight must be positive")
+	}
+	if opts.MaxDefectsPerKm <= 0 {
+		return nil, fmt.Errorf("max defects per km must be positive")
+	}
+	return &Scorer{opts: opts}, nil
+}
+
+// Segment возвращает индекс качества сегмента, nil — у сегмента нет
+// данных. Неизвестная уверенность не учитывается, веса остальных
+// составляющих нормируются без нее.
+func (s *Scorer) Segment(seg Segment) *float64 {
+	if !seg.HasData {
+		return nil
+	}
+
+	coverage := math.Min(math.Max(seg.Coverage, 0), 100) / 100
+	defects := 1.0
+	if seg.Defects > 0 {
+		defects = 0
+		if seg.LengthMeters > 0 {
+			density := float64(seg.Defects) / (seg.LengthMeters / 1000)
+			defects = math.Max(0, 1-density/s.opts.MaxDefectsPerKm)
+		}
+	}
+
+	sum := s.opts.CoverageWeight*coverage + s.opts.DefectWeight*defects
+	weight := s.opts.CoverageWeight + s.opts.DefectWeight
+	if seg.Confidence != nil {
+		sum += s.opts.ConfidenceWeight * math.Min(math.Max(*seg.Confidence, 0), 1)
+		weight += s.opts.ConfidenceWeight
+	}
+	score := round(100 * sum / weight)
+	return &score
+}
+
+// Route возвращает индекс качества маршрута — среднее индексов сегментов,
+// взвешенное по их длине. nil — ни у одного сегмента нет индекса.
+func Route(scores []*float64, lengths []float64) *float64 {
+	var sum, total float64
+	for i, score := range scores {
+		if score == nil {
+			continue
+		}
+		// Сегмент нулевой длины учитывается с единичным весом
+		weight := 1.0
+		if i < len(lengths) && lengths[i] > 0 {
+			weight = lengths[i]
+		}
+		sum += *score * weight
+		total += weight
+	}
+	if total == 0 {
+		return nil
+	}
+	score := round(sum / total)
+	return &score
+}
+
+// round округляет индекс до десятых
+func round(score float64) float64 {
+	return math.Round(score*10) / 10
+}
@@ -0,0 +1,32 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"road-detector-go/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware ограничивает частоту запросов к /api/ для каждого клиента.
+// Клиент определяется функцией key. При превышении возвращается 429
+// с заголовком Retry-After.
+func Middleware(l *Limiter, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.Next()
+			return
+		}
+
+		allowed, wait := l.Allow(key(c))
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			apierror.Abort(c, apierror.New(apierror.CodeRateLimited, "Слишком много запросов, повторите позже"))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,132 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket
+// с отдельной корзиной для каждого клиента.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultIdleTTL через сколько удаляется корзина клиента без запросов
+	defaultIdleTTL = 10 * time.Minute
+	// cleanupInterval как часто удаляются неиспользуемые корзины
+	cleanupInterval = time.Minute
+)
+
+// Options настройки ограничения
+type Options struct {
+	// RPS средняя допустимая частота запросов в секунду
+	RPS float64
+	// Burst сколько запросов можно выполнить подряд без ожидания
+	Burst int
+	// IdleTTL через сколько забывается клиент без запросов
+	IdleTTL time.Duration
+}
+
+// Limiter хранит корзины токенов клиентов в памяти процесса
+type Limiter struct {
+	opts Options
+	now  func() time.Time
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+// bucket корзина токенов одного клиента
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// New создает Limiter. RPS 0 отключает ограничение, Burst меньше 1
+// заменяется на 1.
+func New(opts Options) *Limiter {
+	if opts.IdleTTL <= 0 {
+		opts.IdleTTL = defaultIdleTTL
+	}
+	l := &Limiter{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+	l.opts = opts
+	l.SetLimits(opts.RPS, opts.Burst)
+	return l
+}
+
+// SetLimits меняет частоту и размер корзины без сброса накопленных токенов.
+// Лишние токены отбрасываются при следующем запросе клиента.
+func (l *Limiter) SetLimits(rps float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.opts.RPS = rps
+	l.opts.Burst = burst
+}
+
+// Enabled проверяет, что ограничение включено
+func (l *Limiter) Enabled() bool {
+	return l.RPS() > 0
+}
+
+// RPS возвращает допустимую частоту запросов
+func (l *Limiter) RPS() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.opts.RPS
+}
+
+// Burst возвращает размер корзины
+func (l *Limiter) Burst() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.opts.Burst
+}
+
+// Allow расходует токен клиента key. Если токенов нет, возвращает false
+// и время, через которое появится следующий токен.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.opts.RPS <= 0 {
+		return true, 0
+	}
+
+	now := l.now()
+	l.cleanup(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.opts.Burst), updated: now}
+		l.buckets[key] = b
+	} else {
+		elapsed := now.Sub(b.updated).Seconds()
+		b.tokens = math.Min(float64(l.opts.Burst), b.tokens+elapsed*l.opts.RPS)
+		b.updated = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.opts.RPS * float64(time.Second))
+	return false, wait
+}
+
+// cleanup удаляет корзины клиентов, давно не выполнявших запросы
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > l.opts.IdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
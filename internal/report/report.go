@@ -0,0 +1,215 @@
+// Package report отрисовывает сводные отчеты об анализах и отчеты маршрутов
+// в HTML и преобразует их в PDF внешней командой, например wkhtmltopdf.
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/mediatool"
+)
+
+// ErrPDFDisabled возвращается, если команда преобразования в PDF не задана
+var ErrPDFDisabled = errors.New("pdf rendering is not configured")
+
+// Options настройки отрисовки отчетов
+type Options struct {
+	// PDFCommand команда, которая читает HTML из stdin и пишет PDF в stdout,
+	// например "wkhtmltopdf --quiet - -"; пусто — PDF не создается
+	PDFCommand string
+	// Timeout ожидание команды PDF, а также ffmpeg при извлечении кадров
+	Timeout time.Duration
+	// FFmpegPath и FFprobePath пути к ffmpeg и ffprobe для кадров видео в
+	// отчете маршрута, пусто — из PATH
+	FFmpegPath  string
+	FFprobePath string
+}
+
+// Segment сегмент маршрута в отчете
+type Segment struct {
+	RouteID            string  `json:"route_id"`
+	RouteName          string  `json:"route_name,omitempty"`
+	RoadName           string  `json:"road_name,omitempty"`
+	SegmentID          int     `json:"segment_id"`
+	CoveragePercentage float64 `json:"coverage_percentage"`
+}
+
+// Data содержимое отчета за период [From, To)
+type Data struct {
+	Title  string    `json:"title"`
+	Period string    `json:"period"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+
+	// Analyses число новых анализов за период
+	Analyses         int64   `json:"analyses"`
+	DistanceKm       float64 `json:"distance_km"`
+	SegmentsWithData int64   `json:"segments_with_data"`
+	// AverageCoverage среднее покрытие сегментов с данными, nil — таких нет
+	AverageCoverage *float64 `json:"average_coverage,omitempty"`
+
+	// PreviousAnalyses и PreviousAverageCoverage те же показатели за
+	// предыдущий период такой же длины
+	PreviousAnalyses        int64    `json:"previous_analyses"`
+	PreviousAverageCoverage *float64 `json:"previous_average_coverage,omitempty"`
+	// CoverageChange изменение среднего покрытия к предыдущему периоду в
+	// процентных пунктах, nil — покрытие одного из периодов неизвестно
+	CoverageChange *float64 `json:"coverage_change,omitempty"`
+
+	// WorstSegments сегменты с наименьшим покрытием среди анализов периода
+	WorstSegments []Segment `json:"worst_segments"`
+}
+
+// Renderer отрисовывает отчеты
+type Renderer struct {
+	opts Options
+	args []string
+}
+
+// New создает Renderer
+func New(opts Options) *Renderer {
+	if opts.FFmpegPath == "" {
+		opts.FFmpegPath = "ffmpeg"
+	}
+	if opts.FFprobePath == "" {
+		opts.FFprobePath = "ffprobe"
+	}
+	return &Renderer{opts: opts, args: strings.Fields(opts.PDFCommand)}
+}
+
+// PDFEnabled проверяет, задана ли команда преобразования в PDF
+func (r *Renderer) PDFEnabled() bool {
+	return r != nil && len(r.args) > 0
+}
+
+// HTML отрисовывает отчет в HTML страницу
+func (r *Renderer) HTML(data Data) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// PDF отрисовывает отчет в HTML и преобразует его в PDF командой PDFCommand
+func (r *Renderer) PDF(ctx context.Context, data Data) ([]byte, error) {
+	if !r.PDFEnabled() {
+		return nil, ErrPDFDisabled
+	}
+	html, err := r.HTML(data)
+	if err != nil {
+		return nil, err
+	}
+	return r.convert(ctx, html)
+}
+
+// convert преобразует HTML страницу в PDF командой PDFCommand
+func (r *Renderer) convert(ctx context.Context, html []byte) ([]byte, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	out, err := mediatool.RunInput(ctx, html, r.args[0], r.args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF")) {
+		return nil, fmt.Errorf("%s did not produce a pdf document", filepath.Base(r.args[0]))
+	}
+	return out, nil
+}
+
+// withTimeout ограничивает ctx ожиданием Timeout, если оно задано
+func (r *Renderer) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.opts.Timeout > 0 {
+		return context.WithTimeout(ctx, r.opts.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Text возвращает краткое текстовое содержание отчета для письма
+func Text(data Data) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\nПериод: %s — %s\n\n", data.Title,
+		data.From.Format("02.01.2006"), data.To.Add(-time.Second).Format("02.01.2006"))
+	fmt.Fprintf(&b, "Новых анализов: %d (в предыдущем периоде: %d)\n", data.Analyses, data.PreviousAnalyses)
+	fmt.Fprintf(&b, "Пройдено: %.1f км\n", data.DistanceKm)
+	if data.AverageCoverage != nil {
+		fmt.Fprintf(&b, "Среднее покрытие: %.1f%%", *data.AverageCoverage)
+		if data.CoverageChange != nil {
+			fmt.Fprintf(&b, " (%+.1f п.п. к предыдущему периоду)", *data.CoverageChange)
+		}
+		b.WriteString("\n")
+	}
+	if len(data.WorstSegments) > 0 {
+		b.WriteString("\nХудшие сегменты:\n")
+		for _, seg := range data.WorstSegments {
+			fmt.Fprintf(&b, "- %s, сегмент №%d: %.1f%%\n", segmentRoute(seg), seg.SegmentID, seg.CoveragePercentage)
+		}
+	}
+	return b.String()
+}
+
+// segmentRoute название маршрута сегмента для отчета
+func segmentRoute(seg Segment) string {
+	name := seg.RouteName
+	if name == "" {
+		name = seg.RouteID
+	}
+	if seg.RoadName != "" {
+		name += " (" + seg.RoadName + ")"
+	}
+	return name
+}
+
+// page шаблон HTML отчета. Стили встроены, чтобы отчет одинаково выглядел
+// в почтовом клиенте и после преобразования в PDF.
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date":  func(t time.Time) string { return t.Format("02.01.2006") },
+	"until": func(t time.Time) string { return t.Add(-time.Second).Format("02.01.2006") },
+	"route": segmentRoute,
+	"value": func(v *float64) float64 { return *v },
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: "DejaVu Sans", Arial, sans-serif; color: #212121; margin: 24px; }
+h1 { font-size: 22px; margin-bottom: 4px; }
+.period { color: #616161; margin-top: 0; }
+table { border-collapse: collapse; width: 100%; margin-top: 12px; }
+th, td { border: 1px solid #e0e0e0; padding: 6px 8px; text-align: left; }
+th { background: #f5f5f5; }
+.down { color: #d32f2f; }
+.up { color: #2e7d32; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="period">{{date .From}} — {{until .To}}</p>
+<table>
+<tr><th>Показатель</th><th>За период</th><th>Предыдущий период</th></tr>
+<tr><td>Новых анализов</td><td>{{.Analyses}}</td><td>{{.PreviousAnalyses}}</td></tr>
+<tr><td>Пройдено, км</td><td>{{printf "%.1f" .DistanceKm}}</td><td></td></tr>
+<tr><td>Сегментов с данными</td><td>{{.SegmentsWithData}}</td><td></td></tr>
+<tr><td>Среднее покрытие</td>
+<td>{{with .AverageCoverage}}{{printf "%.1f%%" (value .)}}{{else}}—{{end}}{{with .CoverageChange}} <span class="{{if lt (value .) 0.0}}down{{else}}up{{end}}">({{printf "%+.1f" (value .)}} п.п.)</span>{{end}}</td>
+<td>{{with .PreviousAverageCoverage}}{{printf "%.1f%%" (value .)}}{{else}}—{{end}}</td></tr>
+</table>
+{{if .WorstSegments}}
+<h2>Худшие сегменты</h2>
+<table>
+<tr><th>Маршрут</th><th>Сегмент</th><th>Покрытие</th></tr>
+{{range .WorstSegments}}<tr><td>{{route .}}</td><td>{{.SegmentID}}</td><td>{{printf "%.1f%%" .CoveragePercentage}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>За период нет сегментов с данными.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,256 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/mediatool"
+)
+
+const (
+	// mapWidth ширина схемы маршрута в отчете, высота зависит от формы маршрута
+	mapWidth = 700
+	// mapMaxHeight ограничение высоты схемы маршрута
+	mapMaxHeight = 450
+	// mapPadding отступ схемы от краев
+	mapPadding = 12
+	// noDataColor цвет сегментов без данных на схеме и в таблице
+	noDataColor = "#9e9e9e"
+	// frameWidth ширина кадров видео в отчете
+	frameWidth = 640
+)
+
+// Point точка маршрута
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// RouteSegment сегмент в отчете маршрута
+type RouteSegment struct {
+	SegmentID          int
+	Start              Point
+	End                Point
+	HasData            bool
+	CoveragePercentage float64
+	// Band и Color полоса покрытия сегмента и ее цвет, пусто — без полосы
+	Band         string
+	Color        string
+	DefectsCount int
+	QualityScore *float64
+}
+
+// Frame кадр аннотированного видео в отчете маршрута
+type Frame struct {
+	SegmentID          int
+	CoveragePercentage float64
+	// Image кадр в формате JPEG
+	Image []byte
+}
+
+// RouteData содержимое отчета маршрута
+type RouteData struct {
+	RouteID   string
+	Name      string
+	RoadName  string
+	CreatedAt time.Time
+
+	DistanceKm       float64
+	TotalSegments    int
+	SegmentsWithData int
+	AverageCoverage  float64
+	// Band и Color полоса среднего покрытия маршрута и ее цвет
+	Band         string
+	Color        string
+	TotalDefects int
+	QualityScore *float64
+
+	Segments []RouteSegment
+	// Frames выбранные кадры аннотированного видео, могут отсутствовать
+	Frames []Frame
+}
+
+// RouteHTML отрисовывает отчет маршрута в HTML страницу
+func (r *Renderer) RouteHTML(data RouteData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := routePage.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render route report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RoutePDF отрисовывает отчет маршрута в HTML и преобразует его в PDF
+// командой PDFCommand
+func (r *Renderer) RoutePDF(ctx context.Context, data RouteData) ([]byte, error) {
+	if !r.PDFEnabled() {
+		return nil, ErrPDFDisabled
+	}
+	page, err := r.RouteHTML(data)
+	if err != nil {
+		return nil, err
+	}
+	return r.convert(ctx, page)
+}
+
+// Frames извлекает из видео кадры JPEG в точках positions — долях
+// длительности видео от 0 до 1
+func (r *Renderer) Frames(ctx context.Context, video string, positions []float64) ([][]byte, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	out, err := mediatool.Run(ctx, r.opts.FFprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		video,
+	)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse video duration %q: %w", strings.TrimSpace(string(out)), err)
+	}
+
+	frames := make([][]byte, 0, len(positions))
+	for _, position := range positions {
+		at := math.Max(0, math.Min(position, 1)) * duration
+		frame, err := mediatool.Run(ctx, r.opts.FFmpegPath,
+			"-hide_banner", "-loglevel", "error", "-nostdin",
+			"-ss", strconv.FormatFloat(at, 'f', 3, 64),
+			"-i", video,
+			"-frames:v", "1",
+			"-vf", fmt.Sprintf("scale=%d:-2", frameWidth),
+			"-f", "image2pipe", "-c:v", "mjpeg", "-q:v", "4",
+			"pipe:1",
+		)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// routeMap рисует схему маршрута в SVG: сегменты окрашены в цвета полос
+// покрытия, сегменты без данных — серым. Долгота масштабируется по широте
+// центра, чтобы маршрут не искажался.
+func routeMap(segments []RouteSegment) template.HTML {
+	if len(segments) == 0 {
+		return ""
+	}
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	for _, seg := range segments {
+		for _, p := range []Point{seg.Start, seg.End} {
+			minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+			minLon, maxLon = math.Min(minLon, p.Lon), math.Max(maxLon, p.Lon)
+		}
+	}
+	scaleX := math.Cos((minLat + maxLat) / 2 * math.Pi / 180)
+	spanX := math.Max((maxLon-minLon)*scaleX, 1e-6)
+	spanY := math.Max(maxLat-minLat, 1e-6)
+	scale := float64(mapWidth-2*mapPadding) / spanX
+	if spanY*scale > mapMaxHeight-2*mapPadding {
+		scale = float64(mapMaxHeight-2*mapPadding) / spanY
+	}
+	height := int(math.Ceil(spanY*scale)) + 2*mapPadding
+	project := func(p Point) (float64, float64) {
+		return mapPadding + (p.Lon-minLon)*scaleX*scale, float64(height-mapPadding) - (p.Lat-minLat)*scale
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		mapWidth, height, mapWidth, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fafafa" stroke="#e0e0e0"/>`, mapWidth, height)
+	for _, seg := range segments {
+		x1, y1 := project(seg.Start)
+		x2, y2 := project(seg.End)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="5" stroke-linecap="round"/>`,
+			x1, y1, x2, y2, html.EscapeString(segmentColor(seg)))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// segmentColor цвет сегмента на схеме и в таблице
+func segmentColor(seg RouteSegment) string {
+	if !seg.HasData || seg.Color == "" {
+		return noDataColor
+	}
+	return seg.Color
+}
+
+// routePage шаблон HTML отчета маршрута для печати и приложения к заявке
+// на ремонт разметки
+var routePage = template.Must(template.New("route").Funcs(template.FuncMap{
+	"datetime": func(t time.Time) string { return t.UTC().Format("02.01.2006 15:04 UTC") },
+	"value":    func(v *float64) float64 { return *v },
+	"routemap": routeMap,
+	"color":    func(seg RouteSegment) template.CSS { return template.CSS(segmentColor(seg)) },
+	"jpeg": func(image []byte) template.URL {
+		return template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(image))
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body { font-family: "DejaVu Sans", Arial, sans-serif; color: #212121; margin: 24px; font-size: 13px; }
+h1 { font-size: 22px; margin-bottom: 4px; }
+.meta { color: #616161; margin-top: 0; }
+table { border-collapse: collapse; width: 100%; margin-top: 12px; }
+th, td { border: 1px solid #e0e0e0; padding: 4px 8px; text-align: left; }
+th { background: #f5f5f5; }
+tr { page-break-inside: avoid; }
+.swatch { display: inline-block; width: 12px; height: 12px; margin-right: 6px; vertical-align: middle; }
+.frame { display: inline-block; width: 48%; margin-right: 1%; vertical-align: top; page-break-inside: avoid; }
+.frame img { width: 100%; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p class="meta">{{if .RoadName}}{{.RoadName}}, {{end}}анализ {{datetime .CreatedAt}}, маршрут {{.RouteID}}</p>
+
+<div>{{routemap .Segments}}</div>
+
+<table>
+<tr><th>Показатель</th><th>Значение</th></tr>
+<tr><td>Протяженность, км</td><td>{{printf "%.2f" .DistanceKm}}</td></tr>
+<tr><td>Сегментов</td><td>{{.TotalSegments}}, с данными: {{.SegmentsWithData}}</td></tr>
+<tr><td>Среднее покрытие</td><td>{{if .Color}}<span class="swatch" style="background: {{.Color}}"></span>{{end}}{{printf "%.1f%%" .AverageCoverage}}{{with .Band}} ({{.}}){{end}}</td></tr>
+{{with .QualityScore}}<tr><td>Индекс качества</td><td>{{printf "%.0f" (value .)}} из 100</td></tr>{{end}}
+<tr><td>Дефектов разметки</td><td>{{.TotalDefects}}</td></tr>
+</table>
+
+{{if .Frames}}
+<h2>Кадры участков с наименьшим покрытием</h2>
+{{range .Frames}}<div class="frame"><img src="{{jpeg .Image}}" alt="Сегмент {{.SegmentID}}"><br>Сегмент №{{.SegmentID}}: {{printf "%.1f%%" .CoveragePercentage}}</div>
+{{end}}
+{{end}}
+
+<h2>Сегменты</h2>
+<table>
+<tr><th>№</th><th>Начало</th><th>Конец</th><th>Покрытие</th><th>Полоса</th><th>Дефекты</th><th>Индекс</th></tr>
+{{range .Segments}}<tr>
+<td>{{.SegmentID}}</td>
+<td>{{printf "%.6f, %.6f" .Start.Lat .Start.Lon}}</td>
+<td>{{printf "%.6f, %.6f" .End.Lat .End.Lon}}</td>
+<td><span class="swatch" style="background: {{color .}}"></span>{{if .HasData}}{{printf "%.1f%%" .CoveragePercentage}}{{else}}нет данных{{end}}</td>
+<td>{{.Band}}</td>
+<td>{{.DefectsCount}}</td>
+<td>{{with .QualityScore}}{{printf "%.0f" (value .)}}{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
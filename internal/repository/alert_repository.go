@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAlertRuleNotFound возвращается, если правило отсутствует или
+	// принадлежит другой организации
+	ErrAlertRuleNotFound = errors.New("alert rule not found")
+	// ErrAlertNotFound возвращается, если оповещение отсутствует или
+	// принадлежит другой организации
+	ErrAlertNotFound = errors.New("alert not found")
+)
+
+// AlertQuery фильтры и страница списка оповещений
+type AlertQuery struct {
+	Status   string
+	RuleID   uint
+	RouteID  string
+	Page     int
+	PageSize int
+}
+
+// AlertRepository интерфейс для работы с правилами оповещений и оповещениями.
+// Записи выбираются в пределах организации, nil — записи без организации.
+type AlertRepository interface {
+	CreateRule(rule *model.AlertRule) error
+	ListRules(orgID *uint) ([]model.AlertRule, error)
+	GetRule(id uint, orgID *uint) (*model.AlertRule, error)
+	UpdateRule(rule *model.AlertRule) error
+	DeleteRule(id uint, orgID *uint) error
+	ListActiveRules(orgID *uint) ([]model.AlertRule, error)
+	CreateAlert(alert *model.Alert, newEvents func(alert *model.Alert) ([]*model.OutboxEvent, error)) error
+	ListAlerts(orgID *uint, query AlertQuery) ([]model.Alert, int64, error)
+	GetAlert(id uint, orgID *uint) (*model.Alert, error)
+	UpdateAlert(alert *model.Alert) error
+	DeleteAlert(id uint, orgID *uint) error
+}
+
+// alertRepository реализация AlertRepository
+type alertRepository struct {
+	db *gorm.DB
+}
+
+// NewAlertRepository создает новый instance AlertRepository
+func NewAlertRepository(db *gorm.DB) AlertRepository {
+	return &alertRepository{
+		db: db,
+	}
+}
+
+// CreateRule сохраняет правило
+func (r *alertRepository) CreateRule(rule *model.AlertRule) error {
+	if err := r.db.Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return nil
+}
+
+// ListRules получает правила организации в порядке создания
+func (r *alertRepository) ListRules(orgID *uint) ([]model.AlertRule, error) {
+	var rules []model.AlertRule
+	if err := organizationScope(r.db, orgID).Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// GetRule получает правило организации по ID
+func (r *alertRepository) GetRule(id uint, orgID *uint) (*model.AlertRule, error) {
+	var rule model.AlertRule
+	err := organizationScope(r.db, orgID).Where("id = ?", id).First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %d", ErrAlertRuleNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// UpdateRule сохраняет изменения правила
+func (r *alertRepository) UpdateRule(rule *model.AlertRule) error {
+	if err := r.db.Save(rule).Error; err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return nil
+}
+
+// DeleteRule удаляет правило организации. Созданные по нему оповещения
+// сохраняются.
+func (r *alertRepository) DeleteRule(id uint, orgID *uint) error {
+	result := organizationScope(r.db, orgID).Where("id = ?", id).Delete(&model.AlertRule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id %d", ErrAlertRuleNotFound, id)
+	}
+	return nil
+}
+
+// ListActiveRules получает включенные правила, которые проверяются для
+// маршрута организации orgID: правила этой организации и правила без организации
+func (r *alertRepository) ListActiveRules(orgID *uint) ([]model.AlertRule, error) {
+	db := r.db.Where("active = ?", true)
+	if orgID == nil {
+		db = db.Where("organization_id IS NULL")
+	} else {
+		db = db.Where("organization_id IS NULL OR organization_id = ?", *orgID)
+	}
+
+	var rules []model.AlertRule
+	if err := db.Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list active alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// CreateAlert сохраняет оповещение и события о нем в одной транзакции.
+// newEvents вызывается после сохранения, когда известен ID оповещения,
+// nil — события не сохраняются.
+func (r *alertRepository) CreateAlert(alert *model.Alert, newEvents func(alert *model.Alert) ([]*model.OutboxEvent, error)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(alert).Error; err != nil {
+			return fmt.Errorf("failed to create alert: %w", err)
+		}
+		if newEvents == nil {
+			return nil
+		}
+		events, err := newEvents(alert)
+		if err != nil {
+			return err
+		}
+		return createOutboxEvents(tx, events)
+	})
+}
+
+// ListAlerts получает страницу оповещений организации, начиная с последних
+func (r *alertRepository) ListAlerts(orgID *uint, query AlertQuery) ([]model.Alert, int64, error) {
+	db := organizationScope(r.db.Model(&model.Alert{}), orgID)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.RuleID != 0 {
+		db = db.Where("rule_id = ?", query.RuleID)
+	}
+	if query.RouteID != "" {
+		db = db.Where("route_id = ?", query.RouteID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count alerts: %w", err)
+	}
+
+	var alerts []model.Alert
+	err := db.Order("created_at DESC, id DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&alerts).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	return alerts, total, nil
+}
+
+// GetAlert получает оповещение организации по ID
+func (r *alertRepository) GetAlert(id uint, orgID *uint) (*model.Alert, error) {
+	var alert model.Alert
+	err := organizationScope(r.db, orgID).Where("id = ?", id).First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %d", ErrAlertNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+	return &alert, nil
+}
+
+// UpdateAlert сохраняет изменения оповещения
+func (r *alertRepository) UpdateAlert(alert *model.Alert) error {
+	if err := r.db.Save(alert).Error; err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+	return nil
+}
+
+// DeleteAlert удаляет оповещение организации
+func (r *alertRepository) DeleteAlert(id uint, orgID *uint) error {
+	result := organizationScope(r.db, orgID).Where("id = ?", id).Delete(&model.Alert{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete alert: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id %d", ErrAlertNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrAnalysisJobNotFound возвращается, если анализа в очереди нет
+var ErrAnalysisJobNotFound = errors.New("analysis job not found")
+
+// AnalysisJobRepository интерфейс для работы с очередью анализов
+type AnalysisJobRepository interface {
+	Create(job *model.AnalysisJob) error
+	GetByID(id string) (*model.AnalysisJob, error)
+	Claim(workerID string, staleBefore time.Time) (*model.AnalysisJob, error)
+	Heartbeat(id, workerID string) error
+	Finish(job *model.AnalysisJob) error
+	CountByStatus() (map[string]int64, error)
+}
+
+// analysisJobRepository реализация AnalysisJobRepository
+type analysisJobRepository struct {
+	db *gorm.DB
+}
+
+// NewAnalysisJobRepository создает новый instance AnalysisJobRepository
+func NewAnalysisJobRepository(db *gorm.DB) AnalysisJobRepository {
+	return &analysisJobRepository{
+		db: db,
+	}
+}
+
+// Create ставит анализ в очередь
+func (r *analysisJobRepository) Create(job *model.AnalysisJob) error {
+	if err := r.db.Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create analysis job: %w", err)
+	}
+	return nil
+}
+
+// GetByID получает анализ из очереди по ID
+func (r *analysisJobRepository) GetByID(id string) (*model.AnalysisJob, error) {
+	var job model.AnalysisJob
+	if err := r.db.Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAnalysisJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get analysis job: %w", err)
+	}
+	return &job, nil
+}
+
+// Claim берет для воркера workerID самый старый анализ в очереди или
+// анализ, воркер которого не подавал сигнал с staleBefore. Строка
+// блокируется до конца транзакции, поэтому два воркера не берут один
+// анализ. Возвращает nil, если брать нечего.
+func (r *analysisJobRepository) Claim(workerID string, staleBefore time.Time) (*model.AnalysisJob, error) {
+	var claimed *model.AnalysisJob
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var job model.AnalysisJob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND heartbeat_at < ?)",
+				model.AnalysisJobQueued, model.AnalysisJobRunning, staleBefore).
+			Order("created_at ASC").
+			Limit(1).
+			Find(&job).Error
+		if err != nil {
+			return fmt.Errorf("failed to select analysis job: %w", err)
+		}
+		if job.ID == "" {
+			return nil
+		}
+
+		now := time.Now()
+		job.Status = model.AnalysisJobRunning
+		job.WorkerID = workerID
+		job.Attempts++
+		job.HeartbeatAt = &now
+		job.StartedAt = &now
+		err = tx.Model(&job).Updates(map[string]interface{}{
+			"status":       job.Status,
+			"worker_id":    job.WorkerID,
+			"attempts":     job.Attempts,
+			"heartbeat_at": now,
+			"started_at":   now,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to claim analysis job: %w", err)
+		}
+		claimed = &job
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// Heartbeat отмечает, что воркер workerID продолжает анализ. Возвращает
+// ErrAnalysisJobNotFound, если анализ уже забрал другой воркер.
+func (r *analysisJobRepository) Heartbeat(id, workerID string) error {
+	result := r.db.Model(&model.AnalysisJob{}).
+		Where("id = ? AND worker_id = ? AND status = ?", id, workerID, model.AnalysisJobRunning).
+		Update("heartbeat_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to update analysis job heartbeat: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAnalysisJobNotFound
+	}
+	return nil
+}
+
+// Finish сохраняет статус, результат и ошибку анализа. Результат
+// сохраняется, только если анализ не забрал другой воркер.
+func (r *analysisJobRepository) Finish(job *model.AnalysisJob) error {
+	result := r.db.Model(&model.AnalysisJob{}).
+		Where("id = ? AND worker_id = ? AND status = ?", job.ID, job.WorkerID, model.AnalysisJobRunning).
+		Updates(map[string]interface{}{
+			"status":       job.Status,
+			"result":       job.Result,
+			"error":        job.Error,
+			"error_reason": job.ErrorReason,
+			"finished_at":  job.FinishedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to finish analysis job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAnalysisJobNotFound
+	}
+	return nil
+}
+
+// CountByStatus считает анализы в очереди по статусам
+func (r *analysisJobRepository) CountByStatus() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&model.AnalysisJob{}).
+		Select("status, COUNT(*) AS count").
+		Where("status IN ?", []string{model.AnalysisJobQueued, model.AnalysisJobRunning}).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count analysis jobs: %w", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
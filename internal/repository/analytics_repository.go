@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AnalyticsRepository интерфейс для агрегированных аналитических запросов
+type AnalyticsRepository interface {
+	CoverageHeatmap(northEast, southWest Coordinates, cellLat, cellLon float64, scope RouteScope) ([]HeatmapCell, error)
+	Totals() (*Totals, error)
+	DailyAnalyses(since time.Time) ([]DailyAnalyses, error)
+	WorstSegments(northEast, southWest *Coordinates, corridorM float64, limit int, scope RouteScope) ([]WorstSegment, error)
+	CoverageHistogram(northEast, southWest *Coordinates, from, to *time.Time, buckets int, scope RouteScope) ([]HistogramBucket, error)
+}
+
+// Totals общие количества по всем организациям для сводки администратора
+type Totals struct {
+	Routes   int64
+	Segments int64
+	// FailedDeliveries доставки вебхуков, не удавшиеся после всех попыток
+	FailedDeliveries int64
+	// DatabaseBytes размер базы данных на диске
+	DatabaseBytes int64
+}
+
+// DailyAnalyses количество анализов за день (UTC) и среднее покрытие
+// проанализированных в этот день маршрутов
+type DailyAnalyses struct {
+	Day             time.Time `gorm:"column:day"`
+	Analyses        int64     `gorm:"column:analyses"`
+	AverageCoverage float64   `gorm:"column:average_coverage"`
+}
+
+// WorstSegment сегмент последнего анализа своего участка дороги
+type WorstSegment struct {
+	RouteID            string    `gorm:"column:route_id"`
+	RouteName          string    `gorm:"column:route_name"`
+	RoadName           string    `gorm:"column:road_name"`
+	SegmentID          int       `gorm:"column:segment_id"`
+	CoveragePercentage float64   `gorm:"column:coverage_percentage"`
+	DefectsCount       int       `gorm:"column:defects_count"`
+	QualityScore       *float64  `gorm:"column:quality_score"`
+	StartLat           float64   `gorm:"column:start_lat"`
+	StartLon           float64   `gorm:"column:start_lon"`
+	EndLat             float64   `gorm:"column:end_lat"`
+	EndLon             float64   `gorm:"column:end_lon"`
+	AnalyzedAt         time.Time `gorm:"column:analyzed_at"`
+}
+
+// HistogramBucket число сегментов с покрытием в одном интервале гистограммы
+type HistogramBucket struct {
+	Bucket int   `gorm:"column:bucket"`
+	Count  int64 `gorm:"column:segment_count"`
+}
+
+// HeatmapCell агрегированное покрытие в одной ячейке сетки
+type HeatmapCell struct {
+	Row             int     `gorm:"column:cell_row"`
+	Col             int     `gorm:"column:cell_col"`
+	AverageCoverage float64 `gorm:"column:average_coverage"`
+	SegmentCount    int64   `gorm:"column:segment_count"`
+}
+
+// analyticsRepository реализация AnalyticsRepository
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsRepository создает новый instance AnalyticsRepository
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{
+		db: db,
+	}
+}
+
+// CoverageHeatmap группирует сегменты области в ячейки сетки по их середине
+// и считает среднее покрытие в каждой ячейке на стороне базы данных.
+// Размер ячейки задается в градусах широты и долготы. Для области, пересекающей
+// антимеридиан, northEast.Lon передается развернутой (больше 180).
+func (r *analyticsRepository) CoverageHeatmap(northEast, southWest Coordinates, cellLat, cellLon float64, scope RouteScope) ([]HeatmapCell, error) {
+	var cells []HeatmapCell
+
+	midLat := "(segments.start_lat + segments.end_lat) / 2"
+	midLon := "(segments.start_lon + segments.end_lon) / 2"
+	if northEast.Lon > 180 {
+		// Долготы восточнее антимеридиана переносим за 180, чтобы сетка была непрерывной
+		midLon = fmt.Sprintf("(CASE WHEN %[1]s < %[2]f THEN %[1]s + 360 ELSE %[1]s END)", midLon, southWest.Lon)
+	}
+
+	err := r.db.Table("segments").
+		Select(fmt.Sprintf("FLOOR((%s - ?) / ?)::int AS cell_row, FLOOR((%s - ?) / ?)::int AS cell_col, "+
+			"AVG(segments.coverage_percentage) AS average_coverage, COUNT(*) AS segment_count", midLat, midLon),
+			southWest.Lat, cellLat, southWest.Lon, cellLon).
+		Joins("JOIN routes ON routes.id = segments.route_id AND routes.deleted_at IS NULL").
+		Scopes(routeScope(scope)).
+		Where("segments.deleted_at IS NULL AND segments.has_data = ?", true).
+		Where(fmt.Sprintf("%s BETWEEN ? AND ? AND %s BETWEEN ? AND ?", midLat, midLon),
+			southWest.Lat, northEast.Lat, southWest.Lon, northEast.Lon).
+		Group("cell_row, cell_col").
+		Order("cell_row, cell_col").
+		Scan(&cells).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate coverage heatmap: %w", err)
+	}
+
+	return cells, nil
+}
+
+// Totals считает маршруты, сегменты и неудачные доставки вебхуков.
+// Удаленные маршруты и сегменты не учитываются.
+func (r *analyticsRepository) Totals() (*Totals, error) {
+	var totals Totals
+
+	if err := r.db.Model(&model.Route{}).Count(&totals.Routes).Error; err != nil {
+		return nil, fmt.Errorf("failed to count routes: %w", err)
+	}
+	err := r.db.Model(&model.Segment{}).
+		Joins("JOIN routes ON routes.id = segments.route_id AND routes.deleted_at IS NULL").
+		Count(&totals.Segments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count segments: %w", err)
+	}
+	err = r.db.Model(&model.WebhookDelivery{}).
+		Where("status = ?", model.WebhookDeliveryFailed).
+		Count(&totals.FailedDeliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count failed webhook deliveries: %w", err)
+	}
+	if err := r.db.Raw("SELECT pg_database_size(current_database())").Scan(&totals.DatabaseBytes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
+
+	return &totals, nil
+}
+
+// DailyAnalyses группирует маршруты, созданные начиная с since, по дням.
+// Каждый маршрут — результат одного анализа видео. Среднее покрытие
+// считается по маршрутам, в которых есть сегменты с данными. Дни без
+// анализов не возвращаются. Удаленные маршруты учитываются, чтобы история
+// анализов не менялась при удалении.
+func (r *analyticsRepository) DailyAnalyses(since time.Time) ([]DailyAnalyses, error) {
+	var days []DailyAnalyses
+
+	err := r.db.Unscoped().Model(&model.Route{}).
+		Select("date_trunc('day', created_at AT TIME ZONE 'UTC') AS day, COUNT(*) AS analyses, "+
+			"COALESCE(AVG(average_coverage) FILTER (WHERE segments_with_data > 0), 0) AS average_coverage").
+		Where("created_at >= ?", since).
+		Group("day").
+		Order("day").
+		Scan(&days).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate daily analyses: %w", err)
+	}
+
+	return days, nil
+}
+
+// WorstSegments получает до limit сегментов с данными с наименьшим
+// покрытием, середина которых лежит в области, nil — без ограничения
+// области. Сегмент не учитывается, если тот же участок дороги снят более
+// поздним маршрутом из области scope: середина сегмента с данными этого
+// маршрута ближе corridorM метров. Для области, пересекающей антимеридиан,
+// northEast.Lon передается развернутой (больше 180).
+func (r *analyticsRepository) WorstSegments(northEast, southWest *Coordinates, corridorM float64, limit int, scope RouteScope) ([]WorstSegment, error) {
+	cond, args := scopeCondition(scope)
+
+	area := ""
+	if northEast != nil && southWest != nil {
+		var areaArgs []interface{}
+		area, areaArgs = pointInBoxSQL(segmentMidLatSQL, segmentMidLonSQL, *northEast, *southWest)
+		area = " AND " + area
+		args = append(args, areaArgs...)
+	}
+	// Расстояние между серединами сегментов: разница координат в градусах
+	// переводится в метры по широте сегмента, на расстояниях коридора этого
+	// достаточно
+	newerMidLat := "(newer.start_lat + newer.end_lat) / 2"
+	newerMidLon := "(newer.start_lon + newer.end_lon) / 2"
+	distance := fmt.Sprintf("SQRT(POWER((%[1]s - %[2]s) * %[5]f, 2) + POWER((%[3]s - %[4]s) * %[5]f * COS(RADIANS(%[2]s)), 2))",
+		newerMidLat, segmentMidLatSQL, newerMidLon, segmentMidLonSQL, metersPerDegreeLat)
+	args = append(args, corridorM/metersPerDegreeLat, corridorM, limit)
+
+	var segments []WorstSegment
+	err := r.db.Raw(`
+		WITH scoped AS (
+			SELECT routes.id, routes.name, routes.road_name, routes.created_at
+			FROM routes
+			WHERE routes.deleted_at IS NULL`+cond+`
+		)
+		SELECT segments.route_id, routes.name AS route_name,
+			COALESCE(NULLIF(segments.road_name, ''), routes.road_name, '') AS road_name,
+			segments.segment_id, segments.coverage_percentage, segments.defects_count, segments.quality_score,
+			segments.start_lat, segments.start_lon, segments.end_lat, segments.end_lon,
+			routes.created_at AS analyzed_at
+		FROM segments
+		JOIN scoped routes ON routes.id = segments.route_id
+		WHERE segments.deleted_at IS NULL AND segments.has_data`+area+`
+			AND NOT EXISTS (
+				SELECT 1
+				FROM segments newer
+				JOIN scoped newer_routes ON newer_routes.id = newer.route_id
+				WHERE newer.deleted_at IS NULL AND newer.has_data
+					AND newer_routes.created_at > routes.created_at
+					AND ABS(`+newerMidLat+` - `+segmentMidLatSQL+`) <= ?
+					AND `+distance+` <= ?
+			)
+		ORDER BY segments.coverage_percentage ASC, routes.created_at DESC, segments.segment_id ASC
+		LIMIT ?`,
+		args...).Scan(&segments).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worst segments: %w", err)
+	}
+
+	return segments, nil
+}
+
+// CoverageHistogram делит диапазон покрытия 0–100% на buckets равных
+// интервалов и считает сегменты с данными в каждом на стороне базы данных.
+// Покрытие 100% относится к последнему интервалу. Область и период [from, to)
+// времени анализа необязательны, nil — без ограничения. Интервалы без
+// сегментов не возвращаются.
+func (r *analyticsRepository) CoverageHistogram(northEast, southWest *Coordinates, from, to *time.Time, buckets int, scope RouteScope) ([]HistogramBucket, error) {
+	width := 100 / float64(buckets)
+	db := r.db.Table("segments").
+		Select("LEAST(GREATEST(FLOOR(segments.coverage_percentage / ?)::int, 0), ?) AS bucket, COUNT(*) AS segment_count",
+			width, buckets-1).
+		Joins("JOIN routes ON routes.id = segments.route_id AND routes.deleted_at IS NULL").
+		Scopes(routeScope(scope)).
+		Where("segments.deleted_at IS NULL AND segments.has_data = ?", true)
+	if northEast != nil && southWest != nil {
+		area, args := pointInBoxSQL(segmentMidLatSQL, segmentMidLonSQL, *northEast, *southWest)
+		db = db.Where(area, args...)
+	}
+	if from != nil {
+		db = db.Where("routes.created_at >= ?", *from)
+	}
+	if to != nil {
+		db = db.Where("routes.created_at < ?", *to)
+	}
+
+	var histogram []HistogramBucket
+	if err := db.Group("bucket").Order("bucket").Scan(&histogram).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate coverage histogram: %w", err)
+	}
+
+	return histogram, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrAPIKeyNotFound возвращается, если ключ API отсутствует или уже отозван
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyRepository интерфейс для работы с ключами API
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	List() ([]model.APIKey, error)
+	GetByHash(hash string) (*model.APIKey, error)
+	Revoke(id uint) (*model.APIKey, error)
+	TouchLastUsed(id uint, usedAt time.Time) error
+}
+
+// apiKeyRepository реализация APIKeyRepository
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository создает новый instance APIKeyRepository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+// Create сохраняет ключ
+func (r *apiKeyRepository) Create(key *model.APIKey) error {
+	if err := r.db.Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// List получает все ключи, включая отозванные, в порядке создания
+func (r *apiKeyRepository) List() ([]model.APIKey, error) {
+	var keys []model.APIKey
+	if err := r.db.Order("id ASC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// GetByHash получает действующий ключ по хэшу
+func (r *apiKeyRepository) GetByHash(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.Where("key_hash = ? AND revoked_at IS NULL", hash).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// Revoke отзывает ключ. Повторный отзыв возвращает ErrAPIKeyNotFound.
+func (r *apiKeyRepository) Revoke(id uint) (*model.APIKey, error) {
+	now := time.Now()
+	result := r.db.Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: id %d", ErrAPIKeyNotFound, id)
+	}
+
+	var key model.APIKey
+	if err := r.db.First(&key, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// TouchLastUsed обновляет время последнего использования ключа
+func (r *apiKeyRepository) TouchLastUsed(id uint, usedAt time.Time) error {
+	err := r.db.Model(&model.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AuditQuery фильтры выборки журнала аудита. Пустые поля не ограничивают выборку.
+type AuditQuery struct {
+	Action         string
+	ActorType      string
+	ActorID        *uint
+	OrganizationID *uint
+	RouteID        string
+	From           *time.Time
+	To             *time.Time
+	Page           int
+	PageSize       int
+}
+
+// AuditRepository интерфейс для работы с журналом аудита
+type AuditRepository interface {
+	Create(entry *model.AuditEntry) error
+	List(query AuditQuery) ([]model.AuditEntry, int64, error)
+}
+
+// auditRepository реализация AuditRepository
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository создает новый instance AuditRepository
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+// Create добавляет запись в журнал
+func (r *auditRepository) Create(entry *model.AuditEntry) error {
+	if err := r.db.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+	return nil
+}
+
+// List возвращает страницу записей журнала, начиная с последних, и общее
+// количество записей, подходящих под фильтры
+func (r *auditRepository) List(query AuditQuery) ([]model.AuditEntry, int64, error) {
+	db := r.db.Model(&model.AuditEntry{})
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.ActorType != "" {
+		db = db.Where("actor_type = ?", query.ActorType)
+	}
+	if query.ActorID != nil {
+		db = db.Where("actor_id = ?", *query.ActorID)
+	}
+	if query.OrganizationID != nil {
+		db = db.Where("organization_id = ?", *query.OrganizationID)
+	}
+	if query.RouteID != "" {
+		db = db.Where("route_id = ?", query.RouteID)
+	}
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("created_at < ?", *query.To)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	var entries []model.AuditEntry
+	err := db.Order("created_at DESC, id DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/model"
+	"road-detector-go/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrBoundaryNotFound возвращается, если граница отсутствует или недоступна
+// организации
+var ErrBoundaryNotFound = errors.New("boundary not found")
+
+// segmentLengthSQL длина сегмента в метрах по формуле гаверсинусов
+var segmentLengthSQL = fmt.Sprintf("%.1f * 2 * ASIN(SQRT(POWER(SIN(RADIANS(segments.end_lat - segments.start_lat) / 2), 2) + "+
+	"COS(RADIANS(segments.start_lat)) * COS(RADIANS(segments.end_lat)) * "+
+	"POWER(SIN(RADIANS(segments.end_lon - segments.start_lon) / 2), 2)))", earthRadiusMeters)
+
+const (
+	// segmentMidLatSQL и segmentMidLonSQL середина сегмента, по которой
+	// сегмент относится к границе
+	segmentMidLatSQL = "(segments.start_lat + segments.end_lat) / 2"
+	segmentMidLonSQL = "(segments.start_lon + segments.end_lon) / 2"
+)
+
+// AreaStatistics показатели сегментов маршрутов, середина которых лежит
+// внутри границы
+type AreaStatistics struct {
+	BoundaryID       uint
+	Routes           int64
+	Segments         int64
+	SegmentsWithData int64
+	DistanceMeters   float64
+	// AverageCoverage среднее покрытие сегментов с данными, nil — таких нет
+	AverageCoverage *float64
+	// WorstSegments сегменты с данными с наименьшим покрытием
+	WorstSegments []ReportSegment
+}
+
+// BoundaryRepository интерфейс для работы с административными границами.
+// Границы выбираются в пределах организации вместе с общими границами
+// без организации, изменяются — только в пределах организации.
+type BoundaryRepository interface {
+	Create(boundaries []*model.Boundary) error
+	List(orgID *uint, kind string) ([]model.Boundary, error)
+	Get(id uint, orgID *uint) (*model.Boundary, error)
+	Delete(id uint, orgID *uint) error
+	AreaStatistics(boundaries []model.Boundary, scope RouteScope, worst int) ([]AreaStatistics, error)
+}
+
+// boundaryRepository реализация BoundaryRepository. Сегменты отбираются
+// по описывающему прямоугольнику границы в базе данных, попадание в
+// полигон проверяется на стороне сервиса.
+type boundaryRepository struct {
+	db     *gorm.DB
+	reader *gorm.DB
+}
+
+// NewBoundaryRepository создает новый instance BoundaryRepository.
+// reader используется для агрегатов по сегментам, может совпадать с db.
+func NewBoundaryRepository(db, reader *gorm.DB) BoundaryRepository {
+	return newBoundaryRepository(db, reader)
+}
+
+func newBoundaryRepository(db, reader *gorm.DB) *boundaryRepository {
+	return &boundaryRepository{
+		db:     db,
+		reader: reader,
+	}
+}
+
+// boundaryScope ограничивает запрос границами организации и общими
+// границами без организации
+func boundaryScope(db *gorm.DB, orgID *uint) *gorm.DB {
+	if orgID == nil {
+		return db.Where("organization_id IS NULL")
+	}
+	return db.Where("organization_id = ? OR organization_id IS NULL", *orgID)
+}
+
+// Create сохраняет границы одной транзакцией
+func (r *boundaryRepository) Create(boundaries []*model.Boundary) error {
+	if err := r.db.Create(boundaries).Error; err != nil {
+		return fmt.Errorf("failed to create boundaries: %w", err)
+	}
+	return nil
+}
+
+// List получает доступные организации границы по названию, kind, если не
+// пуст, оставляет границы одного вида
+func (r *boundaryRepository) List(orgID *uint, kind string) ([]model.Boundary, error) {
+	db := boundaryScope(r.db, orgID)
+	if kind != "" {
+		db = db.Where("kind = ?", kind)
+	}
+
+	var boundaries []model.Boundary
+	if err := db.Order("name ASC, id ASC").Find(&boundaries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list boundaries: %w", err)
+	}
+	return boundaries, nil
+}
+
+// Get получает доступную организации границу по ID
+func (r *boundaryRepository) Get(id uint, orgID *uint) (*model.Boundary, error) {
+	var boundary model.Boundary
+	err := boundaryScope(r.db, orgID).Where("id = ?", id).First(&boundary).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %d", ErrBoundaryNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get boundary: %w", err)
+	}
+	return &boundary, nil
+}
+
+// Delete удаляет границу организации
+func (r *boundaryRepository) Delete(id uint, orgID *uint) error {
+	result := organizationScope(r.db, orgID).Where("id = ?", id).Delete(&model.Boundary{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete boundary: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id %d", ErrBoundaryNotFound, id)
+	}
+	return nil
+}
+
+// areaSegmentRow сегмент, середина которого попала в прямоугольник границы
+type areaSegmentRow struct {
+	BoundaryID         uint
+	RouteID            string
+	RouteName          string
+	RoadName           string
+	SegmentID          int
+	HasData            bool
+	CoveragePercentage float64
+	MidLat             float64
+	MidLon             float64
+	LengthM            float64
+}
+
+// AreaStatistics соединяет границы с сегментами маршрутов из области scope
+// по описывающему прямоугольнику границы и оставляет сегменты, середина
+// которых лежит внутри полигона. worst — сколько сегментов с наименьшим
+// покрытием вернуть для каждой границы.
+func (r *boundaryRepository) AreaStatistics(boundaries []model.Boundary, scope RouteScope, worst int) ([]AreaStatistics, error) {
+	if len(boundaries) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(boundaries))
+	polygons := make(map[uint]geo.Polygon, len(boundaries))
+	for i, boundary := range boundaries {
+		polygon, err := geo.ParseGeoJSONPolygon([]byte(boundary.Geometry))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse boundary %d geometry: %w", boundary.ID, err)
+		}
+		ids[i] = boundary.ID
+		polygons[boundary.ID] = polygon
+	}
+
+	cond, args := scopeCondition(scope)
+	rows, err := r.reader.Raw(`
+		SELECT boundaries.id AS boundary_id, segments.route_id, routes.name AS route_name,
+			COALESCE(NULLIF(segments.road_name, ''), routes.road_name, '') AS road_name,
+			segments.segment_id, segments.has_data, segments.coverage_percentage,
+			`+segmentMidLatSQL+` AS mid_lat, `+segmentMidLonSQL+` AS mid_lon,
+			`+segmentLengthSQL+` AS length_m
+		FROM boundaries
+		JOIN segments ON `+segmentMidLatSQL+` BETWEEN boundaries.min_lat AND boundaries.max_lat
+			AND `+segmentMidLonSQL+` BETWEEN boundaries.min_lon AND boundaries.max_lon
+		JOIN routes ON routes.id = segments.route_id
+		WHERE boundaries.id IN ? AND segments.deleted_at IS NULL AND routes.deleted_at IS NULL`+cond,
+		append([]interface{}{ids}, args...)...).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to join boundaries with segments: %w", err)
+	}
+	defer rows.Close()
+
+	type accumulator struct {
+		stats    AreaStatistics
+		routes   map[string]struct{}
+		coverage float64
+	}
+	areas := make(map[uint]*accumulator, len(boundaries))
+	for _, id := range ids {
+		areas[id] = &accumulator{stats: AreaStatistics{BoundaryID: id}, routes: make(map[string]struct{})}
+	}
+
+	for rows.Next() {
+		var row areaSegmentRow
+		if err := r.reader.ScanRows(rows, &row); err != nil {
+			return nil, fmt.Errorf("failed to scan area segment: %w", err)
+		}
+		if !polygons[row.BoundaryID].ContainsPoint(models.Coordinates{Lat: row.MidLat, Lon: row.MidLon}) {
+			continue
+		}
+
+		area := areas[row.BoundaryID]
+		area.routes[row.RouteID] = struct{}{}
+		area.stats.Segments++
+		area.stats.DistanceMeters += row.LengthM
+		if !row.HasData {
+			continue
+		}
+		area.stats.SegmentsWithData++
+		area.coverage += row.CoveragePercentage
+		area.stats.WorstSegments = appendWorstSegment(area.stats.WorstSegments, ReportSegment{
+			RouteID:            row.RouteID,
+			RouteName:          row.RouteName,
+			RoadName:           row.RoadName,
+			SegmentID:          row.SegmentID,
+			CoveragePercentage: row.CoveragePercentage,
+		}, worst)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read area segments: %w", err)
+	}
+
+	statistics := make([]AreaStatistics, 0, len(ids))
+	for _, id := range ids {
+		area := areas[id]
+		area.stats.Routes = int64(len(area.routes))
+		if area.stats.SegmentsWithData > 0 {
+			average := area.coverage / float64(area.stats.SegmentsWithData)
+			area.stats.AverageCoverage = &average
+		}
+		statistics = append(statistics, area.stats)
+	}
+	return statistics, nil
+}
+
+// appendWorstSegment добавляет сегмент в список из не более чем limit
+// сегментов с наименьшим покрытием, упорядоченный по возрастанию покрытия
+func appendWorstSegment(segments []ReportSegment, segment ReportSegment, limit int) []ReportSegment {
+	if limit <= 0 {
+		return segments
+	}
+	if len(segments) == limit && segments[limit-1].CoveragePercentage <= segment.CoveragePercentage {
+		return segments
+	}
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].CoveragePercentage > segment.CoveragePercentage
+	})
+	if len(segments) < limit {
+		segments = append(segments, ReportSegment{})
+	}
+	copy(segments[i+1:], segments[i:])
+	segments[i] = segment
+	return segments
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+
+	"road-detector-go/internal/cache"
+	"road-detector-go/internal/model"
+)
+
+// routePage страница маршрутов с общим количеством
+type routePage struct {
+	routes []*model.Route
+	total  int64
+}
+
+// cacheKey ключ кэша из имени запроса и его параметров
+func cacheKey(name string, params ...interface{}) string {
+	// Параметры состоят из строк, чисел и структур запросов, поэтому
+	// маршалинг не завершается ошибкой
+	data, _ := json.Marshal(params)
+	return name + string(data)
+}
+
+// cloneRoute копирует маршрут вместе с сегментами, метками и
+// пользовательскими полями, чтобы вызывающий код не менял значение в кэше
+func cloneRoute(route *model.Route) *model.Route {
+	clone := *route
+	clone.Segments = slices.Clone(route.Segments)
+	clone.Tags = slices.Clone(route.Tags)
+	clone.CustomFields = maps.Clone(route.CustomFields)
+	return &clone
+}
+
+// cloneRoutes копирует маршруты страницы
+func cloneRoutes(routes []*model.Route) []*model.Route {
+	out := make([]*model.Route, len(routes))
+	for i, route := range routes {
+		out[i] = cloneRoute(route)
+	}
+	return out
+}
+
+// cachedRouteRepository кэширует маршрут по ID, списки, поиск и выборку
+// по области. Любое изменение маршрутов сбрасывает кэш целиком: изменения
+// редки по сравнению с чтением, а один маршрут попадает во многие списки.
+type cachedRouteRepository struct {
+	RouteRepository
+	cache *cache.Cache
+}
+
+// NewCachedRouteRepository добавляет кэш к репозиторию маршрутов
+func NewCachedRouteRepository(routes RouteRepository, c *cache.Cache) RouteRepository {
+	return &cachedRouteRepository{RouteRepository: routes, cache: c}
+}
+
+// GetByID получает маршрут по ID из кэша или базы данных
+func (r *cachedRouteRepository) GetByID(id string, scope RouteScope) (*model.Route, error) {
+	value, err := r.cache.Do(cacheKey("route", id, scope), func() (interface{}, error) {
+		return r.RouteRepository.GetByID(id, scope)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cloneRoute(value.(*model.Route)), nil
+}
+
+// GetByArea получает маршруты в области из кэша или базы данных
+func (r *cachedRouteRepository) GetByArea(query AreaQuery, page, pageSize int) ([]*model.Route, int64, error) {
+	return r.page(cacheKey("area", query, page, pageSize), func() ([]*model.Route, int64, error) {
+		return r.RouteRepository.GetByArea(query, page, pageSize)
+	})
+}
+
+// List получает страницу списка маршрутов и их количество из кэша или базы данных
+func (r *cachedRouteRepository) List(page, pageSize int, query RouteListQuery) ([]*model.Route, int64, error) {
+	return r.page(cacheKey("list", query, page, pageSize), func() ([]*model.Route, int64, error) {
+		return r.RouteRepository.List(page, pageSize, query)
+	})
+}
+
+// Search получает страницу результатов поиска из кэша или базы данных
+func (r *cachedRouteRepository) Search(text string, scope RouteScope, page, pageSize int) ([]*model.Route, int64, error) {
+	return r.page(cacheKey("search", text, scope, page, pageSize), func() ([]*model.Route, int64, error) {
+		return r.RouteRepository.Search(text, scope, page, pageSize)
+	})
+}
+
+// page возвращает закэшированную страницу маршрутов или загружает ее
+func (r *cachedRouteRepository) page(key string, load func() ([]*model.Route, int64, error)) ([]*model.Route, int64, error) {
+	value, err := r.cache.Do(key, func() (interface{}, error) {
+		routes, total, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return routePage{routes: routes, total: total}, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	page := value.(routePage)
+	return cloneRoutes(page.routes), page.total, nil
+}
+
+// invalidate сбрасывает кэш после изменения. Кэш сбрасывается и при
+// ошибке: часть изменений могла быть сохранена.
+func (r *cachedRouteRepository) invalidate(err error) error {
+	r.cache.Purge()
+	return err
+}
+
+func (r *cachedRouteRepository) Create(route *model.Route) error {
+	return r.invalidate(r.RouteRepository.Create(route))
+}
+
+func (r *cachedRouteRepository) CreateWithEvents(route *model.Route, events []*model.OutboxEvent) error {
+	return r.invalidate(r.RouteRepository.CreateWithEvents(route, events))
+}
+
+func (r *cachedRouteRepository) ReplaceWithEvents(route *model.Route, events []*model.OutboxEvent) error {
+	return r.invalidate(r.RouteRepository.ReplaceWithEvents(route, events))
+}
+
+func (r *cachedRouteRepository) Delete(id string) error {
+	return r.invalidate(r.RouteRepository.Delete(id))
+}
+
+func (r *cachedRouteRepository) Restore(id string) error {
+	return r.invalidate(r.RouteRepository.Restore(id))
+}
+
+func (r *cachedRouteRepository) Archive(before time.Time, limit int) (int64, error) {
+	archived, err := r.RouteRepository.Archive(before, limit)
+	return archived, r.invalidate(err)
+}
+
+func (r *cachedRouteRepository) Unarchive(id string) error {
+	return r.invalidate(r.RouteRepository.Unarchive(id))
+}
+
+func (r *cachedRouteRepository) Purge(id string) error {
+	return r.invalidate(r.RouteRepository.Purge(id))
+}
+
+func (r *cachedRouteRepository) DeleteMany(ids []string) ([]string, error) {
+	deleted, err := r.RouteRepository.DeleteMany(ids)
+	return deleted, r.invalidate(err)
+}
+
+func (r *cachedRouteRepository) PurgeMany(ids []string) ([]*model.Route, error) {
+	purged, err := r.RouteRepository.PurgeMany(ids)
+	return purged, r.invalidate(err)
+}
+
+func (r *cachedRouteRepository) AddTags(ids []string, names []string) ([]string, error) {
+	tagged, err := r.RouteRepository.AddTags(ids, names)
+	return tagged, r.invalidate(err)
+}
+
+func (r *cachedRouteRepository) Split(route, part *model.Route, atSegment int) error {
+	return r.invalidate(r.RouteRepository.Split(route, part, atSegment))
+}
+
+func (r *cachedRouteRepository) Update(route *model.Route) error {
+	return r.invalidate(r.RouteRepository.Update(route))
+}
+
+func (r *cachedRouteRepository) UpdateMetadata(route *model.Route) error {
+	return r.invalidate(r.RouteRepository.UpdateMetadata(route))
+}
+
+func (r *cachedRouteRepository) SetStorageBytes(id string, bytes int64) error {
+	return r.invalidate(r.RouteRepository.SetStorageBytes(id, bytes))
+}
+
+func (r *cachedRouteRepository) SetPlayableVideo(id, path string, storageBytes int64) error {
+	return r.invalidate(r.RouteRepository.SetPlayableVideo(id, path, storageBytes))
+}
+
+// cachedTagRepository сбрасывает кэш маршрутов при изменении меток:
+// метки входят в ответы и фильтры списка маршрутов
+type cachedTagRepository struct {
+	TagRepository
+	cache *cache.Cache
+}
+
+// NewCachedTagRepository сбрасывает кэш c при изменении и удалении меток
+func NewCachedTagRepository(tags TagRepository, c *cache.Cache) TagRepository {
+	return &cachedTagRepository{TagRepository: tags, cache: c}
+}
+
+func (r *cachedTagRepository) Rename(id uint, name string) (*model.Tag, error) {
+	tag, err := r.TagRepository.Rename(id, name)
+	r.cache.Purge()
+	return tag, err
+}
+
+func (r *cachedTagRepository) Delete(id uint) error {
+	err := r.TagRepository.Delete(id)
+	r.cache.Purge()
+	return err
+}
+
+func (r *cachedTagRepository) SetRouteTags(routeID string, names []string) ([]model.Tag, error) {
+	tags, err := r.TagRepository.SetRouteTags(routeID, names)
+	r.cache.Purge()
+	return tags, err
+}
+
+// cachedAnalyticsRepository кэширует тепловую карту, гистограмму покрытия,
+// худшие сегменты и сводные количества.
+// Кэш сбрасывается вместе с кэшем маршрутов.
+type cachedAnalyticsRepository struct {
+	AnalyticsRepository
+	cache *cache.Cache
+}
+
+// NewCachedAnalyticsRepository добавляет кэш к репозиторию аналитики
+func NewCachedAnalyticsRepository(analytics AnalyticsRepository, c *cache.Cache) AnalyticsRepository {
+	return &cachedAnalyticsRepository{AnalyticsRepository: analytics, cache: c}
+}
+
+func (r *cachedAnalyticsRepository) CoverageHeatmap(northEast, southWest Coordinates, cellLat, cellLon float64, scope RouteScope) ([]HeatmapCell, error) {
+	key := cacheKey("heatmap", northEast, southWest, cellLat, cellLon, scope)
+	value, err := r.cache.Do(key, func() (interface{}, error) {
+		return r.AnalyticsRepository.CoverageHeatmap(northEast, southWest, cellLat, cellLon, scope)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(value.([]HeatmapCell)), nil
+}
+
+func (r *cachedAnalyticsRepository) WorstSegments(northEast, southWest *Coordinates, corridorM float64, limit int, scope RouteScope) ([]WorstSegment, error) {
+	key := cacheKey("worst", northEast, southWest, corridorM, limit, scope)
+	value, err := r.cache.Do(key, func() (interface{}, error) {
+		return r.AnalyticsRepository.WorstSegments(northEast, southWest, corridorM, limit, scope)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(value.([]WorstSegment)), nil
+}
+
+func (r *cachedAnalyticsRepository) CoverageHistogram(northEast, southWest *Coordinates, from, to *time.Time, buckets int, scope RouteScope) ([]HistogramBucket, error) {
+	key := cacheKey("histogram", northEast, southWest, from, to, buckets, scope)
+	value, err := r.cache.Do(key, func() (interface{}, error) {
+		return r.AnalyticsRepository.CoverageHistogram(northEast, southWest, from, to, buckets, scope)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(value.([]HistogramBucket)), nil
+}
+
+func (r *cachedAnalyticsRepository) Totals() (*Totals, error) {
+	value, err := r.cache.Do(cacheKey("totals"), func() (interface{}, error) {
+		return r.AnalyticsRepository.Totals()
+	})
+	if err != nil {
+		return nil, err
+	}
+	totals := *value.(*Totals)
+	return &totals, nil
+}
+
+func (r *cachedAnalyticsRepository) DailyAnalyses(since time.Time) ([]DailyAnalyses, error) {
+	value, err := r.cache.Do(cacheKey("daily", since), func() (interface{}, error) {
+		return r.AnalyticsRepository.DailyAnalyses(since)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(value.([]DailyAnalyses)), nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// notificationColumns колонки настроек по видам уведомлений
+var notificationColumns = map[string]string{
+	model.NotificationAnalysisCompleted: "analysis_completed",
+	model.NotificationAlerts:            "alerts",
+	model.NotificationReports:           "reports",
+}
+
+// NotificationRepository интерфейс для работы с настройками уведомлений
+// пользователей
+type NotificationRepository interface {
+	GetPreferences(userID uint) (*model.NotificationPreference, error)
+	SavePreferences(preference *model.NotificationPreference) error
+	SubscribedEmail(kind string, userID uint) (string, error)
+	SubscribedEmails(kind string, orgID *uint) ([]string, error)
+}
+
+// notificationRepository реализация NotificationRepository
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository создает новый instance NotificationRepository
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{
+		db: db,
+	}
+}
+
+// GetPreferences получает настройки пользователя. Если пользователь их
+// не сохранял, возвращаются настройки без подписок.
+func (r *notificationRepository) GetPreferences(userID uint) (*model.NotificationPreference, error) {
+	var preference model.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).First(&preference).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &model.NotificationPreference{UserID: userID}, nil
+		}
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return &preference, nil
+}
+
+// SavePreferences создает или обновляет настройки пользователя
+func (r *notificationRepository) SavePreferences(preference *model.NotificationPreference) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"analysis_completed", "alerts", "reports", "updated_at"}),
+	}).Create(preference).Error
+	if err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return nil
+}
+
+// subscribers выбирает пользователей, подписанных на уведомления kind
+func (r *notificationRepository) subscribers(kind string) (*gorm.DB, error) {
+	column, ok := notificationColumns[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown notification kind %q", kind)
+	}
+	return r.db.Table("notification_preferences").
+		Joins("JOIN users ON users.id = notification_preferences.user_id").
+		Where("notification_preferences."+column+" = ?", true), nil
+}
+
+// SubscribedEmail получает адрес пользователя, если он подписан на
+// уведомления kind, иначе пустую строку
+func (r *notificationRepository) SubscribedEmail(kind string, userID uint) (string, error) {
+	db, err := r.subscribers(kind)
+	if err != nil {
+		return "", err
+	}
+	var emails []string
+	if err := db.Where("users.id = ?", userID).Pluck("users.email", &emails).Error; err != nil {
+		return "", fmt.Errorf("failed to get subscribed email: %w", err)
+	}
+	if len(emails) == 0 {
+		return "", nil
+	}
+	return emails[0], nil
+}
+
+// SubscribedEmails получает адреса пользователей, подписанных на
+// уведомления kind организации: ее участников, а для записей без
+// организации (nil) — администраторов сервера
+func (r *notificationRepository) SubscribedEmails(kind string, orgID *uint) ([]string, error) {
+	db, err := r.subscribers(kind)
+	if err != nil {
+		return nil, err
+	}
+	if orgID != nil {
+		db = db.Joins("JOIN organization_members ON organization_members.user_id = users.id").
+			Where("organization_members.organization_id = ?", *orgID)
+	} else {
+		db = db.Where("users.role = ?", model.RoleAdmin)
+	}
+
+	var emails []string
+	if err := db.Order("users.id").Pluck("users.email", &emails).Error; err != nil {
+		return nil, fmt.Errorf("failed to list subscribed emails: %w", err)
+	}
+	return emails, nil
+}
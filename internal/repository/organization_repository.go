@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrOrganizationNotFound возвращается, если организация не найдена
+var ErrOrganizationNotFound = errors.New("organization not found")
+
+// ErrOrganizationExists возвращается при создании организации с занятым slug
+var ErrOrganizationExists = errors.New("organization already exists")
+
+// ErrMemberNotFound возвращается, если пользователь не состоит в организации
+var ErrMemberNotFound = errors.New("organization member not found")
+
+// OrganizationRepository интерфейс для работы с организациями и их участниками
+type OrganizationRepository interface {
+	Create(org *model.Organization) error
+	List() ([]model.Organization, error)
+	GetByID(id uint) (*model.Organization, error)
+	ListForUser(userID uint) ([]OrganizationMembership, error)
+	GetMember(orgID, userID uint) (*model.OrganizationMember, error)
+	AddMember(member *model.OrganizationMember) error
+	RemoveMember(orgID, userID uint) error
+	ListMembers(orgID uint) ([]MemberInfo, error)
+}
+
+// OrganizationMembership организация пользователя с его ролью в ней
+type OrganizationMembership struct {
+	model.Organization
+	Role string
+}
+
+// MemberInfo участник организации с email пользователя
+type MemberInfo struct {
+	UserID    uint
+	Email     string
+	Role      string
+	CreatedAt time.Time
+}
+
+// organizationRepository реализация OrganizationRepository
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository создает новый instance OrganizationRepository
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{
+		db: db,
+	}
+}
+
+// Create сохраняет организацию
+func (r *organizationRepository) Create(org *model.Organization) error {
+	var count int64
+	if err := r.db.Model(&model.Organization{}).Where("slug = ?", org.Slug).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check organization: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrOrganizationExists, org.Slug)
+	}
+
+	if err := r.db.Create(org).Error; err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+	return nil
+}
+
+// List получает все организации в порядке создания
+func (r *organizationRepository) List() ([]model.Organization, error) {
+	var orgs []model.Organization
+	if err := r.db.Order("id ASC").Find(&orgs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	return orgs, nil
+}
+
+// GetByID получает организацию по ID
+func (r *organizationRepository) GetByID(id uint) (*model.Organization, error) {
+	var org model.Organization
+	if err := r.db.First(&org, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %d", ErrOrganizationNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return &org, nil
+}
+
+// ListForUser получает организации, в которых состоит пользователь
+func (r *organizationRepository) ListForUser(userID uint) ([]OrganizationMembership, error) {
+	var orgs []OrganizationMembership
+	err := r.db.Table("organizations").
+		Select("organizations.*, organization_members.role AS role").
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id").
+		Where("organization_members.user_id = ?", userID).
+		Order("organizations.id ASC").
+		Scan(&orgs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user organizations: %w", err)
+	}
+	return orgs, nil
+}
+
+// GetMember получает участие пользователя в организации
+func (r *organizationRepository) GetMember(orgID, userID uint) (*model.OrganizationMember, error) {
+	var member model.OrganizationMember
+	err := r.db.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: user %d in organization %d", ErrMemberNotFound, userID, orgID)
+		}
+		return nil, fmt.Errorf("failed to get organization member: %w", err)
+	}
+	return &member, nil
+}
+
+// AddMember добавляет пользователя в организацию. Для существующего
+// участника обновляется роль.
+func (r *organizationRepository) AddMember(member *model.OrganizationMember) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+	if err != nil {
+		return fmt.Errorf("failed to add organization member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember исключает пользователя из организации
+func (r *organizationRepository) RemoveMember(orgID, userID uint) error {
+	result := r.db.Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Delete(&model.OrganizationMember{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove organization member: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: user %d in organization %d", ErrMemberNotFound, userID, orgID)
+	}
+	return nil
+}
+
+// ListMembers получает участников организации в порядке добавления
+func (r *organizationRepository) ListMembers(orgID uint) ([]MemberInfo, error) {
+	var members []MemberInfo
+	err := r.db.Table("organization_members").
+		Select("organization_members.user_id, users.email, organization_members.role, organization_members.created_at").
+		Joins("JOIN users ON users.id = organization_members.user_id").
+		Where("organization_members.organization_id = ?", orgID).
+		Order("organization_members.created_at ASC, organization_members.user_id ASC").
+		Scan(&members).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization members: %w", err)
+	}
+	return members, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxRepository интерфейс для работы с событиями, ожидающими публикации
+type OutboxRepository interface {
+	Create(event *model.OutboxEvent) error
+	Relay(limit int, publish func(event *model.OutboxEvent) error) (int, error)
+	DeletePublished(before time.Time) (int64, error)
+}
+
+// outboxRepository реализация OutboxRepository
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository создает новый instance OutboxRepository
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+// Create сохраняет событие вне транзакции изменения, например событие
+// неудачного анализа, после которого в базе ничего не меняется
+func (r *outboxRepository) Create(event *model.OutboxEvent) error {
+	if err := r.db.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+	return nil
+}
+
+// createOutboxEvents сохраняет события в транзакции tx изменения, о котором они сообщают
+func createOutboxEvents(tx *gorm.DB, events []*model.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := tx.Create(events).Error; err != nil {
+		return fmt.Errorf("failed to create outbox events: %w", err)
+	}
+	return nil
+}
+
+// Relay передает publish до limit неопубликованных событий и отмечает
+// опубликованными те, для которых он не вернул ошибку. События блокируются
+// до конца транзакции, поэтому несколько экземпляров сервиса не публикуют
+// одно событие одновременно. События с меньшим числом неудачных попыток
+// идут первыми, чтобы постоянно падающие не задерживали новые.
+// Возвращает количество опубликованных событий.
+func (r *outboxRepository) Relay(limit int, publish func(event *model.OutboxEvent) error) (int, error) {
+	published := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var events []model.OutboxEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL").
+			Order("attempts ASC").
+			Order("id ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil {
+			return fmt.Errorf("failed to select outbox events: %w", err)
+		}
+
+		for i := range events {
+			event := &events[i]
+			if publishErr := publish(event); publishErr != nil {
+				err := tx.Model(event).Updates(map[string]interface{}{
+					"attempts": gorm.Expr("attempts + 1"),
+					"error":    publishErr.Error(),
+				}).Error
+				if err != nil {
+					return fmt.Errorf("failed to record outbox event failure: %w", err)
+				}
+				continue
+			}
+			if err := tx.Model(event).Update("published_at", time.Now()).Error; err != nil {
+				return fmt.Errorf("failed to mark outbox event published: %w", err)
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+// DeletePublished удаляет события, опубликованные раньше before
+func (r *outboxRepository) DeletePublished(before time.Time) (int64, error) {
+	result := r.db.Where("published_at < ?", before).Delete(&model.OutboxEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"fmt"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// postgisBoundaryRepository реализация BoundaryRepository, соединяющая
+// границы с сегментами средствами PostGIS. Остальные операции наследуются
+// от boundaryRepository.
+type postgisBoundaryRepository struct {
+	*boundaryRepository
+}
+
+// NewPostGISBoundaryRepository создает BoundaryRepository, использующий
+// PostGIS. Требует, чтобы Handle.SetupPostGIS вернул true.
+func NewPostGISBoundaryRepository(db, reader *gorm.DB) BoundaryRepository {
+	return &postgisBoundaryRepository{
+		boundaryRepository: newBoundaryRepository(db, reader),
+	}
+}
+
+// areaJoinSQL соединяет границы с сегментами, середина которых лежит внутри
+// полигона границы. Прямоугольник границы отсекает заведомо далекие сегменты
+// до проверки ST_Intersects.
+var areaJoinSQL = `
+		WITH areas AS (
+			SELECT id, min_lat, min_lon, max_lat, max_lon,
+				ST_SetSRID(ST_GeomFromGeoJSON(geometry), 4326) AS geom
+			FROM boundaries
+			WHERE id IN ?
+		)
+		SELECT %s
+		FROM areas
+		JOIN segments ON ` + segmentMidLatSQL + ` BETWEEN areas.min_lat AND areas.max_lat
+			AND ` + segmentMidLonSQL + ` BETWEEN areas.min_lon AND areas.max_lon
+			AND ST_Intersects(areas.geom, ST_SetSRID(ST_MakePoint(` + segmentMidLonSQL + `, ` + segmentMidLatSQL + `), 4326))
+		JOIN routes ON routes.id = segments.route_id
+		WHERE segments.deleted_at IS NULL AND routes.deleted_at IS NULL`
+
+// AreaStatistics агрегирует сегменты маршрутов из области scope по границам
+// на стороне базы данных
+func (r *postgisBoundaryRepository) AreaStatistics(boundaries []model.Boundary, scope RouteScope, worst int) ([]AreaStatistics, error) {
+	if len(boundaries) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(boundaries))
+	for i, boundary := range boundaries {
+		ids[i] = boundary.ID
+	}
+	cond, args := scopeCondition(scope)
+	args = append([]interface{}{ids}, args...)
+
+	var totals []struct {
+		BoundaryID       uint
+		Routes           int64
+		Segments         int64
+		SegmentsWithData int64
+		DistanceMeters   float64
+		AverageCoverage  *float64
+	}
+	err := r.reader.Raw(fmt.Sprintf(areaJoinSQL, `areas.id AS boundary_id,
+			COUNT(DISTINCT segments.route_id) AS routes, COUNT(*) AS segments,
+			COUNT(*) FILTER (WHERE segments.has_data) AS segments_with_data,
+			SUM(`+segmentLengthSQL+`) AS distance_meters,
+			AVG(segments.coverage_percentage) FILTER (WHERE segments.has_data) AS average_coverage`)+cond+`
+		GROUP BY areas.id`,
+		args...).Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate boundaries: %w", err)
+	}
+
+	var segments []struct {
+		BoundaryID uint
+		ReportSegment
+	}
+	if worst > 0 {
+		err = r.reader.Raw(`
+			SELECT boundary_id, route_id, route_name, road_name, segment_id, coverage_percentage
+			FROM (`+fmt.Sprintf(areaJoinSQL, `areas.id AS boundary_id, segments.route_id, routes.name AS route_name,
+				COALESCE(NULLIF(segments.road_name, ''), routes.road_name, '') AS road_name,
+				segments.segment_id, segments.coverage_percentage,
+				ROW_NUMBER() OVER (PARTITION BY areas.id
+					ORDER BY segments.coverage_percentage ASC, routes.created_at DESC, segments.segment_id ASC) AS position`)+
+			cond+` AND segments.has_data
+			) ranked
+			WHERE position <= ?
+			ORDER BY boundary_id, position`,
+			append(args, worst)...).Scan(&segments).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get worst boundary segments: %w", err)
+		}
+	}
+
+	statistics := make(map[uint]*AreaStatistics, len(ids))
+	for _, id := range ids {
+		statistics[id] = &AreaStatistics{BoundaryID: id}
+	}
+	for _, total := range totals {
+		area := statistics[total.BoundaryID]
+		area.Routes = total.Routes
+		area.Segments = total.Segments
+		area.SegmentsWithData = total.SegmentsWithData
+		area.DistanceMeters = total.DistanceMeters
+		area.AverageCoverage = total.AverageCoverage
+	}
+	for _, seg := range segments {
+		area := statistics[seg.BoundaryID]
+		area.WorstSegments = append(area.WorstSegments, seg.ReportSegment)
+	}
+
+	result := make([]AreaStatistics, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, *statistics[id])
+	}
+	return result, nil
+}
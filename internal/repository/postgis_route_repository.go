@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"fmt"
+
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// postgisRouteRepository реализация RouteRepository с пространственными
+// запросами PostGIS по колонке geom и GiST индексам.
+// Остальные операции наследуются от routeRepository.
+type postgisRouteRepository struct {
+	*routeRepository
+}
+
+// NewPostGISRouteRepository создает RouteRepository, использующий PostGIS.
+// Требует, чтобы Handle.SetupPostGIS вернул true. reader — как в NewRouteRepository.
+func NewPostGISRouteRepository(db, reader *gorm.DB) RouteRepository {
+	return &postgisRouteRepository{
+		routeRepository: newRouteRepository(db, reader),
+	}
+}
+
+// GetByArea получает страницу маршрутов, геометрия сегментов которых
+// пересекает область, и их общее количество
+func (r *postgisRouteRepository) GetByArea(query AreaQuery, page, pageSize int) ([]*model.Route, int64, error) {
+	ne, sw := query.NorthEast, query.SouthWest
+	cond := "ST_Intersects(geom, ST_MakeEnvelope(?, ?, ?, ?, 4326))"
+	args := []interface{}{sw.Lon, sw.Lat, ne.Lon, ne.Lat}
+	if ne.Lon > 180 {
+		// Область через антимеридиан проверяется двумя прямоугольниками
+		cond = "(" + cond + " OR " + cond + ")"
+		args = []interface{}{sw.Lon, sw.Lat, 180.0, ne.Lat, -180.0, sw.Lat, ne.Lon - 360, ne.Lat}
+	}
+
+	inArea := r.reader.Table("segments").
+		Select("route_id").
+		Where("deleted_at IS NULL AND "+cond, args...)
+	return r.findInArea(inArea, query, page, pageSize)
+}
+
+// GetNear получает маршруты, геометрия сегментов которых находится в пределах
+// radiusM метров от точки, отсортированные по расстоянию
+func (r *postgisRouteRepository) GetNear(point Coordinates, radiusM float64, limit int, scope RouteScope) ([]RouteDistance, error) {
+	scopeCond, scopeArgs := scopeCondition(scope)
+	args := append([]interface{}{point.Lon, point.Lat, radiusM}, scopeArgs...)
+	args = append(args, limit)
+
+	var rows []routeDistanceRow
+	err := r.reader.Raw(`
+		SELECT segments.route_id, MIN(ST_Distance(segments.geom::geography, pt.g)) AS distance_m
+		FROM segments
+		JOIN routes ON routes.id = segments.route_id AND routes.deleted_at IS NULL AND routes.archived_at IS NULL
+		CROSS JOIN (SELECT ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography AS g) pt
+		WHERE segments.deleted_at IS NULL AND ST_DWithin(segments.geom::geography, pt.g, ?)`+scopeCond+`
+		GROUP BY segments.route_id
+		ORDER BY distance_m ASC
+		LIMIT ?`,
+		args...).
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routes near point: %w", err)
+	}
+
+	return r.loadRouteDistances(rows)
+}
+
+// GetNearest получает маршрут, ближайший к точке. Кандидаты отбираются
+// KNN-поиском по GiST индексу, затем уточняются по геодезическому расстоянию.
+func (r *postgisRouteRepository) GetNearest(point Coordinates, scope RouteScope) (*RouteDistance, error) {
+	scopeCond, scopeArgs := scopeCondition(scope)
+	args := append([]interface{}{point.Lon, point.Lat}, scopeArgs...)
+	args = append(args, point.Lon, point.Lat)
+
+	var rows []routeDistanceRow
+	err := r.reader.Raw(`
+		SELECT route_id, distance_m FROM (
+			SELECT segments.route_id,
+				ST_Distance(segments.geom::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography) AS distance_m
+			FROM segments
+			JOIN routes ON routes.id = segments.route_id AND routes.deleted_at IS NULL AND routes.archived_at IS NULL
+			WHERE segments.deleted_at IS NULL`+scopeCond+`
+			ORDER BY segments.geom <-> ST_SetSRID(ST_MakePoint(?, ?), 4326)
+			LIMIT 10
+		) candidates
+		ORDER BY distance_m ASC
+		LIMIT 1`,
+		args...).
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nearest route: %w", err)
+	}
+
+	routes, err := r.loadRouteDistances(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("%w: no routes found", ErrRouteNotFound)
+	}
+
+	return &routes[0], nil
+}
+
+// GetByPolygon получает маршруты, геометрия сегментов которых пересекает полигон
+func (r *postgisRouteRepository) GetByPolygon(polygon geo.Polygon, scope RouteScope) ([]*model.Route, error) {
+	geojson, err := polygon.GeoJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode polygon: %w", err)
+	}
+
+	var routes []*model.Route
+	err = r.reader.Preload("Segments").
+		Scopes(routeScope(scope)).
+		Where("routes.archived_at IS NULL").
+		Where("routes.id IN (?)", r.reader.Table("segments").
+			Select("route_id").
+			Where("deleted_at IS NULL AND ST_Intersects(geom, ST_SetSRID(ST_GeomFromGeoJSON(?), 4326))", string(geojson))).
+		Find(&routes).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routes by polygon: %w", err)
+	}
+
+	return routes, nil
+}
+
+// GetOverlapCandidates получает другие маршруты, геометрия сегментов которых
+// находится в пределах distanceM метров от сегментов маршрута route
+func (r *postgisRouteRepository) GetOverlapCandidates(route *model.Route, distanceM float64, scope RouteScope) ([]*model.Route, error) {
+	var routes []*model.Route
+
+	err := r.db.Preload("Segments").
+		Scopes(routeScope(scope)).
+		Where("id IN (?)", r.db.Raw(`
+			SELECT DISTINCT other.route_id
+			FROM segments own
+			JOIN segments other ON other.route_id <> own.route_id AND other.deleted_at IS NULL
+				AND ST_DWithin(own.geom::geography, other.geom::geography, ?)
+			WHERE own.route_id = ? AND own.deleted_at IS NULL`,
+			distanceM, route.ID)).
+		Find(&routes).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overlap candidates: %w", err)
+	}
+
+	return routes, nil
+}
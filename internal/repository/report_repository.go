@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrReportScheduleNotFound возвращается, если расписание отсутствует
+	// или принадлежит другой организации
+	ErrReportScheduleNotFound = errors.New("report schedule not found")
+	// ErrReportNotFound возвращается, если отчет отсутствует или
+	// принадлежит другой организации
+	ErrReportNotFound = errors.New("report not found")
+)
+
+// ReportSummary показатели анализов за период
+type ReportSummary struct {
+	Analyses         int64
+	DistanceMeters   float64
+	SegmentsWithData int64
+	// AverageCoverage среднее покрытие сегментов с данными, nil — таких нет
+	AverageCoverage *float64
+}
+
+// ReportSegment сегмент анализа с его маршрутом и покрытием
+type ReportSegment struct {
+	RouteID            string
+	RouteName          string
+	RoadName           string
+	SegmentID          int
+	CoveragePercentage float64
+}
+
+// ReportRepository интерфейс для работы с расписаниями отчетов, отчетами и
+// данными для них. Расписания и отчеты выбираются в пределах организации,
+// nil — записи без организации.
+type ReportRepository interface {
+	CreateSchedule(schedule *model.ReportSchedule) error
+	ListSchedules(orgID *uint) ([]model.ReportSchedule, error)
+	GetSchedule(id uint, orgID *uint) (*model.ReportSchedule, error)
+	UpdateSchedule(schedule *model.ReportSchedule) error
+	DeleteSchedule(id uint, orgID *uint) error
+	ListDueSchedules(now time.Time, limit int) ([]model.ReportSchedule, error)
+	ClaimSchedule(schedule *model.ReportSchedule, next time.Time) (bool, error)
+	CreateReport(report *model.Report, newEvents func(report *model.Report) ([]*model.OutboxEvent, error)) error
+	ListReports(orgID *uint, scheduleID uint, page, pageSize int) ([]model.Report, int64, error)
+	GetReport(id uint, orgID *uint) (*model.Report, error)
+	Summarize(scope RouteScope, from, to time.Time) (*ReportSummary, error)
+	WorstSegments(scope RouteScope, from, to time.Time, limit int) ([]ReportSegment, error)
+}
+
+// reportRepository реализация ReportRepository
+type reportRepository struct {
+	db *gorm.DB
+}
+
+// NewReportRepository создает новый instance ReportRepository
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{
+		db: db,
+	}
+}
+
+// CreateSchedule сохраняет расписание
+func (r *reportRepository) CreateSchedule(schedule *model.ReportSchedule) error {
+	if err := r.db.Create(schedule).Error; err != nil {
+		return fmt.Errorf("failed to create report schedule: %w", err)
+	}
+	return nil
+}
+
+// ListSchedules получает расписания организации в порядке создания
+func (r *reportRepository) ListSchedules(orgID *uint) ([]model.ReportSchedule, error) {
+	var schedules []model.ReportSchedule
+	if err := organizationScope(r.db, orgID).Order("id ASC").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list report schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// GetSchedule получает расписание организации по ID
+func (r *reportRepository) GetSchedule(id uint, orgID *uint) (*model.ReportSchedule, error) {
+	var schedule model.ReportSchedule
+	err := organizationScope(r.db, orgID).Where("id = ?", id).First(&schedule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %d", ErrReportScheduleNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get report schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// UpdateSchedule сохраняет изменения расписания
+func (r *reportRepository) UpdateSchedule(schedule *model.ReportSchedule) error {
+	if err := r.db.Save(schedule).Error; err != nil {
+		return fmt.Errorf("failed to update report schedule: %w", err)
+	}
+	return nil
+}
+
+// DeleteSchedule удаляет расписание организации. Созданные по нему отчеты
+// сохраняются.
+func (r *reportRepository) DeleteSchedule(id uint, orgID *uint) error {
+	result := organizationScope(r.db, orgID).Where("id = ?", id).Delete(&model.ReportSchedule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete report schedule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id %d", ErrReportScheduleNotFound, id)
+	}
+	return nil
+}
+
+// ListDueSchedules получает до limit включенных расписаний, период которых
+// закончился к моменту now
+func (r *reportRepository) ListDueSchedules(now time.Time, limit int) ([]model.ReportSchedule, error) {
+	var schedules []model.ReportSchedule
+	err := r.db.Where("active = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC, id ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due report schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// ClaimSchedule переносит следующий запуск расписания на next, если его
+// еще не перенес другой экземпляр сервиса. Возвращает false, если отчет
+// уже создается другим экземпляром.
+func (r *reportRepository) ClaimSchedule(schedule *model.ReportSchedule, next time.Time) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&model.ReportSchedule{}).
+		Where("id = ? AND next_run_at = ?", schedule.ID, schedule.NextRunAt).
+		Updates(map[string]interface{}{"next_run_at": next, "last_run_at": now})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim report schedule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	schedule.NextRunAt = next
+	schedule.LastRunAt = &now
+	return true, nil
+}
+
+// CreateReport сохраняет отчет и события о нем в одной транзакции.
+// newEvents вызывается после сохранения, когда известен ID отчета,
+// nil — события не сохраняются.
+func (r *reportRepository) CreateReport(report *model.Report, newEvents func(report *model.Report) ([]*model.OutboxEvent, error)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(report).Error; err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		if newEvents == nil {
+			return nil
+		}
+		events, err := newEvents(report)
+		if err != nil {
+			return err
+		}
+		return createOutboxEvents(tx, events)
+	})
+}
+
+// ListReports получает страницу отчетов организации, начиная с последних.
+// scheduleID, если не 0, оставляет отчеты одного расписания.
+func (r *reportRepository) ListReports(orgID *uint, scheduleID uint, page, pageSize int) ([]model.Report, int64, error) {
+	db := organizationScope(r.db.Model(&model.Report{}), orgID)
+	if scheduleID != 0 {
+		db = db.Where("schedule_id = ?", scheduleID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count reports: %w", err)
+	}
+
+	var reports []model.Report
+	err := db.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&reports).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reports: %w", err)
+	}
+	return reports, total, nil
+}
+
+// GetReport получает отчет организации по ID
+func (r *reportRepository) GetReport(id uint, orgID *uint) (*model.Report, error) {
+	var report model.Report
+	err := organizationScope(r.db, orgID).Where("id = ?", id).First(&report).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %d", ErrReportNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get report: %w", err)
+	}
+	return &report, nil
+}
+
+// Summarize считает анализы, пройденное расстояние и покрытие сегментов
+// маршрутов из области scope, созданных в период [from, to)
+func (r *reportRepository) Summarize(scope RouteScope, from, to time.Time) (*ReportSummary, error) {
+	cond, args := scopeCondition(scope)
+	periodArgs := append([]interface{}{from, to}, args...)
+
+	var routes struct {
+		Analyses       int64
+		DistanceMeters float64
+	}
+	err := r.db.Raw(`
+		SELECT COUNT(*) AS analyses, COALESCE(SUM(total_distance_meters), 0) AS distance_meters
+		FROM routes
+		WHERE routes.deleted_at IS NULL AND routes.created_at >= ? AND routes.created_at < ?`+cond,
+		periodArgs...).Scan(&routes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize routes: %w", err)
+	}
+
+	var segments struct {
+		SegmentsWithData int64
+		AverageCoverage  *float64
+	}
+	err = r.db.Raw(`
+		SELECT COUNT(*) AS segments_with_data, AVG(segments.coverage_percentage) AS average_coverage
+		FROM segments
+		JOIN routes ON routes.id = segments.route_id
+		WHERE segments.deleted_at IS NULL AND segments.has_data
+			AND routes.deleted_at IS NULL AND routes.created_at >= ? AND routes.created_at < ?`+cond,
+		periodArgs...).Scan(&segments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize segments: %w", err)
+	}
+
+	return &ReportSummary{
+		Analyses:         routes.Analyses,
+		DistanceMeters:   routes.DistanceMeters,
+		SegmentsWithData: segments.SegmentsWithData,
+		AverageCoverage:  segments.AverageCoverage,
+	}, nil
+}
+
+// WorstSegments получает до limit сегментов с данными с наименьшим покрытием
+// среди маршрутов из области scope, созданных в период [from, to)
+func (r *reportRepository) WorstSegments(scope RouteScope, from, to time.Time, limit int) ([]ReportSegment, error) {
+	cond, args := scopeCondition(scope)
+	args = append(append([]interface{}{from, to}, args...), limit)
+
+	var segments []ReportSegment
+	err := r.db.Raw(`
+		SELECT segments.route_id, routes.name AS route_name,
+			COALESCE(NULLIF(segments.road_name, ''), routes.road_name, '') AS road_name,
+			segments.segment_id, segments.coverage_percentage
+		FROM segments
+		JOIN routes ON routes.id = segments.route_id
+		WHERE segments.deleted_at IS NULL AND segments.has_data
+			AND routes.deleted_at IS NULL AND routes.created_at >= ? AND routes.created_at < ?`+cond+`
+		ORDER BY segments.coverage_percentage ASC, routes.created_at DESC, segments.segment_id ASC
+		LIMIT ?`,
+		args...).Scan(&segments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worst segments: %w", err)
+	}
+	return segments, nil
+}
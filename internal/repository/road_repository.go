@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrRoadNotFound возвращается, если дорога с указанным ID отсутствует
+var ErrRoadNotFound = errors.New("road not found")
+
+// RoadTrendPoint покрытие дороги по одному проезду
+type RoadTrendPoint struct {
+	RouteID         string
+	ObservedAt      time.Time
+	Segments        int
+	AverageCoverage float64
+	WorstCoverage   float64
+}
+
+// RoadRepository интерфейс для работы с агрегированными дорогами
+type RoadRepository interface {
+	Create(road *model.Road) error
+	GetByID(id string) (*model.Road, error)
+	List(page, pageSize int, name string) ([]*model.Road, int64, error)
+	GetCandidates(route *model.Route, distanceM float64) ([]*model.Road, error)
+	GetRouteIDs(roadID string) ([]string, error)
+	AddSegments(segments []model.RoadSegment) error
+	AddObservations(observations []model.RoadObservation) error
+	DeleteRouteObservations(routeID string) ([]string, error)
+	Recalculate(roadID string) error
+	GetTrend(roadID string, from, to *time.Time) ([]RoadTrendPoint, error)
+	DeleteAll() error
+}
+
+// roadRepository реализация RoadRepository
+type roadRepository struct {
+	db *gorm.DB
+}
+
+// NewRoadRepository создает новый instance RoadRepository
+func NewRoadRepository(db *gorm.DB) RoadRepository {
+	return &roadRepository{
+		db: db,
+	}
+}
+
+// Create создает дорогу вместе с ее сегментами
+func (r *roadRepository) Create(road *model.Road) error {
+	if err := r.db.Create(road).Error; err != nil {
+		return fmt.Errorf("failed to create road: %w", err)
+	}
+	return nil
+}
+
+// GetByID получает дорогу с сегментами по порядку
+func (r *roadRepository) GetByID(id string) (*model.Road, error) {
+	var road model.Road
+	err := r.db.Preload("Segments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Where("id = ?", id).First(&road).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %s", ErrRoadNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get road: %w", err)
+	}
+	return &road, nil
+}
+
+// List получает список дорог без сегментов с пагинацией и фильтром по названию
+func (r *roadRepository) List(page, pageSize int, name string) ([]*model.Road, int64, error) {
+	var roads []*model.Road
+	var total int64
+
+	query := r.db.Model(&model.Road{})
+	if name != "" {
+		query = query.Where("name ILIKE ?", "%"+escapeLike(name)+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count roads: %w", err)
+	}
+
+	err := query.Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Order("updated_at DESC").
+		Find(&roads).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list roads: %w", err)
+	}
+
+	return roads, total, nil
+}
+
+// GetCandidates получает дороги, у которых есть сегменты в прямоугольнике
+// маршрута route, расширенном на distanceM метров
+func (r *roadRepository) GetCandidates(route *model.Route, distanceM float64) ([]*model.Road, error) {
+	if len(route.Segments) == 0 {
+		return nil, nil
+	}
+
+	northEast, southWest := routeSearchBox(route, distanceM)
+	startCond, startArgs := pointInBoxSQL("start_lat", "start_lon", northEast, southWest)
+	endCond, endArgs := pointInBoxSQL("end_lat", "end_lon", northEast, southWest)
+
+	var roads []*model.Road
+	err := r.db.Preload("Segments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).
+		Where("id IN (?)", r.db.Table("road_segments").
+			Select("road_id").
+			Where(startCond+" OR "+endCond, append(startArgs, endArgs...)...)).
+		Find(&roads).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get road candidates: %w", err)
+	}
+
+	return roads, nil
+}
+
+// GetRouteIDs получает ID маршрутов, проезды которых учтены в дороге
+func (r *roadRepository) GetRouteIDs(roadID string) ([]string, error) {
+	var routeIDs []string
+	err := r.db.Model(&model.RoadObservation{}).
+		Where("road_id = ?", roadID).
+		Distinct().
+		Pluck("route_id", &routeIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get road routes: %w", err)
+	}
+	return routeIDs, nil
+}
+
+// AddSegments добавляет сегменты к существующей дороге
+func (r *roadRepository) AddSegments(segments []model.RoadSegment) error {
+	if len(segments) == 0 {
+		return nil
+	}
+	if err := r.db.Create(&segments).Error; err != nil {
+		return fmt.Errorf("failed to add road segments: %w", err)
+	}
+	return nil
+}
+
+// AddObservations сохраняет наблюдения покрытия
+func (r *roadRepository) AddObservations(observations []model.RoadObservation) error {
+	if len(observations) == 0 {
+		return nil
+	}
+	if err := r.db.Create(&observations).Error; err != nil {
+		return fmt.Errorf("failed to add road observations: %w", err)
+	}
+	return nil
+}
+
+// DeleteRouteObservations удаляет наблюдения маршрута и возвращает ID
+// затронутых дорог, которые нужно пересчитать
+func (r *roadRepository) DeleteRouteObservations(routeID string) ([]string, error) {
+	var roadIDs []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.RoadObservation{}).
+			Where("route_id = ?", routeID).
+			Distinct().
+			Pluck("road_id", &roadIDs).Error; err != nil {
+			return err
+		}
+		return tx.Where("route_id = ?", routeID).Delete(&model.RoadObservation{}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete route observations: %w", err)
+	}
+	return roadIDs, nil
+}
+
+// Recalculate пересчитывает агрегаты сегментов и дороги по наблюдениям
+// на стороне базы данных
+func (r *roadRepository) Recalculate(roadID string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			UPDATE road_segments SET
+				observation_count = COALESCE(agg.observation_count, 0),
+				latest_coverage = COALESCE(agg.latest_coverage, 0),
+				worst_coverage = COALESCE(agg.worst_coverage, 0),
+				average_coverage = COALESCE(agg.average_coverage, 0),
+				last_observed_at = agg.last_observed_at,
+				updated_at = NOW()
+			FROM road_segments rs
+			LEFT JOIN (
+				SELECT road_segment_id,
+					COUNT(*) AS observation_count,
+					(ARRAY_AGG(coverage_percentage ORDER BY observed_at DESC))[1] AS latest_coverage,
+					MIN(coverage_percentage) AS worst_coverage,
+					AVG(coverage_percentage) AS average_coverage,
+					MAX(observed_at) AS last_observed_at
+				FROM road_observations
+				WHERE road_id = ?
+				GROUP BY road_segment_id
+			) agg ON agg.road_segment_id = rs.id
+			WHERE road_segments.id = rs.id AND rs.road_id = ?`,
+			roadID, roadID).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(`
+			UPDATE roads SET
+				segment_count = (SELECT COUNT(*) FROM road_segments WHERE road_id = roads.id),
+				route_count = (SELECT COUNT(DISTINCT route_id) FROM road_observations WHERE road_id = roads.id),
+				average_coverage = COALESCE((SELECT AVG(average_coverage) FROM road_segments
+					WHERE road_id = roads.id AND observation_count > 0), 0),
+				updated_at = NOW()
+			WHERE id = ?`,
+			roadID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to recalculate road: %w", err)
+	}
+	return nil
+}
+
+// GetTrend получает покрытие дороги по каждому проезду в порядке времени
+// проезда, from и to ограничивают время проездов
+func (r *roadRepository) GetTrend(roadID string, from, to *time.Time) ([]RoadTrendPoint, error) {
+	db := r.db.Model(&model.RoadObservation{}).
+		Select(`route_id,
+			MIN(observed_at) AS observed_at,
+			COUNT(*) AS segments,
+			AVG(coverage_percentage) AS average_coverage,
+			MIN(coverage_percentage) AS worst_coverage`).
+		Where("road_id = ?", roadID)
+	if from != nil {
+		db = db.Where("observed_at >= ?", *from)
+	}
+	if to != nil {
+		db = db.Where("observed_at < ?", *to)
+	}
+
+	var points []RoadTrendPoint
+	err := db.Group("route_id").Order("MIN(observed_at) ASC, route_id ASC").Scan(&points).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get road trend: %w", err)
+	}
+	return points, nil
+}
+
+// DeleteAll удаляет все дороги, сегменты и наблюдения перед полным пересчетом
+func (r *roadRepository) DeleteAll() error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"road_observations", "road_segments", "roads"} {
+			if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete roads: %w", err)
+	}
+	return nil
+}
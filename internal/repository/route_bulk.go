@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"fmt"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// FindIDs возвращает ID маршрутов, подходящих под фильтр, не более limit,
+// в порядке создания
+func (r *routeRepository) FindIDs(query RouteListQuery, limit int) ([]string, error) {
+	db := r.db.Model(&model.Route{})
+	if query.Deleted {
+		db = db.Unscoped().Where("routes.deleted_at IS NOT NULL")
+	}
+
+	var ids []string
+	err := r.applyFilter(db, query).
+		Order("routes.created_at ASC").
+		Order("routes.id ASC").
+		Limit(limit).
+		Pluck("routes.id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find route ids: %w", err)
+	}
+	return ids, nil
+}
+
+// GetByIDs получает маршруты с сегментами и метками. Отсутствующие ID пропускаются.
+func (r *routeRepository) GetByIDs(ids []string) ([]*model.Route, error) {
+	var routes []*model.Route
+	err := r.db.Preload("Segments").Preload("Tags").
+		Where("id IN ?", ids).
+		Find(&routes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routes: %w", err)
+	}
+	return routes, nil
+}
+
+// DeleteMany мягко удаляет маршруты с сегментами в одной транзакции.
+// Возвращает ID маршрутов, которые были найдены и удалены.
+func (r *routeRepository) DeleteMany(ids []string) ([]string, error) {
+	var deleted []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Route{}).Where("id IN ?", ids).Pluck("id", &deleted).Error; err != nil {
+			return fmt.Errorf("failed to find routes: %w", err)
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("route_id IN ?", deleted).Delete(&model.Segment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete segments: %w", err)
+		}
+		if err := tx.Where("id IN ?", deleted).Delete(&model.Route{}).Error; err != nil {
+			return fmt.Errorf("failed to delete routes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// PurgeMany безвозвратно удаляет маршруты, в том числе ранее удаленные,
+// с сегментами и метками в одной транзакции. Возвращает удаленные маршруты
+// без сегментов, чтобы вызывающий код мог удалить их файлы.
+func (r *routeRepository) PurgeMany(ids []string) ([]*model.Route, error) {
+	var routes []*model.Route
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id IN ?", ids).Find(&routes).Error; err != nil {
+			return fmt.Errorf("failed to find routes: %w", err)
+		}
+		if len(routes) == 0 {
+			return nil
+		}
+
+		found := make([]string, len(routes))
+		for i, route := range routes {
+			found[i] = route.ID
+		}
+
+		if err := tx.Unscoped().Where("route_id IN ?", found).Delete(&model.Segment{}).Error; err != nil {
+			return fmt.Errorf("failed to purge segments: %w", err)
+		}
+		if err := tx.Exec("DELETE FROM route_tags WHERE route_id IN ?", found).Error; err != nil {
+			return fmt.Errorf("failed to purge route tags: %w", err)
+		}
+		if err := tx.Where("route_id IN ?", found).Delete(&model.ShareLink{}).Error; err != nil {
+			return fmt.Errorf("failed to purge share links: %w", err)
+		}
+		if err := tx.Where("route_id IN ?", found).Delete(&model.ShadowAnalysis{}).Error; err != nil {
+			return fmt.Errorf("failed to purge shadow analyses: %w", err)
+		}
+		if err := tx.Unscoped().Where("id IN ?", found).Delete(&model.Route{}).Error; err != nil {
+			return fmt.Errorf("failed to purge routes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// AddTags добавляет метки маршрутам в одной транзакции, создавая недостающие.
+// Возвращает ID найденных маршрутов.
+func (r *routeRepository) AddTags(ids []string, names []string) ([]string, error) {
+	var tagged []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Route{}).Where("id IN ?", ids).Pluck("id", &tagged).Error; err != nil {
+			return fmt.Errorf("failed to find routes: %w", err)
+		}
+		if len(tagged) == 0 {
+			return nil
+		}
+
+		tags, err := ensureTags(tx, names)
+		if err != nil {
+			return err
+		}
+		if err := linkRouteTags(tx, tagged, tags); err != nil {
+			return err
+		}
+		return touchRoutes(tx, tagged)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tagged, nil
+}
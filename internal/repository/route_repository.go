@@ -1,21 +1,68 @@
 package repository
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
+	"road-detector-go/internal/geo"
 	"road-detector-go/internal/model"
+	"road-detector-go/pkg/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrRouteNotFound возвращается, если маршрут с указанным ID отсутствует
+var ErrRouteNotFound = errors.New("route not found")
+
+// segmentBatchSize количество сегментов в одном INSERT. Ограничено числом
+// параметров запроса PostgreSQL (65535).
+const segmentBatchSize = 1000
+
+// ErrSegmentNotFound возвращается, если у маршрута нет сегмента с указанным номером
+var ErrSegmentNotFound = errors.New("segment not found")
+
 // RouteRepository интерфейс для работы с маршрутами
 type RouteRepository interface {
 	Create(route *model.Route) error
-	GetByID(id string) (*model.Route, error)
-	GetByArea(northEast, southWest Coordinates) ([]*model.Route, error)
-	List(page, pageSize int) ([]*model.Route, int64, error)
+	CreateWithEvents(route *model.Route, events []*model.OutboxEvent) error
+	ReplaceWithEvents(route *model.Route, events []*model.OutboxEvent) error
+	GetByID(id string, scope RouteScope) (*model.Route, error)
+	GetUpdatedAt(id string) (time.Time, error)
+	CheckScope(id string, scope RouteScope) error
+	FilterScope(ids []string, scope RouteScope) ([]string, error)
+	GetByArea(query AreaQuery, page, pageSize int) ([]*model.Route, int64, error)
+	GetNear(point Coordinates, radiusM float64, limit int, scope RouteScope) ([]RouteDistance, error)
+	GetNearest(point Coordinates, scope RouteScope) (*RouteDistance, error)
+	GetByPolygon(polygon geo.Polygon, scope RouteScope) ([]*model.Route, error)
+	GetSegments(routeID string, query SegmentQuery) ([]model.Segment, int64, error)
+	GetSegment(routeID string, segmentID int, scope RouteScope) (*model.Segment, error)
+	GetOverlapCandidates(route *model.Route, distanceM float64, scope RouteScope) ([]*model.Route, error)
+	List(page, pageSize int, query RouteListQuery) ([]*model.Route, int64, error)
+	Search(text string, scope RouteScope, page, pageSize int) ([]*model.Route, int64, error)
 	Delete(id string) error
+	GetDeletedByID(id string) (*model.Route, error)
+	Restore(id string) error
+	Archive(before time.Time, limit int) (int64, error)
+	Unarchive(id string) error
+	Purge(id string) error
+	FindIDs(query RouteListQuery, limit int) ([]string, error)
+	GetByIDs(ids []string) ([]*model.Route, error)
+	DeleteMany(ids []string) ([]string, error)
+	PurgeMany(ids []string) ([]*model.Route, error)
+	AddTags(ids []string, names []string) ([]string, error)
+	Split(route, part *model.Route, atSegment int) error
 	Update(route *model.Route) error
+	UpdateMetadata(route *model.Route) error
+	StorageUsage(owner StorageOwner) (StorageUsage, error)
+	ListUnmeasured(limit int) ([]*model.Route, error)
+	SetStorageBytes(id string, bytes int64) error
+	ListUntranscoded() ([]string, error)
+	SetPlayableVideo(id, path string, storageBytes int64) error
 }
 
 // Coordinates представляет координаты точки
@@ -24,104 +71,731 @@ type Coordinates struct {
 	Lon float64
 }
 
+// Поля сортировки списка маршрутов
+const (
+	RouteSortCreatedAt = "created_at"
+	RouteSortCoverage  = "coverage"
+	RouteSortDistance  = "distance"
+	RouteSortQuality   = "quality"
+)
+
+// routeSortColumns колонки для полей сортировки маршрутов
+var routeSortColumns = map[string]string{
+	RouteSortCreatedAt: "routes.created_at",
+	RouteSortCoverage:  "routes.average_coverage",
+	RouteSortDistance:  "routes.total_distance_meters",
+	// Маршруты без индекса качества считаются хуже любых
+	RouteSortQuality: "COALESCE(routes.quality_score, -1)",
+}
+
+// RouteListQuery параметры выборки списка маршрутов. Пустые фильтры не учитываются.
+type RouteListQuery struct {
+	// Name подстрока названия маршрута
+	Name string
+	// RoadName подстрока названия дороги маршрута или одного из его сегментов
+	RoadName      string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// MinCoverage и MaxCoverage ограничивают среднее покрытие маршрута включительно
+	MinCoverage *float64
+	MaxCoverage *float64
+	// MinQuality и MaxQuality ограничивают индекс качества маршрута
+	// включительно; маршруты без индекса не подходят
+	MinQuality *float64
+	MaxQuality *float64
+	// SortBy поле сортировки, по умолчанию created_at
+	SortBy string
+	Desc   bool
+	// Tags метки, которые должны быть у маршрута одновременно
+	Tags []string
+	// Defects отбирает маршруты по дефектам покрытия
+	Defects DefectFilter
+	// MarkingType маршруты, на сегментах которых найдена разметка этого типа
+	MarkingType string
+	// WithSegments загружает сегменты маршрутов; без него возвращаются только сводные данные
+	WithSegments bool
+	// Deleted возвращает только удаленные маршруты вместо действующих
+	Deleted bool
+	// DeletedBefore удаленные маршруты, удаленные раньше этого времени;
+	// учитывается вместе с Deleted
+	DeletedBefore *time.Time
+	// Archived возвращает только архивные маршруты. Без него архивные
+	// маршруты не выдаются, кроме списка удаленных.
+	Archived bool
+	// After продолжает выдачу после маршрута курсора, номер страницы при этом
+	// не учитывается. Допустим только при сортировке по created_at.
+	After *RouteCursor
+	// Scope ограничивает выдачу маршрутами организации или пользователя
+	Scope RouteScope
+}
+
+// AreaQuery параметры выборки маршрутов, сегменты которых попадают в область
+type AreaQuery struct {
+	// NorthEast и SouthWest углы области. Для области, пересекающей
+	// антимеридиан, NorthEast.Lon передается развернутой (больше 180).
+	NorthEast Coordinates
+	SouthWest Coordinates
+	// Defects отбирает маршруты по дефектам покрытия
+	Defects DefectFilter
+	// MarkingType маршруты, на сегментах которых найдена разметка этого типа
+	MarkingType string
+	// WithSegments загружает сегменты маршрутов; без него возвращаются только сводные данные
+	WithSegments bool
+	// Scope ограничивает выдачу маршрутами организации или пользователя
+	Scope RouteScope
+}
+
+// DefectFilter отбирает маршруты по дефектам покрытия (выбоинам,
+// трещинам). Пустой фильтр не ограничивает выдачу.
+type DefectFilter struct {
+	// HasDefects true — только маршруты с дефектами, false — без дефектов
+	HasDefects *bool
+	// Type маршруты, на сегментах которых есть дефекты этого типа
+	Type string
+}
+
+// RouteScope ограничивает маршруты, доступные запросу. Пустая область
+// не ограничивает выдачу.
+type RouteScope struct {
+	// OrganizationID маршруты организации
+	OrganizationID *uint
+	// OwnerID маршруты пользователя. Без OrganizationID — только личные
+	// маршруты пользователя, не принадлежащие ни одной организации.
+	OwnerID *uint
+	// APIKeyID маршруты, загруженные ключом API без пользователя и организации
+	APIKeyID *uint
+}
+
+// Empty проверяет, что область не ограничивает маршруты
+func (s RouteScope) Empty() bool {
+	return s.OrganizationID == nil && s.OwnerID == nil && s.APIKeyID == nil
+}
+
+// RouteCursor позиция в списке маршрутов, отсортированном по created_at и id
+type RouteCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Поля сортировки сегментов
+const (
+	SegmentSortID       = "segment_id"
+	SegmentSortCoverage = "coverage"
+	SegmentSortFrames   = "frames_count"
+	SegmentSortQuality  = "quality"
+)
+
+// segmentSortColumns колонки для полей сортировки сегментов
+var segmentSortColumns = map[string]string{
+	SegmentSortID:       "segment_id",
+	SegmentSortCoverage: "coverage_percentage",
+	SegmentSortFrames:   "frames_count",
+	SegmentSortQuality:  "COALESCE(quality_score, -1)",
+}
+
+// SegmentQuery параметры выборки сегментов маршрута. Nil фильтры не учитываются.
+type SegmentQuery struct {
+	Page       int
+	PageSize   int
+	CoverageLT *float64
+	CoverageGT *float64
+	HasData    *bool
+	SortBy     string
+	Desc       bool
+	// MarkingType сегменты, на которых найдена разметка этого типа
+	MarkingType string
+	// LowConfidence сегменты с низкой уверенностью модели (true) или без нее
+	LowConfidence *bool
+	// Scope ограничивает выдачу сегментами маршрутов организации или пользователя
+	Scope RouteScope
+}
+
+// RouteDistance маршрут и расстояние от точки запроса до его ближайшего сегмента
+type RouteDistance struct {
+	Route     *model.Route
+	DistanceM float64
+}
+
 // routeRepository реализация RouteRepository
 type routeRepository struct {
 	db *gorm.DB
+	// reader соединение для списков и пространственных запросов: реплика
+	// для чтения или основная база
+	reader *gorm.DB
+
+	// fullTextOnce однократно проверяет наличие колонки search_vector
+	fullTextOnce sync.Once
+	fullText     bool
+}
+
+// NewRouteRepository создает новый instance RouteRepository. Списки,
+// поиск и пространственные запросы выполняются через reader, например
+// реплику для чтения; nil — через db.
+func NewRouteRepository(db, reader *gorm.DB) RouteRepository {
+	return newRouteRepository(db, reader)
 }
 
-// NewRouteRepository создает новый instance RouteRepository
-func NewRouteRepository(db *gorm.DB) RouteRepository {
+// newRouteRepository создает routeRepository для NewRouteRepository
+// и NewPostGISRouteRepository
+func newRouteRepository(db, reader *gorm.DB) *routeRepository {
+	if reader == nil {
+		reader = db
+	}
 	return &routeRepository{
-		db: db,
+		db:     db,
+		reader: reader,
 	}
 }
 
 // Create создает новый маршрут в базе данных
 func (r *routeRepository) Create(route *model.Route) error {
+	return r.CreateWithEvents(route, nil)
+}
+
+// CreateWithEvents создает маршрут и в той же транзакции сохраняет события
+// о нем: событие публикуется, только если маршрут сохранен
+func (r *routeRepository) CreateWithEvents(route *model.Route, events []*model.OutboxEvent) error {
 	tx := r.db.Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
 	}
 
-	// Сначала создаем маршрут
-	if err := tx.Create(route).Error; err != nil {
+	if err := createRoute(tx, route); err != nil {
 		tx.Rollback()
+		return err
+	}
+
+	if err := createOutboxEvents(tx, events); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// replaceKeptRouteColumns поля маршрута, которые ReplaceWithEvents не изменяет
+var replaceKeptRouteColumns = []string{"api_key_id", "owner_id", "organization_id", "created_at", "archived_at"}
+
+// ReplaceWithEvents заменяет маршрут с тем же ID, в том числе удаленный или
+// в архиве, результатом нового анализа: поля анализа, сегменты и метки
+// перезаписываются, а ссылки на маршрут и поля replaceKeptRouteColumns
+// сохраняются. Теневые анализы
+// прежнего видео удаляются. События events сохраняются в той же транзакции.
+func (r *routeRepository) ReplaceWithEvents(route *model.Route, events []*model.OutboxEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		tagNames := make([]string, len(route.Tags))
+		for i, tag := range route.Tags {
+			tagNames[i] = tag.Name
+		}
+		route.Tags = nil
+
+		// Владелец, организация, ключ, время создания и архив остаются от
+		// прежнего маршрута, чтобы перезапись не меняла область доступа
+		var kept model.Route
+		err := tx.Unscoped().
+			Select(replaceKeptRouteColumns).
+			Where("id = ?", route.ID).
+			Take(&kept).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: id %s", ErrRouteNotFound, route.ID)
+			}
+			return fmt.Errorf("failed to get replaced route: %w", err)
+		}
+		route.APIKeyID = kept.APIKeyID
+		route.OwnerID = kept.OwnerID
+		route.OrganizationID = kept.OrganizationID
+		route.CreatedAt = kept.CreatedAt
+		route.ArchivedAt = kept.ArchivedAt
+
+		// Остальные поля, в том числе нулевые: маршрут перестает быть удаленным
+		omit := append([]string{"id", clause.Associations}, replaceKeptRouteColumns...)
+		result := tx.Unscoped().Model(&model.Route{ID: route.ID}).
+			Select("*").
+			Omit(omit...).
+			Updates(route)
+		if result.Error != nil {
+			return fmt.Errorf("failed to replace route: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: id %s", ErrRouteNotFound, route.ID)
+		}
+
+		if err := tx.Unscoped().Where("route_id = ?", route.ID).Delete(&model.Segment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete replaced segments: %w", err)
+		}
+		if err := tx.Where("route_id = ?", route.ID).Delete(&model.ShadowAnalysis{}).Error; err != nil {
+			return fmt.Errorf("failed to delete replaced shadow analyses: %w", err)
+		}
+		tags, err := replaceRouteTags(tx, route.ID, tagNames)
+		if err != nil {
+			return err
+		}
+		route.Tags = tags
+
+		if err := createSegments(tx, route); err != nil {
+			return err
+		}
+		return createOutboxEvents(tx, events)
+	})
+}
+
+// createRoute создает маршрут с сегментами и метками в транзакции tx
+func createRoute(tx *gorm.DB, route *model.Route) error {
+	// Метки привязываются отдельно, чтобы переиспользовать существующие
+	tagNames := make([]string, len(route.Tags))
+	for i, tag := range route.Tags {
+		tagNames[i] = tag.Name
+	}
+	route.Tags = nil
+
+	// Сначала создаем маршрут, сегменты вставляются отдельно пачками
+	if err := tx.Omit(clause.Associations).Create(route).Error; err != nil {
 		return fmt.Errorf("failed to create route: %w", err)
 	}
 
-	// Затем создаем сегменты
-	for i := range route.Segments {
-		// Логируем данные сегмента перед созданием
-		fmt.Printf("Создаем сегмент %d: RouteID=%s, SegmentID=%d, ID=%d\n",
-			i, route.Segments[i].RouteID, route.Segments[i].SegmentID, route.Segments[i].ID)
+	if len(tagNames) > 0 {
+		tags, err := replaceRouteTags(tx, route.ID, tagNames)
+		if err != nil {
+			return err
+		}
+		route.Tags = tags
+	}
+
+	return createSegments(tx, route)
+}
 
+// createSegments вставляет сегменты маршрута пачками по segmentBatchSize
+func createSegments(tx *gorm.DB, route *model.Route) error {
+	if len(route.Segments) == 0 {
+		return nil
+	}
+	for i := range route.Segments {
 		route.Segments[i].ID = 0 // Обнуляем ID для auto-increment
 		route.Segments[i].RouteID = route.ID
 		// Не обнуляем segment_id, он может быть любым
-
-		if err := tx.Create(&route.Segments[i]).Error; err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to create segment %d: %w", i, err)
-		}
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if err := tx.Omit(clause.Associations).CreateInBatches(route.Segments, segmentBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to create segments: %w", err)
 	}
-
 	return nil
 }
 
-// GetByID получает маршрут по ID
-func (r *routeRepository) GetByID(id string) (*model.Route, error) {
+// GetByID получает маршрут по ID из области scope. Маршрут вне области
+// считается ненайденным.
+func (r *routeRepository) GetByID(id string, scope RouteScope) (*model.Route, error) {
 	var route model.Route
-	err := r.db.Preload("Segments").Where("id = ?", id).First(&route).Error
+	err := r.db.Preload("Segments").Preload("Tags").
+		Scopes(routeScope(scope)).
+		Where("routes.id = ?", id).
+		First(&route).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("route with id %s not found", id)
+			return nil, fmt.Errorf("%w: id %s", ErrRouteNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get route: %w", err)
 	}
 	return &route, nil
 }
 
-// GetByArea получает маршруты в заданной области
-func (r *routeRepository) GetByArea(northEast, southWest Coordinates) ([]*model.Route, error) {
+// GetUpdatedAt получает время последнего изменения маршрута без загрузки сегментов
+func (r *routeRepository) GetUpdatedAt(id string) (time.Time, error) {
+	var route model.Route
+	err := r.db.Select("id", "updated_at").Where("id = ?", id).First(&route).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, fmt.Errorf("%w: id %s", ErrRouteNotFound, id)
+		}
+		return time.Time{}, fmt.Errorf("failed to get route: %w", err)
+	}
+	return route.UpdatedAt, nil
+}
+
+// CheckScope проверяет, что маршрут, в том числе удаленный, входит в область
+// scope. Маршрут вне области считается ненайденным.
+func (r *routeRepository) CheckScope(id string, scope RouteScope) error {
+	var count int64
+	err := r.db.Unscoped().Model(&model.Route{}).
+		Scopes(routeScope(scope)).
+		Where("routes.id = ?", id).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to check route scope: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: id %s", ErrRouteNotFound, id)
+	}
+	return nil
+}
+
+// FilterScope возвращает те из ids, которые входят в область scope,
+// включая удаленные маршруты
+func (r *routeRepository) FilterScope(ids []string, scope RouteScope) ([]string, error) {
+	filtered := make([]string, 0, len(ids))
+	if len(ids) == 0 {
+		return filtered, nil
+	}
+	err := r.db.Unscoped().Model(&model.Route{}).
+		Scopes(routeScope(scope)).
+		Where("routes.id IN ?", ids).
+		Pluck("routes.id", &filtered).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check route scope: %w", err)
+	}
+	return filtered, nil
+}
+
+// GetByArea получает страницу маршрутов, у которых начало или конец
+// хотя бы одного сегмента попадает в область, и их общее количество.
+// Условие по координатам использует индексы idx_segments_start и idx_segments_end.
+func (r *routeRepository) GetByArea(query AreaQuery, page, pageSize int) ([]*model.Route, int64, error) {
+	startCond, startArgs := pointInBoxSQL("segments.start_lat", "segments.start_lon", query.NorthEast, query.SouthWest)
+	endCond, endArgs := pointInBoxSQL("segments.end_lat", "segments.end_lon", query.NorthEast, query.SouthWest)
+
+	inArea := r.reader.Table("segments").
+		Select("route_id").
+		Where("deleted_at IS NULL AND ("+startCond+" OR "+endCond+")", append(startArgs, endArgs...)...)
+	return r.findInArea(inArea, query, page, pageSize)
+}
+
+// findInArea получает страницу маршрутов с ID из подзапроса inArea, новые первыми.
+// Сегменты загружаются одним запросом и только для маршрутов страницы.
+func (r *routeRepository) findInArea(inArea *gorm.DB, query AreaQuery, page, pageSize int) ([]*model.Route, int64, error) {
+	var routes []*model.Route
+	var total int64
+
+	db := r.reader.Model(&model.Route{}).
+		Scopes(routeScope(query.Scope), r.defectScope(query.Defects), r.markingScope(query.MarkingType)).
+		Where("routes.archived_at IS NULL").
+		Where("routes.id IN (?)", inArea)
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count routes by area: %w", err)
+	}
+
+	if query.WithSegments {
+		db = db.Preload("Segments")
+	}
+	err := db.Order("routes.created_at DESC, routes.id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&routes).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get routes by area: %w", err)
+	}
+
+	return routes, total, nil
+}
+
+// GetNear получает маршруты, у которых хотя бы один конец сегмента находится
+// в пределах radiusM метров от точки, отсортированные по расстоянию
+func (r *routeRepository) GetNear(point Coordinates, radiusM float64, limit int, scope RouteScope) ([]RouteDistance, error) {
+	distanceExpr, distanceArgs := segmentDistanceSQL(point)
+	northEast, southWest := boundingBoxAround(point, radiusM)
+
+	// Префильтр по прямоугольнику, чтобы не считать расстояние для всей таблицы.
+	// Прямоугольник может выходить за антимеридиан, это учитывается в условии.
+	startCond, startArgs := pointInBoxSQL("segments.start_lat", "segments.start_lon", northEast, southWest)
+	endCond, endArgs := pointInBoxSQL("segments.end_lat", "segments.end_lon", northEast, southWest)
+
+	var rows []routeDistanceRow
+	err := r.reader.Table("segments").
+		Select("segments.route_id, MIN("+distanceExpr+") AS distance_m", distanceArgs...).
+		Joins("JOIN routes ON routes.id = segments.route_id AND routes.deleted_at IS NULL AND routes.archived_at IS NULL").
+		Where("segments.deleted_at IS NULL").
+		Where(startCond+" OR "+endCond, append(startArgs, endArgs...)...).
+		Scopes(routeScope(scope)).
+		Group("segments.route_id").
+		Having("MIN("+distanceExpr+") <= ?", append(distanceArgs, radiusM)...).
+		Order("distance_m ASC").
+		Limit(limit).
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routes near point: %w", err)
+	}
+
+	return r.loadRouteDistances(rows)
+}
+
+// GetNearest получает маршрут, ближайший к точке
+func (r *routeRepository) GetNearest(point Coordinates, scope RouteScope) (*RouteDistance, error) {
+	distanceExpr, distanceArgs := segmentDistanceSQL(point)
+
+	var rows []routeDistanceRow
+	err := r.reader.Table("segments").
+		Select("segments.route_id, "+distanceExpr+" AS distance_m", distanceArgs...).
+		Joins("JOIN routes ON routes.id = segments.route_id AND routes.deleted_at IS NULL AND routes.archived_at IS NULL").
+		Where("segments.deleted_at IS NULL").
+		Scopes(routeScope(scope)).
+		Order("distance_m ASC").
+		Limit(1).
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nearest route: %w", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no routes found", ErrRouteNotFound)
+	}
+
+	routes, err := r.loadRouteDistances(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("%w: no routes found", ErrRouteNotFound)
+	}
+
+	return &routes[0], nil
+}
+
+// GetByPolygon получает маршруты, хотя бы один сегмент которых пересекает полигон.
+// В SQL отбираются сегменты, чей описывающий прямоугольник пересекает прямоугольник
+// полигона, точная проверка пересечения выполняется в Go.
+func (r *routeRepository) GetByPolygon(polygon geo.Polygon, scope RouteScope) ([]*model.Route, error) {
+	box := polygon.BoundingBox()
+
+	var candidates []*model.Route
+	err := r.reader.Preload("Segments").
+		Scopes(routeScope(scope)).
+		Where("routes.archived_at IS NULL").
+		Where("routes.id IN (?)", r.reader.Table("segments").
+			Select("route_id").
+			Where("deleted_at IS NULL").
+			Where("LEAST(start_lat, end_lat) <= ? AND GREATEST(start_lat, end_lat) >= ?",
+				box.NorthEast.Lat, box.SouthWest.Lat).
+			Where("LEAST(start_lon, end_lon) <= ? AND GREATEST(start_lon, end_lon) >= ?",
+				box.NorthEast.Lon, box.SouthWest.Lon)).
+		Find(&candidates).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routes by polygon: %w", err)
+	}
+
+	routes := make([]*model.Route, 0, len(candidates))
+	for _, route := range candidates {
+		for _, seg := range route.Segments {
+			if polygon.IntersectsSegment(
+				models.Coordinates{Lat: seg.StartLat, Lon: seg.StartLon},
+				models.Coordinates{Lat: seg.EndLat, Lon: seg.EndLon},
+			) {
+				routes = append(routes, route)
+				break
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+// routeDistanceRow строка результата запроса расстояний
+type routeDistanceRow struct {
+	RouteID   string  `gorm:"column:route_id"`
+	DistanceM float64 `gorm:"column:distance_m"`
+}
+
+// loadRouteDistances загружает маршруты с сегментами, сохраняя порядок строк
+func (r *routeRepository) loadRouteDistances(rows []routeDistanceRow) ([]RouteDistance, error) {
+	if len(rows) == 0 {
+		return []RouteDistance{}, nil
+	}
+
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.RouteID
+	}
+
 	var routes []*model.Route
+	if err := r.reader.Preload("Segments").Where("id IN ?", ids).Find(&routes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load routes: %w", err)
+	}
+
+	byID := make(map[string]*model.Route, len(routes))
+	for _, route := range routes {
+		byID[route.ID] = route
+	}
+
+	result := make([]RouteDistance, 0, len(rows))
+	for _, row := range rows {
+		if route, ok := byID[row.RouteID]; ok {
+			result = append(result, RouteDistance{Route: route, DistanceM: row.DistanceM})
+		}
+	}
+
+	return result, nil
+}
 
-	// Находим маршруты, у которых есть сегменты в заданной области
+// GetSegments получает страницу сегментов маршрута с фильтрами и сортировкой
+func (r *routeRepository) GetSegments(routeID string, query SegmentQuery) ([]model.Segment, int64, error) {
+	if err := r.ensureExists(routeID, query.Scope); err != nil {
+		return nil, 0, err
+	}
+
+	db := r.db.Model(&model.Segment{}).Where("route_id = ?", routeID)
+	if query.CoverageLT != nil {
+		db = db.Where("coverage_percentage < ?", *query.CoverageLT)
+	}
+	if query.CoverageGT != nil {
+		db = db.Where("coverage_percentage > ?", *query.CoverageGT)
+	}
+	if query.HasData != nil {
+		db = db.Where("has_data = ?", *query.HasData)
+	}
+	if query.MarkingType != "" {
+		db = db.Where(classCoverageCondition, query.MarkingType)
+	}
+	if query.LowConfidence != nil {
+		db = db.Where("low_confidence = ?", *query.LowConfidence)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count segments: %w", err)
+	}
+
+	column, ok := segmentSortColumns[query.SortBy]
+	if !ok {
+		column = segmentSortColumns[SegmentSortID]
+	}
+	order := column + " ASC"
+	if query.Desc {
+		order = column + " DESC"
+	}
+
+	var segments []model.Segment
+	err := db.Order(order).
+		Order("segment_id ASC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&segments).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get segments: %w", err)
+	}
+
+	return segments, total, nil
+}
+
+// GetSegment получает сегмент маршрута из области scope по его номеру
+func (r *routeRepository) GetSegment(routeID string, segmentID int, scope RouteScope) (*model.Segment, error) {
+	if err := r.ensureExists(routeID, scope); err != nil {
+		return nil, err
+	}
+
+	var segment model.Segment
+	err := r.db.Where("route_id = ? AND segment_id = ?", routeID, segmentID).First(&segment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: route %s, segment %d", ErrSegmentNotFound, routeID, segmentID)
+		}
+		return nil, fmt.Errorf("failed to get segment: %w", err)
+	}
+	return &segment, nil
+}
+
+// ensureExists проверяет, что маршрут существует и входит в область scope
+func (r *routeRepository) ensureExists(routeID string, scope RouteScope) error {
+	var count int64
+	err := r.db.Model(&model.Route{}).
+		Scopes(routeScope(scope)).
+		Where("routes.id = ?", routeID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to check route: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: id %s", ErrRouteNotFound, routeID)
+	}
+	return nil
+}
+
+// GetOverlapCandidates получает другие маршруты, у которых есть сегменты
+// в пределах прямоугольника маршрута route, расширенного на distanceM метров.
+// Точная проверка пересечения выполняется вызывающим кодом.
+func (r *routeRepository) GetOverlapCandidates(route *model.Route, distanceM float64, scope RouteScope) ([]*model.Route, error) {
+	if len(route.Segments) == 0 {
+		return nil, nil
+	}
+
+	northEast, southWest := routeSearchBox(route, distanceM)
+	startCond, startArgs := pointInBoxSQL("start_lat", "start_lon", northEast, southWest)
+	endCond, endArgs := pointInBoxSQL("end_lat", "end_lon", northEast, southWest)
+
+	var routes []*model.Route
 	err := r.db.Preload("Segments").
-		Joins("JOIN segments ON segments.route_id = routes.id").
-		Where("(segments.start_lat BETWEEN ? AND ? AND segments.start_lon BETWEEN ? AND ?) OR "+
-			"(segments.end_lat BETWEEN ? AND ? AND segments.end_lon BETWEEN ? AND ?)",
-			southWest.Lat, northEast.Lat, southWest.Lon, northEast.Lon,
-			southWest.Lat, northEast.Lat, southWest.Lon, northEast.Lon).
-		Distinct("routes.id").
+		Scopes(routeScope(scope)).
+		Where("id <> ? AND id IN (?)", route.ID, r.db.Table("segments").
+			Select("route_id").
+			Where("deleted_at IS NULL AND ("+startCond+" OR "+endCond+")", append(startArgs, endArgs...)...)).
 		Find(&routes).Error
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to get routes by area: %w", err)
+		return nil, fmt.Errorf("failed to get overlap candidates: %w", err)
 	}
 
 	return routes, nil
 }
 
-// List получает список маршрутов с пагинацией
-func (r *routeRepository) List(page, pageSize int) ([]*model.Route, int64, error) {
+// List получает список маршрутов с пагинацией и фильтрацией
+func (r *routeRepository) List(page, pageSize int, query RouteListQuery) ([]*model.Route, int64, error) {
 	var routes []*model.Route
 	var total int64
 
+	db := r.reader.Model(&model.Route{})
+	if query.Deleted {
+		db = db.Unscoped().Where("routes.deleted_at IS NOT NULL")
+	}
+	db = r.applyFilter(db, query)
+
 	// Подсчитываем общее количество
-	if err := r.db.Model(&model.Route{}).Count(&total).Error; err != nil {
+	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count routes: %w", err)
 	}
 
+	if query.WithSegments {
+		// Сегменты удаленного маршрута тоже помечены удаленными
+		db = db.Preload("Segments", func(db *gorm.DB) *gorm.DB {
+			if query.Deleted {
+				return db.Unscoped()
+			}
+			return db
+		})
+	}
+	db = db.Preload("Tags")
+
 	// Получаем маршруты с пагинацией
 	offset := (page - 1) * pageSize
-	err := r.db.Preload("Segments").
-		Offset(offset).
+	column, ok := routeSortColumns[query.SortBy]
+	if !ok {
+		column = routeSortColumns[RouteSortCreatedAt]
+	}
+	direction := " ASC"
+	if query.Desc {
+		direction = " DESC"
+	}
+
+	// Курсорная пагинация: выборка по ключу (created_at, id) не сбивается
+	// при добавлении маршрутов между запросами страниц
+	if query.After != nil {
+		cmp := ">"
+		if query.Desc {
+			cmp = "<"
+		}
+		db = db.Where("(routes.created_at, routes.id) "+cmp+" (?, ?)", query.After.CreatedAt, query.After.ID)
+		offset = 0
+	}
+
+	err := db.Offset(offset).
 		Limit(pageSize).
-		Order("created_at DESC").
+		Order(column + direction).
+		Order("routes.id" + direction).
 		Find(&routes).Error
 
 	if err != nil {
@@ -131,7 +805,161 @@ func (r *routeRepository) List(page, pageSize int) ([]*model.Route, int64, error
 	return routes, total, nil
 }
 
-// Delete удаляет маршрут по ID
+// Search ищет маршруты по названию, описанию и названию дороги.
+// Использует полнотекстовый индекс search_vector с ранжированием по релевантности,
+// а если его нет - поиск подстроки через ILIKE.
+func (r *routeRepository) Search(text string, scope RouteScope, page, pageSize int) ([]*model.Route, int64, error) {
+	var routes []*model.Route
+	var total int64
+
+	fullText := r.fullTextAvailable()
+
+	db := r.reader.Model(&model.Route{}).
+		Scopes(routeScope(scope)).
+		Where("routes.archived_at IS NULL")
+	if fullText {
+		db = db.Where("routes.search_vector @@ websearch_to_tsquery('russian', ?)", text)
+	} else {
+		pattern := "%" + escapeLike(text) + "%"
+		db = db.Where("(routes.name ILIKE ? OR routes.description ILIKE ? OR routes.road_name ILIKE ?)",
+			pattern, pattern, pattern)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	if fullText {
+		db = db.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(routes.search_vector, websearch_to_tsquery('russian', ?)) DESC, routes.created_at DESC",
+			Vars:               []interface{}{text},
+			WithoutParentheses: true,
+		}})
+	} else {
+		db = db.Order("routes.created_at DESC")
+	}
+
+	err := db.Preload("Tags").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&routes).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search routes: %w", err)
+	}
+
+	return routes, total, nil
+}
+
+// fullTextAvailable проверяет, создана ли колонка полнотекстового поиска
+func (r *routeRepository) fullTextAvailable() bool {
+	r.fullTextOnce.Do(func() {
+		err := r.db.Raw(`SELECT EXISTS (SELECT 1 FROM information_schema.columns
+			WHERE table_name = 'routes' AND column_name = 'search_vector')`).
+			Scan(&r.fullText).Error
+		if err != nil {
+			r.fullText = false
+		}
+	})
+	return r.fullText
+}
+
+// applyFilter добавляет к запросу условия фильтров
+func (r *routeRepository) applyFilter(db *gorm.DB, query RouteListQuery) *gorm.DB {
+	db = db.Scopes(routeScope(query.Scope), r.defectScope(query.Defects), r.markingScope(query.MarkingType))
+	switch {
+	case query.Archived:
+		db = db.Where("routes.archived_at IS NOT NULL")
+	case !query.Deleted:
+		// Список удаленных включает и архивные маршруты
+		db = db.Where("routes.archived_at IS NULL")
+	}
+	if query.Name != "" {
+		db = db.Where("routes.name ILIKE ?", "%"+escapeLike(query.Name)+"%")
+	}
+	if query.CreatedAfter != nil {
+		db = db.Where("routes.created_at >= ?", *query.CreatedAfter)
+	}
+	if query.CreatedBefore != nil {
+		db = db.Where("routes.created_at < ?", *query.CreatedBefore)
+	}
+	if query.DeletedBefore != nil {
+		db = db.Where("routes.deleted_at < ?", *query.DeletedBefore)
+	}
+	if query.MinCoverage != nil {
+		db = db.Where("routes.average_coverage >= ?", *query.MinCoverage)
+	}
+	if query.MaxCoverage != nil {
+		db = db.Where("routes.average_coverage <= ?", *query.MaxCoverage)
+	}
+	if query.MinQuality != nil {
+		db = db.Where("routes.quality_score >= ?", *query.MinQuality)
+	}
+	if query.MaxQuality != nil {
+		db = db.Where("routes.quality_score <= ?", *query.MaxQuality)
+	}
+	if query.RoadName != "" {
+		pattern := "%" + escapeLike(query.RoadName) + "%"
+		db = db.Where("(routes.road_name ILIKE ? OR routes.id IN (?))", pattern,
+			r.db.Table("segments").
+				Select("route_id").
+				Where("deleted_at IS NULL AND road_name ILIKE ?", pattern))
+	}
+	// Маршрут должен иметь все перечисленные метки
+	for _, tag := range query.Tags {
+		db = db.Where("routes.id IN (?)", r.db.Table("route_tags").
+			Select("route_tags.route_id").
+			Joins("JOIN tags ON tags.id = route_tags.tag_id").
+			Where("tags.name = ?", tag))
+	}
+	return db
+}
+
+// defectScope ограничивает запрос к таблице routes фильтром дефектов.
+// Тип дефекта ищется в JSON дефектов сегментов.
+func (r *routeRepository) defectScope(filter DefectFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.HasDefects != nil {
+			if *filter.HasDefects {
+				db = db.Where("routes.defects_count > 0")
+			} else {
+				db = db.Where("routes.defects_count = 0")
+			}
+		}
+		if filter.Type != "" {
+			contains, _ := json.Marshal([]map[string]string{{"type": filter.Type}})
+			db = db.Where("routes.id IN (?)", r.db.Table("segments").
+				Select("route_id").
+				Where("deleted_at IS NULL AND defects @> ?::jsonb", string(contains)))
+		}
+		return db
+	}
+}
+
+// classCoverageCondition условие на сегмент с ненулевым покрытием разметкой
+// заданного типа
+const classCoverageCondition = "(class_coverage ->> ?)::double precision > 0"
+
+// markingScope ограничивает запрос к таблице routes маршрутами, на
+// сегментах которых найдена разметка типа markingType. Пустой тип запрос
+// не меняет.
+func (r *routeRepository) markingScope(markingType string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if markingType == "" {
+			return db
+		}
+		return db.Where("routes.id IN (?)", r.db.Table("segments").
+			Select("route_id").
+			Where("deleted_at IS NULL AND "+classCoverageCondition, markingType))
+	}
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Delete мягко удаляет маршрут и его сегменты: строки помечаются deleted_at
+// и могут быть восстановлены через Restore
 func (r *routeRepository) Delete(id string) error {
 	tx := r.db.Begin()
 	if tx.Error != nil {
@@ -153,7 +981,7 @@ func (r *routeRepository) Delete(id string) error {
 
 	if result.RowsAffected == 0 {
 		tx.Rollback()
-		return fmt.Errorf("route with id %s not found", id)
+		return fmt.Errorf("%w: id %s", ErrRouteNotFound, id)
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -163,6 +991,154 @@ func (r *routeRepository) Delete(id string) error {
 	return nil
 }
 
+// GetDeletedByID получает маршрут по ID, в том числе удаленный
+func (r *routeRepository) GetDeletedByID(id string) (*model.Route, error) {
+	var route model.Route
+	err := r.db.Unscoped().
+		Preload("Segments", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Tags").
+		Where("id = ?", id).
+		First(&route).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %s", ErrRouteNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get route: %w", err)
+	}
+	return &route, nil
+}
+
+// Restore восстанавливает удаленный маршрут вместе с сегментами
+func (r *routeRepository) Restore(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&model.Route{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return fmt.Errorf("failed to restore route: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: deleted route with id %s", ErrRouteNotFound, id)
+		}
+
+		if err := tx.Unscoped().Model(&model.Segment{}).
+			Where("route_id = ? AND deleted_at IS NOT NULL", id).
+			Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore segments: %w", err)
+		}
+		return nil
+	})
+}
+
+// Archive переносит в архив до limit маршрутов, не изменявшихся с before,
+// начиная с самых старых, и возвращает их количество. Возврат из архива
+// обновляет updated_at, поэтому возвращенный маршрут снова попадает в
+// архив не раньше, чем через тот же срок.
+func (r *routeRepository) Archive(before time.Time, limit int) (int64, error) {
+	ids := r.db.Model(&model.Route{}).
+		Select("id").
+		Where("archived_at IS NULL AND updated_at < ?", before).
+		Order("updated_at ASC").
+		Limit(limit)
+	result := r.db.Model(&model.Route{}).
+		Where("id IN (?)", ids).
+		Update("archived_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to archive routes: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Unarchive возвращает маршрут из архива
+func (r *routeRepository) Unarchive(id string) error {
+	result := r.db.Model(&model.Route{}).
+		Where("id = ? AND archived_at IS NOT NULL", id).
+		Update("archived_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("failed to unarchive route: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: archived route with id %s", ErrRouteNotFound, id)
+	}
+	return nil
+}
+
+// Purge безвозвратно удаляет маршрут, в том числе ранее удаленный,
+// вместе с сегментами и метками
+func (r *routeRepository) Purge(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("route_id = ?", id).Delete(&model.Segment{}).Error; err != nil {
+			return fmt.Errorf("failed to purge segments: %w", err)
+		}
+		if err := tx.Exec("DELETE FROM route_tags WHERE route_id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to purge route tags: %w", err)
+		}
+		if err := tx.Where("route_id = ?", id).Delete(&model.ShareLink{}).Error; err != nil {
+			return fmt.Errorf("failed to purge share links: %w", err)
+		}
+		if err := tx.Where("route_id = ?", id).Delete(&model.ShadowAnalysis{}).Error; err != nil {
+			return fmt.Errorf("failed to purge shadow analyses: %w", err)
+		}
+
+		result := tx.Unscoped().Where("id = ?", id).Delete(&model.Route{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to purge route: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: id %s", ErrRouteNotFound, id)
+		}
+		return nil
+	})
+}
+
+// Split переносит сегменты маршрута route с номера atSegment в новый маршрут
+// part в одной транзакции. Сводная статистика route должна быть уже пересчитана.
+func (r *routeRepository) Split(route, part *model.Route, atSegment int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := createRoute(tx, part); err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().
+			Where("route_id = ? AND segment_id >= ?", route.ID, atSegment).
+			Delete(&model.Segment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete moved segments: %w", err)
+		}
+
+		route.UpdatedAt = time.Now()
+		result := tx.Model(&model.Route{ID: route.ID}).
+			Select("start_lat", "start_lon", "end_lat", "end_lon", "total_frames",
+				"total_distance_meters", "total_segments", "segments_with_data",
+				"average_coverage", "defects_count", "segments_with_defects",
+				"class_coverage", "average_confidence", "low_confidence_segments",
+				"quality_score", "matched_geometry", "updated_at").
+			Updates(route)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update route stats: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: id %s", ErrRouteNotFound, route.ID)
+		}
+		return nil
+	})
+}
+
+// UpdateMetadata сохраняет название, описание и пользовательские поля
+// маршрута, не затрагивая сегменты
+func (r *routeRepository) UpdateMetadata(route *model.Route) error {
+	route.UpdatedAt = time.Now()
+	result := r.db.Model(&model.Route{ID: route.ID}).
+		Select("name", "description", "custom_fields", "updated_at").
+		Updates(route)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update route metadata: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id %s", ErrRouteNotFound, route.ID)
+	}
+	return nil
+}
+
 // Update обновляет маршрут
 func (r *routeRepository) Update(route *model.Route) error {
 	tx := r.db.Begin()
@@ -170,8 +1146,8 @@ func (r *routeRepository) Update(route *model.Route) error {
 		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
 	}
 
-	// Обновляем маршрут
-	if err := tx.Save(route).Error; err != nil {
+	// Обновляем маршрут, сегменты пересоздаются ниже
+	if err := tx.Omit(clause.Associations).Save(route).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to update route: %w", err)
 	}
@@ -183,13 +1159,9 @@ func (r *routeRepository) Update(route *model.Route) error {
 	}
 
 	// Создаем новые сегменты
-	for i := range route.Segments {
-		route.Segments[i].ID = 0 // Обнуляем ID для auto-increment
-		route.Segments[i].RouteID = route.ID
-		if err := tx.Create(&route.Segments[i]).Error; err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to create segment %d: %w", i, err)
-		}
+	if err := createSegments(tx, route); err != nil {
+		tx.Rollback()
+		return err
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -198,3 +1170,36 @@ func (r *routeRepository) Update(route *model.Route) error {
 
 	return nil
 }
+
+// routeScope ограничивает запрос к таблице routes областью scope.
+// Пустая область запрос не меняет.
+func routeScope(scope RouteScope) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		cond, args := scopeCondition(scope)
+		if cond == "" {
+			return db
+		}
+		return db.Where(strings.TrimPrefix(cond, " AND "), args...)
+	}
+}
+
+// scopeCondition возвращает условие routeScope для запросов на чистом SQL
+func scopeCondition(scope RouteScope) (string, []interface{}) {
+	var cond string
+	var args []interface{}
+	if scope.OrganizationID != nil {
+		cond += " AND routes.organization_id = ?"
+		args = append(args, *scope.OrganizationID)
+	} else if scope.OwnerID != nil || scope.APIKeyID != nil {
+		cond += " AND routes.organization_id IS NULL"
+	}
+	if scope.OwnerID != nil {
+		cond += " AND routes.owner_id = ?"
+		args = append(args, *scope.OwnerID)
+	}
+	if scope.APIKeyID != nil {
+		cond += " AND routes.owner_id IS NULL AND routes.api_key_id = ?"
+		args = append(args, *scope.APIKeyID)
+	}
+	return cond, args
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"road-detector-go/internal/geo"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder логгер gorm, запоминающий сформированные запросы
+type sqlRecorder struct {
+	logger.Interface
+	queries []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	query, _ := fc()
+	r.queries = append(r.queries, query)
+}
+
+// newDryRunDB открывает gorm в режиме DryRun: запросы формируются, но не
+// выполняются, база данных не нужна
+func newDryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               recorder,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry run db: %v", err)
+	}
+	return db, recorder
+}
+
+func TestSpatialQueriesSkipArchivedRoutes(t *testing.T) {
+	polygon, err := geo.NewPolygon([][][]float64{{{37.6, 55.7}, {37.7, 55.7}, {37.7, 55.8}, {37.6, 55.7}}})
+	if err != nil {
+		t.Fatalf("failed to build polygon: %v", err)
+	}
+	point := Coordinates{Lat: 55.75, Lon: 37.65}
+
+	tests := []struct {
+		name  string
+		query func(repo RouteRepository) error
+	}{
+		{"GetNear", func(repo RouteRepository) error {
+			_, err := repo.GetNear(point, 100, 10, RouteScope{})
+			return err
+		}},
+		{"GetNearest", func(repo RouteRepository) error {
+			_, err := repo.GetNearest(point, RouteScope{})
+			return err
+		}},
+		{"GetByPolygon", func(repo RouteRepository) error {
+			_, err := repo.GetByPolygon(polygon, RouteScope{})
+			return err
+		}},
+	}
+
+	repos := []struct {
+		name string
+		new  func(db *gorm.DB) RouteRepository
+	}{
+		{"plain", func(db *gorm.DB) RouteRepository { return NewRouteRepository(db, nil) }},
+		{"postgis", func(db *gorm.DB) RouteRepository { return NewPostGISRouteRepository(db, nil) }},
+	}
+
+	for _, repo := range repos {
+		for _, tt := range tests {
+			t.Run(repo.name+"/"+tt.name, func(t *testing.T) {
+				db, recorder := newDryRunDB(t)
+				// Ошибка "маршрут не найден" ожидаема: в режиме DryRun строк нет
+				_ = tt.query(repo.new(db))
+				if len(recorder.queries) == 0 {
+					t.Fatal("no queries recorded")
+				}
+				if !strings.Contains(recorder.queries[0], "routes.archived_at IS NULL") {
+					t.Errorf("query does not skip archived routes:\n%s", recorder.queries[0])
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"fmt"
+
+	"road-detector-go/internal/model"
+)
+
+// StorageOwner владелец маршрутов для учета хранилища. Учитываются маршруты
+// организации, а без нее — личные маршруты пользователя или маршруты
+// ключа API без пользователя. Пустой владелец — анонимные маршруты.
+type StorageOwner struct {
+	OrganizationID *uint
+	OwnerID        *uint
+	APIKeyID       *uint
+}
+
+// StorageUsage место, занятое файлами маршрутов владельца
+type StorageUsage struct {
+	Routes int64
+	Bytes  int64
+	// Unmeasured маршруты, размер файлов которых еще не измерен
+	Unmeasured int64
+}
+
+// StorageUsage возвращает место, занятое маршрутами владельца. Удаленные
+// и архивные маршруты учитываются: их файлы хранятся до полного удаления.
+func (r *routeRepository) StorageUsage(owner StorageOwner) (StorageUsage, error) {
+	db := r.db.Unscoped().Model(&model.Route{})
+	switch {
+	case owner.OrganizationID != nil:
+		db = db.Where("organization_id = ?", *owner.OrganizationID)
+	case owner.OwnerID != nil:
+		db = db.Where("owner_id = ? AND organization_id IS NULL", *owner.OwnerID)
+	case owner.APIKeyID != nil:
+		db = db.Where("api_key_id = ? AND owner_id IS NULL AND organization_id IS NULL", *owner.APIKeyID)
+	default:
+		db = db.Where("api_key_id IS NULL AND owner_id IS NULL AND organization_id IS NULL")
+	}
+
+	var usage StorageUsage
+	err := db.Select("COUNT(*) AS routes, " +
+		"COALESCE(SUM(storage_bytes), 0) AS bytes, " +
+		"COUNT(*) - COUNT(storage_bytes) AS unmeasured").
+		Scan(&usage).Error
+	if err != nil {
+		return StorageUsage{}, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	return usage, nil
+}
+
+// ListUnmeasured возвращает до limit маршрутов, в том числе удаленных, для
+// которых размер файлов еще не измерен
+func (r *routeRepository) ListUnmeasured(limit int) ([]*model.Route, error) {
+	var routes []*model.Route
+	err := r.db.Unscoped().
+		Select("id", "video_filename", "video_path", "playable_video_path").
+		Where("storage_bytes IS NULL").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&routes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unmeasured routes: %w", err)
+	}
+	return routes, nil
+}
+
+// SetStorageBytes сохраняет размер файлов маршрута, в том числе удаленного
+func (r *routeRepository) SetStorageBytes(id string, bytes int64) error {
+	err := r.db.Unscoped().Model(&model.Route{}).
+		Where("id = ?", id).
+		UpdateColumn("storage_bytes", bytes).Error
+	if err != nil {
+		return fmt.Errorf("failed to set route storage bytes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"fmt"
+
+	"road-detector-go/internal/model"
+)
+
+// ListUntranscoded возвращает ID маршрутов с видео, которое еще не
+// проверено на воспроизведение в браузерах, в порядке создания
+func (r *routeRepository) ListUntranscoded() ([]string, error) {
+	var ids []string
+	err := r.db.Model(&model.Route{}).
+		Where("video_path <> '' AND COALESCE(playable_video_path, '') = ''").
+		Order("created_at ASC").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untranscoded routes: %w", err)
+	}
+	return ids, nil
+}
+
+// SetPlayableVideo сохраняет видео маршрута для браузеров и новый размер
+// файлов маршрута
+func (r *routeRepository) SetPlayableVideo(id, path string, storageBytes int64) error {
+	result := r.db.Unscoped().Model(&model.Route{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"playable_video_path": path,
+			"storage_bytes":       storageBytes,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to set playable video: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id %s", ErrRouteNotFound, id)
+	}
+	return nil
+}
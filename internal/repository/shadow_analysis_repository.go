@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"fmt"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ShadowAnalysisRepository интерфейс для работы с результатами теневого анализа
+type ShadowAnalysisRepository interface {
+	Create(analysis *model.ShadowAnalysis) error
+	ListForRoute(routeID string) ([]model.ShadowAnalysis, error)
+}
+
+// shadowAnalysisRepository реализация ShadowAnalysisRepository
+type shadowAnalysisRepository struct {
+	db *gorm.DB
+}
+
+// NewShadowAnalysisRepository создает новый instance ShadowAnalysisRepository
+func NewShadowAnalysisRepository(db *gorm.DB) ShadowAnalysisRepository {
+	return &shadowAnalysisRepository{
+		db: db,
+	}
+}
+
+// Create сохраняет результат теневого анализа
+func (r *shadowAnalysisRepository) Create(analysis *model.ShadowAnalysis) error {
+	if err := r.db.Create(analysis).Error; err != nil {
+		return fmt.Errorf("failed to create shadow analysis: %w", err)
+	}
+	return nil
+}
+
+// ListForRoute получает результаты теневого анализа маршрута в порядке создания
+func (r *shadowAnalysisRepository) ListForRoute(routeID string) ([]model.ShadowAnalysis, error) {
+	var analyses []model.ShadowAnalysis
+	if err := r.db.Where("route_id = ?", routeID).Order("id ASC").Find(&analyses).Error; err != nil {
+		return nil, fmt.Errorf("failed to list shadow analyses: %w", err)
+	}
+	return analyses, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrShareLinkNotFound возвращается, если ссылка отсутствует, отозвана
+// или срок ее действия истек
+var ErrShareLinkNotFound = errors.New("share link not found")
+
+// ShareLinkRepository интерфейс для работы с публичными ссылками на маршруты
+type ShareLinkRepository interface {
+	Create(link *model.ShareLink) error
+	ListForRoute(routeID string) ([]model.ShareLink, error)
+	GetActiveByHash(hash string, now time.Time) (*model.ShareLink, error)
+	Revoke(id uint, routeID string) (*model.ShareLink, error)
+}
+
+// shareLinkRepository реализация ShareLinkRepository
+type shareLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewShareLinkRepository создает новый instance ShareLinkRepository
+func NewShareLinkRepository(db *gorm.DB) ShareLinkRepository {
+	return &shareLinkRepository{
+		db: db,
+	}
+}
+
+// Create сохраняет ссылку
+func (r *shareLinkRepository) Create(link *model.ShareLink) error {
+	if err := r.db.Create(link).Error; err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+	return nil
+}
+
+// ListForRoute получает все ссылки маршрута, включая отозванные, в порядке создания
+func (r *shareLinkRepository) ListForRoute(routeID string) ([]model.ShareLink, error) {
+	var links []model.ShareLink
+	if err := r.db.Where("route_id = ?", routeID).Order("id ASC").Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	return links, nil
+}
+
+// GetActiveByHash получает действующую на момент now ссылку по хэшу токена
+func (r *shareLinkRepository) GetActiveByHash(hash string, now time.Time) (*model.ShareLink, error) {
+	var link model.ShareLink
+	err := r.db.
+		Where("token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", hash, now).
+		First(&link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	return &link, nil
+}
+
+// Revoke отзывает ссылку маршрута. Повторный отзыв возвращает ErrShareLinkNotFound.
+func (r *shareLinkRepository) Revoke(id uint, routeID string) (*model.ShareLink, error) {
+	result := r.db.Model(&model.ShareLink{}).
+		Where("id = ? AND route_id = ? AND revoked_at IS NULL", id, routeID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to revoke share link: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: id %d", ErrShareLinkNotFound, id)
+	}
+
+	var link model.ShareLink
+	if err := r.db.First(&link, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	return &link, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/model"
+	"road-detector-go/pkg/models"
+)
+
+const (
+	// earthRadiusMeters средний радиус Земли в метрах
+	earthRadiusMeters = 6371000.0
+	// metersPerDegreeLat приблизительная длина одного градуса широты
+	metersPerDegreeLat = 111320.0
+)
+
+// haversineSQL возвращает SQL выражение расстояния в метрах от точки (?, ?)
+// до координат в колонках latCol/lonCol. Аргументы: lat, lat, lon.
+func haversineSQL(latCol, lonCol string) string {
+	return fmt.Sprintf("%.1f * 2 * ASIN(SQRT(POWER(SIN(RADIANS(%s - ?) / 2), 2) + "+
+		"COS(RADIANS(?)) * COS(RADIANS(%s)) * POWER(SIN(RADIANS(%s - ?) / 2), 2)))",
+		earthRadiusMeters, latCol, latCol, lonCol)
+}
+
+// haversineArgs возвращает аргументы для выражения haversineSQL
+func haversineArgs(point Coordinates) []interface{} {
+	return []interface{}{point.Lat, point.Lat, point.Lon}
+}
+
+// segmentDistanceSQL возвращает выражение расстояния от точки до ближайшего
+// конца сегмента и аргументы к нему
+func segmentDistanceSQL(point Coordinates) (string, []interface{}) {
+	expr := fmt.Sprintf("LEAST(%s, %s)",
+		haversineSQL("segments.start_lat", "segments.start_lon"),
+		haversineSQL("segments.end_lat", "segments.end_lon"))
+	args := append(haversineArgs(point), haversineArgs(point)...)
+	return expr, args
+}
+
+// boundingBoxAround возвращает прямоугольник, гарантированно содержащий
+// окружность радиуса radiusM вокруг точки. Используется как префильтр по индексу.
+func boundingBoxAround(point Coordinates, radiusM float64) (northEast, southWest Coordinates) {
+	dLat := radiusM / metersPerDegreeLat
+	dLon := radiusM / (metersPerDegreeLat * math.Max(math.Cos(point.Lat*math.Pi/180), 0.01))
+
+	northEast = Coordinates{Lat: math.Min(point.Lat+dLat, 90), Lon: point.Lon + dLon}
+	southWest = Coordinates{Lat: math.Max(point.Lat-dLat, -90), Lon: point.Lon - dLon}
+	return northEast, southWest
+}
+
+// lonBetweenSQL возвращает условие попадания долготы в колонке col в диапазон
+// [west, east]. Диапазон, выходящий за ±180°, переносится через антимеридиан.
+func lonBetweenSQL(col string, west, east float64) (string, []interface{}) {
+	switch {
+	case east-west >= 360:
+		return "TRUE", nil
+	case west < -180:
+		return fmt.Sprintf("(%s >= ? OR %s <= ?)", col, col), []interface{}{west + 360, east}
+	case east > 180:
+		return fmt.Sprintf("(%s >= ? OR %s <= ?)", col, col), []interface{}{west, east - 360}
+	default:
+		return fmt.Sprintf("%s BETWEEN ? AND ?", col), []interface{}{west, east}
+	}
+}
+
+// pointInBoxSQL возвращает условие попадания точки (latCol, lonCol) в прямоугольник
+func pointInBoxSQL(latCol, lonCol string, northEast, southWest Coordinates) (string, []interface{}) {
+	lonCond, lonArgs := lonBetweenSQL(lonCol, southWest.Lon, northEast.Lon)
+	args := append([]interface{}{southWest.Lat, northEast.Lat}, lonArgs...)
+	return fmt.Sprintf("(%s BETWEEN ? AND ? AND %s)", latCol, lonCond), args
+}
+
+// routeSearchBox возвращает прямоугольник сегментов маршрута, расширенный
+// на distanceM метров. Для маршрута через антимеридиан northEast.Lon больше 180.
+func routeSearchBox(route *model.Route, distanceM float64) (northEast, southWest Coordinates) {
+	points := make([]models.Coordinates, 0, len(route.Segments)*2)
+	for _, seg := range route.Segments {
+		points = append(points,
+			models.Coordinates{Lat: seg.StartLat, Lon: seg.StartLon},
+			models.Coordinates{Lat: seg.EndLat, Lon: seg.EndLon})
+	}
+	box := geo.BoundingBoxOf(points)
+
+	northEast, _ = boundingBoxAround(Coordinates{Lat: box.NorthEast.Lat, Lon: box.NorthEast.Lon}, distanceM)
+	_, southWest = boundingBoxAround(Coordinates{Lat: box.SouthWest.Lat, Lon: box.SouthWest.Lon}, distanceM)
+	if box.CrossesAntimeridian() {
+		northEast.Lon += 360
+	}
+	return northEast, southWest
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTagNotFound возвращается, если метка с указанным ID отсутствует
+var ErrTagNotFound = errors.New("tag not found")
+
+// ErrTagExists возвращается, если метка с таким названием уже существует
+var ErrTagExists = errors.New("tag already exists")
+
+// TagRepository интерфейс для работы с метками маршрутов
+type TagRepository interface {
+	List(scope RouteScope) ([]TagCount, error)
+	Create(tag *model.Tag) error
+	Rename(id uint, name string) (*model.Tag, error)
+	Delete(id uint) error
+	SetRouteTags(routeID string, names []string) ([]model.Tag, error)
+}
+
+// TagCount метка с количеством маршрутов, к которым она привязана
+type TagCount struct {
+	model.Tag
+	RouteCount int64 `gorm:"column:route_count"`
+}
+
+// tagRepository реализация TagRepository
+type tagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository создает новый instance TagRepository
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{
+		db: db,
+	}
+}
+
+// List получает все метки по алфавиту с количеством неудаленных маршрутов
+// в области scope
+func (r *tagRepository) List(scope RouteScope) ([]TagCount, error) {
+	scopeCond, scopeArgs := scopeCondition(scope)
+
+	var tags []TagCount
+	err := r.db.Table("tags").
+		Select("tags.id, tags.name, tags.created_at, COUNT(routes.id) AS route_count").
+		Joins("LEFT JOIN route_tags ON route_tags.tag_id = tags.id").
+		Joins("LEFT JOIN routes ON routes.id = route_tags.route_id AND routes.deleted_at IS NULL"+scopeCond, scopeArgs...).
+		Group("tags.id").
+		Order("tags.name ASC").
+		Scan(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return tags, nil
+}
+
+// Create создает метку
+func (r *tagRepository) Create(tag *model.Tag) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureTagNameFree(tx, tag.Name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(tag).Error; err != nil {
+			return fmt.Errorf("failed to create tag: %w", err)
+		}
+		return nil
+	})
+}
+
+// Rename переименовывает метку
+func (r *tagRepository) Rename(id uint, name string) (*model.Tag, error) {
+	var tag model.Tag
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&tag, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: id %d", ErrTagNotFound, id)
+			}
+			return fmt.Errorf("failed to get tag: %w", err)
+		}
+		if err := ensureTagNameFree(tx, name, id); err != nil {
+			return err
+		}
+		tag.Name = name
+		if err := tx.Model(&tag).Update("name", name).Error; err != nil {
+			return fmt.Errorf("failed to rename tag: %w", err)
+		}
+		return touchRoutes(tx, tx.Table("route_tags").Select("route_id").Where("tag_id = ?", id))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Delete удаляет метку и ее привязки к маршрутам
+func (r *tagRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := touchRoutes(tx, tx.Table("route_tags").Select("route_id").Where("tag_id = ?", id)); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM route_tags WHERE tag_id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to delete tag links: %w", err)
+		}
+		result := tx.Delete(&model.Tag{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete tag: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: id %d", ErrTagNotFound, id)
+		}
+		return nil
+	})
+}
+
+// SetRouteTags заменяет метки маршрута, создавая недостающие
+func (r *tagRepository) SetRouteTags(routeID string, names []string) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Route{}).Where("id = ?", routeID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check route: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: id %s", ErrRouteNotFound, routeID)
+		}
+
+		var err error
+		tags, err = replaceRouteTags(tx, routeID, names)
+		if err != nil {
+			return err
+		}
+		return touchRoutes(tx, []string{routeID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// touchRoutes обновляет updated_at маршрутов, чтобы изменение меток
+// отразилось на ETag и Last-Modified. routeIDs - список ID или подзапрос.
+func touchRoutes(tx *gorm.DB, routeIDs interface{}) error {
+	if err := tx.Model(&model.Route{}).
+		Where("id IN (?)", routeIDs).
+		Update("updated_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to touch routes: %w", err)
+	}
+	return nil
+}
+
+// ensureTagNameFree проверяет, что название не занято другой меткой
+func ensureTagNameFree(tx *gorm.DB, name string, exceptID uint) error {
+	var count int64
+	if err := tx.Model(&model.Tag{}).Where("name = ? AND id <> ?", name, exceptID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check tag name: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrTagExists, name)
+	}
+	return nil
+}
+
+// replaceRouteTags в транзакции tx создает отсутствующие метки и заменяет
+// ими метки маршрута. Возвращает метки по алфавиту.
+func replaceRouteTags(tx *gorm.DB, routeID string, names []string) ([]model.Tag, error) {
+	if err := tx.Exec("DELETE FROM route_tags WHERE route_id = ?", routeID).Error; err != nil {
+		return nil, fmt.Errorf("failed to clear route tags: %w", err)
+	}
+	if len(names) == 0 {
+		return []model.Tag{}, nil
+	}
+
+	tags, err := ensureTags(tx, names)
+	if err != nil {
+		return nil, err
+	}
+	if err := linkRouteTags(tx, []string{routeID}, tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ensureTags создает отсутствующие метки и возвращает все метки с
+// указанными названиями по алфавиту
+func ensureTags(tx *gorm.DB, names []string) ([]model.Tag, error) {
+	newTags := make([]model.Tag, len(names))
+	for i, name := range names {
+		newTags[i] = model.Tag{Name: name}
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoNothing: true,
+	}).Create(&newTags).Error; err != nil {
+		return nil, fmt.Errorf("failed to create tags: %w", err)
+	}
+
+	var tags []model.Tag
+	if err := tx.Where("name IN ?", names).Order("name ASC").Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to load tags: %w", err)
+	}
+	return tags, nil
+}
+
+// linkRouteTags привязывает метки к маршрутам, пропуская существующие связи
+func linkRouteTags(tx *gorm.DB, routeIDs []string, tags []model.Tag) error {
+	links := make([]map[string]interface{}, 0, len(routeIDs)*len(tags))
+	for _, routeID := range routeIDs {
+		for _, tag := range tags {
+			links = append(links, map[string]interface{}{"route_id": routeID, "tag_id": tag.ID})
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	if err := tx.Table("route_tags").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(links).Error; err != nil {
+		return fmt.Errorf("failed to link route tags: %w", err)
+	}
+	return nil
+}
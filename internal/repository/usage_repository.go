@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UsageRepository интерфейс для работы со счетчиками использования
+type UsageRepository interface {
+	Get(subject, period string) (*model.UsageCounter, error)
+	Add(subject, period string, uploads int64, analysisSeconds float64) error
+}
+
+// usageRepository реализация UsageRepository
+type usageRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageRepository создает новый instance UsageRepository
+func NewUsageRepository(db *gorm.DB) UsageRepository {
+	return &usageRepository{
+		db: db,
+	}
+}
+
+// Get получает счетчик за период. Если использования не было, возвращает
+// нулевой счетчик.
+func (r *usageRepository) Get(subject, period string) (*model.UsageCounter, error) {
+	var counter model.UsageCounter
+	err := r.db.Where("subject = ? AND period = ?", subject, period).First(&counter).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &model.UsageCounter{Subject: subject, Period: period}, nil
+		}
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return &counter, nil
+}
+
+// Add увеличивает счетчик за период одним запросом, чтобы параллельные
+// анализы не теряли обновления
+func (r *usageRepository) Add(subject, period string, uploads int64, analysisSeconds float64) error {
+	counter := &model.UsageCounter{
+		Subject:         subject,
+		Period:          period,
+		Uploads:         uploads,
+		AnalysisSeconds: analysisSeconds,
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "subject"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"uploads":          gorm.Expr("usage_counters.uploads + ?", uploads),
+			"analysis_seconds": gorm.Expr("usage_counters.analysis_seconds + ?", analysisSeconds),
+			"updated_at":       gorm.Expr("CURRENT_TIMESTAMP"),
+		}),
+	}).Create(counter).Error
+	if err != nil {
+		return fmt.Errorf("failed to update usage: %w", err)
+	}
+	return nil
+}
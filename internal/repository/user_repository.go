@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrUserNotFound возвращается, если пользователь не найден
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrUserExists возвращается при регистрации занятого email
+var ErrUserExists = errors.New("user already exists")
+
+// UserRepository интерфейс для работы с пользователями
+type UserRepository interface {
+	Create(user *model.User) error
+	GetByID(id uint) (*model.User, error)
+	GetByEmail(email string) (*model.User, error)
+	GetByExternalID(issuer, subject string) (*model.User, error)
+	LinkExternal(id uint, issuer, subject string) error
+	SetRole(id uint, role string) error
+}
+
+// userRepository реализация UserRepository
+type userRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository создает новый instance UserRepository
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{
+		db: db,
+	}
+}
+
+// Create сохраняет пользователя. Email должен быть уже приведен к нижнему регистру.
+func (r *userRepository) Create(user *model.User) error {
+	var count int64
+	if err := r.db.Model(&model.User{}).Where("email = ?", user.Email).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check user: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrUserExists, user.Email)
+	}
+
+	if err := r.db.Create(user).Error; err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// GetByID получает пользователя по ID
+func (r *userRepository) GetByID(id uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %d", ErrUserNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// GetByEmail получает пользователя по email
+func (r *userRepository) GetByEmail(email string) (*model.User, error) {
+	var user model.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// GetByExternalID получает пользователя OIDC провайдера по издателю и sub
+func (r *userRepository) GetByExternalID(issuer, subject string) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("external_issuer = ? AND external_subject = ?", issuer, subject).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// LinkExternal привязывает существующего пользователя к учетной записи OIDC провайдера
+func (r *userRepository) LinkExternal(id uint, issuer, subject string) error {
+	result := r.db.Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"external_issuer":  issuer,
+		"external_subject": subject,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to link user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id %d", ErrUserNotFound, id)
+	}
+	return nil
+}
+
+// SetRole изменяет роль пользователя
+func (r *userRepository) SetRole(id uint, role string) error {
+	result := r.db.Model(&model.User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user role: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id %d", ErrUserNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"road-detector-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrWebhookNotFound возвращается, если подписка отсутствует или принадлежит
+// другой организации
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// WebhookRepository интерфейс для работы с вебхуками и их доставками.
+// Подписки выбираются в пределах организации, nil — подписки без организации.
+type WebhookRepository interface {
+	Create(webhook *model.Webhook) error
+	List(orgID *uint) ([]model.Webhook, error)
+	GetByID(id uint, orgID *uint) (*model.Webhook, error)
+	Update(webhook *model.Webhook) error
+	Delete(id uint, orgID *uint) error
+	ListActive(orgID *uint) ([]model.Webhook, error)
+	CreateDelivery(delivery *model.WebhookDelivery) error
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	ListDeliveries(webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	ListPendingDeliveries() ([]PendingDelivery, error)
+}
+
+// PendingDelivery доставка в статусе pending и ее подписка
+type PendingDelivery struct {
+	Delivery model.WebhookDelivery
+	Webhook  model.Webhook
+}
+
+// webhookRepository реализация WebhookRepository
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository создает новый instance WebhookRepository
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+// organizationScope ограничивает запрос записями организации или записями
+// без организации
+func organizationScope(db *gorm.DB, orgID *uint) *gorm.DB {
+	if orgID == nil {
+		return db.Where("organization_id IS NULL")
+	}
+	return db.Where("organization_id = ?", *orgID)
+}
+
+// Create сохраняет подписку
+func (r *webhookRepository) Create(webhook *model.Webhook) error {
+	if err := r.db.Create(webhook).Error; err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+// List получает подписки организации в порядке создания
+func (r *webhookRepository) List(orgID *uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := organizationScope(r.db, orgID).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// GetByID получает подписку организации по ID
+func (r *webhookRepository) GetByID(id uint, orgID *uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := organizationScope(r.db, orgID).Where("id = ?", id).First(&webhook).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %d", ErrWebhookNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+// Update сохраняет изменения подписки
+func (r *webhookRepository) Update(webhook *model.Webhook) error {
+	if err := r.db.Save(webhook).Error; err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return nil
+}
+
+// Delete удаляет подписку организации вместе с журналом ее доставок
+func (r *webhookRepository) Delete(id uint, orgID *uint) error {
+	result := organizationScope(r.db, orgID).Where("id = ?", id).Delete(&model.Webhook{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id %d", ErrWebhookNotFound, id)
+	}
+	if err := r.db.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ListActive получает включенные подписки, которым отправляются события
+// маршрута организации orgID: подписки этой организации и подписки без организации
+func (r *webhookRepository) ListActive(orgID *uint) ([]model.Webhook, error) {
+	db := r.db.Where("active = ?", true)
+	if orgID == nil {
+		db = db.Where("organization_id IS NULL")
+	} else {
+		db = db.Where("organization_id IS NULL OR organization_id = ?", *orgID)
+	}
+
+	var webhooks []model.Webhook
+	if err := db.Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list active webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// CreateDelivery сохраняет доставку события
+func (r *webhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	if err := r.db.Create(delivery).Error; err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+func (r *webhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	if err := r.db.Save(delivery).Error; err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries получает последние доставки подписки
+func (r *webhookRepository) ListDeliveries(webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.db.Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// ListPendingDeliveries получает доставки в статусе pending вместе с подписками
+func (r *webhookRepository) ListPendingDeliveries() ([]PendingDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.db.Where("status = ?", model.WebhookDeliveryPending).
+		Order("id ASC").
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending webhook deliveries: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.WebhookID)
+	}
+	var webhooks []model.Webhook
+	if err := r.db.Where("id IN ?", ids).Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhooks of pending deliveries: %w", err)
+	}
+	byID := make(map[uint]model.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+
+	pending := make([]PendingDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		// Доставки удаленной подписки удаляются вместе с ней
+		webhook, ok := byID[delivery.WebhookID]
+		if !ok {
+			continue
+		}
+		pending = append(pending, PendingDelivery{Delivery: delivery, Webhook: webhook})
+	}
+	return pending, nil
+}
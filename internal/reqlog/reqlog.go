@@ -0,0 +1,134 @@
+// Package reqlog пишет журнал HTTP запросов и добавляет ID запроса
+// (заголовок X-Request-ID) во все записи лога, сделанные при его обработке.
+//
+// ID запроса сохраняется в контексте http.Request и привязывается к горутине,
+// обрабатывающей запрос. Hook добавляет поле request_id в записи logrus с этим
+// контекстом (logger.WithContext) и в записи из привязанной горутины, поэтому
+// сервисы получают ID без передачи контекста в каждый метод. Горутины,
+// запущенные обработчиком, ID не наследуют: для них контекст передается явно.
+package reqlog
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// FieldRequestID поле записи лога с ID запроса
+const FieldRequestID = "request_id"
+
+// contextKey тип ключей контекста пакета
+type contextKey struct{}
+
+// bound ID запросов, обрабатываемых горутинами, по ID горутины
+var bound sync.Map
+
+// WithRequestID возвращает контекст с ID запроса
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// RequestIDFromContext возвращает ID запроса из контекста
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
+
+// CurrentRequestID возвращает ID запроса, который обрабатывает текущая горутина
+func CurrentRequestID() string {
+	if requestID, ok := bound.Load(goroutineID()); ok {
+		return requestID.(string)
+	}
+	return ""
+}
+
+// Middleware присваивает запросу ID, привязывает его к контексту и горутине
+// запроса и после ответа записывает строку журнала: метод, путь, статус,
+// длительность, размер ответа, IP клиента и ID запроса. Подключается первым,
+// чтобы учитывать ответы с ошибками и панику обработчиков.
+func Middleware(logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := apierror.AssignRequestID(c)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), requestID))
+
+		gid := goroutineID()
+		bound.Store(gid, requestID)
+		defer bound.Delete(gid)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		entry := logger.WithFields(logrus.Fields{
+			FieldRequestID: requestID,
+			"method":       c.Request.Method,
+			"path":         c.Request.URL.Path,
+			"route":        c.FullPath(),
+			"status":       status,
+			"latency_ms":   float64(time.Since(start).Microseconds()) / 1000,
+			"bytes":        c.Writer.Size(),
+			"client_ip":    c.ClientIP(),
+			"user_agent":   c.Request.UserAgent(),
+		})
+		switch {
+		case status >= 500:
+			entry.Error("HTTP запрос")
+		case status >= 400:
+			entry.Warn("HTTP запрос")
+		default:
+			entry.Info("HTTP запрос")
+		}
+	}
+}
+
+// Hook добавляет поле request_id в записи лога, сделанные при обработке запроса
+type Hook struct{}
+
+// NewHook создает Hook
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+// Levels уровни записей, которые обрабатывает Hook
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire добавляет ID запроса из контекста записи или текущей горутины
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[FieldRequestID]; ok {
+		return nil
+	}
+	requestID := RequestIDFromContext(entry.Context)
+	if requestID == "" {
+		requestID = CurrentRequestID()
+	}
+	if requestID != "" {
+		entry.Data[FieldRequestID] = requestID
+	}
+	return nil
+}
+
+// goroutineID номер текущей горутины из заголовка ее стека
+// ("goroutine 42 [running]:")
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	field := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(field, ' '); i >= 0 {
+		field = field[:i]
+	}
+	id, _ := strconv.ParseUint(string(field), 10, 64)
+	return id
+}
@@ -0,0 +1,157 @@
+// Package selftest содержит встроенные данные для самопроверки сервиса
+package selftest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+const (
+	sampleWidth  = 160
+	sampleHeight = 120
+	sampleFrames = 10
+	sampleFPS    = 5
+)
+
+// SampleVideoFilename имя встроенного тестового видео
+const SampleVideoFilename = "selftest.avi"
+
+// SampleVideo возвращает крошечное видео MJPEG/AVI: серая дорога с белой
+// прерывистой разметкой посередине. Генерируется на лету, чтобы не хранить
+// бинарный файл в репозитории.
+func SampleVideo() ([]byte, error) {
+	frames := make([][]byte, 0, sampleFrames)
+	for i := 0; i < sampleFrames; i++ {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, sampleFrame(i), &jpeg.Options{Quality: 75}); err != nil {
+			return nil, fmt.Errorf("failed to encode sample frame: %w", err)
+		}
+		frames = append(frames, buf.Bytes())
+	}
+	return buildAVI(frames, sampleWidth, sampleHeight, sampleFPS), nil
+}
+
+// sampleFrame рисует кадр с разметкой, сдвинутой на номер кадра
+func sampleFrame(index int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, sampleWidth, sampleHeight))
+	asphalt := color.RGBA{R: 70, G: 70, B: 70, A: 255}
+	marking := color.RGBA{R: 240, G: 240, B: 240, A: 255}
+
+	for y := 0; y < sampleHeight; y++ {
+		for x := 0; x < sampleWidth; x++ {
+			img.Set(x, y, asphalt)
+		}
+	}
+
+	// Прерывистая линия, движущаяся вниз от кадра к кадру
+	for y := 0; y < sampleHeight; y++ {
+		if ((y+index*6)/15)%2 == 0 {
+			for x := sampleWidth/2 - 3; x < sampleWidth/2+3; x++ {
+				img.Set(x, y, marking)
+			}
+		}
+	}
+	return img
+}
+
+// buildAVI собирает RIFF AVI контейнер с одним MJPEG потоком
+func buildAVI(frames [][]byte, width, height, fps int) []byte {
+	maxFrame := 0
+	for _, frame := range frames {
+		if len(frame) > maxFrame {
+			maxFrame = len(frame)
+		}
+	}
+
+	avih := new(bytes.Buffer)
+	write(avih,
+		uint32(1000000/fps),  // dwMicroSecPerFrame
+		uint32(maxFrame*fps), // dwMaxBytesPerSec
+		uint32(0),            // dwPaddingGranularity
+		uint32(0x10),         // dwFlags: AVIF_HASINDEX
+		uint32(len(frames)),  // dwTotalFrames
+		uint32(0),            // dwInitialFrames
+		uint32(1),            // dwStreams
+		uint32(maxFrame),     // dwSuggestedBufferSize
+		uint32(width),        // dwWidth
+		uint32(height),       // dwHeight
+		[4]uint32{},          // dwReserved
+	)
+
+	strh := new(bytes.Buffer)
+	strh.WriteString("vidsMJPG")
+	write(strh,
+		uint32(0),           // dwFlags
+		uint16(0),           // wPriority
+		uint16(0),           // wLanguage
+		uint32(0),           // dwInitialFrames
+		uint32(1),           // dwScale
+		uint32(fps),         // dwRate
+		uint32(0),           // dwStart
+		uint32(len(frames)), // dwLength
+		uint32(maxFrame),    // dwSuggestedBufferSize
+		uint32(0xFFFFFFFF),  // dwQuality
+		uint32(0),           // dwSampleSize
+		[4]int16{0, 0, int16(width), int16(height)}, // rcFrame
+	)
+
+	strf := new(bytes.Buffer)
+	write(strf, uint32(40), int32(width), int32(height), uint16(1), uint16(24))
+	strf.WriteString("MJPG")
+	write(strf, uint32(width*height*3), int32(0), int32(0), uint32(0), uint32(0))
+
+	strl := list("strl", chunk("strh", strh.Bytes()), chunk("strf", strf.Bytes()))
+	hdrl := list("hdrl", chunk("avih", avih.Bytes()), strl)
+
+	movi := new(bytes.Buffer)
+	idx := new(bytes.Buffer)
+	offset := uint32(4) // смещения в idx1 отсчитываются от fourcc 'movi'
+	for _, frame := range frames {
+		c := chunk("00dc", frame)
+		idx.WriteString("00dc")
+		write(idx, uint32(0x10), offset, uint32(len(frame))) // AVIIF_KEYFRAME
+		movi.Write(c)
+		offset += uint32(len(c))
+	}
+
+	body := new(bytes.Buffer)
+	body.WriteString("AVI ")
+	body.Write(hdrl)
+	body.Write(list("movi", movi.Bytes()))
+	body.Write(chunk("idx1", idx.Bytes()))
+
+	return chunk("RIFF", body.Bytes())
+}
+
+// chunk формирует RIFF chunk с выравниванием до четного размера
+func chunk(id string, data []byte) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString(id)
+	write(buf, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+// list формирует RIFF LIST из вложенных chunk
+func list(listType string, children ...[]byte) []byte {
+	data := []byte(listType)
+	for _, child := range children {
+		data = append(data, child...)
+	}
+	return chunk("LIST", data)
+}
+
+// write записывает значения в little-endian
+func write(buf *bytes.Buffer, values ...interface{}) {
+	for _, v := range values {
+		// Запись в bytes.Buffer не может завершиться ошибкой
+		_ = binary.Write(buf, binary.LittleEndian, v)
+	}
+}
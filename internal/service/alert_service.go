@@ -0,0 +1,516 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/notify"
+	"road-detector-go/internal/repository"
+	"road-detector-go/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidAlertRule возвращается при некорректных данных правила оповещения
+var ErrInvalidAlertRule = errors.New("invalid alert rule")
+
+const (
+	maxAlertRuleNameLength = 255
+	// maxAlertRecipients ограничение числа получателей каждого канала правила
+	maxAlertRecipients = 20
+	// maxAlertMessageSegments сколько сегментов перечисляется в письме и сообщении
+	maxAlertMessageSegments = 20
+)
+
+// AlertService управляет правилами оповещений и проверяет по ним результаты
+// анализа. Оповещение сохраняется вместе с событием alert.created для
+// вебхуков, письма и сообщения Telegram отправляются в фоне без повторов.
+type AlertService struct {
+	alertRepo repository.AlertRepository
+	outbox    *OutboxService
+	notifier  *notify.Notifier
+	// notifications адреса пользователей, подписанных на оповещения, nil —
+	// оповещения получают только адресаты правил
+	notifications *NotificationService
+	logger        *logrus.Logger
+
+	// inflight считает фоновые отправки оповещений
+	inflight sync.WaitGroup
+}
+
+// NewAlertService создает новый сервис оповещений
+func NewAlertService(alertRepo repository.AlertRepository, logger *logrus.Logger) *AlertService {
+	return &AlertService{
+		alertRepo: alertRepo,
+		logger:    logger,
+	}
+}
+
+// SetEventOutbox включает публикацию оповещений вебхукам
+func (s *AlertService) SetEventOutbox(outbox *OutboxService) {
+	s.outbox = outbox
+}
+
+// SetNotifier включает отправку оповещений по почте и в Telegram
+func (s *AlertService) SetNotifier(notifier *notify.Notifier) {
+	s.notifier = notifier
+}
+
+// SetNotificationService включает отправку оповещений пользователям,
+// подписанным на них в настройках уведомлений
+func (s *AlertService) SetNotificationService(notifications *NotificationService) {
+	s.notifications = notifications
+}
+
+// CreateRule создает правило организации orgID (nil — правило для всех маршрутов)
+func (s *AlertService) CreateRule(orgID *uint, req CreateAlertRuleRequest) (*AlertRuleInfo, error) {
+	rule := &model.AlertRule{
+		OrganizationID: orgID,
+		Active:         req.Active == nil || *req.Active,
+	}
+	update := UpdateAlertRuleRequest{
+		Name:              &req.Name,
+		Area:              req.Area,
+		MaxCoverage:       req.MaxCoverage,
+		MinDefectSeverity: &req.MinDefectSeverity,
+		Emails:            req.Emails,
+		TelegramChats:     req.TelegramChats,
+	}
+	if err := s.applyRuleRequest(rule, update); err != nil {
+		return nil, err
+	}
+	if err := s.alertRepo.CreateRule(rule); err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+
+	s.logger.Infof("Создано правило оповещений %d %q, организация: %s", rule.ID, rule.Name, formatOrganizationID(orgID))
+	info := alertRuleInfo(rule)
+	return &info, nil
+}
+
+// ListRules возвращает правила организации
+func (s *AlertService) ListRules(orgID *uint) ([]AlertRuleInfo, error) {
+	rules, err := s.alertRepo.ListRules(orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+
+	result := make([]AlertRuleInfo, len(rules))
+	for i := range rules {
+		result[i] = alertRuleInfo(&rules[i])
+	}
+	return result, nil
+}
+
+// GetRule возвращает правило организации
+func (s *AlertService) GetRule(id uint, orgID *uint) (*AlertRuleInfo, error) {
+	rule, err := s.alertRepo.GetRule(id, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	info := alertRuleInfo(rule)
+	return &info, nil
+}
+
+// UpdateRule меняет условия, область, получателей или признак активности правила
+func (s *AlertService) UpdateRule(id uint, orgID *uint, req UpdateAlertRuleRequest) (*AlertRuleInfo, error) {
+	rule, err := s.alertRepo.GetRule(id, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	if err := s.applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if req.Active != nil {
+		rule.Active = *req.Active
+	}
+	if err := s.alertRepo.UpdateRule(rule); err != nil {
+		return nil, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+
+	s.logger.Infof("Правило оповещений %d изменено, активно: %t", rule.ID, rule.Active)
+	info := alertRuleInfo(rule)
+	return &info, nil
+}
+
+// DeleteRule удаляет правило организации
+func (s *AlertService) DeleteRule(id uint, orgID *uint) error {
+	if err := s.alertRepo.DeleteRule(id, orgID); err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	s.logger.Infof("Правило оповещений %d удалено", id)
+	return nil
+}
+
+// applyRuleRequest проверяет заданные в запросе поля и переносит их в правило
+func (s *AlertService) applyRuleRequest(rule *model.AlertRule, req UpdateAlertRuleRequest) error {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > maxAlertRuleNameLength {
+			return fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidAlertRule, maxAlertRuleNameLength)
+		}
+		rule.Name = name
+	}
+	if len(req.Area) > 0 {
+		if string(req.Area) == "null" {
+			rule.Area = ""
+		} else {
+			polygon, err := geo.ParseGeoJSONPolygon(req.Area)
+			if err != nil {
+				return fmt.Errorf("%w: area: %v", ErrInvalidAlertRule, err)
+			}
+			area, err := polygon.GeoJSON()
+			if err != nil {
+				return fmt.Errorf("failed to encode alert rule area: %w", err)
+			}
+			rule.Area = string(area)
+		}
+	}
+	if req.MaxCoverage != nil {
+		if *req.MaxCoverage < 0 || *req.MaxCoverage > 100 {
+			return fmt.Errorf("%w: max_coverage must be between 0 and 100", ErrInvalidAlertRule)
+		}
+		rule.MaxCoverage = req.MaxCoverage
+		if *req.MaxCoverage == 0 {
+			rule.MaxCoverage = nil
+		}
+	}
+	if req.MinDefectSeverity != nil {
+		severity := strings.ToLower(strings.TrimSpace(*req.MinDefectSeverity))
+		if severity != "" && model.DefectSeverityRank(severity) == 0 {
+			return fmt.Errorf("%w: min_defect_severity must be low, medium or high", ErrInvalidAlertRule)
+		}
+		rule.MinDefectSeverity = severity
+	}
+	if rule.MaxCoverage == nil && rule.MinDefectSeverity == "" {
+		return fmt.Errorf("%w: set max_coverage or min_defect_severity", ErrInvalidAlertRule)
+	}
+
+	if req.Emails != nil {
+		emails, err := normalizeAlertRecipients(req.Emails, "emails", ErrInvalidAlertRule, func(email string) bool {
+			address, err := mail.ParseAddress(email)
+			return err == nil && address.Address == email
+		})
+		if err != nil {
+			return err
+		}
+		if len(emails) > 0 && !s.notifier.EmailEnabled() {
+			return fmt.Errorf("%w: email notifications are not configured", ErrInvalidAlertRule)
+		}
+		rule.Emails = strings.Join(emails, ",")
+	}
+	if req.TelegramChats != nil {
+		chats, err := normalizeAlertRecipients(req.TelegramChats, "telegram_chats", ErrInvalidAlertRule, func(chat string) bool {
+			return !strings.ContainsAny(chat, ", \t")
+		})
+		if err != nil {
+			return err
+		}
+		if len(chats) > 0 && !s.notifier.TelegramEnabled() {
+			return fmt.Errorf("%w: telegram notifications are not configured", ErrInvalidAlertRule)
+		}
+		rule.TelegramChats = strings.Join(chats, ",")
+	}
+	return nil
+}
+
+// normalizeAlertRecipients проверяет получателей канала и убирает повторы.
+// Ошибки оборачивают invalid.
+func normalizeAlertRecipients(recipients []string, field string, invalid error, valid func(string) bool) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, recipient := range recipients {
+		recipient = strings.TrimSpace(recipient)
+		if recipient == "" || !valid(recipient) {
+			return nil, fmt.Errorf("%w: invalid %s entry %q", invalid, field, recipient)
+		}
+		if !seen[recipient] {
+			seen[recipient] = true
+			result = append(result, recipient)
+		}
+	}
+	if len(result) > maxAlertRecipients {
+		return nil, fmt.Errorf("%w: %s must have at most %d entries", invalid, field, maxAlertRecipients)
+	}
+	return result, nil
+}
+
+// ListAlerts возвращает страницу оповещений организации
+func (s *AlertService) ListAlerts(orgID *uint, query repository.AlertQuery) (*ListAlertsResponse, error) {
+	alerts, total, err := s.alertRepo.ListAlerts(orgID, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	return &ListAlertsResponse{Alerts: alerts, Total: total, Page: query.Page, Size: query.PageSize}, nil
+}
+
+// GetAlert возвращает оповещение организации
+func (s *AlertService) GetAlert(id uint, orgID *uint) (*model.Alert, error) {
+	alert, err := s.alertRepo.GetAlert(id, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+	return alert, nil
+}
+
+// AcknowledgeAlert отмечает оповещение подтвержденным. by — кто подтвердил.
+// Повторное подтверждение ничего не меняет.
+func (s *AlertService) AcknowledgeAlert(id uint, orgID *uint, by string) (*model.Alert, error) {
+	alert, err := s.alertRepo.GetAlert(id, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+	if alert.Status == model.AlertStatusAcknowledged {
+		return alert, nil
+	}
+
+	now := time.Now()
+	alert.Status = model.AlertStatusAcknowledged
+	alert.AcknowledgedAt = &now
+	alert.AcknowledgedBy = by
+	if err := s.alertRepo.UpdateAlert(alert); err != nil {
+		return nil, fmt.Errorf("failed to acknowledge alert: %w", err)
+	}
+	s.logger.Infof("Оповещение %d подтверждено: %s", alert.ID, by)
+	return alert, nil
+}
+
+// DeleteAlert удаляет оповещение организации
+func (s *AlertService) DeleteAlert(id uint, orgID *uint) error {
+	if err := s.alertRepo.DeleteAlert(id, orgID); err != nil {
+		return fmt.Errorf("failed to delete alert: %w", err)
+	}
+	s.logger.Infof("Оповещение %d удалено", id)
+	return nil
+}
+
+// Evaluate проверяет результат анализа маршрута организации orgID по
+// включенным правилам и создает оповещение для каждого нарушенного правила.
+// Ошибки записываются в лог и не влияют на результат анализа.
+func (s *AlertService) Evaluate(routeID string, orgID *uint, result *AnalysisResult) {
+	rules, err := s.alertRepo.ListActiveRules(orgID)
+	if err != nil {
+		s.logger.Errorf("Не удалось получить правила оповещений для маршрута %s: %v", routeID, err)
+		return
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		segments, err := matchAlertRule(rule, result.Segments)
+		if err != nil {
+			s.logger.Errorf("Правило оповещений %d не проверено: %v", rule.ID, err)
+			continue
+		}
+		if len(segments) == 0 {
+			continue
+		}
+
+		alert := &model.Alert{
+			RuleID:         rule.ID,
+			OrganizationID: rule.OrganizationID,
+			RouteID:        routeID,
+			RuleName:       rule.Name,
+			Segments:       segments,
+			SegmentsCount:  len(segments),
+			Status:         model.AlertStatusOpen,
+		}
+		if err := s.createAlert(alert); err != nil {
+			s.logger.Errorf("Не удалось сохранить оповещение по правилу %d для маршрута %s: %v", rule.ID, routeID, err)
+			continue
+		}
+		s.logger.Warnf("Оповещение %d по правилу %q: маршрут %s, сегментов: %d", alert.ID, rule.Name, routeID, len(segments))
+		s.notify(rule, alert)
+	}
+}
+
+// createAlert сохраняет оповещение вместе с событием для вебхуков
+func (s *AlertService) createAlert(alert *model.Alert) error {
+	if s.outbox == nil {
+		return s.alertRepo.CreateAlert(alert, nil)
+	}
+
+	err := s.alertRepo.CreateAlert(alert, func(alert *model.Alert) ([]*model.OutboxEvent, error) {
+		event, err := s.outbox.NewEvent(model.WebhookEventAlertCreated, alert.OrganizationID, AlertEventData{
+			AlertID:        alert.ID,
+			RuleID:         alert.RuleID,
+			RuleName:       alert.RuleName,
+			RouteID:        alert.RouteID,
+			OrganizationID: alert.OrganizationID,
+			SegmentsCount:  alert.SegmentsCount,
+			Segments:       alert.Segments,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []*model.OutboxEvent{event}, nil
+	})
+	if err != nil {
+		return err
+	}
+	s.outbox.Notify()
+	return nil
+}
+
+// matchAlertRule возвращает сегменты с данными в области правила, которые
+// нарушают хотя бы одно из его условий. Дефект без степени считается
+// дефектом степени low.
+func matchAlertRule(rule *model.AlertRule, segments []SegmentInfo) ([]model.AlertSegment, error) {
+	var area *geo.Polygon
+	if rule.Area != "" {
+		polygon, err := geo.ParseGeoJSONPolygon([]byte(rule.Area))
+		if err != nil {
+			return nil, fmt.Errorf("invalid area: %w", err)
+		}
+		area = &polygon
+	}
+	minSeverity := model.DefectSeverityRank(rule.MinDefectSeverity)
+
+	var matched []model.AlertSegment
+	for _, seg := range segments {
+		if !seg.HasData {
+			continue
+		}
+		if area != nil && !area.IntersectsSegment(
+			models.Coordinates{Lat: seg.StartCoordinate.Lat, Lon: seg.StartCoordinate.Lon},
+			models.Coordinates{Lat: seg.EndCoordinate.Lat, Lon: seg.EndCoordinate.Lon}) {
+			continue
+		}
+
+		severity, rank := "", 0
+		for _, defect := range seg.Defects {
+			defectSeverity := defect.Severity
+			if model.DefectSeverityRank(defectSeverity) == 0 {
+				defectSeverity = model.DefectSeverityLow
+			}
+			if r := model.DefectSeverityRank(defectSeverity); r > rank {
+				severity, rank = defectSeverity, r
+			}
+		}
+		lowCoverage := rule.MaxCoverage != nil && seg.CoveragePercentage < *rule.MaxCoverage
+		severeDefects := minSeverity > 0 && rank >= minSeverity
+		if lowCoverage || severeDefects {
+			matched = append(matched, model.AlertSegment{
+				SegmentID:          seg.SegmentID,
+				CoveragePercentage: seg.CoveragePercentage,
+				DefectSeverity:     severity,
+			})
+		}
+	}
+	return matched, nil
+}
+
+// notify отправляет оповещение в фоне получателям правила и пользователям
+// организации, подписанным на оповещения
+func (s *AlertService) notify(rule *model.AlertRule, alert *model.Alert) {
+	emails := splitRecipients(rule.Emails)
+	chats := splitRecipients(rule.TelegramChats)
+	if len(emails) == 0 && len(chats) == 0 && s.notifications == nil {
+		return
+	}
+
+	message := alertMessage(alert.RuleName, alert.RouteID, alert.Segments, s.notifier.RouteURL(alert.RouteID))
+	orgID := alert.OrganizationID
+
+	s.inflight.Add(1)
+	go func() {
+		defer s.inflight.Done()
+		emails = mergeRecipients(emails, s.notifications.Subscribers(model.NotificationAlerts, orgID))
+		if len(emails) == 0 && len(chats) == 0 {
+			return
+		}
+		subject, text, err := s.notifier.Render(notify.TemplateAlert, message)
+		if err != nil {
+			s.logger.Errorf("Оповещение %d не отправлено: %v", alert.ID, err)
+			return
+		}
+		if len(emails) > 0 {
+			if err := s.notifier.SendEmail(emails, subject, text); err != nil {
+				s.logger.Errorf("Оповещение %d не отправлено по почте: %v", alert.ID, err)
+			}
+		}
+		for _, chat := range chats {
+			if err := s.notifier.SendTelegram(chat, subject+"\n\n"+text); err != nil {
+				s.logger.Errorf("Оповещение %d не отправлено в чат Telegram %s: %v", alert.ID, chat, err)
+			}
+		}
+	}()
+}
+
+// Shutdown ждет завершения фоновых отправок оповещений, но не дольше
+// timeout. Возвращает false, если отправки не успели завершиться.
+func (s *AlertService) Shutdown(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// alertMessage данные шаблона оповещения с первыми сегментами
+func alertMessage(ruleName, routeID string, segments []model.AlertSegment, url string) notify.AlertMessage {
+	message := notify.AlertMessage{
+		RuleName:      ruleName,
+		RouteID:       routeID,
+		URL:           url,
+		SegmentsCount: len(segments),
+	}
+	for i, seg := range segments {
+		if i == maxAlertMessageSegments {
+			message.More = len(segments) - i
+			break
+		}
+		message.Segments = append(message.Segments, notify.AlertSegment{
+			SegmentID:          seg.SegmentID,
+			CoveragePercentage: seg.CoveragePercentage,
+			DefectSeverity:     seg.DefectSeverity,
+		})
+	}
+	return message
+}
+
+// splitRecipients разбирает получателей, сохраненных через запятую
+func splitRecipients(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// alertRuleInfo преобразует правило в ответ API
+func alertRuleInfo(rule *model.AlertRule) AlertRuleInfo {
+	info := AlertRuleInfo{
+		ID:                rule.ID,
+		OrganizationID:    rule.OrganizationID,
+		Name:              rule.Name,
+		MaxCoverage:       rule.MaxCoverage,
+		MinDefectSeverity: rule.MinDefectSeverity,
+		Emails:            splitRecipients(rule.Emails),
+		TelegramChats:     splitRecipients(rule.TelegramChats),
+		Active:            rule.Active,
+		CreatedAt:         rule.CreatedAt,
+		UpdatedAt:         rule.UpdatedAt,
+	}
+	if rule.Area != "" {
+		info.Area = json.RawMessage(rule.Area)
+	}
+	if info.Emails == nil {
+		info.Emails = []string{}
+	}
+	if info.TelegramChats == nil {
+		info.TelegramChats = []string{}
+	}
+	return info
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"road-detector-go/internal/model"
+)
+
+// ErrInvalidAnalysisParams возвращается при некорректных параметрах анализа
+var ErrInvalidAnalysisParams = errors.New("invalid analysis parameters")
+
+// maxFrameSampleRate максимальная частота анализа кадров
+const maxFrameSampleRate = 120
+
+// modelVariantPattern допустимое название варианта модели
+var modelVariantPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// ValidateAnalysisParams проверяет параметры анализа перед отправкой
+// Python сервису. Условия записаны так, чтобы NaN их не проходил.
+func ValidateAnalysisParams(p model.AnalysisParams) error {
+	if !(p.FrameSampleRate >= 0 && p.FrameSampleRate <= maxFrameSampleRate) {
+		return fmt.Errorf("%w: frame_sample_rate must be between 0 and %d frames per second", ErrInvalidAnalysisParams, maxFrameSampleRate)
+	}
+	if !(p.ConfidenceThreshold >= 0 && p.ConfidenceThreshold <= 1) {
+		return fmt.Errorf("%w: confidence_threshold must be between 0 and 1", ErrInvalidAnalysisParams)
+	}
+	if p.ModelVariant != "" && !modelVariantPattern.MatchString(p.ModelVariant) {
+		return fmt.Errorf("%w: model_variant must be 1-64 characters of A-Z, a-z, 0-9, '_', '.', '-'", ErrInvalidAnalysisParams)
+	}
+	if roi := p.ROI; roi != nil {
+		if !(roi.X >= 0 && roi.Y >= 0 && roi.Width > 0 && roi.Height > 0 && roi.X+roi.Width <= 1 && roi.Y+roi.Height <= 1) {
+			return fmt.Errorf("%w: roi must be x,y,width,height fractions of the frame with width and height above 0 and x+width, y+height at most 1", ErrInvalidAnalysisParams)
+		}
+	}
+	return nil
+}
+
+// ParseROI разбирает область кадра вида "x,y,width,height"
+func ParseROI(value string) (*model.ROI, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("%w: roi must be x,y,width,height", ErrInvalidAnalysisParams)
+	}
+	var values [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: roi must be x,y,width,height", ErrInvalidAnalysisParams)
+		}
+		values[i] = v
+	}
+	return &model.ROI{X: values[0], Y: values[1], Width: values[2], Height: values[3]}, nil
+}
+
+// FormatROI возвращает область кадра в виде "x,y,width,height"
+func FormatROI(roi model.ROI) string {
+	parts := make([]string, 0, 4)
+	for _, v := range []float64{roi.X, roi.Y, roi.Width, roi.Height} {
+		parts = append(parts, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/imageseq"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrAnalysisPending возвращается, если анализ в очереди не завершился за
+// время ожидания. Анализ продолжается, маршрут появится после его завершения.
+var ErrAnalysisPending = errors.New("analysis is still running")
+
+// AnalysisPendingError анализ, который не завершился за время ожидания
+type AnalysisPendingError struct {
+	JobID   string
+	RouteID string
+}
+
+func (e *AnalysisPendingError) Error() string {
+	return fmt.Sprintf("%s: route %s will be available after job %s completes", ErrAnalysisPending, e.RouteID, e.JobID)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrAnalysisPending)
+func (e *AnalysisPendingError) Unwrap() error {
+	return ErrAnalysisPending
+}
+
+// queuedErrorReasons виды ошибок, которые воркер сохраняет вместе с
+// текстом, чтобы экземпляр API вернул клиенту тот же код ошибки
+var queuedErrorReasons = []struct {
+	reason string
+	target error
+}{
+	{"analyzer_rejected", ErrAnalyzerRejected},
+	{"analyzer_bad_response", ErrAnalyzerBadResponse},
+	{"analyzer_unavailable", ErrAnalyzerUnavailable},
+	{"quota_exceeded", ErrQuotaExceeded},
+	{"storage_quota_exceeded", ErrStorageQuotaExceeded},
+	{"invalid_analysis_params", ErrInvalidAnalysisParams},
+	{"invalid_archive", imageseq.ErrInvalidArchive},
+	{"image_sequences_disabled", ErrImageSequencesDisabled},
+	{"route_exists", ErrRouteExists},
+	{"invalid_route_id", ErrInvalidRouteID},
+}
+
+// QueuedAnalysisError ошибка анализа, выполненного воркером
+type QueuedAnalysisError struct {
+	Reason  string
+	Message string
+}
+
+func (e *QueuedAnalysisError) Error() string {
+	return e.Message
+}
+
+// Unwrap возвращает ошибку предметной области по виду ошибки, чтобы
+// клиент получил тот же код, что и без очереди
+func (e *QueuedAnalysisError) Unwrap() error {
+	for _, known := range queuedErrorReasons {
+		if known.reason == e.Reason {
+			return known.target
+		}
+	}
+	return nil
+}
+
+// queuedErrorReason вид ошибки анализа для сохранения в очереди
+func queuedErrorReason(err error) string {
+	for _, known := range queuedErrorReasons {
+		if errors.Is(err, known.target) {
+			return known.reason
+		}
+	}
+	return "internal"
+}
+
+// AnalysisQueueOptions настройки очереди анализов
+type AnalysisQueueOptions struct {
+	// Dir папка видео и архивов в очереди, общая для экземпляров API и
+	// воркеров
+	Dir string
+	// Workers сколько анализов воркер выполняет одновременно
+	Workers int
+	// PollInterval как часто проверять очередь и статус ожидаемого анализа
+	PollInterval time.Duration
+	// StaleAfter анализ, воркер которого не подавал сигнал дольше этого
+	// срока, берет другой воркер
+	StaleAfter time.Duration
+	// MaxAttempts сколько раз анализ берется воркерами, прежде чем
+	// считается неудачным
+	MaxAttempts int
+	// WaitTimeout сколько экземпляр API ждет результат анализа
+	WaitTimeout time.Duration
+}
+
+// analysisJobParams параметры анализа в очереди
+type analysisJobParams struct {
+	StartLat      float64       `json:"start_lat"`
+	StartLon      float64       `json:"start_lon"`
+	EndLat        float64       `json:"end_lat"`
+	EndLon        float64       `json:"end_lon"`
+	SegmentLength float64       `json:"segment_length"`
+	Metadata      RouteMetadata `json:"metadata"`
+}
+
+// AnalysisQueue очередь анализов между экземплярами API и воркерами.
+// Экземпляр API сохраняет видео в общую папку и ставит анализ в очередь
+// в базе данных, воркер берет анализ, выполняет его тем же конвейером и
+// сохраняет результат, который API возвращает клиенту.
+type AnalysisQueue struct {
+	repo     repository.AnalysisJobRepository
+	opts     AnalysisQueueOptions
+	logger   *logrus.Logger
+	workerID string
+}
+
+// NewAnalysisQueue создает очередь анализов и папку для видео
+func NewAnalysisQueue(repo repository.AnalysisJobRepository, opts AnalysisQueueOptions, logger *logrus.Logger) (*AnalysisQueue, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = 2 * time.Minute
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create analysis queue directory: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	return &AnalysisQueue{
+		repo:     repo,
+		opts:     opts,
+		logger:   logger,
+		workerID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}, nil
+}
+
+// submit ставит анализ в очередь и ждет его результат до WaitTimeout.
+// Если анализ не завершился, возвращает *AnalysisPendingError.
+func (q *AnalysisQueue) submit(kind, routeID string, params analysisJobParams, payload io.Reader, filename string) (*AnalysisResult, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode analysis params: %w", err)
+	}
+
+	jobID := uuid.NewString()
+	dir := filepath.Join(q.opts.Dir, jobID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create analysis job directory: %w", err)
+	}
+	payloadPath := filepath.Join(dir, "payload")
+	if err := writePayload(payloadPath, payload); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	job := &model.AnalysisJob{
+		ID:          jobID,
+		RouteID:     routeID,
+		Kind:        kind,
+		Status:      model.AnalysisJobQueued,
+		Params:      string(encoded),
+		PayloadPath: payloadPath,
+		Filename:    filename,
+	}
+	if err := q.repo.Create(job); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	q.logger.Infof("Анализ маршрута %s поставлен в очередь: %s", routeID, jobID)
+
+	return q.wait(jobID, routeID)
+}
+
+// writePayload сохраняет видео или архив в папку очереди
+func writePayload(path string, payload io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create analysis payload: %w", err)
+	}
+	if _, err := io.Copy(file, payload); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write analysis payload: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write analysis payload: %w", err)
+	}
+	return nil
+}
+
+// wait ждет завершения анализа jobID
+func (q *AnalysisQueue) wait(jobID, routeID string) (*AnalysisResult, error) {
+	var deadline <-chan time.Time
+	if q.opts.WaitTimeout > 0 {
+		timer := time.NewTimer(q.opts.WaitTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-deadline:
+			q.logger.Warnf("Анализ маршрута %s не завершился за %s, ответ вернется без результата", routeID, q.opts.WaitTimeout)
+			return nil, &AnalysisPendingError{JobID: jobID, RouteID: routeID}
+		case <-ticker.C:
+		}
+
+		job, err := q.repo.GetByID(jobID)
+		if err != nil {
+			q.logger.Warnf("Не удалось проверить анализ %s в очереди: %v", jobID, err)
+			continue
+		}
+		switch job.Status {
+		case model.AnalysisJobCompleted:
+			var result AnalysisResult
+			if err := json.Unmarshal([]byte(job.Result), &result); err != nil {
+				return nil, fmt.Errorf("failed to decode queued analysis result: %w", err)
+			}
+			return &result, nil
+		case model.AnalysisJobFailed:
+			return nil, &QueuedAnalysisError{Reason: job.ErrorReason, Message: job.Error}
+		}
+	}
+}
+
+// Counts количество анализов в очереди и выполняющихся воркерами
+func (q *AnalysisQueue) Counts() (map[string]int64, error) {
+	return q.repo.CountByStatus()
+}
+
+// CheckDir проверяет, что в папку очереди можно записывать
+func (q *AnalysisQueue) CheckDir() error {
+	file, err := os.CreateTemp(q.opts.Dir, ".health-*")
+	if err != nil {
+		return fmt.Errorf("analysis queue directory is not writable: %w", err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// Run выполняет анализы из очереди в Workers потоков, пока не отменен ctx.
+// Начатые анализы завершаются, после этого Run возвращается. Анализ,
+// прерванный остановкой воркера, берет другой воркер после StaleAfter.
+func (q *AnalysisQueue) Run(ctx context.Context, analyzer *AnalyzerService) {
+	q.logger.Infof("Воркер %s выполняет анализы из очереди, потоков: %d", q.workerID, q.opts.Workers)
+	var wg sync.WaitGroup
+	for n := 0; n < q.opts.Workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, analyzer)
+		}()
+	}
+	wg.Wait()
+}
+
+// work берет анализы из очереди по одному
+func (q *AnalysisQueue) work(ctx context.Context, analyzer *AnalyzerService) {
+	for {
+		// В режиме обслуживания воркер не берет новые анализы
+		if analyzer.maintenance == nil || analyzer.maintenance.Check() == nil {
+			job, err := q.repo.Claim(q.workerID, time.Now().Add(-q.opts.StaleAfter))
+			if err != nil {
+				q.logger.Errorf("Не удалось взять анализ из очереди: %v", err)
+			} else if job != nil {
+				q.process(job, analyzer)
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(q.opts.PollInterval):
+		}
+	}
+}
+
+// process выполняет анализ из очереди и сохраняет результат
+func (q *AnalysisQueue) process(job *model.AnalysisJob, analyzer *AnalyzerService) {
+	log := q.logger.WithField("job_id", job.ID).WithField("route_id", job.RouteID)
+	if job.Attempts > 1 {
+		log.Warnf("Анализ взят повторно, попытка %d", job.Attempts)
+	}
+
+	stop := make(chan struct{})
+	go q.heartbeat(job, stop)
+	result, err := q.execute(job, analyzer)
+	close(stop)
+
+	now := time.Now()
+	job.FinishedAt = &now
+	if err != nil {
+		log.Warnf("Анализ из очереди не выполнен: %v", err)
+		job.Status = model.AnalysisJobFailed
+		job.Error = err.Error()
+		job.ErrorReason = queuedErrorReason(err)
+	} else {
+		encoded, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			log.Errorf("Не удалось сохранить результат анализа: %v", marshalErr)
+			job.Status = model.AnalysisJobFailed
+			job.Error = marshalErr.Error()
+			job.ErrorReason = "internal"
+		} else {
+			job.Status = model.AnalysisJobCompleted
+			job.Result = string(encoded)
+		}
+	}
+
+	if err := q.repo.Finish(job); err != nil {
+		if errors.Is(err, repository.ErrAnalysisJobNotFound) {
+			log.Warn("Анализ уже взят другим воркером, результат не сохранен")
+			return
+		}
+		log.Errorf("Не удалось сохранить результат анализа из очереди: %v", err)
+		return
+	}
+	if err := os.RemoveAll(filepath.Dir(job.PayloadPath)); err != nil {
+		log.Warnf("Не удалось удалить видео анализа из очереди: %v", err)
+	}
+}
+
+// execute выполняет анализ тем же конвейером, что и без очереди
+func (q *AnalysisQueue) execute(job *model.AnalysisJob, analyzer *AnalyzerService) (*AnalysisResult, error) {
+	if job.Attempts > q.opts.MaxAttempts {
+		return nil, fmt.Errorf("analysis was interrupted %d times", job.Attempts-1)
+	}
+	var params analysisJobParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		return nil, fmt.Errorf("failed to decode analysis params: %w", err)
+	}
+	params.Metadata.queued = true
+
+	switch job.Kind {
+	case model.AnalysisJobImages:
+		archive, err := os.ReadFile(job.PayloadPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read queued archive: %w", err)
+		}
+		return analyzer.AnalyzeImageSequence(archive, job.Filename, params.SegmentLength, job.RouteID, params.Metadata)
+	default:
+		video, err := os.Open(job.PayloadPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read queued video: %w", err)
+		}
+		defer video.Close()
+		return analyzer.AnalyzeRoadMarking(params.StartLat, params.StartLon, params.EndLat, params.EndLon,
+			params.SegmentLength, video, job.Filename, job.RouteID, params.Metadata)
+	}
+}
+
+// heartbeat сообщает, что анализ выполняется, пока не закрыт stop
+func (q *AnalysisQueue) heartbeat(job *model.AnalysisJob, stop <-chan struct{}) {
+	ticker := time.NewTicker(q.opts.StaleAfter / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := q.repo.Heartbeat(job.ID, q.workerID); err != nil {
+				q.logger.Warnf("Не удалось обновить сигнал анализа %s: %v", job.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyWindowSize количество последних анализов, по которым считаются
+// перцентили длительности
+const latencyWindowSize = 1000
+
+// analysisStats длительности последних успешных анализов и количество
+// неудачных и медленных с момента запуска сервиса
+type analysisStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	succeeded int64
+	failed    int64
+	slow      int64
+	// slowThreshold порог медленного анализа, 0 — медленные не отмечаются
+	slowThreshold time.Duration
+}
+
+// setSlowThreshold меняет порог медленного анализа
+func (s *analysisStats) setSlowThreshold(threshold time.Duration) {
+	s.mu.Lock()
+	s.slowThreshold = threshold
+	s.mu.Unlock()
+}
+
+// record учитывает завершенный анализ. Возвращает порог, если анализ
+// оказался медленнее него, иначе 0.
+func (s *analysisStats) record(duration time.Duration, err error) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var slow time.Duration
+	if s.slowThreshold > 0 && duration >= s.slowThreshold {
+		s.slow++
+		slow = s.slowThreshold
+	}
+	if err != nil {
+		s.failed++
+		return slow
+	}
+	s.succeeded++
+	if len(s.latencies) < latencyWindowSize {
+		s.latencies = append(s.latencies, duration)
+		return slow
+	}
+	s.latencies[s.next] = duration
+	s.next = (s.next + 1) % latencyWindowSize
+	return slow
+}
+
+// snapshot возвращает счетчики и перцентили длительности анализов
+func (s *analysisStats) snapshot() AnalyzerStats {
+	s.mu.Lock()
+	sorted := append([]time.Duration(nil), s.latencies...)
+	stats := AnalyzerStats{
+		Succeeded: s.succeeded,
+		Failed:    s.failed,
+		Slow:      s.slow,
+		Samples:   len(sorted),
+	}
+	s.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return stats
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	stats.LatencyMs = &LatencyPercentiles{
+		P50: percentileMs(sorted, 0.50),
+		P90: percentileMs(sorted, 0.90),
+		P95: percentileMs(sorted, 0.95),
+		P99: percentileMs(sorted, 0.99),
+		Max: durationMs(sorted[len(sorted)-1]),
+	}
+	return stats
+}
+
+// percentileMs перцентиль p отсортированных длительностей в миллисекундах
+// (ближайший ранг)
+func percentileMs(sorted []time.Duration, p float64) float64 {
+	rank := int(math.Ceil(float64(len(sorted))*p)) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return durationMs(sorted[rank])
+}
+
+// durationMs длительность в миллисекундах
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
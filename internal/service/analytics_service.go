@@ -0,0 +1,226 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"road-detector-go/internal/coverageband"
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// metersPerDegreeLat приблизительная длина одного градуса широты
+	metersPerDegreeLat = 111320.0
+	// maxHeatmapCells ограничивает размер сетки тепловой карты
+	maxHeatmapCells = 40000
+)
+
+var (
+	// ErrHeatmapTooLarge возвращается, если запрошенная сетка слишком детальна для области
+	ErrHeatmapTooLarge = errors.New("heatmap grid is too large for the requested area")
+	// ErrInvalidHistogramQuery возвращается при неверных параметрах гистограммы покрытия
+	ErrInvalidHistogramQuery = errors.New("invalid coverage histogram query")
+)
+
+// AnalyticsService сервис агрегированной аналитики по сегментам
+type AnalyticsService struct {
+	analyticsRepo repository.AnalyticsRepository
+	bands         *coverageband.Classifier
+	logger        *logrus.Logger
+}
+
+// NewAnalyticsService создает новый сервис аналитики
+func NewAnalyticsService(analyticsRepo repository.AnalyticsRepository, logger *logrus.Logger) *AnalyticsService {
+	return &AnalyticsService{
+		analyticsRepo: analyticsRepo,
+		logger:        logger,
+	}
+}
+
+// SetCoverageBands включает отнесение к полосам покрытия худших сегментов
+// и интервалов гистограммы
+func (s *AnalyticsService) SetCoverageBands(bands *coverageband.Classifier) {
+	s.bands = bands
+}
+
+// GetCoverageHeatmap строит тепловую карту среднего покрытия по области
+// с ячейками размером cellSizeM x cellSizeM метров. Учитываются только маршруты
+// из области доступа scope.
+func (s *AnalyticsService) GetCoverageHeatmap(neLat, neLon, swLat, swLon, cellSizeM float64, scope repository.RouteScope) (*HeatmapResponse, error) {
+	s.logger.Infof("Строим тепловую карту: NE(%.6f, %.6f) SW(%.6f, %.6f), ячейка %.0f м",
+		neLat, neLon, swLat, swLon, cellSizeM)
+
+	bbox, err := geo.NewBoundingBox(neLat, neLon, swLat, swLon)
+	if err != nil {
+		return nil, err
+	}
+
+	height := bbox.NorthEast.Lat - bbox.SouthWest.Lat
+	width := bbox.WidthDegrees()
+	if height <= 0 || width <= 0 {
+		return nil, fmt.Errorf("%w: area is empty", geo.ErrInvalidBoundingBox)
+	}
+
+	// Переводим размер ячейки из метров в градусы по центру области
+	centerLat := (bbox.NorthEast.Lat + bbox.SouthWest.Lat) / 2
+	cellLat := cellSizeM / metersPerDegreeLat
+	cellLon := cellSizeM / (metersPerDegreeLat * math.Max(math.Cos(centerLat*math.Pi/180), 0.01))
+
+	rows := int(math.Ceil(height / cellLat))
+	cols := int(math.Ceil(width / cellLon))
+	if rows*cols > maxHeatmapCells {
+		return nil, fmt.Errorf("%w: %dx%d cells, max %d", ErrHeatmapTooLarge, rows, cols, maxHeatmapCells)
+	}
+
+	// Восточная граница разворачивается за 180°, если область пересекает антимеридиан
+	swLat, swLon = bbox.SouthWest.Lat, bbox.SouthWest.Lon
+	ne := repository.Coordinates{Lat: bbox.NorthEast.Lat, Lon: swLon + width}
+	sw := repository.Coordinates{Lat: swLat, Lon: swLon}
+
+	cells, err := s.analyticsRepo.CoverageHeatmap(ne, sw, cellLat, cellLon, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка построения тепловой карты: %v", err)
+		return nil, fmt.Errorf("failed to build coverage heatmap: %w", err)
+	}
+
+	response := &HeatmapResponse{
+		NorthEast:   Coordinates{Lat: bbox.NorthEast.Lat, Lon: bbox.NorthEast.Lon},
+		SouthWest:   Coordinates{Lat: bbox.SouthWest.Lat, Lon: bbox.SouthWest.Lon},
+		CellSizeM:   cellSizeM,
+		CellSizeLat: cellLat,
+		CellSizeLon: cellLon,
+		Rows:        rows,
+		Cols:        cols,
+		Cells:       make([]HeatmapCell, 0, len(cells)),
+	}
+
+	for _, cell := range cells {
+		cellSWLat := swLat + float64(cell.Row)*cellLat
+		cellSWLon := swLon + float64(cell.Col)*cellLon
+		response.Cells = append(response.Cells, HeatmapCell{
+			Row:       cell.Row,
+			Col:       cell.Col,
+			SouthWest: Coordinates{Lat: cellSWLat, Lon: geo.NormalizeLon(cellSWLon)},
+			NorthEast: Coordinates{Lat: cellSWLat + cellLat, Lon: geo.NormalizeLon(cellSWLon + cellLon)},
+			Center:    Coordinates{Lat: cellSWLat + cellLat/2, Lon: geo.NormalizeLon(cellSWLon + cellLon/2)},
+			// Округляем до 1 знака, как и остальную статистику покрытия
+			AverageCoverage: math.Round(cell.AverageCoverage*10) / 10,
+			SegmentCount:    cell.SegmentCount,
+		})
+	}
+
+	s.logger.Infof("Тепловая карта построена: %d непустых ячеек из %d", len(response.Cells), rows*cols)
+	return response, nil
+}
+
+// GetWorstSegments возвращает до limit сегментов с наименьшим покрытием в
+// области area (nil — без ограничения области) для планирования ремонта.
+// Из нескольких анализов одного участка дороги учитывается только последний:
+// сегмент пропускается, если ближе corridorM метров от него есть сегмент с
+// данными более позднего маршрута. Учитываются только маршруты из области
+// доступа scope.
+func (s *AnalyticsService) GetWorstSegments(area *geo.BoundingBox, corridorM float64, limit int, scope repository.RouteScope) (*WorstSegmentsResponse, error) {
+	response := &WorstSegmentsResponse{CorridorM: corridorM, Limit: limit}
+
+	var ne, sw *repository.Coordinates
+	if area != nil {
+		// Восточная граница разворачивается за 180°, если область пересекает антимеридиан
+		ne = &repository.Coordinates{Lat: area.NorthEast.Lat, Lon: area.SouthWest.Lon + area.WidthDegrees()}
+		sw = &repository.Coordinates{Lat: area.SouthWest.Lat, Lon: area.SouthWest.Lon}
+		response.NorthEast = &Coordinates{Lat: area.NorthEast.Lat, Lon: area.NorthEast.Lon}
+		response.SouthWest = &Coordinates{Lat: area.SouthWest.Lat, Lon: area.SouthWest.Lon}
+	}
+
+	segments, err := s.analyticsRepo.WorstSegments(ne, sw, corridorM, limit, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения худших сегментов: %v", err)
+		return nil, fmt.Errorf("failed to get worst segments: %w", err)
+	}
+
+	response.Segments = make([]WorstSegment, len(segments))
+	for i, seg := range segments {
+		worst := WorstSegment{
+			Rank:               i + 1,
+			RouteID:            seg.RouteID,
+			RouteName:          seg.RouteName,
+			RoadName:           seg.RoadName,
+			SegmentID:          seg.SegmentID,
+			StartCoordinate:    Coordinates{Lat: seg.StartLat, Lon: seg.StartLon},
+			EndCoordinate:      Coordinates{Lat: seg.EndLat, Lon: seg.EndLon},
+			CoveragePercentage: seg.CoveragePercentage,
+			DefectsCount:       seg.DefectsCount,
+			QualityScore:       seg.QualityScore,
+			AnalyzedAt:         seg.AnalyzedAt,
+		}
+		if s.bands != nil {
+			band := s.bands.Classify(seg.CoveragePercentage)
+			worst.CoverageBand, worst.CoverageColor = band.Name, band.Color
+		}
+		response.Segments[i] = worst
+	}
+
+	s.logger.Infof("Список худших сегментов: %d из не более %d", len(segments), limit)
+	return response, nil
+}
+
+// GetCoverageHistogram считает сегменты с данными по интервалам покрытия
+// шириной query.BucketSize процентов. Возвращаются все интервалы, включая
+// пустые. Учитываются только маршруты из области доступа scope.
+func (s *AnalyticsService) GetCoverageHistogram(query CoverageHistogramQuery, scope repository.RouteScope) (*CoverageHistogramResponse, error) {
+	if query.BucketSize < 1 || query.BucketSize > 50 || 100%query.BucketSize != 0 {
+		return nil, fmt.Errorf("%w: bucket must be a divisor of 100 between 1 and 50", ErrInvalidHistogramQuery)
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidHistogramQuery)
+	}
+
+	response := &CoverageHistogramResponse{From: query.From, To: query.To, BucketSize: query.BucketSize}
+	var ne, sw *repository.Coordinates
+	if area := query.Area; area != nil {
+		// Восточная граница разворачивается за 180°, если область пересекает антимеридиан
+		ne = &repository.Coordinates{Lat: area.NorthEast.Lat, Lon: area.SouthWest.Lon + area.WidthDegrees()}
+		sw = &repository.Coordinates{Lat: area.SouthWest.Lat, Lon: area.SouthWest.Lon}
+		response.NorthEast = &Coordinates{Lat: area.NorthEast.Lat, Lon: area.NorthEast.Lon}
+		response.SouthWest = &Coordinates{Lat: area.SouthWest.Lat, Lon: area.SouthWest.Lon}
+	}
+
+	count := 100 / query.BucketSize
+	rows, err := s.analyticsRepo.CoverageHistogram(ne, sw, query.From, query.To, count, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка построения гистограммы покрытия: %v", err)
+		return nil, fmt.Errorf("failed to build coverage histogram: %w", err)
+	}
+
+	response.Buckets = make([]CoverageHistogramBucket, count)
+	for i := range response.Buckets {
+		bucket := CoverageHistogramBucket{
+			Min: float64(i * query.BucketSize),
+			Max: float64((i + 1) * query.BucketSize),
+		}
+		if s.bands != nil {
+			// Полоса интервала определяется по его нижней границе
+			band := s.bands.Classify(bucket.Min)
+			bucket.CoverageBand, bucket.CoverageColor = band.Name, band.Color
+		}
+		response.Buckets[i] = bucket
+	}
+	for _, row := range rows {
+		if row.Bucket >= 0 && row.Bucket < count {
+			response.Buckets[row.Bucket].Count = row.Count
+			response.TotalSegments += row.Count
+		}
+	}
+	if response.TotalSegments > 0 {
+		for i := range response.Buckets {
+			share := float64(response.Buckets[i].Count) / float64(response.TotalSegments)
+			response.Buckets[i].Share = math.Round(share*10000) / 10000
+		}
+	}
+
+	s.logger.Infof("Гистограмма покрытия построена: %d сегментов в %d интервалах", response.TotalSegments, count)
+	return response, nil
+}
@@ -2,70 +2,524 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"archive/zip"
 
+	"road-detector-go/internal/analyzerpool"
+	"road-detector-go/internal/buildinfo"
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/errreport"
+	"road-detector-go/internal/geocode"
+	"road-detector-go/internal/imageseq"
+	"road-detector-go/internal/mapmatch"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/onnxanalyzer"
+	road_marking "road-detector-go/internal/proto"
+	"road-detector-go/internal/quality"
+	"road-detector-go/internal/videochunk"
+	"road-detector-go/pkg/models"
+
 	"github.com/sirupsen/logrus"
 )
 
+// Ошибки обращения к Python сервису анализа
+var (
+	// ErrAnalyzerUnavailable сервис анализа недоступен или не смог обработать запрос
+	ErrAnalyzerUnavailable = errors.New("analyzer unavailable")
+	// ErrAnalyzerRejected сервис анализа отклонил входные данные (ответ 4xx)
+	ErrAnalyzerRejected = errors.New("analyzer rejected input")
+	// ErrAnalyzerBadResponse сервис анализа вернул ответ, который не удалось разобрать
+	ErrAnalyzerBadResponse = errors.New("invalid analyzer response")
+)
+
+// maxAnalyzerErrorBody максимальная длина тела ответа с ошибкой в тексте ошибки
+const maxAnalyzerErrorBody = 500
+
 // AnalyzerService сервис для анализа дорожной разметки
 type AnalyzerService struct {
-	pythonServiceURL string
-	logger           *logrus.Logger
-	client           *http.Client
-	routeService     *RouteService
+	// mu защищает клиент Python сервиса, расчет ожидания его ответа и
+	// порог уверенности, которые меняются при перезагрузке конфигурации
+	mu              sync.RWMutex
+	instances       *analyzerpool.Pool
+	logger          *logrus.Logger
+	client          *http.Client
+	timeoutPolicy   TimeoutPolicy
+	lowConfidence   float64
+	routeService    *RouteService
+	debugStore      *debugcapture.Store
+	matcher         mapmatch.Matcher
+	geocoder        geocode.Geocoder
+	geocodeSegments bool
+	usage           *UsageService
+	outbox          *OutboxService
+	reporter        errreport.Reporter
+	shadow          *ShadowService
+	stats           analysisStats
+
+	// unsaved хранилище результатов, которые не удалось сохранить в базе
+	// данных, nil — такие результаты теряются
+	unsaved *UnsavedResultService
+
+	// alerts проверка правил оповещений после сохранения маршрута, nil —
+	// правила не проверяются
+	alerts *AlertService
+
+	// notifications письма владельцам о завершенных анализах, nil — письма
+	// не отправляются
+	notifications *NotificationService
+
+	// jobs статусы выполняющихся анализов для подписчиков, nil — статусы
+	// не отслеживаются
+	jobs *JobTracker
+
+	// stream клиент потокового gRPC анализа, nil — анализ через HTTP
+	stream       road_marking.VideoAnalysisServiceClient
+	streamTarget string
+
+	// chunker делит длинные видео на части, nil — видео анализируется целиком
+	chunker          *videochunk.Splitter
+	chunkParallelism int
+
+	// local локальный анализ моделью ONNX, nil — анализ выполняет Python
+	// сервис
+	local *onnxanalyzer.Analyzer
+
+	// imageSeq разбор архивов снимков и сборка видео из них, nil — анализ
+	// последовательностей снимков выключен
+	imageSeq *imageseq.Converter
+
+	// quality расчет индекса качества дороги, nil — индекс не считается
+	quality *quality.Scorer
+
+	// currentContract формат API анализа, выбранный по версии Python
+	// сервиса, nil — версия еще неизвестна
+	currentContract atomic.Pointer[analyzerContract]
+
+	// maintenance режим обслуживания, в котором новые анализы отклоняются,
+	// nil — анализы принимаются всегда
+	maintenance *MaintenanceService
+	// active выполняющиеся анализы
+	active atomic.Int64
+
+	// queue очередь, через которую анализы выполняют воркеры, nil —
+	// анализ выполняется в этом экземпляре
+	queue *AnalysisQueue
 }
 
-// NewAnalyzerService создает новый сервис анализатора
-func NewAnalyzerService(pythonServiceURL string, logger *logrus.Logger, routeService *RouteService) *AnalyzerService {
-	return &AnalyzerService{
-		pythonServiceURL: pythonServiceURL,
-		logger:           logger,
+// NewAnalyzerService создает новый сервис анализатора, который распределяет
+// анализы между экземплярами Python сервиса из instances
+func NewAnalyzerService(instances analyzerpool.Options, logger *logrus.Logger, routeService *RouteService) *AnalyzerService {
+	s := &AnalyzerService{
+		logger: logger,
 		client: &http.Client{
 			Timeout: 300 * time.Second, // Увеличиваем таймаут для обработки видео
 		},
 		routeService: routeService,
 	}
+	// Экземпляр с неподдерживаемой версией считается нездоровым
+	s.instances = analyzerpool.New(instances, func(ctx context.Context, baseURL string) error {
+		health, err := s.fetchHealth(ctx, baseURL)
+		if err != nil {
+			return err
+		}
+		return s.useContractFor(health.Version)
+	})
+	return s
+}
+
+// Timeout возвращает ожидание ответа Python сервиса на запрос анализа
+func (s *AnalyzerService) Timeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client.Timeout
+}
+
+// SetTimeout задает ожидание ответа Python сервиса, 0 — без ограничения.
+// Выполняющиеся запросы сохраняют прежнее ожидание.
+func (s *AnalyzerService) SetTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client := *s.client
+	client.Timeout = timeout
+	s.client = &client
+}
+
+// SetServiceURLs меняет адреса экземпляров Python сервиса для следующих
+// запросов
+func (s *AnalyzerService) SetServiceURLs(urls []string) {
+	s.instances.SetURLs(urls)
+}
+
+// RunInstanceChecks проверяет /health экземпляров Python сервиса, пока не
+// отменен ctx. Нездоровые экземпляры пропускаются при выборе.
+func (s *AnalyzerService) RunInstanceChecks(ctx context.Context) {
+	s.instances.Run(ctx)
+}
+
+// Instances возвращает состояние экземпляров Python сервиса
+func (s *AnalyzerService) Instances() []analyzerpool.Status {
+	return s.instances.Status()
+}
+
+// SetHTTPTransport заменяет транспорт HTTP клиента Python сервиса,
+// например для внедрения сбоев в режиме хаоса
+func (s *AnalyzerService) SetHTTPTransport(transport http.RoundTripper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client := *s.client
+	client.Transport = transport
+	s.client = &client
+}
+
+// httpClient возвращает клиент для запросов к Python сервису
+func (s *AnalyzerService) httpClient() *http.Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client
+}
+
+// SetDebugCapture включает сохранение отладочных пакетов неудачных анализов
+func (s *AnalyzerService) SetDebugCapture(store *debugcapture.Store) {
+	s.debugStore = store
+}
+
+// SetUnsavedResults включает сохранение результатов анализов, которые не
+// удалось сохранить в базе данных, для повторного сохранения
+func (s *AnalyzerService) SetUnsavedResults(unsaved *UnsavedResultService) {
+	s.unsaved = unsaved
+}
+
+// SetMapMatcher включает привязку сегментов к дорожному графу OSM после анализа
+func (s *AnalyzerService) SetMapMatcher(matcher mapmatch.Matcher) {
+	s.matcher = matcher
+}
+
+// SetGeocoder включает определение названий дорог после анализа.
+// При perSegment название определяется также для каждого сегмента.
+func (s *AnalyzerService) SetGeocoder(geocoder geocode.Geocoder, perSegment bool) {
+	s.geocoder = geocoder
+	s.geocodeSegments = perSegment
 }
 
-// AnalyzeRoadMarking анализирует дорожное покрытие
+// SetUsageService включает учет использования и проверку месячных квот
+// перед анализом
+func (s *AnalyzerService) SetUsageService(usage *UsageService) {
+	s.usage = usage
+}
+
+// SetEventOutbox включает публикацию событий о завершении и ошибке анализа
+// подписчикам. Событие о завершении сохраняется вместе с маршрутом.
+func (s *AnalyzerService) SetEventOutbox(outbox *OutboxService) {
+	s.outbox = outbox
+}
+
+// SetErrorReporter включает отправку неудачных анализов в систему учета ошибок
+func (s *AnalyzerService) SetErrorReporter(reporter errreport.Reporter) {
+	s.reporter = reporter
+}
+
+// SetShadowService включает повтор части анализов теневым анализатором
+func (s *AnalyzerService) SetShadowService(shadow *ShadowService) {
+	s.shadow = shadow
+}
+
+// SetAlertService включает проверку правил оповещений для сохраненных маршрутов
+func (s *AnalyzerService) SetAlertService(alerts *AlertService) {
+	s.alerts = alerts
+}
+
+// SetNotificationService включает письма владельцам маршрутов о
+// завершенных анализах
+func (s *AnalyzerService) SetNotificationService(notifications *NotificationService) {
+	s.notifications = notifications
+}
+
+// SetJobTracker включает отслеживание статусов выполняющихся анализов
+func (s *AnalyzerService) SetJobTracker(jobs *JobTracker) {
+	s.jobs = jobs
+}
+
+// SetMaintenance включает отказ в новых анализах в режиме обслуживания
+func (s *AnalyzerService) SetMaintenance(maintenance *MaintenanceService) {
+	s.maintenance = maintenance
+}
+
+// SetAnalysisQueue передает анализы воркерам через очередь вместо
+// выполнения в этом экземпляре (RUN_MODE=api)
+func (s *AnalyzerService) SetAnalysisQueue(queue *AnalysisQueue) {
+	s.queue = queue
+}
+
+// ActiveAnalyses возвращает число выполняющихся анализов
+func (s *AnalyzerService) ActiveAnalyses() int {
+	return int(s.active.Load())
+}
+
+// checkMaintenance возвращает *MaintenanceError, если включен режим
+// обслуживания. Самопроверка выполняется и в этом режиме, чтобы проверить
+// конвейер до возобновления приема анализов. Анализ из очереди уже принят,
+// воркер в режиме обслуживания просто не берет новые.
+func (s *AnalyzerService) checkMaintenance(metadata RouteMetadata) error {
+	if s.maintenance == nil || metadata.selfTest || metadata.queued {
+		return nil
+	}
+	return s.maintenance.Check()
+}
+
+// uploadSize размер загруженного видео, если он известен до чтения, иначе 0
+func uploadSize(videoFile io.Reader) int64 {
+	if sized, ok := videoFile.(interface{ Len() int }); ok {
+		return int64(sized.Len())
+	}
+	return 0
+}
+
+// AnalyzeRoadMarking анализирует дорожное покрытие. При исчерпанной квоте
+// возвращает *QuotaError, при заполненной квоте хранилища —
+// *StorageQuotaError, в режиме обслуживания — *MaintenanceError.
 func (s *AnalyzerService) AnalyzeRoadMarking(
 	startLat, startLon, endLat, endLon, segmentLength float64,
 	videoFile io.Reader,
 	videoFilename string,
 	routeID string, // Добавлен параметр routeID
+	metadata RouteMetadata,
 ) (*AnalysisResult, error) {
-	s.logger.Infof("Начинаем анализ дорожного покрытия для маршрута %s", routeID)
-	s.logger.Infof("Координаты: start(%.6f, %.6f), end(%.6f, %.6f), длина сегмента: %.2f",
-		startLat, startLon, endLat, endLon, segmentLength)
+	if err := s.checkMaintenance(metadata); err != nil {
+		return nil, err
+	}
+	if err := s.validateTimeout(metadata.Timeout); err != nil {
+		return nil, err
+	}
+	subject := QuotaSubject(metadata.OrganizationID, metadata.OwnerID, metadata.APIKeyID)
+	if s.usage != nil {
+		if err := s.usage.CheckQuota(subject); err != nil {
+			return nil, err
+		}
+		if err := s.usage.CheckStorageQuota(metadata.OrganizationID, metadata.OwnerID, metadata.APIKeyID, uploadSize(videoFile)); err != nil {
+			return nil, err
+		}
+	}
+	started := time.Now()
+	s.active.Add(1)
+	defer s.active.Add(-1)
 
 	// Генерируем ID маршрута если не передан
 	if routeID == "" {
 		routeID = s.routeService.GenerateRouteID()
 		s.logger.Infof("Сгенерирован новый ID маршрута: %s", routeID)
 	}
+	if s.routeService != nil {
+		if err := s.routeService.CheckNewRouteID(routeID, metadata); err != nil {
+			return nil, err
+		}
+	}
 
-	// Создаем multipart форму для отправки файла и данных
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
+	if s.queue != nil && !metadata.queued {
+		return s.queue.submit(model.AnalysisJobVideo, routeID, analysisJobParams{
+			StartLat:      startLat,
+			StartLon:      startLon,
+			EndLat:        endLat,
+			EndLon:        endLon,
+			SegmentLength: segmentLength,
+			Metadata:      metadata,
+		}, videoFile, videoFilename)
+	}
+
+	rec := debugcapture.NewRecorder(routeID)
+	rec.SetParam("start", fmt.Sprintf("%.6f,%.6f", startLat, startLon))
+	rec.SetParam("end", fmt.Sprintf("%.6f,%.6f", endLat, endLon))
+	rec.SetParam("segment_length", fmt.Sprintf("%.2f", segmentLength))
+	rec.SetParam("video_filename", videoFilename)
+	if sized, ok := videoFile.(interface{ Len() int }); ok {
+		rec.SetParam("video_size_bytes", strconv.Itoa(sized.Len()))
+	}
+	if params := metadata.AnalysisParams; !params.Empty() {
+		encoded, _ := json.Marshal(params)
+		rec.SetParam("analysis_params", string(encoded))
+	}
+	if metadata.Timeout > 0 {
+		rec.SetParam("timeout_seconds", strconv.FormatFloat(metadata.Timeout.Seconds(), 'f', -1, 64))
+	}
+
+	log := s.logger
+	if s.debugStore != nil {
+		log = loggerWithHook(s.logger, rec)
+	}
+	if s.jobs != nil {
+		s.jobs.Start(Job{
+			RouteID:        routeID,
+			VideoFilename:  videoFilename,
+			OrganizationID: metadata.OrganizationID,
+			OwnerID:        metadata.OwnerID,
+			APIKeyID:       metadata.APIKeyID,
+		})
+		rec.SetStageHook(func(stage string) {
+			if stage != "total" {
+				s.jobs.Stage(routeID, stage)
+			}
+		})
+	}
+
+	rec.StartStage("total")
+	result, err := s.analyze(startLat, startLon, endLat, endLon, segmentLength, videoFile, videoFilename, routeID, metadata, log, rec)
+	rec.EndStage("total", err != nil)
+
+	if err != nil && (s.debugStore != nil || s.reporter != nil) {
+		bundle := rec.Finish(err)
+		saved := false
+		if s.debugStore != nil {
+			if saveErr := s.debugStore.Save(bundle); saveErr != nil {
+				s.logger.Errorf("Не удалось сохранить отладочный пакет анализа: %v", saveErr)
+			} else {
+				saved = true
+				s.logger.Infof("Сохранен отладочный пакет %s для неудачного анализа маршрута %s", bundle.ID, bundle.RouteID)
+			}
+		}
+		if s.reporter != nil {
+			s.reportAnalysisFailure(bundle, saved, metadata, err)
+		}
+	}
+	if threshold := s.stats.record(time.Since(started), err); threshold > 0 {
+		s.logSlowAnalysis(routeID, videoFilename, rec, time.Since(started), threshold, err)
+	}
+	if err == nil && s.usage != nil {
+		s.usage.RecordAnalysis(subject, time.Since(started))
+	}
+	if err != nil {
+		if event := s.analysisEvent(routeID, videoFilename, metadata, nil, err); event != nil {
+			s.addEvent(event)
+		}
+	}
+	if s.jobs != nil {
+		s.jobs.Finish(routeID, err)
+	}
+
+	return result, err
+}
+
+// analysisEvent создает событие о результате анализа для подписчиков,
+// nil — события не публикуются
+func (s *AnalyzerService) analysisEvent(routeID, videoFilename string, metadata RouteMetadata, result *AnalysisResult, err error) *model.OutboxEvent {
+	if s.outbox == nil {
+		return nil
+	}
+
+	data := AnalysisEventData{
+		RouteID:        routeID,
+		OrganizationID: metadata.OrganizationID,
+		Name:           metadata.Name,
+		VideoFilename:  videoFilename,
+	}
+	name := model.WebhookEventAnalysisCompleted
+	if err != nil {
+		name = model.WebhookEventAnalysisFailed
+		data.Reason = analysisFailureReason(err)
+		data.Error = err.Error()
+	} else {
+		stats := result.OverallStats
+		data.TotalSegments = stats.TotalSegments
+		data.AverageCoverage = stats.AverageCoverage
+		data.RoadName = result.RoadName
+		data.SegmentsWithData = stats.SegmentsWithData
+		data.TotalDistanceMeters = stats.TotalDistanceMeters
+		data.TotalDefects = stats.TotalDefects
+		data.QualityScore = stats.QualityScore
+		data.CoverageBand = stats.CoverageBand
+	}
+
+	event, marshalErr := s.outbox.NewEvent(name, metadata.OrganizationID, data)
+	if marshalErr != nil {
+		s.logger.Errorf("Не удалось сформировать событие %s: %v", name, marshalErr)
+		return nil
+	}
+	return event
+}
+
+// addEvent сохраняет событие отдельно от маршрута. Ошибки записываются
+// в лог и не влияют на результат анализа.
+func (s *AnalyzerService) addEvent(event *model.OutboxEvent) {
+	if err := s.outbox.Add(event); err != nil {
+		s.logger.Errorf("Не удалось сохранить событие %s для маршрута: %v", event.Event, err)
+	}
+}
 
-	// Добавляем координаты в форму - используем названия как ожидает Python сервис /analyze-road-marking
-	writer.WriteField("lat1", fmt.Sprintf("%.6f", startLat))
-	writer.WriteField("lon1", fmt.Sprintf("%.6f", startLon))
-	writer.WriteField("lat2", fmt.Sprintf("%.6f", endLat))
-	writer.WriteField("lon2", fmt.Sprintf("%.6f", endLon))
-	writer.WriteField("segment_length_m", fmt.Sprintf("%.0f", segmentLength))
+// reportAnalysisFailure отправляет неудачный анализ в систему учета ошибок
+// с параметрами запроса, ответом Python сервиса и длительностями этапов
+func (s *AnalyzerService) reportAnalysisFailure(bundle debugcapture.Bundle, saved bool, metadata RouteMetadata, err error) {
+	level := errreport.LevelError
+	if errors.Is(err, ErrAnalyzerRejected) {
+		level = errreport.LevelWarning
+	}
+
+	event := errreport.Event{
+		Level:   level,
+		Message: "Analysis failed: " + err.Error(),
+		Err:     err,
+		Tags: map[string]string{
+			"route_id": bundle.RouteID,
+			"reason":   analysisFailureReason(err),
+		},
+		Extra: map[string]interface{}{
+			"request": bundle.Request,
+			"timings": bundle.Timings,
+		},
+	}
+	if metadata.OrganizationID != nil {
+		event.Tags["organization_id"] = strconv.FormatUint(uint64(*metadata.OrganizationID), 10)
+	}
+	if bundle.Upstream != nil {
+		event.Extra["analyzer_response"] = bundle.Upstream
+	}
+	if saved {
+		event.Tags["debug_bundle_id"] = bundle.ID
+	}
+	s.reporter.Report(event)
+}
+
+// analysisFailureReason краткая причина неудачного анализа для подписчиков
+func analysisFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrAnalyzerRejected):
+		return "analyzer_rejected"
+	case errors.Is(err, ErrAnalyzerBadResponse):
+		return "analyzer_bad_response"
+	case errors.Is(err, ErrAnalyzerUnavailable):
+		return "analyzer_unavailable"
+	default:
+		return "internal"
+	}
+}
+
+// analyze выполняет анализ, записывая этапы и логи в recorder
+func (s *AnalyzerService) analyze(
+	startLat, startLon, endLat, endLon, segmentLength float64,
+	videoFile io.Reader,
+	videoFilename string,
+	routeID string,
+	metadata RouteMetadata,
+	log *logrus.Logger,
+	rec *debugcapture.Recorder,
+) (*AnalysisResult, error) {
+	log.Infof("Начинаем анализ дорожного покрытия для маршрута %s", routeID)
+	log.Infof("Координаты: start(%.6f, %.6f), end(%.6f, %.6f), длина сегмента: %.2f",
+		startLat, startLon, endLat, endLon, segmentLength)
 
 	// Читаем видео файл в буфер для дальнейшего использования
 	var videoData []byte
@@ -73,131 +527,576 @@ func (s *AnalyzerService) AnalyzeRoadMarking(
 		var err error
 		videoData, err = io.ReadAll(videoFile)
 		if err != nil {
-			s.logger.Errorf("Ошибка чтения видео файла: %v", err)
+			log.Errorf("Ошибка чтения видео файла: %v", err)
 			return nil, fmt.Errorf("failed to read video file: %w", err)
 		}
+	}
 
-		// Добавляем видео файл в форму
-		part, err := writer.CreateFormFile("video", videoFilename)
+	// Видео последовательности снимков собирается из них
+	if len(metadata.images) > 0 {
+		rec.SetParam("image_count", strconv.Itoa(len(metadata.images)))
+		rec.StartStage("image_encode")
+		var err error
+		videoData, err = s.imageSeq.Encode(context.Background(), metadata.images)
+		rec.EndStage("image_encode", err != nil)
 		if err != nil {
-			s.logger.Errorf("Ошибка создания form file: %v", err)
-			return nil, fmt.Errorf("failed to create form file: %w", err)
+			log.Errorf("Ошибка сборки видео из снимков: %v", err)
+			return nil, fmt.Errorf("failed to encode image sequence: %w", err)
 		}
+		log.Infof("Из %d снимков собрано видео: %d байт", len(metadata.images), len(videoData))
+	}
 
-		// Записываем в форму
-		_, err = part.Write(videoData)
+	// Длинное видео анализируется по частям, если деление на части включено.
+	// Если видео не удалось разделить, оно анализируется целиком.
+	var (
+		result             *AnalysisResult
+		annotatedVideoData []byte
+		chunks             []videochunk.Chunk
+		err                error
+	)
+	if s.chunker != nil && s.local == nil && len(videoData) > 0 && len(metadata.images) == 0 {
+		rec.StartStage("video_split")
+		chunks, err = s.chunker.Split(context.Background(), videoData)
+		rec.EndStage("video_split", err != nil)
 		if err != nil {
-			s.logger.Errorf("Ошибка записи видео данных: %v", err)
-			return nil, fmt.Errorf("failed to write video data: %w", err)
+			log.Warnf("Не удалось разделить видео на части, видео анализируется целиком: %v", err)
+			chunks = nil
 		}
 	}
-
-	writer.Close()
-
-	// Отправляем запрос к Python сервису используя endpoint который возвращает ZIP
-	url := fmt.Sprintf("%s/analyze-road-marking", s.pythonServiceURL)
-	req, err := http.NewRequest("POST", url, &body)
-	if err != nil {
-		s.logger.Errorf("Ошибка создания HTTP запроса: %v", err)
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if len(chunks) > 1 {
+		result, annotatedVideoData, err = s.analyzeChunks(startLat, startLon, endLat, endLon, segmentLength, metadata.AnalysisParams, metadata.Timeout, chunks, videoFilename, routeID, log, rec)
+	} else if len(metadata.images) > 0 {
+		result, annotatedVideoData, err = s.analyzeImages(startLat, startLon, endLat, endLon, segmentLength, metadata.images, metadata.AnalysisParams, s.analysisTimeout(len(videoData), 0, metadata.Timeout), videoData, videoFilename, routeID, log, rec)
+	} else {
+		result, annotatedVideoData, err = s.analyzeVideo(startLat, startLon, endLat, endLon, segmentLength, metadata.AnalysisParams, s.analysisTimeout(len(videoData), 0, metadata.Timeout), videoData, videoFilename, routeID, log, rec)
 	}
-
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	s.logger.Infof("Отправляем запрос к Python сервису: %s", url)
-	resp, err := s.client.Do(req)
 	if err != nil {
-		s.logger.Errorf("Ошибка отправки запроса: %v", err)
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		s.logger.Errorf("Python сервис вернул ошибку %d: %s", resp.StatusCode, string(bodyBytes))
-		return nil, fmt.Errorf("python service returned error %d: %s", resp.StatusCode, string(bodyBytes))
+	s.flagLowConfidence(result)
+	if result.OverallStats.LowConfidenceSegments > 0 {
+		log.Warnf("Низкая уверенность модели на %d сегментах из %d",
+			result.OverallStats.LowConfidenceSegments, result.OverallStats.TotalSegments)
 	}
+	s.scoreQuality(result)
 
-	// Читаем ZIP архив
-	zipData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		s.logger.Errorf("Ошибка чтения ZIP архива: %v", err)
-		return nil, fmt.Errorf("failed to read ZIP archive: %w", err)
+	// Привязываем сегменты к дорогам. Ошибка привязки не прерывает анализ:
+	// исходные координаты сохраняются в любом случае.
+	if s.matcher != nil {
+		rec.StartStage("map_matching")
+		err = s.matchSegments(result)
+		rec.EndStage("map_matching", err != nil)
+		if err != nil {
+			log.Warnf("Не удалось привязать сегменты к дорожному графу: %v", err)
+		} else {
+			log.Infof("Сегменты привязаны к дорожному графу, точек трека: %d", len(result.MatchedGeometry))
+		}
 	}
 
-	s.logger.Infof("Получен ZIP архив размером %d байт", len(zipData))
-
-	// Обрабатываем ZIP архив
-	result, annotatedVideoData, err := s.processZipArchive(zipData, startLat, startLon, endLat, endLon, segmentLength)
-	if err != nil {
-		s.logger.Errorf("Ошибка обработки ZIP архива: %v", err)
-		return nil, fmt.Errorf("failed to process ZIP archive: %w", err)
+	// Определяем названия дорог. Ошибка геокодирования не прерывает анализ.
+	if s.geocoder != nil {
+		rec.StartStage("geocoding")
+		err = s.geocodeRoute(result, log)
+		rec.EndStage("geocoding", err != nil)
+		if err != nil {
+			log.Warnf("Не удалось определить название дороги: %v", err)
+		} else if result.RoadName != "" {
+			log.Infof("Маршрут проходит по дороге: %s", result.RoadName)
+		}
 	}
 
 	// Сохраняем аннотированное видео
+	var annotatedVideoPath string
 	if annotatedVideoData != nil && len(annotatedVideoData) > 0 {
-		annotatedVideoPath := fmt.Sprintf("static/annotated_%s_%s", routeID, videoFilename)
+		annotatedVideoPath = s.AnnotatedVideoPath(routeID, videoFilename)
 		err = s.saveAnnotatedVideo(annotatedVideoPath, annotatedVideoData)
 		if err != nil {
-			s.logger.Errorf("Ошибка сохранения аннотированного видео: %v", err)
+			log.Errorf("Ошибка сохранения аннотированного видео: %v", err)
+			annotatedVideoPath = ""
 		} else {
-			s.logger.Infof("Аннотированное видео сохранено: %s", annotatedVideoPath)
+			log.Infof("Аннотированное видео сохранено: %s", annotatedVideoPath)
 		}
 	}
 
-	s.logger.Infof("Анализ завершен. Найдено %d сегментов, средний покрытие: %.2f%%",
+	log.Infof("Анализ завершен. Найдено %d сегментов, средний покрытие: %.2f%%",
 		result.OverallStats.TotalSegments, result.OverallStats.AverageCoverage)
 
+	// Полосы покрытия попадают в событие о завершении и уведомления
+	s.routeService.applyCoverageBands(result.Segments, &result.OverallStats)
+
+	// Событие о завершении сохраняется в одной транзакции с маршрутом,
+	// а если маршрут не сохранен — отдельно
+	event := s.analysisEvent(routeID, videoFilename, metadata, result, nil)
+	saved := false
+
 	// Сохраняем результат в базе данных
 	if s.routeService != nil && len(videoData) > 0 {
-		s.logger.Infof("Начинаем сохранение маршрута в БД. Размер видео: %d байт", len(videoData))
+		log.Infof("Начинаем сохранение маршрута в БД. Размер видео: %d байт", len(videoData))
 		videoReader := bytes.NewReader(videoData)
-		err = s.routeService.SaveRoute(routeID, videoFilename, videoReader, result)
+		var events []*model.OutboxEvent
+		if event != nil {
+			events = append(events, event)
+		}
+		rec.StartStage("db_save")
+		err = s.routeService.SaveRoute(routeID, videoFilename, videoReader, result, metadata, events...)
+		rec.EndStage("db_save", err != nil)
 		if err != nil {
-			s.logger.Errorf("Ошибка сохранения маршрута в БД: %v", err)
+			log.Errorf("Ошибка сохранения маршрута в БД: %v", err)
 			// Не возвращаем ошибку, так как анализ прошел успешно
-			s.logger.Warnf("Анализ выполнен, но данные не сохранены в БД")
+			log.Warnf("Анализ выполнен, но данные не сохранены в БД")
+			if s.unsaved != nil {
+				if id, saveErr := s.unsaved.Save(routeID, videoFilename, videoData, result, metadata, err); saveErr != nil {
+					log.Errorf("Не удалось сохранить результат анализа для повторного сохранения: %v", saveErr)
+				} else {
+					log.Warnf("Результат анализа сохранен как несохраненный %s, его можно сохранить повторно без анализа", id)
+					result.UnsavedResultID = id
+				}
+			}
 		} else {
-			s.logger.Infof("Маршрут %s успешно сохранен в базе данных", routeID)
+			saved = true
+			log.Infof("Маршрут %s успешно сохранен в базе данных", routeID)
+			// Теневой анализатор сравнивает сегменты видео, а не трека снимков
+			if len(metadata.images) == 0 {
+				s.shadow.Submit(routeID, videoFilename, videoData, startLat, startLon, endLat, endLon, segmentLength, metadata.AnalysisParams)
+			}
+			if s.alerts != nil {
+				s.alerts.Evaluate(routeID, metadata.OrganizationID, result)
+			}
+			s.notifications.AnalysisCompleted(routeID, metadata, result)
 		}
 	} else {
 		if s.routeService == nil {
-			s.logger.Warn("RouteService не инициализирован - сохранение в БД пропущено")
+			log.Warn("RouteService не инициализирован - сохранение в БД пропущено")
 		}
 		if len(videoData) == 0 {
-			s.logger.Warn("Видео данных нет - сохранение в БД пропущено")
+			log.Warn("Видео данных нет - сохранение в БД пропущено")
+		}
+	}
+	if event != nil {
+		if saved {
+			s.outbox.Notify()
+		} else {
+			s.addEvent(event)
 		}
 	}
 
+	result.RouteID = routeID
+	result.Saved = saved
+	result.Links = analysisLinks(routeID, saved, annotatedVideoPath)
 	return result, nil
 }
 
-// CheckHealth проверяет состояние сервиса
-func (s *AnalyzerService) CheckHealth() error {
-	s.logger.Info("Проверяем состояние Python сервиса")
+// analysisLinks ссылки на маршрут, его видео и аннотированное видео.
+// Ссылки есть, только если маршрут сохранен: видео отдаются с проверкой
+// доступа к маршруту.
+func analysisLinks(routeID string, saved bool, annotatedVideoPath string) *AnalysisLinks {
+	var links AnalysisLinks
+	if saved {
+		links.Route = "/api/v1/routes/" + url.PathEscape(routeID)
+		links.Video = links.Route + "/video"
+		if annotatedVideoPath != "" {
+			links.AnnotatedVideo = links.Video + "/annotated"
+		}
+	}
+	if links == (AnalysisLinks{}) {
+		return nil
+	}
+	return &links
+}
 
-	url := fmt.Sprintf("%s/health", s.pythonServiceURL)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create health check request: %w", err)
+// analyzeVideo анализирует видео выбранным способом и возвращает результат
+// и аннотированное видео
+func (s *AnalyzerService) analyzeVideo(
+	startLat, startLon, endLat, endLon, segmentLength float64,
+	params model.AnalysisParams,
+	timeout time.Duration,
+	videoData []byte,
+	videoFilename string,
+	routeID string,
+	log *logrus.Logger,
+	rec *debugcapture.Recorder,
+) (*AnalysisResult, []byte, error) {
+	// Локальный анализ или потоковый gRPC анализ, если они включены. Если
+	// gRPC сервис недоступен, анализ выполняется через HTTP.
+	var (
+		result             *AnalysisResult
+		annotatedVideoData []byte
+		err                error
+	)
+	rec.SetParam("timeout", timeout.String())
+	log.Infof("Ожидание ответа анализатора: %s", timeout)
+	useHTTP := s.stream == nil && s.local == nil
+	if s.local != nil {
+		rec.SetParam("transport", "onnx")
+		result, err = s.analyzeLocal(startLat, startLon, endLat, endLon, segmentLength, params, timeout, videoData, log, rec)
+	} else if !useHTTP {
+		rec.SetParam("transport", "grpc")
+		result, annotatedVideoData, err = s.analyzeGRPC(startLat, startLon, endLat, endLon, segmentLength, params, timeout, videoData, videoFilename, routeID, log, rec)
+		if err != nil && analyzerStreamFallback(err) {
+			log.Warnf("gRPC сервис анализа недоступен, анализ выполняется через HTTP: %v", err)
+			useHTTP = true
+		}
+	}
+	if useHTTP {
+		rec.SetParam("transport", "http")
+		result, annotatedVideoData, err = s.analyzeHTTP(startLat, startLon, endLat, endLon, segmentLength, params, timeout, videoData, videoFilename, log, rec)
+	}
+	return result, annotatedVideoData, err
+}
+
+// analyzeHTTP отправляет видео Python сервису одним multipart запросом и
+// разбирает ZIP архив с результатами и аннотированным видео
+func (s *AnalyzerService) analyzeHTTP(
+	startLat, startLon, endLat, endLon, segmentLength float64,
+	params model.AnalysisParams,
+	timeout time.Duration,
+	videoData []byte,
+	videoFilename string,
+	log *logrus.Logger,
+	rec *debugcapture.Recorder,
+) (*AnalysisResult, []byte, error) {
+	// Создаем multipart форму для отправки файла и данных
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	// Добавляем координаты в форму в формате, который поддерживает версия
+	// Python сервиса
+	contract := s.contract()
+	rec.SetParam("contract", contract.name)
+	if !params.Empty() && !contract.params {
+		return nil, nil, fmt.Errorf("%w: analyzer API %s does not support analysis parameters", ErrAnalyzerRejected, contract.name)
+	}
+	contract.writeFields(writer, startLat, startLon, endLat, endLon, segmentLength, params)
+
+	if videoData != nil {
+		// Добавляем видео файл в форму
+		part, err := writer.CreateFormFile("video", videoFilename)
+		if err != nil {
+			log.Errorf("Ошибка создания form file: %v", err)
+			return nil, nil, fmt.Errorf("failed to create form file: %w", err)
+		}
+
+		// Записываем в форму
+		_, err = part.Write(videoData)
+		if err != nil {
+			log.Errorf("Ошибка записи видео данных: %v", err)
+			return nil, nil, fmt.Errorf("failed to write video data: %w", err)
+		}
 	}
 
-	resp, err := s.client.Do(req)
+	writer.Close()
+
+	// Отправляем запрос к Python сервису
+	rec.StartStage("python_request")
+	url, resp, release, err := s.postAnalysis(contract.path, body.Bytes(), writer.FormDataContentType(), timeout, log)
 	if err != nil {
-		s.logger.Errorf("Python сервис недоступен: %v", err)
-		return fmt.Errorf("python service unavailable: %w", err)
+		rec.EndStage("python_request", true)
+		rec.SetUpstream(url, nil, nil)
+		return nil, nil, err
 	}
+	defer release()
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		s.logger.Errorf("Python сервис вернул статус %d", resp.StatusCode)
-		return fmt.Errorf("python service returned status %d", resp.StatusCode)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		rec.EndStage("python_request", true)
+		rec.SetUpstream(url, resp, bodyBytes)
+		log.Errorf("Python сервис вернул ошибку %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, nil, analyzerStatusError(resp.StatusCode, bodyBytes)
 	}
 
-	s.logger.Info("Python сервис работает нормально")
+	// Читаем ответ
+	respData, err := io.ReadAll(resp.Body)
+	rec.EndStage("python_request", err != nil)
+	rec.SetUpstream(url, resp, nil)
+	if err != nil {
+		log.Errorf("Ошибка чтения ответа Python сервиса: %v", err)
+		return nil, nil, fmt.Errorf("%w: failed to read response: %v", ErrAnalyzerUnavailable, err)
+	}
+
+	// Обрабатываем ответ
+	rec.StartStage("response_processing")
+	result, annotatedVideoData, err := contract.parse(s, respData, startLat, startLon, endLat, endLon, segmentLength)
+	rec.EndStage("response_processing", err != nil)
+	if err != nil {
+		// Сохраняем начало ответа, чтобы было видно, что именно вернул сервис
+		rec.SetUpstream(url, resp, respData)
+		log.Errorf("Ошибка обработки ответа Python сервиса: %v", err)
+		return nil, nil, fmt.Errorf("%w: failed to process response: %v", ErrAnalyzerBadResponse, err)
+	}
+	return result, annotatedVideoData, nil
+}
+
+// postAnalysis отправляет запрос анализа экземпляру Python сервиса. Если
+// экземпляр не принимает соединения, он отмечается нездоровым и запрос
+// отправляется следующему. release освобождает экземпляр после чтения ответа.
+func (s *AnalyzerService) postAnalysis(path string, body []byte, contentType string, timeout time.Duration, log *logrus.Logger) (string, *http.Response, func(), error) {
+	client := *s.httpClient()
+	client.Timeout = timeout
+	var (
+		tried   []*analyzerpool.Instance
+		url     string
+		sendErr error
+	)
+	for {
+		instance, release := s.instances.Acquire(tried...)
+		if instance == nil {
+			return url, nil, nil, sendErr
+		}
+
+		url = instance.URL + path
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			release()
+			log.Errorf("Ошибка создания HTTP запроса: %v", err)
+			return url, nil, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", contentType)
+
+		log.Infof("Отправляем запрос к Python сервису: %s", url)
+		resp, err := client.Do(req)
+		if err == nil {
+			return url, resp, release, nil
+		}
+		release()
+		log.Errorf("Ошибка отправки запроса: %v", err)
+		sendErr = fmt.Errorf("%w: failed to send request: %v", ErrAnalyzerUnavailable, err)
+		if !dialFailed(err) {
+			return url, nil, nil, sendErr
+		}
+		s.instances.MarkDown(instance, err)
+		tried = append(tried, instance)
+	}
+}
+
+// dialFailed проверяет, что соединение с экземпляром не установлено и
+// запрос можно отправить другому экземпляру
+func dialFailed(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// analyzerStatusError возвращает ошибку для ответа Python сервиса со статусом
+// status: 4xx означает, что сервис отклонил входные данные, остальные статусы —
+// что сервис не смог их обработать
+func analyzerStatusError(status int, body []byte) error {
+	detail := strings.TrimSpace(string(body))
+	// FastAPI возвращает причину в поле detail
+	var parsed struct {
+		Detail string `json:"detail"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Detail != "" {
+		detail = parsed.Detail
+	}
+	if runes := []rune(detail); len(runes) > maxAnalyzerErrorBody {
+		detail = string(runes[:maxAnalyzerErrorBody]) + "..."
+	}
+
+	if status >= 400 && status < 500 {
+		return fmt.Errorf("%w: status %d: %s", ErrAnalyzerRejected, status, detail)
+	}
+	return fmt.Errorf("%w: status %d: %s", ErrAnalyzerUnavailable, status, detail)
+}
+
+// matchSegments привязывает начала и концы сегментов к дорожному графу
+// и сохраняет результат в result
+func (s *AnalyzerService) matchSegments(result *AnalysisResult) error {
+	if len(result.Segments) == 0 {
+		return nil
+	}
+
+	// Соседние сегменты обычно имеют общую точку, ее отправляем один раз
+	points := make([]models.Coordinates, 0, len(result.Segments)+1)
+	startIdx := make([]int, len(result.Segments))
+	endIdx := make([]int, len(result.Segments))
+	for i, seg := range result.Segments {
+		start := models.Coordinates{Lat: seg.StartCoordinate.Lat, Lon: seg.StartCoordinate.Lon}
+		if len(points) == 0 || points[len(points)-1] != start {
+			points = append(points, start)
+		}
+		startIdx[i] = len(points) - 1
+		points = append(points, models.Coordinates{Lat: seg.EndCoordinate.Lat, Lon: seg.EndCoordinate.Lon})
+		endIdx[i] = len(points) - 1
+	}
+
+	matched, err := s.matcher.Match(points)
+	if err != nil {
+		return err
+	}
+
+	for i := range result.Segments {
+		if p := matched.Points[startIdx[i]]; p != nil {
+			result.Segments[i].MatchedStartCoordinate = &Coordinates{Lat: p.Lat, Lon: p.Lon}
+		}
+		if p := matched.Points[endIdx[i]]; p != nil {
+			result.Segments[i].MatchedEndCoordinate = &Coordinates{Lat: p.Lat, Lon: p.Lon}
+		}
+	}
+	result.MatchedGeometry = fromModelCoordinates(matched.Geometry)
 	return nil
 }
 
+// geocodeRoute определяет название дороги для маршрута по середине
+// центрального сегмента и, если включено, для каждого сегмента
+func (s *AnalyzerService) geocodeRoute(result *AnalysisResult, log *logrus.Logger) error {
+	if len(result.Segments) == 0 {
+		return nil
+	}
+
+	name, err := s.geocoder.RoadName(segmentMidpoint(result.Segments[len(result.Segments)/2]))
+	if err != nil && !errors.Is(err, geocode.ErrNotFound) {
+		return err
+	}
+	result.RoadName = name
+
+	if !s.geocodeSegments {
+		return nil
+	}
+	for i := range result.Segments {
+		name, err := s.geocoder.RoadName(segmentMidpoint(result.Segments[i]))
+		if err != nil {
+			if !errors.Is(err, geocode.ErrNotFound) {
+				log.Warnf("Не удалось определить название дороги для сегмента %d: %v", result.Segments[i].SegmentID, err)
+			}
+			continue
+		}
+		result.Segments[i].RoadName = name
+	}
+	return nil
+}
+
+// segmentMidpoint возвращает середину сегмента, предпочитая координаты,
+// привязанные к дорожному графу
+func segmentMidpoint(seg SegmentInfo) models.Coordinates {
+	start, end := seg.StartCoordinate, seg.EndCoordinate
+	if seg.MatchedStartCoordinate != nil && seg.MatchedEndCoordinate != nil {
+		start, end = *seg.MatchedStartCoordinate, *seg.MatchedEndCoordinate
+	}
+	return models.Coordinates{Lat: (start.Lat + end.Lat) / 2, Lon: (start.Lon + end.Lon) / 2}
+}
+
+// SetSlowAnalysisThreshold задает длительность анализа, после которой он
+// записывается в лог как медленный. 0 отключает проверку.
+func (s *AnalyzerService) SetSlowAnalysisThreshold(threshold time.Duration) {
+	s.stats.setSlowThreshold(threshold)
+}
+
+// logSlowAnalysis пишет в лог медленный анализ с его параметрами
+func (s *AnalyzerService) logSlowAnalysis(routeID, videoFilename string, rec *debugcapture.Recorder, duration, threshold time.Duration, err error) {
+	fields := logrus.Fields{
+		"route_id":       routeID,
+		"video_filename": videoFilename,
+		"duration_sec":   duration.Seconds(),
+		"threshold_sec":  threshold.Seconds(),
+	}
+	for name, value := range rec.Params() {
+		fields[name] = value
+	}
+	for _, timing := range rec.Timings() {
+		fields["stage_"+timing.Stage+"_ms"] = timing.DurationMs
+	}
+	entry := s.logger.WithFields(fields)
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	entry.Warn("Медленный анализ видео")
+}
+
+// Stats возвращает количество анализов с момента запуска сервиса
+// и перцентили длительности последних успешных анализов
+func (s *AnalyzerService) Stats() AnalyzerStats {
+	return s.stats.snapshot()
+}
+
+// AnnotatedVideoPath возвращает путь, по которому сохраняется аннотированное видео маршрута
+func (s *AnalyzerService) AnnotatedVideoPath(routeID, videoFilename string) string {
+	return fmt.Sprintf("static/annotated_%s_%s", routeID, videoFilename)
+}
+
+// FetchHealth запрашивает /health Python сервиса и возвращает его ответ
+func (s *AnalyzerService) FetchHealth() (*models.HealthResponse, error) {
+	return s.FetchHealthContext(context.Background())
+}
+
+// FetchHealthContext запрашивает /health Python сервиса, ожидая ответ
+// не дольше, чем позволяет ctx. Если экземпляров несколько, запрос
+// отправляется здоровому.
+func (s *AnalyzerService) FetchHealthContext(ctx context.Context) (*models.HealthResponse, error) {
+	return s.fetchHealth(ctx, s.instances.Pick().URL)
+}
+
+// fetchHealth запрашивает /health экземпляра Python сервиса baseURL
+func (s *AnalyzerService) fetchHealth(ctx context.Context, baseURL string) (*models.HealthResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/health", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("python service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("python service returned status %d", resp.StatusCode)
+	}
+
+	var health models.HealthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to parse python health response: %w", err)
+	}
+
+	return &health, nil
+}
+
+// CheckPythonVersion проверяет, совместима ли версия Python сервиса с этой
+// сборкой, и выбирает по ней формат API анализа
+func (s *AnalyzerService) CheckPythonVersion() PythonVersionStatus {
+	status := PythonVersionStatus{
+		Supported: buildinfo.PythonCompatibility,
+		Contracts: buildinfo.PythonContracts,
+	}
+
+	health, err := s.FetchHealth()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Reachable = true
+	status.Version = health.Version
+
+	// Формат API анализа выбирается по версии: несовместимая версия
+	// формат не меняет
+	if err := s.useContractFor(health.Version); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Compatible = true
+	status.Contract = s.contract().name
+
+	return status
+}
+
+// loggerWithHook создает логгер с теми же настройками и дополнительным hook
+func loggerWithHook(base *logrus.Logger, hook logrus.Hook) *logrus.Logger {
+	hooks := make(logrus.LevelHooks, len(base.Hooks))
+	for level, levelHooks := range base.Hooks {
+		hooks[level] = append([]logrus.Hook(nil), levelHooks...)
+	}
+	hooks.Add(hook)
+
+	return &logrus.Logger{
+		Out:          base.Out,
+		Hooks:        hooks,
+		Formatter:    base.Formatter,
+		ReportCaller: base.ReportCaller,
+		Level:        base.GetLevel(),
+		ExitFunc:     base.ExitFunc,
+	}
+}
+
 // calculateDistance вычисляет расстояние между двумя точками в метрах
 func (s *AnalyzerService) calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	// Формула Haversine для точного вычисления расстояния
@@ -302,6 +1201,15 @@ func (s *AnalyzerService) processZipArchive(zipData []byte, startLat, startLon,
 			FramesCount        int     `json:"frames_count"`
 			CoveragePercentage float64 `json:"coverage_percentage"`
 			HasData            bool    `json:"has_data"`
+			// Defects дефекты покрытия, если Python сервис их распознает
+			Defects []model.SegmentDefect `json:"defects"`
+			// ClassCoverage покрытие по типам разметки, если Python сервис
+			// их различает
+			ClassCoverage map[string]float64 `json:"class_coverage"`
+			// AverageConfidence и MinConfidence уверенность модели на
+			// кадрах сегмента, если Python сервис ее сообщает
+			AverageConfidence *float64 `json:"average_confidence"`
+			MinConfidence     *float64 `json:"min_confidence"`
 		} `json:"segments"`
 		Coordinates struct {
 			Start struct {
@@ -325,41 +1233,87 @@ func (s *AnalyzerService) processZipArchive(zipData []byte, startLat, startLon,
 	// Преобразуем результаты в наш формат
 	segments := make([]SegmentInfo, len(pythonResults.Segments))
 	for i, seg := range pythonResults.Segments {
+		segments[i] = SegmentInfo{
+			FramesCount:        seg.FramesCount,
+			CoveragePercentage: seg.CoveragePercentage,
+			HasData:            seg.HasData,
+			Defects:            seg.Defects,
+			ClassCoverage:      seg.ClassCoverage,
+			AverageConfidence:  seg.AverageConfidence,
+			MinConfidence:      seg.MinConfidence,
+		}
+	}
+	result := newAnalysisResult(startLat, startLon, endLat, endLon, segmentLength, OverallStats{
+		TotalFrames:         pythonResults.OverallStats.TotalFrames,
+		TotalDistanceMeters: pythonResults.OverallStats.TotalDistanceMeters,
+		TotalSegments:       pythonResults.OverallStats.TotalSegments,
+		SegmentsWithData:    pythonResults.OverallStats.SegmentsWithData,
+		AverageCoverage:     pythonResults.OverallStats.AverageCoverage,
+	}, segments)
+
+	return result, videoData, nil
+}
+
+// newAnalysisResult собирает результат анализа из статистики и сегментов
+// Python сервиса. Сегменты нумеруются по порядку, их координаты
+// интерполируются между начальной и конечной точками маршрута, дефекты
+// покрытия нормализуются и подсчитываются. Покрытие маршрута по типам
+// разметки и уверенность модели — средние по сегментам с данными.
+// Признак низкой уверенности выставляет flagLowConfidence.
+func newAnalysisResult(startLat, startLon, endLat, endLon, segmentLength float64, stats OverallStats, segments []SegmentInfo) *AnalysisResult {
+	stats.TotalDefects, stats.SegmentsWithDefects = 0, 0
+	var classCoverage []map[string]float64
+	var confidence []float64
+	for i := range segments {
+		segments[i].ClassCoverage = model.NormalizeClassCoverage(segments[i].ClassCoverage)
+		if segments[i].HasData && segments[i].ClassCoverage != nil {
+			classCoverage = append(classCoverage, segments[i].ClassCoverage)
+		}
+		segments[i].AverageConfidence = normalizeConfidence(segments[i].AverageConfidence)
+		segments[i].MinConfidence = normalizeConfidence(segments[i].MinConfidence)
+		if segments[i].HasData && segments[i].AverageConfidence != nil {
+			confidence = append(confidence, *segments[i].AverageConfidence)
+		}
+		segments[i].Defects = model.NormalizeDefects(segments[i].Defects)
+		segments[i].DefectsCount = model.CountDefects(segments[i].Defects)
+		if segments[i].DefectsCount > 0 {
+			stats.TotalDefects += segments[i].DefectsCount
+			stats.SegmentsWithDefects++
+		}
+
 		// Интерполируем координаты сегмента
-		progress := float64(i) / float64(len(pythonResults.Segments))
-		if len(pythonResults.Segments) == 1 {
+		progress := float64(i) / float64(len(segments))
+		if len(segments) == 1 {
 			progress = 0.5
 		}
 
 		startSegLat := startLat + (endLat-startLat)*progress
 		startSegLon := startLon + (endLon-startLon)*progress
 
-		endProgress := float64(i+1) / float64(len(pythonResults.Segments))
-		if i == len(pythonResults.Segments)-1 {
+		endProgress := float64(i+1) / float64(len(segments))
+		if i == len(segments)-1 {
 			endProgress = 1.0
 		}
 
 		endSegLat := startLat + (endLat-startLat)*endProgress
 		endSegLon := startLon + (endLon-startLon)*endProgress
 
-		segments[i] = SegmentInfo{
-			SegmentID:          i,
-			FramesCount:        seg.FramesCount,
-			CoveragePercentage: seg.CoveragePercentage,
-			HasData:            seg.HasData,
-			StartCoordinate: Coordinates{
-				Lat: startSegLat,
-				Lon: startSegLon,
-			},
-			EndCoordinate: Coordinates{
-				Lat: endSegLat,
-				Lon: endSegLon,
-			},
+		segments[i].SegmentID = i
+		segments[i].StartCoordinate = Coordinates{
+			Lat: startSegLat,
+			Lon: startSegLon,
+		}
+		segments[i].EndCoordinate = Coordinates{
+			Lat: endSegLat,
+			Lon: endSegLon,
 		}
 	}
+	stats.SegmentLengthMeters = segmentLength
+	stats.ClassCoverage = model.AverageClassCoverage(classCoverage)
+	stats.AverageConfidence = averageConfidence(confidence)
 
 	// Создаем финальный результат
-	result := &AnalysisResult{
+	return &AnalysisResult{
 		StartPoint: Coordinates{
 			Lat: startLat,
 			Lon: startLon,
@@ -370,17 +1324,8 @@ func (s *AnalyzerService) processZipArchive(zipData []byte, startLat, startLon,
 		},
 		SegmentLength: segmentLength,
 		Segments:      segments,
-		OverallStats: OverallStats{
-			TotalFrames:         pythonResults.OverallStats.TotalFrames,
-			TotalDistanceMeters: pythonResults.OverallStats.TotalDistanceMeters,
-			SegmentLengthMeters: segmentLength,
-			TotalSegments:       pythonResults.OverallStats.TotalSegments,
-			SegmentsWithData:    pythonResults.OverallStats.SegmentsWithData,
-			AverageCoverage:     pythonResults.OverallStats.AverageCoverage,
-		},
+		OverallStats:  stats,
 	}
-
-	return result, videoData, nil
 }
 
 // saveAnnotatedVideo сохраняет аннотированное видео на диск
@@ -391,9 +1336,15 @@ func (s *AnalyzerService) saveAnnotatedVideo(filePath string, videoData []byte)
 		return fmt.Errorf("failed to create directory %s: %w", dir, err)
 	}
 
-	// Записываем файл
-	err := os.WriteFile(filePath, videoData, 0644)
-	if err != nil {
+	// Записываем во временный файл и переименовываем, чтобы при остановке
+	// сервиса посреди записи не оставить недописанное видео
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, videoData, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write video file %s: %w", filePath, err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to write video file %s: %w", filePath, err)
 	}
 
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/videochunk"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SetVideoChunking включает анализ длинных видео по частям: части
+// анализируются параллельно, не больше parallelism одновременно, и
+// распределяются между экземплярами Python сервиса
+func (s *AnalyzerService) SetVideoChunking(chunker *videochunk.Splitter, parallelism int) {
+	s.chunker = chunker
+	s.chunkParallelism = max(1, parallelism)
+}
+
+// analyzeChunks анализирует части видео и объединяет их результаты в один
+// маршрут. Часть маршрута, которая приходится на часть видео, считается по
+// ее времени, как при движении с постоянной скоростью. Анализ завершается
+// ошибкой, если не удалось проанализировать хотя бы одну часть.
+func (s *AnalyzerService) analyzeChunks(
+	startLat, startLon, endLat, endLon, segmentLength float64,
+	params model.AnalysisParams,
+	requested time.Duration,
+	chunks []videochunk.Chunk,
+	videoFilename string,
+	routeID string,
+	log *logrus.Logger,
+	rec *debugcapture.Recorder,
+) (*AnalysisResult, []byte, error) {
+	total := chunks[len(chunks)-1].End
+	rec.SetParam("chunks", strconv.Itoa(len(chunks)))
+	log.Infof("Видео длительностью %.0f с разделено на %d частей, анализ по %d параллельно",
+		total, len(chunks), s.chunkParallelism)
+
+	var (
+		wg        sync.WaitGroup
+		slots     = make(chan struct{}, s.chunkParallelism)
+		results   = make([]*AnalysisResult, len(chunks))
+		annotated = make([][]byte, len(chunks))
+		errs      = make([]error, len(chunks))
+		recs      = make([]*debugcapture.Recorder, len(chunks))
+	)
+	ext := filepath.Ext(videoFilename)
+	base := strings.TrimSuffix(videoFilename, ext)
+	for i, chunk := range chunks {
+		// Этапы частей записываются отдельно, чтобы одинаковые этапы
+		// параллельных частей не смешивались
+		recs[i] = debugcapture.NewRecorder(routeID)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			from, to := chunk.Start/total, chunk.End/total
+			stage := fmt.Sprintf("chunk_%d", chunk.Index)
+			rec.StartStage(stage)
+			results[i], annotated[i], errs[i] = s.analyzeVideo(
+				startLat+(endLat-startLat)*from, startLon+(endLon-startLon)*from,
+				startLat+(endLat-startLat)*to, startLon+(endLon-startLon)*to,
+				segmentLength, params, s.analysisTimeout(len(chunk.Data), chunk.End-chunk.Start, requested), chunk.Data,
+				fmt.Sprintf("%s_part%03d.mp4", base, chunk.Index), routeID, log, recs[i])
+			rec.EndStage(stage, errs[i] != nil)
+		}()
+	}
+	wg.Wait()
+
+	// Способ анализа одинаков для всех частей
+	for _, key := range []string{"transport", "contract"} {
+		if value, ok := recs[0].Params()[key]; ok {
+			rec.SetParam(key, value)
+		}
+	}
+	for i, err := range errs {
+		if err != nil {
+			log.Errorf("Ошибка анализа части %d видео: %v", i, err)
+			return nil, nil, fmt.Errorf("chunk %d (%.0f-%.0f s): %w", i, chunks[i].Start, chunks[i].End, err)
+		}
+	}
+
+	result := s.mergeChunkResults(startLat, startLon, endLat, endLon, segmentLength, chunks, results)
+	log.Infof("Результаты %d частей объединены: кадров %d, сегментов %d",
+		len(chunks), result.OverallStats.TotalFrames, result.OverallStats.TotalSegments)
+
+	return result, s.concatAnnotated(annotated, log, rec), nil
+}
+
+// mergeChunkResults объединяет результаты частей видео. Сегменты частей
+// делят часть поровну; кадры сегмента части распределяются между
+// сегментами маршрута пропорционально тому, какая доля сегмента части на
+// них приходится. Покрытие сегмента маршрута — среднее покрытие
+// попавших в него сегментов частей, взвешенное по числу кадров.
+// Одинаковые дефекты соседних частей объединяет newAnalysisResult.
+func (s *AnalyzerService) mergeChunkResults(startLat, startLon, endLat, endLon, segmentLength float64, chunks []videochunk.Chunk, results []*AnalysisResult) *AnalysisResult {
+	total := chunks[len(chunks)-1].End
+	distance := s.calculateDistance(startLat, startLon, endLat, endLon)
+	count := 1
+	if segmentLength > 0 {
+		count = max(1, int(math.Ceil(distance/segmentLength)))
+	}
+
+	segments := make([]SegmentInfo, count)
+	frames := make([]float64, count)
+	weights := make([]float64, count)
+	covered := make([]float64, count)
+	// Покрытие по типам разметки считается так же, как общее, но только по
+	// сегментам частей, для которых тип задан
+	classCovered := make([]map[string]float64, count)
+	classWeights := make([]map[string]float64, count)
+	// Уверенность усредняется по сегментам частей, для которых она известна
+	confident := make([]float64, count)
+	confidenceWeights := make([]float64, count)
+	minConfidence := make([]*float64, count)
+	stats := OverallStats{
+		TotalDistanceMeters: distance,
+		TotalSegments:       count,
+	}
+	for i, result := range results {
+		stats.TotalFrames += result.OverallStats.TotalFrames
+		from, to := chunks[i].Start/total, chunks[i].End/total
+		n := float64(len(result.Segments))
+		for j, seg := range result.Segments {
+			// Границы сегмента части в долях маршрута
+			a := from + (to-from)*float64(j)/n
+			b := from + (to-from)*float64(j+1)/n
+			// Дефекты не делятся и относятся к сегменту маршрута, на который
+			// приходится середина сегмента части
+			if len(seg.Defects) > 0 {
+				k := min(count-1, int((a+b)/2*float64(count)))
+				segments[k].Defects = append(segments[k].Defects, seg.Defects...)
+			}
+			if !seg.HasData {
+				continue
+			}
+			// Сегмент с данными, но без числа кадров, учитывается как один кадр
+			weight := float64(max(seg.FramesCount, 1))
+			for k := max(0, int(a*float64(count))); k < count && float64(k)/float64(count) < b; k++ {
+				overlap := math.Min(b, float64(k+1)/float64(count)) - math.Max(a, float64(k)/float64(count))
+				if overlap <= 0 {
+					continue
+				}
+				share := overlap / (b - a)
+				frames[k] += float64(seg.FramesCount) * share
+				weights[k] += weight * share
+				covered[k] += weight * share * seg.CoveragePercentage
+				if seg.AverageConfidence != nil {
+					confident[k] += weight * share * *seg.AverageConfidence
+					confidenceWeights[k] += weight * share
+				}
+				if seg.MinConfidence != nil && (minConfidence[k] == nil || *seg.MinConfidence < *minConfidence[k]) {
+					minConfidence[k] = seg.MinConfidence
+				}
+				for markingType, value := range seg.ClassCoverage {
+					if classCovered[k] == nil {
+						classCovered[k] = make(map[string]float64)
+						classWeights[k] = make(map[string]float64)
+					}
+					classCovered[k][markingType] += weight * share * value
+					classWeights[k][markingType] += weight * share
+				}
+			}
+		}
+	}
+
+	for i := range segments {
+		if weights[i] == 0 {
+			continue
+		}
+		segments[i].FramesCount = int(math.Round(frames[i]))
+		segments[i].HasData = true
+		segments[i].CoveragePercentage = covered[i] / weights[i]
+		if confidenceWeights[i] > 0 {
+			average := confident[i] / confidenceWeights[i]
+			segments[i].AverageConfidence = &average
+		}
+		segments[i].MinConfidence = minConfidence[i]
+		for markingType, value := range classCovered[i] {
+			if segments[i].ClassCoverage == nil {
+				segments[i].ClassCoverage = make(map[string]float64)
+			}
+			segments[i].ClassCoverage[markingType] = value / classWeights[i][markingType]
+		}
+		stats.SegmentsWithData++
+		stats.AverageCoverage += segments[i].CoveragePercentage
+	}
+	if stats.SegmentsWithData > 0 {
+		stats.AverageCoverage /= float64(stats.SegmentsWithData)
+	}
+	return newAnalysisResult(startLat, startLon, endLat, endLon, segmentLength, stats, segments)
+}
+
+// concatAnnotated склеивает аннотированные видео частей. Возвращает nil,
+// если аннотированного видео нет хотя бы у одной части или склеить не
+// удалось: маршрут сохраняется без аннотированного видео.
+func (s *AnalyzerService) concatAnnotated(clips [][]byte, log *logrus.Logger, rec *debugcapture.Recorder) []byte {
+	missing := 0
+	for _, clip := range clips {
+		if len(clip) == 0 {
+			missing++
+		}
+	}
+	if missing == len(clips) {
+		return nil
+	}
+	if missing > 0 {
+		log.Warnf("Нет аннотированного видео у %d из %d частей, аннотированное видео не сохраняется", missing, len(clips))
+		return nil
+	}
+
+	rec.StartStage("video_concat")
+	video, err := s.chunker.Concat(context.Background(), clips)
+	rec.EndStage("video_concat", err != nil)
+	if err != nil {
+		log.Warnf("Не удалось склеить аннотированное видео частей: %v", err)
+		return nil
+	}
+	return video
+}
@@ -0,0 +1,54 @@
+package service
+
+import "math"
+
+// SetLowConfidenceThreshold задает среднюю уверенность модели, ниже которой
+// результат сегмента отмечается ненадежным. 0 отключает отметку.
+func (s *AnalyzerService) SetLowConfidenceThreshold(threshold float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lowConfidence = threshold
+}
+
+// flagLowConfidence отмечает сегменты с данными, средняя уверенность модели
+// на которых ниже порога, и подсчитывает их. Сегменты без уверенности не
+// отмечаются.
+func (s *AnalyzerService) flagLowConfidence(result *AnalysisResult) {
+	s.mu.RLock()
+	threshold := s.lowConfidence
+	s.mu.RUnlock()
+
+	result.OverallStats.LowConfidenceSegments = 0
+	for i := range result.Segments {
+		seg := &result.Segments[i]
+		seg.LowConfidence = threshold > 0 && seg.HasData &&
+			seg.AverageConfidence != nil && *seg.AverageConfidence < threshold
+		if seg.LowConfidence {
+			result.OverallStats.LowConfidenceSegments++
+		}
+	}
+}
+
+// normalizeConfidence ограничивает уверенность диапазоном [0, 1].
+// Нечисловое значение считается отсутствующим.
+func normalizeConfidence(confidence *float64) *float64 {
+	if confidence == nil || math.IsNaN(*confidence) {
+		return nil
+	}
+	value := min(max(*confidence, 0), 1)
+	return &value
+}
+
+// averageConfidence возвращает среднюю уверенность, nil — уверенность
+// неизвестна ни для одного сегмента
+func averageConfidence(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	average := sum / float64(len(values))
+	return &average
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"strconv"
+
+	"road-detector-go/internal/buildinfo"
+	"road-detector-go/internal/model"
+	"road-detector-go/pkg/models"
+)
+
+// ErrAnalyzerUnsupported версия Python сервиса не поддерживается этой сборкой
+var ErrAnalyzerUnsupported = errors.New("unsupported analyzer version")
+
+// analyzerContract запрос и ответ анализа в одном из форматов API Python
+// сервиса
+type analyzerContract struct {
+	name string
+	// path путь запроса анализа
+	path string
+	// params формат принимает дополнительные параметры анализа
+	params bool
+	// writeFields записывает параметры анализа в форму
+	writeFields func(w *multipart.Writer, startLat, startLon, endLat, endLon, segmentLength float64, params model.AnalysisParams)
+	// parse разбирает тело ответа в результат анализа и аннотированное видео
+	parse func(s *AnalyzerService, body []byte, startLat, startLon, endLat, endLon, segmentLength float64) (*AnalysisResult, []byte, error)
+}
+
+// analyzerContracts реализации форматов из buildinfo.PythonContracts
+var analyzerContracts = map[string]*analyzerContract{
+	buildinfo.PythonContractJSON: {
+		name: buildinfo.PythonContractJSON,
+		path: "/analyze",
+		writeFields: func(w *multipart.Writer, startLat, startLon, endLat, endLon, segmentLength float64, _ model.AnalysisParams) {
+			w.WriteField("startLat", fmt.Sprintf("%.6f", startLat))
+			w.WriteField("startLon", fmt.Sprintf("%.6f", startLon))
+			w.WriteField("endLat", fmt.Sprintf("%.6f", endLat))
+			w.WriteField("endLon", fmt.Sprintf("%.6f", endLon))
+			w.WriteField("segmentLength", fmt.Sprintf("%.0f", segmentLength))
+		},
+		parse: func(s *AnalyzerService, body []byte, startLat, startLon, endLat, endLon, segmentLength float64) (*AnalysisResult, []byte, error) {
+			var resp models.PythonAPIResponse
+			if err := json.Unmarshal(body, &resp); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse analysis response: %w", err)
+			}
+			return s.frameResultsToAnalysis(resp.FrameResults, startLat, startLon, endLat, endLon, segmentLength), nil, nil
+		},
+	},
+	buildinfo.PythonContractZIP: {
+		name:   buildinfo.PythonContractZIP,
+		path:   "/analyze-road-marking",
+		params: true,
+		writeFields: func(w *multipart.Writer, startLat, startLon, endLat, endLon, segmentLength float64, params model.AnalysisParams) {
+			// Названия полей, которые ожидает Python сервис /analyze-road-marking
+			w.WriteField("lat1", fmt.Sprintf("%.6f", startLat))
+			w.WriteField("lon1", fmt.Sprintf("%.6f", startLon))
+			w.WriteField("lat2", fmt.Sprintf("%.6f", endLat))
+			w.WriteField("lon2", fmt.Sprintf("%.6f", endLon))
+			w.WriteField("segment_length_m", fmt.Sprintf("%.0f", segmentLength))
+			writeAnalysisParams(w, params)
+		},
+		parse: func(s *AnalyzerService, body []byte, startLat, startLon, endLat, endLon, segmentLength float64) (*AnalysisResult, []byte, error) {
+			s.logger.Infof("Получен ZIP архив размером %d байт", len(body))
+			return s.processZipArchive(body, startLat, startLon, endLat, endLon, segmentLength)
+		},
+	},
+}
+
+// writeAnalysisParams записывает в форму заданные параметры анализа
+func writeAnalysisParams(w *multipart.Writer, params model.AnalysisParams) {
+	if params.FrameSampleRate > 0 {
+		w.WriteField("frame_sample_rate", strconv.FormatFloat(params.FrameSampleRate, 'f', -1, 64))
+	}
+	if params.ConfidenceThreshold > 0 {
+		w.WriteField("confidence_threshold", strconv.FormatFloat(params.ConfidenceThreshold, 'f', -1, 64))
+	}
+	if params.ModelVariant != "" {
+		w.WriteField("model_variant", params.ModelVariant)
+	}
+	if roi := params.ROI; roi != nil {
+		w.WriteField("roi", FormatROI(*roi))
+	}
+}
+
+// defaultAnalyzerContract формат, который используется, пока версия Python
+// сервиса неизвестна
+var defaultAnalyzerContract = analyzerContracts[buildinfo.PythonContractZIP]
+
+// contract возвращает формат API анализа, выбранный по версии Python сервиса
+func (s *AnalyzerService) contract() *analyzerContract {
+	if c := s.currentContract.Load(); c != nil {
+		return c
+	}
+	return defaultAnalyzerContract
+}
+
+// useContractFor выбирает формат API анализа для версии Python сервиса.
+// Возвращает ErrAnalyzerUnsupported, если версия не поддерживается, формат
+// в этом случае не меняется.
+func (s *AnalyzerService) useContractFor(version string) error {
+	pc, ok, err := buildinfo.PythonContractFor(version)
+	if err != nil {
+		return fmt.Errorf("%w: failed to parse version %q: %v", ErrAnalyzerUnsupported, version, err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: version %q, supported %s", ErrAnalyzerUnsupported, version, buildinfo.PythonCompatibility)
+	}
+
+	c := analyzerContracts[pc.Name]
+	if prev := s.currentContract.Swap(c); prev != c {
+		s.logger.Infof("Формат API анализа Python сервиса %s: %s (версия %s)", c.name, c.path, version)
+	}
+	return nil
+}
+
+// frameResultsToAnalysis собирает результат анализа из результатов кадров
+// (1 — разметка есть, 0 — нет). Кадры распределяются по сегментам поровну,
+// как при движении с постоянной скоростью.
+func (s *AnalyzerService) frameResultsToAnalysis(frames []int, startLat, startLon, endLat, endLon, segmentLength float64) *AnalysisResult {
+	distance := s.calculateDistance(startLat, startLon, endLat, endLon)
+	count := 1
+	if segmentLength > 0 {
+		count = max(1, int(math.Ceil(distance/segmentLength)))
+	}
+
+	segments := make([]SegmentInfo, count)
+	marked := make([]int, count)
+	for i, frame := range frames {
+		seg := i * count / len(frames)
+		segments[seg].FramesCount++
+		if frame != 0 {
+			marked[seg]++
+		}
+	}
+
+	stats := OverallStats{
+		TotalFrames:         len(frames),
+		TotalDistanceMeters: distance,
+		TotalSegments:       count,
+	}
+	for i := range segments {
+		if segments[i].FramesCount == 0 {
+			continue
+		}
+		segments[i].HasData = true
+		segments[i].CoveragePercentage = float64(marked[i]) * 100 / float64(segments[i].FramesCount)
+		stats.SegmentsWithData++
+		stats.AverageCoverage += segments[i].CoveragePercentage
+	}
+	if stats.SegmentsWithData > 0 {
+		stats.AverageCoverage /= float64(stats.SegmentsWithData)
+	}
+
+	s.logger.Infof("Обработано кадров: %d, сегментов: %d", stats.TotalFrames, stats.TotalSegments)
+	return newAnalysisResult(startLat, startLon, endLat, endLon, segmentLength, stats, segments)
+}
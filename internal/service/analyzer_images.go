@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/imageseq"
+	"road-detector-go/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrImageSequencesDisabled анализ последовательностей снимков не настроен
+var ErrImageSequencesDisabled = errors.New("image sequence analysis is not configured")
+
+// SetImageSequences включает анализ последовательностей снимков
+func (s *AnalyzerService) SetImageSequences(converter *imageseq.Converter) {
+	s.imageSeq = converter
+}
+
+// AnalyzeImageSequence анализирует ZIP архив снимков с координатами. Из
+// снимков собирается видео, которое анализируется как обычно, а результаты
+// кадров распределяются по сегментам по месту съемки каждого снимка.
+// Маршрут начинается в месте первого снимка и заканчивается в месте
+// последнего. При исчерпанной квоте возвращает *QuotaError, в режиме
+// обслуживания — *MaintenanceError.
+func (s *AnalyzerService) AnalyzeImageSequence(
+	archive []byte,
+	archiveFilename string,
+	segmentLength float64,
+	routeID string,
+	metadata RouteMetadata,
+) (*AnalysisResult, error) {
+	// Экземпляру API, который передает анализы воркерам, сборка видео
+	// не нужна, архив проверяет воркер
+	if s.queue != nil && !metadata.queued {
+		return s.enqueueImageSequence(archive, archiveFilename, segmentLength, routeID, metadata)
+	}
+	if s.imageSeq == nil {
+		return nil, ErrImageSequencesDisabled
+	}
+	// Архив не разбирается, если анализ все равно будет отклонен
+	if err := s.checkMaintenance(metadata); err != nil {
+		return nil, err
+	}
+	images, err := s.imageSeq.Parse(archive)
+	if err != nil {
+		return nil, err
+	}
+	if distances := s.imageDistances(images); distances[len(distances)-1] == 0 {
+		return nil, fmt.Errorf("%w: all images are taken at the same place", imageseq.ErrInvalidArchive)
+	}
+
+	// Каждый снимок — один кадр, и анализироваться должен каждый кадр
+	metadata.AnalysisParams.FrameSampleRate = imageseq.FrameRate
+	metadata.images = images
+	first, last := images[0].Position, images[len(images)-1].Position
+	return s.AnalyzeRoadMarking(first.Lat, first.Lon, last.Lat, last.Lon, segmentLength,
+		nil, imageseq.VideoFilename(archiveFilename), routeID, metadata)
+}
+
+// enqueueImageSequence ставит анализ архива снимков в очередь с теми же
+// проверками, что и анализ видео
+func (s *AnalyzerService) enqueueImageSequence(
+	archive []byte,
+	archiveFilename string,
+	segmentLength float64,
+	routeID string,
+	metadata RouteMetadata,
+) (*AnalysisResult, error) {
+	if err := s.checkMaintenance(metadata); err != nil {
+		return nil, err
+	}
+	if err := s.validateTimeout(metadata.Timeout); err != nil {
+		return nil, err
+	}
+	if s.usage != nil {
+		if err := s.usage.CheckQuota(QuotaSubject(metadata.OrganizationID, metadata.OwnerID, metadata.APIKeyID)); err != nil {
+			return nil, err
+		}
+		// Видео из снимков еще не собрано, его размер оценивается по архиву
+		if err := s.usage.CheckStorageQuota(metadata.OrganizationID, metadata.OwnerID, metadata.APIKeyID, int64(len(archive))); err != nil {
+			return nil, err
+		}
+	}
+	s.active.Add(1)
+	defer s.active.Add(-1)
+
+	if routeID == "" {
+		routeID = s.routeService.GenerateRouteID()
+		s.logger.Infof("Сгенерирован новый ID маршрута: %s", routeID)
+	}
+	if err := s.routeService.CheckNewRouteID(routeID, metadata); err != nil {
+		return nil, err
+	}
+	return s.queue.submit(model.AnalysisJobImages, routeID, analysisJobParams{
+		SegmentLength: segmentLength,
+		Metadata:      metadata,
+	}, bytes.NewReader(archive), archiveFilename)
+}
+
+// analyzeImages анализирует видео, собранное из снимков. Анализатор делит
+// прямую между первым и последним снимком на сегменты не длиннее
+// расстояния между снимками, чтобы на каждый снимок пришелся свой сегмент
+// анализатора, а затем эти результаты переносятся на сегменты трека снимков.
+func (s *AnalyzerService) analyzeImages(
+	startLat, startLon, endLat, endLon, segmentLength float64,
+	images []imageseq.Image,
+	params model.AnalysisParams,
+	timeout time.Duration,
+	videoData []byte,
+	videoFilename string,
+	routeID string,
+	log *logrus.Logger,
+	rec *debugcapture.Recorder,
+) (*AnalysisResult, []byte, error) {
+	frameLength := math.Max(1, math.Floor(s.calculateDistance(startLat, startLon, endLat, endLon)/float64(len(images))))
+	frames, annotatedVideoData, err := s.analyzeVideo(startLat, startLon, endLat, endLon, frameLength, params, timeout, videoData, videoFilename, routeID, log, rec)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := s.imagesToAnalysis(frames, images, segmentLength)
+	log.Infof("Результаты %d снимков распределены по %d сегментам трека длиной %.0f м",
+		len(images), result.OverallStats.TotalSegments, result.OverallStats.TotalDistanceMeters)
+	return result, annotatedVideoData, nil
+}
+
+// imageSegment результаты снимков, попавших в один сегмент трека
+type imageSegment struct {
+	images        int
+	coverage      float64
+	classCoverage []map[string]float64
+	confidence    []float64
+	minConfidence *float64
+	defects       []model.SegmentDefect
+	// sources сегменты анализатора, дефекты которых уже учтены
+	sources map[int]bool
+}
+
+// imagesToAnalysis переносит результаты анализатора на сегменты трека
+// снимков. Сегменты анализатора соответствуют кадрам по порядку, как при
+// разборе результатов кадров; снимок попадает в сегмент трека по
+// расстоянию от первого снимка вдоль трека.
+func (s *AnalyzerService) imagesToAnalysis(frames *AnalysisResult, images []imageseq.Image, segmentLength float64) *AnalysisResult {
+	distances := s.imageDistances(images)
+	total := distances[len(distances)-1]
+	count := 1
+	if segmentLength > 0 {
+		count = max(1, int(math.Ceil(total/segmentLength)))
+	}
+
+	acc := make([]imageSegment, count)
+	for i := range images {
+		if len(frames.Segments) == 0 {
+			break
+		}
+		source := i * len(frames.Segments) / len(images)
+		frame := frames.Segments[source]
+		if !frame.HasData {
+			continue
+		}
+		k := 0
+		if segmentLength > 0 {
+			k = min(count-1, int(distances[i]/segmentLength))
+		}
+		seg := &acc[k]
+		seg.images++
+		seg.coverage += frame.CoveragePercentage
+		if frame.ClassCoverage != nil {
+			seg.classCoverage = append(seg.classCoverage, frame.ClassCoverage)
+		}
+		if frame.AverageConfidence != nil {
+			seg.confidence = append(seg.confidence, *frame.AverageConfidence)
+		}
+		if c := frame.MinConfidence; c != nil && (seg.minConfidence == nil || *c < *seg.minConfidence) {
+			seg.minConfidence = c
+		}
+		// Несколько снимков могут прийтись на один сегмент анализатора,
+		// его дефекты учитываются один раз
+		if seg.sources == nil {
+			seg.sources = make(map[int]bool)
+		}
+		if !seg.sources[source] {
+			seg.sources[source] = true
+			seg.defects = append(seg.defects, frame.Defects...)
+		}
+	}
+
+	segments := make([]SegmentInfo, count)
+	stats := OverallStats{
+		TotalFrames:         len(images),
+		TotalDistanceMeters: total,
+		TotalSegments:       count,
+	}
+	for k, seg := range acc {
+		segments[k].FramesCount = seg.images
+		if seg.images == 0 {
+			continue
+		}
+		segments[k].HasData = true
+		segments[k].CoveragePercentage = seg.coverage / float64(seg.images)
+		segments[k].ClassCoverage = model.AverageClassCoverage(seg.classCoverage)
+		segments[k].AverageConfidence = averageConfidence(seg.confidence)
+		segments[k].MinConfidence = seg.minConfidence
+		segments[k].Defects = seg.defects
+		stats.SegmentsWithData++
+		stats.AverageCoverage += segments[k].CoveragePercentage
+	}
+	if stats.SegmentsWithData > 0 {
+		stats.AverageCoverage /= float64(stats.SegmentsWithData)
+	}
+
+	first, last := images[0].Position, images[len(images)-1].Position
+	result := newAnalysisResult(first.Lat, first.Lon, last.Lat, last.Lon, segmentLength, stats, segments)
+
+	// Концы сегментов лежат на треке снимков, а не на прямой между его концами
+	bound := func(k int) float64 {
+		if k >= count {
+			return total
+		}
+		return float64(k) * segmentLength
+	}
+	for k := range result.Segments {
+		result.Segments[k].StartCoordinate = pointAlongImages(images, distances, bound(k))
+		result.Segments[k].EndCoordinate = pointAlongImages(images, distances, bound(k+1))
+	}
+	return result
+}
+
+// imageDistances возвращает расстояние от первого снимка до каждого снимка
+// вдоль трека в метрах
+func (s *AnalyzerService) imageDistances(images []imageseq.Image) []float64 {
+	distances := make([]float64, len(images))
+	for i := 1; i < len(images); i++ {
+		prev, cur := images[i-1].Position, images[i].Position
+		distances[i] = distances[i-1] + s.calculateDistance(prev.Lat, prev.Lon, cur.Lat, cur.Lon)
+	}
+	return distances
+}
+
+// pointAlongImages возвращает точку трека снимков на расстоянии distance от
+// первого снимка
+func pointAlongImages(images []imageseq.Image, distances []float64, distance float64) Coordinates {
+	for i := 1; i < len(images); i++ {
+		if distance > distances[i] && i < len(images)-1 {
+			continue
+		}
+		prev, cur := images[i-1].Position, images[i].Position
+		progress := 0.0
+		if step := distances[i] - distances[i-1]; step > 0 {
+			progress = min(max((distance-distances[i-1])/step, 0), 1)
+		}
+		return Coordinates{
+			Lat: prev.Lat + (cur.Lat-prev.Lat)*progress,
+			Lon: prev.Lon + (cur.Lon-prev.Lon)*progress,
+		}
+	}
+	return Coordinates{Lat: images[0].Position.Lat, Lon: images[0].Position.Lon}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/onnxanalyzer"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SetLocalAnalyzer включает локальный анализ моделью ONNX вместо Python
+// сервиса
+func (s *AnalyzerService) SetLocalAnalyzer(local *onnxanalyzer.Analyzer) {
+	s.local = local
+}
+
+// LocalAnalyzer возвращает локальный анализатор, nil — анализ выполняет
+// Python сервис
+func (s *AnalyzerService) LocalAnalyzer() *onnxanalyzer.Analyzer {
+	return s.local
+}
+
+// analyzeLocal анализирует видео локальной моделью. Аннотированное видео
+// локальный анализ не создает.
+func (s *AnalyzerService) analyzeLocal(
+	startLat, startLon, endLat, endLon, segmentLength float64,
+	params model.AnalysisParams,
+	timeout time.Duration,
+	videoData []byte,
+	log *logrus.Logger,
+	rec *debugcapture.Recorder,
+) (*AnalysisResult, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	log.Infof("Анализируем видео локальной моделью ONNX: %d байт", len(videoData))
+	rec.StartStage("local_inference")
+	frames, err := s.local.Analyze(ctx, videoData, params)
+	rec.EndStage("local_inference", err != nil)
+	if err != nil {
+		log.Errorf("Ошибка локального анализа: %v", err)
+		if errors.Is(err, onnxanalyzer.ErrInvalidInput) {
+			return nil, fmt.Errorf("%w: %w", ErrAnalyzerRejected, err)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrAnalyzerUnavailable, err)
+	}
+	return s.frameResultsToAnalysis(frames, startLat, startLon, endLat, endLon, segmentLength), nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import "road-detector-go/internal/quality"
+
+// SetQualityScorer включает расчет индекса качества дороги для новых
+// анализов
+func (s *AnalyzerService) SetQualityScorer(scorer *quality.Scorer) {
+	s.quality = scorer
+}
+
+// scoreQuality считает индекс качества сегментов и маршрута по покрытию,
+// дефектам и уверенности модели
+func (s *AnalyzerService) scoreQuality(result *AnalysisResult) {
+	if s.quality == nil {
+		return
+	}
+	scores := make([]*float64, len(result.Segments))
+	lengths := make([]float64, len(result.Segments))
+	for i := range result.Segments {
+		seg := &result.Segments[i]
+		lengths[i] = s.calculateDistance(seg.StartCoordinate.Lat, seg.StartCoordinate.Lon,
+			seg.EndCoordinate.Lat, seg.EndCoordinate.Lon)
+		seg.QualityScore = s.quality.Segment(quality.Segment{
+			HasData:      seg.HasData,
+			Coverage:     seg.CoveragePercentage,
+			Defects:      seg.DefectsCount,
+			LengthMeters: lengths[i],
+			Confidence:   seg.AverageConfidence,
+		})
+		scores[i] = seg.QualityScore
+	}
+	result.OverallStats.QualityScore = quality.Route(scores, lengths)
+}
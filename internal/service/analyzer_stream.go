@@ -0,0 +1,240 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/model"
+	road_marking "road-detector-go/internal/proto"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// analyzerStreamChunkSize размер части видео в одном сообщении потока
+const analyzerStreamChunkSize = 256 << 10
+
+// SetStreamClient включает потоковый анализ через gRPC сервис по адресу
+// target. Если сервис недоступен или не поддерживает потоковый анализ,
+// видео отправляется через HTTP.
+func (s *AnalyzerService) SetStreamClient(conn grpc.ClientConnInterface, target string) {
+	s.stream = road_marking.NewVideoAnalysisServiceClient(conn)
+	s.streamTarget = target
+}
+
+// analyzeGRPC отправляет видео gRPC сервису частями и собирает результат
+// из результатов кадров, итога анализа и частей аннотированного видео
+func (s *AnalyzerService) analyzeGRPC(
+	startLat, startLon, endLat, endLon, segmentLength float64,
+	params model.AnalysisParams,
+	timeout time.Duration,
+	videoData []byte,
+	videoFilename string,
+	routeID string,
+	log *logrus.Logger,
+	rec *debugcapture.Recorder,
+) (*AnalysisResult, []byte, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+	}
+
+	req := &road_marking.AnalyzeVideoParams{
+		VideoFilename:  videoFilename,
+		StartPoint:     &road_marking.Coordinates{Lat: startLat, Lon: startLon},
+		EndPoint:       &road_marking.Coordinates{Lat: endLat, Lon: endLon},
+		SegmentLengthM: int32(math.Round(segmentLength)),
+		RouteId:        routeID,
+
+		FrameSampleRate:     params.FrameSampleRate,
+		ConfidenceThreshold: params.ConfidenceThreshold,
+		ModelVariant:        params.ModelVariant,
+	}
+	if roi := params.ROI; roi != nil {
+		req.Roi = &road_marking.RegionOfInterest{X: roi.X, Y: roi.Y, Width: roi.Width, Height: roi.Height}
+	}
+
+	log.Infof("Отправляем видео в gRPC сервис анализа: %s", s.streamTarget)
+	rec.StartStage("python_stream")
+	summary, annotatedVideoData, frames, err := s.streamVideo(ctx, req, videoData, log)
+	rec.EndStage("python_stream", err != nil)
+	rec.SetUpstream("grpc://"+s.streamTarget, nil, nil)
+	if err != nil {
+		log.Errorf("Ошибка потокового анализа: %v", err)
+		return nil, nil, err
+	}
+
+	segments := make([]SegmentInfo, len(summary.GetSegments()))
+	positions := make(map[int32]int, len(segments))
+	for i, seg := range summary.GetSegments() {
+		segments[i] = SegmentInfo{
+			FramesCount:        int(seg.GetFramesCount()),
+			CoveragePercentage: seg.GetCoveragePercentage(),
+			HasData:            seg.GetHasData(),
+		}
+		positions[seg.GetSegmentId()] = i
+	}
+	// Дефекты, покрытие по типам разметки и уверенность модели ссылаются на
+	// сегменты итога по их ID
+	for _, sd := range summary.GetSegmentDefects() {
+		i, ok := positions[sd.GetSegmentId()]
+		if !ok {
+			log.Warnf("Дефекты покрытия для неизвестного сегмента %d пропущены", sd.GetSegmentId())
+			continue
+		}
+		for _, d := range sd.GetDefects() {
+			segments[i].Defects = append(segments[i].Defects, model.SegmentDefect{
+				Type:     d.GetType(),
+				Severity: d.GetSeverity(),
+				Count:    int(d.GetCount()),
+			})
+		}
+	}
+	for _, sc := range summary.GetSegmentClassCoverage() {
+		i, ok := positions[sc.GetSegmentId()]
+		if !ok {
+			log.Warnf("Покрытие по типам разметки для неизвестного сегмента %d пропущено", sc.GetSegmentId())
+			continue
+		}
+		for _, class := range sc.GetClasses() {
+			if segments[i].ClassCoverage == nil {
+				segments[i].ClassCoverage = make(map[string]float64)
+			}
+			segments[i].ClassCoverage[class.GetMarkingType()] = class.GetCoveragePercentage()
+		}
+	}
+	for _, sc := range summary.GetSegmentConfidence() {
+		i, ok := positions[sc.GetSegmentId()]
+		if !ok {
+			log.Warnf("Уверенность модели для неизвестного сегмента %d пропущена", sc.GetSegmentId())
+			continue
+		}
+		average, minimum := sc.GetAverageConfidence(), sc.GetMinConfidence()
+		segments[i].AverageConfidence = &average
+		segments[i].MinConfidence = &minimum
+	}
+	stats := summary.GetOverallStats()
+	result := newAnalysisResult(startLat, startLon, endLat, endLon, segmentLength, OverallStats{
+		TotalFrames:         int(stats.GetTotalFrames()),
+		TotalDistanceMeters: stats.GetTotalDistanceMeters(),
+		TotalSegments:       int(stats.GetTotalSegments()),
+		SegmentsWithData:    int(stats.GetSegmentsWithData()),
+		AverageCoverage:     stats.GetAverageCoverage(),
+	}, segments)
+
+	log.Infof("Получено результатов кадров: %d, сегментов: %d, аннотированное видео: %d байт",
+		frames, len(segments), len(annotatedVideoData))
+	return result, annotatedVideoData, nil
+}
+
+// streamVideo передает параметры и видео в поток и читает ответы сервиса
+// до конца потока. Возвращает итог анализа, аннотированное видео и
+// количество полученных результатов кадров.
+func (s *AnalyzerService) streamVideo(
+	ctx context.Context,
+	params *road_marking.AnalyzeVideoParams,
+	videoData []byte,
+	log *logrus.Logger,
+) (*road_marking.AnalysisSummary, []byte, int, error) {
+	stream, err := s.stream.AnalyzeVideo(ctx)
+	if err != nil {
+		return nil, nil, 0, analyzerStreamError(err)
+	}
+
+	// Видео отправляется параллельно с чтением ответов: сервис начинает
+	// возвращать результаты кадров, не дожидаясь конца видео
+	sent := make(chan error, 1)
+	go func() {
+		sent <- sendVideo(stream, params, videoData)
+	}()
+
+	var (
+		summary   *road_marking.AnalysisSummary
+		annotated bytes.Buffer
+		frames    int
+	)
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, frames, analyzerStreamError(err)
+		}
+		switch payload := resp.GetPayload().(type) {
+		case *road_marking.AnalyzeVideoResponse_Frame:
+			frames++
+			log.Debugf("Кадр %d: сегмент %d, покрытие %.2f%%",
+				payload.Frame.GetFrameIndex(), payload.Frame.GetSegmentId(), payload.Frame.GetCoveragePercentage())
+		case *road_marking.AnalyzeVideoResponse_Summary:
+			summary = payload.Summary
+		case *road_marking.AnalyzeVideoResponse_AnnotatedVideoChunk:
+			annotated.Write(payload.AnnotatedVideoChunk)
+		}
+	}
+
+	// Ошибку отправки, возникшую на стороне сервиса, Send возвращает как
+	// io.EOF, а ее статус приходит в Recv
+	if err := <-sent; err != nil && err != io.EOF {
+		return nil, nil, frames, analyzerStreamError(err)
+	}
+	if summary == nil {
+		return nil, nil, frames, fmt.Errorf("%w: analysis stream ended without summary", ErrAnalyzerBadResponse)
+	}
+	return summary, annotated.Bytes(), frames, nil
+}
+
+// sendVideo отправляет параметры анализа, затем видео частями и закрывает
+// отправку
+func sendVideo(stream road_marking.VideoAnalysisService_AnalyzeVideoClient, params *road_marking.AnalyzeVideoParams, videoData []byte) error {
+	err := stream.Send(&road_marking.AnalyzeVideoRequest{
+		Payload: &road_marking.AnalyzeVideoRequest_Params{Params: params},
+	})
+	if err != nil {
+		return err
+	}
+	for len(videoData) > 0 {
+		n := min(len(videoData), analyzerStreamChunkSize)
+		err := stream.Send(&road_marking.AnalyzeVideoRequest{
+			Payload: &road_marking.AnalyzeVideoRequest_VideoChunk{VideoChunk: videoData[:n]},
+		})
+		if err != nil {
+			return err
+		}
+		videoData = videoData[n:]
+	}
+	return stream.CloseSend()
+}
+
+// analyzerStreamError возвращает ошибку для статуса gRPC сервиса: ошибки
+// входных данных означают, что сервис отклонил видео, остальные — что
+// сервис не смог его обработать
+func analyzerStreamError(err error) error {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return fmt.Errorf("%w: %w", ErrAnalyzerRejected, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrAnalyzerUnavailable, err)
+	}
+}
+
+// analyzerStreamFallback проверяет, что анализ можно повторить через HTTP:
+// gRPC сервис недоступен или не поддерживает потоковый анализ
+func analyzerStreamFallback(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Unimplemented:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutPolicy как считать ожидание ответа анализатора по размеру и
+// длительности видео. Если PerMB и PerVideoSecond равны 0, ожидание
+// одинаково для всех видео и задается SetTimeout.
+type TimeoutPolicy struct {
+	// Min и Max границы ожидания. Max ограничивает и ожидание, заданное в
+	// запросе.
+	Min time.Duration
+	Max time.Duration
+	// PerMB ожидание на мегабайт видео
+	PerMB time.Duration
+	// PerVideoSecond ожидание на секунду видео, если длительность известна
+	PerVideoSecond time.Duration
+}
+
+// scaled проверяет, что ожидание зависит от видео
+func (p TimeoutPolicy) scaled() bool {
+	return p.PerMB > 0 || p.PerVideoSecond > 0
+}
+
+// SetTimeoutPolicy задает расчет ожидания ответа анализатора для следующих
+// анализов
+func (s *AnalyzerService) SetTimeoutPolicy(policy TimeoutPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeoutPolicy = policy
+}
+
+// MaxTimeout возвращает наибольшее ожидание ответа анализатора, в том числе
+// заданное в запросе
+func (s *AnalyzerService) MaxTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return max(s.client.Timeout, s.timeoutPolicy.Max)
+}
+
+// validateTimeout проверяет ожидание, заданное в запросе: оно не может
+// превышать Max политики
+func (s *AnalyzerService) validateTimeout(requested time.Duration) error {
+	s.mu.RLock()
+	limit := s.timeoutPolicy.Max
+	s.mu.RUnlock()
+	if requested < 0 || (requested > 0 && limit > 0 && requested > limit) {
+		return fmt.Errorf("%w: timeout_seconds must be between 1 and %.0f", ErrInvalidAnalysisParams, limit.Seconds())
+	}
+	return nil
+}
+
+// analysisTimeout возвращает ожидание ответа анализатора для видео размером
+// size байт и длительностью duration секунд (0 — длительность неизвестна).
+// Ожидание requested, заданное в запросе, заменяет рассчитанное.
+func (s *AnalyzerService) analysisTimeout(size int, duration float64, requested time.Duration) time.Duration {
+	if requested > 0 {
+		return requested
+	}
+	s.mu.RLock()
+	policy, fixed := s.timeoutPolicy, s.client.Timeout
+	s.mu.RUnlock()
+	if !policy.scaled() {
+		return fixed
+	}
+
+	timeout := time.Duration(float64(policy.PerMB) * float64(size) / (1 << 20))
+	if duration > 0 {
+		timeout = max(timeout, time.Duration(float64(policy.PerVideoSecond)*duration))
+	}
+	timeout = max(timeout, policy.Min)
+	if policy.Max > 0 {
+		timeout = min(timeout, policy.Max)
+	}
+	return timeout
+}
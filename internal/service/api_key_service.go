@@ -0,0 +1,197 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidAPIKey возвращается, если ключ API не указан, неизвестен или отозван
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// ErrInvalidAPIKeyRequest возвращается при некорректных данных нового ключа
+var ErrInvalidAPIKeyRequest = errors.New("invalid api key request")
+
+const (
+	// apiKeyPrefix начало всех ключей, чтобы их было легко найти в конфигурации и логах
+	apiKeyPrefix = "rdk_"
+	// apiKeyBytes количество случайных байт ключа
+	apiKeyBytes = 32
+	// apiKeyDisplayLength длина начала ключа, которое хранится открыто
+	apiKeyDisplayLength = 12
+	maxAPIKeyNameLength = 100
+	// apiKeyUsageInterval как часто обновляется время последнего использования ключа
+	apiKeyUsageInterval = time.Minute
+)
+
+// APIKeyService сервис ключей API
+type APIKeyService struct {
+	keyRepo      repository.APIKeyRepository
+	orgRepo      repository.OrganizationRepository
+	logger       *logrus.Logger
+	adminKeyHash string
+}
+
+// NewAPIKeyService создает новый сервис ключей API
+func NewAPIKeyService(keyRepo repository.APIKeyRepository, logger *logrus.Logger) *APIKeyService {
+	return &APIKeyService{
+		keyRepo: keyRepo,
+		logger:  logger,
+	}
+}
+
+// SetAdminKey задает ключ администратора из конфигурации. Он не хранится в БД
+// и нужен, чтобы создать первые ключи.
+func (s *APIKeyService) SetAdminKey(key string) {
+	if key == "" {
+		s.adminKeyHash = ""
+		return
+	}
+	s.adminKeyHash = hashAPIKey(key)
+}
+
+// SetOrganizationRepository включает выпуск ключей, ограниченных организацией
+func (s *APIKeyService) SetOrganizationRepository(orgRepo repository.OrganizationRepository) {
+	s.orgRepo = orgRepo
+}
+
+// CreateKey создает ключ и возвращает его вместе с открытым значением,
+// которое больше нигде не сохраняется
+func (s *APIKeyService) CreateKey(req CreateAPIKeyRequest) (*CreatedAPIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKeyRequest)
+	}
+	if utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return nil, fmt.Errorf("%w: name is longer than %d characters", ErrInvalidAPIKeyRequest, maxAPIKeyNameLength)
+	}
+
+	if req.OrganizationID != nil {
+		if s.orgRepo == nil {
+			return nil, fmt.Errorf("%w: organizations are not enabled", ErrInvalidAPIKeyRequest)
+		}
+		if _, err := s.orgRepo.GetByID(*req.OrganizationID); err != nil {
+			return nil, fmt.Errorf("failed to get organization: %w", err)
+		}
+	}
+
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := &model.APIKey{
+		Name:           name,
+		Prefix:         key[:apiKeyDisplayLength],
+		KeyHash:        hashAPIKey(key),
+		Admin:          req.Admin,
+		OrganizationID: req.OrganizationID,
+	}
+	if err := s.keyRepo.Create(apiKey); err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	s.logger.Infof("Создан ключ API %d (%s), администратор: %t, организация: %s",
+		apiKey.ID, apiKey.Name, apiKey.Admin, formatOrganizationID(apiKey.OrganizationID))
+	return &CreatedAPIKeyResponse{
+		APIKeyInfo: apiKeyInfo(apiKey),
+		Key:        key,
+	}, nil
+}
+
+// ListKeys возвращает все ключи без их значений
+func (s *APIKeyService) ListKeys() ([]APIKeyInfo, error) {
+	keys, err := s.keyRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	result := make([]APIKeyInfo, len(keys))
+	for i := range keys {
+		result[i] = apiKeyInfo(&keys[i])
+	}
+	return result, nil
+}
+
+// RevokeKey отзывает ключ, после чего запросы с ним отклоняются
+func (s *APIKeyService) RevokeKey(id uint) (*APIKeyInfo, error) {
+	key, err := s.keyRepo.Revoke(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	s.logger.Infof("Ключ API %d (%s) отозван", key.ID, key.Name)
+	info := apiKeyInfo(key)
+	return &info, nil
+}
+
+// Authenticate проверяет ключ и возвращает его данные. Ключ администратора
+// из конфигурации возвращается с нулевым ID.
+func (s *APIKeyService) Authenticate(key string) (*model.APIKey, error) {
+	if key == "" {
+		return nil, ErrInvalidAPIKey
+	}
+	hash := hashAPIKey(key)
+
+	if s.adminKeyHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(s.adminKeyHash)) == 1 {
+		return &model.APIKey{Name: "admin (config)", Admin: true}, nil
+	}
+
+	apiKey, err := s.keyRepo.GetByHash(hash)
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to check api key: %w", err)
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyUsageInterval {
+		if err := s.keyRepo.TouchLastUsed(apiKey.ID, now); err != nil {
+			s.logger.Warnf("Не удалось обновить время использования ключа API %d: %v", apiKey.ID, err)
+		}
+	}
+	return apiKey, nil
+}
+
+// hashAPIKey возвращает SHA-256 ключа в hex. Ключи случайные и длинные,
+// поэтому медленный хэш не нужен.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyInfo преобразует ключ в ответ API
+func apiKeyInfo(key *model.APIKey) APIKeyInfo {
+	return APIKeyInfo{
+		ID:             key.ID,
+		Name:           key.Name,
+		Prefix:         key.Prefix,
+		Admin:          key.Admin,
+		CreatedAt:      key.CreatedAt,
+		LastUsedAt:     key.LastUsedAt,
+		RevokedAt:      key.RevokedAt,
+		OrganizationID: key.OrganizationID,
+	}
+}
+
+// formatOrganizationID возвращает ID организации для логов
+func formatOrganizationID(id *uint) string {
+	if id == nil {
+		return "все"
+	}
+	return fmt.Sprint(*id)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// archiveBatchSize сколько маршрутов переносится в архив одним запросом
+const archiveBatchSize = 500
+
+// ArchiveOptions настройки переноса старых маршрутов в архив
+type ArchiveOptions struct {
+	// After сколько маршрут не должен изменяться, чтобы попасть в архив, 0 — не переносится
+	After time.Duration
+	// Interval как часто искать маршруты для архива
+	Interval time.Duration
+}
+
+// ArchiveService в фоне переносит в архив маршруты, не изменявшиеся дольше
+// ArchiveOptions.After.
+// Архивные маршруты остаются в базе и доступны по ID, но не попадают в списки,
+// поиск и выборку по области.
+type ArchiveService struct {
+	routeRepo repository.RouteRepository
+	logger    *logrus.Logger
+	opts      ArchiveOptions
+	now       func() time.Time
+}
+
+// NewArchiveService создает сервис архива
+func NewArchiveService(routeRepo repository.RouteRepository, opts ArchiveOptions, logger *logrus.Logger) *ArchiveService {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+	return &ArchiveService{
+		routeRepo: routeRepo,
+		logger:    logger,
+		opts:      opts,
+		now:       time.Now,
+	}
+}
+
+// Run переносит старые маршруты в архив сразу и затем каждые Interval,
+// пока не отменен ctx. Без After сразу возвращается.
+func (s *ArchiveService) Run(ctx context.Context) {
+	if s.opts.After <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		s.archive(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// archive переносит в архив маршруты, не изменявшиеся дольше After, пачками
+// по archiveBatchSize
+func (s *ArchiveService) archive(ctx context.Context) {
+	before := s.now().Add(-s.opts.After)
+	var total int64
+	for ctx.Err() == nil {
+		archived, err := s.routeRepo.Archive(before, archiveBatchSize)
+		if err != nil {
+			s.logger.Errorf("Ошибка переноса маршрутов в архив: %v", err)
+			break
+		}
+		total += archived
+		if archived < archiveBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		s.logger.Infof("Перенесено в архив маршрутов, не изменявшихся с %s: %d", before.Format(time.DateOnly), total)
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxAuditSummaryLength ограничение длины описания операции в журнале
+const maxAuditSummaryLength = 1000
+
+// AuditService ведет журнал аудита изменяющих операций
+type AuditService struct {
+	auditRepo repository.AuditRepository
+	logger    *logrus.Logger
+}
+
+// NewAuditService создает новый сервис журнала аудита
+func NewAuditService(auditRepo repository.AuditRepository, logger *logrus.Logger) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+// Record сохраняет запись журнала. Операция к этому моменту уже выполнена,
+// поэтому ошибка записи только попадает в лог.
+func (s *AuditService) Record(entry *model.AuditEntry) {
+	if utf8.RuneCountInString(entry.Summary) > maxAuditSummaryLength {
+		entry.Summary = string([]rune(entry.Summary)[:maxAuditSummaryLength]) + "…"
+	}
+	if err := s.auditRepo.Create(entry); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"action":   entry.Action,
+			"actor":    entry.ActorType,
+			"route_id": entry.RouteID,
+		}).Errorf("Не удалось записать операцию в журнал аудита: %v", err)
+	}
+}
+
+// ListEntries возвращает страницу журнала аудита
+func (s *AuditService) ListEntries(query repository.AuditQuery) (*ListAuditResponse, error) {
+	entries, total, err := s.auditRepo.List(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	result := make([]AuditEntryInfo, len(entries))
+	for i, entry := range entries {
+		result[i] = AuditEntryInfo{
+			ID:             entry.ID,
+			CreatedAt:      entry.CreatedAt,
+			Action:         entry.Action,
+			ActorType:      entry.ActorType,
+			ActorID:        entry.ActorID,
+			ActorName:      entry.ActorName,
+			OrganizationID: entry.OrganizationID,
+			IP:             entry.IP,
+			Method:         entry.Method,
+			Path:           entry.Path,
+			Status:         entry.Status,
+			RouteID:        entry.RouteID,
+			Target:         entry.Target,
+			Summary:        entry.Summary,
+		}
+	}
+
+	return &ListAuditResponse{
+		Entries: result,
+		Total:   total,
+		Page:    query.Page,
+		Size:    query.PageSize,
+	}, nil
+}
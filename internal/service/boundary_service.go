@@ -0,0 +1,213 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"road-detector-go/internal/coverageband"
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxBoundariesPerUpload ограничивает число границ в одной загрузке
+	maxBoundariesPerUpload = 500
+	// maxBoundaryNameLength и maxBoundaryKindLength ограничения длины
+	// названия и вида границы
+	maxBoundaryNameLength = 255
+	maxBoundaryKindLength = 64
+	// MaxAreaWorstSegments ограничивает число худших сегментов границы в статистике
+	MaxAreaWorstSegments = 50
+)
+
+// ErrInvalidBoundary возвращается при некорректных данных границы
+var ErrInvalidBoundary = errors.New("invalid boundary")
+
+// BoundaryService сервис административных границ и статистики по ним
+type BoundaryService struct {
+	boundaryRepo repository.BoundaryRepository
+	bands        *coverageband.Classifier
+	logger       *logrus.Logger
+}
+
+// NewBoundaryService создает новый сервис границ
+func NewBoundaryService(boundaryRepo repository.BoundaryRepository, logger *logrus.Logger) *BoundaryService {
+	return &BoundaryService{
+		boundaryRepo: boundaryRepo,
+		logger:       logger,
+	}
+}
+
+// SetCoverageBands включает отнесение среднего покрытия границ к полосам
+func (s *BoundaryService) SetCoverageBands(bands *coverageband.Classifier) {
+	s.bands = bands
+}
+
+// CreateBoundaries загружает границы организации из GeoJSON. Каждый полигон
+// FeatureCollection становится отдельной границей.
+func (s *BoundaryService) CreateBoundaries(orgID *uint, req CreateBoundariesRequest) ([]BoundaryInfo, error) {
+	if len(req.GeoJSON) == 0 {
+		return nil, fmt.Errorf("%w: geojson is required", ErrInvalidBoundary)
+	}
+	kind := strings.TrimSpace(req.Kind)
+	if utf8.RuneCountInString(kind) > maxBoundaryKindLength {
+		return nil, fmt.Errorf("%w: kind must be at most %d characters", ErrInvalidBoundary, maxBoundaryKindLength)
+	}
+	nameProperty := req.NameProperty
+	if nameProperty == "" {
+		nameProperty = "name"
+	}
+
+	features, err := geo.ParseGeoJSONFeatures(req.GeoJSON)
+	if err != nil {
+		return nil, err
+	}
+	if len(features) > maxBoundariesPerUpload {
+		return nil, fmt.Errorf("%w: at most %d boundaries per upload", ErrInvalidBoundary, maxBoundariesPerUpload)
+	}
+
+	boundaries := make([]*model.Boundary, len(features))
+	for i, feature := range features {
+		name := strings.TrimSpace(req.Name)
+		if value, ok := feature.Properties[nameProperty].(string); ok && strings.TrimSpace(value) != "" {
+			name = strings.TrimSpace(value)
+		}
+		if name == "" || utf8.RuneCountInString(name) > maxBoundaryNameLength {
+			return nil, fmt.Errorf("%w: feature %d: name (property %q) must be 1-%d characters",
+				ErrInvalidBoundary, i, nameProperty, maxBoundaryNameLength)
+		}
+
+		geometry, err := feature.Polygon.GeoJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode boundary geometry: %w", err)
+		}
+		box := feature.Polygon.BoundingBox()
+		boundaries[i] = &model.Boundary{
+			OrganizationID: orgID,
+			Name:           name,
+			Kind:           kind,
+			Geometry:       string(geometry),
+			MinLat:         box.SouthWest.Lat,
+			MinLon:         box.SouthWest.Lon,
+			MaxLat:         box.NorthEast.Lat,
+			MaxLon:         box.NorthEast.Lon,
+		}
+	}
+
+	if err := s.boundaryRepo.Create(boundaries); err != nil {
+		s.logger.Errorf("Ошибка сохранения границ: %v", err)
+		return nil, err
+	}
+	s.logger.Infof("Загружено границ: %d, вид %q", len(boundaries), kind)
+
+	infos := make([]BoundaryInfo, len(boundaries))
+	for i, boundary := range boundaries {
+		infos[i] = boundaryInfo(boundary, false)
+	}
+	return infos, nil
+}
+
+// ListBoundaries возвращает границы организации и общие границы, kind,
+// если не пуст, оставляет границы одного вида
+func (s *BoundaryService) ListBoundaries(orgID *uint, kind string) ([]BoundaryInfo, error) {
+	boundaries, err := s.boundaryRepo.List(orgID, kind)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]BoundaryInfo, len(boundaries))
+	for i := range boundaries {
+		infos[i] = boundaryInfo(&boundaries[i], false)
+	}
+	return infos, nil
+}
+
+// GetBoundary возвращает границу вместе с геометрией
+func (s *BoundaryService) GetBoundary(id uint, orgID *uint) (*BoundaryInfo, error) {
+	boundary, err := s.boundaryRepo.Get(id, orgID)
+	if err != nil {
+		return nil, err
+	}
+	info := boundaryInfo(boundary, true)
+	return &info, nil
+}
+
+// DeleteBoundary удаляет границу организации
+func (s *BoundaryService) DeleteBoundary(id uint, orgID *uint) error {
+	if err := s.boundaryRepo.Delete(id, orgID); err != nil {
+		return err
+	}
+	s.logger.Infof("Удалена граница %d", id)
+	return nil
+}
+
+// AreaStatistics считает для каждой доступной организации границы вида kind
+// протяженность проанализированных сегментов, среднее покрытие и до worst
+// сегментов с наименьшим покрытием. Сегмент относится к границе, внутри
+// которой лежит его середина. Учитываются только маршруты из области scope.
+func (s *BoundaryService) AreaStatistics(orgID *uint, kind string, worst int, scope repository.RouteScope) (*AreaStatisticsResponse, error) {
+	boundaries, err := s.boundaryRepo.List(orgID, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	statistics, err := s.boundaryRepo.AreaStatistics(boundaries, scope, worst)
+	if err != nil {
+		s.logger.Errorf("Ошибка расчета статистики по границам: %v", err)
+		return nil, fmt.Errorf("failed to compute area statistics: %w", err)
+	}
+
+	response := &AreaStatisticsResponse{Areas: make([]AreaStatistics, len(boundaries)), Total: len(boundaries)}
+	for i, boundary := range boundaries {
+		stats := statistics[i]
+		area := AreaStatistics{
+			BoundaryID:       boundary.ID,
+			Name:             boundary.Name,
+			Kind:             boundary.Kind,
+			Routes:           stats.Routes,
+			Segments:         stats.Segments,
+			SegmentsWithData: stats.SegmentsWithData,
+			DistanceKm:       math.Round(stats.DistanceMeters) / 1000,
+			WorstSegments:    make([]AreaSegment, len(stats.WorstSegments)),
+		}
+		if stats.AverageCoverage != nil {
+			// Округляем до 1 знака, как и остальную статистику покрытия
+			average := math.Round(*stats.AverageCoverage*10) / 10
+			area.AverageCoverage = &average
+			if s.bands != nil {
+				band := s.bands.Classify(average)
+				area.CoverageBand, area.CoverageColor = band.Name, band.Color
+			}
+		}
+		for j, seg := range stats.WorstSegments {
+			area.WorstSegments[j] = AreaSegment(seg)
+		}
+		response.Areas[i] = area
+	}
+
+	s.logger.Infof("Статистика по границам рассчитана: %d границ", len(boundaries))
+	return response, nil
+}
+
+// boundaryInfo переносит границу в ответ API
+func boundaryInfo(boundary *model.Boundary, withGeometry bool) BoundaryInfo {
+	info := BoundaryInfo{
+		ID:             boundary.ID,
+		OrganizationID: boundary.OrganizationID,
+		Name:           boundary.Name,
+		Kind:           boundary.Kind,
+		NorthEast:      Coordinates{Lat: boundary.MaxLat, Lon: boundary.MaxLon},
+		SouthWest:      Coordinates{Lat: boundary.MinLat, Lon: boundary.MinLon},
+		CreatedAt:      boundary.CreatedAt,
+	}
+	if withGeometry {
+		info.Geometry = json.RawMessage(boundary.Geometry)
+	}
+	return info
+}
@@ -0,0 +1,55 @@
+package service
+
+import "road-detector-go/internal/coverageband"
+
+// SetCoverageBands включает отнесение покрытия маршрутов и сегментов к
+// полосам в ответах и выгрузках
+func (s *RouteService) SetCoverageBands(bands *coverageband.Classifier) {
+	s.bands = bands
+}
+
+// CoverageBands возвращает полосы покрытия, nil — полосы не заданы
+func (s *RouteService) CoverageBands() *coverageband.Classifier {
+	return s.bands
+}
+
+// applyCoverageBands относит к полосам покрытие сегментов с данными и
+// среднее покрытие маршрута, если у него есть сегменты с данными
+func (s *RouteService) applyCoverageBands(segments []SegmentInfo, stats *OverallStats) {
+	if s.bands == nil {
+		return
+	}
+	for i := range segments {
+		if segments[i].HasData {
+			band := s.bands.Classify(segments[i].CoveragePercentage)
+			segments[i].CoverageBand, segments[i].CoverageColor = band.Name, band.Color
+		}
+	}
+	if stats != nil && stats.SegmentsWithData > 0 {
+		band := s.bands.Classify(stats.AverageCoverage)
+		stats.CoverageBand, stats.CoverageColor = band.Name, band.Color
+	}
+}
+
+// SetCoverageBands включает отнесение покрытия дорог и их участков к полосам
+func (s *RoadService) SetCoverageBands(bands *coverageband.Classifier) {
+	s.bands = bands
+}
+
+// applySummaryBand относит к полосе среднее покрытие дороги
+func (s *RoadService) applySummaryBand(summary *RoadSummary) {
+	if s.bands == nil || summary.SegmentCount == 0 {
+		return
+	}
+	band := s.bands.Classify(summary.AverageCoverage)
+	summary.CoverageBand, summary.CoverageColor = band.Name, band.Color
+}
+
+// applySegmentBand относит к полосе последнее покрытие участка дороги
+func (s *RoadService) applySegmentBand(segment *RoadSegmentInfo) {
+	if s.bands == nil || segment.ObservationCount == 0 {
+		return
+	}
+	band := s.bands.Classify(segment.LatestCoverage)
+	segment.CoverageBand, segment.CoverageColor = band.Name, band.Color
+}
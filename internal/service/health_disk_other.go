@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package service
+
+import "errors"
+
+// freeDiskBytes на этой платформе не поддерживается
+func freeDiskBytes(string) (uint64, error) {
+	return 0, errors.New("free disk space check is not supported on this platform")
+}
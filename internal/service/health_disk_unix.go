@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package service
+
+import "syscall"
+
+// freeDiskBytes свободное для непривилегированного процесса место
+// в файловой системе каталога path
+func freeDiskBytes(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/buildinfo"
+	"road-detector-go/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Статусы проверок состояния
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+	HealthCheckUp         = "up"
+	HealthCheckDown       = "down"
+)
+
+// DBPinger проверяет соединение с базой данных, например *sql.DB
+type DBPinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthOptions настройки проверки готовности
+type HealthOptions struct {
+	// Timeout ожидание всех проверок
+	Timeout time.Duration
+	// MinFreeDiskBytes минимальное свободное место в каталоге файлов,
+	// при меньшем сервис не готов принимать видео
+	MinFreeDiskBytes uint64
+}
+
+// HealthService проверяет зависимости сервиса: базу данных, Python сервис,
+// каталог файлов и свободное место на диске
+type HealthService struct {
+	db              DBPinger
+	analyzerService *AnalyzerService
+	staticDir       string
+	dbSchemaVersion int
+	logger          *logrus.Logger
+	opts            HealthOptions
+	startedAt       time.Time
+	// dbReady проверяет, что миграции выполнены, nil — база готова после подключения
+	dbReady func() bool
+	// replica реплика для чтения, nil — не настроена
+	replica DBPinger
+	// maintenance режим обслуживания для ответа /api/v1/health, nil — не
+	// показывается
+	maintenance *MaintenanceService
+	// queue очередь анализов экземпляра API, nil — анализ выполняется в
+	// этом экземпляре
+	queue *AnalysisQueue
+}
+
+// NewHealthService создает новый сервис проверки состояния с настройками
+// по умолчанию: ожидание 3 секунды, не меньше 500 МБ свободного места
+func NewHealthService(db DBPinger, analyzerService *AnalyzerService, staticDir string, dbSchemaVersion int, logger *logrus.Logger) *HealthService {
+	s := &HealthService{
+		db:              db,
+		analyzerService: analyzerService,
+		staticDir:       staticDir,
+		dbSchemaVersion: dbSchemaVersion,
+		logger:          logger,
+		startedAt:       time.Now(),
+	}
+	s.SetOptions(HealthOptions{})
+	return s
+}
+
+// SetOptions задает настройки проверки, нулевые поля заменяются
+// значениями по умолчанию
+func (s *HealthService) SetOptions(opts HealthOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 3 * time.Second
+	}
+	if opts.MinFreeDiskBytes == 0 {
+		opts.MinFreeDiskBytes = 500 << 20
+	}
+	s.opts = opts
+}
+
+// SetDatabaseReady задает проверку подготовки базы данных. Пока она
+// возвращает false, проверка database не проходит, даже если сервер БД
+// уже отвечает.
+func (s *HealthService) SetDatabaseReady(ready func() bool) {
+	s.dbReady = ready
+}
+
+// SetReplica включает проверку database_replica реплики для чтения
+func (s *HealthService) SetReplica(replica DBPinger) {
+	s.replica = replica
+}
+
+// SetMaintenance добавляет состояние режима обслуживания в подробный ответ.
+// Готовность от режима не зависит: чтение в нем продолжает работать.
+func (s *HealthService) SetMaintenance(maintenance *MaintenanceService) {
+	s.maintenance = maintenance
+}
+
+// SetAnalysisQueue заменяет проверку Python сервиса проверкой очереди
+// анализов: экземпляр API к Python сервису не обращается
+func (s *HealthService) SetAnalysisQueue(queue *AnalysisQueue) {
+	s.queue = queue
+}
+
+// Check проверяет все зависимости параллельно и возвращает состояние
+// сервиса с версиями. Сервис готов, если все проверки прошли.
+func (s *HealthService) Check() HealthResponse {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	type healthCheck struct {
+		name string
+		run  func(ctx context.Context) (map[string]interface{}, error)
+	}
+	checks := []healthCheck{
+		{"database", s.checkDatabase},
+		{"python_service", s.checkPythonService},
+		{"static_dir", s.checkStaticDir},
+		{"disk_space", s.checkDiskSpace},
+	}
+	if s.analyzerService.LocalAnalyzer() != nil {
+		// Локальный анализ не обращается к Python сервису
+		checks[1] = healthCheck{"local_analyzer", s.checkLocalAnalyzer}
+	}
+	if s.queue != nil {
+		checks[1] = healthCheck{"analysis_queue", s.checkAnalysisQueue}
+	}
+	if s.replica != nil {
+		checks = append(checks, healthCheck{"database_replica", s.checkReplica})
+	}
+
+	response := HealthResponse{
+		Status:          HealthStatusHealthy,
+		Checks:          make([]HealthCheck, len(checks)),
+		CheckedAt:       time.Now(),
+		Service:         buildinfo.Current(),
+		DBSchemaVersion: s.dbSchemaVersion,
+		UptimeSeconds:   time.Since(s.startedAt).Seconds(),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, name string, run func(ctx context.Context) (map[string]interface{}, error)) {
+			defer wg.Done()
+			response.Checks[i] = runHealthCheck(ctx, name, run)
+		}(i, check.name, check.run)
+	}
+	wg.Wait()
+
+	for _, check := range response.Checks {
+		if check.Status != HealthCheckUp {
+			response.Status = HealthStatusUnhealthy
+			s.logger.Warnf("Проверка %s не прошла: %s", check.Name, check.Error)
+		}
+	}
+	summarizeHealth(&response)
+
+	if s.maintenance != nil {
+		status := s.maintenance.Status()
+		response.Maintenance = &status
+	}
+	return response
+}
+
+// summarizeHealth заполняет краткое состояние основных зависимостей по
+// результатам проверок
+func summarizeHealth(response *HealthResponse) {
+	response.Database = HealthCheckUp
+	response.Storage = HealthCheckUp
+	response.Analyzer = HealthCheckUp
+	for _, check := range response.Checks {
+		var summary *string
+		switch check.Name {
+		case "database":
+			summary = &response.Database
+		case "static_dir", "disk_space":
+			summary = &response.Storage
+		case "python_service", "local_analyzer", "analysis_queue":
+			summary = &response.Analyzer
+		default:
+			continue
+		}
+		if check.Status != HealthCheckUp {
+			*summary = HealthCheckDown
+		}
+		if check.Name == "python_service" && check.Status == HealthCheckUp {
+			response.PythonVersion, _ = check.Details["version"].(string)
+			if loaded, ok := check.Details["model_loaded"].(bool); ok {
+				response.ModelLoaded = &loaded
+			}
+		}
+	}
+}
+
+// runHealthCheck выполняет проверку и ограничивает ее временем ctx
+func runHealthCheck(ctx context.Context, name string, run func(ctx context.Context) (map[string]interface{}, error)) HealthCheck {
+	type result struct {
+		details map[string]interface{}
+		err     error
+	}
+
+	start := time.Now()
+	done := make(chan result, 1)
+	go func() {
+		details, err := run(ctx)
+		done <- result{details, err}
+	}()
+
+	check := HealthCheck{Name: name, Status: HealthCheckUp}
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+	check.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	check.Details = res.details
+	if res.err != nil {
+		check.Status = HealthCheckDown
+		check.Error = res.err.Error()
+	}
+	return check
+}
+
+// checkDatabase проверяет соединение с базой данных
+func (s *HealthService) checkDatabase(ctx context.Context) (map[string]interface{}, error) {
+	if err := s.db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("database ping failed: %w", err)
+	}
+	if s.dbReady != nil && !s.dbReady() {
+		return nil, errors.New("database is not initialized yet")
+	}
+	return map[string]interface{}{"schema_version": s.dbSchemaVersion}, nil
+}
+
+// checkReplica проверяет соединение с репликой для чтения
+func (s *HealthService) checkReplica(ctx context.Context) (map[string]interface{}, error) {
+	if err := s.replica.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("replica ping failed: %w", err)
+	}
+	return nil, nil
+}
+
+// checkPythonService проверяет /health Python сервиса
+func (s *HealthService) checkPythonService(ctx context.Context) (map[string]interface{}, error) {
+	health, err := s.analyzerService.FetchHealthContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]interface{}{
+		"version":      health.Version,
+		"model_loaded": health.ModelLoaded,
+	}
+	if instances := s.analyzerService.Instances(); len(instances) > 1 {
+		healthy := 0
+		for _, instance := range instances {
+			if instance.Healthy {
+				healthy++
+			}
+		}
+		details["instances"] = len(instances)
+		details["healthy_instances"] = healthy
+	}
+	return details, nil
+}
+
+// checkLocalAnalyzer проверяет, что локальный анализ моделью ONNX доступен
+func (s *HealthService) checkLocalAnalyzer(context.Context) (map[string]interface{}, error) {
+	return s.analyzerService.LocalAnalyzer().Check()
+}
+
+// checkAnalysisQueue проверяет папку очереди анализов и считает анализы,
+// ожидающие воркеров
+func (s *HealthService) checkAnalysisQueue(context.Context) (map[string]interface{}, error) {
+	if err := s.queue.CheckDir(); err != nil {
+		return nil, err
+	}
+	counts, err := s.queue.Counts()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"queued":  counts[model.AnalysisJobQueued],
+		"running": counts[model.AnalysisJobRunning],
+	}, nil
+}
+
+// checkStaticDir проверяет, что в каталог файлов можно записать файл
+func (s *HealthService) checkStaticDir(context.Context) (map[string]interface{}, error) {
+	file, err := os.CreateTemp(s.staticDir, ".healthcheck-*")
+	if err != nil {
+		return nil, fmt.Errorf("static dir is not writable: %w", err)
+	}
+	name := file.Name()
+	_, writeErr := file.WriteString("ok")
+	closeErr := file.Close()
+	removeErr := os.Remove(name)
+	switch {
+	case writeErr != nil:
+		return nil, fmt.Errorf("static dir is not writable: %w", writeErr)
+	case closeErr != nil:
+		return nil, fmt.Errorf("static dir is not writable: %w", closeErr)
+	case removeErr != nil:
+		return nil, fmt.Errorf("failed to remove health check file: %w", removeErr)
+	}
+	return map[string]interface{}{"path": s.staticDir}, nil
+}
+
+// checkDiskSpace проверяет свободное место в каталоге файлов
+func (s *HealthService) checkDiskSpace(context.Context) (map[string]interface{}, error) {
+	free, err := freeDiskBytes(s.staticDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get free disk space: %w", err)
+	}
+	details := map[string]interface{}{
+		"free_bytes":     free,
+		"min_free_bytes": s.opts.MinFreeDiskBytes,
+	}
+	if free < s.opts.MinFreeDiskBytes {
+		return details, fmt.Errorf("free disk space %d bytes is below %d", free, s.opts.MinFreeDiskBytes)
+	}
+	return details, nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"road-detector-go/internal/repository"
+)
+
+// Статусы анализа видео
+const (
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// jobWatcherBuffer сколько изменений ждут отправки одному подписчику.
+// Подписчик, который не успевает их получать, отключается.
+const jobWatcherBuffer = 64
+
+// Job состояние анализа видео
+type Job struct {
+	RouteID        string
+	VideoFilename  string
+	OrganizationID *uint
+	OwnerID        *uint
+	APIKeyID       *uint
+	Status         string
+	// Stage текущий этап анализа: python_request, map_matching, db_save и другие
+	Stage string
+	// Err ошибка неудачного анализа
+	Err        error
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// Visible проверяет, доступен ли маршрут анализа области scope. Как и
+// в списках маршрутов, без организации пользователю видны только его
+// личные маршруты.
+func (j *Job) Visible(scope repository.RouteScope) bool {
+	return scopeIncludes(scope, j.OrganizationID, j.OwnerID, j.APIKeyID)
+}
+
+// scopeIncludes проверяет, входит ли в область scope маршрут организации
+// orgID, пользователя ownerID и ключа API apiKeyID
+func scopeIncludes(scope repository.RouteScope, orgID, ownerID, apiKeyID *uint) bool {
+	switch {
+	case scope.OrganizationID != nil:
+		return orgID != nil && *orgID == *scope.OrganizationID
+	case scope.OwnerID != nil:
+		return orgID == nil && ownerID != nil && *ownerID == *scope.OwnerID
+	case scope.APIKeyID != nil:
+		return orgID == nil && ownerID == nil && apiKeyID != nil && *apiKeyID == *scope.APIKeyID
+	default:
+		return true
+	}
+}
+
+// JobWatcher подписка на изменения статусов анализов. Канал Updates
+// закрывается при отмене подписки или если подписчик не успевает получать
+// изменения: тогда Dropped возвращает true.
+type JobWatcher struct {
+	Updates <-chan Job
+
+	updates chan Job
+	dropped bool
+}
+
+// Dropped проверяет, была ли подписка отключена из-за переполнения
+func (w *JobWatcher) Dropped() bool {
+	return w.dropped
+}
+
+// JobTracker хранит выполняющиеся в этом экземпляре сервиса анализы и
+// рассылает изменения их статусов подписчикам. Завершенный анализ
+// рассылается один раз и больше не хранится.
+type JobTracker struct {
+	mu       sync.Mutex
+	running  map[string]*Job
+	watchers map[*JobWatcher]struct{}
+	now      func() time.Time
+}
+
+// NewJobTracker создает пустой список анализов
+func NewJobTracker() *JobTracker {
+	return &JobTracker{
+		running:  make(map[string]*Job),
+		watchers: make(map[*JobWatcher]struct{}),
+		now:      time.Now,
+	}
+}
+
+// Start добавляет выполняющийся анализ
+func (t *JobTracker) Start(job Job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job.Status = JobRunning
+	job.StartedAt = t.now()
+	t.running[job.RouteID] = &job
+	t.publish(job)
+}
+
+// Stage отмечает начало этапа анализа
+func (t *JobTracker) Stage(routeID, stage string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.running[routeID]
+	if !ok || job.Stage == stage {
+		return
+	}
+	job.Stage = stage
+	t.publish(*job)
+}
+
+// Finish отмечает анализ завершенным или, при ошибке err, неудачным
+func (t *JobTracker) Finish(routeID string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.running[routeID]
+	if !ok {
+		return
+	}
+	delete(t.running, routeID)
+
+	finished := t.now()
+	job.FinishedAt = &finished
+	job.Status = JobCompleted
+	if err != nil {
+		job.Status = JobFailed
+		job.Err = err
+	}
+	t.publish(*job)
+}
+
+// Watch подписывается на изменения и возвращает выполняющиеся анализы на
+// момент подписки. Подписку нужно отменить через Unwatch.
+func (t *JobTracker) Watch() ([]Job, *JobWatcher) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	updates := make(chan Job, jobWatcherBuffer)
+	watcher := &JobWatcher{Updates: updates, updates: updates}
+	t.watchers[watcher] = struct{}{}
+
+	jobs := make([]Job, 0, len(t.running))
+	for _, job := range t.running {
+		jobs = append(jobs, *job)
+	}
+	return jobs, watcher
+}
+
+// Unwatch отменяет подписку
+func (t *JobTracker) Unwatch(watcher *JobWatcher) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.watchers[watcher]; ok {
+		delete(t.watchers, watcher)
+		close(watcher.updates)
+	}
+}
+
+// publish отправляет изменение подписчикам. Вызывается под t.mu.
+func (t *JobTracker) publish(job Job) {
+	for watcher := range t.watchers {
+		select {
+		case watcher.updates <- job:
+		default:
+			watcher.dropped = true
+			delete(t.watchers, watcher)
+			close(watcher.updates)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrMaintenance возвращается, если сервис на обслуживании и не принимает
+// новые анализы
+var ErrMaintenance = errors.New("maintenance mode")
+
+// ErrDependenciesUnhealthy возвращается, если режим обслуживания нельзя
+// выключить, потому что проверка готовности не проходит
+var ErrDependenciesUnhealthy = errors.New("dependencies are unhealthy")
+
+// DefaultMaintenanceRetryAfter через сколько клиенту предлагается повторить
+// запрос, если при включении режима срок не указан
+const DefaultMaintenanceRetryAfter = 5 * time.Minute
+
+// MaintenanceError отказ в анализе на время обслуживания. RetryAfter —
+// через сколько повторить запрос.
+type MaintenanceError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *MaintenanceError) Error() string {
+	if e.Reason == "" {
+		return ErrMaintenance.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrMaintenance, e.Reason)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrMaintenance)
+func (e *MaintenanceError) Unwrap() error {
+	return ErrMaintenance
+}
+
+// MaintenanceStatus состояние режима обслуживания
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+	// Since когда режим включен
+	Since             *time.Time `json:"since,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+	// ActiveAnalyses анализы, которые еще выполняются в этом экземпляре
+	// сервиса; обновлять анализатор или БД безопасно, когда их 0
+	ActiveAnalyses int `json:"active_analyses"`
+}
+
+// MaintenanceService режим обслуживания: новые анализы отклоняются, а
+// чтение и уже начатые анализы продолжают работать. Состояние хранится в
+// памяти экземпляра сервиса и сбрасывается при перезапуске.
+type MaintenanceService struct {
+	healthService   *HealthService
+	analyzerService *AnalyzerService
+	logger          *logrus.Logger
+
+	mu         sync.RWMutex
+	enabled    bool
+	reason     string
+	since      time.Time
+	retryAfter time.Duration
+}
+
+// NewMaintenanceService создает сервис режима обслуживания, режим выключен
+func NewMaintenanceService(healthService *HealthService, analyzerService *AnalyzerService, logger *logrus.Logger) *MaintenanceService {
+	return &MaintenanceService{
+		healthService:   healthService,
+		analyzerService: analyzerService,
+		logger:          logger,
+	}
+}
+
+// Enable включает режим обслуживания. retryAfter 0 заменяется на
+// DefaultMaintenanceRetryAfter. Повторный вызов обновляет причину и срок.
+func (s *MaintenanceService) Enable(reason string, retryAfter time.Duration) MaintenanceStatus {
+	if retryAfter <= 0 {
+		retryAfter = DefaultMaintenanceRetryAfter
+	}
+	s.mu.Lock()
+	if !s.enabled {
+		s.since = time.Now()
+	}
+	s.enabled, s.reason, s.retryAfter = true, reason, retryAfter
+	s.mu.Unlock()
+
+	status := s.Status()
+	s.logger.Warnf("Включен режим обслуживания: %s, новые анализы не принимаются, выполняется анализов: %d",
+		reason, status.ActiveAnalyses)
+	return status
+}
+
+// Disable выключает режим обслуживания, если проверка готовности проходит.
+// Иначе возвращает ErrDependenciesUnhealthy с непрошедшими проверками; force
+// выключает режим без проверки.
+func (s *MaintenanceService) Disable(force bool) (MaintenanceStatus, error) {
+	if !force {
+		health := s.healthService.Check()
+		if health.Status != HealthStatusHealthy {
+			var failed []string
+			for _, check := range health.Checks {
+				if check.Status != HealthCheckUp {
+					failed = append(failed, check.Name)
+				}
+			}
+			return s.Status(), fmt.Errorf("%w: %s", ErrDependenciesUnhealthy, strings.Join(failed, ", "))
+		}
+	}
+
+	s.mu.Lock()
+	wasEnabled := s.enabled
+	s.enabled, s.reason, s.since, s.retryAfter = false, "", time.Time{}, 0
+	s.mu.Unlock()
+
+	if wasEnabled {
+		s.logger.Warnf("Режим обслуживания выключен (без проверки готовности: %t), анализы снова принимаются", force)
+	}
+	return s.Status(), nil
+}
+
+// Status возвращает состояние режима обслуживания
+func (s *MaintenanceService) Status() MaintenanceStatus {
+	s.mu.RLock()
+	status := MaintenanceStatus{Enabled: s.enabled, Reason: s.reason}
+	if s.enabled {
+		since := s.since
+		status.Since = &since
+		status.RetryAfterSeconds = int(s.retryAfter.Seconds())
+	}
+	s.mu.RUnlock()
+
+	status.ActiveAnalyses = s.analyzerService.ActiveAnalyses()
+	return status
+}
+
+// Check возвращает *MaintenanceError, если режим обслуживания включен
+func (s *MaintenanceService) Check() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.enabled {
+		return nil
+	}
+	return &MaintenanceError{Reason: s.reason, RetryAfter: s.retryAfter}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/notify"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidNotificationPreferences возвращается при некорректных настройках
+// уведомлений
+var ErrInvalidNotificationPreferences = errors.New("invalid notification preferences")
+
+// NotificationService хранит настройки уведомлений пользователей по почте и
+// отправляет письма о завершении их анализов. Адреса подписчиков оповещений
+// и отчетов получают AlertService и ReportService.
+type NotificationService struct {
+	repo     repository.NotificationRepository
+	notifier *notify.Notifier
+	logger   *logrus.Logger
+
+	// inflight считает фоновые отправки уведомлений
+	inflight sync.WaitGroup
+}
+
+// NewNotificationService создает сервис уведомлений
+func NewNotificationService(repo repository.NotificationRepository, logger *logrus.Logger) *NotificationService {
+	return &NotificationService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// SetNotifier включает отправку уведомлений по почте
+func (s *NotificationService) SetNotifier(notifier *notify.Notifier) {
+	s.notifier = notifier
+}
+
+// GetPreferences возвращает настройки уведомлений пользователя
+func (s *NotificationService) GetPreferences(user *UserIdentity) (*NotificationPreferencesInfo, error) {
+	preference, err := s.repo.GetPreferences(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.preferencesInfo(user, preference), nil
+}
+
+// UpdatePreferences меняет заданные в запросе настройки пользователя.
+// Подписаться можно, только если отправка писем настроена.
+func (s *NotificationService) UpdatePreferences(user *UserIdentity, req UpdateNotificationPreferencesRequest) (*NotificationPreferencesInfo, error) {
+	preference, err := s.repo.GetPreferences(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, update := range []struct {
+		value *bool
+		field *bool
+	}{
+		{req.AnalysisCompleted, &preference.AnalysisCompleted},
+		{req.Alerts, &preference.Alerts},
+		{req.Reports, &preference.Reports},
+	} {
+		if update.value == nil {
+			continue
+		}
+		if *update.value && !s.notifier.EmailEnabled() {
+			return nil, fmt.Errorf("%w: email notifications are not configured", ErrInvalidNotificationPreferences)
+		}
+		*update.field = *update.value
+	}
+
+	if err := s.repo.SavePreferences(preference); err != nil {
+		return nil, err
+	}
+	s.logger.Infof("Настройки уведомлений пользователя %d изменены: анализы %t, оповещения %t, отчеты %t",
+		user.ID, preference.AnalysisCompleted, preference.Alerts, preference.Reports)
+	return s.preferencesInfo(user, preference), nil
+}
+
+// preferencesInfo преобразует настройки в ответ API
+func (s *NotificationService) preferencesInfo(user *UserIdentity, preference *model.NotificationPreference) *NotificationPreferencesInfo {
+	info := &NotificationPreferencesInfo{
+		Email:             user.Email,
+		EmailEnabled:      s.notifier.EmailEnabled(),
+		AnalysisCompleted: preference.AnalysisCompleted,
+		Alerts:            preference.Alerts,
+		Reports:           preference.Reports,
+	}
+	if !preference.UpdatedAt.IsZero() {
+		info.UpdatedAt = &preference.UpdatedAt
+	}
+	return info
+}
+
+// Subscribers возвращает адреса пользователей организации orgID (nil —
+// администраторов сервера), подписанных на уведомления kind. Ошибки
+// записываются в лог, и уведомление отправляется без подписчиков.
+func (s *NotificationService) Subscribers(kind string, orgID *uint) []string {
+	if s == nil || !s.notifier.EmailEnabled() {
+		return nil
+	}
+	emails, err := s.repo.SubscribedEmails(kind, orgID)
+	if err != nil {
+		s.logger.Errorf("Не удалось получить подписчиков уведомлений %s организации %s: %v", kind, formatOrganizationID(orgID), err)
+		return nil
+	}
+	return emails
+}
+
+// AnalysisCompleted отправляет в фоне письмо о завершенном анализе
+// владельцу маршрута, если он подписан на такие уведомления
+func (s *NotificationService) AnalysisCompleted(routeID string, metadata RouteMetadata, result *AnalysisResult) {
+	if s == nil || metadata.OwnerID == nil || !s.notifier.EmailEnabled() {
+		return
+	}
+
+	stats := result.OverallStats
+	message := notify.AnalysisMessage{
+		RouteID:          routeID,
+		Name:             metadata.Name,
+		RoadName:         result.RoadName,
+		URL:              s.notifier.RouteURL(routeID),
+		DistanceKm:       stats.TotalDistanceMeters / 1000,
+		TotalSegments:    stats.TotalSegments,
+		SegmentsWithData: stats.SegmentsWithData,
+		AverageCoverage:  stats.AverageCoverage,
+		Band:             stats.CoverageBand,
+		TotalDefects:     stats.TotalDefects,
+		QualityScore:     stats.QualityScore,
+	}
+	if message.Name == "" {
+		message.Name = routeID
+	}
+	ownerID := *metadata.OwnerID
+
+	s.inflight.Add(1)
+	go func() {
+		defer s.inflight.Done()
+		email, err := s.repo.SubscribedEmail(model.NotificationAnalysisCompleted, ownerID)
+		if err != nil {
+			s.logger.Errorf("Уведомление о маршруте %s не отправлено: %v", routeID, err)
+			return
+		}
+		if email == "" {
+			return
+		}
+		subject, text, err := s.notifier.Render(notify.TemplateAnalysisCompleted, message)
+		if err != nil {
+			s.logger.Errorf("Уведомление о маршруте %s не отправлено: %v", routeID, err)
+			return
+		}
+		if err := s.notifier.SendEmail([]string{email}, subject, text); err != nil {
+			s.logger.Errorf("Уведомление о маршруте %s не отправлено по почте: %v", routeID, err)
+		}
+	}()
+}
+
+// Shutdown ждет завершения фоновых отправок уведомлений, но не дольше
+// timeout. Возвращает false, если отправки не успели завершиться.
+func (s *NotificationService) Shutdown(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// mergeRecipients объединяет списки получателей без повторов
+func mergeRecipients(lists ...[]string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, recipient := range list {
+			if !seen[recipient] {
+				seen[recipient] = true
+				result = append(result, recipient)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidOrganizationRequest возвращается при некорректных данных организации
+// или участника
+var ErrInvalidOrganizationRequest = errors.New("invalid organization request")
+
+// ErrNotOrganizationMember возвращается, если пользователь не состоит в организации,
+// от имени которой выполняется запрос
+var ErrNotOrganizationMember = errors.New("not an organization member")
+
+const maxOrganizationNameLength = 200
+
+// organizationSlugPattern допустимый короткий идентификатор организации
+var organizationSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// OrganizationService сервис организаций и их участников
+type OrganizationService struct {
+	orgRepo  repository.OrganizationRepository
+	userRepo repository.UserRepository
+	logger   *logrus.Logger
+}
+
+// NewOrganizationService создает новый сервис организаций
+func NewOrganizationService(orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, logger *logrus.Logger) *OrganizationService {
+	return &OrganizationService{
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// CreateOrganization создает организацию
+func (s *OrganizationService) CreateOrganization(req CreateOrganizationRequest) (*OrganizationInfo, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidOrganizationRequest)
+	}
+	if utf8.RuneCountInString(name) > maxOrganizationNameLength {
+		return nil, fmt.Errorf("%w: name is longer than %d characters", ErrInvalidOrganizationRequest, maxOrganizationNameLength)
+	}
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !organizationSlugPattern.MatchString(slug) {
+		return nil, fmt.Errorf("%w: slug must be 1-64 lowercase latin letters, digits or dashes", ErrInvalidOrganizationRequest)
+	}
+
+	org := &model.Organization{Name: name, Slug: slug}
+	if err := s.orgRepo.Create(org); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	s.logger.Infof("Создана организация %d (%s)", org.ID, org.Slug)
+	info := organizationInfo(org, "")
+	return &info, nil
+}
+
+// ListOrganizations возвращает все организации
+func (s *OrganizationService) ListOrganizations() ([]OrganizationInfo, error) {
+	orgs, err := s.orgRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	result := make([]OrganizationInfo, len(orgs))
+	for i := range orgs {
+		result[i] = organizationInfo(&orgs[i], "")
+	}
+	return result, nil
+}
+
+// ListUserOrganizations возвращает организации пользователя с его ролью в них
+func (s *OrganizationService) ListUserOrganizations(userID uint) ([]OrganizationInfo, error) {
+	memberships, err := s.orgRepo.ListForUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user organizations: %w", err)
+	}
+
+	result := make([]OrganizationInfo, len(memberships))
+	for i := range memberships {
+		result[i] = organizationInfo(&memberships[i].Organization, memberships[i].Role)
+	}
+	return result, nil
+}
+
+// ResolveTenant определяет организацию запроса пользователя. Если orgID
+// не указан, используется единственная организация пользователя, а при
+// нескольких или ни одной возвращается nil. Запрос от имени организации,
+// в которой пользователь не состоит, возвращает ErrNotOrganizationMember.
+func (s *OrganizationService) ResolveTenant(userID uint, orgID *uint) (*Tenant, error) {
+	if orgID == nil {
+		memberships, err := s.orgRepo.ListForUser(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list user organizations: %w", err)
+		}
+		if len(memberships) != 1 {
+			return nil, nil
+		}
+		return &Tenant{OrganizationID: memberships[0].ID, Role: memberships[0].Role}, nil
+	}
+
+	member, err := s.orgRepo.GetMember(*orgID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMemberNotFound) {
+			return nil, fmt.Errorf("%w: organization %d", ErrNotOrganizationMember, *orgID)
+		}
+		return nil, fmt.Errorf("failed to get organization member: %w", err)
+	}
+	return &Tenant{OrganizationID: member.OrganizationID, Role: member.Role}, nil
+}
+
+// CheckOrganization проверяет, что организация существует
+func (s *OrganizationService) CheckOrganization(orgID uint) error {
+	if _, err := s.orgRepo.GetByID(orgID); err != nil {
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+	return nil
+}
+
+// ListMembers возвращает участников организации
+func (s *OrganizationService) ListMembers(orgID uint) ([]OrganizationMemberInfo, error) {
+	if err := s.CheckOrganization(orgID); err != nil {
+		return nil, err
+	}
+	members, err := s.orgRepo.ListMembers(orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization members: %w", err)
+	}
+
+	result := make([]OrganizationMemberInfo, len(members))
+	for i, member := range members {
+		result[i] = OrganizationMemberInfo{
+			UserID:    member.UserID,
+			Email:     member.Email,
+			Role:      member.Role,
+			CreatedAt: member.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+// AddMember добавляет зарегистрированного пользователя в организацию
+// или меняет его роль
+func (s *OrganizationService) AddMember(orgID uint, req AddMemberRequest) (*OrganizationMemberInfo, error) {
+	role := req.Role
+	if role == "" {
+		role = model.OrgRoleMember
+	}
+	if role != model.OrgRoleMember && role != model.OrgRoleAdmin {
+		return nil, fmt.Errorf("%w: role must be %s or %s", ErrInvalidOrganizationRequest, model.OrgRoleMember, model.OrgRoleAdmin)
+	}
+	if err := s.CheckOrganization(orgID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	member := &model.OrganizationMember{OrganizationID: orgID, UserID: user.ID, Role: role}
+	if err := s.orgRepo.AddMember(member); err != nil {
+		return nil, fmt.Errorf("failed to add organization member: %w", err)
+	}
+
+	s.logger.Infof("Пользователь %d (%s) добавлен в организацию %d, роль: %s", user.ID, user.Email, orgID, role)
+	return &OrganizationMemberInfo{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      role,
+		CreatedAt: member.CreatedAt,
+	}, nil
+}
+
+// RemoveMember исключает пользователя из организации. Его маршруты
+// остаются в организации.
+func (s *OrganizationService) RemoveMember(orgID, userID uint) error {
+	if err := s.orgRepo.RemoveMember(orgID, userID); err != nil {
+		return fmt.Errorf("failed to remove organization member: %w", err)
+	}
+	s.logger.Infof("Пользователь %d исключен из организации %d", userID, orgID)
+	return nil
+}
+
+// organizationInfo преобразует организацию в ответ API
+func organizationInfo(org *model.Organization, role string) OrganizationInfo {
+	return OrganizationInfo{
+		ID:        org.ID,
+		Name:      org.Name,
+		Slug:      org.Slug,
+		Role:      role,
+		CreatedAt: org.CreatedAt,
+	}
+}
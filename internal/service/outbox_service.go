@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// outboxBatchSize сколько событий публикуется за одну транзакцию
+const outboxBatchSize = 100
+
+// OutboxOptions настройки публикации событий
+type OutboxOptions struct {
+	// Interval как часто проверять неопубликованные события. Событие,
+	// сохраненное этим экземпляром сервиса, публикуется сразу.
+	Interval time.Duration
+	// Retention сколько хранить опубликованные события
+	Retention time.Duration
+}
+
+// OutboxService сохраняет события в таблицу outbox_events и публикует их
+// подписчикам в фоне. Событие записывается в одной транзакции с изменением,
+// о котором сообщает, поэтому не теряется при падении сервиса между
+// сохранением и отправкой: неопубликованные события публикуются после
+// перезапуска. Подписчики получают событие хотя бы один раз.
+type OutboxService struct {
+	outboxRepo repository.OutboxRepository
+	webhooks   *WebhookService
+	logger     *logrus.Logger
+	opts       OutboxOptions
+	now        func() time.Time
+
+	// wake будит ретранслятор после сохранения события
+	wake chan struct{}
+}
+
+// NewOutboxService создает сервис событий с настройками по умолчанию:
+// проверка раз в 5 секунд, опубликованные события хранятся 7 дней
+func NewOutboxService(outboxRepo repository.OutboxRepository, webhooks *WebhookService, logger *logrus.Logger) *OutboxService {
+	s := &OutboxService{
+		outboxRepo: outboxRepo,
+		webhooks:   webhooks,
+		logger:     logger,
+		now:        time.Now,
+		wake:       make(chan struct{}, 1),
+	}
+	s.SetOptions(OutboxOptions{})
+	return s
+}
+
+// SetOptions задает настройки публикации, нулевые поля заменяются
+// значениями по умолчанию
+func (s *OutboxService) SetOptions(opts OutboxOptions) {
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = 7 * 24 * time.Hour
+	}
+	s.opts = opts
+}
+
+// NewEvent создает событие для сохранения в одной транзакции с изменением
+func (s *OutboxService) NewEvent(event string, orgID *uint, data interface{}) (*model.OutboxEvent, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+	return &model.OutboxEvent{
+		EventID:        uuid.NewString(),
+		Event:          event,
+		OrganizationID: orgID,
+		Data:           string(encoded),
+		CreatedAt:      s.now().UTC(),
+	}, nil
+}
+
+// Add сохраняет событие, которое не связано с изменением в базе данных
+func (s *OutboxService) Add(event *model.OutboxEvent) error {
+	if err := s.outboxRepo.Create(event); err != nil {
+		return err
+	}
+	s.Notify()
+	return nil
+}
+
+// Notify сообщает ретранслятору о новом событии, чтобы не ждать следующей проверки
+func (s *OutboxService) Notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run публикует неопубликованные события и удаляет старые опубликованные,
+// пока не отменен ctx
+func (s *OutboxService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(time.Hour)
+	defer pruneTicker.Stop()
+
+	s.relay()
+	s.prune()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			s.relay()
+		case <-ticker.C:
+			s.relay()
+		case <-pruneTicker.C:
+			s.prune()
+		}
+	}
+}
+
+// relay публикует неопубликованные события пачками, пока они не закончатся
+// или публикация не перестанет продвигаться
+func (s *OutboxService) relay() {
+	for {
+		published, err := s.outboxRepo.Relay(outboxBatchSize, s.publish)
+		if err != nil {
+			s.logger.Errorf("Ошибка публикации событий: %v", err)
+			return
+		}
+		if published > 0 {
+			s.logger.Debugf("Опубликовано событий: %d", published)
+		}
+		if published < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// publish передает событие подписчикам
+func (s *OutboxService) publish(event *model.OutboxEvent) error {
+	if err := s.webhooks.Publish(event); err != nil {
+		s.logger.Warnf("Событие %s %s не опубликовано, будет повторено: %v", event.Event, event.EventID, err)
+		return err
+	}
+	return nil
+}
+
+// prune удаляет опубликованные события старше Retention
+func (s *OutboxService) prune() {
+	deleted, err := s.outboxRepo.DeletePublished(s.now().Add(-s.opts.Retention))
+	if err != nil {
+		s.logger.Errorf("Ошибка удаления опубликованных событий: %v", err)
+		return
+	}
+	if deleted > 0 {
+		s.logger.Infof("Удалено опубликованных событий старше %s: %d", s.opts.Retention, deleted)
+	}
+}
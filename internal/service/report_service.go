@@ -0,0 +1,613 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/notify"
+	"road-detector-go/internal/report"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidReportSchedule возвращается при некорректных данных расписания отчетов
+var ErrInvalidReportSchedule = errors.New("invalid report schedule")
+
+const (
+	maxReportScheduleNameLength = 255
+	// reportWorstSegments сколько худших сегментов включается в отчет
+	reportWorstSegments = 10
+	// reportBatchSize сколько расписаний обрабатывается за одну проверку
+	reportBatchSize = 50
+)
+
+// ReportOptions настройки сводных отчетов
+type ReportOptions struct {
+	// Interval как часто проверять расписания, у которых закончился период
+	Interval time.Duration
+	// Render отрисовка отчетов в HTML и PDF
+	Render report.Options
+}
+
+// ReportService создает сводные отчеты организаций по расписаниям: число
+// новых анализов, худшие сегменты и изменение покрытия к предыдущему периоду.
+// Отчет сохраняется вместе с событием report.generated для вебхуков, письма
+// с отчетом отправляются в фоне без повторов.
+type ReportService struct {
+	reportRepo repository.ReportRepository
+	renderer   *report.Renderer
+	outbox     *OutboxService
+	notifier   *notify.Notifier
+	logger     *logrus.Logger
+	// notifications адреса пользователей, подписанных на отчеты, nil —
+	// отчеты получают только адресаты расписаний
+	notifications *NotificationService
+	opts          ReportOptions
+	now           func() time.Time
+
+	// inflight считает фоновые отправки отчетов
+	inflight sync.WaitGroup
+}
+
+// NewReportService создает сервис отчетов
+func NewReportService(reportRepo repository.ReportRepository, opts ReportOptions, logger *logrus.Logger) *ReportService {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Minute
+	}
+	return &ReportService{
+		reportRepo: reportRepo,
+		renderer:   report.New(opts.Render),
+		logger:     logger,
+		opts:       opts,
+		now:        time.Now,
+	}
+}
+
+// SetEventOutbox включает публикацию отчетов вебхукам
+func (s *ReportService) SetEventOutbox(outbox *OutboxService) {
+	s.outbox = outbox
+}
+
+// SetNotifier включает отправку отчетов по почте
+func (s *ReportService) SetNotifier(notifier *notify.Notifier) {
+	s.notifier = notifier
+}
+
+// SetNotificationService включает отправку отчетов пользователям,
+// подписанным на них в настройках уведомлений
+func (s *ReportService) SetNotificationService(notifications *NotificationService) {
+	s.notifications = notifications
+}
+
+// CreateSchedule создает расписание организации orgID (nil — отчеты по всем
+// маршрутам). Первый отчет создается после окончания текущего периода.
+func (s *ReportService) CreateSchedule(orgID *uint, req CreateReportScheduleRequest) (*ReportScheduleInfo, error) {
+	schedule := &model.ReportSchedule{
+		OrganizationID: orgID,
+		Format:         model.ReportFormatHTML,
+		Webhook:        req.Webhook,
+		Active:         req.Active == nil || *req.Active,
+	}
+	update := UpdateReportScheduleRequest{
+		Name:   &req.Name,
+		Period: &req.Period,
+		Emails: req.Emails,
+	}
+	if req.Format != "" {
+		update.Format = &req.Format
+	}
+	if err := s.applyScheduleRequest(schedule, update); err != nil {
+		return nil, err
+	}
+	if err := s.reportRepo.CreateSchedule(schedule); err != nil {
+		return nil, fmt.Errorf("failed to create report schedule: %w", err)
+	}
+
+	s.logger.Infof("Создано расписание отчетов %d %q (%s), организация: %s",
+		schedule.ID, schedule.Name, schedule.Period, formatOrganizationID(orgID))
+	info := reportScheduleInfo(schedule)
+	return &info, nil
+}
+
+// ListSchedules возвращает расписания организации
+func (s *ReportService) ListSchedules(orgID *uint) ([]ReportScheduleInfo, error) {
+	schedules, err := s.reportRepo.ListSchedules(orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list report schedules: %w", err)
+	}
+
+	result := make([]ReportScheduleInfo, len(schedules))
+	for i := range schedules {
+		result[i] = reportScheduleInfo(&schedules[i])
+	}
+	return result, nil
+}
+
+// GetSchedule возвращает расписание организации
+func (s *ReportService) GetSchedule(id uint, orgID *uint) (*ReportScheduleInfo, error) {
+	schedule, err := s.reportRepo.GetSchedule(id, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report schedule: %w", err)
+	}
+	info := reportScheduleInfo(schedule)
+	return &info, nil
+}
+
+// UpdateSchedule меняет название, период, формат, получателей или признак
+// активности расписания
+func (s *ReportService) UpdateSchedule(id uint, orgID *uint, req UpdateReportScheduleRequest) (*ReportScheduleInfo, error) {
+	schedule, err := s.reportRepo.GetSchedule(id, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report schedule: %w", err)
+	}
+	if err := s.applyScheduleRequest(schedule, req); err != nil {
+		return nil, err
+	}
+	if req.Webhook != nil {
+		schedule.Webhook = *req.Webhook
+	}
+	if req.Active != nil {
+		schedule.Active = *req.Active
+	}
+	if err := s.reportRepo.UpdateSchedule(schedule); err != nil {
+		return nil, fmt.Errorf("failed to update report schedule: %w", err)
+	}
+
+	s.logger.Infof("Расписание отчетов %d изменено, активно: %t", schedule.ID, schedule.Active)
+	info := reportScheduleInfo(schedule)
+	return &info, nil
+}
+
+// DeleteSchedule удаляет расписание организации
+func (s *ReportService) DeleteSchedule(id uint, orgID *uint) error {
+	if err := s.reportRepo.DeleteSchedule(id, orgID); err != nil {
+		return fmt.Errorf("failed to delete report schedule: %w", err)
+	}
+	s.logger.Infof("Расписание отчетов %d удалено", id)
+	return nil
+}
+
+// applyScheduleRequest проверяет заданные в запросе поля и переносит их в расписание
+func (s *ReportService) applyScheduleRequest(schedule *model.ReportSchedule, req UpdateReportScheduleRequest) error {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > maxReportScheduleNameLength {
+			return fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidReportSchedule, maxReportScheduleNameLength)
+		}
+		schedule.Name = name
+	}
+	if req.Period != nil {
+		period := strings.ToLower(strings.TrimSpace(*req.Period))
+		if period != model.ReportPeriodWeekly && period != model.ReportPeriodMonthly {
+			return fmt.Errorf("%w: period must be weekly or monthly", ErrInvalidReportSchedule)
+		}
+		if period != schedule.Period {
+			schedule.Period = period
+			schedule.NextRunAt = addReportPeriod(period, reportPeriodStart(period, s.now()), 1)
+		}
+	}
+	if req.Format != nil {
+		format := strings.ToLower(strings.TrimSpace(*req.Format))
+		switch format {
+		case model.ReportFormatHTML:
+		case model.ReportFormatPDF:
+			if !s.renderer.PDFEnabled() {
+				return fmt.Errorf("%w: pdf reports are not configured", ErrInvalidReportSchedule)
+			}
+		default:
+			return fmt.Errorf("%w: format must be html or pdf", ErrInvalidReportSchedule)
+		}
+		schedule.Format = format
+	}
+	if req.Emails != nil {
+		emails, err := normalizeAlertRecipients(req.Emails, "emails", ErrInvalidReportSchedule, func(email string) bool {
+			address, err := mail.ParseAddress(email)
+			return err == nil && address.Address == email
+		})
+		if err != nil {
+			return err
+		}
+		if len(emails) > 0 && !s.notifier.EmailEnabled() {
+			return fmt.Errorf("%w: email notifications are not configured", ErrInvalidReportSchedule)
+		}
+		schedule.Emails = strings.Join(emails, ",")
+	}
+	return nil
+}
+
+// ListReports возвращает страницу отчетов организации без их данных.
+// scheduleID, если не 0, оставляет отчеты одного расписания.
+func (s *ReportService) ListReports(orgID *uint, scheduleID uint, page, pageSize int) (*ListReportsResponse, error) {
+	reports, total, err := s.reportRepo.ListReports(orgID, scheduleID, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+
+	result := make([]ReportInfo, len(reports))
+	for i := range reports {
+		result[i] = reportInfo(&reports[i], nil)
+	}
+	return &ListReportsResponse{Reports: result, Total: total, Page: page, Size: pageSize}, nil
+}
+
+// GetReport возвращает отчет организации вместе с его данными
+func (s *ReportService) GetReport(id uint, orgID *uint) (*ReportInfo, error) {
+	rep, data, err := s.getReport(id, orgID)
+	if err != nil {
+		return nil, err
+	}
+	info := reportInfo(rep, data)
+	return &info, nil
+}
+
+// RenderReport отрисовывает отчет организации в format: html или pdf.
+// Возвращает содержимое, тип содержимого и имя файла.
+func (s *ReportService) RenderReport(ctx context.Context, id uint, orgID *uint, format string) ([]byte, string, string, error) {
+	rep, data, err := s.getReport(id, orgID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	content, contentType, err := s.render(ctx, *data, format)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return content, contentType, reportFilename(rep, format), nil
+}
+
+// getReport получает отчет организации и разбирает его данные
+func (s *ReportService) getReport(id uint, orgID *uint) (*model.Report, *report.Data, error) {
+	rep, err := s.reportRepo.GetReport(id, orgID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get report: %w", err)
+	}
+	var data report.Data
+	if err := json.Unmarshal([]byte(rep.Data), &data); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode report %d: %w", rep.ID, err)
+	}
+	return rep, &data, nil
+}
+
+// render отрисовывает данные отчета в format
+func (s *ReportService) render(ctx context.Context, data report.Data, format string) ([]byte, string, error) {
+	if format == model.ReportFormatPDF {
+		content, err := s.renderer.PDF(ctx, data)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to render pdf report: %w", err)
+		}
+		return content, "application/pdf", nil
+	}
+	content, err := s.renderer.HTML(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, "text/html; charset=utf-8", nil
+}
+
+// PDFEnabled проверяет, можно ли отрисовать отчет в PDF
+func (s *ReportService) PDFEnabled() bool {
+	return s.renderer.PDFEnabled()
+}
+
+// RunNow создает и доставляет отчет расписания организации за последний
+// завершенный период, не меняя время следующего отчета
+func (s *ReportService) RunNow(id uint, orgID *uint) (*ReportInfo, error) {
+	schedule, err := s.reportRepo.GetSchedule(id, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report schedule: %w", err)
+	}
+	rep, data, err := s.generate(schedule, s.now())
+	if err != nil {
+		return nil, err
+	}
+	info := reportInfo(rep, data)
+	return &info, nil
+}
+
+// Run создает отчеты расписаний, у которых закончился период, сразу и затем
+// каждые Interval, пока не отменен ctx
+func (s *ReportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		s.runDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue создает отчеты расписаний, у которых закончился период. Если
+// сервис не работал несколько периодов, создается только отчет за последний.
+func (s *ReportService) runDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := s.now()
+		schedules, err := s.reportRepo.ListDueSchedules(now, reportBatchSize)
+		if err != nil {
+			s.logger.Errorf("Ошибка получения расписаний отчетов: %v", err)
+			return
+		}
+
+		for i := range schedules {
+			if ctx.Err() != nil {
+				return
+			}
+			schedule := &schedules[i]
+			next := addReportPeriod(schedule.Period, reportPeriodStart(schedule.Period, now), 1)
+			claimed, err := s.reportRepo.ClaimSchedule(schedule, next)
+			if err != nil {
+				s.logger.Errorf("Ошибка переноса расписания отчетов %d: %v", schedule.ID, err)
+				return
+			}
+			if !claimed {
+				continue
+			}
+			if _, _, err := s.generate(schedule, now); err != nil {
+				s.logger.Errorf("Отчет по расписанию %d не создан: %v", schedule.ID, err)
+			}
+		}
+		if len(schedules) < reportBatchSize {
+			return
+		}
+	}
+}
+
+// generate создает отчет расписания за последний завершенный к моменту now
+// период, сохраняет его и доставляет получателям
+func (s *ReportService) generate(schedule *model.ReportSchedule, now time.Time) (*model.Report, *report.Data, error) {
+	to := reportPeriodStart(schedule.Period, now)
+	from := addReportPeriod(schedule.Period, to, -1)
+	data, err := s.collect(schedule, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	rep := &model.Report{
+		ScheduleID:     schedule.ID,
+		OrganizationID: schedule.OrganizationID,
+		Period:         schedule.Period,
+		PeriodStart:    from,
+		PeriodEnd:      to,
+		Data:           string(encoded),
+	}
+	if err := s.createReport(schedule, rep, data); err != nil {
+		return nil, nil, fmt.Errorf("failed to save report: %w", err)
+	}
+	s.logger.Infof("Создан отчет %d по расписанию %d за %s — %s: анализов %d",
+		rep.ID, schedule.ID, from.Format(time.DateOnly), to.Format(time.DateOnly), data.Analyses)
+
+	s.deliver(schedule, rep, *data)
+	return rep, data, nil
+}
+
+// collect собирает данные отчета за период [from, to) и предыдущий период
+func (s *ReportService) collect(schedule *model.ReportSchedule, from, to time.Time) (*report.Data, error) {
+	scope := repository.RouteScope{OrganizationID: schedule.OrganizationID}
+	current, err := s.reportRepo.Summarize(scope, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize report period: %w", err)
+	}
+	previous, err := s.reportRepo.Summarize(scope, addReportPeriod(schedule.Period, from, -1), from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize previous report period: %w", err)
+	}
+	worst, err := s.reportRepo.WorstSegments(scope, from, to, reportWorstSegments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worst segments: %w", err)
+	}
+
+	data := &report.Data{
+		Title:                   schedule.Name,
+		Period:                  schedule.Period,
+		From:                    from,
+		To:                      to,
+		Analyses:                current.Analyses,
+		DistanceKm:              math.Round(current.DistanceMeters/10) / 100,
+		SegmentsWithData:        current.SegmentsWithData,
+		AverageCoverage:         roundReportCoverage(current.AverageCoverage),
+		PreviousAnalyses:        previous.Analyses,
+		PreviousAverageCoverage: roundReportCoverage(previous.AverageCoverage),
+		WorstSegments:           make([]report.Segment, len(worst)),
+	}
+	if data.AverageCoverage != nil && data.PreviousAverageCoverage != nil {
+		change := math.Round((*data.AverageCoverage-*data.PreviousAverageCoverage)*100) / 100
+		data.CoverageChange = &change
+	}
+	for i, seg := range worst {
+		data.WorstSegments[i] = report.Segment(seg)
+	}
+	return data, nil
+}
+
+// createReport сохраняет отчет вместе с событием для вебхуков, если они
+// включены в расписании
+func (s *ReportService) createReport(schedule *model.ReportSchedule, rep *model.Report, data *report.Data) error {
+	if s.outbox == nil || !schedule.Webhook {
+		return s.reportRepo.CreateReport(rep, nil)
+	}
+
+	err := s.reportRepo.CreateReport(rep, func(rep *model.Report) ([]*model.OutboxEvent, error) {
+		event, err := s.outbox.NewEvent(model.WebhookEventReportGenerated, rep.OrganizationID, ReportEventData{
+			ReportID:       rep.ID,
+			ScheduleID:     rep.ScheduleID,
+			OrganizationID: rep.OrganizationID,
+			Period:         rep.Period,
+			PeriodStart:    rep.PeriodStart,
+			PeriodEnd:      rep.PeriodEnd,
+			Report:         *data,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []*model.OutboxEvent{event}, nil
+	})
+	if err != nil {
+		return err
+	}
+	s.outbox.Notify()
+	return nil
+}
+
+// deliver отправляет отчет в фоне получателям расписания и пользователям
+// организации, подписанным на отчеты. Если PDF не удалось создать, к письму
+// прикладывается HTML.
+func (s *ReportService) deliver(schedule *model.ReportSchedule, rep *model.Report, data report.Data) {
+	emails := splitRecipients(schedule.Emails)
+	if len(emails) == 0 && s.notifications == nil {
+		return
+	}
+
+	format := schedule.Format
+	orgID := schedule.OrganizationID
+	s.inflight.Add(1)
+	go func() {
+		defer s.inflight.Done()
+		emails = mergeRecipients(emails, s.notifications.Subscribers(model.NotificationReports, orgID))
+		if len(emails) == 0 {
+			return
+		}
+		subject, text, err := s.notifier.Render(notify.TemplateReport, notify.ReportMessage{
+			Title:   data.Title,
+			From:    data.From,
+			To:      data.To.Add(-time.Second),
+			Summary: report.Text(data),
+		})
+		if err != nil {
+			s.logger.Errorf("Отчет %d не отправлен: %v", rep.ID, err)
+			return
+		}
+		html, err := s.renderer.HTML(data)
+		if err != nil {
+			s.logger.Errorf("Отчет %d не отправлен: %v", rep.ID, err)
+			return
+		}
+
+		attachment := notify.Attachment{
+			Filename:    reportFilename(rep, model.ReportFormatHTML),
+			ContentType: "text/html; charset=utf-8",
+			Data:        html,
+		}
+		if format == model.ReportFormatPDF {
+			content, err := s.renderer.PDF(context.Background(), data)
+			if err != nil {
+				s.logger.Errorf("PDF отчета %d не создан, прикладывается HTML: %v", rep.ID, err)
+			} else {
+				attachment = notify.Attachment{
+					Filename:    reportFilename(rep, model.ReportFormatPDF),
+					ContentType: "application/pdf",
+					Data:        content,
+				}
+			}
+		}
+
+		err = s.notifier.Send(notify.Email{
+			To:          emails,
+			Subject:     subject,
+			Text:        text,
+			HTML:        string(html),
+			Attachments: []notify.Attachment{attachment},
+		})
+		if err != nil {
+			s.logger.Errorf("Отчет %d не отправлен по почте: %v", rep.ID, err)
+		}
+	}()
+}
+
+// Shutdown ждет завершения фоновых отправок отчетов, но не дольше timeout.
+// Возвращает false, если отправки не успели завершиться.
+func (s *ReportService) Shutdown(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// reportPeriodStart начало периода, в который попадает t: понедельник
+// недели или первое число месяца по UTC
+func reportPeriodStart(period string, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if period == model.ReportPeriodMonthly {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// addReportPeriod сдвигает начало периода на n периодов
+func addReportPeriod(period string, start time.Time, n int) time.Time {
+	if period == model.ReportPeriodMonthly {
+		return start.AddDate(0, n, 0)
+	}
+	return start.AddDate(0, 0, 7*n)
+}
+
+// roundReportCoverage округляет покрытие до сотых
+func roundReportCoverage(value *float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	rounded := math.Round(*value*100) / 100
+	return &rounded
+}
+
+// reportFilename имя файла отчета в формате format
+func reportFilename(rep *model.Report, format string) string {
+	return fmt.Sprintf("report-%d-%s.%s", rep.ID, rep.PeriodStart.Format(time.DateOnly), format)
+}
+
+// reportScheduleInfo преобразует расписание в ответ API
+func reportScheduleInfo(schedule *model.ReportSchedule) ReportScheduleInfo {
+	info := ReportScheduleInfo{
+		ID:             schedule.ID,
+		OrganizationID: schedule.OrganizationID,
+		Name:           schedule.Name,
+		Period:         schedule.Period,
+		Format:         schedule.Format,
+		Emails:         splitRecipients(schedule.Emails),
+		Webhook:        schedule.Webhook,
+		Active:         schedule.Active,
+		NextRunAt:      schedule.NextRunAt,
+		LastRunAt:      schedule.LastRunAt,
+		CreatedAt:      schedule.CreatedAt,
+		UpdatedAt:      schedule.UpdatedAt,
+	}
+	if info.Emails == nil {
+		info.Emails = []string{}
+	}
+	return info
+}
+
+// reportInfo преобразует отчет в ответ API
+func reportInfo(rep *model.Report, data *report.Data) ReportInfo {
+	return ReportInfo{
+		ID:             rep.ID,
+		ScheduleID:     rep.ScheduleID,
+		OrganizationID: rep.OrganizationID,
+		Period:         rep.Period,
+		PeriodStart:    rep.PeriodStart,
+		PeriodEnd:      rep.PeriodEnd,
+		CreatedAt:      rep.CreatedAt,
+		Data:           data,
+	}
+}
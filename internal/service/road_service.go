@@ -0,0 +1,328 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"road-detector-go/internal/coverageband"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// roadCorridorMeters ширина коридора, в котором проезды считаются одной дорогой
+	roadCorridorMeters = 20.0
+	// roadMinOverlap минимальная доля сегментов маршрута, совпавших с дорогой,
+	// чтобы маршрут был отнесен к ней
+	roadMinOverlap = 0.5
+	// roadRebuildPageSize размер страницы маршрутов при полном пересчете
+	roadRebuildPageSize = 100
+)
+
+// RoadService объединяет проезды по одной улице в логические дороги
+// с покрытием, агрегированным по сегментам
+type RoadService struct {
+	roadRepo  repository.RoadRepository
+	routeRepo repository.RouteRepository
+	logger    *logrus.Logger
+	// bands полосы покрытия для ответов, nil — не задаются
+	bands *coverageband.Classifier
+
+	// mu исключает одновременное создание двух дорог для одного коридора
+	mu sync.Mutex
+}
+
+// NewRoadService создает новый сервис дорог
+func NewRoadService(roadRepo repository.RoadRepository, routeRepo repository.RouteRepository, logger *logrus.Logger) *RoadService {
+	return &RoadService{
+		roadRepo:  roadRepo,
+		routeRepo: routeRepo,
+		logger:    logger,
+	}
+}
+
+// AssignRoute относит маршрут к дороге, с которой он совпадает не менее чем на
+// roadMinOverlap, либо создает новую дорогу. Сегменты маршрута вне дороги
+// добавляются к ней как новые участки.
+func (s *RoadService) AssignRoute(route *model.Route) error {
+	if len(route.Segments) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	candidates, err := s.roadRepo.GetCandidates(route, roadCorridorMeters)
+	if err != nil {
+		return err
+	}
+
+	road, matches := s.bestRoad(route, candidates)
+	if road == nil {
+		road, err = s.createRoad(route)
+		if err != nil {
+			return err
+		}
+		// Сегменты новой дороги совпадают с сегментами маршрута по порядку
+		matches = make([]int, len(route.Segments))
+		for i := range matches {
+			matches[i] = i
+		}
+		s.logger.Infof("Для маршрута %s создана новая дорога %s", route.ID, road.ID)
+	} else {
+		if err := s.extendRoad(road, route, matches); err != nil {
+			return err
+		}
+		s.logger.Infof("Маршрут %s отнесен к дороге %s", route.ID, road.ID)
+	}
+
+	observations := make([]model.RoadObservation, 0, len(route.Segments))
+	for i, seg := range route.Segments {
+		if !seg.HasData {
+			continue
+		}
+		observations = append(observations, model.RoadObservation{
+			RoadID:             road.ID,
+			RoadSegmentID:      road.Segments[matches[i]].ID,
+			RouteID:            route.ID,
+			SegmentID:          seg.SegmentID,
+			CoveragePercentage: seg.CoveragePercentage,
+			ObservedAt:         route.CreatedAt,
+		})
+	}
+	if err := s.roadRepo.AddObservations(observations); err != nil {
+		return err
+	}
+
+	return s.roadRepo.Recalculate(road.ID)
+}
+
+// RemoveRoute удаляет проезды маршрута из дорог и пересчитывает их
+func (s *RoadService) RemoveRoute(routeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roadIDs, err := s.roadRepo.DeleteRouteObservations(routeID)
+	if err != nil {
+		return err
+	}
+	for _, roadID := range roadIDs {
+		if err := s.roadRepo.Recalculate(roadID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rebuild удаляет все дороги и заново строит их по сохраненным маршрутам
+func (s *RoadService) Rebuild() (*RoadRebuildResponse, error) {
+	s.logger.Info("Полный пересчет дорог по всем маршрутам")
+
+	if err := s.roadRepo.DeleteAll(); err != nil {
+		s.logger.Errorf("Ошибка удаления дорог: %v", err)
+		return nil, fmt.Errorf("failed to rebuild roads: %w", err)
+	}
+
+	response := &RoadRebuildResponse{}
+	for page := 1; ; page++ {
+		routes, total, err := s.routeRepo.List(page, roadRebuildPageSize, repository.RouteListQuery{WithSegments: true, SortBy: repository.RouteSortCreatedAt})
+		if err != nil {
+			s.logger.Errorf("Ошибка получения маршрутов для пересчета дорог: %v", err)
+			return nil, fmt.Errorf("failed to rebuild roads: %w", err)
+		}
+
+		for _, route := range routes {
+			if err := s.AssignRoute(route); err != nil {
+				s.logger.Errorf("Ошибка добавления маршрута %s в дороги: %v", route.ID, err)
+				return nil, fmt.Errorf("failed to rebuild roads: %w", err)
+			}
+			response.Routes++
+		}
+
+		if int64(page*roadRebuildPageSize) >= total {
+			break
+		}
+	}
+
+	_, roads, err := s.roadRepo.List(1, 1, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count roads: %w", err)
+	}
+	response.Roads = roads
+
+	s.logger.Infof("Пересчет дорог завершен: маршрутов %d, дорог %d", response.Routes, response.Roads)
+	return response, nil
+}
+
+// GetRoad получает дорогу с агрегированными сегментами
+func (s *RoadService) GetRoad(roadID string) (*RoadResponse, error) {
+	s.logger.Infof("Получаем дорогу %s", roadID)
+
+	road, err := s.roadRepo.GetByID(roadID)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения дороги: %v", err)
+		return nil, fmt.Errorf("failed to get road: %w", err)
+	}
+
+	routeIDs, err := s.roadRepo.GetRouteIDs(roadID)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения маршрутов дороги: %v", err)
+		return nil, fmt.Errorf("failed to get road: %w", err)
+	}
+
+	response := &RoadResponse{
+		RoadSummary: roadToSummary(road),
+		RouteIDs:    routeIDs,
+		Segments:    make([]RoadSegmentInfo, len(road.Segments)),
+	}
+	for i, seg := range road.Segments {
+		response.Segments[i] = RoadSegmentInfo{
+			Position:         seg.Position,
+			StartCoordinate:  Coordinates{Lat: seg.StartLat, Lon: seg.StartLon},
+			EndCoordinate:    Coordinates{Lat: seg.EndLat, Lon: seg.EndLon},
+			ObservationCount: seg.ObservationCount,
+			LatestCoverage:   seg.LatestCoverage,
+			WorstCoverage:    seg.WorstCoverage,
+			AverageCoverage:  seg.AverageCoverage,
+			LastObservedAt:   seg.LastObservedAt,
+		}
+		s.applySegmentBand(&response.Segments[i])
+	}
+	s.applySummaryBand(&response.RoadSummary)
+
+	return response, nil
+}
+
+// ListRoads получает список дорог с пагинацией и фильтром по названию
+func (s *RoadService) ListRoads(page, pageSize int, name string) ([]RoadSummary, int64, error) {
+	s.logger.Infof("Получаем список дорог: страница %d, размер %d", page, pageSize)
+
+	roads, total, err := s.roadRepo.List(page, pageSize, name)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения списка дорог: %v", err)
+		return nil, 0, fmt.Errorf("failed to list roads: %w", err)
+	}
+
+	summaries := make([]RoadSummary, len(roads))
+	for i, road := range roads {
+		summaries[i] = roadToSummary(road)
+		s.applySummaryBand(&summaries[i])
+	}
+	return summaries, total, nil
+}
+
+// bestRoad выбирает дорогу с наибольшей долей совпавших сегментов маршрута.
+// matches[i] - индекс сегмента дороги для i-го сегмента маршрута или -1.
+func (s *RoadService) bestRoad(route *model.Route, candidates []*model.Road) (*model.Road, []int) {
+	var (
+		best        *model.Road
+		bestMatches []int
+		bestRatio   = roadMinOverlap
+	)
+
+	for _, road := range candidates {
+		lines := make([]segmentLine, len(road.Segments))
+		for i, seg := range road.Segments {
+			lines[i] = segmentLine{
+				start: models.Coordinates{Lat: seg.StartLat, Lon: seg.StartLon},
+				end:   models.Coordinates{Lat: seg.EndLat, Lon: seg.EndLon},
+			}
+		}
+
+		matches := make([]int, len(route.Segments))
+		matched := 0
+		for i, seg := range route.Segments {
+			matches[i], _ = nearestParallel(routeSegmentLine(seg), lines, roadCorridorMeters)
+			if matches[i] >= 0 {
+				matched++
+			}
+		}
+
+		if ratio := float64(matched) / float64(len(route.Segments)); ratio >= bestRatio {
+			best, bestMatches, bestRatio = road, matches, ratio
+		}
+	}
+
+	return best, bestMatches
+}
+
+// createRoad создает дорогу по геометрии сегментов маршрута
+func (s *RoadService) createRoad(route *model.Route) (*model.Road, error) {
+	road := &model.Road{
+		ID:   uuid.New().String(),
+		Name: route.RoadName,
+	}
+	if road.Name == "" {
+		road.Name = fmt.Sprintf("Дорога %s", road.ID[:8])
+	}
+
+	for i, seg := range route.Segments {
+		road.Segments = append(road.Segments, model.RoadSegment{
+			Position: i,
+			StartLat: seg.StartLat,
+			StartLon: seg.StartLon,
+			EndLat:   seg.EndLat,
+			EndLon:   seg.EndLon,
+		})
+	}
+
+	if err := s.roadRepo.Create(road); err != nil {
+		return nil, err
+	}
+	return road, nil
+}
+
+// extendRoad добавляет к дороге сегменты маршрута, для которых не нашлось
+// пары, и дописывает их индексы в matches
+func (s *RoadService) extendRoad(road *model.Road, route *model.Route, matches []int) error {
+	position := 0
+	for _, seg := range road.Segments {
+		if seg.Position >= position {
+			position = seg.Position + 1
+		}
+	}
+
+	var added []model.RoadSegment
+	var routeIndexes []int
+	for i, seg := range route.Segments {
+		if matches[i] >= 0 {
+			continue
+		}
+		added = append(added, model.RoadSegment{
+			RoadID:   road.ID,
+			Position: position,
+			StartLat: seg.StartLat,
+			StartLon: seg.StartLon,
+			EndLat:   seg.EndLat,
+			EndLon:   seg.EndLon,
+		})
+		routeIndexes = append(routeIndexes, i)
+		position++
+	}
+
+	if err := s.roadRepo.AddSegments(added); err != nil {
+		return err
+	}
+	for j, seg := range added {
+		matches[routeIndexes[j]] = len(road.Segments)
+		road.Segments = append(road.Segments, seg)
+	}
+	return nil
+}
+
+// roadToSummary преобразует модель дороги в краткое описание
+func roadToSummary(road *model.Road) RoadSummary {
+	return RoadSummary{
+		ID:              road.ID,
+		Name:            road.Name,
+		SegmentCount:    road.SegmentCount,
+		RouteCount:      road.RouteCount,
+		AverageCoverage: road.AverageCoverage,
+		CreatedAt:       road.CreatedAt,
+		UpdatedAt:       road.UpdatedAt,
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidTrendQuery возвращается при неверных параметрах ряда покрытия дороги
+var ErrInvalidTrendQuery = errors.New("invalid road trend query")
+
+// Способы сглаживания ряда покрытия дороги
+const (
+	TrendSmoothingNone          = "none"
+	TrendSmoothingMovingAverage = "moving_average"
+	TrendSmoothingEWMA          = "ewma"
+)
+
+// trendMonth период, за который считается скорость изменения покрытия
+const trendMonth = 30 * 24 * time.Hour
+
+// GetTrend возвращает среднее и худшее покрытие дороги по каждому проезду в
+// порядке времени проезда, сглаженный ряд и скорость изменения покрытия
+func (s *RoadService) GetTrend(roadID string, query RoadTrendQuery) (*RoadTrendResponse, error) {
+	s.logger.Infof("Получаем ряд покрытия дороги %s, сглаживание: %s", roadID, query.Smoothing)
+
+	switch query.Smoothing {
+	case TrendSmoothingNone:
+	case TrendSmoothingMovingAverage:
+		if query.Window < 2 {
+			return nil, fmt.Errorf("%w: window must be at least 2", ErrInvalidTrendQuery)
+		}
+	case TrendSmoothingEWMA:
+		if query.Alpha <= 0 || query.Alpha > 1 {
+			return nil, fmt.Errorf("%w: alpha must be greater than 0 and at most 1", ErrInvalidTrendQuery)
+		}
+	default:
+		return nil, fmt.Errorf("%w: smoothing must be none, moving_average or ewma", ErrInvalidTrendQuery)
+	}
+
+	road, err := s.roadRepo.GetByID(roadID)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения дороги: %v", err)
+		return nil, fmt.Errorf("failed to get road: %w", err)
+	}
+
+	rows, err := s.roadRepo.GetTrend(roadID, query.From, query.To)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения ряда покрытия дороги: %v", err)
+		return nil, fmt.Errorf("failed to get road trend: %w", err)
+	}
+
+	response := &RoadTrendResponse{
+		RoadID:    road.ID,
+		Name:      road.Name,
+		Smoothing: query.Smoothing,
+		Points:    make([]RoadTrendPoint, len(rows)),
+		Total:     len(rows),
+	}
+	for i, row := range rows {
+		response.Points[i] = RoadTrendPoint{
+			RouteID:          row.RouteID,
+			ObservedAt:       row.ObservedAt,
+			SegmentsObserved: row.Segments,
+			AverageCoverage:  row.AverageCoverage,
+			WorstCoverage:    row.WorstCoverage,
+		}
+	}
+
+	switch query.Smoothing {
+	case TrendSmoothingMovingAverage:
+		response.Window = query.Window
+		smoothMovingAverage(response.Points, query.Window)
+	case TrendSmoothingEWMA:
+		response.Alpha = query.Alpha
+		smoothEWMA(response.Points, query.Alpha)
+	}
+	response.ChangePerMonth = coverageChangePerMonth(response.Points)
+
+	return response, nil
+}
+
+// smoothMovingAverage заполняет сглаженное покрытие средним последних window
+// точек, в начале ряда — всех точек до текущей
+func smoothMovingAverage(points []RoadTrendPoint, window int) {
+	sum := 0.0
+	for i := range points {
+		sum += points[i].AverageCoverage
+		if i >= window {
+			sum -= points[i-window].AverageCoverage
+		}
+		value := sum / float64(min(i+1, window))
+		points[i].SmoothedCoverage = &value
+	}
+}
+
+// smoothEWMA заполняет сглаженное покрытие экспоненциальным средним с весом
+// новой точки alpha
+func smoothEWMA(points []RoadTrendPoint, alpha float64) {
+	var previous float64
+	for i := range points {
+		value := points[i].AverageCoverage
+		if i > 0 {
+			value = alpha*value + (1-alpha)*previous
+		}
+		previous = value
+		points[i].SmoothedCoverage = &value
+	}
+}
+
+// coverageChangePerMonth возвращает наклон линейной регрессии среднего
+// покрытия по времени в процентных пунктах за 30 дней, nil — если проездов
+// меньше двух или все они в один момент
+func coverageChangePerMonth(points []RoadTrendPoint) *float64 {
+	if len(points) < 2 {
+		return nil
+	}
+
+	// Время отсчитывается от первого проезда в месяцах, чтобы не терять точность
+	origin := points[0].ObservedAt
+	var sumX, sumY float64
+	for _, p := range points {
+		sumX += float64(p.ObservedAt.Sub(origin)) / float64(trendMonth)
+		sumY += p.AverageCoverage
+	}
+	n := float64(len(points))
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX float64
+	for _, p := range points {
+		dx := float64(p.ObservedAt.Sub(origin))/float64(trendMonth) - meanX
+		cov += dx * (p.AverageCoverage - meanY)
+		varX += dx * dx
+	}
+	if varX == 0 {
+		return nil
+	}
+	slope := cov / varX
+	return &slope
+}
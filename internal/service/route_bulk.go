@@ -0,0 +1,226 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+)
+
+// ErrInvalidBulkRequest возвращается при некорректном запросе массовой операции
+var ErrInvalidBulkRequest = errors.New("invalid bulk request")
+
+// Операции массовой обработки маршрутов
+const (
+	BulkOperationDelete = "delete"
+	BulkOperationTag    = "tag"
+	BulkOperationExport = "export"
+)
+
+// Статусы маршрутов в отчете массовой операции
+const (
+	bulkStatusOK       = "ok"
+	bulkStatusNotFound = "not_found"
+)
+
+// maxBulkRoutes максимальное количество маршрутов в одной массовой операции
+const maxBulkRoutes = 1000
+
+// BulkRoutes выполняет массовую операцию над маршрутами в одной транзакции.
+// Ненайденные маршруты отмечаются в отчете и не прерывают операцию,
+// ошибка базы данных отменяет операцию целиком.
+func (s *RouteService) BulkRoutes(req BulkRouteRequest) (*BulkRouteResponse, error) {
+	ids, err := s.resolveBulkRouteIDs(req)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("Массовая операция %s над %d маршрутами", req.Operation, len(ids))
+
+	// Маршруты вне области доступа из списка route_ids пропускаются и попадают
+	// в отчет как ненайденные
+	targets := ids
+	if !req.Scope.Empty() && req.Filter == nil {
+		targets, err = s.routeRepo.FilterScope(ids, req.Scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check route access: %w", err)
+		}
+	}
+
+	var done []string
+	var exported []RouteResponse
+	switch req.Operation {
+	case BulkOperationDelete:
+		done, err = s.bulkDelete(targets, req.Purge)
+	case BulkOperationTag:
+		var tags []string
+		tags, err = NormalizeTags(req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		if len(tags) == 0 {
+			return nil, fmt.Errorf("%w: tags are required for operation tag", ErrInvalidBulkRequest)
+		}
+		done, err = s.routeRepo.AddTags(targets, tags)
+	case BulkOperationExport:
+		var routes []*model.Route
+		routes, err = s.routeRepo.GetByIDs(targets)
+		for _, route := range routes {
+			done = append(done, route.ID)
+		}
+		exported = s.orderedResponses(ids, routes)
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidBulkRequest, req.Operation)
+	}
+	if err != nil {
+		s.logger.Errorf("Ошибка массовой операции %s: %v", req.Operation, err)
+		return nil, fmt.Errorf("failed to run bulk %s: %w", req.Operation, err)
+	}
+
+	response := &BulkRouteResponse{
+		Operation: req.Operation,
+		Total:     len(ids),
+		Results:   make([]BulkItemResult, len(ids)),
+		Routes:    exported,
+	}
+	succeeded := make(map[string]bool, len(done))
+	for _, id := range done {
+		succeeded[id] = true
+	}
+	for i, id := range ids {
+		status := bulkStatusNotFound
+		if succeeded[id] {
+			status = bulkStatusOK
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+		response.Results[i] = BulkItemResult{RouteID: id, Status: status}
+	}
+
+	s.logger.Infof("Массовая операция %s завершена: успешно %d, не найдено %d",
+		req.Operation, response.Succeeded, response.Failed)
+	return response, nil
+}
+
+// resolveBulkRouteIDs возвращает ID маршрутов из запроса: список без повторов
+// или результат фильтра
+func (s *RouteService) resolveBulkRouteIDs(req BulkRouteRequest) ([]string, error) {
+	if (len(req.RouteIDs) == 0) == (req.Filter == nil) {
+		return nil, fmt.Errorf("%w: exactly one of route_ids and filter is required", ErrInvalidBulkRequest)
+	}
+
+	if req.Filter == nil {
+		ids := make([]string, 0, len(req.RouteIDs))
+		seen := make(map[string]bool, len(req.RouteIDs))
+		for _, id := range req.RouteIDs {
+			id = strings.TrimSpace(id)
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+		}
+		if len(ids) > maxBulkRoutes {
+			return nil, fmt.Errorf("%w: more than %d route ids", ErrInvalidBulkRequest, maxBulkRoutes)
+		}
+		return ids, nil
+	}
+
+	tags, err := NormalizeTags(req.Filter.Tags)
+	if err != nil {
+		return nil, err
+	}
+	markingType := strings.ToLower(strings.TrimSpace(req.Filter.MarkingType))
+	if markingType != "" && !model.ValidMarkingType(markingType) {
+		return nil, fmt.Errorf("%w: invalid marking_type", ErrInvalidBulkRequest)
+	}
+	query := repository.RouteListQuery{
+		Name:          strings.TrimSpace(req.Filter.Name),
+		RoadName:      strings.TrimSpace(req.Filter.Road),
+		Tags:          tags,
+		CreatedAfter:  req.Filter.CreatedAfter,
+		CreatedBefore: req.Filter.CreatedBefore,
+		MinCoverage:   req.Filter.MinCoverage,
+		MaxCoverage:   req.Filter.MaxCoverage,
+		MinQuality:    req.Filter.MinQuality,
+		MaxQuality:    req.Filter.MaxQuality,
+		Deleted:       req.Filter.Deleted,
+		Archived:      req.Filter.Archived,
+		Scope:         req.Scope,
+		Defects: repository.DefectFilter{
+			HasDefects: req.Filter.HasDefects,
+			Type:       strings.ToLower(strings.TrimSpace(req.Filter.DefectType)),
+		},
+		MarkingType: markingType,
+	}
+
+	ids, err := s.routeRepo.FindIDs(query, maxBulkRoutes+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find routes: %w", err)
+	}
+	if len(ids) > maxBulkRoutes {
+		return nil, fmt.Errorf("%w: filter matches more than %d routes", ErrInvalidBulkRequest, maxBulkRoutes)
+	}
+	return ids, nil
+}
+
+// bulkDelete удаляет маршруты и исключает их из дорог. При purge маршруты
+// удаляются безвозвратно вместе с файлами.
+func (s *RouteService) bulkDelete(ids []string, purge bool) ([]string, error) {
+	// Удаленные ранее маршруты не загружаются: они уже исчезли с карты
+	visible := s.routesForFeed(ids)
+	var deleted []string
+	if purge {
+		routes, err := s.routeRepo.PurgeMany(ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, route := range routes {
+			deleted = append(deleted, route.ID)
+			s.removeRouteFiles(route)
+		}
+	} else {
+		var err error
+		deleted, err = s.routeRepo.DeleteMany(ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.roadService != nil {
+		for _, id := range deleted {
+			if err := s.roadService.RemoveRoute(id); err != nil {
+				s.logger.Errorf("Не удалось удалить маршрут %s из дорог: %v", id, err)
+			}
+		}
+	}
+
+	removed := make(map[string]bool, len(deleted))
+	for _, id := range deleted {
+		removed[id] = true
+	}
+	for _, route := range visible {
+		if removed[route.ID] {
+			s.routesChanged(RouteDeleted, route)
+		}
+	}
+	return deleted, nil
+}
+
+// orderedResponses преобразует маршруты в ответы API в порядке ids
+func (s *RouteService) orderedResponses(ids []string, routes []*model.Route) []RouteResponse {
+	byID := make(map[string]*model.Route, len(routes))
+	for _, route := range routes {
+		byID[route.ID] = route
+	}
+
+	responses := make([]RouteResponse, 0, len(routes))
+	for _, id := range ids {
+		if route, ok := byID[id]; ok {
+			responses = append(responses, *s.modelToResponse(route))
+		}
+	}
+	return responses
+}
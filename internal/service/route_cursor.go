@@ -0,0 +1,40 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/repository"
+)
+
+// ErrInvalidCursor возвращается, если курсор списка маршрутов поврежден
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeRouteCursor кодирует позицию в списке маршрутов в непрозрачную строку
+func EncodeRouteCursor(cursor repository.RouteCursor) string {
+	raw := strconv.FormatInt(cursor.CreatedAt.UnixNano(), 10) + ":" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeRouteCursor разбирает курсор, выданный EncodeRouteCursor
+func DecodeRouteCursor(value string) (repository.RouteCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return repository.RouteCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found || id == "" {
+		return repository.RouteCursor{}, fmt.Errorf("%w: malformed value", ErrInvalidCursor)
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return repository.RouteCursor{}, fmt.Errorf("%w: malformed timestamp", ErrInvalidCursor)
+	}
+
+	return repository.RouteCursor{CreatedAt: time.Unix(0, unixNano).UTC(), ID: id}, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+)
+
+// ErrInvalidDiff возвращается, если маршрут нельзя сравнить с указанным
+var ErrInvalidDiff = errors.New("invalid route diff")
+
+// ErrNoPreviousAnalysis возвращается, если у маршрута нет более раннего
+// анализа того же коридора
+var ErrNoPreviousAnalysis = errors.New("no previous analysis of the route corridor")
+
+// DiffAgainstPrevious значение against для сравнения с последним более
+// ранним анализом того же коридора
+const DiffAgainstPrevious = "previous"
+
+// Состояние сегмента в сравнении двух анализов
+const (
+	RouteDiffDegraded  = "degraded"
+	RouteDiffImproved  = "improved"
+	RouteDiffUnchanged = "unchanged"
+	// RouteDiffNoData сегмент или его пара без данных
+	RouteDiffNoData = "no_data"
+	// RouteDiffUnmatched у сегмента нет пары в другом анализе
+	RouteDiffUnmatched = "unmatched"
+)
+
+// DiffRoutes сравнивает покрытие маршрута routeID с другим анализом того же
+// коридора: маршрутом against или, если against равен DiffAgainstPrevious,
+// последним более ранним маршрутом из области доступа scope, у которого доля
+// совпавших сегментов не меньше minOverlap. Сегменты сопоставляются, как в
+// FindOverlaps. Разница покрытия больше threshold процентных пунктов
+// считается ухудшением или улучшением.
+func (s *RouteService) DiffRoutes(routeID, against string, distanceM, minOverlap, threshold float64, scope repository.RouteScope) (*RouteDiffResponse, error) {
+	s.logger.Infof("Сравниваем маршрут %s с %s: коридор %.0f м, порог %.1f п.п.", routeID, against, distanceM, threshold)
+
+	if against == routeID {
+		return nil, fmt.Errorf("%w: route cannot be compared with itself", ErrInvalidDiff)
+	}
+
+	route, err := s.routeRepo.GetByID(routeID, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения маршрута: %v", err)
+		return nil, fmt.Errorf("failed to get route: %w", err)
+	}
+
+	var other *model.Route
+	if against == DiffAgainstPrevious {
+		other, err = s.findPreviousAnalysis(route, distanceM, minOverlap, scope)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		other, err = s.routeRepo.GetByID(against, scope)
+		if err != nil {
+			s.logger.Errorf("Ошибка получения маршрута для сравнения: %v", err)
+			return nil, fmt.Errorf("failed to get route to compare with: %w", err)
+		}
+	}
+
+	response := diffRouteSegments(route, other, distanceM, threshold)
+	s.logger.Infof("Сравнение %s с %s: совпало %d сегментов, ухудшилось %d, улучшилось %d",
+		routeID, other.ID, response.Summary.MatchedSegments, response.Summary.DegradedSegments, response.Summary.ImprovedSegments)
+	return response, nil
+}
+
+// findPreviousAnalysis находит последний созданный раньше route маршрут того
+// же коридора с долей совпавших сегментов не меньше minOverlap
+func (s *RouteService) findPreviousAnalysis(route *model.Route, distanceM, minOverlap float64, scope repository.RouteScope) (*model.Route, error) {
+	candidates, err := s.routeRepo.GetOverlapCandidates(route, distanceM, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка поиска предыдущего анализа: %v", err)
+		return nil, fmt.Errorf("failed to find previous analysis: %w", err)
+	}
+
+	var previous *model.Route
+	for _, candidate := range candidates {
+		if !candidate.CreatedAt.Before(route.CreatedAt) {
+			continue
+		}
+		if previous != nil && !candidate.CreatedAt.After(previous.CreatedAt) {
+			continue
+		}
+		pairs := matchSegmentPairs(route.Segments, candidate.Segments, distanceM)
+		matched := make(map[int]bool)
+		for _, pair := range pairs {
+			matched[pair.SegmentID] = true
+		}
+		if float64(len(matched)) < minOverlap*float64(len(route.Segments)) || len(matched) == 0 {
+			continue
+		}
+		previous = candidate
+	}
+	if previous == nil {
+		return nil, fmt.Errorf("%w: route %s", ErrNoPreviousAnalysis, route.ID)
+	}
+	return previous, nil
+}
+
+// diffRouteSegments сопоставляет сегменты маршрутов и сводит разницу покрытия
+// в сводку и участки подряд идущих ухудшившихся или улучшившихся сегментов
+func diffRouteSegments(route, other *model.Route, distanceM, threshold float64) *RouteDiffResponse {
+	own := make([]model.Segment, len(route.Segments))
+	copy(own, route.Segments)
+	sort.Slice(own, func(i, j int) bool { return own[i].SegmentID < own[j].SegmentID })
+
+	candidates := make([]segmentLine, len(other.Segments))
+	for i, seg := range other.Segments {
+		candidates[i] = routeSegmentLine(seg)
+	}
+
+	response := &RouteDiffResponse{
+		RouteID:          route.ID,
+		CreatedAt:        route.CreatedAt,
+		AgainstRouteID:   other.ID,
+		AgainstName:      other.Name,
+		AgainstCreatedAt: other.CreatedAt,
+		DistanceMeters:   distanceM,
+		Threshold:        threshold,
+		Segments:         make([]RouteDiffSegment, 0, len(own)),
+		Sections:         make([]RouteDiffSection, 0),
+	}
+
+	calculator := geo.NewCalculator()
+	summary := &response.Summary
+	deltaSum := 0.0
+	var section *RouteDiffSection
+	for _, seg := range own {
+		line := routeSegmentLine(seg)
+		diff := RouteDiffSegment{
+			SegmentID:          int(seg.SegmentID),
+			CoveragePercentage: seg.CoveragePercentage,
+			LengthMeters:       calculator.DistanceMeters(line.start, line.end),
+			Status:             RouteDiffUnmatched,
+		}
+
+		if best, distance := nearestParallel(line, candidates, distanceM); best >= 0 {
+			match := other.Segments[best]
+			matchID := int(match.SegmentID)
+			matchCoverage := match.CoveragePercentage
+			diff.AgainstSegmentID = &matchID
+			diff.AgainstCoveragePercentage = &matchCoverage
+			diff.DistanceMeters = &distance
+			summary.MatchedSegments++
+
+			if seg.HasData && match.HasData {
+				delta := seg.CoveragePercentage - match.CoveragePercentage
+				diff.CoverageDelta = &delta
+				deltaSum += delta
+				summary.ComparedSegments++
+				switch {
+				case delta < -threshold:
+					diff.Status = RouteDiffDegraded
+					summary.DegradedSegments++
+					summary.DegradedLengthMeters += diff.LengthMeters
+				case delta > threshold:
+					diff.Status = RouteDiffImproved
+					summary.ImprovedSegments++
+					summary.ImprovedLengthMeters += diff.LengthMeters
+				default:
+					diff.Status = RouteDiffUnchanged
+					summary.UnchangedSegments++
+				}
+			} else {
+				diff.Status = RouteDiffNoData
+			}
+		} else {
+			summary.UnmatchedSegments++
+		}
+		response.Segments = append(response.Segments, diff)
+
+		// Участок продолжается, пока подряд идут сегменты с тем же изменением
+		if diff.Status != RouteDiffDegraded && diff.Status != RouteDiffImproved {
+			section = nil
+			continue
+		}
+		if section == nil || section.Status != diff.Status {
+			response.Sections = append(response.Sections, RouteDiffSection{
+				Status:         diff.Status,
+				StartSegmentID: diff.SegmentID,
+			})
+			section = &response.Sections[len(response.Sections)-1]
+		}
+		section.EndSegmentID = diff.SegmentID
+		section.SegmentsCount++
+		section.LengthMeters += diff.LengthMeters
+		section.AverageDelta += (*diff.CoverageDelta - section.AverageDelta) / float64(section.SegmentsCount)
+	}
+
+	if summary.ComparedSegments > 0 {
+		summary.AverageDelta = deltaSum / float64(summary.ComparedSegments)
+	}
+	return response
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"sync"
+
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/pkg/models"
+)
+
+// Виды изменений маршрутов в ленте
+const (
+	RouteCreated = "route.created"
+	RouteUpdated = "route.updated"
+	RouteDeleted = "route.deleted"
+)
+
+// routeWatcherBuffer сколько изменений ждут отправки одному подписчику.
+// Подписчик, который не успевает их получать, отключается.
+const routeWatcherBuffer = 256
+
+// RouteChange изменение маршрута
+type RouteChange struct {
+	Type    string
+	RouteID string
+	// Route маршрут после изменения без сегментов, у удаленного — до удаления
+	Route RouteResponse
+
+	// points концы сегментов маршрута, по которым он ищется в области
+	points []models.Coordinates
+}
+
+// InArea проверяет, проходит ли маршрут через область. Как и в поиске
+// маршрутов по области, достаточно одного конца сегмента внутри нее.
+func (c *RouteChange) InArea(area geo.BoundingBox) bool {
+	for _, p := range c.points {
+		if area.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Visible проверяет, доступен ли маршрут области scope
+func (c *RouteChange) Visible(scope repository.RouteScope) bool {
+	return scopeIncludes(scope, c.Route.OrganizationID, c.Route.OwnerID, c.Route.APIKeyID)
+}
+
+// RouteWatcher подписка на изменения маршрутов. Канал Updates закрывается
+// при отмене подписки, остановке ленты или если подписчик не успевает
+// получать изменения: тогда Dropped возвращает true.
+type RouteWatcher struct {
+	Updates <-chan RouteChange
+
+	updates chan RouteChange
+	dropped bool
+}
+
+// Dropped проверяет, была ли подписка отключена из-за переполнения
+func (w *RouteWatcher) Dropped() bool {
+	return w.dropped
+}
+
+// RouteFeed рассылает подписчикам изменения маршрутов, сделанные этим
+// экземпляром сервиса
+type RouteFeed struct {
+	mu       sync.Mutex
+	watchers map[*RouteWatcher]struct{}
+	closed   bool
+}
+
+// NewRouteFeed создает ленту без подписчиков
+func NewRouteFeed() *RouteFeed {
+	return &RouteFeed{watchers: make(map[*RouteWatcher]struct{})}
+}
+
+// Watch подписывается на изменения. Подписку нужно отменить через Unwatch.
+// После Close возвращается подписка с закрытым каналом.
+func (f *RouteFeed) Watch() *RouteWatcher {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	updates := make(chan RouteChange, routeWatcherBuffer)
+	watcher := &RouteWatcher{Updates: updates, updates: updates}
+	if f.closed {
+		close(updates)
+		return watcher
+	}
+	f.watchers[watcher] = struct{}{}
+	return watcher
+}
+
+// Unwatch отменяет подписку
+func (f *RouteFeed) Unwatch(watcher *RouteWatcher) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.watchers[watcher]; ok {
+		delete(f.watchers, watcher)
+		close(watcher.updates)
+	}
+}
+
+// Active проверяет, есть ли подписчики. Без них изменения можно не
+// подготавливать.
+func (f *RouteFeed) Active() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.watchers) > 0
+}
+
+// Close закрывает подписки при остановке сервиса
+func (f *RouteFeed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	for watcher := range f.watchers {
+		delete(f.watchers, watcher)
+		close(watcher.updates)
+	}
+}
+
+// publish отправляет изменение подписчикам
+func (f *RouteFeed) publish(change RouteChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for watcher := range f.watchers {
+		select {
+		case watcher.updates <- change:
+		default:
+			watcher.dropped = true
+			delete(f.watchers, watcher)
+			close(watcher.updates)
+		}
+	}
+}
+
+// SetRouteFeed включает рассылку изменений маршрутов подписчикам feed
+func (s *RouteService) SetRouteFeed(feed *RouteFeed) {
+	s.feed = feed
+}
+
+// watchingRoutes проверяет, нужно ли рассылать изменения маршрутов
+func (s *RouteService) watchingRoutes() bool {
+	return s.feed != nil && s.feed.Active()
+}
+
+// routesChanged рассылает изменение маршрутов. Маршруты должны быть
+// загружены с сегментами.
+func (s *RouteService) routesChanged(changeType string, routes ...*model.Route) {
+	if !s.watchingRoutes() {
+		return
+	}
+	for _, route := range routes {
+		response := s.modelToResponse(route)
+		response.Segments = nil
+
+		points := make([]models.Coordinates, 0, len(route.Segments)*2+2)
+		for _, seg := range route.Segments {
+			points = append(points,
+				models.Coordinates{Lat: seg.StartLat, Lon: seg.StartLon},
+				models.Coordinates{Lat: seg.EndLat, Lon: seg.EndLon})
+		}
+		if len(points) == 0 {
+			points = append(points,
+				models.Coordinates{Lat: route.StartLat, Lon: route.StartLon},
+				models.Coordinates{Lat: route.EndLat, Lon: route.EndLon})
+		}
+		s.feed.publish(RouteChange{Type: changeType, RouteID: route.ID, Route: *response, points: points})
+	}
+}
+
+// routesForFeed загружает маршруты для рассылки изменения, если есть
+// подписчики. Ошибка только записывается в лог: изменение уже выполнено
+// или еще выполняется.
+func (s *RouteService) routesForFeed(ids []string) []*model.Route {
+	if !s.watchingRoutes() || len(ids) == 0 {
+		return nil
+	}
+	routes, err := s.routeRepo.GetByIDs(ids)
+	if err != nil {
+		s.logger.Warnf("Не удалось загрузить маршруты для рассылки изменений: %v", err)
+		return nil
+	}
+	return routes
+}
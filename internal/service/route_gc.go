@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"road-detector-go/internal/repository"
+)
+
+// GCOptions параметры очистки удаленных маршрутов и файлов
+type GCOptions struct {
+	// DeletedBefore маршруты, удаленные раньше этого времени, удаляются
+	// безвозвратно вместе с файлами
+	DeletedBefore time.Time
+	// OrphanMinAge папки видео без маршрута моложе этого возраста не
+	// удаляются: анализ, который их создал, может еще выполняться
+	OrphanMinAge time.Duration
+	// DryRun только находит маршруты и папки, ничего не удаляя
+	DryRun bool
+}
+
+// GCReport результат очистки
+type GCReport struct {
+	DryRun       bool     `json:"dry_run"`
+	PurgedRoutes []string `json:"purged_routes"`
+	OrphanDirs   []string `json:"orphan_dirs"`
+	// FreedBytes размер удаленных папок без маршрута
+	FreedBytes int64 `json:"freed_bytes"`
+}
+
+// CollectGarbage безвозвратно удаляет давно удаленные маршруты и папки
+// видео, для которых нет маршрута даже среди удаленных
+func (s *RouteService) CollectGarbage(opts GCOptions) (*GCReport, error) {
+	report := &GCReport{DryRun: opts.DryRun, PurgedRoutes: []string{}, OrphanDirs: []string{}}
+
+	ids, err := s.routeRepo.FindIDs(repository.RouteListQuery{
+		Deleted:       true,
+		DeletedBefore: &opts.DeletedBefore,
+	}, -1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deleted routes: %w", err)
+	}
+	if opts.DryRun {
+		report.PurgedRoutes = append(report.PurgedRoutes, ids...)
+	} else {
+		for start := 0; start < len(ids); start += maxBulkRoutes {
+			end := min(start+maxBulkRoutes, len(ids))
+			purged, err := s.bulkDelete(ids[start:end], true)
+			if err != nil {
+				return nil, fmt.Errorf("failed to purge deleted routes: %w", err)
+			}
+			report.PurgedRoutes = append(report.PurgedRoutes, purged...)
+		}
+	}
+
+	if err := s.collectOrphanDirs(opts, report); err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Очистка завершена (без удаления: %t): маршрутов %d, папок без маршрута %d, %d байт",
+		opts.DryRun, len(report.PurgedRoutes), len(report.OrphanDirs), report.FreedBytes)
+	return report, nil
+}
+
+// collectOrphanDirs удаляет папки static/videos/<id>, маршрута которых нет
+// в базе данных
+func (s *RouteService) collectOrphanDirs(opts GCOptions, report *GCReport) error {
+	videosDir := filepath.Join(s.staticDir, "videos")
+	entries, err := os.ReadDir(videosDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read videos directory: %w", err)
+	}
+
+	cutoff := time.Now().Add(-opts.OrphanMinAge)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		_, err = s.routeRepo.GetDeletedByID(entry.Name())
+		if !errors.Is(err, repository.ErrRouteNotFound) {
+			if err != nil {
+				return fmt.Errorf("failed to check route %s: %w", entry.Name(), err)
+			}
+			continue
+		}
+
+		path := filepath.Join(videosDir, entry.Name())
+		size := dirSize(path)
+		if !opts.DryRun {
+			if err := os.RemoveAll(path); err != nil {
+				s.logger.Warnf("Не удалось удалить папку %s: %v", path, err)
+				continue
+			}
+			s.logger.Infof("Удалена папка видео без маршрута: %s", path)
+		}
+		report.OrphanDirs = append(report.OrphanDirs, path)
+		report.FreedBytes += size
+	}
+	return nil
+}
+
+// dirSize суммарный размер файлов в папке
+func dirSize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,98 @@
+package service
+
+// RouteGeoJSON выгружает маршрут в GeoJSON FeatureCollection: трек маршрута
+// и отдельные линии сегментов с покрытием разметки, его полосой и
+// дефектами покрытия. Если маршрут привязан к дорожному графу,
+// используются привязанные координаты.
+func RouteGeoJSON(route *RouteResponse) GeoJSONFeatureCollection {
+	collection := GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]GeoJSONFeature, 0, len(route.Segments)+1),
+	}
+
+	track := route.MatchedGeometry
+	if len(track) < 2 {
+		track = []Coordinates{route.StartPoint, route.EndPoint}
+	}
+	properties := map[string]interface{}{
+		"kind":                    "route",
+		"route_id":                route.ID,
+		"name":                    route.Name,
+		"road_name":               route.RoadName,
+		"total_segments":          route.OverallStats.TotalSegments,
+		"average_coverage":        route.OverallStats.AverageCoverage,
+		"distance_meters":         route.OverallStats.TotalDistanceMeters,
+		"total_defects":           route.OverallStats.TotalDefects,
+		"low_confidence_segments": route.OverallStats.LowConfidenceSegments,
+	}
+	if route.OverallStats.ClassCoverage != nil {
+		properties["class_coverage"] = route.OverallStats.ClassCoverage
+	}
+	if route.OverallStats.AverageConfidence != nil {
+		properties["average_confidence"] = *route.OverallStats.AverageConfidence
+	}
+	if route.OverallStats.QualityScore != nil {
+		properties["quality_score"] = *route.OverallStats.QualityScore
+	}
+	if route.OverallStats.CoverageBand != "" {
+		properties["coverage_band"] = route.OverallStats.CoverageBand
+		properties["coverage_color"] = route.OverallStats.CoverageColor
+	}
+	collection.Features = append(collection.Features, GeoJSONFeature{
+		Type:       "Feature",
+		Geometry:   lineString(track...),
+		Properties: properties,
+	})
+
+	for _, segment := range route.Segments {
+		start, end := segment.StartCoordinate, segment.EndCoordinate
+		if segment.MatchedStartCoordinate != nil && segment.MatchedEndCoordinate != nil {
+			start, end = *segment.MatchedStartCoordinate, *segment.MatchedEndCoordinate
+		}
+		properties := map[string]interface{}{
+			"kind":                "segment",
+			"segment_id":          segment.SegmentID,
+			"coverage_percentage": segment.CoveragePercentage,
+			"has_data":            segment.HasData,
+			"frames_count":        segment.FramesCount,
+			"road_name":           segment.RoadName,
+			"defects_count":       segment.DefectsCount,
+			"low_confidence":      segment.LowConfidence,
+		}
+		if len(segment.Defects) > 0 {
+			properties["defects"] = segment.Defects
+		}
+		if segment.ClassCoverage != nil {
+			properties["class_coverage"] = segment.ClassCoverage
+		}
+		if segment.AverageConfidence != nil {
+			properties["average_confidence"] = *segment.AverageConfidence
+		}
+		if segment.MinConfidence != nil {
+			properties["min_confidence"] = *segment.MinConfidence
+		}
+		if segment.QualityScore != nil {
+			properties["quality_score"] = *segment.QualityScore
+		}
+		if segment.CoverageBand != "" {
+			properties["coverage_band"] = segment.CoverageBand
+			properties["coverage_color"] = segment.CoverageColor
+		}
+		collection.Features = append(collection.Features, GeoJSONFeature{
+			Type:       "Feature",
+			Geometry:   lineString(start, end),
+			Properties: properties,
+		})
+	}
+
+	return collection
+}
+
+// lineString создает GeoJSON LineString из точек
+func lineString(points ...Coordinates) GeoJSONGeometry {
+	coordinates := make([][2]float64, len(points))
+	for i, point := range points {
+		coordinates[i] = [2]float64{point.Lon, point.Lat}
+	}
+	return GeoJSONGeometry{Type: "LineString", Coordinates: coordinates}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/hls"
+	"road-detector-go/internal/repository"
+)
+
+var (
+	// ErrHLSDisabled возвращается, если нарезка видео на сегменты HLS не
+	// настроена
+	ErrHLSDisabled = errors.New("hls packaging is not configured")
+	// ErrVideoNotFound возвращается, если у маршрута нет видео или
+	// запрошенного файла видео
+	ErrVideoNotFound = errors.New("route video not found")
+)
+
+// hlsDirName каталог сегментов HLS в каталоге видео маршрута
+const hlsDirName = "hls"
+
+// hlsCache нарезает видео маршрутов на сегменты HLS по первому запросу.
+// Одно видео нарезается один раз, даже если его запросили одновременно
+// несколько клиентов.
+type hlsCache struct {
+	packager hls.Packager
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	builds map[string]*hlsBuild
+}
+
+// hlsBuild выполняющаяся нарезка видео
+type hlsBuild struct {
+	done chan struct{}
+	err  error
+}
+
+// SetHLSPackager включает воспроизведение видео маршрутов по HLS. timeout
+// ограничивает нарезку одного видео, 0 — без ограничения.
+func (s *RouteService) SetHLSPackager(packager hls.Packager, timeout time.Duration) {
+	s.hls = &hlsCache{
+		packager: packager,
+		timeout:  timeout,
+		builds:   make(map[string]*hlsBuild),
+	}
+}
+
+// RouteVideoHLS возвращает путь к плейлисту или сегменту HLS name видео
+// маршрута. При первом запросе видео нарезается на сегменты, которые
+// хранятся рядом с видео до его замены или удаления маршрута.
+func (s *RouteService) RouteVideoHLS(ctx context.Context, routeID, name string) (string, error) {
+	if s.hls == nil {
+		return "", ErrHLSDisabled
+	}
+	if name != hls.PlaylistName && !hls.IsSegmentName(name) {
+		return "", fmt.Errorf("%w: %s", ErrVideoNotFound, name)
+	}
+	route, err := s.routeRepo.GetByID(routeID, repository.RouteScope{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get route: %w", err)
+	}
+	if route.VideoPath == "" {
+		return "", fmt.Errorf("%w: route %s has no video", ErrVideoNotFound, routeID)
+	}
+
+	// Копия для браузеров уже в H.264 и нарезается без перекодирования
+	source := route.VideoPath
+	if route.PlayableVideoPath != "" {
+		source = route.PlayableVideoPath
+	}
+	dir := filepath.Join(s.staticDir, "videos", routeID, hlsDirName)
+	if err := s.hls.ensure(ctx, routeID, source, dir); err != nil {
+		if !errors.Is(err, context.Canceled) {
+			s.logger.Errorf("Не удалось нарезать видео маршрута %s на сегменты HLS: %v", routeID, err)
+		}
+		return "", err
+	}
+
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrVideoNotFound, name)
+	}
+	return path, nil
+}
+
+// ensure нарезает видео source в каталог dir, если плейлиста в нем еще
+// нет. Нарезка продолжается, даже если клиент, запросивший ее, отключился.
+func (c *hlsCache) ensure(ctx context.Context, routeID, source, dir string) error {
+	playlist := filepath.Join(dir, hls.PlaylistName)
+	if _, err := os.Stat(playlist); err == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	build, ok := c.builds[routeID]
+	if !ok {
+		// Нарезка могла завершиться, пока ожидалась блокировка
+		if _, err := os.Stat(playlist); err == nil {
+			c.mu.Unlock()
+			return nil
+		}
+		build = &hlsBuild{done: make(chan struct{})}
+		c.builds[routeID] = build
+		go func() {
+			build.err = c.build(source, dir)
+			c.mu.Lock()
+			delete(c.builds, routeID)
+			c.mu.Unlock()
+			close(build.done)
+		}()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-build.done:
+		return build.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// build нарезает видео во временный каталог и переименовывает его в dir,
+// чтобы плейлист не отдавался до появления всех сегментов
+func (c *hlsCache) build(source, dir string) error {
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), hlsDirName+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create hls directory: %w", err)
+	}
+	if err := c.packager.Package(ctx, source, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	// Каталог без плейлиста остался от прерванной нарезки
+	if err := os.RemoveAll(dir); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("failed to replace hls directory: %w", err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("failed to move hls directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"road-detector-go/internal/repository"
+)
+
+var (
+	// ErrInvalidRouteID возвращается, если переданный ID маршрута не
+	// подходит для сохранения
+	ErrInvalidRouteID = errors.New("invalid route id")
+	// ErrRouteExists возвращается, если маршрут с переданным ID уже есть, а
+	// перезапись не запрошена
+	ErrRouteExists = errors.New("route already exists")
+)
+
+// routeIDPattern допустимый ID маршрута: помещается в столбец id и
+// используется как имя папки видео, поэтому без точек и разделителей пути
+var routeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,36}$`)
+
+// CheckNewRouteID проверяет ID маршрута до начала анализа, чтобы ошибка
+// не возникала при сохранении уже выполненного анализа. Существующий
+// маршрут, в том числе удаленный, допускается только с metadata.Overwrite
+// и только в области доступа metadata.Scope.
+func (s *RouteService) CheckNewRouteID(routeID string, metadata RouteMetadata) error {
+	if !routeIDPattern.MatchString(routeID) {
+		return fmt.Errorf("%w: %q, expected up to 36 latin letters, digits, '-' or '_'", ErrInvalidRouteID, routeID)
+	}
+
+	err := s.routeRepo.CheckScope(routeID, repository.RouteScope{})
+	if errors.Is(err, repository.ErrRouteNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check route id: %w", err)
+	}
+	if !metadata.Overwrite {
+		return fmt.Errorf("%w: %s", ErrRouteExists, routeID)
+	}
+
+	// Чужой маршрут не перезаписывается и не раскрывается
+	if err := s.CheckRouteAccess(routeID, metadata.Scope); err != nil {
+		if errors.Is(err, repository.ErrRouteNotFound) {
+			return fmt.Errorf("%w: %s", ErrRouteExists, routeID)
+		}
+		return err
+	}
+	return nil
+}
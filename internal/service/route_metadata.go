@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"road-detector-go/internal/repository"
+)
+
+// ErrInvalidRouteMetadata возвращается при некорректных метаданных маршрута
+var ErrInvalidRouteMetadata = errors.New("invalid route metadata")
+
+const (
+	maxRouteNameLength        = 255
+	maxRouteDescriptionLength = 4000
+	maxCustomFields           = 50
+	maxCustomFieldKeyLength   = 64
+	maxCustomFieldValueLength = 1000
+)
+
+// customFieldKeyPattern допустимые символы ключа пользовательского поля
+var customFieldKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Validate нормализует и проверяет метаданные, переданные при анализе
+func (m *RouteMetadata) Validate() error {
+	m.Name = strings.TrimSpace(m.Name)
+	m.Description = strings.TrimSpace(m.Description)
+	if err := validateRouteName(m.Name, true); err != nil {
+		return err
+	}
+	if err := validateRouteDescription(m.Description); err != nil {
+		return err
+	}
+
+	tags, err := NormalizeTags(m.Tags)
+	if err != nil {
+		return err
+	}
+	m.Tags = tags
+	return ValidateAnalysisParams(m.AnalysisParams)
+}
+
+// UpdateRouteMetadata частично обновляет название, описание и пользовательские
+// поля маршрута. Сегменты и результаты анализа не изменяются.
+func (s *RouteService) UpdateRouteMetadata(routeID string, req UpdateRouteRequest) (*RouteResponse, error) {
+	s.logger.Infof("Обновляем метаданные маршрута %s", routeID)
+
+	route, err := s.routeRepo.GetByID(routeID, repository.RouteScope{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route: %w", err)
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if err := validateRouteName(name, false); err != nil {
+			return nil, err
+		}
+		route.Name = name
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if err := validateRouteDescription(description); err != nil {
+			return nil, err
+		}
+		route.Description = description
+	}
+	if req.CustomFields != nil {
+		fields, err := mergeCustomFields(route.CustomFields, req.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+		route.CustomFields = fields
+	}
+
+	if err := s.routeRepo.UpdateMetadata(route); err != nil {
+		s.logger.Errorf("Ошибка обновления метаданных маршрута: %v", err)
+		return nil, fmt.Errorf("failed to update route metadata: %w", err)
+	}
+
+	s.routesChanged(RouteUpdated, route)
+	s.logger.Infof("Метаданные маршрута %s обновлены", routeID)
+	return s.modelToResponse(route), nil
+}
+
+// validateRouteName проверяет название маршрута. При allowEmpty пустое
+// название допустимо и означает сгенерированное.
+func validateRouteName(name string, allowEmpty bool) error {
+	if name == "" && !allowEmpty {
+		return fmt.Errorf("%w: name must not be empty", ErrInvalidRouteMetadata)
+	}
+	if utf8.RuneCountInString(name) > maxRouteNameLength {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidRouteMetadata, maxRouteNameLength)
+	}
+	return nil
+}
+
+// validateRouteDescription проверяет длину описания маршрута
+func validateRouteDescription(description string) error {
+	if utf8.RuneCountInString(description) > maxRouteDescriptionLength {
+		return fmt.Errorf("%w: description is longer than %d characters", ErrInvalidRouteMetadata, maxRouteDescriptionLength)
+	}
+	return nil
+}
+
+// mergeCustomFields применяет изменения к пользовательским полям:
+// значение nil удаляет поле, остальные добавляются или заменяются
+func mergeCustomFields(current map[string]string, changes map[string]*string) (map[string]string, error) {
+	merged := make(map[string]string, len(current)+len(changes))
+	for key, value := range current {
+		merged[key] = value
+	}
+
+	for key, value := range changes {
+		if !customFieldKeyPattern.MatchString(key) || len(key) > maxCustomFieldKeyLength {
+			return nil, fmt.Errorf("%w: custom field key %q must be 1-%d characters of A-Z, a-z, 0-9, '_', '.', '-'",
+				ErrInvalidRouteMetadata, key, maxCustomFieldKeyLength)
+		}
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		if utf8.RuneCountInString(*value) > maxCustomFieldValueLength {
+			return nil, fmt.Errorf("%w: custom field %q is longer than %d characters",
+				ErrInvalidRouteMetadata, key, maxCustomFieldValueLength)
+		}
+		merged[key] = *value
+	}
+
+	if len(merged) > maxCustomFields {
+		return nil, fmt.Errorf("%w: more than %d custom fields", ErrInvalidRouteMetadata, maxCustomFields)
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return merged, nil
+}
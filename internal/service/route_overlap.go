@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/pkg/models"
+)
+
+// maxOverlapAngleDegrees максимальный угол между сегментами одной дороги.
+// Отсекает пересекающиеся под углом улицы.
+const maxOverlapAngleDegrees = 30.0
+
+// FindOverlaps находит маршруты, проходящие по тому же коридору, что и
+// маршрут routeID: сегменты считаются парой, если середина сегмента лежит
+// не дальше distanceM метров от сегмента другого маршрута и они почти параллельны.
+// Возвращаются маршруты из области доступа scope, у которых доля совпавших
+// сегментов не меньше minOverlap.
+func (s *RouteService) FindOverlaps(routeID string, distanceM, minOverlap float64, scope repository.RouteScope) (*RouteOverlapResponse, error) {
+	s.logger.Infof("Ищем пересечения маршрута %s: коридор %.0f м, минимальная доля %.2f", routeID, distanceM, minOverlap)
+
+	route, err := s.routeRepo.GetByID(routeID, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения маршрута: %v", err)
+		return nil, fmt.Errorf("failed to get route: %w", err)
+	}
+
+	candidates, err := s.routeRepo.GetOverlapCandidates(route, distanceM, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка поиска кандидатов на пересечение: %v", err)
+		return nil, fmt.Errorf("failed to find overlapping routes: %w", err)
+	}
+
+	response := &RouteOverlapResponse{
+		RouteID:        routeID,
+		DistanceMeters: distanceM,
+		MinOverlap:     minOverlap,
+		Overlaps:       make([]RouteOverlap, 0),
+	}
+
+	for _, other := range candidates {
+		pairs := matchSegmentPairs(route.Segments, other.Segments, distanceM)
+		if len(route.Segments) == 0 || len(pairs) == 0 {
+			continue
+		}
+
+		overlapped := make(map[int]bool)
+		for _, pair := range pairs {
+			overlapped[pair.SegmentID] = true
+		}
+		ratio := float64(len(overlapped)) / float64(len(route.Segments))
+		if ratio < minOverlap {
+			continue
+		}
+
+		response.Overlaps = append(response.Overlaps, RouteOverlap{
+			RouteID:         other.ID,
+			Name:            other.Name,
+			RoadName:        other.RoadName,
+			CreatedAt:       other.CreatedAt,
+			OverlapRatio:    ratio,
+			OverlapSegments: len(overlapped),
+			SegmentPairs:    pairs,
+		})
+	}
+
+	sort.Slice(response.Overlaps, func(i, j int) bool {
+		return response.Overlaps[i].OverlapRatio > response.Overlaps[j].OverlapRatio
+	})
+	response.Total = len(response.Overlaps)
+
+	s.logger.Infof("Найдено %d маршрутов, пересекающихся с %s (кандидатов: %d)", response.Total, routeID, len(candidates))
+	return response, nil
+}
+
+// matchSegmentPairs сопоставляет каждому сегменту маршрута ближайший
+// подходящий сегмент другого маршрута
+func matchSegmentPairs(own, other []model.Segment, distanceM float64) []SegmentOverlap {
+	candidates := make([]segmentLine, len(other))
+	for i, seg := range other {
+		candidates[i] = routeSegmentLine(seg)
+	}
+
+	pairs := make([]SegmentOverlap, 0)
+	for _, seg := range own {
+		best, distance := nearestParallel(routeSegmentLine(seg), candidates, distanceM)
+		if best < 0 {
+			continue
+		}
+		match := other[best]
+		pairs = append(pairs, SegmentOverlap{
+			SegmentID:               int(seg.SegmentID),
+			OtherSegmentID:          int(match.SegmentID),
+			DistanceMeters:          distance,
+			CoveragePercentage:      seg.CoveragePercentage,
+			OtherCoveragePercentage: match.CoveragePercentage,
+			CoverageDelta:           seg.CoveragePercentage - match.CoveragePercentage,
+		})
+	}
+	return pairs
+}
+
+// segmentLine отрезок сегмента для сопоставления разных проездов
+type segmentLine struct {
+	start, end models.Coordinates
+}
+
+// routeSegmentLine возвращает отрезок сегмента маршрута
+func routeSegmentLine(seg model.Segment) segmentLine {
+	return segmentLine{
+		start: models.Coordinates{Lat: seg.StartLat, Lon: seg.StartLon},
+		end:   models.Coordinates{Lat: seg.EndLat, Lon: seg.EndLon},
+	}
+}
+
+// nearestParallel возвращает индекс и расстояние до ближайшего к середине line
+// почти параллельного отрезка не дальше distanceM метров, либо -1
+func nearestParallel(line segmentLine, candidates []segmentLine, distanceM float64) (int, float64) {
+	mid := geo.Midpoint(line.start, line.end)
+
+	best := -1
+	bestDistance := distanceM
+	for i, candidate := range candidates {
+		distance := geo.PointToSegmentMeters(mid, candidate.start, candidate.end)
+		if distance > bestDistance {
+			continue
+		}
+		if geo.AxisAngleDegrees(line.start, line.end, candidate.start, candidate.end) > maxOverlapAngleDegrees {
+			continue
+		}
+		best, bestDistance = i, distance
+	}
+	return best, bestDistance
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"road-detector-go/internal/report"
+	"road-detector-go/internal/repository"
+)
+
+// routeReportFrames сколько кадров участков с наименьшим покрытием
+// включается в отчет маршрута
+const routeReportFrames = 4
+
+// SetReportRenderer включает отчеты маршрутов в PDF
+func (s *RouteService) SetReportRenderer(renderer *report.Renderer) {
+	s.reports = renderer
+}
+
+// RouteReportPDF создает отчет маршрута в PDF: схему маршрута, общую
+// статистику, таблицу сегментов с цветами полос покрытия и кадры
+// аннотированного видео на участках с наименьшим покрытием. Если кадры
+// извлечь не удалось, отчет создается без них.
+func (s *RouteService) RouteReportPDF(ctx context.Context, routeID string) ([]byte, error) {
+	if !s.reports.PDFEnabled() {
+		return nil, report.ErrPDFDisabled
+	}
+	route, err := s.GetRouteByID(routeID, repository.RouteScope{})
+	if err != nil {
+		return nil, err
+	}
+
+	data := routeReportData(route)
+	if route.VideoPath != "" {
+		frames, err := s.routeReportFrames(ctx, route)
+		if err != nil {
+			s.logger.Warnf("Кадры видео маршрута %s не добавлены в отчет: %v", routeID, err)
+		}
+		data.Frames = frames
+	}
+
+	pdf, err := s.reports.RoutePDF(ctx, data)
+	if err != nil {
+		s.logger.Errorf("Ошибка создания отчета маршрута %s: %v", routeID, err)
+		return nil, err
+	}
+	s.logger.Infof("Создан отчет маршрута %s: %d байт, кадров: %d", routeID, len(pdf), len(data.Frames))
+	return pdf, nil
+}
+
+// routeReportFrames извлекает из аннотированного видео кадры середин
+// сегментов с наименьшим покрытием. Положение сегмента в видео
+// определяется по числу кадров предыдущих сегментов.
+func (s *RouteService) routeReportFrames(ctx context.Context, route *RouteResponse) ([]report.Frame, error) {
+	totalFrames := 0
+	for _, seg := range route.Segments {
+		totalFrames += seg.FramesCount
+	}
+
+	type candidate struct {
+		seg      SegmentInfo
+		position float64
+	}
+	var candidates []candidate
+	passed := 0
+	for i, seg := range route.Segments {
+		position := (float64(i) + 0.5) / float64(len(route.Segments))
+		if totalFrames > 0 {
+			position = (float64(passed) + float64(seg.FramesCount)/2) / float64(totalFrames)
+		}
+		passed += seg.FramesCount
+		if seg.HasData {
+			candidates = append(candidates, candidate{seg: seg, position: position})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].seg.CoveragePercentage < candidates[j].seg.CoveragePercentage
+	})
+	if len(candidates) > routeReportFrames {
+		candidates = candidates[:routeReportFrames]
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].seg.SegmentID < candidates[j].seg.SegmentID })
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	positions := make([]float64, len(candidates))
+	for i, c := range candidates {
+		positions[i] = c.position
+	}
+	images, err := s.reports.Frames(ctx, route.VideoPath, positions)
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]report.Frame, len(images))
+	for i, image := range images {
+		frames[i] = report.Frame{
+			SegmentID:          candidates[i].seg.SegmentID,
+			CoveragePercentage: candidates[i].seg.CoveragePercentage,
+			Image:              image,
+		}
+	}
+	return frames, nil
+}
+
+// routeReportData переносит маршрут в данные отчета
+func routeReportData(route *RouteResponse) report.RouteData {
+	stats := route.OverallStats
+	data := report.RouteData{
+		RouteID:          route.ID,
+		Name:             route.Name,
+		RoadName:         route.RoadName,
+		CreatedAt:        route.CreatedAt,
+		DistanceKm:       stats.TotalDistanceMeters / 1000,
+		TotalSegments:    stats.TotalSegments,
+		SegmentsWithData: stats.SegmentsWithData,
+		AverageCoverage:  stats.AverageCoverage,
+		Band:             stats.CoverageBand,
+		Color:            stats.CoverageColor,
+		TotalDefects:     stats.TotalDefects,
+		QualityScore:     stats.QualityScore,
+		Segments:         make([]report.RouteSegment, len(route.Segments)),
+	}
+	for i, seg := range route.Segments {
+		data.Segments[i] = report.RouteSegment{
+			SegmentID:          seg.SegmentID,
+			Start:              report.Point{Lat: seg.StartCoordinate.Lat, Lon: seg.StartCoordinate.Lon},
+			End:                report.Point{Lat: seg.EndCoordinate.Lat, Lon: seg.EndCoordinate.Lon},
+			HasData:            seg.HasData,
+			CoveragePercentage: seg.CoveragePercentage,
+			Band:               seg.CoverageBand,
+			Color:              seg.CoverageColor,
+			DefectsCount:       seg.DefectsCount,
+			QualityScore:       seg.QualityScore,
+		}
+	}
+	return data
+}
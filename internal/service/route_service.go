@@ -2,14 +2,19 @@ package service
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"time"
 
+	"road-detector-go/internal/coverageband"
+	"road-detector-go/internal/geo"
 	"road-detector-go/internal/model"
+	"road-detector-go/internal/report"
 	"road-detector-go/internal/repository"
+	"road-detector-go/pkg/models"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -17,9 +22,20 @@ import (
 
 // RouteService сервис для работы с маршрутами
 type RouteService struct {
-	routeRepo repository.RouteRepository
-	logger    *logrus.Logger
-	staticDir string
+	routeRepo   repository.RouteRepository
+	logger      *logrus.Logger
+	staticDir   string
+	roadService *RoadService
+	// bands полосы покрытия для ответов, nil — не задаются
+	bands *coverageband.Classifier
+	// reports отрисовка отчетов маршрутов, nil — отчеты недоступны
+	reports *report.Renderer
+	// feed лента изменений маршрутов для подписчиков, nil — не ведется
+	feed *RouteFeed
+	// transcodes очередь перекодирования видео для браузеров, nil — выключена
+	transcodes *TranscodeService
+	// hls нарезка видео на сегменты HLS, nil — выключена
+	hls *hlsCache
 }
 
 // NewRouteService создает новый сервис для работы с маршрутами
@@ -31,8 +47,14 @@ func NewRouteService(routeRepo repository.RouteRepository, logger *logrus.Logger
 	}
 }
 
-// SaveRoute сохраняет маршрут в базе данных
-func (s *RouteService) SaveRoute(routeID, videoFilename string, videoData io.Reader, analysisResult *AnalysisResult) error {
+// SetRoadService включает объединение сохраненных маршрутов в дороги
+func (s *RouteService) SetRoadService(roadService *RoadService) {
+	s.roadService = roadService
+}
+
+// SaveRoute сохраняет маршрут в базе данных. События events сохраняются
+// в той же транзакции.
+func (s *RouteService) SaveRoute(routeID, videoFilename string, videoData io.Reader, analysisResult *AnalysisResult, metadata RouteMetadata, events ...*model.OutboxEvent) error {
 	s.logger.Infof("Начинаем сохранение маршрута в БД. Размер видео: %d байт", videoData.(*bytes.Reader).Len())
 	s.logger.Infof("Сохраняем маршрут %s в базе данных", routeID)
 	s.logger.Infof("Детали анализа: сегментов=%d, среднее покрытие=%.2f%%, общее количество кадров=%d",
@@ -40,6 +62,16 @@ func (s *RouteService) SaveRoute(routeID, videoFilename string, videoData io.Rea
 		analysisResult.OverallStats.AverageCoverage,
 		analysisResult.OverallStats.TotalFrames)
 
+	// Анализ с перезаписью заменяет существующий маршрут
+	var previous *model.Route
+	if metadata.Overwrite {
+		var err error
+		previous, err = s.routeRepo.GetDeletedByID(routeID)
+		if err != nil && !errors.Is(err, repository.ErrRouteNotFound) {
+			return fmt.Errorf("failed to get overwritten route: %w", err)
+		}
+	}
+
 	// Сохраняем видео файл
 	var videoPath string
 	if videoData != nil && videoFilename != "" {
@@ -51,10 +83,16 @@ func (s *RouteService) SaveRoute(routeID, videoFilename string, videoData io.Rea
 		}
 	}
 
+	name := metadata.Name
+	if name == "" {
+		name = fmt.Sprintf("Маршрут %s", routeID[:8])
+	}
+
 	// Создаем объект маршрута
 	route := &model.Route{
 		ID:                  routeID,
-		Name:                fmt.Sprintf("Маршрут %s", routeID[:8]),
+		Name:                name,
+		Description:         metadata.Description,
 		StartLat:            analysisResult.StartPoint.Lat,
 		StartLon:            analysisResult.StartPoint.Lon,
 		EndLat:              analysisResult.EndPoint.Lat,
@@ -65,10 +103,36 @@ func (s *RouteService) SaveRoute(routeID, videoFilename string, videoData io.Rea
 		TotalSegments:       analysisResult.OverallStats.TotalSegments,
 		SegmentsWithData:    analysisResult.OverallStats.SegmentsWithData,
 		AverageCoverage:     analysisResult.OverallStats.AverageCoverage,
+		DefectsCount:        analysisResult.OverallStats.TotalDefects,
+		SegmentsWithDefects: analysisResult.OverallStats.SegmentsWithDefects,
+		ClassCoverage:       analysisResult.OverallStats.ClassCoverage,
+		AverageConfidence:   analysisResult.OverallStats.AverageConfidence,
 		VideoFilename:       videoFilename,
 		VideoPath:           videoPath,
+		RoadName:            analysisResult.RoadName,
+		APIKeyID:            metadata.APIKeyID,
+		OwnerID:             metadata.OwnerID,
+		OrganizationID:      metadata.OrganizationID,
 		CreatedAt:           time.Now(),
 	}
+	route.LowConfidenceSegments = analysisResult.OverallStats.LowConfidenceSegments
+	route.QualityScore = analysisResult.OverallStats.QualityScore
+	if !metadata.AnalysisParams.Empty() {
+		params := metadata.AnalysisParams
+		route.AnalysisParams = &params
+	}
+	for _, tag := range metadata.Tags {
+		route.Tags = append(route.Tags, model.Tag{Name: tag})
+	}
+
+	if len(analysisResult.MatchedGeometry) > 0 {
+		geometry, err := geo.LineStringGeoJSON(toModelCoordinates(analysisResult.MatchedGeometry))
+		if err != nil {
+			s.logger.Warnf("Не удалось сохранить привязанную геометрию маршрута: %v", err)
+		} else {
+			route.MatchedGeometry = string(geometry)
+		}
+	}
 
 	// Преобразуем сегменты
 	for i, seg := range analysisResult.Segments {
@@ -85,17 +149,42 @@ func (s *RouteService) SaveRoute(routeID, videoFilename string, videoData io.Rea
 			StartLon:           seg.StartCoordinate.Lon,
 			EndLat:             seg.EndCoordinate.Lat,
 			EndLon:             seg.EndCoordinate.Lon,
+			RoadName:           seg.RoadName,
+			Defects:            seg.Defects,
+			DefectsCount:       seg.DefectsCount,
+			ClassCoverage:      seg.ClassCoverage,
+			AverageConfidence:  seg.AverageConfidence,
+			MinConfidence:      seg.MinConfidence,
+			LowConfidence:      seg.LowConfidence,
+			QualityScore:       seg.QualityScore,
+		}
+		if seg.MatchedStartCoordinate != nil {
+			segment.MatchedStartLat = &seg.MatchedStartCoordinate.Lat
+			segment.MatchedStartLon = &seg.MatchedStartCoordinate.Lon
+		}
+		if seg.MatchedEndCoordinate != nil {
+			segment.MatchedEndLat = &seg.MatchedEndCoordinate.Lat
+			segment.MatchedEndLon = &seg.MatchedEndCoordinate.Lon
 		}
 		route.Segments = append(route.Segments, segment)
 	}
 
+	s.measureStorage(route)
+
 	// Сохраняем в базе данных
 	s.logger.Infof("Сохраняем маршрут в БД. Количество сегментов: %d", len(route.Segments))
-	err := s.routeRepo.Create(route)
+	var err error
+	if previous != nil {
+		route.CustomFields = previous.CustomFields
+		err = s.routeRepo.ReplaceWithEvents(route, events)
+	} else {
+		err = s.routeRepo.CreateWithEvents(route, events)
+	}
 	if err != nil {
 		s.logger.Errorf("Ошибка сохранения маршрута в БД: %v", err)
-		// Удаляем видео файл если что-то пошло не так
-		if videoPath != "" {
+		// Удаляем видео файл если что-то пошло не так. Видео заменяемого
+		// маршрута по тому же пути уже перезаписано, его оставляем.
+		if videoPath != "" && (previous == nil || previous.VideoPath != videoPath) {
 			s.logger.Infof("Удаляем видео файл %s из-за ошибки сохранения в БД", videoPath)
 			os.Remove(videoPath)
 		}
@@ -103,14 +192,34 @@ func (s *RouteService) SaveRoute(routeID, videoFilename string, videoData io.Rea
 	}
 
 	s.logger.Infof("Маршрут %s успешно сохранен в БД с %d сегментами", routeID, len(route.Segments))
+	if previous != nil {
+		s.logger.Infof("Анализ маршрута %s заменен новым", routeID)
+		s.removeReplacedFiles(previous, route)
+		s.routesChanged(RouteUpdated, route)
+	} else {
+		s.routesChanged(RouteCreated, route)
+	}
+	s.queueTranscode(route)
+
+	// Ошибка агрегации не отменяет сохранение: дороги можно пересчитать позже
+	if s.roadService != nil {
+		if previous != nil && !previous.DeletedAt.Valid {
+			if err := s.roadService.RemoveRoute(routeID); err != nil {
+				s.logger.Errorf("Не удалось убрать прежний анализ маршрута %s из дорог: %v", routeID, err)
+			}
+		}
+		if err := s.roadService.AssignRoute(route); err != nil {
+			s.logger.Errorf("Не удалось добавить маршрут %s в дороги: %v", routeID, err)
+		}
+	}
 	return nil
 }
 
-// GetRouteByID получает маршрут по ID
-func (s *RouteService) GetRouteByID(routeID string) (*RouteResponse, error) {
+// GetRouteByID получает маршрут по ID из области доступа scope
+func (s *RouteService) GetRouteByID(routeID string, scope repository.RouteScope) (*RouteResponse, error) {
 	s.logger.Infof("Получаем маршрут %s из базы данных", routeID)
 
-	route, err := s.routeRepo.GetByID(routeID)
+	route, err := s.routeRepo.GetByID(routeID, scope)
 	if err != nil {
 		s.logger.Errorf("Ошибка получения маршрута: %v", err)
 		return nil, fmt.Errorf("failed to get route: %w", err)
@@ -119,19 +228,72 @@ func (s *RouteService) GetRouteByID(routeID string) (*RouteResponse, error) {
 	return s.modelToResponse(route), nil
 }
 
-// GetRoutesByArea получает маршруты в заданной области
-func (s *RouteService) GetRoutesByArea(neLat, neLon, swLat, swLon float64) ([]RouteResponse, error) {
-	s.logger.Infof("Получаем маршруты в области: NE(%.6f, %.6f) SW(%.6f, %.6f)",
-		neLat, neLon, swLat, swLon)
+// RouteAnnotatedVideo возвращает путь к аннотированному видео маршрута
+func (s *RouteService) RouteAnnotatedVideo(routeID string) (string, error) {
+	route, err := s.routeRepo.GetByID(routeID, repository.RouteScope{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get route: %w", err)
+	}
+	if route.VideoFilename == "" {
+		return "", fmt.Errorf("%w: route %s has no annotated video", ErrVideoNotFound, routeID)
+	}
+	path := filepath.Join(s.staticDir, "annotated_"+route.ID+"_"+route.VideoFilename)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrVideoNotFound, filepath.Base(path))
+	}
+	return path, nil
+}
+
+// GetRouteVersion возвращает время последнего изменения маршрута
+func (s *RouteService) GetRouteVersion(routeID string) (time.Time, error) {
+	updatedAt, err := s.routeRepo.GetUpdatedAt(routeID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get route version: %w", err)
+	}
+	return updatedAt, nil
+}
+
+// CheckRouteAccess проверяет, что маршрут, в том числе удаленный, входит
+// в область scope. Для маршрута вне области возвращается ErrRouteNotFound,
+// чтобы по ответу нельзя было узнать о чужих маршрутах.
+func (s *RouteService) CheckRouteAccess(routeID string, scope repository.RouteScope) error {
+	if scope.Empty() {
+		return nil
+	}
+	if err := s.routeRepo.CheckScope(routeID, scope); err != nil {
+		return fmt.Errorf("failed to check route access: %w", err)
+	}
+	return nil
+}
 
-	// Преобразуем координаты
-	ne := repository.Coordinates{Lat: neLat, Lon: neLon}
-	sw := repository.Coordinates{Lat: swLat, Lon: swLon}
+// GetRoutesByArea получает страницу маршрутов в заданной области и их общее
+// количество. Область нормализуется, пересекающая антимеридиан проверяется
+// по обе стороны от него. Возвращаются только маршруты из области доступа scope,
+// подходящие под фильтр дефектов покрытия defects и, если задан markingType,
+// с разметкой этого типа.
+func (s *RouteService) GetRoutesByArea(neLat, neLon, swLat, swLon float64, withSegments bool, defects repository.DefectFilter, markingType string, scope repository.RouteScope, page, pageSize int) ([]RouteResponse, int64, error) {
+	s.logger.Infof("Получаем маршруты в области: NE(%.6f, %.6f) SW(%.6f, %.6f), страница %d, размер %d",
+		neLat, neLon, swLat, swLon, page, pageSize)
 
-	routes, err := s.routeRepo.GetByArea(ne, sw)
+	bbox, err := geo.NewBoundingBox(neLat, neLon, swLat, swLon)
+	if err != nil {
+		s.logger.Warnf("Неверная область запроса: %v", err)
+		return nil, 0, err
+	}
+
+	// Восточная граница разворачивается за 180°, если область пересекает антимеридиан
+	query := repository.AreaQuery{
+		NorthEast:    repository.Coordinates{Lat: bbox.NorthEast.Lat, Lon: bbox.SouthWest.Lon + bbox.WidthDegrees()},
+		SouthWest:    repository.Coordinates{Lat: bbox.SouthWest.Lat, Lon: bbox.SouthWest.Lon},
+		Defects:      defects,
+		MarkingType:  markingType,
+		WithSegments: withSegments,
+		Scope:        scope,
+	}
+	routes, total, err := s.routeRepo.GetByArea(query, page, pageSize)
 	if err != nil {
 		s.logger.Errorf("Ошибка получения маршрутов по области: %v", err)
-		return nil, fmt.Errorf("failed to get routes by area: %w", err)
+		return nil, 0, fmt.Errorf("failed to get routes by area: %w", err)
 	}
 
 	responses := make([]RouteResponse, len(routes))
@@ -139,18 +301,97 @@ func (s *RouteService) GetRoutesByArea(neLat, neLon, swLat, swLon float64) ([]Ro
 		responses[i] = *s.modelToResponse(route)
 	}
 
-	s.logger.Infof("Найдено %d маршрутов в области", len(responses))
+	s.logger.Infof("Найдено %d маршрутов в области", total)
+	return responses, total, nil
+}
+
+// SearchByPolygon получает маршруты, пересекающие полигон, оставляя
+// в ответе только пересекающие его сегменты. Ищутся только маршруты из области
+// доступа scope.
+func (s *RouteService) SearchByPolygon(polygon geo.Polygon, scope repository.RouteScope) (*PolygonSearchResponse, error) {
+	s.logger.Infof("Ищем маршруты в полигоне: %d контуров, %d вершин во внешнем контуре",
+		len(polygon.Rings), len(polygon.Rings[0]))
+
+	routes, err := s.routeRepo.GetByPolygon(polygon, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка поиска маршрутов по полигону: %v", err)
+		return nil, fmt.Errorf("failed to search routes by polygon: %w", err)
+	}
+
+	response := &PolygonSearchResponse{Routes: make([]RouteResponse, 0, len(routes))}
+	for _, route := range routes {
+		routeResponse := s.modelToResponse(route)
+
+		matched := make([]SegmentInfo, 0, len(routeResponse.Segments))
+		for _, seg := range routeResponse.Segments {
+			if polygon.IntersectsSegment(
+				models.Coordinates{Lat: seg.StartCoordinate.Lat, Lon: seg.StartCoordinate.Lon},
+				models.Coordinates{Lat: seg.EndCoordinate.Lat, Lon: seg.EndCoordinate.Lon},
+			) {
+				matched = append(matched, seg)
+			}
+		}
+		routeResponse.Segments = matched
+
+		response.Routes = append(response.Routes, *routeResponse)
+		response.TotalSegments += len(matched)
+	}
+	response.Total = len(response.Routes)
+
+	s.logger.Infof("Найдено %d маршрутов и %d сегментов в полигоне", response.Total, response.TotalSegments)
+	return response, nil
+}
+
+// GetRoutesNear получает маршруты в радиусе radiusM метров от точки.
+// Учитываются только маршруты из области доступа scope.
+func (s *RouteService) GetRoutesNear(lat, lon, radiusM float64, limit int, scope repository.RouteScope) ([]NearbyRoute, error) {
+	s.logger.Infof("Получаем маршруты рядом с точкой (%.6f, %.6f), радиус %.0f м", lat, lon, radiusM)
+
+	routes, err := s.routeRepo.GetNear(repository.Coordinates{Lat: lat, Lon: lon}, radiusM, limit, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения маршрутов рядом с точкой: %v", err)
+		return nil, fmt.Errorf("failed to get routes near point: %w", err)
+	}
+
+	responses := make([]NearbyRoute, len(routes))
+	for i, route := range routes {
+		responses[i] = NearbyRoute{
+			RouteResponse:  *s.modelToResponse(route.Route),
+			DistanceMeters: route.DistanceM,
+		}
+	}
+
+	s.logger.Infof("Найдено %d маршрутов рядом с точкой", len(responses))
 	return responses, nil
 }
 
-// ListRoutes получает список всех маршрутов с пагинацией
-func (s *RouteService) ListRoutes(page, pageSize int) ([]RouteResponse, int64, error) {
-	s.logger.Infof("Получаем список маршрутов: страница %d, размер %d", page, pageSize)
+// GetNearestRoute получает маршрут, ближайший к точке, среди маршрутов
+// из области доступа scope
+func (s *RouteService) GetNearestRoute(lat, lon float64, scope repository.RouteScope) (*NearbyRoute, error) {
+	s.logger.Infof("Получаем ближайший маршрут к точке (%.6f, %.6f)", lat, lon)
 
-	routes, total, err := s.routeRepo.List(page, pageSize)
+	route, err := s.routeRepo.GetNearest(repository.Coordinates{Lat: lat, Lon: lon}, scope)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения ближайшего маршрута: %v", err)
+		return nil, fmt.Errorf("failed to get nearest route: %w", err)
+	}
+
+	return &NearbyRoute{
+		RouteResponse:  *s.modelToResponse(route.Route),
+		DistanceMeters: route.DistanceM,
+	}, nil
+}
+
+// ListRoutes получает список маршрутов с пагинацией и фильтрацией
+// Без query.WithSegments сегменты не загружаются, возвращается только сводная статистика.
+// При сортировке по created_at возвращает также курсор следующей страницы, если она есть.
+func (s *RouteService) ListRoutes(page, pageSize int, query repository.RouteListQuery) ([]RouteResponse, int64, string, error) {
+	s.logger.Infof("Получаем список маршрутов: страница %d, размер %d, параметры %+v", page, pageSize, query)
+
+	routes, total, err := s.routeRepo.List(page, pageSize, query)
 	if err != nil {
 		s.logger.Errorf("Ошибка получения списка маршрутов: %v", err)
-		return nil, 0, fmt.Errorf("failed to list routes: %w", err)
+		return nil, 0, "", fmt.Errorf("failed to list routes: %w", err)
 	}
 
 	responses := make([]RouteResponse, len(routes))
@@ -158,41 +399,222 @@ func (s *RouteService) ListRoutes(page, pageSize int) ([]RouteResponse, int64, e
 		responses[i] = *s.modelToResponse(route)
 	}
 
+	// При курсорной пагинации номер страницы неизвестен, поэтому следующая
+	// страница считается существующей, если текущая заполнена целиком
+	hasMore := int64(page*pageSize) < total
+	if query.After != nil {
+		hasMore = len(routes) == pageSize
+	}
+	var nextCursor string
+	if hasMore && len(routes) > 0 && (query.SortBy == "" || query.SortBy == repository.RouteSortCreatedAt) {
+		last := routes[len(routes)-1]
+		nextCursor = EncodeRouteCursor(repository.RouteCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
 	s.logger.Infof("Получено %d маршрутов из %d общих", len(responses), total)
+	return responses, total, nextCursor, nil
+}
+
+// SearchRoutes ищет маршруты по названию, описанию и названию дороги.
+// Ищутся только маршруты из области доступа scope.
+func (s *RouteService) SearchRoutes(text string, scope repository.RouteScope, page, pageSize int) ([]RouteResponse, int64, error) {
+	s.logger.Infof("Ищем маршруты по запросу %q: страница %d, размер %d", text, page, pageSize)
+
+	routes, total, err := s.routeRepo.Search(text, scope, page, pageSize)
+	if err != nil {
+		s.logger.Errorf("Ошибка поиска маршрутов: %v", err)
+		return nil, 0, fmt.Errorf("failed to search routes: %w", err)
+	}
+
+	responses := make([]RouteResponse, len(routes))
+	for i, route := range routes {
+		responses[i] = *s.modelToResponse(route)
+	}
+
+	s.logger.Infof("По запросу %q найдено %d маршрутов", text, total)
 	return responses, total, nil
 }
 
-// DeleteRoute удаляет маршрут по ID
-func (s *RouteService) DeleteRoute(routeID string) error {
-	s.logger.Infof("Удаляем маршрут %s", routeID)
+// ListSegments получает страницу сегментов маршрута с фильтрами и сортировкой
+func (s *RouteService) ListSegments(routeID string, query repository.SegmentQuery) (*ListSegmentsResponse, error) {
+	s.logger.Infof("Получаем сегменты маршрута %s: страница %d, размер %d", routeID, query.Page, query.PageSize)
+
+	segments, total, err := s.routeRepo.GetSegments(routeID, query)
+	if err != nil {
+		s.logger.Errorf("Ошибка получения сегментов маршрута: %v", err)
+		return nil, fmt.Errorf("failed to list segments: %w", err)
+	}
+
+	response := &ListSegmentsResponse{
+		RouteID:  routeID,
+		Segments: make([]SegmentInfo, len(segments)),
+		Total:    total,
+		Page:     query.Page,
+		Size:     query.PageSize,
+	}
+	for i, seg := range segments {
+		response.Segments[i] = segmentToInfo(seg)
+	}
+	s.applyCoverageBands(response.Segments, nil)
+	return response, nil
+}
+
+// GetSegment получает сегмент маршрута из области доступа scope по номеру
+func (s *RouteService) GetSegment(routeID string, segmentID int, scope repository.RouteScope) (*SegmentInfo, error) {
+	s.logger.Infof("Получаем сегмент %d маршрута %s", segmentID, routeID)
 
-	// Сначала получаем маршрут для удаления видео файла
-	route, err := s.routeRepo.GetByID(routeID)
+	segment, err := s.routeRepo.GetSegment(routeID, segmentID, scope)
 	if err != nil {
-		s.logger.Errorf("Ошибка получения маршрута для удаления: %v", err)
-		return fmt.Errorf("failed to get route for deletion: %w", err)
+		s.logger.Errorf("Ошибка получения сегмента: %v", err)
+		return nil, fmt.Errorf("failed to get segment: %w", err)
 	}
 
+	info := []SegmentInfo{segmentToInfo(*segment)}
+	s.applyCoverageBands(info, nil)
+	return &info[0], nil
+}
+
+// DeleteRoute мягко удаляет маршрут по ID. Видео сохраняется, маршрут
+// можно восстановить через RestoreRoute или удалить навсегда через PurgeRoute.
+func (s *RouteService) DeleteRoute(routeID string) error {
+	s.logger.Infof("Удаляем маршрут %s", routeID)
+	deleted := s.routesForFeed([]string{routeID})
+
 	// Удаляем из базы данных
-	err = s.routeRepo.Delete(routeID)
+	err := s.routeRepo.Delete(routeID)
 	if err != nil {
 		s.logger.Errorf("Ошибка удаления маршрута из БД: %v", err)
 		return fmt.Errorf("failed to delete route from database: %w", err)
 	}
 
-	// Удаляем видео файл если он существует
-	if route.VideoPath != "" {
-		if err := os.Remove(route.VideoPath); err != nil {
-			s.logger.Warnf("Не удалось удалить видео файл %s: %v", route.VideoPath, err)
-		} else {
-			s.logger.Infof("Видео файл %s успешно удален", route.VideoPath)
+	if s.roadService != nil {
+		if err := s.roadService.RemoveRoute(routeID); err != nil {
+			s.logger.Errorf("Не удалось удалить маршрут %s из дорог: %v", routeID, err)
 		}
 	}
 
+	s.routesChanged(RouteDeleted, deleted...)
 	s.logger.Infof("Маршрут %s успешно удален", routeID)
 	return nil
 }
 
+// RestoreRoute восстанавливает удаленный маршрут и возвращает его в дороги
+func (s *RouteService) RestoreRoute(routeID string) (*RouteResponse, error) {
+	s.logger.Infof("Восстанавливаем маршрут %s", routeID)
+
+	if err := s.routeRepo.Restore(routeID); err != nil {
+		return nil, fmt.Errorf("failed to restore route: %w", err)
+	}
+
+	route, err := s.routeRepo.GetByID(routeID, repository.RouteScope{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get restored route: %w", err)
+	}
+
+	if s.roadService != nil {
+		if err := s.roadService.AssignRoute(route); err != nil {
+			s.logger.Errorf("Не удалось добавить маршрут %s в дороги: %v", routeID, err)
+		}
+	}
+
+	s.routesChanged(RouteCreated, route)
+	s.logger.Infof("Маршрут %s восстановлен", routeID)
+	return s.modelToResponse(route), nil
+}
+
+// UnarchiveRoute возвращает маршрут из архива в списки
+func (s *RouteService) UnarchiveRoute(routeID string) (*RouteResponse, error) {
+	s.logger.Infof("Возвращаем маршрут %s из архива", routeID)
+
+	if err := s.routeRepo.Unarchive(routeID); err != nil {
+		return nil, fmt.Errorf("failed to unarchive route: %w", err)
+	}
+
+	route, err := s.routeRepo.GetByID(routeID, repository.RouteScope{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unarchived route: %w", err)
+	}
+
+	s.routesChanged(RouteCreated, route)
+	s.logger.Infof("Маршрут %s возвращен из архива", routeID)
+	return s.modelToResponse(route), nil
+}
+
+// PurgeRoute безвозвратно удаляет маршрут, в том числе ранее удаленный,
+// вместе с видео и аннотированным видео
+func (s *RouteService) PurgeRoute(routeID string) error {
+	s.logger.Infof("Окончательно удаляем маршрут %s", routeID)
+
+	route, err := s.routeRepo.GetDeletedByID(routeID)
+	if err != nil {
+		return fmt.Errorf("failed to get route for purge: %w", err)
+	}
+
+	if s.roadService != nil && !route.DeletedAt.Valid {
+		if err := s.roadService.RemoveRoute(routeID); err != nil {
+			s.logger.Errorf("Не удалось удалить маршрут %s из дорог: %v", routeID, err)
+		}
+	}
+
+	if err := s.routeRepo.Purge(routeID); err != nil {
+		s.logger.Errorf("Ошибка окончательного удаления маршрута из БД: %v", err)
+		return fmt.Errorf("failed to purge route from database: %w", err)
+	}
+
+	s.removeRouteFiles(route)
+	// Ранее удаленный маршрут уже исчез с карты
+	if !route.DeletedAt.Valid {
+		s.routesChanged(RouteDeleted, route)
+	}
+
+	s.logger.Infof("Маршрут %s окончательно удален", routeID)
+	return nil
+}
+
+// removeRouteFiles удаляет видео маршрута и аннотированное видео.
+// Ошибки только логируются: запись в БД уже удалена.
+func (s *RouteService) removeRouteFiles(route *model.Route) {
+	paths := []string{filepath.Join(s.staticDir, "videos", route.ID)}
+	if route.VideoPath != "" {
+		paths = append(paths, route.VideoPath)
+	}
+	annotated, _ := filepath.Glob(filepath.Join(s.staticDir, "annotated_"+route.ID+"_*"))
+	paths = append(paths, annotated...)
+
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			s.logger.Warnf("Не удалось удалить файл %s: %v", path, err)
+		} else {
+			s.logger.Infof("Файл %s удален", path)
+		}
+	}
+}
+
+// removeReplacedFiles удаляет видео и аннотированное видео заменяемого
+// маршрута previous, которые не используются новым анализом route
+func (s *RouteService) removeReplacedFiles(previous, route *model.Route) {
+	var paths []string
+	if previous.VideoPath != "" && previous.VideoPath != route.VideoPath {
+		paths = append(paths, previous.VideoPath)
+	}
+	if previous.VideoFilename != "" && previous.VideoFilename != route.VideoFilename {
+		paths = append(paths, filepath.Join(s.staticDir, "annotated_"+previous.ID+"_"+previous.VideoFilename))
+	}
+	// Копия для браузеров сделана из прежнего видео, даже если новое
+	// сохранено по тому же пути
+	if previous.PlayableVideoPath != "" && previous.PlayableVideoPath != previous.VideoPath && previous.PlayableVideoPath != route.VideoPath {
+		paths = append(paths, previous.PlayableVideoPath)
+	}
+	// Сегменты HLS нарезаны из прежнего видео
+	paths = append(paths, filepath.Join(s.staticDir, "videos", previous.ID, hlsDirName))
+
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			s.logger.Warnf("Не удалось удалить файл %s: %v", path, err)
+		}
+	}
+}
+
 // saveVideoFile сохраняет видео файл в статической папке
 func (s *RouteService) saveVideoFile(routeID, originalFilename string, videoData io.Reader) (string, error) {
 	s.logger.Infof("Начинаем сохранение видео файла. RouteID: %s, оригинальное имя: %s", routeID, originalFilename)
@@ -217,19 +639,26 @@ func (s *RouteService) saveVideoFile(routeID, originalFilename string, videoData
 	filePath := filepath.Join(routeDir, filename)
 	s.logger.Infof("Путь к файлу: %s", filePath)
 
-	// Создаем файл
-	file, err := os.Create(filePath)
+	// Создаем временный файл: видео появляется под своим именем только
+	// после полной записи
+	tmpPath := filePath + ".tmp"
+	file, err := os.Create(tmpPath)
 	if err != nil {
 		s.logger.Errorf("Ошибка создания файла %s: %v", filePath, err)
 		return "", fmt.Errorf("failed to create video file: %w", err)
 	}
-	defer file.Close()
 
 	// Копируем данные
 	bytesWritten, err := io.Copy(file, videoData)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
 	if err != nil {
 		s.logger.Errorf("Ошибка записи данных в файл %s: %v", filePath, err)
-		os.Remove(filePath) // Удаляем файл в случае ошибки
+		os.Remove(tmpPath) // Удаляем файл в случае ошибки
 		return "", fmt.Errorf("failed to write video data: %w", err)
 	}
 
@@ -242,6 +671,8 @@ func (s *RouteService) modelToResponse(route *model.Route) *RouteResponse {
 	response := &RouteResponse{
 		ID:            route.ID,
 		Name:          route.Name,
+		Description:   route.Description,
+		RoadName:      route.RoadName,
 		StartPoint:    Coordinates{Lat: route.StartLat, Lon: route.StartLon},
 		EndPoint:      Coordinates{Lat: route.EndLat, Lon: route.EndLon},
 		SegmentLength: float64(route.SegmentLengthM),
@@ -252,28 +683,96 @@ func (s *RouteService) modelToResponse(route *model.Route) *RouteResponse {
 			TotalSegments:       int(route.TotalSegments),
 			SegmentsWithData:    int(route.SegmentsWithData),
 			AverageCoverage:     route.AverageCoverage,
+			TotalDefects:        route.DefectsCount,
+			SegmentsWithDefects: route.SegmentsWithDefects,
+			ClassCoverage:       route.ClassCoverage,
+			AverageConfidence:   route.AverageConfidence,
 		},
-		CreatedAt:     route.CreatedAt,
-		VideoFilename: route.VideoFilename,
-		VideoPath:     route.VideoPath,
+		CreatedAt:         route.CreatedAt,
+		UpdatedAt:         route.UpdatedAt,
+		VideoFilename:     route.VideoFilename,
+		VideoPath:         route.VideoPath,
+		PlayableVideoPath: route.PlayableVideoPath,
+		StorageBytes:      route.StorageBytes,
+		CustomFields:      route.CustomFields,
+		APIKeyID:          route.APIKeyID,
+		OwnerID:           route.OwnerID,
+		OrganizationID:    route.OrganizationID,
+		ArchivedAt:        route.ArchivedAt,
+		AnalysisParams:    route.AnalysisParams,
+	}
+	response.OverallStats.LowConfidenceSegments = route.LowConfidenceSegments
+	response.OverallStats.QualityScore = route.QualityScore
+	if route.DeletedAt.Valid {
+		response.DeletedAt = &route.DeletedAt.Time
+	}
+	if len(route.Tags) > 0 {
+		response.Tags = tagNames(route.Tags)
+	}
+
+	if route.MatchedGeometry != "" {
+		geometry, err := geo.ParseGeoJSONLineString([]byte(route.MatchedGeometry))
+		if err != nil {
+			s.logger.Warnf("Некорректная привязанная геометрия маршрута %s: %v", route.ID, err)
+		} else {
+			response.MatchedGeometry = fromModelCoordinates(geometry)
+		}
 	}
 
 	// Преобразуем сегменты
 	for _, seg := range route.Segments {
-		segment := SegmentInfo{
-			SegmentID:          int(seg.SegmentID),
-			FramesCount:        int(seg.FramesCount),
-			CoveragePercentage: seg.CoveragePercentage,
-			HasData:            seg.HasData,
-			StartCoordinate:    Coordinates{Lat: seg.StartLat, Lon: seg.StartLon},
-			EndCoordinate:      Coordinates{Lat: seg.EndLat, Lon: seg.EndLon},
-		}
-		response.Segments = append(response.Segments, segment)
+		response.Segments = append(response.Segments, segmentToInfo(seg))
 	}
+	s.applyCoverageBands(response.Segments, &response.OverallStats)
 
 	return response
 }
 
+// segmentToInfo преобразует модель сегмента в ответ API
+func segmentToInfo(seg model.Segment) SegmentInfo {
+	segment := SegmentInfo{
+		SegmentID:          int(seg.SegmentID),
+		FramesCount:        int(seg.FramesCount),
+		CoveragePercentage: seg.CoveragePercentage,
+		HasData:            seg.HasData,
+		StartCoordinate:    Coordinates{Lat: seg.StartLat, Lon: seg.StartLon},
+		EndCoordinate:      Coordinates{Lat: seg.EndLat, Lon: seg.EndLon},
+		RoadName:           seg.RoadName,
+		Defects:            seg.Defects,
+		DefectsCount:       seg.DefectsCount,
+		ClassCoverage:      seg.ClassCoverage,
+		AverageConfidence:  seg.AverageConfidence,
+		MinConfidence:      seg.MinConfidence,
+		LowConfidence:      seg.LowConfidence,
+		QualityScore:       seg.QualityScore,
+	}
+	if seg.MatchedStartLat != nil && seg.MatchedStartLon != nil {
+		segment.MatchedStartCoordinate = &Coordinates{Lat: *seg.MatchedStartLat, Lon: *seg.MatchedStartLon}
+	}
+	if seg.MatchedEndLat != nil && seg.MatchedEndLon != nil {
+		segment.MatchedEndCoordinate = &Coordinates{Lat: *seg.MatchedEndLat, Lon: *seg.MatchedEndLon}
+	}
+	return segment
+}
+
+// toModelCoordinates преобразует координаты сервиса в координаты пакета geo
+func toModelCoordinates(points []Coordinates) []models.Coordinates {
+	result := make([]models.Coordinates, len(points))
+	for i, p := range points {
+		result[i] = models.Coordinates{Lat: p.Lat, Lon: p.Lon}
+	}
+	return result
+}
+
+// fromModelCoordinates преобразует координаты пакета geo в координаты сервиса
+func fromModelCoordinates(points []models.Coordinates) []Coordinates {
+	result := make([]Coordinates, len(points))
+	for i, p := range points {
+		result[i] = Coordinates{Lat: p.Lat, Lon: p.Lon}
+	}
+	return result
+}
+
 // GenerateRouteID генерирует уникальный ID для маршрута
 func (s *RouteService) GenerateRouteID() string {
 	return uuid.New().String()
@@ -286,7 +785,7 @@ func (s *RouteService) UpdateRoute(route *model.Route) error {
 
 // GetRouteVideo возвращает путь к видео файлу маршрута
 func (s *RouteService) GetRouteVideo(routeID string) (string, error) {
-	route, err := s.routeRepo.GetByID(routeID)
+	route, err := s.routeRepo.GetByID(routeID, repository.RouteScope{})
 	if err != nil {
 		return "", fmt.Errorf("failed to get route: %w", err)
 	}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/quality"
+	"road-detector-go/internal/repository"
+	"road-detector-go/pkg/models"
+)
+
+// ErrInvalidSplit возвращается, если маршрут нельзя разделить по указанному сегменту
+var ErrInvalidSplit = errors.New("invalid split position")
+
+// CloneRoute создает копию маршрута с новым ID, сегментами, метками и видео
+func (s *RouteService) CloneRoute(routeID string) (*RouteResponse, error) {
+	s.logger.Infof("Копируем маршрут %s", routeID)
+
+	route, err := s.routeRepo.GetByID(routeID, repository.RouteScope{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route: %w", err)
+	}
+
+	clone := s.copyRoute(route, route.Segments, " (копия)")
+	clone.MatchedGeometry = route.MatchedGeometry
+
+	if err := s.routeRepo.Create(clone); err != nil {
+		s.removeRouteFiles(clone)
+		s.logger.Errorf("Ошибка сохранения копии маршрута: %v", err)
+		return nil, fmt.Errorf("failed to save route copy: %w", err)
+	}
+
+	s.assignToRoads(clone)
+	s.queueTranscode(clone)
+	s.routesChanged(RouteCreated, clone)
+	s.logger.Infof("Маршрут %s скопирован в %s", routeID, clone.ID)
+	return s.modelToResponse(clone), nil
+}
+
+// SplitRoute делит маршрут по сегменту atSegment: сегменты с меньшими номерами
+// остаются в маршруте, остальные переносятся в новый. Сводная статистика
+// обеих частей пересчитывается, видео копируется в новый маршрут.
+func (s *RouteService) SplitRoute(routeID string, atSegment int) (*SplitRouteResponse, error) {
+	s.logger.Infof("Делим маршрут %s по сегменту %d", routeID, atSegment)
+
+	route, err := s.routeRepo.GetByID(routeID, repository.RouteScope{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route: %w", err)
+	}
+
+	sortSegments(route.Segments)
+	var head, tail []model.Segment
+	for _, seg := range route.Segments {
+		if int(seg.SegmentID) < atSegment {
+			head = append(head, seg)
+		} else {
+			tail = append(tail, seg)
+		}
+	}
+	if len(head) == 0 || len(tail) == 0 {
+		return nil, fmt.Errorf("%w: segment %d leaves one of the parts empty", ErrInvalidSplit, atSegment)
+	}
+
+	part := s.copyRoute(route, tail, " (часть 2)")
+
+	route.Segments = head
+	recalculateRouteStats(route)
+	// Привязанная геометрия относится ко всему треку и после разделения не действительна
+	route.MatchedGeometry = ""
+
+	if err := s.routeRepo.Split(route, part, atSegment); err != nil {
+		s.removeRouteFiles(part)
+		s.logger.Errorf("Ошибка разделения маршрута: %v", err)
+		return nil, fmt.Errorf("failed to split route: %w", err)
+	}
+
+	if s.roadService != nil {
+		if err := s.roadService.RemoveRoute(route.ID); err != nil {
+			s.logger.Errorf("Не удалось удалить маршрут %s из дорог: %v", route.ID, err)
+		}
+	}
+	s.assignToRoads(route)
+	s.assignToRoads(part)
+	s.queueTranscode(part)
+	s.routesChanged(RouteUpdated, route)
+	s.routesChanged(RouteCreated, part)
+
+	s.logger.Infof("Маршрут %s разделен, сегменты с %d перенесены в %s", routeID, atSegment, part.ID)
+	return &SplitRouteResponse{
+		Original: *s.modelToResponse(route),
+		Created:  *s.modelToResponse(part),
+	}, nil
+}
+
+// copyRoute создает новый маршрут с метаданными route и копиями сегментов
+// segments. К названию добавляется suffix, видео копируется, владелец и организация сохраняются.
+func (s *RouteService) copyRoute(route *model.Route, segments []model.Segment, suffix string) *model.Route {
+	copied := &model.Route{
+		ID:             s.GenerateRouteID(),
+		Name:           routeNameWithSuffix(route.Name, suffix),
+		Description:    route.Description,
+		SegmentLengthM: route.SegmentLengthM,
+		VideoFilename:  route.VideoFilename,
+		RoadName:       route.RoadName,
+		OwnerID:        route.OwnerID,
+		OrganizationID: route.OrganizationID,
+		CreatedAt:      time.Now(),
+	}
+
+	if len(route.CustomFields) > 0 {
+		copied.CustomFields = make(map[string]string, len(route.CustomFields))
+		for key, value := range route.CustomFields {
+			copied.CustomFields[key] = value
+		}
+	}
+	for _, tag := range route.Tags {
+		copied.Tags = append(copied.Tags, model.Tag{Name: tag.Name})
+	}
+
+	for _, seg := range segments {
+		seg.ID = 0
+		seg.RouteID = copied.ID
+		seg.CreatedAt = time.Time{}
+		seg.UpdatedAt = time.Time{}
+		copied.Segments = append(copied.Segments, seg)
+	}
+	sortSegments(copied.Segments)
+	recalculateRouteStats(copied)
+
+	if route.VideoPath != "" {
+		if err := s.copyVideoFile(route, copied); err != nil {
+			s.logger.Warnf("Не удалось скопировать видео маршрута %s: %v", route.ID, err)
+		}
+	}
+	s.measureStorage(copied)
+	return copied
+}
+
+// copyVideoFile копирует видео маршрута src в каталог маршрута dst
+func (s *RouteService) copyVideoFile(src, dst *model.Route) error {
+	file, err := os.Open(src.VideoPath)
+	if err != nil {
+		return fmt.Errorf("failed to open video: %w", err)
+	}
+	defer file.Close()
+
+	path, err := s.saveVideoFile(dst.ID, src.VideoPath, file)
+	if err != nil {
+		return err
+	}
+	dst.VideoPath = path
+	return nil
+}
+
+// assignToRoads относит маршрут к дороге, ошибка только логируется
+func (s *RouteService) assignToRoads(route *model.Route) {
+	if s.roadService == nil {
+		return
+	}
+	if err := s.roadService.AssignRoute(route); err != nil {
+		s.logger.Errorf("Не удалось добавить маршрут %s в дороги: %v", route.ID, err)
+	}
+}
+
+// recalculateRouteStats пересчитывает концы и сводную статистику маршрута
+// по его сегментам так же, как при анализе. Сегменты должны быть упорядочены.
+func recalculateRouteStats(route *model.Route) {
+	if len(route.Segments) == 0 {
+		return
+	}
+
+	calculator := geo.NewCalculator()
+	infos := make([]models.SegmentInfo, len(route.Segments))
+	totalFrames := 0
+	totalDistance := 0.0
+	scores := make([]*float64, len(route.Segments))
+	lengths := make([]float64, len(route.Segments))
+	for i, seg := range route.Segments {
+		start := models.Coordinates{Lat: seg.StartLat, Lon: seg.StartLon}
+		end := models.Coordinates{Lat: seg.EndLat, Lon: seg.EndLon}
+		infos[i] = models.SegmentInfo{
+			SegmentID:          seg.SegmentID,
+			FramesCount:        seg.FramesCount,
+			CoveragePercentage: seg.CoveragePercentage,
+			StartCoordinate:    start,
+			EndCoordinate:      end,
+			HasData:            seg.HasData,
+		}
+		totalFrames += int(seg.FramesCount)
+		lengths[i] = calculator.DistanceMeters(start, end)
+		totalDistance += lengths[i]
+		scores[i] = seg.QualityScore
+	}
+
+	stats := calculator.CalculateOverallStats(infos, totalFrames, totalDistance, route.SegmentLengthM)
+
+	first, last := route.Segments[0], route.Segments[len(route.Segments)-1]
+	route.StartLat, route.StartLon = first.StartLat, first.StartLon
+	route.EndLat, route.EndLon = last.EndLat, last.EndLon
+	route.TotalFrames = int(stats.TotalFrames)
+	route.TotalDistanceMeters = stats.TotalDistanceMeters
+	route.TotalSegments = int(stats.TotalSegments)
+	route.SegmentsWithData = int(stats.SegmentsWithData)
+	route.AverageCoverage = stats.AverageCoverage
+
+	route.DefectsCount, route.SegmentsWithDefects, route.LowConfidenceSegments = 0, 0, 0
+	var classCoverage []map[string]float64
+	var confidence []float64
+	for _, seg := range route.Segments {
+		if seg.DefectsCount > 0 {
+			route.DefectsCount += seg.DefectsCount
+			route.SegmentsWithDefects++
+		}
+		if seg.LowConfidence {
+			route.LowConfidenceSegments++
+		}
+		if !seg.HasData {
+			continue
+		}
+		if seg.ClassCoverage != nil {
+			classCoverage = append(classCoverage, seg.ClassCoverage)
+		}
+		if seg.AverageConfidence != nil {
+			confidence = append(confidence, *seg.AverageConfidence)
+		}
+	}
+	route.ClassCoverage = model.AverageClassCoverage(classCoverage)
+	route.AverageConfidence = averageConfidence(confidence)
+	route.QualityScore = quality.Route(scores, lengths)
+}
+
+// sortSegments упорядочивает сегменты по номеру
+func sortSegments(segments []model.Segment) {
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].SegmentID < segments[j].SegmentID
+	})
+}
+
+// routeNameWithSuffix добавляет суффикс к названию, укорачивая его до
+// допустимой длины
+func routeNameWithSuffix(name, suffix string) string {
+	limit := maxRouteNameLength - utf8.RuneCountInString(suffix)
+	if runes := []rune(name); len(runes) > limit {
+		name = string(runes[:limit])
+	}
+	return name + suffix
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"road-detector-go/internal/model"
+)
+
+// storageBackfillBatch маршрутов, измеряемых за один запрос к базе данных
+const storageBackfillBatch = 100
+
+// measureStorage записывает в маршрут размер его видео, копии видео для
+// браузеров и аннотированного видео. Отсутствующие файлы занимают 0 байт.
+func (s *RouteService) measureStorage(route *model.Route) {
+	var size int64
+	paths := []string{route.VideoPath}
+	if route.PlayableVideoPath != route.VideoPath {
+		paths = append(paths, route.PlayableVideoPath)
+	}
+	if route.VideoFilename != "" {
+		paths = append(paths, filepath.Join(s.staticDir, "annotated_"+route.ID+"_"+route.VideoFilename))
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+	}
+	route.StorageBytes = &size
+}
+
+// BackfillStorageBytes измеряет файлы маршрутов, сохраненных до учета
+// хранилища, и возвращает число измеренных маршрутов
+func (s *RouteService) BackfillStorageBytes() (int, error) {
+	measured := 0
+	for {
+		routes, err := s.routeRepo.ListUnmeasured(storageBackfillBatch)
+		if err != nil {
+			return measured, err
+		}
+		if len(routes) == 0 {
+			return measured, nil
+		}
+		for _, route := range routes {
+			s.measureStorage(route)
+			if err := s.routeRepo.SetStorageBytes(route.ID, *route.StorageBytes); err != nil {
+				return measured, fmt.Errorf("failed to measure route %s: %w", route.ID, err)
+			}
+			measured++
+		}
+	}
+}
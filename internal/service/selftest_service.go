@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/selftest"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Координаты тестового маршрута самопроверки
+const (
+	selfTestStartLat      = 55.755800
+	selfTestStartLon      = 37.617600
+	selfTestEndLat        = 55.756800
+	selfTestEndLon        = 37.618600
+	selfTestSegmentLength = 50
+)
+
+// selfTestRouteName название тестового маршрута, по нему его легко отличить в списке
+const selfTestRouteName = "Самопроверка"
+
+// SelfTestService прогоняет тестовое видео через весь конвейер анализа
+type SelfTestService struct {
+	analyzerService *AnalyzerService
+	routeService    *RouteService
+	logger          *logrus.Logger
+	videoPath       string
+}
+
+// NewSelfTestService создает сервис самопроверки. Если videoPath пуст,
+// используется встроенное тестовое видео.
+func NewSelfTestService(analyzerService *AnalyzerService, routeService *RouteService, logger *logrus.Logger, videoPath string) *SelfTestService {
+	return &SelfTestService{
+		analyzerService: analyzerService,
+		routeService:    routeService,
+		logger:          logger,
+		videoPath:       videoPath,
+	}
+}
+
+// Run выполняет самопроверку на временном маршруте и удаляет его после проверки
+func (s *SelfTestService) Run() *SelfTestReport {
+	report := &SelfTestReport{
+		RouteID:   s.routeService.GenerateRouteID(),
+		StartedAt: time.Now(),
+		Passed:    true,
+	}
+	s.logger.Infof("Запуск самопроверки на временном маршруте %s", report.RouteID)
+
+	var (
+		videoData []byte
+		filename  string
+		route     *RouteResponse
+	)
+
+	report.run("sample_video", func() error {
+		var err error
+		videoData, filename, err = s.loadVideo()
+		return err
+	})
+
+	report.run("python_health", func() error {
+		_, err := s.analyzerService.FetchHealth()
+		return err
+	})
+
+	report.run("analysis", func() error {
+		if videoData == nil {
+			return errSelfTestSkipped
+		}
+		_, err := s.analyzerService.AnalyzeRoadMarking(
+			selfTestStartLat, selfTestStartLon, selfTestEndLat, selfTestEndLon,
+			selfTestSegmentLength, bytes.NewReader(videoData), filename, report.RouteID,
+			RouteMetadata{Name: selfTestRouteName, selfTest: true},
+		)
+		return err
+	})
+
+	report.run("db_save", func() error {
+		if report.stageFailed("analysis") {
+			return errSelfTestSkipped
+		}
+		var err error
+		route, err = s.routeService.GetRouteByID(report.RouteID, repository.RouteScope{})
+		return err
+	})
+
+	report.run("file_storage", func() error {
+		if route == nil {
+			return errSelfTestSkipped
+		}
+		if route.VideoPath == "" {
+			return errors.New("route has no stored video")
+		}
+		info, err := os.Stat(route.VideoPath)
+		if err != nil {
+			return err
+		}
+		if info.Size() != int64(len(videoData)) {
+			return fmt.Errorf("stored video size %d differs from uploaded %d", info.Size(), len(videoData))
+		}
+		return nil
+	})
+
+	report.run("cleanup", func() error {
+		os.Remove(s.analyzerService.AnnotatedVideoPath(report.RouteID, filename))
+		if route == nil {
+			return nil
+		}
+		return s.routeService.PurgeRoute(report.RouteID)
+	})
+
+	report.DurationMs = float64(time.Since(report.StartedAt).Microseconds()) / 1000
+	if report.Passed {
+		s.logger.Infof("Самопроверка пройдена за %.0f мс", report.DurationMs)
+	} else {
+		s.logger.Errorf("Самопроверка не пройдена")
+	}
+	return report
+}
+
+// loadVideo возвращает видео для самопроверки
+func (s *SelfTestService) loadVideo() ([]byte, string, error) {
+	if s.videoPath != "" {
+		data, err := os.ReadFile(s.videoPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read self-test video: %w", err)
+		}
+		return data, filepath.Base(s.videoPath), nil
+	}
+
+	data, err := selftest.SampleVideo()
+	if err != nil {
+		return nil, "", err
+	}
+	return data, selftest.SampleVideoFilename, nil
+}
+
+// errSelfTestSkipped означает, что этап пропущен из-за ошибки предыдущего
+var errSelfTestSkipped = errors.New("skipped because a previous stage failed")
+
+// run выполняет этап самопроверки и записывает результат
+func (r *SelfTestReport) run(name string, fn func() error) {
+	start := time.Now()
+	err := fn()
+
+	stage := SelfTestStage{
+		Name:       name,
+		Status:     "passed",
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	switch {
+	case errors.Is(err, errSelfTestSkipped):
+		stage.Status = "skipped"
+		r.Passed = false
+	case err != nil:
+		stage.Status = "failed"
+		stage.Error = err.Error()
+		r.Passed = false
+	}
+	r.Stages = append(r.Stages, stage)
+}
+
+// stageFailed проверяет, не прошел ли этап
+func (r *SelfTestReport) stageFailed(name string) bool {
+	for _, stage := range r.Stages {
+		if stage.Name == name {
+			return stage.Status != "passed"
+		}
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"road-detector-go/internal/analyzerpool"
+	"road-detector-go/internal/debugcapture"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxShadowAnalyses сколько теневых анализов выполняется одновременно.
+// Анализ сверх этого числа пропускается, чтобы теневой анализатор не
+// задерживал основные анализы.
+const maxShadowAnalyses = 2
+
+// ShadowOptions настройки теневого анализа
+type ShadowOptions struct {
+	// URL базовый адрес второго анализатора, пусто — теневой анализ выключен
+	URL string
+	// Percent доля анализов в процентах, которые повторяются теневым анализатором
+	Percent float64
+	// Timeout ожидание ответа теневого анализатора
+	Timeout time.Duration
+}
+
+// ShadowService повторяет часть анализов вторым (теневым) анализатором и
+// сохраняет его результаты рядом с маршрутом. Маршрут всегда строится по
+// основному анализатору; теневые результаты нужны, чтобы оценить расхождение
+// новой версии модели до переключения на нее.
+type ShadowService struct {
+	shadowRepo   repository.ShadowAnalysisRepository
+	routeService *RouteService
+	logger       *logrus.Logger
+	opts         ShadowOptions
+	analyzer     *AnalyzerService
+
+	slots  chan struct{}
+	sample func() float64
+}
+
+// NewShadowService создает сервис теневого анализа с анализатором opts.URL
+func NewShadowService(shadowRepo repository.ShadowAnalysisRepository, routeService *RouteService, opts ShadowOptions, logger *logrus.Logger) *ShadowService {
+	analyzer := NewAnalyzerService(analyzerpool.Options{URLs: []string{opts.URL}}, logger, nil)
+	analyzer.SetTimeout(opts.Timeout)
+	return &ShadowService{
+		shadowRepo:   shadowRepo,
+		routeService: routeService,
+		logger:       logger,
+		opts:         opts,
+		analyzer:     analyzer,
+		slots:        make(chan struct{}, maxShadowAnalyses),
+		sample:       rand.Float64,
+	}
+}
+
+// Enabled проверяет, что теневой анализ включен
+func (s *ShadowService) Enabled() bool {
+	return s != nil && s.opts.URL != "" && s.opts.Percent > 0
+}
+
+// Submit отбирает анализ с вероятностью Percent и в фоне повторяет его
+// теневым анализатором. Ничего не делает, если анализ не отобран или уже
+// выполняется maxShadowAnalyses теневых анализов.
+func (s *ShadowService) Submit(routeID, videoFilename string, videoData []byte, startLat, startLon, endLat, endLon, segmentLength float64, params model.AnalysisParams) {
+	if !s.Enabled() || len(videoData) == 0 || s.sample()*100 >= s.opts.Percent {
+		return
+	}
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.logger.Warnf("Теневой анализ маршрута %s пропущен: выполняется %d теневых анализов", routeID, maxShadowAnalyses)
+		return
+	}
+
+	go func() {
+		defer func() { <-s.slots }()
+		s.run(routeID, videoFilename, videoData, startLat, startLon, endLat, endLon, segmentLength, params)
+	}()
+}
+
+// run выполняет теневой анализ и сохраняет его результат, в том числе ошибку
+func (s *ShadowService) run(routeID, videoFilename string, videoData []byte, startLat, startLon, endLat, endLon, segmentLength float64, params model.AnalysisParams) {
+	s.logger.Infof("Теневой анализ маршрута %s анализатором %s", routeID, s.opts.URL)
+
+	analysis := &model.ShadowAnalysis{
+		RouteID:     routeID,
+		AnalyzerURL: s.opts.URL,
+		Status:      model.ShadowAnalysisCompleted,
+	}
+	// Формат API анализа выбирается по версии теневого анализатора
+	var err error
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if health, healthErr := s.analyzer.fetchHealth(ctx, s.opts.URL); healthErr == nil {
+		analysis.AnalyzerVersion = health.Version
+		err = s.analyzer.useContractFor(health.Version)
+	}
+	cancel()
+
+	var result *AnalysisResult
+	if err == nil {
+		rec := debugcapture.NewRecorder(routeID)
+		started := time.Now()
+		result, _, err = s.analyzer.analyzeHTTP(startLat, startLon, endLat, endLon, segmentLength, params, s.analyzer.Timeout(), videoData, videoFilename, s.logger, rec)
+		analysis.DurationMs = float64(time.Since(started).Microseconds()) / 1000
+	}
+	if err != nil {
+		s.logger.Warnf("Теневой анализ маршрута %s завершился ошибкой: %v", routeID, err)
+		analysis.Status = model.ShadowAnalysisFailed
+		analysis.Error = err.Error()
+	} else {
+		analysis.TotalFrames = result.OverallStats.TotalFrames
+		analysis.TotalSegments = result.OverallStats.TotalSegments
+		analysis.SegmentsWithData = result.OverallStats.SegmentsWithData
+		analysis.AverageCoverage = result.OverallStats.AverageCoverage
+		analysis.Segments = make([]model.ShadowSegment, len(result.Segments))
+		for i, seg := range result.Segments {
+			analysis.Segments[i] = model.ShadowSegment{
+				SegmentID:          seg.SegmentID,
+				FramesCount:        seg.FramesCount,
+				CoveragePercentage: seg.CoveragePercentage,
+				HasData:            seg.HasData,
+			}
+		}
+	}
+
+	if err := s.shadowRepo.Create(analysis); err != nil {
+		s.logger.Errorf("Ошибка сохранения теневого анализа маршрута %s: %v", routeID, err)
+		return
+	}
+	if analysis.Status == model.ShadowAnalysisCompleted {
+		s.logger.Infof("Теневой анализ маршрута %s завершен: среднее покрытие %.2f%%", routeID, analysis.AverageCoverage)
+	}
+}
+
+// Compare сравнивает основной анализ маршрута с его теневыми анализами.
+// Сегмент считается измененным, если покрытие разошлось больше чем на
+// threshold процентных пунктов или не совпало наличие данных.
+func (s *ShadowService) Compare(routeID string, threshold float64) (*AnalysisComparison, error) {
+	route, err := s.routeService.GetRouteByID(routeID, repository.RouteScope{})
+	if err != nil {
+		return nil, err
+	}
+	analyses, err := s.shadowRepo.ListForRoute(routeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shadow analyses: %w", err)
+	}
+
+	comparison := &AnalysisComparison{
+		RouteID:   routeID,
+		Threshold: threshold,
+		Primary: AnalysisSnapshot{
+			TotalFrames:      route.OverallStats.TotalFrames,
+			TotalSegments:    route.OverallStats.TotalSegments,
+			SegmentsWithData: route.OverallStats.SegmentsWithData,
+			AverageCoverage:  route.OverallStats.AverageCoverage,
+		},
+		Shadows: make([]ShadowComparison, 0, len(analyses)),
+	}
+	for _, analysis := range analyses {
+		shadow := ShadowComparison{
+			ID:              analysis.ID,
+			AnalyzerURL:     analysis.AnalyzerURL,
+			AnalyzerVersion: analysis.AnalyzerVersion,
+			Status:          analysis.Status,
+			Error:           analysis.Error,
+			DurationMs:      analysis.DurationMs,
+			CreatedAt:       analysis.CreatedAt,
+		}
+		if analysis.Status == model.ShadowAnalysisCompleted {
+			shadow.Stats = &AnalysisSnapshot{
+				TotalFrames:      analysis.TotalFrames,
+				TotalSegments:    analysis.TotalSegments,
+				SegmentsWithData: analysis.SegmentsWithData,
+				AverageCoverage:  analysis.AverageCoverage,
+			}
+			shadow.Diff = diffAnalyses(route, analysis, threshold)
+		}
+		comparison.Shadows = append(comparison.Shadows, shadow)
+	}
+	return comparison, nil
+}
+
+// diffAnalyses считает расхождение теневого анализа с маршрутом. Сегменты
+// сопоставляются по ID.
+func diffAnalyses(route *RouteResponse, analysis model.ShadowAnalysis, threshold float64) *AnalysisDiff {
+	diff := &AnalysisDiff{
+		AverageCoverageDelta: analysis.AverageCoverage - route.OverallStats.AverageCoverage,
+		SegmentCountDelta:    len(analysis.Segments) - len(route.Segments),
+		Segments:             []SegmentDiff{},
+	}
+
+	primary := make(map[int]SegmentInfo, len(route.Segments))
+	for _, seg := range route.Segments {
+		primary[seg.SegmentID] = seg
+	}
+	var totalAbs float64
+	for _, shadowSeg := range analysis.Segments {
+		seg, ok := primary[shadowSeg.SegmentID]
+		if !ok {
+			continue
+		}
+		diff.SegmentsCompared++
+		delta := shadowSeg.CoveragePercentage - seg.CoveragePercentage
+		abs := math.Abs(delta)
+		totalAbs += abs
+		diff.MaxAbsoluteCoverageDiff = math.Max(diff.MaxAbsoluteCoverageDiff, abs)
+
+		hasDataMismatch := shadowSeg.HasData != seg.HasData
+		if hasDataMismatch {
+			diff.HasDataMismatches++
+		}
+		if abs > threshold || hasDataMismatch {
+			diff.SegmentsChanged++
+			diff.Segments = append(diff.Segments, SegmentDiff{
+				SegmentID:       seg.SegmentID,
+				PrimaryCoverage: seg.CoveragePercentage,
+				ShadowCoverage:  shadowSeg.CoveragePercentage,
+				Delta:           delta,
+				PrimaryHasData:  seg.HasData,
+				ShadowHasData:   shadowSeg.HasData,
+			})
+		}
+	}
+	if diff.SegmentsCompared > 0 {
+		diff.MeanAbsoluteCoverageDiff = totalAbs / float64(diff.SegmentsCompared)
+	}
+	return diff
+}
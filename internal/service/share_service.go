@@ -0,0 +1,160 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidShareRequest возвращается при некорректных данных ссылки
+var ErrInvalidShareRequest = errors.New("invalid share link request")
+
+const (
+	// shareTokenPrefix начало всех токенов ссылок
+	shareTokenPrefix = "shr_"
+	// shareTokenBytes количество случайных байт токена
+	shareTokenBytes = 24
+	// shareTokenDisplayLength сколько символов токена сохраняется открыто
+	shareTokenDisplayLength = 12
+	// SharedRoutesPath путь публичного доступа к маршруту по токену
+	SharedRoutesPath = "/api/v1/shared/"
+)
+
+// ShareService управляет публичными ссылками на маршруты. Ссылка дает доступ
+// только на чтение к одному маршруту, его видео и выгрузке для карт.
+// Токен хранится в виде SHA-256 хэша и показывается только при создании.
+type ShareService struct {
+	shareRepo    repository.ShareLinkRepository
+	routeService *RouteService
+	logger       *logrus.Logger
+	now          func() time.Time
+}
+
+// NewShareService создает новый сервис публичных ссылок
+func NewShareService(shareRepo repository.ShareLinkRepository, routeService *RouteService, logger *logrus.Logger) *ShareService {
+	return &ShareService{
+		shareRepo:    shareRepo,
+		routeService: routeService,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// CreateShareLink создает ссылку на маршрут и возвращает ее вместе с токеном
+func (s *ShareService) CreateShareLink(routeID string, req CreateShareLinkRequest, creator ShareLinkCreator) (*CreatedShareLinkResponse, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidShareRequest)
+	}
+
+	secret := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	token := shareTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	link := &model.ShareLink{
+		RouteID:           routeID,
+		Prefix:            token[:shareTokenDisplayLength],
+		TokenHash:         hashShareToken(token),
+		ExpiresAt:         req.ExpiresAt,
+		CreatedByUserID:   creator.UserID,
+		CreatedByAPIKeyID: creator.APIKeyID,
+	}
+	if err := s.shareRepo.Create(link); err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	s.logger.Infof("Создана ссылка %d (%s) на маршрут %s", link.ID, link.Prefix, routeID)
+	return &CreatedShareLinkResponse{
+		ShareLinkInfo: s.shareLinkInfo(link),
+		Token:         token,
+		URL:           SharedRoutesPath + token,
+	}, nil
+}
+
+// ListShareLinks возвращает все ссылки маршрута, включая отозванные и просроченные
+func (s *ShareService) ListShareLinks(routeID string) ([]ShareLinkInfo, error) {
+	links, err := s.shareRepo.ListForRoute(routeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+
+	result := make([]ShareLinkInfo, len(links))
+	for i := range links {
+		result[i] = s.shareLinkInfo(&links[i])
+	}
+	return result, nil
+}
+
+// RevokeShareLink отзывает ссылку маршрута
+func (s *ShareService) RevokeShareLink(routeID string, id uint) (*ShareLinkInfo, error) {
+	link, err := s.shareRepo.Revoke(id, routeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke share link: %w", err)
+	}
+
+	s.logger.Infof("Ссылка %d (%s) на маршрут %s отозвана", link.ID, link.Prefix, routeID)
+	info := s.shareLinkInfo(link)
+	return &info, nil
+}
+
+// ResolveShareLink возвращает маршрут действующей ссылки. Для неизвестного,
+// отозванного или просроченного токена возвращается ErrShareLinkNotFound.
+func (s *ShareService) ResolveShareLink(token string) (*RouteResponse, error) {
+	if !strings.HasPrefix(token, shareTokenPrefix) {
+		return nil, repository.ErrShareLinkNotFound
+	}
+
+	link, err := s.shareRepo.GetActiveByHash(hashShareToken(token), s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve share link: %w", err)
+	}
+
+	route, err := s.routeService.GetRouteByID(link.RouteID, repository.RouteScope{})
+	if err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+// SharedRouteView убирает из маршрута данные, которые не показываются
+// по публичной ссылке: путь к файлу и владельцев
+func SharedRouteView(route *RouteResponse) *RouteResponse {
+	view := *route
+	view.VideoPath = ""
+	view.APIKeyID = nil
+	view.OwnerID = nil
+	view.OrganizationID = nil
+	return &view
+}
+
+// hashShareToken хэш токена ссылки для хранения и поиска
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// shareLinkInfo преобразует ссылку в ответ API
+func (s *ShareService) shareLinkInfo(link *model.ShareLink) ShareLinkInfo {
+	return ShareLinkInfo{
+		ID:                link.ID,
+		RouteID:           link.RouteID,
+		Prefix:            link.Prefix,
+		ExpiresAt:         link.ExpiresAt,
+		RevokedAt:         link.RevokedAt,
+		Active:            link.RevokedAt == nil && (link.ExpiresAt == nil || link.ExpiresAt.After(s.now())),
+		CreatedAt:         link.CreatedAt,
+		CreatedByUserID:   link.CreatedByUserID,
+		CreatedByAPIKeyID: link.CreatedByAPIKeyID,
+	}
+}
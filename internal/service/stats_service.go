@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// statsDays количество дней в истории анализов сводки администратора
+const statsDays = 30
+
+// StatsService собирает сводку для панели администратора
+type StatsService struct {
+	analyticsRepo repository.AnalyticsRepository
+	analyzer      *AnalyzerService
+	staticDir     string
+	logger        *logrus.Logger
+}
+
+// NewStatsService создает новый сервис сводки
+func NewStatsService(analyticsRepo repository.AnalyticsRepository, analyzer *AnalyzerService, staticDir string, logger *logrus.Logger) *StatsService {
+	return &StatsService{
+		analyticsRepo: analyticsRepo,
+		analyzer:      analyzer,
+		staticDir:     staticDir,
+		logger:        logger,
+	}
+}
+
+// GetAdminStats возвращает количество маршрутов и сегментов, анализы
+// и среднее покрытие по дням за последние 30 дней, занятое место,
+// неудачные задачи и длительность анализов
+func (s *StatsService) GetAdminStats() (*AdminStatsResponse, error) {
+	now := time.Now().UTC()
+
+	totals, err := s.analyticsRepo.Totals()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get totals: %w", err)
+	}
+
+	today := now.Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(statsDays - 1))
+	days, err := s.analyticsRepo.DailyAnalyses(since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily analyses: %w", err)
+	}
+
+	staticBytes, staticFiles, err := dirUsage(s.staticDir)
+	if err != nil {
+		// Сводка полезна и без размера каталога, поэтому ошибка не прерывает запрос
+		s.logger.Warnf("Не удалось посчитать размер каталога %s: %v", s.staticDir, err)
+	}
+
+	analyzer := s.analyzer.Stats()
+
+	return &AdminStatsResponse{
+		Routes:   totals.Routes,
+		Segments: totals.Segments,
+		Daily:    fillDays(days, since, statsDays),
+		Storage: StorageStats{
+			StaticBytes:   staticBytes,
+			StaticFiles:   staticFiles,
+			DatabaseBytes: totals.DatabaseBytes,
+		},
+		FailedJobs: FailedJobStats{
+			Analyses:          analyzer.Failed,
+			WebhookDeliveries: totals.FailedDeliveries,
+		},
+		Analyzer:    analyzer,
+		GeneratedAt: now,
+	}, nil
+}
+
+// fillDays раскладывает дни с анализами по count дням начиная с since,
+// дни без анализов получают нулевые значения
+func fillDays(days []repository.DailyAnalyses, since time.Time, count int) []DailyAnalysisStats {
+	byDate := make(map[string]repository.DailyAnalyses, len(days))
+	for _, day := range days {
+		byDate[day.Day.UTC().Format(time.DateOnly)] = day
+	}
+
+	out := make([]DailyAnalysisStats, 0, count)
+	for i := 0; i < count; i++ {
+		date := since.AddDate(0, 0, i).Format(time.DateOnly)
+		day := byDate[date]
+		out = append(out, DailyAnalysisStats{
+			Date:            date,
+			Analyses:        day.Analyses,
+			AverageCoverage: day.AverageCoverage,
+		})
+	}
+	return out
+}
+
+// dirUsage возвращает суммарный размер и количество файлов в каталоге.
+// Файлы, удаленные или переименованные во время обхода, пропускаются.
+func dirUsage(dir string) (int64, int64, error) {
+	var size, files int64
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path != dir && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		files++
+		return nil
+	})
+	return size, files, err
+}
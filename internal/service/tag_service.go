@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidTag возвращается при некорректном названии метки
+var ErrInvalidTag = errors.New("invalid tag")
+
+const (
+	maxTagLength    = 64
+	maxTagsPerRoute = 20
+)
+
+// TagService сервис меток маршрутов
+type TagService struct {
+	tagRepo repository.TagRepository
+	logger  *logrus.Logger
+}
+
+// NewTagService создает новый сервис меток
+func NewTagService(tagRepo repository.TagRepository, logger *logrus.Logger) *TagService {
+	return &TagService{
+		tagRepo: tagRepo,
+		logger:  logger,
+	}
+}
+
+// ListTags возвращает все метки с количеством маршрутов из области доступа scope
+func (s *TagService) ListTags(scope repository.RouteScope) ([]TagInfo, error) {
+	tags, err := s.tagRepo.List(scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	result := make([]TagInfo, len(tags))
+	for i, tag := range tags {
+		result[i] = TagInfo{
+			ID:         tag.ID,
+			Name:       tag.Name,
+			RouteCount: tag.RouteCount,
+			CreatedAt:  tag.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+// CreateTag создает метку
+func (s *TagService) CreateTag(name string) (*TagInfo, error) {
+	name, err := NormalizeTag(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &model.Tag{Name: name}
+	if err := s.tagRepo.Create(tag); err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	s.logger.Infof("Создана метка %q", tag.Name)
+	return &TagInfo{ID: tag.ID, Name: tag.Name, CreatedAt: tag.CreatedAt}, nil
+}
+
+// RenameTag переименовывает метку
+func (s *TagService) RenameTag(id uint, name string) (*TagInfo, error) {
+	name, err := NormalizeTag(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := s.tagRepo.Rename(id, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+
+	s.logger.Infof("Метка %d переименована в %q", id, tag.Name)
+	return &TagInfo{ID: tag.ID, Name: tag.Name, CreatedAt: tag.CreatedAt}, nil
+}
+
+// DeleteTag удаляет метку у всех маршрутов
+func (s *TagService) DeleteTag(id uint) error {
+	if err := s.tagRepo.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	s.logger.Infof("Метка %d удалена", id)
+	return nil
+}
+
+// SetRouteTags заменяет метки маршрута. Отсутствующие метки создаются.
+func (s *TagService) SetRouteTags(routeID string, names []string) (*RouteTagsResponse, error) {
+	names, err := NormalizeTags(names)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepo.SetRouteTags(routeID, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set route tags: %w", err)
+	}
+
+	s.logger.Infof("Метки маршрута %s: %v", routeID, names)
+	return &RouteTagsResponse{RouteID: routeID, Tags: tagNames(tags)}, nil
+}
+
+// NormalizeTag приводит название метки к каноническому виду: без лишних
+// пробелов и в нижнем регистре
+func NormalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if name == "" {
+		return "", fmt.Errorf("%w: name must not be empty", ErrInvalidTag)
+	}
+	if utf8.RuneCountInString(name) > maxTagLength {
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidTag, name, maxTagLength)
+	}
+	return name, nil
+}
+
+// NormalizeTags нормализует список меток маршрута и удаляет повторы
+func NormalizeTags(names []string) ([]string, error) {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	if len(result) > maxTagsPerRoute {
+		return nil, fmt.Errorf("%w: more than %d tags", ErrInvalidTag, maxTagsPerRoute)
+	}
+	return result, nil
+}
+
+// tagNames возвращает названия меток по алфавиту
+func tagNames(tags []model.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	sort.Strings(names)
+	return names
+}
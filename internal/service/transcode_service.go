@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/repository"
+	"road-detector-go/internal/transcode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TranscodeOptions настройки очереди перекодирования видео
+type TranscodeOptions struct {
+	// Workers видео, перекодируемых одновременно
+	Workers int
+	// QueueSize видео, ожидающих перекодирования. Видео, не поместившееся
+	// в очередь, перекодируется после перезапуска.
+	QueueSize int
+	// Timeout ограничение перекодирования одного видео, 0 — без ограничения
+	Timeout time.Duration
+}
+
+// TranscodeService в фоне проверяет, воспроизводятся ли видео маршрутов в
+// браузерах, и создает рядом с видео, которое не воспроизводится, копию в
+// H.264 MP4. Исходное видео не меняется.
+type TranscodeService struct {
+	routes     *RouteService
+	transcoder transcode.Transcoder
+	opts       TranscodeOptions
+	logger     *logrus.Logger
+	jobs       chan string
+}
+
+// NewTranscodeService создает очередь перекодирования видео маршрутов
+func NewTranscodeService(routes *RouteService, transcoder transcode.Transcoder, opts TranscodeOptions, logger *logrus.Logger) *TranscodeService {
+	opts.Workers = max(1, opts.Workers)
+	opts.QueueSize = max(1, opts.QueueSize)
+	return &TranscodeService{
+		routes:     routes,
+		transcoder: transcoder,
+		opts:       opts,
+		logger:     logger,
+		jobs:       make(chan string, opts.QueueSize),
+	}
+}
+
+// SetTranscodeService включает перекодирование видео сохраненных маршрутов
+func (s *RouteService) SetTranscodeService(transcodes *TranscodeService) {
+	s.transcodes = transcodes
+}
+
+// queueTranscode ставит видео маршрута в очередь перекодирования, если она
+// включена
+func (s *RouteService) queueTranscode(route *model.Route) {
+	if s.transcodes != nil && route.VideoPath != "" {
+		s.transcodes.Enqueue(route.ID)
+	}
+}
+
+// Enqueue ставит видео маршрута в очередь, не дожидаясь места в ней
+func (s *TranscodeService) Enqueue(routeID string) {
+	select {
+	case s.jobs <- routeID:
+	default:
+		s.logger.Warnf("Очередь перекодирования заполнена, видео маршрута %s будет перекодировано после перезапуска", routeID)
+	}
+}
+
+// Run перекодирует видео из очереди до отмены ctx. Сначала в очередь
+// ставятся видео, которые еще не проверены, в том числе сохраненные до
+// включения перекодирования.
+func (s *TranscodeService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case routeID := <-s.jobs:
+					s.process(ctx, routeID)
+				}
+			}
+		}()
+	}
+
+	ids, err := s.routes.routeRepo.ListUntranscoded()
+	if err != nil {
+		s.logger.Errorf("Не удалось найти видео для перекодирования: %v", err)
+	} else if len(ids) > 0 {
+		s.logger.Infof("Видео, еще не проверенных на воспроизведение в браузерах: %d", len(ids))
+	}
+sweep:
+	for _, id := range ids {
+		select {
+		case s.jobs <- id:
+		case <-ctx.Done():
+			break sweep
+		}
+	}
+	wg.Wait()
+}
+
+// process проверяет видео маршрута и при необходимости перекодирует его.
+// При ошибке видео остается непроверенным и проверяется снова после
+// перезапуска.
+func (s *TranscodeService) process(ctx context.Context, routeID string) {
+	route, err := s.routes.routeRepo.GetByID(routeID, repository.RouteScope{})
+	if err != nil {
+		if !errors.Is(err, repository.ErrRouteNotFound) {
+			s.logger.Errorf("Не удалось получить маршрут %s для перекодирования: %v", routeID, err)
+		}
+		return
+	}
+	// Маршрут мог быть поставлен в очередь дважды
+	if route.VideoPath == "" || route.PlayableVideoPath != "" {
+		return
+	}
+
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+	playable, err := s.transcoder.Playable(ctx, route.VideoPath)
+	if err != nil {
+		s.logger.Warnf("Не удалось проверить видео маршрута %s: %v", routeID, err)
+		return
+	}
+
+	path := route.VideoPath
+	if !playable {
+		path = playableCopyPath(route.VideoPath)
+		started := time.Now()
+		if err := s.transcoder.Transcode(ctx, route.VideoPath, path); err != nil {
+			s.logger.Errorf("Не удалось перекодировать видео маршрута %s: %v", routeID, err)
+			return
+		}
+		s.logger.Infof("Видео маршрута %s перекодировано в H.264 MP4 за %s", routeID, time.Since(started).Round(time.Second))
+	}
+
+	route.PlayableVideoPath = path
+	s.routes.measureStorage(route)
+	if err := s.routes.routeRepo.SetPlayableVideo(route.ID, path, *route.StorageBytes); err != nil {
+		s.logger.Errorf("Не удалось сохранить видео маршрута %s для браузеров: %v", routeID, err)
+		if path != route.VideoPath {
+			os.Remove(path)
+		}
+	}
+}
+
+// playableCopyPath путь копии видео для браузеров рядом с исходным видео
+func playableCopyPath(videoPath string) string {
+	return strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".playable.mp4"
+}
@@ -1,7 +1,15 @@
 package service
 
 import (
+	"encoding/json"
 	"time"
+
+	"road-detector-go/internal/buildinfo"
+	"road-detector-go/internal/geo"
+	"road-detector-go/internal/imageseq"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/report"
+	"road-detector-go/internal/repository"
 )
 
 // Coordinates представляет географические координаты
@@ -18,6 +26,25 @@ type SegmentInfo struct {
 	HasData            bool        `json:"has_data"`
 	StartCoordinate    Coordinates `json:"start_coordinate"`
 	EndCoordinate      Coordinates `json:"end_coordinate"`
+	RoadName           string      `json:"road_name,omitempty"`
+	// Координаты, привязанные к дорожному графу OSM
+	MatchedStartCoordinate *Coordinates `json:"matched_start_coordinate,omitempty"`
+	MatchedEndCoordinate   *Coordinates `json:"matched_end_coordinate,omitempty"`
+	// Дефекты покрытия сегмента и их общее число
+	Defects      []model.SegmentDefect `json:"defects,omitempty"`
+	DefectsCount int                   `json:"defects_count"`
+	// ClassCoverage покрытие по типам разметки, если анализатор их различает
+	ClassCoverage map[string]float64 `json:"class_coverage,omitempty"`
+	// Уверенность модели, если анализатор ее сообщает, и признак
+	// ненадежного результата
+	AverageConfidence *float64 `json:"average_confidence,omitempty"`
+	MinConfidence     *float64 `json:"min_confidence,omitempty"`
+	LowConfidence     bool     `json:"low_confidence"`
+	// QualityScore индекс качества сегмента от 0 до 100
+	QualityScore *float64 `json:"quality_score,omitempty"`
+	// Полоса покрытия сегмента с данными и ее цвет на карте
+	CoverageBand  string `json:"coverage_band,omitempty"`
+	CoverageColor string `json:"coverage_color,omitempty"`
 }
 
 // OverallStats общая статистика анализа
@@ -28,6 +55,18 @@ type OverallStats struct {
 	TotalSegments       int     `json:"total_segments"`
 	SegmentsWithData    int     `json:"segments_with_data"`
 	AverageCoverage     float64 `json:"average_coverage"`
+	TotalDefects        int     `json:"total_defects"`
+	SegmentsWithDefects int     `json:"segments_with_defects"`
+	// ClassCoverage среднее покрытие по типам разметки
+	ClassCoverage map[string]float64 `json:"class_coverage,omitempty"`
+	// AverageConfidence средняя уверенность модели по сегментам с данными
+	AverageConfidence     *float64 `json:"average_confidence,omitempty"`
+	LowConfidenceSegments int      `json:"low_confidence_segments"`
+	// QualityScore индекс качества маршрута от 0 до 100
+	QualityScore *float64 `json:"quality_score,omitempty"`
+	// Полоса среднего покрытия и ее цвет на карте
+	CoverageBand  string `json:"coverage_band,omitempty"`
+	CoverageColor string `json:"coverage_color,omitempty"`
 }
 
 // AnalysisResult результат анализа дороги
@@ -37,20 +76,105 @@ type AnalysisResult struct {
 	SegmentLength float64       `json:"segment_length"`
 	Segments      []SegmentInfo `json:"segments"`
 	OverallStats  OverallStats  `json:"overall_stats"`
+	RoadName      string        `json:"road_name,omitempty"`
+	// MatchedGeometry трек по дорожному графу OSM, если выполнялась привязка
+	MatchedGeometry []Coordinates `json:"matched_geometry,omitempty"`
+
+	// RouteID ID маршрута анализа
+	RouteID string `json:"route_id,omitempty"`
+	// Saved маршрут сохранен в базе данных
+	Saved bool `json:"saved"`
+	// UnsavedResultID ID результата для повторного сохранения, если
+	// маршрут не сохранен в базе данных
+	UnsavedResultID string `json:"unsaved_result_id,omitempty"`
+	// Links ссылки на сохраненный маршрут и видео анализа
+	Links *AnalysisLinks `json:"links,omitempty"`
+}
+
+// AnalysisLinks ссылки на маршрут и видео анализа относительно адреса
+// сервера. Отсутствуют, если маршрут или видео не сохранены.
+type AnalysisLinks struct {
+	Route          string `json:"route,omitempty"`
+	Video          string `json:"video,omitempty"`
+	AnnotatedVideo string `json:"annotated_video,omitempty"`
 }
 
 // RouteResponse ответ с информацией о маршруте
 type RouteResponse struct {
 	ID            string        `json:"id"`
 	Name          string        `json:"name"`
+	Description   string        `json:"description,omitempty"`
+	RoadName      string        `json:"road_name,omitempty"`
 	StartPoint    Coordinates   `json:"start_point"`
 	EndPoint      Coordinates   `json:"end_point"`
 	SegmentLength float64       `json:"segment_length"`
-	Segments      []SegmentInfo `json:"segments"`
+	Segments      []SegmentInfo `json:"segments,omitempty"`
 	OverallStats  OverallStats  `json:"overall_stats"`
 	CreatedAt     time.Time     `json:"created_at"`
-	VideoFilename string        `json:"video_filename,omitempty"`
-	VideoPath     string        `json:"video_path,omitempty"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	DeletedAt     *time.Time    `json:"deleted_at,omitempty"`
+	// ArchivedAt время переноса в архив
+	ArchivedAt    *time.Time `json:"archived_at,omitempty"`
+	VideoFilename string     `json:"video_filename,omitempty"`
+	VideoPath     string     `json:"video_path,omitempty"`
+	// PlayableVideoPath видео для браузеров: исходное или его копия в H.264 MP4
+	PlayableVideoPath string `json:"playable_video_path,omitempty"`
+	// StorageBytes размер видео, его копии для браузеров и аннотированного видео
+	StorageBytes *int64 `json:"storage_bytes,omitempty"`
+	// MatchedGeometry трек по дорожному графу OSM, если выполнялась привязка
+	MatchedGeometry []Coordinates     `json:"matched_geometry,omitempty"`
+	CustomFields    map[string]string `json:"custom_fields,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	// APIKeyID ключ API, с которым был создан маршрут
+	APIKeyID *uint `json:"api_key_id,omitempty"`
+	// OwnerID пользователь, которому принадлежит маршрут
+	OwnerID *uint `json:"owner_id,omitempty"`
+	// OrganizationID организация, которой принадлежит маршрут
+	OrganizationID *uint `json:"organization_id,omitempty"`
+	// AnalysisParams параметры анализа, переданные Python сервису
+	AnalysisParams *model.AnalysisParams `json:"analysis_params,omitempty"`
+}
+
+// RouteMetadata пользовательские данные маршрута, передаваемые при анализе.
+// Пустое название заменяется сгенерированным.
+type RouteMetadata struct {
+	Name        string
+	Description string
+	Tags        []string
+	// APIKeyID ключ API, с которым создается маршрут
+	APIKeyID *uint
+	// OwnerID пользователь, которому будет принадлежать маршрут
+	OwnerID *uint
+	// OrganizationID организация, которой будет принадлежать маршрут
+	OrganizationID *uint
+
+	// AnalysisParams параметры анализа, передаваемые Python сервису и
+	// сохраняемые с маршрутом
+	AnalysisParams model.AnalysisParams
+	// Timeout ожидание ответа анализатора, заданное в запросе, 0 — по
+	// размеру видео. Не сохраняется с маршрутом.
+	Timeout time.Duration
+	// Overwrite заменить анализ существующего маршрута с тем же ID
+	Overwrite bool
+	// Scope маршруты, которые разрешено перезаписать
+	Scope repository.RouteScope
+
+	// images снимки, из которых собирается видео анализа, nil —
+	// анализируется переданное видео
+	images []imageseq.Image
+	// selfTest анализ самопроверки, выполняется и в режиме обслуживания
+	selfTest bool
+	// queued анализ взят воркером из очереди и выполняется здесь же
+	queued bool
+}
+
+// UpdateRouteRequest частичное обновление метаданных маршрута.
+// Отсутствующие поля не изменяются.
+type UpdateRouteRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	// CustomFields объединяются с сохраненными, значение null удаляет поле
+	CustomFields map[string]*string `json:"custom_fields"`
 }
 
 // SaveRouteRequest запрос на сохранение маршрута
@@ -66,10 +190,43 @@ type GetSegmentsByAreaRequest struct {
 	SouthWest Coordinates `json:"south_west"`
 }
 
-// GetSegmentsByAreaResponse ответ со списком сегментов в области
+// GetSegmentsByAreaResponse ответ со страницей маршрутов в области
 type GetSegmentsByAreaResponse struct {
 	Routes []RouteResponse `json:"routes"`
-	Total  int             `json:"total"`
+	Total  int64           `json:"total"`
+	Page   int             `json:"page"`
+	Size   int             `json:"size"`
+}
+
+// NearbyRoute маршрут с расстоянием до точки запроса
+type NearbyRoute struct {
+	RouteResponse
+	DistanceMeters float64 `json:"distance_meters"`
+}
+
+// GetRoutesNearResponse ответ со списком маршрутов рядом с точкой
+type GetRoutesNearResponse struct {
+	Center  Coordinates   `json:"center"`
+	RadiusM float64       `json:"radius_m"`
+	Routes  []NearbyRoute `json:"routes"`
+	Total   int           `json:"total"`
+}
+
+// PolygonSearchResponse ответ поиска маршрутов по полигону.
+// У каждого маршрута возвращаются только сегменты, пересекающие полигон.
+type PolygonSearchResponse struct {
+	Routes        []RouteResponse `json:"routes"`
+	Total         int             `json:"total"`
+	TotalSegments int             `json:"total_segments"`
+}
+
+// ListSegmentsResponse ответ со страницей сегментов маршрута
+type ListSegmentsResponse struct {
+	RouteID  string        `json:"route_id"`
+	Segments []SegmentInfo `json:"segments"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	Size     int           `json:"size"`
 }
 
 // ListRoutesResponse ответ со списком маршрутов
@@ -78,4 +235,1093 @@ type ListRoutesResponse struct {
 	Total  int64           `json:"total"`
 	Page   int             `json:"page"`
 	Size   int             `json:"size"`
+	// NextCursor курсор следующей страницы для параметра cursor
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// SearchRoutesResponse ответ полнотекстового поиска маршрутов
+type SearchRoutesResponse struct {
+	Query  string          `json:"query"`
+	Routes []RouteResponse `json:"routes"`
+	Total  int64           `json:"total"`
+	Page   int             `json:"page"`
+	Size   int             `json:"size"`
+}
+
+// TagInfo метка маршрутов
+type TagInfo struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	RouteCount int64     `json:"route_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ListTagsResponse ответ со списком меток
+type ListTagsResponse struct {
+	Tags  []TagInfo `json:"tags"`
+	Total int       `json:"total"`
+}
+
+// RouteTagsResponse метки маршрута после изменения
+type RouteTagsResponse struct {
+	RouteID string   `json:"route_id"`
+	Tags    []string `json:"tags"`
+}
+
+// SplitRouteResponse результат разделения маршрута
+type SplitRouteResponse struct {
+	Original RouteResponse `json:"original"`
+	Created  RouteResponse `json:"created"`
+}
+
+// BulkRouteFilter фильтр маршрутов массовой операции, поля как у списка маршрутов
+type BulkRouteFilter struct {
+	Name          string     `json:"name"`
+	Road          string     `json:"road"`
+	Tags          []string   `json:"tags"`
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+	MinCoverage   *float64   `json:"min_coverage"`
+	MaxCoverage   *float64   `json:"max_coverage"`
+	MinQuality    *float64   `json:"min_quality"`
+	MaxQuality    *float64   `json:"max_quality"`
+	HasDefects    *bool      `json:"has_defects"`
+	DefectType    string     `json:"defect_type"`
+	MarkingType   string     `json:"marking_type"`
+	Deleted       bool       `json:"deleted"`
+	Archived      bool       `json:"archived"`
+}
+
+// BulkRouteRequest запрос массовой операции над маршрутами.
+// Маршруты задаются списком route_ids или фильтром.
+type BulkRouteRequest struct {
+	Operation string           `json:"operation"`
+	RouteIDs  []string         `json:"route_ids"`
+	Filter    *BulkRouteFilter `json:"filter"`
+	// Tags метки для операции tag
+	Tags []string `json:"tags"`
+	// Purge удаляет маршруты безвозвратно в операции delete
+	Purge bool `json:"purge"`
+	// Scope ограничивает операцию маршрутами организации или пользователя,
+	// маршруты вне области отмечаются как ненайденные
+	Scope repository.RouteScope `json:"-"`
+}
+
+// BulkItemResult результат массовой операции для одного маршрута
+type BulkItemResult struct {
+	RouteID string `json:"route_id"`
+	Status  string `json:"status"` // ok или not_found
+}
+
+// BulkRouteResponse отчет о массовой операции
+type BulkRouteResponse struct {
+	Operation string           `json:"operation"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+	// Routes маршруты с сегментами для операции export
+	Routes []RouteResponse `json:"routes,omitempty"`
+}
+
+// HeatmapCell ячейка тепловой карты покрытия
+type HeatmapCell struct {
+	Row             int         `json:"row"`
+	Col             int         `json:"col"`
+	Center          Coordinates `json:"center"`
+	SouthWest       Coordinates `json:"south_west"`
+	NorthEast       Coordinates `json:"north_east"`
+	AverageCoverage float64     `json:"average_coverage"`
+	SegmentCount    int64       `json:"segment_count"`
+}
+
+// HeatmapResponse ответ с тепловой картой покрытия по области
+type HeatmapResponse struct {
+	NorthEast   Coordinates   `json:"north_east"`
+	SouthWest   Coordinates   `json:"south_west"`
+	CellSizeM   float64       `json:"cell_size_m"`
+	CellSizeLat float64       `json:"cell_size_lat"`
+	CellSizeLon float64       `json:"cell_size_lon"`
+	Rows        int           `json:"rows"`
+	Cols        int           `json:"cols"`
+	Cells       []HeatmapCell `json:"cells"`
+}
+
+// WorstSegment сегмент в списке участков с наименьшим покрытием
+type WorstSegment struct {
+	// Rank место сегмента в списке, начиная с 1
+	Rank               int         `json:"rank"`
+	RouteID            string      `json:"route_id"`
+	RouteName          string      `json:"route_name,omitempty"`
+	RoadName           string      `json:"road_name,omitempty"`
+	SegmentID          int         `json:"segment_id"`
+	StartCoordinate    Coordinates `json:"start_coordinate"`
+	EndCoordinate      Coordinates `json:"end_coordinate"`
+	CoveragePercentage float64     `json:"coverage_percentage"`
+	CoverageBand       string      `json:"coverage_band,omitempty"`
+	CoverageColor      string      `json:"coverage_color,omitempty"`
+	DefectsCount       int         `json:"defects_count"`
+	QualityScore       *float64    `json:"quality_score,omitempty"`
+	// AnalyzedAt время анализа маршрута сегмента
+	AnalyzedAt time.Time `json:"analyzed_at"`
+}
+
+// WorstSegmentsResponse ответ со списком участков с наименьшим покрытием
+type WorstSegmentsResponse struct {
+	// NorthEast и SouthWest область списка, nil — все доступные маршруты
+	NorthEast *Coordinates `json:"north_east,omitempty"`
+	SouthWest *Coordinates `json:"south_west,omitempty"`
+	// CorridorM расстояние, на котором более поздний анализ заменяет сегмент
+	CorridorM float64        `json:"corridor_m"`
+	Limit     int            `json:"limit"`
+	Segments  []WorstSegment `json:"segments"`
+}
+
+// CoverageHistogramQuery параметры гистограммы покрытия
+type CoverageHistogramQuery struct {
+	// Area область сегментов, nil — все доступные маршруты
+	Area *geo.BoundingBox
+	// From и To период [From, To) времени анализа, nil — без ограничения
+	From, To *time.Time
+	// BucketSize ширина интервала в процентах покрытия, делитель 100
+	BucketSize int
+}
+
+// CoverageHistogramBucket интервал гистограммы покрытия [Min, Max).
+// Последний интервал включает 100%.
+type CoverageHistogramBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int64   `json:"count"`
+	// Share доля сегментов интервала от всех сегментов гистограммы, от 0 до 1
+	Share         float64 `json:"share"`
+	CoverageBand  string  `json:"coverage_band,omitempty"`
+	CoverageColor string  `json:"coverage_color,omitempty"`
+}
+
+// CoverageHistogramResponse распределение сегментов с данными по покрытию
+type CoverageHistogramResponse struct {
+	NorthEast     *Coordinates              `json:"north_east,omitempty"`
+	SouthWest     *Coordinates              `json:"south_west,omitempty"`
+	From          *time.Time                `json:"from,omitempty"`
+	To            *time.Time                `json:"to,omitempty"`
+	BucketSize    int                       `json:"bucket_size"`
+	TotalSegments int64                     `json:"total_segments"`
+	Buckets       []CoverageHistogramBucket `json:"buckets"`
+}
+
+// PythonVersionStatus результат проверки совместимости с Python сервисом
+type PythonVersionStatus struct {
+	Reachable  bool                   `json:"reachable"`
+	Version    string                 `json:"version,omitempty"`
+	Compatible bool                   `json:"compatible"`
+	Supported  buildinfo.VersionRange `json:"supported"`
+	Error      string                 `json:"error,omitempty"`
+	// Contract формат API анализа, выбранный по версии
+	Contract string `json:"contract,omitempty"`
+	// Contracts форматы API анализа и версии, которые их используют
+	Contracts []buildinfo.PythonContract `json:"contracts"`
+}
+
+// VersionResponse ответ с версиями сервиса и матрицей совместимости
+type VersionResponse struct {
+	Service         buildinfo.Build     `json:"service"`
+	PythonService   PythonVersionStatus `json:"python_service"`
+	DBSchemaVersion int                 `json:"db_schema_version"`
+	APIVersions     []string            `json:"api_versions"`
+}
+
+// SelfTestStage результат одного этапа самопроверки
+type SelfTestStage struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"` // passed, failed или skipped
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// SelfTestReport отчет самопроверки конвейера анализа
+type SelfTestReport struct {
+	Passed     bool            `json:"passed"`
+	RouteID    string          `json:"route_id"`
+	StartedAt  time.Time       `json:"started_at"`
+	DurationMs float64         `json:"duration_ms"`
+	Stages     []SelfTestStage `json:"stages"`
+}
+
+// SegmentOverlap пара сегментов двух маршрутов, проходящих по одному участку
+type SegmentOverlap struct {
+	SegmentID               int     `json:"segment_id"`
+	OtherSegmentID          int     `json:"other_segment_id"`
+	DistanceMeters          float64 `json:"distance_meters"`
+	CoveragePercentage      float64 `json:"coverage_percentage"`
+	OtherCoveragePercentage float64 `json:"other_coverage_percentage"`
+	// CoverageDelta разница покрытия: текущий маршрут минус другой
+	CoverageDelta float64 `json:"coverage_delta"`
+}
+
+// RouteOverlap маршрут, существенно пересекающийся с заданным
+type RouteOverlap struct {
+	RouteID   string    `json:"route_id"`
+	Name      string    `json:"name"`
+	RoadName  string    `json:"road_name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// OverlapRatio доля сегментов заданного маршрута, у которых есть пара в этом маршруте
+	OverlapRatio    float64          `json:"overlap_ratio"`
+	OverlapSegments int              `json:"overlap_segments"`
+	SegmentPairs    []SegmentOverlap `json:"segment_pairs"`
+}
+
+// RouteOverlapResponse ответ с маршрутами, пересекающимися с заданным
+type RouteOverlapResponse struct {
+	RouteID        string         `json:"route_id"`
+	DistanceMeters float64        `json:"distance_meters"`
+	MinOverlap     float64        `json:"min_overlap"`
+	Overlaps       []RouteOverlap `json:"overlaps"`
+	Total          int            `json:"total"`
+}
+
+// RouteDiffSegment сегмент маршрута в сравнении с другим анализом того же коридора.
+// Поля пары пусты, если у сегмента нет пары.
+type RouteDiffSegment struct {
+	SegmentID                 int      `json:"segment_id"`
+	AgainstSegmentID          *int     `json:"against_segment_id,omitempty"`
+	DistanceMeters            *float64 `json:"distance_meters,omitempty"`
+	LengthMeters              float64  `json:"length_meters"`
+	CoveragePercentage        float64  `json:"coverage_percentage"`
+	AgainstCoveragePercentage *float64 `json:"against_coverage_percentage,omitempty"`
+	// CoverageDelta разница покрытия: маршрут минус сравниваемый, только
+	// если данные есть у обоих сегментов
+	CoverageDelta *float64 `json:"coverage_delta,omitempty"`
+	Status        string   `json:"status"`
+}
+
+// RouteDiffSection подряд идущие сегменты маршрута, покрытие которых ухудшилось
+// или улучшилось
+type RouteDiffSection struct {
+	Status         string  `json:"status"`
+	StartSegmentID int     `json:"start_segment_id"`
+	EndSegmentID   int     `json:"end_segment_id"`
+	SegmentsCount  int     `json:"segments_count"`
+	LengthMeters   float64 `json:"length_meters"`
+	AverageDelta   float64 `json:"average_delta"`
+}
+
+// RouteDiffSummary сводка сравнения двух анализов
+type RouteDiffSummary struct {
+	MatchedSegments   int `json:"matched_segments"`
+	UnmatchedSegments int `json:"unmatched_segments"`
+	// ComparedSegments совпавшие сегменты с данными в обоих анализах
+	ComparedSegments     int     `json:"compared_segments"`
+	DegradedSegments     int     `json:"degraded_segments"`
+	ImprovedSegments     int     `json:"improved_segments"`
+	UnchangedSegments    int     `json:"unchanged_segments"`
+	DegradedLengthMeters float64 `json:"degraded_length_meters"`
+	ImprovedLengthMeters float64 `json:"improved_length_meters"`
+	AverageDelta         float64 `json:"average_delta"`
+}
+
+// RouteDiffResponse ответ со сравнением маршрута с другим анализом того же коридора
+type RouteDiffResponse struct {
+	RouteID          string             `json:"route_id"`
+	CreatedAt        time.Time          `json:"created_at"`
+	AgainstRouteID   string             `json:"against_route_id"`
+	AgainstName      string             `json:"against_name"`
+	AgainstCreatedAt time.Time          `json:"against_created_at"`
+	DistanceMeters   float64            `json:"distance_meters"`
+	Threshold        float64            `json:"threshold"`
+	Summary          RouteDiffSummary   `json:"summary"`
+	Sections         []RouteDiffSection `json:"sections"`
+	Segments         []RouteDiffSegment `json:"segments"`
+}
+
+// RoadSummary краткая информация о дороге
+type RoadSummary struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	SegmentCount    int       `json:"segment_count"`
+	RouteCount      int       `json:"route_count"`
+	AverageCoverage float64   `json:"average_coverage"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	// Полоса среднего покрытия дороги и ее цвет на карте
+	CoverageBand  string `json:"coverage_band,omitempty"`
+	CoverageColor string `json:"coverage_color,omitempty"`
+}
+
+// RoadSegmentInfo участок дороги с покрытием, агрегированным по проездам
+type RoadSegmentInfo struct {
+	Position         int         `json:"position"`
+	StartCoordinate  Coordinates `json:"start_coordinate"`
+	EndCoordinate    Coordinates `json:"end_coordinate"`
+	ObservationCount int         `json:"observation_count"`
+	LatestCoverage   float64     `json:"latest_coverage"`
+	WorstCoverage    float64     `json:"worst_coverage"`
+	AverageCoverage  float64     `json:"average_coverage"`
+	LastObservedAt   *time.Time  `json:"last_observed_at,omitempty"`
+	// Полоса последнего покрытия участка и ее цвет на карте
+	CoverageBand  string `json:"coverage_band,omitempty"`
+	CoverageColor string `json:"coverage_color,omitempty"`
+}
+
+// RoadResponse дорога с участками и маршрутами, из которых она собрана
+type RoadResponse struct {
+	RoadSummary
+	RouteIDs []string          `json:"route_ids"`
+	Segments []RoadSegmentInfo `json:"segments"`
+}
+
+// ListRoadsResponse ответ со списком дорог
+type ListRoadsResponse struct {
+	Roads []RoadSummary `json:"roads"`
+	Total int64         `json:"total"`
+	Page  int           `json:"page"`
+	Size  int           `json:"size"`
+}
+
+// RoadTrendQuery период и сглаживание ряда покрытия дороги
+type RoadTrendQuery struct {
+	From, To  *time.Time
+	Smoothing string
+	// Window число точек скользящего среднего
+	Window int
+	// Alpha вес новой точки в экспоненциальном сглаживании, от 0 до 1
+	Alpha float64
+}
+
+// RoadTrendPoint покрытие дороги по одному проезду
+type RoadTrendPoint struct {
+	RouteID    string    `json:"route_id"`
+	ObservedAt time.Time `json:"observed_at"`
+	// SegmentsObserved число участков дороги, покрытых проездом
+	SegmentsObserved int     `json:"segments_observed"`
+	AverageCoverage  float64 `json:"average_coverage"`
+	WorstCoverage    float64 `json:"worst_coverage"`
+	// SmoothedCoverage сглаженное среднее покрытие, пусто без сглаживания
+	SmoothedCoverage *float64 `json:"smoothed_coverage,omitempty"`
+}
+
+// RoadTrendResponse ряд покрытия дороги во времени, по точке на проезд
+type RoadTrendResponse struct {
+	RoadID    string           `json:"road_id"`
+	Name      string           `json:"name"`
+	Smoothing string           `json:"smoothing"`
+	Window    int              `json:"window,omitempty"`
+	Alpha     float64          `json:"alpha,omitempty"`
+	Points    []RoadTrendPoint `json:"points"`
+	Total     int              `json:"total"`
+	// ChangePerMonth изменение среднего покрытия в процентных пунктах за 30
+	// дней по линейной регрессии, пусто, если проездов меньше двух
+	ChangePerMonth *float64 `json:"change_per_month,omitempty"`
+}
+
+// RoadRebuildResponse результат полного пересчета дорог
+type RoadRebuildResponse struct {
+	Routes int   `json:"routes"`
+	Roads  int64 `json:"roads"`
+}
+
+// APIKeyInfo ключ API без его значения
+type APIKeyInfo struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Admin      bool       `json:"admin"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// OrganizationID организация ключа, пусто — ключ видит все организации
+	OrganizationID *uint `json:"organization_id,omitempty"`
+}
+
+// CreateAPIKeyRequest запрос создания ключа API
+type CreateAPIKeyRequest struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+	// OrganizationID ограничивает ключ данными организации
+	OrganizationID *uint `json:"organization_id"`
+}
+
+// CreatedAPIKeyResponse созданный ключ API. Значение ключа возвращается только один раз.
+type CreatedAPIKeyResponse struct {
+	APIKeyInfo
+	Key string `json:"key"`
+}
+
+// ListAPIKeysResponse ответ со списком ключей API
+type ListAPIKeysResponse struct {
+	Keys  []APIKeyInfo `json:"keys"`
+	Total int          `json:"total"`
+}
+
+// RegisterUserRequest запрос регистрации пользователя
+type RegisterUserRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginRequest запрос входа пользователя
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// UserInfo пользователь без хэша пароля
+type UserInfo struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TokenResponse ответ с токеном доступа
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// ExpiresIn срок действия токена в секундах
+	ExpiresIn int64    `json:"expires_in"`
+	User      UserInfo `json:"user"`
+}
+
+// UserIdentity пользователь, подтвержденный токеном
+type UserIdentity struct {
+	ID    uint   `json:"id"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// OrganizationInfo организация в ответе API
+type OrganizationInfo struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	// Role роль текущего пользователя в организации
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateOrganizationRequest запрос создания организации
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// ListOrganizationsResponse ответ со списком организаций
+type ListOrganizationsResponse struct {
+	Organizations []OrganizationInfo `json:"organizations"`
+	Total         int                `json:"total"`
+}
+
+// OrganizationMemberInfo участник организации
+type OrganizationMemberInfo struct {
+	UserID    uint      `json:"user_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddMemberRequest запрос добавления пользователя в организацию.
+// Для существующего участника меняется роль.
+type AddMemberRequest struct {
+	Email string `json:"email"`
+	// Role member или admin, по умолчанию member
+	Role string `json:"role"`
+}
+
+// ListMembersResponse ответ со списком участников организации
+type ListMembersResponse struct {
+	Members []OrganizationMemberInfo `json:"members"`
+	Total   int                      `json:"total"`
+}
+
+// Tenant организация, от имени которой выполняется запрос
+type Tenant struct {
+	OrganizationID uint `json:"organization_id"`
+	// Role роль в организации: member или admin
+	Role string `json:"role"`
+}
+
+// UsageResponse использование сервиса в текущем месяце
+type UsageResponse struct {
+	// Subject чье использование учитывается: org:<id>, user:<id>, key:<id> или anonymous
+	Subject string `json:"subject"`
+	// Period месяц в формате 2006-01 (UTC)
+	Period          string         `json:"period"`
+	PeriodEnd       time.Time      `json:"period_end"`
+	Uploads         QuotaUsage     `json:"uploads"`
+	AnalysisMinutes QuotaUsage     `json:"analysis_minutes"`
+	RateLimit       *RateLimitInfo `json:"rate_limit,omitempty"`
+}
+
+// StorageUsageResponse место, занятое видео и аннотированными видео
+// маршрутов, в том числе удаленных, но еще не удаленных окончательно
+type StorageUsageResponse struct {
+	// Subject чье использование учитывается: org:<id>, user:<id>, key:<id> или anonymous
+	Subject string `json:"subject"`
+	Routes  int64  `json:"routes"`
+	// UnmeasuredRoutes маршруты, размер которых еще не измерен и не учтен в Bytes
+	UnmeasuredRoutes int64      `json:"unmeasured_routes"`
+	Bytes            QuotaUsage `json:"bytes"`
+}
+
+// QuotaUsage использование ресурса. Limit и Remaining отсутствуют,
+// если квота не задана.
+type QuotaUsage struct {
+	Used      float64  `json:"used"`
+	Limit     *float64 `json:"limit,omitempty"`
+	Remaining *float64 `json:"remaining,omitempty"`
+}
+
+// RateLimitInfo ограничение частоты запросов
+type RateLimitInfo struct {
+	RPS   float64 `json:"rps"`
+	Burst int     `json:"burst"`
+}
+
+// AuditEntryInfo запись журнала аудита в ответе API
+type AuditEntryInfo struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Action    string    `json:"action"`
+	// ActorType кто выполнил операцию: user, api_key или anonymous
+	ActorType      string `json:"actor_type"`
+	ActorID        *uint  `json:"actor_id,omitempty"`
+	ActorName      string `json:"actor_name,omitempty"`
+	OrganizationID *uint  `json:"organization_id,omitempty"`
+	IP             string `json:"ip"`
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	Status         int    `json:"status"`
+	RouteID        string `json:"route_id,omitempty"`
+	Target         string `json:"target,omitempty"`
+	Summary        string `json:"summary,omitempty"`
+}
+
+// ListAuditResponse ответ со страницей журнала аудита
+type ListAuditResponse struct {
+	Entries []AuditEntryInfo `json:"entries"`
+	Total   int64            `json:"total"`
+	Page    int              `json:"page"`
+	Size    int              `json:"size"`
+}
+
+// WebhookInfo подписка на события в ответе API
+type WebhookInfo struct {
+	ID             uint      `json:"id"`
+	OrganizationID *uint     `json:"organization_id,omitempty"`
+	URL            string    `json:"url"`
+	Events         []string  `json:"events"`
+	Format         string    `json:"format"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CreatedWebhookResponse созданная подписка вместе с ключом подписи,
+// который больше не возвращается
+type CreatedWebhookResponse struct {
+	WebhookInfo
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookRequest запрос создания подписки. Пустой список событий
+// подписывает на все события формата, пустой формат — json.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Format string   `json:"format"`
+	Active *bool    `json:"active"`
+}
+
+// UpdateWebhookRequest запрос изменения подписки, отсутствующие поля не меняются
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Format *string  `json:"format"`
+	Active *bool    `json:"active"`
+}
+
+// ListWebhooksResponse ответ со списком подписок
+type ListWebhooksResponse struct {
+	Webhooks []WebhookInfo `json:"webhooks"`
+	Total    int           `json:"total"`
+}
+
+// WebhookDeliveryInfo доставка события в ответе API
+type WebhookDeliveryInfo struct {
+	ID       uint            `json:"id"`
+	EventID  string          `json:"event_id"`
+	Event    string          `json:"event"`
+	Payload  json.RawMessage `json:"payload"`
+	Status   string          `json:"status"`
+	Attempts int             `json:"attempts"`
+	// ResponseStatus HTTP статус ответа подписчика на последнюю попытку
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ListWebhookDeliveriesResponse ответ со списком последних доставок подписки
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryInfo `json:"deliveries"`
+	Total      int                   `json:"total"`
+}
+
+// WebhookPayload тело запроса, отправляемого подписчику
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// AnalysisEventData данные событий analysis.completed и analysis.failed
+type AnalysisEventData struct {
+	RouteID        string `json:"route_id"`
+	OrganizationID *uint  `json:"organization_id,omitempty"`
+	Name           string `json:"name,omitempty"`
+	VideoFilename  string `json:"video_filename,omitempty"`
+	// Статистика и RoadName заполняются для завершенного анализа
+	TotalSegments       int      `json:"total_segments,omitempty"`
+	AverageCoverage     float64  `json:"average_coverage,omitempty"`
+	RoadName            string   `json:"road_name,omitempty"`
+	SegmentsWithData    int      `json:"segments_with_data,omitempty"`
+	TotalDistanceMeters float64  `json:"total_distance_meters,omitempty"`
+	TotalDefects        int      `json:"total_defects,omitempty"`
+	QualityScore        *float64 `json:"quality_score,omitempty"`
+	CoverageBand        string   `json:"coverage_band,omitempty"`
+	// Reason и Error заполняются для неудачного анализа
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AlertRuleInfo правило оповещения в ответе API
+type AlertRuleInfo struct {
+	ID             uint   `json:"id"`
+	OrganizationID *uint  `json:"organization_id,omitempty"`
+	Name           string `json:"name"`
+	// Area GeoJSON полигон области правила
+	Area              json.RawMessage `json:"area,omitempty"`
+	MaxCoverage       *float64        `json:"max_coverage,omitempty"`
+	MinDefectSeverity string          `json:"min_defect_severity,omitempty"`
+	Emails            []string        `json:"emails"`
+	TelegramChats     []string        `json:"telegram_chats"`
+	Active            bool            `json:"active"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// CreateAlertRuleRequest запрос создания правила оповещения. Нужно задать
+// хотя бы одно условие: max_coverage или min_defect_severity.
+type CreateAlertRuleRequest struct {
+	Name              string          `json:"name"`
+	Area              json.RawMessage `json:"area"`
+	MaxCoverage       *float64        `json:"max_coverage"`
+	MinDefectSeverity string          `json:"min_defect_severity"`
+	Emails            []string        `json:"emails"`
+	TelegramChats     []string        `json:"telegram_chats"`
+	Active            *bool           `json:"active"`
+}
+
+// UpdateAlertRuleRequest запрос изменения правила, отсутствующие поля не
+// меняются. area: null снимает ограничение области, max_coverage: 0 и
+// пустой min_defect_severity отключают условие.
+type UpdateAlertRuleRequest struct {
+	Name              *string         `json:"name"`
+	Area              json.RawMessage `json:"area"`
+	MaxCoverage       *float64        `json:"max_coverage"`
+	MinDefectSeverity *string         `json:"min_defect_severity"`
+	Emails            []string        `json:"emails"`
+	TelegramChats     []string        `json:"telegram_chats"`
+	Active            *bool           `json:"active"`
+}
+
+// ListAlertRulesResponse ответ со списком правил оповещений
+type ListAlertRulesResponse struct {
+	Rules []AlertRuleInfo `json:"rules"`
+	Total int             `json:"total"`
+}
+
+// ListAlertsResponse ответ со страницей оповещений
+type ListAlertsResponse struct {
+	Alerts []model.Alert `json:"alerts"`
+	Total  int64         `json:"total"`
+	Page   int           `json:"page"`
+	Size   int           `json:"size"`
+}
+
+// AlertEventData данные события alert.created
+type AlertEventData struct {
+	AlertID        uint                 `json:"alert_id"`
+	RuleID         uint                 `json:"rule_id"`
+	RuleName       string               `json:"rule_name"`
+	RouteID        string               `json:"route_id"`
+	OrganizationID *uint                `json:"organization_id,omitempty"`
+	SegmentsCount  int                  `json:"segments_count"`
+	Segments       []model.AlertSegment `json:"segments"`
+}
+
+// ReportScheduleInfo расписание отчетов в ответе API
+type ReportScheduleInfo struct {
+	ID             uint       `json:"id"`
+	OrganizationID *uint      `json:"organization_id,omitempty"`
+	Name           string     `json:"name"`
+	Period         string     `json:"period"`
+	Format         string     `json:"format"`
+	Emails         []string   `json:"emails"`
+	Webhook        bool       `json:"webhook"`
+	Active         bool       `json:"active"`
+	NextRunAt      time.Time  `json:"next_run_at"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CreateReportScheduleRequest запрос создания расписания отчетов
+type CreateReportScheduleRequest struct {
+	Name   string `json:"name"`
+	Period string `json:"period"`
+	// Format формат вложения письма, по умолчанию html
+	Format  string   `json:"format"`
+	Emails  []string `json:"emails"`
+	Webhook bool     `json:"webhook"`
+	Active  *bool    `json:"active"`
+}
+
+// UpdateReportScheduleRequest запрос изменения расписания, отсутствующие
+// поля не меняются. Новый period переносит следующий отчет на конец
+// текущего периода.
+type UpdateReportScheduleRequest struct {
+	Name    *string  `json:"name"`
+	Period  *string  `json:"period"`
+	Format  *string  `json:"format"`
+	Emails  []string `json:"emails"`
+	Webhook *bool    `json:"webhook"`
+	Active  *bool    `json:"active"`
+}
+
+// ListReportSchedulesResponse ответ со списком расписаний отчетов
+type ListReportSchedulesResponse struct {
+	Schedules []ReportScheduleInfo `json:"schedules"`
+	Total     int                  `json:"total"`
+}
+
+// ReportInfo отчет в ответе API. Data заполняется только для одного отчета.
+type ReportInfo struct {
+	ID             uint         `json:"id"`
+	ScheduleID     uint         `json:"schedule_id"`
+	OrganizationID *uint        `json:"organization_id,omitempty"`
+	Period         string       `json:"period"`
+	PeriodStart    time.Time    `json:"period_start"`
+	PeriodEnd      time.Time    `json:"period_end"`
+	CreatedAt      time.Time    `json:"created_at"`
+	Data           *report.Data `json:"data,omitempty"`
+}
+
+// ListReportsResponse ответ со страницей отчетов
+type ListReportsResponse struct {
+	Reports []ReportInfo `json:"reports"`
+	Total   int64        `json:"total"`
+	Page    int          `json:"page"`
+	Size    int          `json:"size"`
+}
+
+// NotificationPreferencesInfo настройки уведомлений пользователя в ответе API
+type NotificationPreferencesInfo struct {
+	// Email адрес, на который отправляются уведомления
+	Email string `json:"email"`
+	// EmailEnabled настроена ли отправка писем на сервере
+	EmailEnabled      bool       `json:"email_enabled"`
+	AnalysisCompleted bool       `json:"analysis_completed"`
+	Alerts            bool       `json:"alerts"`
+	Reports           bool       `json:"reports"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateNotificationPreferencesRequest частичное обновление настроек
+// уведомлений. Отсутствующие поля не изменяются.
+type UpdateNotificationPreferencesRequest struct {
+	AnalysisCompleted *bool `json:"analysis_completed"`
+	Alerts            *bool `json:"alerts"`
+	Reports           *bool `json:"reports"`
+}
+
+// ReportEventData данные события report.generated
+type ReportEventData struct {
+	ReportID       uint        `json:"report_id"`
+	ScheduleID     uint        `json:"schedule_id"`
+	OrganizationID *uint       `json:"organization_id,omitempty"`
+	Period         string      `json:"period"`
+	PeriodStart    time.Time   `json:"period_start"`
+	PeriodEnd      time.Time   `json:"period_end"`
+	Report         report.Data `json:"report"`
+}
+
+// BoundaryInfo административная граница в ответе API. Геометрия
+// возвращается только при запросе одной границы.
+type BoundaryInfo struct {
+	ID             uint            `json:"id"`
+	OrganizationID *uint           `json:"organization_id,omitempty"`
+	Name           string          `json:"name"`
+	Kind           string          `json:"kind,omitempty"`
+	NorthEast      Coordinates     `json:"north_east"`
+	SouthWest      Coordinates     `json:"south_west"`
+	Geometry       json.RawMessage `json:"geometry,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// CreateBoundariesRequest тело запроса загрузки границ. GeoJSON может быть
+// полигоном, Feature или FeatureCollection с полигонами. Название границы
+// берется из свойства NameProperty объекта, для единственного полигона
+// можно передать Name.
+type CreateBoundariesRequest struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// NameProperty свойство Feature с названием границы, по умолчанию name
+	NameProperty string          `json:"name_property"`
+	GeoJSON      json.RawMessage `json:"geojson"`
+}
+
+// ListBoundariesResponse ответ со списком границ
+type ListBoundariesResponse struct {
+	Boundaries []BoundaryInfo `json:"boundaries"`
+	Total      int            `json:"total"`
+}
+
+// AreaSegment сегмент с наименьшим покрытием внутри границы
+type AreaSegment struct {
+	RouteID            string  `json:"route_id"`
+	RouteName          string  `json:"route_name,omitempty"`
+	RoadName           string  `json:"road_name,omitempty"`
+	SegmentID          int     `json:"segment_id"`
+	CoveragePercentage float64 `json:"coverage_percentage"`
+}
+
+// AreaStatistics показатели сегментов маршрутов внутри одной границы
+type AreaStatistics struct {
+	BoundaryID       uint    `json:"boundary_id"`
+	Name             string  `json:"name"`
+	Kind             string  `json:"kind,omitempty"`
+	Routes           int64   `json:"routes"`
+	Segments         int64   `json:"segments"`
+	SegmentsWithData int64   `json:"segments_with_data"`
+	DistanceKm       float64 `json:"distance_km"`
+	// AverageCoverage среднее покрытие сегментов с данными, nil — таких нет
+	AverageCoverage *float64      `json:"average_coverage"`
+	CoverageBand    string        `json:"coverage_band,omitempty"`
+	CoverageColor   string        `json:"coverage_color,omitempty"`
+	WorstSegments   []AreaSegment `json:"worst_segments"`
+}
+
+// AreaStatisticsResponse ответ со статистикой по границам
+type AreaStatisticsResponse struct {
+	Areas []AreaStatistics `json:"areas"`
+	Total int              `json:"total"`
+}
+
+// ShareLinkInfo публичная ссылка на маршрут в ответе API. Токен
+// возвращается только при создании.
+type ShareLinkInfo struct {
+	ID      uint   `json:"id"`
+	RouteID string `json:"route_id"`
+	// Prefix начало токена, по которому ссылку можно узнать в списке
+	Prefix            string     `json:"prefix"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	Active            bool       `json:"active"`
+	CreatedAt         time.Time  `json:"created_at"`
+	CreatedByUserID   *uint      `json:"created_by_user_id,omitempty"`
+	CreatedByAPIKeyID *uint      `json:"created_by_api_key_id,omitempty"`
+}
+
+// CreatedShareLinkResponse созданная ссылка вместе с токеном и путем
+// публичного доступа к маршруту
+type CreatedShareLinkResponse struct {
+	ShareLinkInfo
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// CreateShareLinkRequest запрос создания ссылки. Без expires_at ссылка
+// действует до отзыва.
+type CreateShareLinkRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ShareLinkCreator пользователь или ключ API, создающий ссылку
+type ShareLinkCreator struct {
+	UserID   *uint
+	APIKeyID *uint
+}
+
+// ListShareLinksResponse ответ со списком ссылок маршрута
+type ListShareLinksResponse struct {
+	ShareLinks []ShareLinkInfo `json:"share_links"`
+	Total      int             `json:"total"`
+}
+
+// GeoJSONFeatureCollection выгрузка маршрута для карт в формате GeoJSON
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature объект GeoJSON с геометрией и свойствами
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONGeometry геометрия GeoJSON. Для LineString координаты — массив
+// точек [lon, lat].
+type GeoJSONGeometry struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+// HealthCheck результат проверки одной зависимости сервиса
+type HealthCheck struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // up или down
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	// Details версия зависимости, свободное место и другие сведения проверки
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthResponse состояние сервиса и его зависимостей. Один и тот же
+// ответ возвращают /readyz и /api/v1/health.
+type HealthResponse struct {
+	Status string `json:"status"` // healthy или unhealthy
+	// Краткое состояние основных зависимостей: up или down. Analyzer —
+	// Python сервис, локальный анализ или очередь анализов в роли api.
+	Database string `json:"database"`
+	Storage  string `json:"storage"`
+	Analyzer string `json:"analyzer"`
+	// PythonVersion и ModelLoaded из ответа /health Python сервиса, если он
+	// проверялся и ответил
+	PythonVersion string `json:"python_version,omitempty"`
+	ModelLoaded   *bool  `json:"model_loaded,omitempty"`
+
+	Checks          []HealthCheck   `json:"checks"`
+	CheckedAt       time.Time       `json:"checked_at"`
+	Service         buildinfo.Build `json:"service"`
+	DBSchemaVersion int             `json:"db_schema_version"`
+	UptimeSeconds   float64         `json:"uptime_seconds"`
+	// Maintenance режим обслуживания
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"`
+}
+
+// LatencyPercentiles перцентили длительности в миллисекундах
+type LatencyPercentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// AnalyzerStats анализы с момента запуска сервиса
+type AnalyzerStats struct {
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+	// Slow анализы, длившиеся дольше порога медленного анализа
+	Slow int64 `json:"slow"`
+	// Samples количество последних успешных анализов, по которым посчитаны перцентили
+	Samples   int                 `json:"samples"`
+	LatencyMs *LatencyPercentiles `json:"latency_ms,omitempty"`
+}
+
+// DailyAnalysisStats анализы за один день (UTC)
+type DailyAnalysisStats struct {
+	Date            string  `json:"date"` // 2006-01-02
+	Analyses        int64   `json:"analyses"`
+	AverageCoverage float64 `json:"average_coverage"`
+}
+
+// StorageStats место, занятое данными сервиса
+type StorageStats struct {
+	StaticBytes   int64 `json:"static_bytes"`
+	StaticFiles   int64 `json:"static_files"`
+	DatabaseBytes int64 `json:"database_bytes"`
+}
+
+// AdminStatsResponse сводка для панели администратора
+type AdminStatsResponse struct {
+	Routes   int64 `json:"routes"`
+	Segments int64 `json:"segments"`
+	// Daily анализы за последние дни, включая дни без анализов
+	Daily   []DailyAnalysisStats `json:"daily"`
+	Storage StorageStats         `json:"storage"`
+	// FailedJobs неудачные анализы с момента запуска и неудачные доставки вебхуков
+	FailedJobs  FailedJobStats `json:"failed_jobs"`
+	Analyzer    AnalyzerStats  `json:"analyzer"`
+	GeneratedAt time.Time      `json:"generated_at"`
+}
+
+// FailedJobStats количество неудачных фоновых задач
+type FailedJobStats struct {
+	Analyses          int64 `json:"analyses"`
+	WebhookDeliveries int64 `json:"webhook_deliveries"`
+}
+
+// AnalysisSnapshot итоги одного анализа маршрута
+type AnalysisSnapshot struct {
+	TotalFrames      int     `json:"total_frames"`
+	TotalSegments    int     `json:"total_segments"`
+	SegmentsWithData int     `json:"segments_with_data"`
+	AverageCoverage  float64 `json:"average_coverage"`
+}
+
+// SegmentDiff расхождение теневого анализа с основным в одном сегменте
+type SegmentDiff struct {
+	SegmentID       int     `json:"segment_id"`
+	PrimaryCoverage float64 `json:"primary_coverage"`
+	ShadowCoverage  float64 `json:"shadow_coverage"`
+	Delta           float64 `json:"delta"`
+	PrimaryHasData  bool    `json:"primary_has_data"`
+	ShadowHasData   bool    `json:"shadow_has_data"`
+}
+
+// AnalysisDiff расхождение теневого анализа с основным. Разница считается
+// как теневой минус основной, в процентных пунктах покрытия.
+type AnalysisDiff struct {
+	AverageCoverageDelta     float64 `json:"average_coverage_delta"`
+	MeanAbsoluteCoverageDiff float64 `json:"mean_absolute_coverage_diff"`
+	MaxAbsoluteCoverageDiff  float64 `json:"max_absolute_coverage_diff"`
+	// SegmentsCompared сегменты, которые есть в обоих анализах
+	SegmentsCompared int `json:"segments_compared"`
+	// SegmentsChanged сегменты, покрытие которых разошлось больше порога
+	// или наличие данных в которых не совпало
+	SegmentsChanged   int `json:"segments_changed"`
+	HasDataMismatches int `json:"has_data_mismatches"`
+	// SegmentCountDelta разница количества сегментов
+	SegmentCountDelta int `json:"segment_count_delta"`
+	// Segments измененные сегменты
+	Segments []SegmentDiff `json:"segments"`
+}
+
+// ShadowComparison теневой анализ маршрута и его расхождение с основным
+type ShadowComparison struct {
+	ID              uint      `json:"id"`
+	AnalyzerURL     string    `json:"analyzer_url"`
+	AnalyzerVersion string    `json:"analyzer_version,omitempty"`
+	Status          string    `json:"status"`
+	Error           string    `json:"error,omitempty"`
+	DurationMs      float64   `json:"duration_ms"`
+	CreatedAt       time.Time `json:"created_at"`
+	// Stats и Diff есть только у завершенного анализа
+	Stats *AnalysisSnapshot `json:"stats,omitempty"`
+	Diff  *AnalysisDiff     `json:"diff,omitempty"`
+}
+
+// AnalysisComparison сравнение основного анализа маршрута с теневыми
+type AnalysisComparison struct {
+	RouteID string `json:"route_id"`
+	// Threshold порог расхождения покрытия сегмента в процентных пунктах
+	Threshold float64            `json:"threshold"`
+	Primary   AnalysisSnapshot   `json:"primary"`
+	Shadows   []ShadowComparison `json:"shadows"`
 }
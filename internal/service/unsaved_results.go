@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"road-detector-go/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnsavedResultNotFound возвращается, если несохраненного результата с
+// указанным ID нет
+var ErrUnsavedResultNotFound = errors.New("unsaved result not found")
+
+const (
+	// unsavedResultFile результат анализа и данные маршрута
+	unsavedResultFile = "result.json"
+	// unsavedVideoFile видео анализа
+	unsavedVideoFile = "video"
+)
+
+// UnsavedResult результат анализа, который не удалось сохранить в базе
+// данных
+type UnsavedResult struct {
+	ID            string          `json:"id"`
+	RouteID       string          `json:"route_id"`
+	VideoFilename string          `json:"video_filename"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Error         string          `json:"error"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	Metadata      RouteMetadata   `json:"metadata"`
+	Result        *AnalysisResult `json:"result"`
+}
+
+// UnsavedResultSummary краткое описание несохраненного результата для
+// списка
+type UnsavedResultSummary struct {
+	ID              string    `json:"id"`
+	RouteID         string    `json:"route_id"`
+	VideoFilename   string    `json:"video_filename"`
+	VideoSizeBytes  int64     `json:"video_size_bytes"`
+	TotalSegments   int       `json:"total_segments"`
+	AverageCoverage float64   `json:"average_coverage"`
+	CreatedAt       time.Time `json:"created_at"`
+	Error           string    `json:"error"`
+	Attempts        int       `json:"attempts"`
+	LastError       string    `json:"last_error,omitempty"`
+}
+
+// UnsavedResultService хранит результаты анализов, которые не удалось
+// сохранить в базе данных, и повторяет их сохранение без повторного
+// анализа. Каждый результат хранится в своей папке вместе с видео.
+type UnsavedResultService struct {
+	dir          string
+	routeService *RouteService
+	logger       *logrus.Logger
+}
+
+// NewUnsavedResultService создает хранилище несохраненных результатов в
+// папке dir
+func NewUnsavedResultService(dir string, routeService *RouteService, logger *logrus.Logger) (*UnsavedResultService, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create unsaved results directory: %w", err)
+	}
+	return &UnsavedResultService{
+		dir:          dir,
+		routeService: routeService,
+		logger:       logger,
+	}, nil
+}
+
+// Save сохраняет результат анализа и видео, которые не удалось сохранить
+// в базе данных из-за saveErr, и возвращает ID результата
+func (s *UnsavedResultService) Save(routeID, videoFilename string, video []byte, result *AnalysisResult, metadata RouteMetadata, saveErr error) (string, error) {
+	unsaved := UnsavedResult{
+		ID:            uuid.NewString(),
+		RouteID:       routeID,
+		VideoFilename: videoFilename,
+		CreatedAt:     time.Now(),
+		Error:         saveErr.Error(),
+		Metadata:      metadata,
+		Result:        result,
+	}
+
+	dir := filepath.Join(s.dir, unsaved.ID)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create unsaved result directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, unsavedVideoFile), video, 0640); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to write unsaved video: %w", err)
+	}
+	// Результат записывается последним: папка без него в список не попадает
+	if err := s.write(&unsaved); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return unsaved.ID, nil
+}
+
+// List возвращает несохраненные результаты, новые первыми
+func (s *UnsavedResultService) List() ([]UnsavedResultSummary, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unsaved results directory: %w", err)
+	}
+
+	summaries := make([]UnsavedResultSummary, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		unsaved, err := s.Get(entry.Name())
+		if err != nil {
+			continue
+		}
+		summary := UnsavedResultSummary{
+			ID:            unsaved.ID,
+			RouteID:       unsaved.RouteID,
+			VideoFilename: unsaved.VideoFilename,
+			CreatedAt:     unsaved.CreatedAt,
+			Error:         unsaved.Error,
+			Attempts:      unsaved.Attempts,
+			LastError:     unsaved.LastError,
+		}
+		if unsaved.Result != nil {
+			summary.TotalSegments = unsaved.Result.OverallStats.TotalSegments
+			summary.AverageCoverage = unsaved.Result.OverallStats.AverageCoverage
+		}
+		if info, err := os.Stat(filepath.Join(s.dir, unsaved.ID, unsavedVideoFile)); err == nil {
+			summary.VideoSizeBytes = info.Size()
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].CreatedAt.After(summaries[j].CreatedAt)
+	})
+	return summaries, nil
+}
+
+// Get загружает несохраненный результат по ID
+func (s *UnsavedResultService) Get(id string) (*UnsavedResult, error) {
+	// ID всегда UUID, что заодно исключает выход за пределы папки
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsavedResultNotFound, id)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, id, unsavedResultFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsavedResultNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read unsaved result: %w", err)
+	}
+
+	var unsaved UnsavedResult
+	if err := json.Unmarshal(data, &unsaved); err != nil {
+		return nil, fmt.Errorf("failed to decode unsaved result: %w", err)
+	}
+	return &unsaved, nil
+}
+
+// Replay повторяет сохранение результата в базе данных без повторного
+// анализа. После успешного сохранения результат удаляется из хранилища,
+// при ошибке остается в нем с числом попыток и последней ошибкой.
+func (s *UnsavedResultService) Replay(id string) (*RouteResponse, error) {
+	unsaved, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if unsaved.Result == nil {
+		return nil, fmt.Errorf("unsaved result %s has no analysis result", id)
+	}
+	dir := filepath.Join(s.dir, unsaved.ID)
+	video, err := os.ReadFile(filepath.Join(dir, unsavedVideoFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unsaved video: %w", err)
+	}
+
+	s.logger.Infof("Повторяем сохранение маршрута %s из несохраненного результата %s", unsaved.RouteID, unsaved.ID)
+	saveErr := s.routeService.SaveRoute(unsaved.RouteID, unsaved.VideoFilename, bytes.NewReader(video), unsaved.Result, unsaved.Metadata)
+	if saveErr != nil {
+		unsaved.Attempts++
+		unsaved.LastError = saveErr.Error()
+		if err := s.write(unsaved); err != nil {
+			s.logger.Warnf("Не удалось обновить несохраненный результат %s: %v", unsaved.ID, err)
+		}
+		return nil, saveErr
+	}
+
+	s.logger.Infof("Маршрут %s сохранен из несохраненного результата %s", unsaved.RouteID, unsaved.ID)
+	if err := os.RemoveAll(dir); err != nil {
+		s.logger.Warnf("Не удалось удалить несохраненный результат %s: %v", unsaved.ID, err)
+	}
+	return s.routeService.GetRouteByID(unsaved.RouteID, repository.RouteScope{})
+}
+
+// write записывает результат во временный файл и переименовывает его,
+// чтобы List не прочитал частично записанный результат
+func (s *UnsavedResultService) write(unsaved *UnsavedResult) error {
+	data, err := json.Marshal(unsaved)
+	if err != nil {
+		return fmt.Errorf("failed to encode unsaved result: %w", err)
+	}
+	path := filepath.Join(s.dir, unsaved.ID, unsavedResultFile)
+	if err := os.WriteFile(path+".tmp", data, 0640); err != nil {
+		return fmt.Errorf("failed to write unsaved result: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write unsaved result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrQuotaExceeded возвращается, если месячная квота исчерпана
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrStorageQuotaExceeded возвращается, если загрузка не помещается в
+	// квоту хранилища
+	ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// anonymousSubject счетчик запросов без организации, пользователя и ключа
+const anonymousSubject = "anonymous"
+
+// QuotaError исчерпанная квота. RetryAfter — время до начала следующего месяца.
+type QuotaError struct {
+	Resource   string
+	Limit      float64
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: monthly %s limit %g reached", ErrQuotaExceeded, e.Resource, e.Limit)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrQuotaExceeded)
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// StorageQuotaError загрузка не помещается в квоту хранилища
+type StorageQuotaError struct {
+	Limit     int64
+	Used      int64
+	Requested int64
+}
+
+func (e *StorageQuotaError) Error() string {
+	return fmt.Sprintf("%s: %d of %d bytes used, upload needs %d bytes", ErrStorageQuotaExceeded, e.Used, e.Limit, e.Requested)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrStorageQuotaExceeded)
+func (e *StorageQuotaError) Unwrap() error {
+	return ErrStorageQuotaExceeded
+}
+
+// QuotaLimits месячные квоты и квота хранилища. Нулевое значение — без
+// ограничения.
+type QuotaLimits struct {
+	MonthlyUploads         int64
+	MonthlyAnalysisMinutes float64
+	// StorageBytes место для видео и аннотированных видео маршрутов
+	StorageBytes int64
+}
+
+// UsageService учитывает использование сервиса и проверяет месячные квоты
+// и квоту хранилища. Использование считается по организации, а без нее —
+// по пользователю или ключу API.
+type UsageService struct {
+	usageRepo repository.UsageRepository
+	routeRepo repository.RouteRepository
+	logger    *logrus.Logger
+	now       func() time.Time
+
+	mu     sync.RWMutex
+	limits QuotaLimits
+}
+
+// NewUsageService создает новый сервис учета использования без квот.
+// Место в хранилище считается по маршрутам routeRepo.
+func NewUsageService(usageRepo repository.UsageRepository, routeRepo repository.RouteRepository, logger *logrus.Logger) *UsageService {
+	return &UsageService{
+		usageRepo: usageRepo,
+		routeRepo: routeRepo,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// SetQuotaLimits задает месячные квоты
+func (s *UsageService) SetQuotaLimits(limits QuotaLimits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits
+}
+
+// quotaLimits возвращает действующие месячные квоты
+func (s *UsageService) quotaLimits() QuotaLimits {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.limits
+}
+
+// CheckQuota проверяет, что у subject остались загрузки и минуты анализа
+// в текущем месяце. Квота проверяется перед анализом, поэтому параллельные
+// загрузки могут немного превысить ее.
+func (s *UsageService) CheckQuota(subject string) error {
+	limits := s.quotaLimits()
+	if limits.MonthlyUploads <= 0 && limits.MonthlyAnalysisMinutes <= 0 {
+		return nil
+	}
+
+	now := s.now().UTC()
+	counter, err := s.usageRepo.Get(subject, usagePeriod(now))
+	if err != nil {
+		return fmt.Errorf("failed to check quota: %w", err)
+	}
+
+	retryAfter := periodEnd(now).Sub(now)
+	if limits.MonthlyUploads > 0 && counter.Uploads >= limits.MonthlyUploads {
+		return &QuotaError{Resource: "uploads", Limit: float64(limits.MonthlyUploads), RetryAfter: retryAfter}
+	}
+	if limits.MonthlyAnalysisMinutes > 0 && counter.AnalysisSeconds/60 >= limits.MonthlyAnalysisMinutes {
+		return &QuotaError{Resource: "analysis minutes", Limit: limits.MonthlyAnalysisMinutes, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordAnalysis учитывает загрузку видео и время ее анализа. Ошибка учета
+// записывается в лог и не отменяет сохраненный результат анализа.
+func (s *UsageService) RecordAnalysis(subject string, duration time.Duration) {
+	period := usagePeriod(s.now().UTC())
+	if err := s.usageRepo.Add(subject, period, 1, duration.Seconds()); err != nil {
+		s.logger.Errorf("Не удалось учесть использование %s за %s: %v", subject, period, err)
+	}
+}
+
+// GetUsage возвращает использование subject в текущем месяце и квоты
+func (s *UsageService) GetUsage(subject string) (*UsageResponse, error) {
+	now := s.now().UTC()
+	counter, err := s.usageRepo.Get(subject, usagePeriod(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	limits := s.quotaLimits()
+	return &UsageResponse{
+		Subject:         subject,
+		Period:          counter.Period,
+		PeriodEnd:       periodEnd(now),
+		Uploads:         quotaUsage(float64(counter.Uploads), float64(limits.MonthlyUploads)),
+		AnalysisMinutes: quotaUsage(counter.AnalysisSeconds/60, limits.MonthlyAnalysisMinutes),
+	}, nil
+}
+
+// CheckStorageQuota проверяет, что загрузка size байт поместится в квоту
+// хранилища владельца. Размер аннотированного видео до анализа не известен,
+// поэтому он учитывается только в следующих загрузках, как и маршруты, еще
+// не измеренные после обновления.
+func (s *UsageService) CheckStorageQuota(organizationID, userID, apiKeyID *uint, size int64) error {
+	limit := s.quotaLimits().StorageBytes
+	if limit <= 0 {
+		return nil
+	}
+
+	usage, err := s.routeRepo.StorageUsage(storageOwner(organizationID, userID, apiKeyID))
+	if err != nil {
+		return fmt.Errorf("failed to check storage quota: %w", err)
+	}
+	if usage.Bytes+size > limit {
+		return &StorageQuotaError{Limit: limit, Used: usage.Bytes, Requested: size}
+	}
+	return nil
+}
+
+// GetStorageUsage возвращает место, занятое маршрутами организации,
+// пользователя или ключа API, и квоту хранилища
+func (s *UsageService) GetStorageUsage(organizationID, userID, apiKeyID *uint) (*StorageUsageResponse, error) {
+	usage, err := s.routeRepo.StorageUsage(storageOwner(organizationID, userID, apiKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	return &StorageUsageResponse{
+		Subject:          QuotaSubject(organizationID, userID, apiKeyID),
+		Routes:           usage.Routes,
+		UnmeasuredRoutes: usage.Unmeasured,
+		Bytes:            quotaUsage(float64(usage.Bytes), float64(s.quotaLimits().StorageBytes)),
+	}, nil
+}
+
+// storageOwner владелец маршрутов в квоте хранилища с тем же приоритетом,
+// что и в QuotaSubject
+func storageOwner(organizationID, userID, apiKeyID *uint) repository.StorageOwner {
+	switch {
+	case organizationID != nil:
+		return repository.StorageOwner{OrganizationID: organizationID}
+	case userID != nil:
+		return repository.StorageOwner{OwnerID: userID}
+	default:
+		return repository.StorageOwner{APIKeyID: apiKeyID}
+	}
+}
+
+// QuotaSubject возвращает, чье использование учитывается: организации,
+// а без нее пользователя или ключа API
+func QuotaSubject(organizationID, userID, apiKeyID *uint) string {
+	switch {
+	case organizationID != nil:
+		return "org:" + strconv.FormatUint(uint64(*organizationID), 10)
+	case userID != nil:
+		return "user:" + strconv.FormatUint(uint64(*userID), 10)
+	case apiKeyID != nil:
+		return "key:" + strconv.FormatUint(uint64(*apiKeyID), 10)
+	default:
+		return anonymousSubject
+	}
+}
+
+// usagePeriod месяц, к которому относится момент now
+func usagePeriod(now time.Time) string {
+	return now.Format("2006-01")
+}
+
+// periodEnd начало следующего месяца по UTC
+func periodEnd(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// quotaUsage использование ресурса с лимитом, если он задан
+func quotaUsage(used, limit float64) QuotaUsage {
+	usage := QuotaUsage{Used: used}
+	if limit > 0 {
+		remaining := limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		usage.Limit = &limit
+		usage.Remaining = &remaining
+	}
+	return usage
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/jwt"
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/oidc"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials возвращается при неверном email или пароле
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrInvalidUserRequest возвращается при некорректных данных регистрации
+var ErrInvalidUserRequest = errors.New("invalid user request")
+
+// ErrRegistrationDisabled возвращается, если регистрация пользователей отключена
+var ErrRegistrationDisabled = errors.New("registration disabled")
+
+// ErrPasswordLoginDisabled возвращается, если вход по паролю отключен и
+// пользователи входят только через OIDC провайдера
+var ErrPasswordLoginDisabled = errors.New("password login disabled")
+
+// ErrInvalidToken возвращается для неверного или просроченного токена пользователя
+var ErrInvalidToken = errors.New("invalid user token")
+
+const (
+	// tokenIssuer издатель токенов сервиса
+	tokenIssuer = "road-detector-go"
+	// minPasswordLength минимальная длина пароля
+	minPasswordLength = 8
+	// maxPasswordBytes ограничение bcrypt на длину пароля
+	maxPasswordBytes = 72
+	maxEmailLength   = 255
+)
+
+// UserService сервис пользователей и токенов доступа
+type UserService struct {
+	userRepo            repository.UserRepository
+	logger              *logrus.Logger
+	secret              []byte
+	tokenTTL            time.Duration
+	registrationEnabled bool
+	// dummyHash сравнивается с паролем неизвестного пользователя, чтобы время
+	// ответа не показывало, зарегистрирован ли email
+	dummyHash []byte
+
+	oidc *oidc.Provider
+	// externalUsers ID пользователей OIDC провайдера по sub, чтобы не обращаться
+	// к БД при каждом запросе
+	externalUsers sync.Map
+}
+
+// NewUserService создает новый сервис пользователей. Токены подписываются
+// ключом secret и действуют tokenTTL. Без secret вход по паролю отключен.
+func NewUserService(userRepo repository.UserRepository, secret []byte, tokenTTL time.Duration, logger *logrus.Logger) *UserService {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("road-detector-go"), bcrypt.DefaultCost)
+	return &UserService{
+		userRepo:            userRepo,
+		logger:              logger,
+		secret:              secret,
+		tokenTTL:            tokenTTL,
+		registrationEnabled: true,
+		dummyHash:           dummyHash,
+	}
+}
+
+// SetRegistrationEnabled разрешает или запрещает регистрацию новых пользователей
+func (s *UserService) SetRegistrationEnabled(enabled bool) {
+	s.registrationEnabled = enabled
+}
+
+// SetOIDCProvider включает проверку токенов внешнего OIDC провайдера.
+// Пользователь создается при первом запросе с токеном провайдера.
+func (s *UserService) SetOIDCProvider(provider *oidc.Provider) {
+	s.oidc = provider
+}
+
+// Register создает пользователя с ролью user. Роль администратора при
+// регистрации не выдается: email никак не подтверждается, ее назначает
+// SetRole, например командой set-user-role.
+func (s *UserService) Register(req RegisterUserRequest) (*UserInfo, error) {
+	if len(s.secret) == 0 {
+		return nil, ErrPasswordLoginDisabled
+	}
+	if !s.registrationEnabled {
+		return nil, ErrRegistrationDisabled
+	}
+
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Password) < minPasswordLength {
+		return nil, fmt.Errorf("%w: password must be at least %d characters", ErrInvalidUserRequest, minPasswordLength)
+	}
+	if len(req.Password) > maxPasswordBytes {
+		return nil, fmt.Errorf("%w: password must be at most %d bytes", ErrInvalidUserRequest, maxPasswordBytes)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &model.User{
+		Email:        email,
+		PasswordHash: string(hash),
+		Role:         model.RoleUser,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to register user: %w", err)
+	}
+
+	s.logger.Infof("Зарегистрирован пользователь %d (%s), роль: %s", user.ID, user.Email, user.Role)
+	info := userInfo(user)
+	return &info, nil
+}
+
+// Login проверяет email и пароль и выпускает токен доступа
+func (s *UserService) Login(req LoginRequest) (*TokenResponse, error) {
+	if len(s.secret) == 0 {
+		return nil, ErrPasswordLoginDisabled
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(req.Password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	token, err := jwt.Sign(jwt.Claims{
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Email:     user.Email,
+		Role:      user.Role,
+		Issuer:    tokenIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.tokenTTL).Unix(),
+	}, s.secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue token: %w", err)
+	}
+
+	return &TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.tokenTTL / time.Second),
+		User:        userInfo(user),
+	}, nil
+}
+
+// Authenticate проверяет токен и возвращает пользователя из него. Роль берется
+// из токена, поэтому ее изменение вступает в силу после повторного входа.
+// Токены с подписью HS256 выпущены сервером, остальные проверяются OIDC провайдером.
+func (s *UserService) Authenticate(token string) (*UserIdentity, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+
+	parsed, err := jwt.Decode(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if parsed.Header.Alg != "HS256" && s.oidc != nil {
+		return s.authenticateExternal(token)
+	}
+	if len(s.secret) == 0 {
+		return nil, fmt.Errorf("%w: password login tokens are disabled", ErrInvalidToken)
+	}
+
+	claims, err := jwt.Parse(token, s.secret, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Issuer != tokenIssuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil || id == 0 {
+		return nil, fmt.Errorf("%w: bad subject %q", ErrInvalidToken, claims.Subject)
+	}
+
+	role := claims.Role
+	if role != model.RoleAdmin {
+		role = model.RoleUser
+	}
+	return &UserIdentity{
+		ID:    uint(id),
+		Email: claims.Email,
+		Role:  role,
+	}, nil
+}
+
+// SetRole назначает роль пользователю с указанным email. Роль попадает в
+// токен, поэтому вступает в силу после повторного входа.
+func (s *UserService) SetRole(rawEmail, role string) (*UserInfo, error) {
+	email, err := normalizeEmail(rawEmail)
+	if err != nil {
+		return nil, err
+	}
+	if role != model.RoleUser && role != model.RoleAdmin {
+		return nil, fmt.Errorf("%w: role must be %s or %s", ErrInvalidUserRequest, model.RoleUser, model.RoleAdmin)
+	}
+
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", email, err)
+	}
+	if err := s.userRepo.SetRole(user.ID, role); err != nil {
+		return nil, err
+	}
+	user.Role = role
+
+	s.logger.Infof("Пользователю %d (%s) назначена роль %s", user.ID, user.Email, role)
+	info := userInfo(user)
+	return &info, nil
+}
+
+// authenticateExternal проверяет токен OIDC провайдера. Роль определяется
+// по ролям в токене при каждом запросе.
+func (s *UserService) authenticateExternal(token string) (*UserIdentity, error) {
+	identity, err := s.oidc.Verify(token)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidToken) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		return nil, fmt.Errorf("failed to verify oidc token: %w", err)
+	}
+
+	role := model.RoleUser
+	if identity.Admin {
+		role = model.RoleAdmin
+	}
+
+	cacheKey := identity.Issuer + "\x00" + identity.Subject
+	id, ok := s.externalUsers.Load(cacheKey)
+	if !ok {
+		user, err := s.resolveExternalUser(identity, role)
+		if err != nil {
+			return nil, err
+		}
+		id = user.ID
+		s.externalUsers.Store(cacheKey, id)
+	}
+
+	return &UserIdentity{
+		ID:    id.(uint),
+		Email: identity.Email,
+		Role:  role,
+	}, nil
+}
+
+// resolveExternalUser находит пользователя OIDC провайдера или создает его.
+// Существующий пользователь с тем же email привязывается к провайдеру, только
+// если провайдер подтвердил email.
+func (s *UserService) resolveExternalUser(identity *oidc.Identity, role string) (*model.User, error) {
+	user, err := s.userRepo.GetByExternalID(identity.Issuer, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get oidc user: %w", err)
+	}
+
+	if identity.Email == "" {
+		return nil, fmt.Errorf("%w: token has no email claim", ErrInvalidToken)
+	}
+
+	existing, err := s.userRepo.GetByEmail(identity.Email)
+	switch {
+	case err == nil:
+		if !identity.EmailVerified || existing.ExternalSubject != nil {
+			return nil, fmt.Errorf("%w: %s is linked to another account", repository.ErrUserExists, identity.Email)
+		}
+		if err := s.userRepo.LinkExternal(existing.ID, identity.Issuer, identity.Subject); err != nil {
+			return nil, fmt.Errorf("failed to link oidc user: %w", err)
+		}
+		s.logger.Infof("Пользователь %d (%s) привязан к OIDC провайдеру %s", existing.ID, existing.Email, identity.Issuer)
+		return existing, nil
+	case !errors.Is(err, repository.ErrUserNotFound):
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	issuer, subject := identity.Issuer, identity.Subject
+	user = &model.User{
+		Email:           identity.Email,
+		Role:            role,
+		ExternalIssuer:  &issuer,
+		ExternalSubject: &subject,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		// Пользователя мог создать параллельный запрос с тем же токеном
+		if created, getErr := s.userRepo.GetByExternalID(issuer, subject); getErr == nil {
+			return created, nil
+		}
+		return nil, fmt.Errorf("failed to create oidc user: %w", err)
+	}
+
+	s.logger.Infof("Создан пользователь %d (%s) OIDC провайдера %s", user.ID, user.Email, issuer)
+	return user, nil
+}
+
+// IsAdmin проверяет, есть ли у пользователя роль администратора
+func (u *UserIdentity) IsAdmin() bool {
+	return u.Role == model.RoleAdmin
+}
+
+// normalizeEmail проверяет email и приводит его к нижнему регистру
+func normalizeEmail(raw string) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(raw))
+	if email == "" {
+		return "", fmt.Errorf("%w: email is required", ErrInvalidUserRequest)
+	}
+	if len(email) > maxEmailLength {
+		return "", fmt.Errorf("%w: email is longer than %d characters", ErrInvalidUserRequest, maxEmailLength)
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", fmt.Errorf("%w: invalid email", ErrInvalidUserRequest)
+	}
+	return email, nil
+}
+
+// userInfo преобразует пользователя в ответ API
+func userInfo(user *model.User) UserInfo {
+	return UserInfo{
+		ID:        user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
+}
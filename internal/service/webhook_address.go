@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// webhookLookupTimeout ожидание DNS при проверке адреса подписчика
+const webhookLookupTimeout = 5 * time.Second
+
+// reservedWebhookPrefixes служебные и зарезервированные сети, не покрытые
+// методами netip.Addr
+var reservedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// publicWebhookAddr сообщает, можно ли отправлять события на адрес addr:
+// loopback, частные, link-local (в том числе 169.254.169.254 метаданных
+// облака), multicast и зарезервированные сети запрещены
+func publicWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range reservedWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookHost разрешает имя узла подписчика и проверяет все его адреса
+func (s *WebhookService) checkWebhookHost(host string) error {
+	if s.opts.AllowPrivateNetworks {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicWebhookAddr(addr) {
+			return fmt.Errorf("%w: url points to a private or reserved address", ErrInvalidWebhookRequest)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookLookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: url host cannot be resolved", ErrInvalidWebhookRequest)
+	}
+	for _, addr := range addrs {
+		if !publicWebhookAddr(addr) {
+			return fmt.Errorf("%w: url points to a private or reserved address", ErrInvalidWebhookRequest)
+		}
+	}
+	return nil
+}
+
+// newWebhookTransport транспорт доставки событий. Адрес проверяется еще раз
+// при установке соединения: имя подписчика могло начать указывать на
+// внутренний адрес после создания подписки, а ответ подписчика может
+// перенаправить запрос. Прокси из окружения в этом режиме не используется,
+// иначе проверялся бы адрес прокси, а не подписчика.
+func newWebhookTransport(allowPrivate bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if allowPrivate {
+		return transport
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("webhook address %s is not allowed", address)
+			}
+			if !publicWebhookAddr(addrPort.Addr()) {
+				return fmt.Errorf("webhook address %s is not allowed", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
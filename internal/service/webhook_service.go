@@ -0,0 +1,642 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"road-detector-go/internal/model"
+	"road-detector-go/internal/notify"
+	"road-detector-go/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidWebhookRequest возвращается при некорректных данных подписки
+var ErrInvalidWebhookRequest = errors.New("invalid webhook request")
+
+// Заголовки запроса подписчику
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	maxWebhookURLLength = 2048
+	// webhookSecretBytes длина случайного ключа подписи
+	webhookSecretBytes  = 32
+	webhookSecretPrefix = "whsec_"
+	// maxWebhookResponseDrain сколько байт ответа подписчика дочитывается,
+	// чтобы соединение можно было использовать повторно
+	maxWebhookResponseDrain = 500
+	// maxWebhookDeliveries ограничение списка доставок в ответе API
+	maxWebhookDeliveries = 100
+)
+
+// webhookEvents события, на которые можно подписаться
+var webhookEvents = []string{model.WebhookEventAnalysisCompleted, model.WebhookEventAnalysisFailed, model.WebhookEventAlertCreated, model.WebhookEventReportGenerated}
+
+// chatWebhookEvents события, о которых сообщают подписки формата slack
+var chatWebhookEvents = []string{model.WebhookEventAnalysisCompleted, model.WebhookEventAlertCreated}
+
+// chatWebhookPayload тело запроса входящего вебхука Slack или Mattermost
+type chatWebhookPayload struct {
+	Text string `json:"text"`
+}
+
+// WebhookOptions настройки доставки событий
+type WebhookOptions struct {
+	// MaxAttempts сколько раз отправляется событие до отметки failed
+	MaxAttempts int
+	// RetryDelay пауза перед второй попыткой, далее она удваивается
+	RetryDelay time.Duration
+	// Timeout ожидание ответа подписчика
+	Timeout time.Duration
+	// AllowPrivateNetworks разрешает подписки на адреса внутренних сетей
+	AllowPrivateNetworks bool
+}
+
+// WebhookService управляет подписками и отправляет им события анализа:
+// в JSON с подписью или, для подписок формата slack, сообщениями по
+// шаблонам уведомлений во входящие вебхуки Slack и Mattermost.
+// Отправка выполняется в фоне с повторами по экспоненциальной задержке,
+// каждая попытка записывается в журнал доставок. Повторы хранятся в памяти
+// процесса: при остановке Shutdown дожидается текущих попыток, а ожидающие
+// повтора доставки отмечаются failed. Доставки, прерванные аварийной
+// остановкой, возобновляет ResumePending.
+type WebhookService struct {
+	webhookRepo repository.WebhookRepository
+	logger      *logrus.Logger
+	client      *http.Client
+	notifier    *notify.Notifier
+	opts        WebhookOptions
+	now         func() time.Time
+
+	// stop закрывается при остановке сервиса, inflight считает фоновые доставки
+	stop     chan struct{}
+	stopOnce sync.Once
+	inflight sync.WaitGroup
+}
+
+// NewWebhookService создает новый сервис вебхуков с настройками доставки
+// по умолчанию: 6 попыток, первая пауза 10 секунд, ожидание ответа 10 секунд
+func NewWebhookService(webhookRepo repository.WebhookRepository, logger *logrus.Logger) *WebhookService {
+	s := &WebhookService{
+		webhookRepo: webhookRepo,
+		logger:      logger,
+		client:      &http.Client{},
+		now:         time.Now,
+		stop:        make(chan struct{}),
+	}
+	s.SetDeliveryOptions(WebhookOptions{})
+	return s
+}
+
+// SetDeliveryOptions задает настройки доставки, нулевые поля заменяются
+// значениями по умолчанию
+func (s *WebhookService) SetDeliveryOptions(opts WebhookOptions) {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 6
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = 10 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	s.opts = opts
+	s.client.Timeout = opts.Timeout
+	s.client.Transport = newWebhookTransport(opts.AllowPrivateNetworks)
+}
+
+// SetNotifier включает подписки формата slack: тексты сообщений берутся
+// из шаблонов уведомлений
+func (s *WebhookService) SetNotifier(notifier *notify.Notifier) {
+	s.notifier = notifier
+}
+
+// CreateWebhook создает подписку организации orgID (nil — подписку на события
+// всех маршрутов) и возвращает ее вместе с ключом подписи
+func (s *WebhookService) CreateWebhook(orgID *uint, req CreateWebhookRequest) (*CreatedWebhookResponse, error) {
+	target, err := s.validateWebhookURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	format, err := s.validateWebhookFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(req.Events, format)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook := &model.Webhook{
+		OrganizationID: orgID,
+		URL:            target,
+		Secret:         webhookSecretPrefix + hex.EncodeToString(secret),
+		Events:         strings.Join(events, ","),
+		Format:         format,
+		Active:         req.Active == nil || *req.Active,
+	}
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	s.logger.Infof("Создан вебхук %d (%s), организация: %s, формат: %s, события: %s",
+		webhook.ID, webhook.URL, formatOrganizationID(orgID), webhook.Format, webhook.Events)
+	return &CreatedWebhookResponse{
+		WebhookInfo: webhookInfo(webhook),
+		Secret:      webhook.Secret,
+	}, nil
+}
+
+// ListWebhooks возвращает подписки организации
+func (s *WebhookService) ListWebhooks(orgID *uint) ([]WebhookInfo, error) {
+	webhooks, err := s.webhookRepo.List(orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	result := make([]WebhookInfo, len(webhooks))
+	for i := range webhooks {
+		result[i] = webhookInfo(&webhooks[i])
+	}
+	return result, nil
+}
+
+// GetWebhook возвращает подписку организации
+func (s *WebhookService) GetWebhook(id uint, orgID *uint) (*WebhookInfo, error) {
+	webhook, err := s.webhookRepo.GetByID(id, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	info := webhookInfo(webhook)
+	return &info, nil
+}
+
+// UpdateWebhook меняет адрес, события, формат или признак активности
+// подписки. При смене формата без новых событий текущие события должны
+// поддерживаться новым форматом.
+func (s *WebhookService) UpdateWebhook(id uint, orgID *uint, req UpdateWebhookRequest) (*WebhookInfo, error) {
+	webhook, err := s.webhookRepo.GetByID(id, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	if req.URL != nil {
+		target, err := s.validateWebhookURL(*req.URL)
+		if err != nil {
+			return nil, err
+		}
+		webhook.URL = target
+	}
+	if req.Format != nil {
+		format, err := s.validateWebhookFormat(*req.Format)
+		if err != nil {
+			return nil, err
+		}
+		webhook.Format = format
+	}
+	if req.Events != nil || req.Format != nil {
+		events := req.Events
+		if events == nil {
+			events = strings.Split(webhook.Events, ",")
+		}
+		normalized, err := normalizeWebhookEvents(events, webhook.Format)
+		if err != nil {
+			return nil, err
+		}
+		webhook.Events = strings.Join(normalized, ",")
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := s.webhookRepo.Update(webhook); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	s.logger.Infof("Вебхук %d изменен: %s, формат: %s, события: %s, активен: %t",
+		webhook.ID, webhook.URL, webhook.Format, webhook.Events, webhook.Active)
+	info := webhookInfo(webhook)
+	return &info, nil
+}
+
+// DeleteWebhook удаляет подписку организации
+func (s *WebhookService) DeleteWebhook(id uint, orgID *uint) error {
+	if err := s.webhookRepo.Delete(id, orgID); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	s.logger.Infof("Вебхук %d удален", id)
+	return nil
+}
+
+// ListDeliveries возвращает последние доставки подписки организации
+func (s *WebhookService) ListDeliveries(id uint, orgID *uint, limit int) ([]WebhookDeliveryInfo, error) {
+	if _, err := s.webhookRepo.GetByID(id, orgID); err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	if limit < 1 || limit > maxWebhookDeliveries {
+		limit = maxWebhookDeliveries
+	}
+
+	deliveries, err := s.webhookRepo.ListDeliveries(id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	result := make([]WebhookDeliveryInfo, len(deliveries))
+	for i, delivery := range deliveries {
+		result[i] = WebhookDeliveryInfo{
+			ID:             delivery.ID,
+			EventID:        delivery.EventID,
+			Event:          delivery.Event,
+			Payload:        json.RawMessage(delivery.Payload),
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			ResponseStatus: delivery.ResponseStatus,
+			Error:          delivery.Error,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			CreatedAt:      delivery.CreatedAt,
+			UpdatedAt:      delivery.UpdatedAt,
+		}
+	}
+	return result, nil
+}
+
+// Publish создает доставки события подпискам организации события и
+// подпискам без организации, отправка выполняется в фоне. Вызывается
+// ретранслятором событий (OutboxService). Возвращает ошибку, если не удалось
+// сохранить хотя бы одну доставку: событие будет опубликовано повторно, и
+// подписчики, доставка которым уже создана, получат его еще раз с тем же ID.
+// Если сообщение для подписок формата slack не удалось составить, они
+// пропускаются с записью в лог.
+func (s *WebhookService) Publish(event *model.OutboxEvent) error {
+	webhooks, err := s.webhookRepo.ListActive(event.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	payload := WebhookPayload{
+		ID:        event.EventID,
+		Event:     event.Event,
+		CreatedAt: event.CreatedAt.UTC(),
+		Data:      json.RawMessage(event.Data),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	var (
+		errs     []error
+		chatBody []byte
+		chatErr  error
+	)
+	for i := range webhooks {
+		webhook := webhooks[i]
+		if !webhookSubscribed(&webhook, event.Event) {
+			continue
+		}
+		webhookBody := body
+		if webhook.Format == model.WebhookFormatSlack {
+			if chatBody == nil && chatErr == nil {
+				chatBody, chatErr = s.chatMessage(event)
+			}
+			if chatErr != nil {
+				s.logger.Errorf("Событие %s не отправлено вебхуку %d: %v", event.Event, webhook.ID, chatErr)
+				continue
+			}
+			webhookBody = chatBody
+		}
+		delivery := &model.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   payload.ID,
+			Event:     event.Event,
+			Payload:   string(webhookBody),
+			Status:    model.WebhookDeliveryPending,
+		}
+		if err := s.webhookRepo.CreateDelivery(delivery); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %d: %w", webhook.ID, err))
+			continue
+		}
+		s.start(&webhook, delivery, webhookBody)
+	}
+	return errors.Join(errs...)
+}
+
+// chatMessage составляет по шаблону уведомлений сообщение о событии для
+// подписок формата slack: тему и текст со ссылкой на маршрут
+func (s *WebhookService) chatMessage(event *model.OutboxEvent) ([]byte, error) {
+	if s.notifier == nil {
+		return nil, errors.New("chat messages are not configured")
+	}
+
+	var (
+		name string
+		data interface{}
+	)
+	switch event.Event {
+	case model.WebhookEventAnalysisCompleted:
+		var analysis AnalysisEventData
+		if err := json.Unmarshal([]byte(event.Data), &analysis); err != nil {
+			return nil, fmt.Errorf("failed to decode event data: %w", err)
+		}
+		name = notify.TemplateAnalysisCompleted
+		message := notify.AnalysisMessage{
+			RouteID:          analysis.RouteID,
+			Name:             analysis.Name,
+			RoadName:         analysis.RoadName,
+			URL:              s.notifier.RouteURL(analysis.RouteID),
+			DistanceKm:       analysis.TotalDistanceMeters / 1000,
+			TotalSegments:    analysis.TotalSegments,
+			SegmentsWithData: analysis.SegmentsWithData,
+			AverageCoverage:  analysis.AverageCoverage,
+			Band:             analysis.CoverageBand,
+			TotalDefects:     analysis.TotalDefects,
+			QualityScore:     analysis.QualityScore,
+		}
+		if message.Name == "" {
+			message.Name = analysis.RouteID
+		}
+		data = message
+	case model.WebhookEventAlertCreated:
+		var alert AlertEventData
+		if err := json.Unmarshal([]byte(event.Data), &alert); err != nil {
+			return nil, fmt.Errorf("failed to decode event data: %w", err)
+		}
+		name = notify.TemplateAlert
+		data = alertMessage(alert.RuleName, alert.RouteID, alert.Segments, s.notifier.RouteURL(alert.RouteID))
+	default:
+		return nil, fmt.Errorf("event %s has no chat message", event.Event)
+	}
+
+	subject, text, err := s.notifier.Render(name, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(chatWebhookPayload{Text: subject + "\n\n" + text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chat message: %w", err)
+	}
+	return body, nil
+}
+
+// ResumePending возобновляет доставки, которые остались pending после
+// аварийной остановки: при штатной остановке ожидающие доставки отмечаются
+// failed. Доставки выключенных подписок отмечаются failed. Возвращает
+// количество возобновленных доставок.
+func (s *WebhookService) ResumePending() (int, error) {
+	pending, err := s.webhookRepo.ListPendingDeliveries()
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for i := range pending {
+		webhook, delivery := &pending[i].Webhook, &pending[i].Delivery
+		if !webhook.Active {
+			delivery.Status = model.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+			delivery.Error = "webhook disabled before delivery was resumed"
+			if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+				s.logger.Errorf("Не удалось сохранить результат доставки %d: %v", delivery.ID, err)
+			}
+			continue
+		}
+		s.start(webhook, delivery, []byte(delivery.Payload))
+		resumed++
+	}
+	return resumed, nil
+}
+
+// start отправляет доставку в фоне
+func (s *WebhookService) start(webhook *model.Webhook, delivery *model.WebhookDelivery, body []byte) {
+	s.inflight.Add(1)
+	go func() {
+		defer s.inflight.Done()
+		s.deliver(webhook, delivery, body)
+	}()
+}
+
+// Shutdown прекращает повторы доставок и ждет завершения текущих попыток,
+// но не дольше timeout. Возвращает false, если попытки не успели завершиться.
+func (s *WebhookService) Shutdown(timeout time.Duration) bool {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// deliver отправляет событие подписчику, повторяя попытки с удваивающейся
+// паузой, пока подписчик не ответит 2xx или не закончатся попытки
+func (s *WebhookService) deliver(webhook *model.Webhook, delivery *model.WebhookDelivery, body []byte) {
+	delay := s.opts.RetryDelay
+	for {
+		delivery.Attempts++
+		status, err := s.send(webhook, delivery, body)
+		delivery.ResponseStatus = status
+		delivery.NextAttemptAt = nil
+
+		switch {
+		case err == nil:
+			delivery.Status = model.WebhookDeliverySucceeded
+			delivery.Error = ""
+		case delivery.Attempts >= s.opts.MaxAttempts:
+			delivery.Status = model.WebhookDeliveryFailed
+			delivery.Error = err.Error()
+		default:
+			next := s.now().Add(delay)
+			delivery.NextAttemptAt = &next
+			delivery.Error = err.Error()
+		}
+
+		if updateErr := s.webhookRepo.UpdateDelivery(delivery); updateErr != nil {
+			s.logger.Errorf("Не удалось сохранить результат доставки %d: %v", delivery.ID, updateErr)
+		}
+
+		switch delivery.Status {
+		case model.WebhookDeliverySucceeded:
+			s.logger.Infof("Событие %s доставлено вебхуку %d с попытки %d", delivery.Event, webhook.ID, delivery.Attempts)
+			return
+		case model.WebhookDeliveryFailed:
+			s.logger.Warnf("Событие %s не доставлено вебхуку %d за %d попыток: %v",
+				delivery.Event, webhook.ID, delivery.Attempts, err)
+			return
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-s.stop:
+			delivery.Status = model.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+			delivery.Error = "delivery interrupted by shutdown: " + delivery.Error
+			if updateErr := s.webhookRepo.UpdateDelivery(delivery); updateErr != nil {
+				s.logger.Errorf("Не удалось сохранить результат доставки %d: %v", delivery.ID, updateErr)
+			}
+			s.logger.Warnf("Повтор доставки события %s вебхуку %d отменен остановкой сервиса", delivery.Event, webhook.ID)
+			return
+		}
+		delay *= 2
+	}
+}
+
+// send выполняет одну попытку доставки и возвращает HTTP статус ответа
+func (s *WebhookService) send(webhook *model.Webhook, delivery *model.WebhookDelivery, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "road-detector-webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Тело ответа в журнал доставок не попадает: его видят пользователи API,
+	// а подписчик может вернуть в нем внутренние данные
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseDrain))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("subscriber returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload подпись тела события: sha256= и HMAC-SHA256 строки
+// "<timestamp>.<body>" в hex. Подписчик вычисляет ее тем же способом.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhookURL проверяет адрес подписчика. Если внутренние сети не
+// разрешены, узел не должен разрешаться в частный или служебный адрес.
+func (s *WebhookService) validateWebhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > maxWebhookURLLength {
+		return "", fmt.Errorf("%w: url is longer than %d characters", ErrInvalidWebhookRequest, maxWebhookURLLength)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidWebhookRequest)
+	}
+	if err := s.checkWebhookHost(parsed.Hostname()); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// validateWebhookFormat проверяет формат подписки, пусто — json. Формат
+// slack доступен, только если заданы шаблоны уведомлений.
+func (s *WebhookService) validateWebhookFormat(format string) (string, error) {
+	switch strings.TrimSpace(format) {
+	case "", model.WebhookFormatJSON:
+		return model.WebhookFormatJSON, nil
+	case model.WebhookFormatSlack:
+		if s.notifier == nil {
+			return "", fmt.Errorf("%w: chat messages are not configured", ErrInvalidWebhookRequest)
+		}
+		return model.WebhookFormatSlack, nil
+	default:
+		return "", fmt.Errorf("%w: format must be %s or %s", ErrInvalidWebhookRequest, model.WebhookFormatJSON, model.WebhookFormatSlack)
+	}
+}
+
+// normalizeWebhookEvents проверяет события подписки формата format и
+// убирает повторы. Пустой список означает все события формата.
+func normalizeWebhookEvents(events []string, format string) ([]string, error) {
+	supported := webhookEvents
+	if format == model.WebhookFormatSlack {
+		supported = chatWebhookEvents
+	}
+	if len(events) == 0 {
+		return supported, nil
+	}
+
+	var result []string
+	seen := make(map[string]bool)
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		known := false
+		for _, candidate := range supported {
+			if event == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: unknown event %q for format %s, supported: %s",
+				ErrInvalidWebhookRequest, event, format, strings.Join(supported, ", "))
+		}
+		if !seen[event] {
+			seen[event] = true
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+// webhookSubscribed проверяет, подписан ли вебхук на событие
+func webhookSubscribed(webhook *model.Webhook, event string) bool {
+	for _, subscribed := range strings.Split(webhook.Events, ",") {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookInfo преобразует подписку в ответ API
+func webhookInfo(webhook *model.Webhook) WebhookInfo {
+	return WebhookInfo{
+		ID:             webhook.ID,
+		OrganizationID: webhook.OrganizationID,
+		URL:            webhook.URL,
+		Events:         strings.Split(webhook.Events, ","),
+		Format:         webhook.Format,
+		Active:         webhook.Active,
+		CreatedAt:      webhook.CreatedAt,
+		UpdatedAt:      webhook.UpdatedAt,
+	}
+}
@@ -0,0 +1,154 @@
+// Package tlsserver запускает HTTPS сервер без внешнего обратного прокси:
+// с сертификатом из файлов или выпущенным через Let's Encrypt (ACME),
+// заголовком HSTS и перенаправлением с HTTP на HTTPS.
+package tlsserver
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Options настройки TLS
+type Options struct {
+	// CertFile и KeyFile сертификат и закрытый ключ в формате PEM
+	CertFile string
+	KeyFile  string
+	// AutocertDomains домены, для которых сертификат выпускается через
+	// Let's Encrypt. Задаются вместо CertFile и KeyFile.
+	AutocertDomains []string
+	// AutocertCacheDir каталог для выпущенных сертификатов и ключа аккаунта ACME
+	AutocertCacheDir string
+	// AutocertEmail контакт для уведомлений Let's Encrypt об истечении сертификата
+	AutocertEmail string
+	// RedirectAddr адрес HTTP listener, перенаправляющего запросы на HTTPS,
+	// например :80. Пусто — listener не запускается.
+	RedirectAddr string
+	// HSTSMaxAge значение max-age заголовка Strict-Transport-Security, 0 — без заголовка
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+}
+
+// Enabled проверяет, что TLS настроен
+func (o Options) Enabled() bool {
+	return o.CertFile != "" || o.KeyFile != "" || len(o.AutocertDomains) > 0
+}
+
+// Server HTTPS сервер с выбранным источником сертификата
+type Server struct {
+	opts    Options
+	manager *autocert.Manager
+}
+
+// New проверяет настройки и создает Server. Сертификат из файлов читается
+// при запуске, а через ACME — при первом TLS соединении.
+func New(opts Options) (*Server, error) {
+	hasFiles := opts.CertFile != "" || opts.KeyFile != ""
+	switch {
+	case hasFiles && len(opts.AutocertDomains) > 0:
+		return nil, errors.New("tls certificate files and autocert domains are mutually exclusive")
+	case hasFiles && (opts.CertFile == "" || opts.KeyFile == ""):
+		return nil, errors.New("both tls certificate and key files are required")
+	case !hasFiles && len(opts.AutocertDomains) == 0:
+		return nil, errors.New("tls certificate files or autocert domains are required")
+	}
+
+	s := &Server{opts: opts}
+	if len(opts.AutocertDomains) > 0 {
+		if opts.AutocertCacheDir == "" {
+			return nil, errors.New("autocert cache dir is required")
+		}
+		s.manager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.AutocertDomains...),
+			Cache:      autocert.DirCache(opts.AutocertCacheDir),
+			Email:      opts.AutocertEmail,
+		}
+	}
+	return s, nil
+}
+
+// Mode источник сертификата для логов
+func (s *Server) Mode() string {
+	if s.manager != nil {
+		return "Let's Encrypt (" + strings.Join(s.opts.AutocertDomains, ", ") + ")"
+	}
+	return s.opts.CertFile
+}
+
+// HSTS добавляет к ответам заголовок Strict-Transport-Security. Возвращает
+// nil, если HSTSMaxAge не задан.
+func (s *Server) HSTS() gin.HandlerFunc {
+	if s.opts.HSTSMaxAge <= 0 {
+		return nil
+	}
+	value := "max-age=" + strconv.FormatInt(int64(s.opts.HSTSMaxAge/time.Second), 10)
+	if s.opts.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	return func(c *gin.Context) {
+		c.Header("Strict-Transport-Security", value)
+		c.Next()
+	}
+}
+
+// ListenAndServeTLS обслуживает server по HTTPS. Адрес, обработчик и тайм-ауты
+// задаются в server, TLSConfig заполняется по настройкам сертификата.
+func (s *Server) ListenAndServeTLS(server *http.Server) error {
+	if s.manager != nil {
+		// Конфигурация менеджера принимает и проверки ACME tls-alpn-01
+		server.TLSConfig = s.manager.TLSConfig()
+		server.TLSConfig.MinVersion = tls.VersionTLS12
+		return server.ListenAndServeTLS("", "")
+	}
+	server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	return server.ListenAndServeTLS(s.opts.CertFile, s.opts.KeyFile)
+}
+
+// RedirectServer создает HTTP сервер на RedirectAddr, который перенаправляет
+// запросы на HTTPS сервер с адресом httpsAddr. При выпуске сертификата через
+// ACME он также отвечает на проверки http-01.
+func (s *Server) RedirectServer(httpsAddr string) *http.Server {
+	var handler http.Handler = redirectHandler(httpsPort(httpsAddr))
+	if s.manager != nil {
+		handler = s.manager.HTTPHandler(handler)
+	}
+	return &http.Server{
+		Addr:              s.opts.RedirectAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// redirectHandler перенаправляет запрос на тот же путь по HTTPS. Код 308
+// сохраняет метод и тело запроса.
+func redirectHandler(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.Trim(host, "[]")
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// httpsPort порт из адреса HTTPS listener
+func httpsPort(addr string) string {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return ""
+	}
+	return port
+}
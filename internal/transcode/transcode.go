@@ -0,0 +1,149 @@
+// Package transcode перекодирует видео, которые не воспроизводятся в
+// браузерах (H.265, MOV и другие), в H.264 MP4 с помощью ffmpeg.
+package transcode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"road-detector-go/internal/mediatool"
+)
+
+// Transcoder перекодирует видео в формат, который воспроизводят браузеры
+type Transcoder interface {
+	// Playable проверяет, воспроизводится ли видео в браузерах без
+	// перекодирования
+	Playable(ctx context.Context, path string) (bool, error)
+	// Transcode записывает в output копию видео input в H.264 MP4
+	Transcode(ctx context.Context, input, output string) error
+}
+
+// Options настройки перекодирования
+type Options struct {
+	// FFmpegPath путь к ffmpeg, пусто — ffmpeg из PATH
+	FFmpegPath string
+	// FFprobePath путь к ffprobe, пусто — ffprobe из PATH
+	FFprobePath string
+	// CRF качество H.264: меньше — лучше и больше файл
+	CRF int
+	// MaxHeight максимальная высота кадра, более высокое видео уменьшается;
+	// 0 — без уменьшения
+	MaxHeight int
+}
+
+// ffmpegTranscoder перекодирует видео внешними ffmpeg и ffprobe
+type ffmpegTranscoder struct {
+	opts    Options
+	ffmpeg  string
+	ffprobe string
+}
+
+// New проверяет, что ffmpeg и ffprobe доступны
+func New(opts Options) (Transcoder, error) {
+	if opts.FFmpegPath == "" {
+		opts.FFmpegPath = "ffmpeg"
+	}
+	if opts.FFprobePath == "" {
+		opts.FFprobePath = "ffprobe"
+	}
+	if opts.CRF < 0 || opts.CRF > 51 {
+		return nil, fmt.Errorf("crf must be between 0 and 51, got %d", opts.CRF)
+	}
+	if opts.MaxHeight < 0 {
+		return nil, fmt.Errorf("max height must not be negative")
+	}
+	ffmpeg, err := exec.LookPath(opts.FFmpegPath)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	ffprobe, err := exec.LookPath(opts.FFprobePath)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe not found: %w", err)
+	}
+	return &ffmpegTranscoder{opts: opts, ffmpeg: ffmpeg, ffprobe: ffprobe}, nil
+}
+
+// probeResult видеопоток и контейнер по данным ffprobe
+type probeResult struct {
+	Streams []struct {
+		CodecName string `json:"codec_name"`
+		PixFmt    string `json:"pix_fmt"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+	} `json:"format"`
+}
+
+// Playable проверяет, что видео — H.264 4:2:0 в контейнере MP4. MOV
+// ffprobe определяет тем же форматом, что и MP4, поэтому учитывается и
+// расширение файла.
+func (t *ffmpegTranscoder) Playable(ctx context.Context, path string) (bool, error) {
+	out, err := mediatool.Run(ctx, t.ffprobe,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=codec_name,pix_fmt:format=format_name",
+		"-of", "json",
+		path,
+	)
+	if err != nil {
+		return false, err
+	}
+	var probe probeResult
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return false, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	if len(probe.Streams) == 0 {
+		return false, fmt.Errorf("no video stream in %s", filepath.Base(path))
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4", ".m4v":
+	default:
+		return false, nil
+	}
+	stream := probe.Streams[0]
+	return stream.CodecName == "h264" && stream.PixFmt == "yuv420p" &&
+		strings.Contains(probe.Format.FormatName, "mp4"), nil
+}
+
+// Transcode перекодирует видео в H.264 4:2:0 со звуком AAC. Файл output
+// появляется только после полной записи.
+func (t *ffmpegTranscoder) Transcode(ctx context.Context, input, output string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(output), filepath.Base(output)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	// Высота и ширина H.264 4:2:0 должны быть четными
+	scale := "scale=trunc(iw/2)*2:trunc(ih/2)*2"
+	if t.opts.MaxHeight > 0 {
+		scale = "scale=-2:'min(" + strconv.Itoa(t.opts.MaxHeight) + ",trunc(ih/2)*2)'"
+	}
+	_, err = mediatool.Run(ctx, t.ffmpeg,
+		"-hide_banner", "-loglevel", "error", "-nostdin", "-y",
+		"-i", input,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-vf", scale,
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", strconv.Itoa(t.opts.CRF),
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "128k",
+		"-movflags", "+faststart",
+		"-f", "mp4",
+		tmp.Name(),
+	)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return fmt.Errorf("failed to move transcoded video: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Package videochunk делит длинное видео на части по времени и склеивает
+// аннотированные части обратно с помощью ffmpeg. Части вырезаются без
+// перекодирования, поэтому границы проходят по ключевым кадрам и длительность
+// частей может немного отличаться от заданной.
+package videochunk
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"road-detector-go/internal/mediatool"
+)
+
+// Options настройки деления видео
+type Options struct {
+	// FFmpegPath путь к ffmpeg, пусто — ffmpeg из PATH
+	FFmpegPath string
+	// FFprobePath путь к ffprobe, пусто — ffprobe из PATH
+	FFprobePath string
+	// ChunkDuration длительность одной части; видео не длиннее этого не делится
+	ChunkDuration time.Duration
+}
+
+// Chunk часть видео
+type Chunk struct {
+	Index int
+	// Start и End границы части в секундах от начала видео
+	Start float64
+	End   float64
+	Data  []byte
+}
+
+// Splitter делит видео на части
+type Splitter struct {
+	opts    Options
+	ffmpeg  string
+	ffprobe string
+}
+
+// New проверяет, что ffmpeg и ffprobe доступны
+func New(opts Options) (*Splitter, error) {
+	if opts.FFmpegPath == "" {
+		opts.FFmpegPath = "ffmpeg"
+	}
+	if opts.FFprobePath == "" {
+		opts.FFprobePath = "ffprobe"
+	}
+	if opts.ChunkDuration <= 0 {
+		return nil, fmt.Errorf("chunk duration must be positive")
+	}
+	ffmpeg, err := exec.LookPath(opts.FFmpegPath)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	ffprobe, err := exec.LookPath(opts.FFprobePath)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe not found: %w", err)
+	}
+	return &Splitter{opts: opts, ffmpeg: ffmpeg, ffprobe: ffprobe}, nil
+}
+
+// ChunkDuration возвращает длительность одной части
+func (s *Splitter) ChunkDuration() time.Duration {
+	return s.opts.ChunkDuration
+}
+
+// Split делит видео на части по ChunkDuration. Возвращает nil, если видео
+// не длиннее одной части.
+func (s *Splitter) Split(ctx context.Context, video []byte) ([]Chunk, error) {
+	dir, err := os.MkdirTemp("", "videochunk-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input")
+	if err := os.WriteFile(input, video, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	duration, err := s.duration(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if duration <= s.opts.ChunkDuration.Seconds() {
+		return nil, nil
+	}
+
+	// Звук анализу не нужен и не всегда помещается в контейнер MP4
+	list := filepath.Join(dir, "chunks.csv")
+	_, err = mediatool.Run(ctx, s.ffmpeg,
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-i", input,
+		"-map", "0:v:0", "-an", "-c", "copy",
+		"-f", "segment",
+		"-segment_time", strconv.FormatFloat(s.opts.ChunkDuration.Seconds(), 'f', -1, 64),
+		"-reset_timestamps", "1",
+		"-segment_list", list, "-segment_list_type", "csv",
+		filepath.Join(dir, "chunk%04d.mp4"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return readChunks(dir, list)
+}
+
+// readChunks читает части из списка сегментов ffmpeg: имя файла, начало и
+// конец в секундах
+func readChunks(dir, list string) ([]Chunk, error) {
+	data, err := os.ReadFile(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segment list: %w", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse segment list: %w", err)
+	}
+
+	chunks := make([]Chunk, 0, len(records))
+	for i, record := range records {
+		if len(record) < 3 {
+			return nil, fmt.Errorf("invalid segment list record %q", record)
+		}
+		start, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid segment start %q: %w", record[1], err)
+		}
+		end, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid segment end %q: %w", record[2], err)
+		}
+		chunk, err := os.ReadFile(filepath.Join(dir, filepath.Base(record[0])))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		chunks = append(chunks, Chunk{Index: i, Start: start, End: end, Data: chunk})
+	}
+	return chunks, nil
+}
+
+// duration возвращает длительность видео в секундах
+func (s *Splitter) duration(ctx context.Context, path string) (float64, error) {
+	out, err := mediatool.Run(ctx, s.ffprobe,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	)
+	if err != nil {
+		return 0, err
+	}
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse video duration %q: %w", strings.TrimSpace(string(out)), err)
+	}
+	return duration, nil
+}
+
+// Concat склеивает части MP4 с одинаковыми параметрами кодирования в одно
+// видео без перекодирования
+func (s *Splitter) Concat(ctx context.Context, clips [][]byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "videochunk-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var list strings.Builder
+	for i, clip := range clips {
+		name := fmt.Sprintf("clip%04d.mp4", i)
+		if err := os.WriteFile(filepath.Join(dir, name), clip, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write temp file: %w", err)
+		}
+		fmt.Fprintf(&list, "file '%s'\n", name)
+	}
+	listPath := filepath.Join(dir, "clips.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	output := filepath.Join(dir, "output.mp4")
+	_, err = mediatool.Run(ctx, s.ffmpeg,
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-f", "concat", "-safe", "0", "-i", listPath,
+		"-c", "copy", "-movflags", "+faststart",
+		output,
+	)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read concatenated video: %w", err)
+	}
+	return data, nil
+}
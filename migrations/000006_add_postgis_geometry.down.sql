@@ -0,0 +1,12 @@
+-- Удаляем геометрию PostGIS, координатные колонки остаются
+DROP INDEX IF EXISTS idx_routes_geom;
+DROP INDEX IF EXISTS idx_segments_geog;
+DROP INDEX IF EXISTS idx_segments_geom;
+
+DROP TRIGGER IF EXISTS trg_routes_geom ON routes;
+DROP TRIGGER IF EXISTS trg_segments_geom ON segments;
+DROP FUNCTION IF EXISTS routes_set_geom();
+DROP FUNCTION IF EXISTS segments_set_geom();
+
+ALTER TABLE routes DROP COLUMN IF EXISTS geom;
+ALTER TABLE segments DROP COLUMN IF EXISTS geom;
@@ -0,0 +1,38 @@
+-- Опциональная поддержка PostGIS: применяется только если расширение доступно.
+-- Сервер выполняет эти же шаги автоматически при POSTGIS_MODE=auto|on.
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS geom geometry(LineString, 4326);
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS geom geometry(LineString, 4326);
+
+-- Геометрия поддерживается триггерами из координатных колонок
+CREATE OR REPLACE FUNCTION segments_set_geom() RETURNS trigger AS $$
+BEGIN
+    NEW.geom := ST_SetSRID(ST_MakeLine(ST_MakePoint(NEW.start_lon, NEW.start_lat), ST_MakePoint(NEW.end_lon, NEW.end_lat)), 4326);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_segments_geom ON segments;
+CREATE TRIGGER trg_segments_geom BEFORE INSERT OR UPDATE OF start_lat, start_lon, end_lat, end_lon ON segments
+    FOR EACH ROW EXECUTE FUNCTION segments_set_geom();
+
+CREATE OR REPLACE FUNCTION routes_set_geom() RETURNS trigger AS $$
+BEGIN
+    NEW.geom := ST_SetSRID(ST_MakeLine(ST_MakePoint(NEW.start_lon, NEW.start_lat), ST_MakePoint(NEW.end_lon, NEW.end_lat)), 4326);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_routes_geom ON routes;
+CREATE TRIGGER trg_routes_geom BEFORE INSERT OR UPDATE OF start_lat, start_lon, end_lat, end_lon ON routes
+    FOR EACH ROW EXECUTE FUNCTION routes_set_geom();
+
+-- Заполняем геометрию для существующих записей
+UPDATE segments SET geom = ST_SetSRID(ST_MakeLine(ST_MakePoint(start_lon, start_lat), ST_MakePoint(end_lon, end_lat)), 4326) WHERE geom IS NULL;
+UPDATE routes SET geom = ST_SetSRID(ST_MakeLine(ST_MakePoint(start_lon, start_lat), ST_MakePoint(end_lon, end_lat)), 4326) WHERE geom IS NULL;
+
+-- Пространственные индексы
+CREATE INDEX IF NOT EXISTS idx_segments_geom ON segments USING GIST (geom);
+CREATE INDEX IF NOT EXISTS idx_segments_geog ON segments USING GIST ((geom::geography));
+CREATE INDEX IF NOT EXISTS idx_routes_geom ON routes USING GIST (geom);
@@ -0,0 +1,7 @@
+-- Удаляем привязанную к дорогам геометрию
+ALTER TABLE segments DROP COLUMN IF EXISTS matched_end_lon;
+ALTER TABLE segments DROP COLUMN IF EXISTS matched_end_lat;
+ALTER TABLE segments DROP COLUMN IF EXISTS matched_start_lon;
+ALTER TABLE segments DROP COLUMN IF EXISTS matched_start_lat;
+
+ALTER TABLE routes DROP COLUMN IF EXISTS matched_geometry;
@@ -0,0 +1,7 @@
+-- Геометрия, привязанная к дорожному графу OSM. Исходные координаты не меняются.
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS matched_geometry TEXT;
+
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS matched_start_lat DOUBLE PRECISION;
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS matched_start_lon DOUBLE PRECISION;
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS matched_end_lat DOUBLE PRECISION;
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS matched_end_lon DOUBLE PRECISION;
@@ -0,0 +1,3 @@
+-- Удаляем названия дорог
+ALTER TABLE segments DROP COLUMN IF EXISTS road_name;
+ALTER TABLE routes DROP COLUMN IF EXISTS road_name;
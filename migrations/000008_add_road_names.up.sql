@@ -0,0 +1,3 @@
+-- Названия дорог по данным обратного геокодирования
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS road_name VARCHAR(255);
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS road_name VARCHAR(255);
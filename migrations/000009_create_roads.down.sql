@@ -0,0 +1,4 @@
+-- Удаляем агрегированные дороги
+DROP TABLE IF EXISTS road_observations;
+DROP TABLE IF EXISTS road_segments;
+DROP TABLE IF EXISTS roads;
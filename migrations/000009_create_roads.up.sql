@@ -0,0 +1,47 @@
+-- Дороги, собранные из нескольких проездов по одному коридору
+CREATE TABLE IF NOT EXISTS roads (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    segment_count INTEGER NOT NULL DEFAULT 0,
+    route_count INTEGER NOT NULL DEFAULT 0,
+    average_coverage DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Участки дороги с покрытием, агрегированным по проездам
+CREATE TABLE IF NOT EXISTS road_segments (
+    id SERIAL PRIMARY KEY,
+    road_id VARCHAR(36) NOT NULL REFERENCES roads(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    start_lat DOUBLE PRECISION NOT NULL,
+    start_lon DOUBLE PRECISION NOT NULL,
+    end_lat DOUBLE PRECISION NOT NULL,
+    end_lon DOUBLE PRECISION NOT NULL,
+    observation_count INTEGER NOT NULL DEFAULT 0,
+    latest_coverage DOUBLE PRECISION NOT NULL DEFAULT 0,
+    worst_coverage DOUBLE PRECISION NOT NULL DEFAULT 0,
+    average_coverage DOUBLE PRECISION NOT NULL DEFAULT 0,
+    last_observed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_road_segments_road_id ON road_segments(road_id);
+CREATE INDEX IF NOT EXISTS idx_road_segments_start ON road_segments(start_lat, start_lon);
+CREATE INDEX IF NOT EXISTS idx_road_segments_end ON road_segments(end_lat, end_lon);
+
+-- Покрытие участка дороги, измеренное сегментом конкретного маршрута
+CREATE TABLE IF NOT EXISTS road_observations (
+    id SERIAL PRIMARY KEY,
+    road_id VARCHAR(36) NOT NULL,
+    road_segment_id INTEGER NOT NULL REFERENCES road_segments(id) ON DELETE CASCADE,
+    route_id VARCHAR(36) NOT NULL,
+    segment_id INTEGER NOT NULL,
+    coverage_percentage DOUBLE PRECISION NOT NULL,
+    observed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_road_observations_road_id ON road_observations(road_id);
+CREATE INDEX IF NOT EXISTS idx_road_observations_road_segment_id ON road_observations(road_segment_id);
+CREATE INDEX IF NOT EXISTS idx_road_observations_route_id ON road_observations(route_id);
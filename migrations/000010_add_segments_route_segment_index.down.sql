@@ -0,0 +1,2 @@
+-- Удаляем индекс сегментов маршрута
+DROP INDEX IF EXISTS idx_segments_route_segment;
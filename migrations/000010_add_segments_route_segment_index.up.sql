@@ -0,0 +1,2 @@
+-- Индекс для постраничной выдачи сегментов маршрута и поиска сегмента по номеру
+CREATE INDEX IF NOT EXISTS idx_segments_route_segment ON segments(route_id, segment_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- Удаляем индексы сортировки маршрутов
+DROP INDEX IF EXISTS idx_routes_total_distance;
+DROP INDEX IF EXISTS idx_routes_average_coverage;
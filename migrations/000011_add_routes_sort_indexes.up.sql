@@ -0,0 +1,3 @@
+-- Индексы для сортировки и фильтрации списка маршрутов
+CREATE INDEX IF NOT EXISTS idx_routes_average_coverage ON routes(average_coverage);
+CREATE INDEX IF NOT EXISTS idx_routes_total_distance ON routes(total_distance_meters);
@@ -0,0 +1,3 @@
+-- Удаляем полнотекстовый поиск по маршрутам
+DROP INDEX IF EXISTS idx_routes_search_vector;
+ALTER TABLE routes DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,6 @@
+-- Полнотекстовый поиск по названию, описанию и названию дороги маршрута (PostgreSQL 12+)
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian',
+        coalesce(name, '') || ' ' || coalesce(description, '') || ' ' || coalesce(road_name, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_routes_search_vector ON routes USING GIN (search_vector);
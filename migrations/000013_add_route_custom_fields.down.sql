@@ -0,0 +1,2 @@
+-- Удаляем пользовательские поля маршрута
+ALTER TABLE routes DROP COLUMN IF EXISTS custom_fields;
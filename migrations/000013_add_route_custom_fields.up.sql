@@ -0,0 +1,2 @@
+-- Пользовательские поля маршрута
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS custom_fields JSONB;
@@ -0,0 +1,3 @@
+-- Удаляем метки маршрутов
+DROP TABLE IF EXISTS route_tags;
+DROP TABLE IF EXISTS tags;
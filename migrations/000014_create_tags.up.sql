@@ -0,0 +1,17 @@
+-- Метки маршрутов
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags(name);
+
+-- Связь маршрутов с метками
+CREATE TABLE IF NOT EXISTS route_tags (
+    route_id VARCHAR(36) NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (route_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_route_tags_tag_id ON route_tags(tag_id);
@@ -0,0 +1,3 @@
+-- Удаляем ключи доступа к API
+ALTER TABLE routes DROP COLUMN IF EXISTS api_key_id;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,3 @@
+-- Удаляем пользователей и владельцев маршрутов
+ALTER TABLE routes DROP COLUMN IF EXISTS owner_id;
+DROP TABLE IF EXISTS users;
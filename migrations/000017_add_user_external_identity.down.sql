@@ -0,0 +1,4 @@
+-- Удаляем привязку пользователей к OIDC провайдеру
+DROP INDEX IF EXISTS idx_users_external;
+ALTER TABLE users DROP COLUMN IF EXISTS external_subject;
+ALTER TABLE users DROP COLUMN IF EXISTS external_issuer;
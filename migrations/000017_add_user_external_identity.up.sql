@@ -0,0 +1,5 @@
+-- Пользователи внешнего OIDC провайдера определяются по издателю и sub токена
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_issuer VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_subject VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external ON users(external_issuer, external_subject);
@@ -0,0 +1,5 @@
+-- Удаляем организации и привязку к ним маршрутов и ключей
+ALTER TABLE api_keys DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE routes DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
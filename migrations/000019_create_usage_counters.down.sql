@@ -0,0 +1,2 @@
+-- Удаляем счетчики использования
+DROP TABLE IF EXISTS usage_counters;
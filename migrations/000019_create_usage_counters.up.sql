@@ -0,0 +1,9 @@
+-- Использование сервиса за месяц для проверки квот
+CREATE TABLE IF NOT EXISTS usage_counters (
+    subject VARCHAR(64) NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    uploads BIGINT NOT NULL DEFAULT 0,
+    analysis_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subject, period)
+);
@@ -0,0 +1,2 @@
+-- Удаляем журнал аудита
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,24 @@
+-- Журнал аудита изменяющих операций
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    action VARCHAR(64) NOT NULL,
+    actor_type VARCHAR(16) NOT NULL,
+    actor_id INTEGER,
+    actor_name VARCHAR(255),
+    organization_id INTEGER,
+    ip VARCHAR(64),
+    method VARCHAR(8),
+    path VARCHAR(255),
+    status INTEGER,
+    route_id VARCHAR(36),
+    target VARCHAR(64),
+    summary TEXT
+);
+
+-- Индексы для фильтров выборки журнала
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_organization_id ON audit_log(organization_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_route_id ON audit_log(route_id);
@@ -0,0 +1,3 @@
+-- Удаляем вебхуки и журнал доставок
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
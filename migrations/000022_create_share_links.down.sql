@@ -0,0 +1,2 @@
+-- Удаляем публичные ссылки на маршруты
+DROP TABLE IF EXISTS share_links;
@@ -0,0 +1,15 @@
+-- Публичные ссылки на маршруты только для чтения
+CREATE TABLE IF NOT EXISTS share_links (
+    id SERIAL PRIMARY KEY,
+    route_id VARCHAR(36) NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+    prefix VARCHAR(16) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_by_api_key_id INTEGER REFERENCES api_keys(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_share_links_token_hash ON share_links(token_hash);
+CREATE INDEX IF NOT EXISTS idx_share_links_route_id ON share_links(route_id);
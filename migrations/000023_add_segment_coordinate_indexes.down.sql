@@ -0,0 +1,3 @@
+-- Удаляем индексы координат сегментов
+DROP INDEX IF EXISTS idx_segments_end;
+DROP INDEX IF EXISTS idx_segments_start;
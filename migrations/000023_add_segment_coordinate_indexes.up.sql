@@ -0,0 +1,3 @@
+-- Индексы координат сегментов для поиска маршрутов по области
+CREATE INDEX IF NOT EXISTS idx_segments_start ON segments(start_lat, start_lon);
+CREATE INDEX IF NOT EXISTS idx_segments_end ON segments(end_lat, end_lon);
@@ -0,0 +1,2 @@
+-- Удаляем таблицу событий, ожидающих публикации
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,15 @@
+-- События, ожидающие публикации подписчикам
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id SERIAL PRIMARY KEY,
+    event_id VARCHAR(36) NOT NULL,
+    event VARCHAR(64) NOT NULL,
+    organization_id INTEGER,
+    data TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_event_id ON outbox_events(event_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events(published_at);
@@ -0,0 +1,3 @@
+-- Удаляем отметку архива маршрутов
+DROP INDEX IF EXISTS idx_routes_archived_at;
+ALTER TABLE routes DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,4 @@
+-- Время переноса маршрута в архив, архивные маршруты не попадают в списки
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_routes_archived_at ON routes(archived_at);
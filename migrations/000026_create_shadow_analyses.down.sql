@@ -0,0 +1,2 @@
+-- Удаляем таблицу теневых анализов
+DROP TABLE IF EXISTS shadow_analyses;
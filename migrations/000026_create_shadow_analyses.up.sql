@@ -0,0 +1,18 @@
+-- Результаты теневых анализов для сравнения версий анализатора
+CREATE TABLE IF NOT EXISTS shadow_analyses (
+    id SERIAL PRIMARY KEY,
+    route_id VARCHAR(36) NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+    analyzer_url VARCHAR(500) NOT NULL,
+    analyzer_version VARCHAR(64),
+    status VARCHAR(16) NOT NULL,
+    error TEXT,
+    duration_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    total_frames INTEGER NOT NULL DEFAULT 0,
+    total_segments INTEGER NOT NULL DEFAULT 0,
+    segments_with_data INTEGER NOT NULL DEFAULT 0,
+    average_coverage DOUBLE PRECISION NOT NULL DEFAULT 0,
+    segments JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_shadow_analyses_route_id ON shadow_analyses(route_id);
@@ -0,0 +1,2 @@
+-- Удаляем параметры анализа маршрута
+ALTER TABLE routes DROP COLUMN IF EXISTS analysis_params;
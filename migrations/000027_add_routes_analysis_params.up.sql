@@ -0,0 +1,2 @@
+-- Параметры анализа, переданные Python сервису
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS analysis_params JSONB;
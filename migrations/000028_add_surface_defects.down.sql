@@ -0,0 +1,6 @@
+-- Удаляем дефекты покрытия сегментов и маршрутов
+DROP INDEX IF EXISTS idx_routes_defects_count;
+ALTER TABLE routes DROP COLUMN IF EXISTS segments_with_defects;
+ALTER TABLE routes DROP COLUMN IF EXISTS defects_count;
+ALTER TABLE segments DROP COLUMN IF EXISTS defects_count;
+ALTER TABLE segments DROP COLUMN IF EXISTS defects;
@@ -0,0 +1,6 @@
+-- Дефекты покрытия (выбоины, трещины) сегментов и их число на маршруте
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS defects JSONB;
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS defects_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS defects_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS segments_with_defects INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_routes_defects_count ON routes (defects_count);
@@ -0,0 +1,3 @@
+-- Удаляем покрытие по типам разметки
+ALTER TABLE routes DROP COLUMN IF EXISTS class_coverage;
+ALTER TABLE segments DROP COLUMN IF EXISTS class_coverage;
@@ -0,0 +1,3 @@
+-- Покрытие по типам разметки (линии, переходы, стоп-линии, стрелки)
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS class_coverage JSONB;
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS class_coverage JSONB;
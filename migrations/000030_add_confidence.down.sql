@@ -0,0 +1,6 @@
+-- Удаляем уверенность модели сегментов и маршрутов
+ALTER TABLE routes DROP COLUMN IF EXISTS low_confidence_segments;
+ALTER TABLE routes DROP COLUMN IF EXISTS average_confidence;
+ALTER TABLE segments DROP COLUMN IF EXISTS low_confidence;
+ALTER TABLE segments DROP COLUMN IF EXISTS min_confidence;
+ALTER TABLE segments DROP COLUMN IF EXISTS average_confidence;
@@ -0,0 +1,6 @@
+-- Уверенность модели по сегментам и признак ненадежного результата
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS average_confidence DOUBLE PRECISION;
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS min_confidence DOUBLE PRECISION;
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS low_confidence BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS average_confidence DOUBLE PRECISION;
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS low_confidence_segments INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Удаляем индекс качества дороги
+DROP INDEX IF EXISTS idx_routes_quality_score;
+ALTER TABLE routes DROP COLUMN IF EXISTS quality_score;
+ALTER TABLE segments DROP COLUMN IF EXISTS quality_score;
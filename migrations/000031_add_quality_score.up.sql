@@ -0,0 +1,4 @@
+-- Индекс качества дороги сегментов и маршрутов
+ALTER TABLE segments ADD COLUMN IF NOT EXISTS quality_score DOUBLE PRECISION;
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS quality_score DOUBLE PRECISION;
+CREATE INDEX IF NOT EXISTS idx_routes_quality_score ON routes (quality_score);
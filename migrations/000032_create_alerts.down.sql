@@ -0,0 +1,3 @@
+-- Удаляем оповещения и их правила
+DROP TABLE IF EXISTS alerts;
+DROP TABLE IF EXISTS alert_rules;
@@ -0,0 +1,38 @@
+-- Правила оповещений о плохом покрытии и дефектах в области
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    area TEXT,
+    max_coverage DOUBLE PRECISION,
+    min_defect_severity VARCHAR(16),
+    emails TEXT,
+    telegram_chats TEXT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_organization_id ON alert_rules(organization_id);
+
+-- Оповещения о сегментах маршрутов, нарушивших правило
+CREATE TABLE IF NOT EXISTS alerts (
+    id SERIAL PRIMARY KEY,
+    rule_id INTEGER NOT NULL,
+    organization_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE,
+    route_id VARCHAR(36) NOT NULL,
+    rule_name VARCHAR(255) NOT NULL,
+    segments JSONB,
+    segments_count INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    acknowledged_by VARCHAR(255),
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_rule_id ON alerts(rule_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_organization_id ON alerts(organization_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_route_id ON alerts(route_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_status ON alerts(status);
+CREATE INDEX IF NOT EXISTS idx_alerts_created_at ON alerts(created_at);
@@ -0,0 +1,3 @@
+-- Удаляем отчеты и их расписания
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS report_schedules;
@@ -0,0 +1,2 @@
+-- Удаляем административные границы
+DROP TABLE IF EXISTS boundaries;
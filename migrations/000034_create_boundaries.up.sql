@@ -0,0 +1,17 @@
+-- Административные границы для статистики по районам
+CREATE TABLE IF NOT EXISTS boundaries (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(64) NOT NULL DEFAULT '',
+    geometry TEXT NOT NULL,
+    min_lat DOUBLE PRECISION NOT NULL,
+    min_lon DOUBLE PRECISION NOT NULL,
+    max_lat DOUBLE PRECISION NOT NULL,
+    max_lon DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_boundaries_organization_id ON boundaries(organization_id);
+CREATE INDEX IF NOT EXISTS idx_boundaries_kind ON boundaries(kind);
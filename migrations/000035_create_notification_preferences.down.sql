@@ -0,0 +1,2 @@
+-- Удаляем настройки уведомлений
+DROP TABLE IF EXISTS notification_preferences;
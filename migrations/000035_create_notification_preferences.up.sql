@@ -0,0 +1,9 @@
+-- Настройки уведомлений пользователей по почте
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    analysis_completed BOOLEAN NOT NULL DEFAULT FALSE,
+    alerts BOOLEAN NOT NULL DEFAULT FALSE,
+    reports BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Удаляем формат подписок
+ALTER TABLE webhooks DROP COLUMN IF EXISTS format;
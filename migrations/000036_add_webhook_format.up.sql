@@ -0,0 +1,2 @@
+-- Формат тела запроса подписки: события JSON или сообщения Slack
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS format VARCHAR(16) NOT NULL DEFAULT 'json';
@@ -0,0 +1,23 @@
+-- Очередь анализов между экземплярами API и воркерами (RUN_MODE)
+CREATE TABLE IF NOT EXISTS analysis_jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    route_id VARCHAR(36) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    params TEXT NOT NULL,
+    payload_path TEXT NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    worker_id VARCHAR(255),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    heartbeat_at TIMESTAMP WITH TIME ZONE,
+    result TEXT,
+    error TEXT,
+    error_reason VARCHAR(32),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_route_id ON analysis_jobs (route_id);
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_status ON analysis_jobs (status);
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_created_at ON analysis_jobs (created_at);
@@ -0,0 +1 @@
+ALTER TABLE routes DROP COLUMN IF EXISTS storage_bytes;
@@ -0,0 +1,3 @@
+-- Размер файлов маршрута для квоты хранилища. NULL — не измерен, такие
+-- маршруты измеряются после запуска сервера и командой migrate
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS storage_bytes BIGINT;
@@ -0,0 +1 @@
+ALTER TABLE routes DROP COLUMN IF EXISTS playable_video_path;
@@ -0,0 +1,3 @@
+-- Видео маршрута, которое воспроизводится в браузерах: исходное или его
+-- копия в H.264 MP4. Пусто — видео еще не проверено.
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS playable_video_path VARCHAR(500);